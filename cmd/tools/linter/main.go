@@ -1,33 +1,47 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/broadinstitute/yale/internal/tools/linter"
+	"github.com/broadinstitute/yale/internal/yale/logs"
 	"github.com/spf13/cobra"
 	"os"
 )
 
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
 func main() {
 	cmd := &cobra.Command{
 		Use:   "linter [path/to/manifests] [path/to/manifests] ...",
 		Short: "A linter for K8s resources that use Yale",
 		Long: `
-The Yale linter searches directories of K8s manifests for Deployments 
-and Statefulsets that depend on Yale-managed secrets.
+The Yale linter searches directories of K8s manifests for Deployments,
+StatefulSets, DaemonSets, CronJobs, and Jobs that depend on Yale-managed secrets.
 
 Note that the directory arguments can be globs, e.g.: "output/*"
 
-The linter will verify that each Deployment or StatefulSet has one of the
+The linter will verify that each of these workloads has one of the
 following annotations:
 
     reloader.stakater.com/auto: "true"
     reloader.stakater.com/search: "true"
     secret.reloader.stakater.com/reload: "<name-of-the-Yale-secret>"
 
-This is important because if a Deployment or StatefulSet is not restarted
+This is important because if a workload is not restarted
 after a key rotation, Yale could end up disabling a key that is still in use,
 causing an outage.
 
+Workloads that don't need to be restarted (eg. because they re-read secrets at request time)
+can opt out of linting with the annotation:
+
+    yale.terra.bio/lint-ignore: "true"
+
+Ignored resources are reported separately in the summary.
+
 Note: If running the linter on a bulk Thelma render with manifests for multiple environments
 (produced by, say, thelma render -e ALL -r ALL), be sure to pass in each environment's
 directory as a separate CLI argument. For example:
@@ -39,14 +53,27 @@ This is sometimes easier to do with globbing:
     ./linter "${THELMA_HOME}/output/*"
 
 Otherwise, the linter will confuse which resources belong to which environment.
+
+Pass --output json to print a structured report of the findings to stdout instead, eg. for
+a dashboard. Either way, the linter exits non-zero if any findings are reported.
 `,
 	}
 
 	cmd.ArgAliases = []string{"path/to/manifests"}
 
+	var output string
+	cmd.Flags().StringVarP(&output, "output", "o", outputText, "output format, one of: "+outputText+", "+outputJSON)
+
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
-		_, err := linter.Run(args...)
-		return err
+		switch output {
+		case outputText:
+			_, err := linter.Run(args...)
+			return err
+		case outputJSON:
+			return runWithJSONOutput(args)
+		default:
+			return fmt.Errorf("--output must be one of %s, %s: %s", outputText, outputJSON, output)
+		}
 	}
 
 	if err := cmd.Execute(); err != nil {
@@ -54,3 +81,27 @@ Otherwise, the linter will confuse which resources belong to which environment.
 		os.Exit(1)
 	}
 }
+
+// runWithJSONOutput runs the linter and prints a JSON report of its findings to stdout. Log messages are
+// redirected to stderr so that stdout contains only the report.
+func runWithJSONOutput(args []string) error {
+	logs.Info.SetOutput(os.Stderr)
+	logs.Warn.SetOutput(os.Stderr)
+
+	res, err := linter.Run(args...)
+	if res == nil {
+		return err
+	}
+
+	report := linter.NewReport(res)
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err = encoder.Encode(report); err != nil {
+		return fmt.Errorf("error writing JSON report: %v", err)
+	}
+
+	if report.Summary.Count > 0 {
+		return fmt.Errorf("found %d resources with missing annotations", report.Summary.Count)
+	}
+	return nil
+}
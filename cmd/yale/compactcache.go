@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"path/filepath"
+
+	"github.com/broadinstitute/yale/internal/yale"
+	"github.com/broadinstitute/yale/internal/yale/cache"
+	"github.com/broadinstitute/yale/internal/yale/client"
+	"github.com/broadinstitute/yale/internal/yale/logs"
+	"k8s.io/client-go/util/homedir"
+)
+
+const compactCacheCommand = "compact-cache"
+
+type compactCacheArgs struct {
+	local           bool
+	kubeconfig      string
+	cacheBackend    string
+	cacheNamespace  string
+	cacheGSMProject string
+	cacheKMSKey     string
+	dryRun          bool
+}
+
+// runCompactCache implements the `yale compact-cache` subcommand, which removes SyncStatus keys for
+// resources that no longer exist from every cache entry
+func runCompactCache(argv []string) {
+	args, err := parseCompactCacheArgs(argv)
+	if err != nil {
+		logs.Error.Fatal(err)
+	}
+
+	logs.Info.Printf("Building clients...")
+	clients, err := client.Build(args.local, args.kubeconfig)
+	if err != nil {
+		logs.Error.Fatalf("Error building clients: %v, exiting\n", err)
+	}
+
+	m := yale.NewYale(clients, func(options *yale.Options) {
+		options.CacheBackend = args.cacheBackend
+		options.CacheNamespace = args.cacheNamespace
+		options.CacheGSMProject = args.cacheGSMProject
+		options.CacheKMSKeyName = args.cacheKMSKey
+	})
+	if _, err = m.CompactCache(yale.CompactCacheOptions{DryRun: args.dryRun}); err != nil {
+		logs.Error.Fatal(err)
+	}
+}
+
+func parseCompactCacheArgs(argv []string) (*compactCacheArgs, error) {
+	flags := flag.NewFlagSet(compactCacheCommand, flag.ExitOnError)
+
+	var kubeconfig *string
+	if home := homedir.HomeDir(); home != "" {
+		kubeconfig = flags.String("kubeconfig", filepath.Join(home, ".kube", "config"), "(optional) absolute path to kubectl config")
+	} else {
+		kubeconfig = flags.String("kubeconfig", "", "absolute path to kubeconfig file")
+	}
+	local := flags.Bool("local", false, "use this flag when running locally (outside of cluster to use local kube config")
+	cacheNamespace := flags.String("cachenamespace", cache.DefaultCacheNamespace, "namespace where yale should cache service account keys")
+	cacheBackend := flags.String("cache-backend", cache.K8sBackend, "where yale caches service account keys (k8s or gsm); should match the main yale process")
+	cacheGSMProject := flags.String("cache-gsm-project", "", "GCP project where yale caches service account keys, with -cache-backend gsm")
+	cacheKMSKey := flags.String("cache-kms-key", "", "resource name of a GCP KMS key to encrypt cache entries with; should match the key the main yale process is configured with")
+	dryRun := flags.Bool("dry-run", false, "log how many SyncStatus keys would be removed from each cache entry without saving any changes")
+
+	if err := flags.Parse(argv); err != nil {
+		return nil, err
+	}
+	if err := validateCacheBackend(*cacheBackend, *cacheGSMProject); err != nil {
+		return nil, err
+	}
+
+	return &compactCacheArgs{
+		local:           *local,
+		kubeconfig:      *kubeconfig,
+		cacheBackend:    *cacheBackend,
+		cacheNamespace:  *cacheNamespace,
+		cacheGSMProject: *cacheGSMProject,
+		cacheKMSKey:     *cacheKMSKey,
+		dryRun:          *dryRun,
+	}, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/broadinstitute/yale/internal/yale/logs"
+)
+
+// runOnInterval calls run immediately and then once per interval until ctx is cancelled. If a run is still
+// executing when the next tick arrives, that tick is skipped. Errors from a run are logged and do not stop
+// the loop. Once ctx is cancelled, runOnInterval waits for the current run, if any, to finish and returns.
+func runOnInterval(ctx context.Context, interval time.Duration, run func() error) {
+	var wg sync.WaitGroup
+	var running atomic.Bool
+
+	tick := func() {
+		if !running.CompareAndSwap(false, true) {
+			logs.Warn.Printf("previous run is still executing, skipping this run")
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer running.Store(false)
+			if err := run(); err != nil {
+				logs.Error.Printf("error during run: %v", err)
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	tick()
+	for {
+		select {
+		case <-ctx.Done():
+			logs.Info.Printf("shutting down, waiting for the current run to finish...")
+			wg.Wait()
+			return
+		case <-ticker.C:
+			tick()
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/broadinstitute/yale/internal/yale/cache"
+	"github.com/broadinstitute/yale/internal/yale/client"
+	"github.com/broadinstitute/yale/internal/yale/keysync"
+	"github.com/broadinstitute/yale/internal/yale/logs"
+	"k8s.io/client-go/util/homedir"
+)
+
+const describeCommand = "describe"
+
+// output formats supported by `yale describe`
+const textOutput = "text"
+const jsonOutput = "json"
+
+type describeArgs struct {
+	local           bool
+	kubeconfig      string
+	cacheBackend    string
+	cacheNamespace  string
+	cacheGSMProject string
+	kms             bool
+	output          string
+	identifier      string
+}
+
+// entryDescription is a decoded, human-readable view of a cache entry. It deliberately omits the current
+// key's JSON, since that is a live credential.
+type entryDescription struct {
+	Identifier        string                  `json:"identifier"`
+	Type              string                  `json:"type"`
+	Scope             string                  `json:"scope"`
+	CurrentKey        *keyDescription         `json:"currentKey,omitempty"`
+	RotatedKeys       []keyDescription        `json:"rotatedKeys"`
+	DisabledKeys      []keyDescription        `json:"disabledKeys"`
+	SyncStatus        []syncStatusDescription `json:"syncStatus"`
+	LastError         *errorDescription       `json:"lastError,omitempty"`
+	LastSuccessfulRun *time.Time              `json:"lastSuccessfulRun,omitempty"`
+	History           []keyEventDescription   `json:"history"`
+}
+
+// keyDescription describes a key, with the time it was created, rotated, or disabled
+type keyDescription struct {
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Age       string    `json:"age"`
+}
+
+// syncStatusDescription describes the last successful sync of a single GcpSaKey or AzureClientSecret
+type syncStatusDescription struct {
+	Resource     string `json:"resource"`
+	SpecChecksum string `json:"specChecksum"`
+	KeyID        string `json:"keyID"`
+	// CurrentKey true if the synced key is the entry's current key
+	CurrentKey bool `json:"currentKey"`
+	// Partial true if the last sync only succeeded for some of the resource's destinations; the others are
+	// retried on the next run
+	Partial bool `json:"partial,omitempty"`
+	// SyncedDestinations for partial syncs, the destinations that were synced (eg. k8s, vault)
+	SyncedDestinations []string `json:"syncedDestinations,omitempty"`
+}
+
+// keyEventDescription describes a single change in a key's lifecycle
+type keyEventDescription struct {
+	KeyID     string    `json:"keyID"`
+	Event     string    `json:"event"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type errorDescription struct {
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// runDescribe implements the `yale describe` subcommand, which prints a decoded summary of the cache entry
+// for an identifier, for debugging why Yale did or didn't rotate a key
+func runDescribe(argv []string) {
+	args, err := parseDescribeArgs(argv)
+	if err != nil {
+		logs.Error.Fatal(err)
+	}
+
+	// only the client for the cache backend is needed to read the cache, so don't require other credentials
+	var cacheStore cache.Store
+	if args.cacheBackend == cache.GSMBackend {
+		secretManager, err := client.BuildSecretManager()
+		if err != nil {
+			logs.Error.Fatalf("Error building clients: %v, exiting\n", err)
+		}
+		cacheStore = cache.NewGSMStore(secretManager, args.cacheGSMProject)
+	} else {
+		k8s, err := client.BuildK8s(args.local, args.kubeconfig)
+		if err != nil {
+			logs.Error.Fatalf("Error building clients: %v, exiting\n", err)
+		}
+		cacheStore = cache.NewK8sStore(k8s, args.cacheNamespace)
+	}
+	var options []cache.Option
+	if args.kms {
+		kms, err := client.BuildKMS()
+		if err != nil {
+			logs.Error.Fatalf("Error building clients: %v, exiting\n", err)
+		}
+		options = append(options, func(options *cache.Options) {
+			options.KMS = cache.NewKMS(kms)
+		})
+	}
+
+	if err = describe(cache.NewWithStore(cacheStore, options...), args, os.Stdout, time.Now()); err != nil {
+		logs.Error.Fatal(err)
+	}
+}
+
+// describe looks up the cache entry for the identifier and writes a summary of it in the requested format
+func describe(yaleCache cache.Cache, args *describeArgs, stdout io.Writer, now time.Time) error {
+	entry, err := findCacheEntry(yaleCache, args.identifier)
+	if err != nil {
+		return err
+	}
+	description := describeEntry(entry, now)
+
+	if args.output == jsonOutput {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(description)
+	}
+	return writeDescription(stdout, description)
+}
+
+// describeEntry decodes a cache entry into an entryDescription, computing key ages relative to now
+func describeEntry(entry *cache.Entry, now time.Time) entryDescription {
+	description := entryDescription{
+		Identifier:   entry.Identify(),
+		Type:         entry.Type.String(),
+		Scope:        entry.Scope(),
+		RotatedKeys:  describeKeys(entry.RotatedKeys, now),
+		DisabledKeys: describeKeys(entry.DisabledKeys, now),
+		SyncStatus:   []syncStatusDescription{},
+		History:      []keyEventDescription{},
+	}
+	if entry.CurrentKey.ID != "" {
+		description.CurrentKey = &keyDescription{
+			ID:        entry.CurrentKey.ID,
+			Timestamp: entry.CurrentKey.CreatedAt,
+			Age:       formatAge(now.Sub(entry.CurrentKey.CreatedAt)),
+		}
+	}
+
+	for resource, status := range entry.SyncStatus {
+		// status values are in the form "<checksum>:<key id>", followed by the synced destinations for partial syncs
+		hash, synced, partial := keysync.DecodeSyncStatus(status)
+		checksum, keyID, _ := strings.Cut(hash, ":")
+		description.SyncStatus = append(description.SyncStatus, syncStatusDescription{
+			Resource:           resource,
+			SpecChecksum:       checksum,
+			KeyID:              keyID,
+			CurrentKey:         keyID != "" && keyID == entry.CurrentKey.ID,
+			Partial:            partial,
+			SyncedDestinations: synced,
+		})
+	}
+	sort.Slice(description.SyncStatus, func(i, j int) bool {
+		return description.SyncStatus[i].Resource < description.SyncStatus[j].Resource
+	})
+
+	if entry.LastError.Message != "" {
+		description.LastError = &errorDescription{
+			Message:   entry.LastError.Message,
+			Timestamp: entry.LastError.Timestamp,
+		}
+	}
+	if !entry.LastSuccessfulRun.IsZero() {
+		lastSuccessfulRun := entry.LastSuccessfulRun
+		description.LastSuccessfulRun = &lastSuccessfulRun
+	}
+	for _, event := range entry.History {
+		description.History = append(description.History, keyEventDescription{
+			KeyID:     event.KeyID,
+			Event:     string(event.Type),
+			Timestamp: event.Timestamp,
+		})
+	}
+	return description
+}
+
+// describeKeys returns descriptions of the keys in a key id -> timestamp map, oldest first
+func describeKeys(keys map[string]time.Time, now time.Time) []keyDescription {
+	result := []keyDescription{}
+	for id, timestamp := range keys {
+		result = append(result, keyDescription{
+			ID:        id,
+			Timestamp: timestamp,
+			Age:       formatAge(now.Sub(timestamp)),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Timestamp.Equal(result[j].Timestamp) {
+			return result[i].ID < result[j].ID
+		}
+		return result[i].Timestamp.Before(result[j].Timestamp)
+	})
+	return result
+}
+
+// writeDescription writes a human-readable summary of a cache entry
+func writeDescription(w io.Writer, d entryDescription) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Identifier:\t%s\n", d.Identifier)
+	fmt.Fprintf(tw, "Type:\t%s\n", d.Type)
+	fmt.Fprintf(tw, "Scope:\t%s\n", d.Scope)
+	if d.CurrentKey == nil {
+		fmt.Fprintf(tw, "Current key:\t<none>\n")
+	} else {
+		fmt.Fprintf(tw, "Current key:\t%s (created %s, %s ago)\n", d.CurrentKey.ID, d.CurrentKey.Timestamp.Format(time.RFC3339), d.CurrentKey.Age)
+	}
+	if d.LastSuccessfulRun == nil {
+		fmt.Fprintf(tw, "Last successful run:\t<never>\n")
+	} else {
+		fmt.Fprintf(tw, "Last successful run:\t%s\n", d.LastSuccessfulRun.Format(time.RFC3339))
+	}
+	if d.LastError == nil {
+		fmt.Fprintf(tw, "Last error:\t<none>\n")
+	} else {
+		fmt.Fprintf(tw, "Last error:\t%s (at %s)\n", d.LastError.Message, d.LastError.Timestamp.Format(time.RFC3339))
+	}
+
+	writeKeys(tw, "Rotated keys", "rotated", d.RotatedKeys)
+	writeKeys(tw, "Disabled keys", "disabled", d.DisabledKeys)
+
+	fmt.Fprintf(tw, "Sync status:\t%s\n", countOrNone(len(d.SyncStatus)))
+	for _, status := range d.SyncStatus {
+		current := "current key"
+		if !status.CurrentKey {
+			current = "not current key"
+		}
+		fmt.Fprintf(tw, "  %s\tkey %s (%s), spec checksum %s", status.Resource, status.KeyID, current, status.SpecChecksum)
+		if status.Partial {
+			// the cache doesn't record a resource's destinations, only which ones were synced
+			synced := "no destinations"
+			if len(status.SyncedDestinations) > 0 {
+				synced = strings.Join(status.SyncedDestinations, ", ")
+			}
+			fmt.Fprintf(tw, ", partially synced to %s; other destinations pending", synced)
+		}
+		fmt.Fprintln(tw)
+	}
+
+	fmt.Fprintf(tw, "History:\t%s\n", countOrNone(len(d.History)))
+	for _, event := range d.History {
+		fmt.Fprintf(tw, "  %s\t%s %s\n", event.KeyID, event.Event, event.Timestamp.Format(time.RFC3339))
+	}
+
+	return tw.Flush()
+}
+
+// writeKeys writes a section listing the given keys
+func writeKeys(w io.Writer, heading string, verb string, keys []keyDescription) {
+	fmt.Fprintf(w, "%s:\t%s\n", heading, countOrNone(len(keys)))
+	for _, key := range keys {
+		fmt.Fprintf(w, "  %s\t%s %s (%s ago)\n", key.ID, verb, key.Timestamp.Format(time.RFC3339), key.Age)
+	}
+}
+
+func countOrNone(count int) string {
+	if count == 0 {
+		return "<none>"
+	}
+	return fmt.Sprintf("%d", count)
+}
+
+// formatAge formats a duration as days and hours (eg. "3d4h"), or hours and minutes if under a day
+func formatAge(d time.Duration) string {
+	if d < time.Minute {
+		return "0m"
+	}
+	if d < 24*time.Hour {
+		return strings.TrimSuffix(d.Truncate(time.Minute).String(), "0s")
+	}
+	days := d / (24 * time.Hour)
+	hours := (d % (24 * time.Hour)) / time.Hour
+	return fmt.Sprintf("%dd%dh", days, hours)
+}
+
+func parseDescribeArgs(argv []string) (*describeArgs, error) {
+	flags := flag.NewFlagSet(describeCommand, flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage of %s: yale %s [flags] <identifier>\n", describeCommand, describeCommand)
+		flags.PrintDefaults()
+	}
+
+	var kubeconfig *string
+	if home := homedir.HomeDir(); home != "" {
+		kubeconfig = flags.String("kubeconfig", filepath.Join(home, ".kube", "config"), "(optional) absolute path to kubectl config")
+	} else {
+		kubeconfig = flags.String("kubeconfig", "", "absolute path to kubeconfig file")
+	}
+	local := flags.Bool("local", false, "use this flag when running locally (outside of cluster to use local kube config")
+	cacheNamespace := flags.String("cachenamespace", cache.DefaultCacheNamespace, "namespace where yale caches service account keys")
+	cacheBackend := flags.String("cache-backend", cache.K8sBackend, "where yale caches service account keys (k8s or gsm)")
+	cacheGSMProject := flags.String("cache-gsm-project", "", "GCP project where yale caches service account keys, with -cache-backend gsm")
+	kms := flags.Bool("kms", false, "build a GCP KMS client to read cache entries encrypted with -cache-kms-key (requires GCP credentials)")
+	output := flags.String("output", textOutput, "output format (text or json)")
+
+	if err := flags.Parse(argv); err != nil {
+		return nil, err
+	}
+	if flags.NArg() < 1 {
+		return nil, fmt.Errorf("usage: yale %s [flags] <identifier>", describeCommand)
+	}
+	identifier := flags.Arg(0)
+	// allow flags after the identifier, eg. `yale describe sa@p.com -output json`
+	if err := flags.Parse(flags.Args()[1:]); err != nil {
+		return nil, err
+	}
+	if flags.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments after identifier %s: %s", identifier, strings.Join(flags.Args(), " "))
+	}
+	if err := validateCacheBackend(*cacheBackend, *cacheGSMProject); err != nil {
+		return nil, err
+	}
+	if *output != textOutput && *output != jsonOutput {
+		return nil, fmt.Errorf("-output must be one of %s or %s: %s", textOutput, jsonOutput, *output)
+	}
+
+	return &describeArgs{
+		local:           *local,
+		kubeconfig:      *kubeconfig,
+		cacheBackend:    *cacheBackend,
+		cacheNamespace:  *cacheNamespace,
+		cacheGSMProject: *cacheGSMProject,
+		kms:             *kms,
+		output:          *output,
+		identifier:      identifier,
+	}, nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/broadinstitute/yale/internal/yale/cache"
+	"github.com/broadinstitute/yale/internal/yale/client"
+	apiv1b1 "github.com/broadinstitute/yale/internal/yale/crd/api/v1beta1"
+	"github.com/broadinstitute/yale/internal/yale/keysync"
+	"github.com/broadinstitute/yale/internal/yale/logs"
+	"k8s.io/client-go/util/homedir"
+)
+
+const fetchCommand = "fetch"
+
+// stdoutOutput is the -output value that writes the key to stdout instead of a file
+const stdoutOutput = "-"
+
+type fetchArgs struct {
+	local           bool
+	kubeconfig      string
+	cacheBackend    string
+	cacheNamespace  string
+	cacheGSMProject string
+	identifier      string
+	output          string
+	format          apiv1b1.ReplicationFormat
+}
+
+// runFetch implements the `yale fetch` subcommand, which reads the current key for an identifier
+// from the cache and writes it to a local file or stdout, for local development
+func runFetch(argv []string) {
+	args, err := parseFetchArgs(argv)
+	if err != nil {
+		logs.Error.Fatal(err)
+	}
+
+	logs.Info.Printf("Building clients...")
+	clients, err := client.Build(args.local, args.kubeconfig)
+	if err != nil {
+		logs.Error.Fatalf("Error building clients: %v, exiting\n", err)
+	}
+
+	// fetch never writes to the cache, so it only needs a KMS client to read encrypted entries, not a key
+	cacheStore := cache.NewK8sStore(clients.GetK8s(), args.cacheNamespace)
+	if args.cacheBackend == cache.GSMBackend {
+		cacheStore = cache.NewGSMStore(clients.GetGoogleSecretManager(), args.cacheGSMProject)
+	}
+	yaleCache := cache.NewWithStore(cacheStore, func(options *cache.Options) {
+		options.KMS = cache.NewKMS(clients.GetKMS())
+	})
+	if err = fetchKey(yaleCache, args, os.Stdout); err != nil {
+		logs.Error.Fatal(err)
+	}
+}
+
+// fetchKey looks up the cache entry for the identifier and writes its current key to the
+// output file, or to stdout if the output is "-"
+func fetchKey(yaleCache cache.Cache, args *fetchArgs, stdout io.Writer) error {
+	entry, err := findCacheEntry(yaleCache, args.identifier)
+	if err != nil {
+		return err
+	}
+	if entry.CurrentKey.ID == "" {
+		return fmt.Errorf("cache entry for %s has no current key", args.identifier)
+	}
+
+	formatted, err := keysync.FormatCurrentKey(entry, args.format)
+	if err != nil {
+		return fmt.Errorf("error formatting current key %s for %s: %v", entry.CurrentKey.ID, args.identifier, err)
+	}
+
+	if args.output == stdoutOutput {
+		_, err = stdout.Write(formatted)
+		return err
+	}
+
+	// the key is a live credential, so make sure it is only readable by the current user
+	if err = os.WriteFile(args.output, formatted, 0600); err != nil {
+		return fmt.Errorf("error writing key to %s: %v", args.output, err)
+	}
+	logs.Info.Printf("wrote current key %s for %s to %s (format: %s)", entry.CurrentKey.ID, args.identifier, args.output, args.format)
+	return nil
+}
+
+// findCacheEntry returns the cache entry for the identifier, without creating one if it doesn't exist
+func findCacheEntry(yaleCache cache.Cache, identifier string) (*cache.Entry, error) {
+	entries, err := yaleCache.List()
+	if err != nil {
+		return nil, fmt.Errorf("error listing cache entries: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.Identify() == identifier {
+			return entry, nil
+		}
+	}
+	return nil, fmt.Errorf("no cache entry found for %s", identifier)
+}
+
+func parseFetchArgs(argv []string) (*fetchArgs, error) {
+	flags := flag.NewFlagSet(fetchCommand, flag.ExitOnError)
+
+	var kubeconfig *string
+	if home := homedir.HomeDir(); home != "" {
+		kubeconfig = flags.String("kubeconfig", filepath.Join(home, ".kube", "config"), "(optional) absolute path to kubectl config")
+	} else {
+		kubeconfig = flags.String("kubeconfig", "", "absolute path to kubeconfig file")
+	}
+	local := flags.Bool("local", false, "use this flag when running locally (outside of cluster to use local kube config")
+	cacheNamespace := flags.String("cachenamespace", cache.DefaultCacheNamespace, "namespace where yale caches service account keys")
+	cacheBackend := flags.String("cache-backend", cache.K8sBackend, "where yale caches service account keys (k8s or gsm)")
+	cacheGSMProject := flags.String("cache-gsm-project", "", "GCP project where yale caches service account keys, with -cache-backend gsm")
+	identifier := flags.String("identifier", "", "service account email or Azure application id to fetch the current key for")
+	output := flags.String("output", stdoutOutput, "file to write the key to, or - for stdout")
+	format := flags.String("format", apiv1b1.JSON.String(), "format to write the key in (json, pem, base64, plaintext, or yaml)")
+	confirm := flags.Bool("confirm", false, "required; acknowledges that a live credential will be written outside the cluster")
+
+	if err := flags.Parse(argv); err != nil {
+		return nil, err
+	}
+	if err := validateCacheBackend(*cacheBackend, *cacheGSMProject); err != nil {
+		return nil, err
+	}
+	if *identifier == "" {
+		return nil, fmt.Errorf("-identifier is required")
+	}
+	var replicationFormat apiv1b1.ReplicationFormat
+	if err := replicationFormat.UnmarshalText([]byte(*format)); err != nil || replicationFormat == apiv1b1.Map || replicationFormat == apiv1b1.Dotenv {
+		return nil, fmt.Errorf("-format must be one of json, pem, base64, plaintext, or yaml: %s", *format)
+	}
+	if !*confirm {
+		return nil, fmt.Errorf("refusing to fetch a live credential without -confirm")
+	}
+
+	return &fetchArgs{
+		local:           *local,
+		kubeconfig:      *kubeconfig,
+		cacheBackend:    *cacheBackend,
+		cacheNamespace:  *cacheNamespace,
+		cacheGSMProject: *cacheGSMProject,
+		identifier:      *identifier,
+		output:          *output,
+		format:          replicationFormat,
+	}, nil
+}
@@ -1,60 +1,281 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"github.com/broadinstitute/yale/internal/yale"
+	"github.com/broadinstitute/yale/internal/yale/audit"
 	"github.com/broadinstitute/yale/internal/yale/cache"
 	"github.com/broadinstitute/yale/internal/yale/client"
+	"github.com/broadinstitute/yale/internal/yale/health"
+	"github.com/broadinstitute/yale/internal/yale/keyops"
+	"github.com/broadinstitute/yale/internal/yale/keysync"
 	"github.com/broadinstitute/yale/internal/yale/logs"
+	"github.com/broadinstitute/yale/internal/yale/metrics"
 	"github.com/broadinstitute/yale/internal/yale/slack"
+	"github.com/broadinstitute/yale/internal/yale/teams"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/util/homedir"
+	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
+	// embed the timezone database, since the runtime image does not include one
+	_ "time/tzdata"
 )
 
 type args struct {
 	// use local kube config
-	local                    bool
-	kubeconfig               string
-	cacheNamespace           string
-	ignoreUsageMetrics       bool
-	windowStart              string
-	windowEnd                string
-	disableVaultReplication  bool
-	disableGitHubReplication bool
+	local                     bool
+	kubeconfig                string
+	cacheNamespace            string
+	ignoreUsageMetrics        bool
+	windowStart               string
+	windowEnd                 string
+	disableVaultReplication   bool
+	disableGitHubReplication  bool
+	disableGitLabReplication  bool
+	disableGCSReplication     bool
+	disableConsulReplication  bool
+	verifyVaultReplications   bool
+	forceResync               bool
+	slackWebhookInfo          string
+	slackWebhookAlerts        string
+	maxTrackedKeys            int
+	syncDelayAfterIssue       time.Duration
+	additionalKubeconfigs     kubeconfigsFlag
+	maxConcurrentReplications int
+	maxDestinationFailures    int
+	dryRun                    bool
+	pruneRemovedReplications  bool
+	adoptUnownedSecrets       bool
+	metricsAddr               string
+	interval                  time.Duration
+	once                      bool
+	allowSubDayThresholds     bool
+	windowTimezone            string
+	windows                   windowsFlag
+	teamsWebhookUrl           string
+	messageTemplates          messageTemplatesFlag
+	errorDigest               bool
+	rotationWarningLead       time.Duration
+	cacheKMSKey               string
+	keyCreateMaxAttempts      int
+	keyCreateRetryDelay       time.Duration
+	disableWindows            windowsFlag
+	includeNamespaces         namespacesFlag
+	excludeNamespaces         namespacesFlag
+	crdLabelSelector          string
+	errorRepostInterval       time.Duration
+	keyInUseRepostInterval    time.Duration
+	eventWebhookUrl           string
+	slackWebhookUrl           string
+	cacheBackend              string
+	cacheGSMProject           string
+	deleteOrphanedSecrets     bool
+	healthAddr                string
+	readinessStaleAfter       time.Duration
+	maxRotatedKeys            int
+	allowedVaultAddresses     vaultAddressesFlag
+	maxConcurrentIdentifiers  int
+	recoverCacheFromSecrets   bool
+	minRotationInterval       time.Duration
+	githubApp                 *client.GitHubAppOptions
+	checkDedicatedCache       bool
+	auditLog                  string
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case selfTestCommand:
+			runSelfTest(os.Args[2:])
+			return
+		case rotateAllCommand:
+			runRotateAll(os.Args[2:])
+			return
+		case rotateNowCommand:
+			runRotateNow(os.Args[2:])
+			return
+		case fetchCommand:
+			runFetch(os.Args[2:])
+			return
+		case compactCacheCommand:
+			runCompactCache(os.Args[2:])
+			return
+		case describeCommand:
+			runDescribe(os.Args[2:])
+			return
+		case validateCommand:
+			runValidate(os.Args[2:])
+			return
+		}
+	}
+
 	args := parseArgs()
 
+	if args.metricsAddr != "" {
+		metrics.Serve(args.metricsAddr)
+	}
+
+	checker := health.New(readinessStaleAfter(args))
+	if args.healthAddr != "" {
+		health.Serve(args.healthAddr, checker)
+	}
+
 	logs.Info.Printf("Building clients...")
-	clients, err := client.Build(args.local, args.kubeconfig)
+	clients, err := client.Build(args.local, args.kubeconfig, func(options *client.Options) {
+		options.GitHubApp = args.githubApp
+	})
 
 	if err != nil {
 		logs.Error.Fatalf("Error building clients: %v, exiting\n", err)
 	}
 
-	window, err := parseRotateWindow(args, time.Now())
+	if args.cacheBackend == cache.K8sBackend {
+		if err = cache.ValidateNamespace(clients.GetK8s(), args.cacheNamespace, args.checkDedicatedCache); err != nil {
+			logs.Error.Fatalf("-cachenamespace: %v", err)
+		}
+	}
+
+	// validate the rotation and disable windows up front; they are parsed again for every run, since their
+	// boundaries are times of day relative to when the run starts
+	if _, err = parseRotateWindow(args, time.Now()); err != nil {
+		logs.Error.Fatal(err)
+	}
+	if _, err = parseDisableWindow(args, time.Now()); err != nil {
+		logs.Error.Fatal(err)
+	}
+
+	targetClusters, err := client.BuildTargetClusters(args.additionalKubeconfigs)
+	if err != nil {
+		logs.Error.Fatalf("Error building clients for additional clusters: %v, exiting\n", err)
+	}
+	checker.ClientsInitialized()
+
+	auditLog, err := openAuditLog(args.auditLog)
 	if err != nil {
 		logs.Error.Fatal(err)
 	}
 
-	m := yale.NewYale(clients, func(options *yale.Options) {
+	run := func() error {
+		window, err := parseRotateWindow(args, time.Now())
+		if err != nil {
+			return err
+		}
+		disableWindow, err := parseDisableWindow(args, time.Now())
+		if err != nil {
+			return err
+		}
+		_yale := newYale(clients, args, window, disableWindow, targetClusters, auditLog)
+		// a forced resync only applies to the first run; later runs in daemon mode go back to syncing incrementally
+		args.forceResync = false
+		if err = _yale.Run(); err != nil {
+			return err
+		}
+		checker.RecordSuccessfulRun(time.Now())
+		return nil
+	}
+
+	if args.once || args.interval == 0 {
+		if err = run(); err != nil {
+			logs.Error.Fatal(err)
+		}
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logs.Info.Printf("running every %s until interrupted", args.interval)
+	runOnInterval(ctx, args.interval, run)
+}
+
+// newYale builds a Yale instance configured with the given runtime flags
+const auditLogUsage = "path of a file to append a JSON line to for every key Yale issues, disables, deletes, or syncs, or - for stdout"
+
+// openAuditLog opens the audit log at the given -audit-log path, or returns nil if the path is empty
+func openAuditLog(path string) (audit.Logger, error) {
+	if path == "" {
+		return nil, nil
+	}
+	auditLog, err := audit.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("-audit-log: %v", err)
+	}
+	return auditLog, nil
+}
+
+// flushAuditLog writes any buffered records to an audit log returned by openAuditLog
+func flushAuditLog(auditLog audit.Logger) error {
+	if auditLog == nil {
+		return nil
+	}
+	if err := auditLog.Flush(); err != nil {
+		return fmt.Errorf("error writing audit log: %v", err)
+	}
+	return nil
+}
+
+func newYale(clients *client.Clients, args *args, window *yale.RotateWindow, disableWindow *yale.RotateWindow, targetClusters map[string]kubernetes.Interface, auditLog audit.Logger) *yale.Yale {
+	return yale.NewYale(clients, func(options *yale.Options) {
+		options.CacheBackend = args.cacheBackend
 		options.CacheNamespace = args.cacheNamespace
+		options.CacheGSMProject = args.cacheGSMProject
+		options.CacheKMSKeyName = args.cacheKMSKey
 		options.IgnoreUsageMetrics = args.ignoreUsageMetrics
-		options.SlackWebhookUrl = os.Getenv(slack.WebhookEnvVar)
+		options.SlackWebhookUrl = args.slackWebhookUrl
+		options.SlackInfoWebhookUrl = args.slackWebhookInfo
+		options.SlackAlertsWebhookUrl = args.slackWebhookAlerts
+		options.MessageTemplates = args.messageTemplates
+		options.ErrorDigest = args.errorDigest
+		options.RotationWarningLead = args.rotationWarningLead
+		options.TeamsWebhookUrl = args.teamsWebhookUrl
+		if options.TeamsWebhookUrl == "" {
+			options.TeamsWebhookUrl = os.Getenv(teams.WebhookEnvVar)
+		}
 		options.RotateWindow = *window
+		options.DisableWindow = *disableWindow
 		options.DisableVaultReplication = args.disableVaultReplication
 		options.DisableGitHubReplication = args.disableGitHubReplication
+		options.DisableGitLabReplication = args.disableGitLabReplication
+		options.DisableGCSReplication = args.disableGCSReplication
+		options.DisableConsulReplication = args.disableConsulReplication
+		options.VerifyVaultReplications = args.verifyVaultReplications
+		options.ForceResync = args.forceResync
+		options.MaxTrackedKeys = args.maxTrackedKeys
+		options.MinRotationInterval = args.minRotationInterval
+		options.MaxRotatedKeys = args.maxRotatedKeys
+		options.AllowedVaultAddresses = args.allowedVaultAddresses
+		options.SyncDelayAfterIssue = args.syncDelayAfterIssue
+		options.TargetClusters = targetClusters
+		options.MaxConcurrentReplications = args.maxConcurrentReplications
+		options.MaxConcurrentIdentifiers = args.maxConcurrentIdentifiers
+		options.MaxDestinationFailures = args.maxDestinationFailures
+		options.DryRun = args.dryRun
+		options.PruneRemovedReplications = args.pruneRemovedReplications
+		options.DeleteOrphanedSecrets = args.deleteOrphanedSecrets
+		options.RecoverCacheFromSecrets = args.recoverCacheFromSecrets
+		options.AdoptUnownedSecrets = args.adoptUnownedSecrets
+		options.AllowSubDayThresholds = args.allowSubDayThresholds
+		options.KeyCreateMaxAttempts = args.keyCreateMaxAttempts
+		options.KeyCreateRetryBaseDelay = args.keyCreateRetryDelay
+		options.IncludeNamespaces = args.includeNamespaces
+		options.ExcludeNamespaces = args.excludeNamespaces
+		options.CRDLabelSelector = args.crdLabelSelector
+		options.ErrorRepostInterval = args.errorRepostInterval
+		options.KeyInUseErrorRepostInterval = args.keyInUseRepostInterval
+		options.EventWebhookUrl = args.eventWebhookUrl
+		options.AuditLog = auditLog
 	})
-	if err = m.Run(); err != nil {
-		logs.Error.Fatal(err)
-	}
 }
 
 func parseArgs() *args {
@@ -66,13 +287,121 @@ func parseArgs() *args {
 	}
 	local := flag.Bool("local", false, "use this flag when running locally (outside of cluster to use local kube config")
 	cacheNamespace := flag.String("cachenamespace", cache.DefaultCacheNamespace, "namespace where yale should cache service account keys")
+	checkDedicatedCache := flag.Bool("check-dedicated-cache-namespace", false, "at startup, warn if -cachenamespace contains secrets other than Yale cache entries, whose names could collide with them")
+	cacheBackend := flag.String("cache-backend", cache.K8sBackend, "where yale should cache service account keys: k8s (Secrets in -cachenamespace) or gsm (Google Secret Manager secrets in -cache-gsm-project)")
+	cacheGSMProject := flag.String("cache-gsm-project", "", "GCP project where yale should cache service account keys, with -cache-backend gsm")
+	cacheKMSKey := flag.String("cache-kms-key", "", "resource name of a GCP KMS key (projects/P/locations/L/keyRings/R/cryptoKeys/K) to encrypt cache entries with; entries are written in plaintext if not set")
 	ignoreUsageMetrics := flag.Bool("ignoreusagemetrics", false, "do not check if service account key is in use before disabling")
-	windowStart := flag.String("window-start", "", "use to restrict rotation to a particular time of day (HH:MM). eg. 05:00")
+	windowStart := flag.String("window-start", "", "use to restrict rotation to a particular time of day (HH:MM). eg. 05:00; may be after -window-end for a window that spans midnight")
 	windowEnd := flag.String("window-end", "", "use to restrict rotation to a particular time of day (HH:MM). eg. 06:00")
+	var windows windowsFlag
+	flag.Var(&windows, "window", "HH:MM-HH:MM time of day that rotation is restricted to (eg. 05:00-06:00); may be repeated to permit rotation in any of several windows. Also restricts disabling and deleting old keys, unless -disable-window is given")
+	var disableWindows windowsFlag
+	flag.Var(&disableWindows, "disable-window", "HH:MM-HH:MM time of day that disabling and deleting old keys is restricted to (eg. 05:00-06:00); may be repeated. Evaluated independently of -window, so old keys can be disabled outside the rotation window")
+	windowTimezone := flag.String("window-timezone", "", "IANA timezone (eg. America/New_York) that rotation and disable windows are expressed in; defaults to the local timezone")
 	disableVaultReplication := flag.Bool("disable-vault-replication", false, "use to globally disable Vault replication")
 	disableGitHubReplication := flag.Bool("disable-github-replication", false, "use to globally disable GitHub replication")
+	disableGitLabReplication := flag.Bool("disable-gitlab-replication", false, "use to globally disable GitLab replication")
+	disableGCSReplication := flag.Bool("disable-gcs-replication", false, "use to globally disable GCS replication")
+	disableConsulReplication := flag.Bool("disable-consul-replication", false, "use to globally disable Consul replication")
+	verifyVaultReplications := flag.Bool("verify-vault-replications", false, "read back Vault replications on every run and re-sync any paths that have drifted from the current key")
+	forceResync := flag.Bool("force-resync", false, "sync every resource to all of its destinations, even if its sync status is up-to-date; in daemon mode, only applies to the first run")
+	slackWebhookFile := flag.String("slack-webhook-file", "", "path to a file (eg. a mounted K8s secret) containing the Slack webhook for notifications; takes precedence over $"+slack.WebhookEnvVar)
+	slackWebhookInfo := flag.String("slack-webhook-info", "", "Slack webhook for informational notifications (keys issued, disabled, deleted); defaults to -slack-webhook-file or $"+slack.WebhookEnvVar)
+	slackWebhookAlerts := flag.String("slack-webhook-alerts", "", "Slack webhook for error notifications; defaults to -slack-webhook-file or $"+slack.WebhookEnvVar)
+	messageTemplates := make(messageTemplatesFlag)
+	flag.Var(messageTemplates, "message-template", "EVENT=TEMPLATE Go text/template for the Slack message sent for an event (keyIssued, keyDisabled, keyDeleted, keyApproachingRotation, or error), eg. 'keyIssued=issued {{ .KeyID }} for {{ .Identifier }}' (may be repeated)")
+	errorDigest := flag.Bool("error-digest", false, "report all the errors from a run in a single notification at the end of the run, instead of one notification per failing resource")
+	rotationWarningLead := flag.Duration("rotation-warning-lead", 0, "send a notification when a key is within this long (eg. 24h) of being rotated, once per key; disabled if not set")
+	teamsWebhookUrl := flag.String("teams-webhook-url", "", "Microsoft Teams incoming webhook for notifications, sent in addition to any Slack notifications; defaults to $"+teams.WebhookEnvVar)
+	minRotationInterval := flag.Duration("min-rotation-interval", yale.DefaultMinRotationInterval, "never rotate a key that was issued less than this long ago, even if it is due for rotation; capped at each resource's rotation age (0 to disable)")
+	maxTrackedKeys := flag.Int("max-tracked-keys", yale.DefaultMaxTrackedKeys, "refuse to rotate a key while this many rotated and disabled keys are still pending cleanup (0 for no limit)")
+	syncDelayAfterIssue := flag.Duration("sync-delay-after-issue", 0, "wait until a newly-issued key is at least this old (eg. 30s) before syncing it to destinations; it will be synced on a subsequent run")
+	additionalKubeconfigs := make(kubeconfigsFlag)
+	dryRun := flag.Bool("dry-run", false, "log the writes key sync would perform to K8s, Vault, GSM, and GitHub without performing them (does not affect key rotation)")
+	flag.Var(additionalKubeconfigs, "additional-kubeconfig", "NAME=PATH kubeconfig for an additional cluster that secrets can be synced to with secret.targetClusters (may be repeated)")
+	pruneRemovedReplications := flag.Bool("prune-removed-replications", false, "delete Vault paths, GSM secrets, and GitHub secrets that were removed from a resource's replications since its last sync")
+	deleteOrphanedSecrets := flag.Bool("delete-orphaned-secrets", false, "delete the K8s secrets previously synced for a service account or application once no resources use it, in case K8s did not garbage-collect them")
+	adoptUnownedSecrets := flag.Bool("adopt-unowned-gsm-secrets", false, "add versions to pre-existing GSM secrets that don't have the owned_by: yale label, instead of returning an error")
+	interval := flag.Duration("interval", 0, "run Yale in a loop, once per interval (eg. 60s), until interrupted; runs once if not set")
+	once := flag.Bool("once", false, "run Yale once and exit (the default); cannot be combined with -interval")
+	maxRotatedKeys := flag.Int("max-rotated-keys", yale.DefaultMaxRotatedKeys, "send a single alert when a key has more than this many rotated keys waiting to be disabled (0 to disable)")
+	var allowedVaultAddresses vaultAddressesFlag
+	flag.Var(&allowedVaultAddresses, "allowed-vault-addresses", "comma-separated list of Vault server addresses (eg. https://vault.enclave.example.com), besides the default one, that Vault replications may write to with their address field (may be repeated)")
+	recoverCacheFromSecrets := flag.Bool("recover-cache-from-secrets", false, "if a service account or application has an empty cache entry (eg. because its cache secret was deleted), restore its current key from the K8s secrets Yale synced it to instead of issuing a new key")
+	maxConcurrentIdentifiers := flag.Int("max-concurrent-identifiers", yale.DefaultMaxConcurrentIdentifiers, "maximum number of service accounts or Azure applications, along with their resources, to process at once during a run")
+	healthAddr := flag.String("health-addr", "", "address (eg. :8080) to serve liveness and readiness probes on at /healthz and /readyz; disabled if empty")
+	readinessStaleAfter := flag.Duration("readiness-stale-after", 0, "report Yale as not ready at /readyz if no run has succeeded within this long; defaults to three times -interval")
+	metricsAddr := flag.String("metrics-addr", "", "address (eg. :9090) to expose Prometheus metrics on at /metrics; disabled if empty")
+	allowSubDayThresholds := flag.Bool("allow-sub-day-thresholds", false, "allow keyRotation thresholds shorter than the usual minimums of several days, down to an hour (eg. for short-lived environments)")
+	maxConcurrentReplications := flag.Int("max-concurrent-replications", keysync.DefaultMaxConcurrentReplications, "maximum number of Vault, GSM, or GitHub replications for a resource to perform at once")
+	maxDestinationFailures := flag.Int("max-destination-failures", keysync.DefaultMaxDestinationFailures, "stop syncing to a Vault, GSM, GitHub, or other external destination for the rest of a run after this many errors, and send a single notification (0 for no limit)")
+	keyCreateMaxAttempts := flag.Int("key-create-max-attempts", keyops.DefaultCreateMaxAttempts, "maximum number of times to attempt creating a new GCP or Azure key when creation fails with a 429, 5xx, or network error")
+	keyCreateRetryDelay := flag.Duration("key-create-retry-delay", keyops.DefaultCreateRetryBaseDelay, "delay before the first retry of a failed key creation, doubled for each subsequent retry")
+	var includeNamespaces namespacesFlag
+	flag.Var(&includeNamespaces, "include-namespaces", "comma-separated list of namespaces; if set, only GcpSaKeys and AzureClientSecrets in these namespaces are processed (may be repeated)")
+	var excludeNamespaces namespacesFlag
+	flag.Var(&excludeNamespaces, "exclude-namespaces", "comma-separated list of namespaces whose GcpSaKeys and AzureClientSecrets are not processed, even if also given to -include-namespaces (may be repeated)")
+	errorRepostInterval := flag.Duration("error-repost-interval", yale.DefaultErrorRepostInterval, "minimum time between repeat notifications for a resource's error")
+	keyInUseRepostInterval := flag.Duration("key-in-use-error-repost-interval", 0, "minimum time between repeat notifications for errors about keys that have reached their disable cutoff but are still in use; defaults to -error-repost-interval")
+	crdLabelSelector := flag.String("crd-label-selector", "", "label selector (eg. tier=critical) restricting which GcpSaKeys and AzureClientSecrets are processed, for running multiple Yale instances; each instance should use its own -cachenamespace")
+	auditLog := flag.String("audit-log", "", auditLogUsage)
+	eventWebhookUrl := flag.String("event-webhook-url", "", "URL to POST a JSON payload to whenever a key is issued, disabled, or deleted; delivery is best-effort and failures are only logged")
+	logLevel := flag.String("log-level", string(logs.GetLevel()), "minimum level of log lines to write: debug, info, warn, or error. info omits per-resource detail such as sync status checks; use debug to include it")
+	githubAppFlags := addGitHubAppFlags(flag.CommandLine)
+	logFormat := flag.String("log-format", string(logs.TextFormat), "format of log lines: text, or json for one JSON object per line with level, ts, msg, and contextual fields like identifier, namespace, and event")
 
 	flag.Parse()
+	if err := logs.SetFormat(*logFormat); err != nil {
+		logs.Error.Fatalf("-log-format: %v", err)
+	}
+	if err := logs.SetLevel(*logLevel); err != nil {
+		logs.Error.Fatalf("-log-level: %v", err)
+	}
+	if *once && *interval != 0 {
+		logs.Error.Fatal("-once cannot be combined with -interval")
+	}
+	if *interval < 0 {
+		logs.Error.Fatal("-interval must be positive")
+	}
+	if *readinessStaleAfter < 0 {
+		logs.Error.Fatal("-readiness-stale-after must be positive")
+	}
+	if *maxConcurrentIdentifiers < 1 {
+		logs.Error.Fatal("-max-concurrent-identifiers must be at least 1")
+	}
+	if *minRotationInterval < 0 {
+		logs.Error.Fatal("-min-rotation-interval must not be negative")
+	}
+	if *keyCreateMaxAttempts < 1 {
+		logs.Error.Fatal("-key-create-max-attempts must be at least 1")
+	}
+	if *rotationWarningLead < 0 {
+		logs.Error.Fatal("-rotation-warning-lead must be positive")
+	}
+	if err := slack.ValidateTemplates(messageTemplates); err != nil {
+		logs.Error.Fatalf("-message-template: %v", err)
+	}
+	if *errorRepostInterval <= 0 {
+		logs.Error.Fatal("-error-repost-interval must be positive")
+	}
+	if *keyInUseRepostInterval < 0 {
+		logs.Error.Fatal("-key-in-use-error-repost-interval must be positive")
+	}
+	if _, err := labels.Parse(*crdLabelSelector); err != nil {
+		logs.Error.Fatalf("-crd-label-selector: %v", err)
+	}
+	if err := validateCacheBackend(*cacheBackend, *cacheGSMProject); err != nil {
+		logs.Error.Fatal(err)
+	}
+	slackWebhookUrl, err := readSlackWebhookUrl(*slackWebhookFile)
+	if err != nil {
+		logs.Error.Fatalf("-slack-webhook-file: %v", err)
+	}
+	githubApp, err := githubAppFlags.options()
+	if err != nil {
+		logs.Error.Fatal(err)
+	}
 	return &args{
 		*local,
 		*kubeconfig,
@@ -82,17 +411,257 @@ func parseArgs() *args {
 		*windowEnd,
 		*disableVaultReplication,
 		*disableGitHubReplication,
+		*disableGitLabReplication,
+		*disableGCSReplication,
+		*disableConsulReplication,
+		*verifyVaultReplications,
+		*forceResync,
+		*slackWebhookInfo,
+		*slackWebhookAlerts,
+		*maxTrackedKeys,
+		*syncDelayAfterIssue,
+		additionalKubeconfigs,
+		*maxConcurrentReplications,
+		*maxDestinationFailures,
+		*dryRun,
+		*pruneRemovedReplications,
+		*adoptUnownedSecrets,
+		*metricsAddr,
+		*interval,
+		*once,
+		*allowSubDayThresholds,
+		*windowTimezone,
+		windows,
+		*teamsWebhookUrl,
+		messageTemplates,
+		*errorDigest,
+		*rotationWarningLead,
+		*cacheKMSKey,
+		*keyCreateMaxAttempts,
+		*keyCreateRetryDelay,
+		disableWindows,
+		includeNamespaces,
+		excludeNamespaces,
+		*crdLabelSelector,
+		*errorRepostInterval,
+		*keyInUseRepostInterval,
+		*eventWebhookUrl,
+		slackWebhookUrl,
+		*cacheBackend,
+		*cacheGSMProject,
+		*deleteOrphanedSecrets,
+		*healthAddr,
+		*readinessStaleAfter,
+		*maxRotatedKeys,
+		allowedVaultAddresses,
+		*maxConcurrentIdentifiers,
+		*recoverCacheFromSecrets,
+		*minRotationInterval,
+		githubApp,
+		*checkDedicatedCache,
+		*auditLog,
+	}
+}
+
+// githubAppFlags the -github-app-* flags, which every command that syncs keys accepts so that GitHub
+// replications can be authenticated as a GitHub App
+type githubAppFlags struct {
+	appID          *int64
+	installationID *int64
+	privateKeyFile *string
+}
+
+// addGitHubAppFlags registers the -github-app-* flags in the flag set
+func addGitHubAppFlags(flags *flag.FlagSet) *githubAppFlags {
+	return &githubAppFlags{
+		appID:          flags.Int64("github-app-id", 0, "id of a GitHub App to authenticate GitHub replications as, instead of with $GITHUB_AUTH_TOKEN; requires -github-app-installation-id and -github-app-private-key-file"),
+		installationID: flags.Int64("github-app-installation-id", 0, "id of the GitHub App's installation in the org that GitHub replications write to"),
+		privateKeyFile: flags.String("github-app-private-key-file", "", "path to a file (eg. a mounted K8s secret) containing the GitHub App's PEM-encoded private key"),
 	}
 }
 
+// options returns the GitHub App credentials given by the flags, once they have been parsed (see githubAppOptions)
+func (f *githubAppFlags) options() (*client.GitHubAppOptions, error) {
+	return githubAppOptions(*f.appID, *f.installationID, *f.privateKeyFile)
+}
+
+// githubAppOptions returns the GitHub App credentials to authenticate GitHub replications with, or nil if no
+// -github-app-* flags were given, in which case Yale falls back to $GITHUB_AUTH_TOKEN
+func githubAppOptions(appID int64, installationID int64, privateKeyFile string) (*client.GitHubAppOptions, error) {
+	if appID == 0 && installationID == 0 && privateKeyFile == "" {
+		return nil, nil
+	}
+	if appID <= 0 || installationID <= 0 || privateKeyFile == "" {
+		return nil, fmt.Errorf("-github-app-id, -github-app-installation-id, and -github-app-private-key-file must all be set to authenticate as a GitHub App")
+	}
+	return &client.GitHubAppOptions{
+		AppID:          appID,
+		InstallationID: installationID,
+		PrivateKeyFile: privateKeyFile,
+	}, nil
+}
+
+// defaultReadinessStaleAfter how long after the last successful run Yale stops reporting itself as ready, if
+// neither -readiness-stale-after nor -interval is set
+const defaultReadinessStaleAfter = time.Hour
+
+// readinessStaleAfter returns how long after the last successful run Yale stops reporting itself as ready. Unless
+// set explicitly, this is three times the run interval, so that a single failed or slow run doesn't make Yale
+// unready.
+func readinessStaleAfter(args *args) time.Duration {
+	if args.readinessStaleAfter > 0 {
+		return args.readinessStaleAfter
+	}
+	if args.interval > 0 {
+		return 3 * args.interval
+	}
+	return defaultReadinessStaleAfter
+}
+
+// validateCacheBackend returns an error if the cache backend is not supported, or the GSM project is missing
+// for the gsm backend
+func validateCacheBackend(backend string, gsmProject string) error {
+	switch backend {
+	case cache.K8sBackend:
+		return nil
+	case cache.GSMBackend:
+		if gsmProject == "" {
+			return fmt.Errorf("-cache-gsm-project is required with -cache-backend %s", cache.GSMBackend)
+		}
+		return nil
+	default:
+		return fmt.Errorf("-cache-backend must be one of %s or %s: %s", cache.K8sBackend, cache.GSMBackend, backend)
+	}
+}
+
+// readSlackWebhookUrl returns the Slack webhook URL from the file at path, with trailing whitespace trimmed,
+// or from $YALE_SLACK_WEBHOOK_URL if path is empty. The file takes precedence if both are set.
+func readSlackWebhookUrl(path string) (string, error) {
+	envValue := os.Getenv(slack.WebhookEnvVar)
+	if path == "" {
+		return envValue, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading Slack webhook: %v", err)
+	}
+	if envValue != "" {
+		logs.Info.Printf("both -slack-webhook-file and $%s are set; using Slack webhook from %s", slack.WebhookEnvVar, path)
+	} else {
+		logs.Info.Printf("using Slack webhook from %s", path)
+	}
+	return strings.TrimRight(string(content), " \t\r\n"), nil
+}
+
+// kubeconfigsFlag collects repeated -additional-kubeconfig NAME=PATH flags into a map of cluster name to kubeconfig path
+type kubeconfigsFlag map[string]string
+
+func (k kubeconfigsFlag) String() string {
+	var pairs []string
+	for name, path := range k {
+		pairs = append(pairs, name+"="+path)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (k kubeconfigsFlag) Set(value string) error {
+	name, path, found := strings.Cut(value, "=")
+	if !found || name == "" || path == "" {
+		return fmt.Errorf("must be in NAME=PATH format: %s", value)
+	}
+	if _, exists := k[name]; exists {
+		return fmt.Errorf("kubeconfig for cluster %s given more than once", name)
+	}
+	k[name] = path
+	return nil
+}
+
+// messageTemplatesFlag collects repeated -message-template EVENT=TEMPLATE flags into a map of event to template
+type messageTemplatesFlag map[string]string
+
+func (m messageTemplatesFlag) String() string {
+	var events []string
+	for event := range m {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+	return strings.Join(events, ",")
+}
+
+func (m messageTemplatesFlag) Set(value string) error {
+	event, tmpl, found := strings.Cut(value, "=")
+	if !found || event == "" {
+		return fmt.Errorf("must be in EVENT=TEMPLATE format: %s", value)
+	}
+	if _, exists := m[event]; exists {
+		return fmt.Errorf("template for event %s given more than once", event)
+	}
+	m[event] = tmpl
+	return nil
+}
+
+// windowsFlag collects repeated -window HH:MM-HH:MM flags
+type windowsFlag []string
+
+func (w *windowsFlag) String() string {
+	return strings.Join(*w, ",")
+}
+
+func (w *windowsFlag) Set(value string) error {
+	*w = append(*w, value)
+	return nil
+}
+
+// namespacesFlag collects comma-separated namespaces from repeated flags
+type namespacesFlag []string
+
+func (n *namespacesFlag) String() string {
+	return strings.Join(*n, ",")
+}
+
+func (n *namespacesFlag) Set(value string) error {
+	for _, namespace := range strings.Split(value, ",") {
+		namespace = strings.TrimSpace(namespace)
+		if namespace == "" {
+			return fmt.Errorf("namespace must not be empty: %q", value)
+		}
+		*n = append(*n, namespace)
+	}
+	return nil
+}
+
+// vaultAddressesFlag collects comma-separated Vault addresses from repeated flags
+type vaultAddressesFlag []string
+
+func (v *vaultAddressesFlag) String() string {
+	return strings.Join(*v, ",")
+}
+
+func (v *vaultAddressesFlag) Set(value string) error {
+	for _, address := range strings.Split(value, ",") {
+		address = strings.TrimSpace(address)
+		u, err := url.Parse(address)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid Vault address %q, expected an http or https URL like https://vault.example.com", address)
+		}
+		*v = append(*v, address)
+	}
+	return nil
+}
+
 func parseRotateWindow(args *args, now time.Time) (*yale.RotateWindow, error) {
 	if args.windowStart == "" {
-		if args.windowEnd == "" {
+		if args.windowEnd != "" {
+			return nil, fmt.Errorf("-window-end requires -window-start")
+		}
+		if len(args.windows) == 0 {
+			if args.windowTimezone != "" && len(args.disableWindows) == 0 {
+				return nil, fmt.Errorf("-window-timezone requires -window, -disable-window, or -window-start and -window-end")
+			}
 			return &yale.RotateWindow{
 				Enabled: false,
 			}, nil
-		} else {
-			return nil, fmt.Errorf("-window-end requires -window-start")
 		}
 	} else {
 		if args.windowEnd == "" {
@@ -100,32 +669,99 @@ func parseRotateWindow(args *args, now time.Time) (*yale.RotateWindow, error) {
 		}
 	}
 
-	start, err := parseWindowBoundary(args.windowStart, now)
+	now, err := inWindowTimezone(args, now)
 	if err != nil {
-		return nil, fmt.Errorf("-window-start: %v", err)
+		return nil, err
 	}
 
-	end, err := parseWindowBoundary(args.windowEnd, now)
+	windows, err := parseWindowsFlag("-window", args.windows, now)
 	if err != nil {
-		return nil, fmt.Errorf("-window-end: %v", err)
+		return nil, err
 	}
-
 	window := &yale.RotateWindow{
-		Enabled:   true,
-		StartTime: *start,
-		EndTime:   *end,
+		Enabled: true,
+		Windows: windows,
 	}
 
-	if window.StartTime.After(window.EndTime) {
-		return nil, fmt.Errorf("-window-start must be before -window-end: %s, %s", args.windowStart, args.windowEnd)
+	// the legacy -window-start and -window-end flags are treated as one more window
+	if args.windowStart != "" {
+		start, err := parseWindowBoundary(args.windowStart, now)
+		if err != nil {
+			return nil, fmt.Errorf("-window-start: %v", err)
+		}
+
+		end, err := parseWindowBoundary(args.windowEnd, now)
+		if err != nil {
+			return nil, fmt.Errorf("-window-end: %v", err)
+		}
+		window.Windows = append(window.Windows, yale.TimeWindow{StartTime: *start, EndTime: *end})
 	}
 
 	return window, nil
 }
 
+// parseDisableWindow parses the -disable-window flags. If none are given, disabling and deleting old keys is not
+// restricted to any time of day.
+func parseDisableWindow(args *args, now time.Time) (*yale.RotateWindow, error) {
+	if len(args.disableWindows) == 0 {
+		return &yale.RotateWindow{
+			Enabled: false,
+		}, nil
+	}
+
+	now, err := inWindowTimezone(args, now)
+	if err != nil {
+		return nil, err
+	}
+
+	windows, err := parseWindowsFlag("-disable-window", args.disableWindows, now)
+	if err != nil {
+		return nil, err
+	}
+	return &yale.RotateWindow{
+		Enabled: true,
+		Windows: windows,
+	}, nil
+}
+
+// inWindowTimezone returns now in the timezone given by -window-timezone, if any
+func inWindowTimezone(args *args, now time.Time) (time.Time, error) {
+	if args.windowTimezone == "" {
+		return now, nil
+	}
+	location, err := time.LoadLocation(args.windowTimezone)
+	if err != nil {
+		return now, fmt.Errorf("-window-timezone: %v", err)
+	}
+	// boundaries are built on today's date in the window's timezone, which may differ from the local date
+	return now.In(location), nil
+}
+
+// parseWindowsFlag parses repeated HH:MM-HH:MM values of the named flag into time windows on today's date
+func parseWindowsFlag(flagName string, values []string, now time.Time) ([]yale.TimeWindow, error) {
+	var windows []yale.TimeWindow
+	for _, value := range values {
+		startHHMM, endHHMM, found := strings.Cut(value, "-")
+		if !found {
+			return nil, fmt.Errorf("%s: must be in HH:MM-HH:MM format: %s", flagName, value)
+		}
+		start, err := parseWindowBoundary(startHHMM, now)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: start %v", flagName, value, err)
+		}
+		end, err := parseWindowBoundary(endHHMM, now)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: end %v", flagName, value, err)
+		}
+		// a start after the end means the window wraps around midnight, which TimeWindow handles
+		windows = append(windows, yale.TimeWindow{StartTime: *start, EndTime: *end})
+	}
+	return windows, nil
+}
+
 var rotateWindowRegexp = regexp.MustCompile("^[0-9]{2}:[0-9]{2}$")
 
-// parse HH:MM time-of-day into time.Time on today's date
+// parse HH:MM time-of-day into time.Time on today's date, in now's timezone
 func parseWindowBoundary(hhmm string, now time.Time) (*time.Time, error) {
 	if !rotateWindowRegexp.MatchString(hhmm) {
 		return nil, fmt.Errorf("must be in HH:MM format: %s", hhmm)
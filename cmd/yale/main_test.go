@@ -1,7 +1,21 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
 	"github.com/broadinstitute/yale/internal/yale"
+	"github.com/broadinstitute/yale/internal/yale/audit"
+	"github.com/broadinstitute/yale/internal/yale/cache"
+	"github.com/broadinstitute/yale/internal/yale/client"
+	apiv1b1 "github.com/broadinstitute/yale/internal/yale/crd/api/v1beta1"
+	"github.com/broadinstitute/yale/internal/yale/slack"
+	"github.com/broadinstitute/yale/internal/yale/testutils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"testing"
@@ -17,6 +31,7 @@ func Test_parseRotateWindow(t *testing.T) {
 		name      string
 		start     string
 		end       string
+		windows   []string
 		expectErr string
 		expected  *yale.RotateWindow
 	}{
@@ -59,19 +74,55 @@ func Test_parseRotateWindow(t *testing.T) {
 			expectErr: "-window-end: minute must be between 0 and 59",
 		},
 		{
-			name:      "start after end",
-			start:     "13:22",
-			end:       "12:01",
-			expectErr: "-window-start must be before -window-end",
+			name:  "start after end wraps around midnight",
+			start: "22:00",
+			end:   "06:00",
+			expected: &yale.RotateWindow{
+				Enabled: true,
+				Windows: []yale.TimeWindow{{StartTime: parseTimeOrPanic("2023-07-31T22:00:00Z"), EndTime: parseTimeOrPanic("2023-07-31T06:00:00Z")}},
+			},
 		},
 		{
 			name:  "correct parsing",
 			start: "07:34",
 			end:   "15:01",
 			expected: &yale.RotateWindow{
-				Enabled:   true,
-				StartTime: parseTimeOrPanic("2023-07-31T07:34:00Z"),
-				EndTime:   parseTimeOrPanic("2023-07-31T15:01:00Z"),
+				Enabled: true,
+				Windows: []yale.TimeWindow{{StartTime: parseTimeOrPanic("2023-07-31T07:34:00Z"), EndTime: parseTimeOrPanic("2023-07-31T15:01:00Z")}},
+			},
+		},
+		{
+			name:      "-window flag missing separator",
+			windows:   []string{"05:00"},
+			expectErr: "-window: must be in HH:MM-HH:MM format: 05:00",
+		},
+		{
+			name:      "-window flag bad HH",
+			windows:   []string{"05:00-06:00", "05:00-26:00"},
+			expectErr: "-window 05:00-26:00: end hour must be between 0 and 23",
+		},
+		{
+			name:    "multiple -window flags",
+			windows: []string{"05:00-06:00", "22:00-23:30"},
+			expected: &yale.RotateWindow{
+				Enabled: true,
+				Windows: []yale.TimeWindow{
+					{StartTime: parseTimeOrPanic("2023-07-31T05:00:00Z"), EndTime: parseTimeOrPanic("2023-07-31T06:00:00Z")},
+					{StartTime: parseTimeOrPanic("2023-07-31T22:00:00Z"), EndTime: parseTimeOrPanic("2023-07-31T23:30:00Z")},
+				},
+			},
+		},
+		{
+			name:    "-window flags combined with legacy flags",
+			windows: []string{"05:00-06:00"},
+			start:   "07:34",
+			end:     "15:01",
+			expected: &yale.RotateWindow{
+				Enabled: true,
+				Windows: []yale.TimeWindow{
+					{StartTime: parseTimeOrPanic("2023-07-31T05:00:00Z"), EndTime: parseTimeOrPanic("2023-07-31T06:00:00Z")},
+					{StartTime: parseTimeOrPanic("2023-07-31T07:34:00Z"), EndTime: parseTimeOrPanic("2023-07-31T15:01:00Z")},
+				},
 			},
 		},
 	}
@@ -86,6 +137,7 @@ func Test_parseRotateWindow(t *testing.T) {
 			if tc.end != "" {
 				args.windowEnd = tc.end
 			}
+			args.windows = tc.windows
 
 			window, err := parseRotateWindow(args, now)
 			if tc.expectErr != "" {
@@ -99,6 +151,143 @@ func Test_parseRotateWindow(t *testing.T) {
 	}
 }
 
+func Test_parseDisableWindow(t *testing.T) {
+	now := parseTimeOrPanic("2023-07-31T09:11:22Z")
+
+	t.Run("no flags set", func(t *testing.T) {
+		window, err := parseDisableWindow(&args{}, now)
+		require.NoError(t, err)
+		assert.Equal(t, &yale.RotateWindow{}, window)
+	})
+
+	t.Run("invalid window", func(t *testing.T) {
+		_, err := parseDisableWindow(&args{disableWindows: []string{"09:00"}}, now)
+		assert.ErrorContains(t, err, "-disable-window: must be in HH:MM-HH:MM format: 09:00")
+	})
+
+	t.Run("invalid boundary", func(t *testing.T) {
+		_, err := parseDisableWindow(&args{disableWindows: []string{"09:00-25:00"}}, now)
+		assert.ErrorContains(t, err, "-disable-window 09:00-25:00: end hour must be between 0 and 23")
+	})
+
+	t.Run("independent of the rotation window", func(t *testing.T) {
+		a := &args{windows: []string{"01:00-02:00"}, disableWindows: []string{"13:00-15:00", "22:00-01:00"}}
+		window, err := parseDisableWindow(a, now)
+		require.NoError(t, err)
+		assert.Equal(t, &yale.RotateWindow{
+			Enabled: true,
+			Windows: []yale.TimeWindow{
+				{StartTime: parseTimeOrPanic("2023-07-31T13:00:00Z"), EndTime: parseTimeOrPanic("2023-07-31T15:00:00Z")},
+				{StartTime: parseTimeOrPanic("2023-07-31T22:00:00Z"), EndTime: parseTimeOrPanic("2023-07-31T01:00:00Z")},
+			},
+		}, window)
+	})
+
+	t.Run("uses the window timezone", func(t *testing.T) {
+		a := &args{disableWindows: []string{"09:00-17:00"}, windowTimezone: "America/New_York"}
+		window, err := parseDisableWindow(a, now)
+		require.NoError(t, err)
+		require.Len(t, window.Windows, 1)
+		assert.Equal(t, "2023-07-31T13:00:00Z", window.Windows[0].StartTime.UTC().Format(layout))
+		assert.Equal(t, "2023-07-31T21:00:00Z", window.Windows[0].EndTime.UTC().Format(layout))
+
+		// the timezone is allowed without a rotation window, as long as there is a disable window
+		rotateWindow, err := parseRotateWindow(a, now)
+		require.NoError(t, err)
+		assert.False(t, rotateWindow.Enabled)
+	})
+}
+
+func Test_parseRotateWindowTimezone(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	t.Run("invalid timezone", func(t *testing.T) {
+		_, err := parseRotateWindow(&args{windowStart: "07:00", windowEnd: "09:00", windowTimezone: "America/Nowhere"}, time.Now())
+		assert.ErrorContains(t, err, "-window-timezone: unknown time zone America/Nowhere")
+	})
+
+	t.Run("timezone without window", func(t *testing.T) {
+		_, err := parseRotateWindow(&args{windowTimezone: "America/New_York"}, time.Now())
+		assert.ErrorContains(t, err, "-window-timezone requires -window, -disable-window, or -window-start and -window-end")
+	})
+
+	testCases := []struct {
+		name          string
+		now           string
+		start         string
+		end           string
+		expectedStart string
+		expectedEnd   string
+		inside        []string
+		outside       []string
+	}{
+		{
+			name:          "boundaries are in the window timezone",
+			now:           "2023-07-31T09:11:22Z",
+			start:         "07:34",
+			end:           "15:01",
+			expectedStart: "2023-07-31T11:34:00Z",
+			expectedEnd:   "2023-07-31T19:01:00Z",
+			inside:        []string{"2023-07-31T11:34:00Z", "2023-07-31T19:00:00Z"},
+			outside:       []string{"2023-07-31T07:34:00Z", "2023-07-31T19:02:00Z"},
+		},
+		{
+			name:          "boundaries use the date in the window timezone",
+			now:           "2023-08-01T02:00:00Z",
+			start:         "07:34",
+			end:           "15:01",
+			expectedStart: "2023-07-31T11:34:00Z",
+			expectedEnd:   "2023-07-31T19:01:00Z",
+		},
+		{
+			name:          "window spanning the start of DST",
+			now:           "2023-03-12T12:00:00Z",
+			start:         "01:00",
+			end:           "05:00",
+			expectedStart: "2023-03-12T06:00:00Z",
+			expectedEnd:   "2023-03-12T09:00:00Z",
+			inside:        []string{"2023-03-12T08:59:00Z"},
+			outside:       []string{"2023-03-12T09:01:00Z"},
+		},
+		{
+			name:          "window spanning the end of DST",
+			now:           "2023-11-05T12:00:00Z",
+			start:         "00:30",
+			end:           "03:00",
+			expectedStart: "2023-11-05T04:30:00Z",
+			expectedEnd:   "2023-11-05T08:00:00Z",
+			inside:        []string{"2023-11-05T07:59:00Z"},
+			outside:       []string{"2023-11-05T04:29:00Z", "2023-11-05T08:01:00Z"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			window, err := parseRotateWindow(&args{
+				windowStart:    tc.start,
+				windowEnd:      tc.end,
+				windowTimezone: "America/New_York",
+			}, parseTimeOrPanic(tc.now))
+			require.NoError(t, err)
+
+			assert.True(t, window.Enabled)
+			require.Len(t, window.Windows, 1)
+			assert.Equal(t, newYork.String(), window.Windows[0].StartTime.Location().String())
+			assert.Equal(t, newYork.String(), window.Windows[0].EndTime.Location().String())
+			assert.Equal(t, tc.expectedStart, window.Windows[0].StartTime.UTC().Format(layout))
+			assert.Equal(t, tc.expectedEnd, window.Windows[0].EndTime.UTC().Format(layout))
+
+			for _, inside := range tc.inside {
+				assert.True(t, window.Contains(parseTimeOrPanic(inside)), "%s should be inside window", inside)
+			}
+			for _, outside := range tc.outside {
+				assert.False(t, window.Contains(parseTimeOrPanic(outside)), "%s should be outside window", outside)
+			}
+		})
+	}
+}
+
 func parseTimeOrPanic(value string) time.Time {
 	t, err := time.Parse(layout, value)
 	if err != nil {
@@ -106,3 +295,438 @@ func parseTimeOrPanic(value string) time.Time {
 	}
 	return t
 }
+
+func Test_parseSelfTestArgs(t *testing.T) {
+	_, err := parseSelfTestArgs([]string{"-test-sa", "sa@p.iam.gserviceaccount.com"})
+	assert.ErrorContains(t, err, "-project is required")
+
+	_, err = parseSelfTestArgs([]string{"-project", "p"})
+	assert.ErrorContains(t, err, "-test-sa is required")
+
+	args, err := parseSelfTestArgs([]string{"-project", "p", "-test-sa", "sa@p.iam.gserviceaccount.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "p", args.project)
+	assert.Equal(t, "sa@p.iam.gserviceaccount.com", args.testSA)
+	assert.Equal(t, "yale-cache", args.namespace)
+	assert.Equal(t, "secret/yale/selftest", args.vaultPath)
+}
+
+func Test_parseRotateAllArgs(t *testing.T) {
+	_, err := parseRotateAllArgs([]string{"-dry-run"})
+	assert.ErrorContains(t, err, "-older-than is required")
+
+	_, err = parseRotateAllArgs([]string{"-older-than", "30"})
+	assert.ErrorContains(t, err, "-older-than: must be a number of days")
+
+	_, err = parseRotateAllArgs([]string{"-older-than", "30d"})
+	assert.ErrorContains(t, err, "refusing to rotate keys without -confirm")
+
+	args, err := parseRotateAllArgs([]string{"-older-than", "30d", "-dry-run"})
+	require.NoError(t, err)
+	assert.Equal(t, 30*24*time.Hour, args.olderThan)
+	assert.True(t, args.dryRun)
+	assert.Equal(t, defaultMaxRotations, args.maxRotations)
+
+	args, err = parseRotateAllArgs([]string{"-older-than", "36h", "-confirm", "-max-rotations", "5"})
+	require.NoError(t, err)
+	assert.Equal(t, 36*time.Hour, args.olderThan)
+	assert.False(t, args.dryRun)
+	assert.Equal(t, 5, args.maxRotations)
+
+	args, err = parseRotateAllArgs([]string{"-older-than", "36h", "-confirm", "-max-rotations=0"})
+	require.NoError(t, err)
+	assert.Equal(t, 0, args.maxRotations)
+	assert.Empty(t, args.auditLog)
+
+	args, err = parseRotateAllArgs([]string{"-older-than", "36h", "-confirm", "-audit-log", "/var/log/yale/audit.jsonl"})
+	require.NoError(t, err)
+	assert.Equal(t, "/var/log/yale/audit.jsonl", args.auditLog)
+}
+
+func Test_parseRotateNowArgs(t *testing.T) {
+	_, err := parseRotateNowArgs([]string{"-confirm"})
+	assert.ErrorContains(t, err, "expected exactly one identifier")
+
+	_, err = parseRotateNowArgs([]string{"-confirm", "sa-1@p.com", "sa-2@p.com"})
+	assert.ErrorContains(t, err, "expected exactly one identifier")
+
+	_, err = parseRotateNowArgs([]string{"sa@p.com"})
+	assert.ErrorContains(t, err, "refusing to rotate the key for sa@p.com without -confirm")
+
+	args, err := parseRotateNowArgs([]string{"-confirm", "-cachenamespace", "my-cache", "sa@p.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "sa@p.com", args.identifier)
+	assert.Equal(t, "my-cache", args.cacheNamespace)
+	assert.Empty(t, args.auditLog)
+
+	args, err = parseRotateNowArgs([]string{"-confirm", "-audit-log", "-", "sa@p.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "-", args.auditLog)
+}
+
+func Test_openAuditLog(t *testing.T) {
+	auditLog, err := openAuditLog("")
+	require.NoError(t, err)
+	assert.Nil(t, auditLog)
+	assert.NoError(t, flushAuditLog(auditLog))
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditLog, err = openAuditLog(path)
+	require.NoError(t, err)
+	auditLog.Log(audit.Create, &cache.Entry{Type: cache.GcpSaKey, Identifier: cache.GcpSaKeyEntryIdentifier{Email: "sa@p.com", Project: "p"}}, "key-1", "", "")
+	require.NoError(t, flushAuditLog(auditLog))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"keyId":"key-1"`)
+
+	require.NoError(t, os.WriteFile(path, []byte("not json\n"), 0600))
+	_, err = openAuditLog(path)
+	assert.ErrorContains(t, err, "-audit-log: ")
+}
+
+func Test_parseValidateArgs(t *testing.T) {
+	_, err := parseValidateArgs([]string{"-crd-label-selector", "tier in ("})
+	assert.ErrorContains(t, err, "-crd-label-selector")
+
+	args, err := parseValidateArgs([]string{"-additional-kubeconfig", "remote=/path/to/kubeconfig", "-exclude-namespaces", "ns-a,ns-b"})
+	require.NoError(t, err)
+	assert.Equal(t, kubeconfigsFlag{"remote": "/path/to/kubeconfig"}, args.additionalKubeconfigs)
+	assert.Equal(t, namespacesFlag{"ns-a", "ns-b"}, args.excludeNamespaces)
+	assert.Equal(t, "yale-cache", args.cacheNamespace)
+}
+
+func Test_parseAge(t *testing.T) {
+	d, err := parseAge("7d")
+	require.NoError(t, err)
+	assert.Equal(t, 7*24*time.Hour, d)
+
+	d, err = parseAge("90m")
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Minute, d)
+
+	_, err = parseAge("0d")
+	assert.ErrorContains(t, err, "must be greater than zero")
+
+	_, err = parseAge("a week")
+	assert.ErrorContains(t, err, "must be a number of days")
+}
+
+func Test_parseFetchArgs(t *testing.T) {
+	_, err := parseFetchArgs([]string{"-confirm"})
+	assert.ErrorContains(t, err, "-identifier is required")
+
+	_, err = parseFetchArgs([]string{"-identifier", "sa@p.com", "-format", "map", "-confirm"})
+	assert.ErrorContains(t, err, "-format must be one of")
+
+	_, err = parseFetchArgs([]string{"-identifier", "sa@p.com"})
+	assert.ErrorContains(t, err, "refusing to fetch a live credential without -confirm")
+
+	args, err := parseFetchArgs([]string{"-identifier", "sa@p.com", "-confirm"})
+	require.NoError(t, err)
+	assert.Equal(t, "sa@p.com", args.identifier)
+	assert.Equal(t, stdoutOutput, args.output)
+	assert.Equal(t, apiv1b1.JSON, args.format)
+
+	args, err = parseFetchArgs([]string{"-identifier", "sa@p.com", "-output", "/tmp/key.pem", "-format", "pem", "-confirm"})
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/key.pem", args.output)
+	assert.Equal(t, apiv1b1.PEM, args.format)
+}
+
+func Test_fetchKey(t *testing.T) {
+	yaleCache := cache.New(testutils.NewFakeK8sClient(t), cache.DefaultCacheNamespace)
+	identifier := cache.GcpSaKeyEntryIdentifier{Email: "sa@p.com", Project: "p"}
+	entry, err := yaleCache.GetOrCreate(identifier)
+	require.NoError(t, err)
+	entry.CurrentKey = cache.CurrentKey{
+		ID:   "key-1",
+		JSON: `{"private_key":"my-pem"}`,
+	}
+	require.NoError(t, yaleCache.Save(entry))
+
+	var stdout bytes.Buffer
+	err = fetchKey(yaleCache, &fetchArgs{identifier: "sa@p.com", output: stdoutOutput, format: apiv1b1.PEM}, &stdout)
+	require.NoError(t, err)
+	assert.Equal(t, "my-pem", stdout.String())
+
+	output := filepath.Join(t.TempDir(), "key.json")
+	err = fetchKey(yaleCache, &fetchArgs{identifier: "sa@p.com", output: output, format: apiv1b1.JSON}, &stdout)
+	require.NoError(t, err)
+	content, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, `{"private_key":"my-pem"}`, string(content))
+	info, err := os.Stat(output)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	err = fetchKey(yaleCache, &fetchArgs{identifier: "missing@p.com", output: stdoutOutput, format: apiv1b1.JSON}, &stdout)
+	assert.ErrorContains(t, err, "no cache entry found for missing@p.com")
+}
+
+func Test_parseDescribeArgs(t *testing.T) {
+	_, err := parseDescribeArgs([]string{})
+	assert.ErrorContains(t, err, "usage: yale describe [flags] <identifier>")
+
+	_, err = parseDescribeArgs([]string{"-output", "yaml", "sa@p.com"})
+	assert.ErrorContains(t, err, "-output must be one of text or json")
+
+	_, err = parseDescribeArgs([]string{"sa@p.com", "other@p.com"})
+	assert.ErrorContains(t, err, "unexpected arguments after identifier sa@p.com: other@p.com")
+
+	args, err := parseDescribeArgs([]string{"sa@p.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "sa@p.com", args.identifier)
+	assert.Equal(t, textOutput, args.output)
+
+	args, err = parseDescribeArgs([]string{"sa@p.com", "-output", "json"})
+	require.NoError(t, err)
+	assert.Equal(t, "sa@p.com", args.identifier)
+	assert.Equal(t, jsonOutput, args.output)
+
+	_, err = parseDescribeArgs([]string{"-cache-backend", "gsm", "sa@p.com"})
+	assert.ErrorContains(t, err, "-cache-gsm-project is required with -cache-backend gsm")
+
+	args, err = parseDescribeArgs([]string{"-cache-backend", "gsm", "-cache-gsm-project", "my-project", "sa@p.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "gsm", args.cacheBackend)
+	assert.Equal(t, "my-project", args.cacheGSMProject)
+}
+
+func Test_describe(t *testing.T) {
+	now := parseTimeOrPanic("2023-07-31T09:00:00Z")
+
+	yaleCache := cache.New(testutils.NewFakeK8sClient(t), cache.DefaultCacheNamespace)
+	entry, err := yaleCache.GetOrCreate(cache.GcpSaKeyEntryIdentifier{Email: "sa@p.com", Project: "p"})
+	require.NoError(t, err)
+	entry.CurrentKey = cache.CurrentKey{
+		ID:        "key-3",
+		JSON:      `{"private_key":"my-pem"}`,
+		CreatedAt: parseTimeOrPanic("2023-07-28T05:00:00Z"),
+	}
+	entry.RotatedKeys["key-2"] = parseTimeOrPanic("2023-07-28T05:00:00Z")
+	entry.DisabledKeys["key-1"] = parseTimeOrPanic("2023-07-31T06:30:00Z")
+	entry.SyncStatus["ns-b/gsk-b"] = "abc123:key-2"
+	entry.SyncStatus["ns-a/gsk-a"] = "def456:key-3"
+	entry.SyncStatus["ns-c/gsk-c"] = "789abc:key-3;synced=k8s,vault"
+	entry.LastError = cache.LastError{
+		Message:   "error syncing to Vault",
+		Timestamp: parseTimeOrPanic("2023-07-31T08:00:00Z"),
+	}
+	entry.LastSuccessfulRun = parseTimeOrPanic("2023-07-30T08:00:00Z")
+	entry.RecordKeyEvent("key-2", cache.KeyIssued, parseTimeOrPanic("2023-07-21T05:00:00Z"))
+	entry.RecordKeyEvent("key-2", cache.KeyRotated, parseTimeOrPanic("2023-07-28T05:00:00Z"))
+	entry.RecordKeyEvent("key-3", cache.KeyIssued, parseTimeOrPanic("2023-07-28T05:00:00Z"))
+	entry.RecordKeyEvent("key-1", cache.KeyDisabled, parseTimeOrPanic("2023-07-31T06:30:00Z"))
+	require.NoError(t, yaleCache.Save(entry))
+
+	var stdout bytes.Buffer
+	require.NoError(t, describe(yaleCache, &describeArgs{identifier: "sa@p.com", output: textOutput}, &stdout, now))
+	assert.Equal(t, `Identifier:           sa@p.com
+Type:                 GcpSaKey
+Scope:                p
+Current key:          key-3 (created 2023-07-28T05:00:00Z, 3d4h ago)
+Last successful run:  2023-07-30T08:00:00Z
+Last error:           error syncing to Vault (at 2023-07-31T08:00:00Z)
+Rotated keys:         1
+  key-2               rotated 2023-07-28T05:00:00Z (3d4h ago)
+Disabled keys:        1
+  key-1               disabled 2023-07-31T06:30:00Z (2h30m ago)
+Sync status:          3
+  ns-a/gsk-a          key key-3 (current key), spec checksum def456
+  ns-b/gsk-b          key key-2 (not current key), spec checksum abc123
+  ns-c/gsk-c          key key-3 (current key), spec checksum 789abc, partially synced to k8s, vault; other destinations pending
+History:              4
+  key-2               issued 2023-07-21T05:00:00Z
+  key-2               rotated 2023-07-28T05:00:00Z
+  key-3               issued 2023-07-28T05:00:00Z
+  key-1               disabled 2023-07-31T06:30:00Z
+`, stdout.String())
+
+	stdout.Reset()
+	require.NoError(t, describe(yaleCache, &describeArgs{identifier: "sa@p.com", output: jsonOutput}, &stdout, now))
+	assert.NotContains(t, stdout.String(), "my-pem", "the current key's JSON should never be printed")
+	var description entryDescription
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &description))
+	assert.Equal(t, "sa@p.com", description.Identifier)
+	assert.Equal(t, "key-3", description.CurrentKey.ID)
+	assert.Equal(t, "3d4h", description.CurrentKey.Age)
+	assert.Equal(t, []syncStatusDescription{
+		{Resource: "ns-a/gsk-a", SpecChecksum: "def456", KeyID: "key-3", CurrentKey: true},
+		{Resource: "ns-b/gsk-b", SpecChecksum: "abc123", KeyID: "key-2", CurrentKey: false},
+		{Resource: "ns-c/gsk-c", SpecChecksum: "789abc", KeyID: "key-3", CurrentKey: true, Partial: true, SyncedDestinations: []string{"k8s", "vault"}},
+	}, description.SyncStatus)
+	assert.Equal(t, "error syncing to Vault", description.LastError.Message)
+	assert.Equal(t, keyEventDescription{KeyID: "key-1", Event: "disabled", Timestamp: parseTimeOrPanic("2023-07-31T06:30:00Z")}, description.History[3])
+
+	err = describe(yaleCache, &describeArgs{identifier: "missing@p.com", output: textOutput}, &stdout, now)
+	assert.ErrorContains(t, err, "no cache entry found for missing@p.com")
+}
+
+func Test_kubeconfigsFlag(t *testing.T) {
+	k := make(kubeconfigsFlag)
+	require.NoError(t, k.Set("cluster-a=/path/to/a"))
+	require.NoError(t, k.Set("cluster-b=/path/to/b"))
+	assert.Equal(t, kubeconfigsFlag{"cluster-a": "/path/to/a", "cluster-b": "/path/to/b"}, k)
+	assert.Equal(t, "cluster-a=/path/to/a,cluster-b=/path/to/b", k.String())
+
+	assert.ErrorContains(t, k.Set("cluster-a=/other/path"), "given more than once")
+	assert.ErrorContains(t, k.Set("/path/only"), "must be in NAME=PATH format")
+	assert.ErrorContains(t, k.Set("=/path"), "must be in NAME=PATH format")
+}
+
+func Test_messageTemplatesFlag(t *testing.T) {
+	m := make(messageTemplatesFlag)
+	require.NoError(t, m.Set("keyIssued=issued {{ .KeyID }} for {{ .Identifier }}"))
+	require.NoError(t, m.Set("error=failed: {{ .Error }} (a=b)"))
+	assert.Equal(t, messageTemplatesFlag{"keyIssued": "issued {{ .KeyID }} for {{ .Identifier }}", "error": "failed: {{ .Error }} (a=b)"}, m)
+	assert.Equal(t, "error,keyIssued", m.String())
+
+	assert.ErrorContains(t, m.Set("keyIssued=other"), "given more than once")
+	assert.ErrorContains(t, m.Set("keyDeleted"), "must be in EVENT=TEMPLATE format")
+	assert.ErrorContains(t, m.Set("=template"), "must be in EVENT=TEMPLATE format")
+}
+
+func Test_namespacesFlag(t *testing.T) {
+	var n namespacesFlag
+	require.NoError(t, n.Set("ns-a,ns-b"))
+	require.NoError(t, n.Set(" ns-c "))
+	assert.Equal(t, namespacesFlag{"ns-a", "ns-b", "ns-c"}, n)
+	assert.Equal(t, "ns-a,ns-b,ns-c", n.String())
+
+	assert.ErrorContains(t, n.Set("ns-d,,ns-e"), "namespace must not be empty")
+}
+
+func Test_vaultAddressesFlag(t *testing.T) {
+	var v vaultAddressesFlag
+	require.NoError(t, v.Set("https://vault-a.example.com, https://vault-b.example.com:8200"))
+	require.NoError(t, v.Set("http://localhost:8200"))
+	assert.Equal(t, vaultAddressesFlag{"https://vault-a.example.com", "https://vault-b.example.com:8200", "http://localhost:8200"}, v)
+
+	assert.ErrorContains(t, v.Set("vault.example.com"), `invalid Vault address "vault.example.com"`)
+	assert.ErrorContains(t, v.Set("https://vault-c.example.com,"), `invalid Vault address ""`)
+}
+
+func Test_readSlackWebhookUrl(t *testing.T) {
+	t.Setenv(slack.WebhookEnvVar, "https://hooks.slack.com/from-env")
+
+	url, err := readSlackWebhookUrl("")
+	require.NoError(t, err)
+	assert.Equal(t, "https://hooks.slack.com/from-env", url)
+
+	path := filepath.Join(t.TempDir(), "webhook")
+	require.NoError(t, os.WriteFile(path, []byte("https://hooks.slack.com/from-file\n"), 0600))
+	url, err = readSlackWebhookUrl(path)
+	require.NoError(t, err)
+	assert.Equal(t, "https://hooks.slack.com/from-file", url)
+
+	_, err = readSlackWebhookUrl(filepath.Join(t.TempDir(), "missing"))
+	assert.ErrorContains(t, err, "error reading Slack webhook")
+}
+
+func Test_validateCacheBackend(t *testing.T) {
+	assert.NoError(t, validateCacheBackend("k8s", ""))
+	assert.NoError(t, validateCacheBackend("gsm", "my-project"))
+	assert.ErrorContains(t, validateCacheBackend("gsm", ""), "-cache-gsm-project is required with -cache-backend gsm")
+	assert.ErrorContains(t, validateCacheBackend("vault", ""), "-cache-backend must be one of k8s or gsm: vault")
+}
+
+func Test_githubAppOptions(t *testing.T) {
+	app, err := githubAppOptions(0, 0, "")
+	require.NoError(t, err)
+	assert.Nil(t, app)
+
+	app, err = githubAppOptions(123, 456, "/etc/github-app/private-key.pem")
+	require.NoError(t, err)
+	assert.Equal(t, &client.GitHubAppOptions{AppID: 123, InstallationID: 456, PrivateKeyFile: "/etc/github-app/private-key.pem"}, app)
+
+	_, err = githubAppOptions(123, 0, "/etc/github-app/private-key.pem")
+	assert.ErrorContains(t, err, "must all be set to authenticate as a GitHub App")
+}
+
+func Test_subcommandsAcceptGitHubAppFlags(t *testing.T) {
+	githubAppArgs := []string{"-github-app-id", "123", "-github-app-installation-id", "456", "-github-app-private-key-file", "/etc/github-app/private-key.pem"}
+	expected := &client.GitHubAppOptions{AppID: 123, InstallationID: 456, PrivateKeyFile: "/etc/github-app/private-key.pem"}
+
+	rotateAll, err := parseRotateAllArgs(append([]string{"-older-than", "30d", "-dry-run"}, githubAppArgs...))
+	require.NoError(t, err)
+	assert.Equal(t, expected, rotateAll.githubApp)
+
+	rotateNow, err := parseRotateNowArgs(append(append([]string{"-confirm"}, githubAppArgs...), "sa@p.com"))
+	require.NoError(t, err)
+	assert.Equal(t, expected, rotateNow.githubApp)
+
+	selfTest, err := parseSelfTestArgs(append([]string{"-project", "p", "-test-sa", "sa@p.iam.gserviceaccount.com"}, githubAppArgs...))
+	require.NoError(t, err)
+	assert.Equal(t, expected, selfTest.githubApp)
+
+	_, err = parseRotateNowArgs([]string{"-confirm", "-github-app-id", "123", "sa@p.com"})
+	assert.ErrorContains(t, err, "must all be set to authenticate as a GitHub App")
+}
+
+func Test_readinessStaleAfter(t *testing.T) {
+	assert.Equal(t, time.Hour, readinessStaleAfter(&args{}))
+	assert.Equal(t, 3*time.Minute, readinessStaleAfter(&args{interval: time.Minute}))
+	assert.Equal(t, 10*time.Minute, readinessStaleAfter(&args{interval: time.Minute, readinessStaleAfter: 10 * time.Minute}))
+}
+
+func Test_runOnInterval_ContinuesAfterErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var runs atomic.Int32
+	done := make(chan struct{})
+	go func() {
+		runOnInterval(ctx, 5*time.Millisecond, func() error {
+			if runs.Add(1) == 3 {
+				cancel()
+			}
+			return fmt.Errorf("run failed")
+		})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("runOnInterval did not return after context was cancelled")
+	}
+	assert.GreaterOrEqual(t, runs.Load(), int32(3))
+}
+
+func Test_runOnInterval_SkipsTicksWhileRunIsExecutingAndFinishesItOnShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var runs atomic.Int32
+	var finished atomic.Bool
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		runOnInterval(ctx, time.Millisecond, func() error {
+			runs.Add(1)
+			close(started)
+			<-release
+			finished.Store(true)
+			return nil
+		})
+		close(done)
+	}()
+
+	// let many ticks pass while the first run is blocked
+	<-started
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	// shutdown should wait for the in-progress run
+	select {
+	case <-done:
+		t.Fatal("runOnInterval returned before the current run finished")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	<-done
+
+	assert.True(t, finished.Load())
+	assert.Equal(t, int32(1), runs.Load())
+}
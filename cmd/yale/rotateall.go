@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/broadinstitute/yale/internal/yale"
+	"github.com/broadinstitute/yale/internal/yale/cache"
+	"github.com/broadinstitute/yale/internal/yale/client"
+	"github.com/broadinstitute/yale/internal/yale/logs"
+	"github.com/broadinstitute/yale/internal/yale/slack"
+	"github.com/broadinstitute/yale/internal/yale/teams"
+	"k8s.io/client-go/util/homedir"
+)
+
+const rotateAllCommand = "rotate-all"
+
+// defaultMaxRotations default cap on the number of keys rotate-all rotates in one invocation, so that a sweep
+// that forgets -max-rotations can't rotate every key in the cluster at once
+const defaultMaxRotations = 10
+
+type rotateAllArgs struct {
+	local           bool
+	kubeconfig      string
+	cacheBackend    string
+	cacheNamespace  string
+	cacheGSMProject string
+	cacheKMSKey     string
+	olderThan       time.Duration
+	dryRun          bool
+	confirm         bool
+	maxRotations    int
+	githubApp       *client.GitHubAppOptions
+	auditLog        string
+}
+
+// runRotateAll implements the `yale rotate-all` subcommand, which forces rotation of every
+// current key older than a given age
+func runRotateAll(argv []string) {
+	args, err := parseRotateAllArgs(argv)
+	if err != nil {
+		logs.Error.Fatal(err)
+	}
+
+	logs.Info.Printf("Building clients...")
+	clients, err := client.Build(args.local, args.kubeconfig, func(options *client.Options) {
+		options.GitHubApp = args.githubApp
+	})
+	if err != nil {
+		logs.Error.Fatalf("Error building clients: %v, exiting\n", err)
+	}
+
+	auditLog, err := openAuditLog(args.auditLog)
+	if err != nil {
+		logs.Error.Fatal(err)
+	}
+
+	m := yale.NewYale(clients, func(options *yale.Options) {
+		options.CacheBackend = args.cacheBackend
+		options.CacheNamespace = args.cacheNamespace
+		options.CacheGSMProject = args.cacheGSMProject
+		options.CacheKMSKeyName = args.cacheKMSKey
+		options.SlackWebhookUrl = os.Getenv(slack.WebhookEnvVar)
+		options.TeamsWebhookUrl = os.Getenv(teams.WebhookEnvVar)
+		if auditLog != nil {
+			options.AuditLog = auditLog
+		}
+	})
+	err = m.RotateAll(yale.RotateAllOptions{
+		OlderThan:    args.olderThan,
+		DryRun:       args.dryRun,
+		MaxRotations: args.maxRotations,
+	})
+	// flush even if rotation failed partway, so keys that were issued or synced are still recorded
+	err = errors.Join(err, flushAuditLog(auditLog))
+	if err != nil {
+		logs.Error.Fatal(err)
+	}
+}
+
+func parseRotateAllArgs(argv []string) (*rotateAllArgs, error) {
+	flags := flag.NewFlagSet(rotateAllCommand, flag.ExitOnError)
+
+	var kubeconfig *string
+	if home := homedir.HomeDir(); home != "" {
+		kubeconfig = flags.String("kubeconfig", filepath.Join(home, ".kube", "config"), "(optional) absolute path to kubectl config")
+	} else {
+		kubeconfig = flags.String("kubeconfig", "", "absolute path to kubeconfig file")
+	}
+	local := flags.Bool("local", false, "use this flag when running locally (outside of cluster to use local kube config")
+	cacheNamespace := flags.String("cachenamespace", cache.DefaultCacheNamespace, "namespace where yale should cache service account keys")
+	cacheBackend := flags.String("cache-backend", cache.K8sBackend, "where yale caches service account keys (k8s or gsm); should match the main yale process")
+	cacheGSMProject := flags.String("cache-gsm-project", "", "GCP project where yale caches service account keys, with -cache-backend gsm")
+	cacheKMSKey := flags.String("cache-kms-key", "", "resource name of a GCP KMS key to encrypt cache entries with; should match the key the main yale process is configured with")
+	olderThan := flags.String("older-than", "", "rotate every current key older than this age (eg. 30d, 12h)")
+	dryRun := flags.Bool("dry-run", false, "log which keys would be rotated without rotating them")
+	confirm := flags.Bool("confirm", false, "required to actually rotate keys when -dry-run is not set")
+	maxRotations := flags.Int("max-rotations", defaultMaxRotations, "maximum number of keys to rotate in this invocation; pass -max-rotations=0 to remove the limit")
+	auditLog := flags.String("audit-log", "", auditLogUsage)
+
+	githubAppFlags := addGitHubAppFlags(flags)
+
+	if err := flags.Parse(argv); err != nil {
+		return nil, err
+	}
+	githubApp, err := githubAppFlags.options()
+	if err != nil {
+		return nil, err
+	}
+	if err := validateCacheBackend(*cacheBackend, *cacheGSMProject); err != nil {
+		return nil, err
+	}
+	if *olderThan == "" {
+		return nil, fmt.Errorf("-older-than is required")
+	}
+	age, err := parseAge(*olderThan)
+	if err != nil {
+		return nil, fmt.Errorf("-older-than: %v", err)
+	}
+	if !*dryRun && !*confirm {
+		return nil, fmt.Errorf("refusing to rotate keys without -confirm; use -dry-run to preview which keys would be rotated")
+	}
+	if *maxRotations < 0 {
+		return nil, fmt.Errorf("-max-rotations must not be negative: %d", *maxRotations)
+	}
+
+	return &rotateAllArgs{
+		local:           *local,
+		kubeconfig:      *kubeconfig,
+		cacheBackend:    *cacheBackend,
+		cacheNamespace:  *cacheNamespace,
+		cacheGSMProject: *cacheGSMProject,
+		cacheKMSKey:     *cacheKMSKey,
+		olderThan:       age,
+		dryRun:          *dryRun,
+		confirm:         *confirm,
+		maxRotations:    *maxRotations,
+		githubApp:       githubApp,
+		auditLog:        *auditLog,
+	}, nil
+}
+
+var ageInDaysRegexp = regexp.MustCompile("^([0-9]+)d$")
+
+// parseAge parses an age like "30d", or any duration understood by time.ParseDuration, eg. "36h"
+func parseAge(age string) (time.Duration, error) {
+	var d time.Duration
+	if match := ageInDaysRegexp.FindStringSubmatch(age); match != nil {
+		days, err := strconv.Atoi(match[1])
+		if err != nil {
+			return 0, fmt.Errorf("unable to parse days: %s", age)
+		}
+		d = time.Duration(days) * 24 * time.Hour
+	} else {
+		var err error
+		d, err = time.ParseDuration(age)
+		if err != nil {
+			return 0, fmt.Errorf("must be a number of days (eg. 30d) or a duration (eg. 12h): %s", age)
+		}
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("must be greater than zero: %s", age)
+	}
+	return d, nil
+}
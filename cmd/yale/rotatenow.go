@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/broadinstitute/yale/internal/yale"
+	"github.com/broadinstitute/yale/internal/yale/cache"
+	"github.com/broadinstitute/yale/internal/yale/client"
+	"github.com/broadinstitute/yale/internal/yale/logs"
+	"github.com/broadinstitute/yale/internal/yale/slack"
+	"github.com/broadinstitute/yale/internal/yale/teams"
+	"k8s.io/client-go/util/homedir"
+)
+
+const rotateNowCommand = "rotate-now"
+
+type rotateNowArgs struct {
+	local           bool
+	kubeconfig      string
+	cacheBackend    string
+	cacheNamespace  string
+	cacheGSMProject string
+	cacheKMSKey     string
+	identifier      string
+	githubApp       *client.GitHubAppOptions
+	auditLog        string
+	// allowDecommissioned issue a new key even if the identifier is being decommissioned
+	allowDecommissioned bool
+}
+
+// runRotateNow implements the `yale rotate-now <identifier>` subcommand, which immediately rotates
+// the current key for a single identifier and syncs the new key to its destinations
+func runRotateNow(argv []string) {
+	args, err := parseRotateNowArgs(argv)
+	if err != nil {
+		logs.Error.Fatal(err)
+	}
+
+	logs.Info.Printf("Building clients...")
+	clients, err := client.Build(args.local, args.kubeconfig, func(options *client.Options) {
+		options.GitHubApp = args.githubApp
+	})
+	if err != nil {
+		logs.Error.Fatalf("Error building clients: %v, exiting\n", err)
+	}
+
+	auditLog, err := openAuditLog(args.auditLog)
+	if err != nil {
+		logs.Error.Fatal(err)
+	}
+
+	m := yale.NewYale(clients, func(options *yale.Options) {
+		options.CacheBackend = args.cacheBackend
+		options.CacheNamespace = args.cacheNamespace
+		options.CacheGSMProject = args.cacheGSMProject
+		options.CacheKMSKeyName = args.cacheKMSKey
+		options.SlackWebhookUrl = os.Getenv(slack.WebhookEnvVar)
+		options.TeamsWebhookUrl = os.Getenv(teams.WebhookEnvVar)
+		if auditLog != nil {
+			options.AuditLog = auditLog
+		}
+	})
+	err = m.RotateNow(args.identifier, yale.RotateNowOptions{
+		AllowDecommissioned: args.allowDecommissioned,
+	})
+	// flush even if rotation failed partway, so keys that were issued or synced are still recorded
+	err = errors.Join(err, flushAuditLog(auditLog))
+	if err != nil {
+		logs.Error.Fatal(err)
+	}
+}
+
+func parseRotateNowArgs(argv []string) (*rotateNowArgs, error) {
+	flags := flag.NewFlagSet(rotateNowCommand, flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: yale %s [flags] <service account email or Azure application id>\n", rotateNowCommand)
+		flags.PrintDefaults()
+	}
+
+	var kubeconfig *string
+	if home := homedir.HomeDir(); home != "" {
+		kubeconfig = flags.String("kubeconfig", filepath.Join(home, ".kube", "config"), "(optional) absolute path to kubectl config")
+	} else {
+		kubeconfig = flags.String("kubeconfig", "", "absolute path to kubeconfig file")
+	}
+	local := flags.Bool("local", false, "use this flag when running locally (outside of cluster to use local kube config")
+	cacheNamespace := flags.String("cachenamespace", cache.DefaultCacheNamespace, "namespace where yale should cache service account keys")
+	cacheBackend := flags.String("cache-backend", cache.K8sBackend, "where yale caches service account keys (k8s or gsm); should match the main yale process")
+	cacheGSMProject := flags.String("cache-gsm-project", "", "GCP project where yale caches service account keys, with -cache-backend gsm")
+	cacheKMSKey := flags.String("cache-kms-key", "", "resource name of a GCP KMS key to encrypt cache entries with; should match the key the main yale process is configured with")
+	confirm := flags.Bool("confirm", false, "required; acknowledges that the current key will be rotated immediately")
+	allowDecommissioned := flags.Bool("allow-decommissioned", false, "issue a new key even if the identifier's resources set keyRotation.decommission")
+	auditLog := flags.String("audit-log", "", auditLogUsage)
+
+	githubAppFlags := addGitHubAppFlags(flags)
+
+	if err := flags.Parse(argv); err != nil {
+		return nil, err
+	}
+	githubApp, err := githubAppFlags.options()
+	if err != nil {
+		return nil, err
+	}
+	if err := validateCacheBackend(*cacheBackend, *cacheGSMProject); err != nil {
+		return nil, err
+	}
+	if flags.NArg() != 1 || flags.Arg(0) == "" {
+		return nil, fmt.Errorf("expected exactly one identifier (service account email or Azure application id), got %d arguments; flags must come before the identifier", flags.NArg())
+	}
+	if !*confirm {
+		return nil, fmt.Errorf("refusing to rotate the key for %s without -confirm", flags.Arg(0))
+	}
+
+	return &rotateNowArgs{
+		local:           *local,
+		kubeconfig:      *kubeconfig,
+		cacheBackend:    *cacheBackend,
+		cacheNamespace:  *cacheNamespace,
+		cacheGSMProject: *cacheGSMProject,
+		cacheKMSKey:     *cacheKMSKey,
+		identifier:      flags.Arg(0),
+		githubApp:       githubApp,
+		auditLog:        *auditLog,
+
+		allowDecommissioned: *allowDecommissioned,
+	}, nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/broadinstitute/yale/internal/yale/cache"
+	"github.com/broadinstitute/yale/internal/yale/client"
+	"github.com/broadinstitute/yale/internal/yale/keyops"
+	"github.com/broadinstitute/yale/internal/yale/logs"
+	"github.com/broadinstitute/yale/internal/yale/selftest"
+	"k8s.io/client-go/util/homedir"
+)
+
+const selfTestCommand = "selftest"
+
+type selfTestArgs struct {
+	local      bool
+	kubeconfig string
+	project    string
+	testSA     string
+	namespace  string
+	vaultPath  string
+	githubApp  *client.GitHubAppOptions
+}
+
+// runSelfTest implements the `yale selftest` subcommand, which exercises a disposable
+// service account end-to-end to validate a new Yale deployment
+func runSelfTest(argv []string) {
+	args, err := parseSelfTestArgs(argv)
+	if err != nil {
+		logs.Error.Fatal(err)
+	}
+
+	logs.Info.Printf("Building clients...")
+	clients, err := client.Build(args.local, args.kubeconfig, func(options *client.Options) {
+		options.GitHubApp = args.githubApp
+	})
+	if err != nil {
+		logs.Error.Fatalf("Error building clients: %v, exiting\n", err)
+	}
+
+	report := selftest.New(clients.GetK8s(), clients.GetVault(), keyops.New(clients.GetIAM()), selftest.Options{
+		Project:        args.project,
+		ServiceAccount: args.testSA,
+		Namespace:      args.namespace,
+		VaultPath:      args.vaultPath,
+	}).Run()
+
+	fmt.Print(report.String())
+	if !report.Passed() {
+		os.Exit(1)
+	}
+}
+
+func parseSelfTestArgs(argv []string) (*selfTestArgs, error) {
+	flags := flag.NewFlagSet(selfTestCommand, flag.ExitOnError)
+
+	var kubeconfig *string
+	if home := homedir.HomeDir(); home != "" {
+		kubeconfig = flags.String("kubeconfig", filepath.Join(home, ".kube", "config"), "(optional) absolute path to kubectl config")
+	} else {
+		kubeconfig = flags.String("kubeconfig", "", "absolute path to kubeconfig file")
+	}
+	local := flags.Bool("local", false, "use this flag when running locally (outside of cluster to use local kube config")
+	project := flags.String("project", "", "google project the test service account lives in")
+	testSA := flags.String("test-sa", "", "email of a disposable service account to issue, disable, and delete a key for")
+	namespace := flags.String("namespace", cache.DefaultCacheNamespace, "namespace where the temporary test secret should be created")
+	vaultPath := flags.String("vault-path", selftest.DefaultVaultPath, "scratch Vault path the test key should be replicated to")
+
+	githubAppFlags := addGitHubAppFlags(flags)
+
+	if err := flags.Parse(argv); err != nil {
+		return nil, err
+	}
+	githubApp, err := githubAppFlags.options()
+	if err != nil {
+		return nil, err
+	}
+	if *project == "" {
+		return nil, fmt.Errorf("-project is required")
+	}
+	if *testSA == "" {
+		return nil, fmt.Errorf("-test-sa is required")
+	}
+
+	return &selfTestArgs{
+		local:      *local,
+		kubeconfig: *kubeconfig,
+		project:    *project,
+		testSA:     *testSA,
+		namespace:  *namespace,
+		vaultPath:  *vaultPath,
+		githubApp:  githubApp,
+	}, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/broadinstitute/yale/internal/yale"
+	"github.com/broadinstitute/yale/internal/yale/cache"
+	"github.com/broadinstitute/yale/internal/yale/client"
+	"github.com/broadinstitute/yale/internal/yale/logs"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/util/homedir"
+)
+
+const validateCommand = "validate"
+
+type validateArgs struct {
+	local                 bool
+	kubeconfig            string
+	cacheBackend          string
+	cacheNamespace        string
+	cacheGSMProject       string
+	cacheKMSKey           string
+	additionalKubeconfigs kubeconfigsFlag
+	includeNamespaces     namespacesFlag
+	excludeNamespaces     namespacesFlag
+	crdLabelSelector      string
+}
+
+// runValidate implements the `yale validate` subcommand, which checks GcpSaKeys, AzureClientSecrets, and
+// cache entries for problems without changing anything, and exits non-zero if it finds any
+func runValidate(argv []string) {
+	args, err := parseValidateArgs(argv)
+	if err != nil {
+		logs.Error.Fatal(err)
+	}
+
+	logs.Info.Printf("Building clients...")
+	clients, err := client.Build(args.local, args.kubeconfig)
+	if err != nil {
+		logs.Error.Fatalf("Error building clients: %v, exiting\n", err)
+	}
+	targetClusters, err := client.BuildTargetClusters(args.additionalKubeconfigs)
+	if err != nil {
+		logs.Error.Fatalf("Error building clients for additional clusters: %v, exiting\n", err)
+	}
+
+	m := yale.NewYale(clients, func(options *yale.Options) {
+		options.CacheBackend = args.cacheBackend
+		options.CacheNamespace = args.cacheNamespace
+		options.CacheGSMProject = args.cacheGSMProject
+		options.CacheKMSKeyName = args.cacheKMSKey
+		options.TargetClusters = targetClusters
+		options.IncludeNamespaces = args.includeNamespaces
+		options.ExcludeNamespaces = args.excludeNamespaces
+		options.CRDLabelSelector = args.crdLabelSelector
+	})
+	problems, err := m.Validate()
+	if err != nil {
+		logs.Error.Fatal(err)
+	}
+	if len(problems) > 0 {
+		logs.Error.Fatalf("validate: found %d problems", len(problems))
+	}
+}
+
+func parseValidateArgs(argv []string) (*validateArgs, error) {
+	flags := flag.NewFlagSet(validateCommand, flag.ExitOnError)
+
+	var kubeconfig *string
+	if home := homedir.HomeDir(); home != "" {
+		kubeconfig = flags.String("kubeconfig", filepath.Join(home, ".kube", "config"), "(optional) absolute path to kubectl config")
+	} else {
+		kubeconfig = flags.String("kubeconfig", "", "absolute path to kubeconfig file")
+	}
+	local := flags.Bool("local", false, "use this flag when running locally (outside of cluster to use local kube config")
+	cacheNamespace := flags.String("cachenamespace", cache.DefaultCacheNamespace, "namespace where yale should cache service account keys")
+	cacheBackend := flags.String("cache-backend", cache.K8sBackend, "where yale caches service account keys (k8s or gsm); should match the main yale process")
+	cacheGSMProject := flags.String("cache-gsm-project", "", "GCP project where yale caches service account keys, with -cache-backend gsm")
+	cacheKMSKey := flags.String("cache-kms-key", "", "resource name of a GCP KMS key to encrypt cache entries with; should match the key the main yale process is configured with")
+	additionalKubeconfigs := make(kubeconfigsFlag)
+	flags.Var(additionalKubeconfigs, "additional-kubeconfig", "NAME=PATH kubeconfig for an additional cluster that secrets can be synced to with secret.targetClusters (may be repeated)")
+	var includeNamespaces namespacesFlag
+	flags.Var(&includeNamespaces, "include-namespaces", "comma-separated list of namespaces; if set, only GcpSaKeys and AzureClientSecrets in these namespaces are checked (may be repeated)")
+	var excludeNamespaces namespacesFlag
+	flags.Var(&excludeNamespaces, "exclude-namespaces", "comma-separated list of namespaces whose GcpSaKeys and AzureClientSecrets are not checked, even if also given to -include-namespaces (may be repeated)")
+	crdLabelSelector := flags.String("crd-label-selector", "", "label selector (eg. tier=critical) restricting which GcpSaKeys and AzureClientSecrets are checked; should match the main yale process")
+
+	if err := flags.Parse(argv); err != nil {
+		return nil, err
+	}
+	if err := validateCacheBackend(*cacheBackend, *cacheGSMProject); err != nil {
+		return nil, err
+	}
+	if _, err := labels.Parse(*crdLabelSelector); err != nil {
+		return nil, fmt.Errorf("-crd-label-selector: %v", err)
+	}
+
+	return &validateArgs{
+		local:                 *local,
+		kubeconfig:            *kubeconfig,
+		cacheBackend:          *cacheBackend,
+		cacheNamespace:        *cacheNamespace,
+		cacheGSMProject:       *cacheGSMProject,
+		cacheKMSKey:           *cacheKMSKey,
+		additionalKubeconfigs: additionalKubeconfigs,
+		includeNamespaces:     includeNamespaces,
+		excludeNamespaces:     excludeNamespaces,
+		crdLabelSelector:      *crdLabelSelector,
+	}, nil
+}
@@ -6,6 +6,10 @@ const ignoreAnnotation = "yale.terra.bio/linter-ignore"
 
 const ignoreAll = "all"
 
+// if "true", the linter skips the resource entirely, eg. because it re-reads secrets at request time and does
+// not need to be restarted by reloader
+const skipAnnotation = "yale.terra.bio/lint-ignore"
+
 type ignoreCfg struct {
 	all     bool
 	secrets map[string]struct{}
@@ -41,3 +45,8 @@ func (i ignoreCfg) ignoresSecret(name string) bool {
 	_, exists := i.secrets[name]
 	return exists
 }
+
+// skipsResource returns true if the resource's annotations opt it out of linting entirely
+func skipsResource(annotations map[string]string) bool {
+	return annotations[skipAnnotation] == "true"
+}
@@ -34,3 +34,10 @@ func Test_parseIgnore(t *testing.T) {
 	assert.Equal(t, 3, len(i.secrets))
 	assert.True(t, i.ignoresSecret("foo"))
 }
+
+func Test_skipsResource(t *testing.T) {
+	assert.False(t, skipsResource(map[string]string{}))
+	assert.False(t, skipsResource(map[string]string{"yale.terra.bio/lint-ignore": "false"}))
+	assert.False(t, skipsResource(map[string]string{"yale.terra.bio/linter-ignore": "all"}))
+	assert.True(t, skipsResource(map[string]string{"yale.terra.bio/lint-ignore": "true"}))
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/broadinstitute/yale/internal/yale/crd/api/v1beta1"
 	"github.com/broadinstitute/yale/internal/yale/logs"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -17,6 +18,9 @@ import (
 type resources struct {
 	deployments  []resource[appsv1.Deployment]
 	statefulSets []resource[appsv1.StatefulSet]
+	daemonSets   []resource[appsv1.DaemonSet]
+	cronJobs     []resource[batchv1.CronJob]
+	jobs         []resource[batchv1.Job]
 	gsks         []resource[v1beta1.GcpSaKey]
 }
 
@@ -24,6 +28,7 @@ type resource[T any] struct {
 	typed       T
 	document    document
 	kind        string
+	namespace   string
 	name        string
 	annotations map[string]string
 }
@@ -39,9 +44,21 @@ type document struct {
 	filename string
 }
 
+// results is the outcome of linting one or more directories
+type results struct {
+	// matches are references to Yale secrets by resources that will not be restarted when the secrets change
+	matches []reference
+	// passed is the number of resources that reference Yale secrets and will be restarted when they change
+	passed int
+	// failed is the number of resources with at least one match
+	failed int
+	// ignored is the number of resources skipped because they have the lint-ignore annotation
+	ignored int
+}
+
 // in:#terra-asset-management after:2024-10-11 -cert -zebrafish -events -volumeattachments -customresourcedefinitions
 
-func Run(globs ...string) ([]reference, error) {
+func Run(globs ...string) (*results, error) {
 	parser, err := newParser()
 	if err != nil {
 		return nil, err
@@ -52,30 +69,35 @@ func Run(globs ...string) ([]reference, error) {
 		return nil, err
 	}
 
-	var matches []reference
+	res := new(results)
 	for _, dir := range dirs {
-		dirMatches, err := scanDir(parser, dir)
+		dirResults, err := scanDir(parser, dir)
 		if err != nil {
 			return nil, fmt.Errorf("error scanning dir %s: %v", dir, err)
 		}
-		matches = append(dirMatches, matches...)
+		res.matches = append(dirResults.matches, res.matches...)
+		res.passed += dirResults.passed
+		res.failed += dirResults.failed
+		res.ignored += dirResults.ignored
 	}
 
-	count := len(matches)
+	logs.Info.Printf("%d resources passed, %d failed, %d ignored", res.passed, res.failed, res.ignored)
+
+	count := len(res.matches)
 	msg := fmt.Sprintf("Found %d resources with missing annotations", count)
 	if count <= 0 {
 		logs.Info.Println(msg)
-		return nil, nil
+		return res, nil
 	}
 
 	msg = msg + ":\n"
-	for _, m := range matches {
+	for _, m := range res.matches {
 		msg = msg + "    " + m.summarize() + "\n"
 	}
-	return matches, errors.New(msg)
+	return res, errors.New(msg)
 }
 
-func scanDir(parser *parser, dir string) ([]reference, error) {
+func scanDir(parser *parser, dir string) (*results, error) {
 	logs.Info.Printf("Scanning %s...", dir)
 	resources, err := parser.parseFilesInDirectory(dir)
 	if err != nil {
@@ -85,30 +107,49 @@ func scanDir(parser *parser, dir string) ([]reference, error) {
 	var secrets []secret
 	for _, gsk := range resources.gsks {
 		secretName := gsk.typed.Spec.Secret.Name
+		if secretName == "" {
+			// key is only replicated outside of K8s, so there's no secret for workloads to reference
+			continue
+		}
 		secrets = append(secrets, secret{
 			name:   secretName,
 			regexp: buildRegexpToMatchSecretName(secretName),
 		})
 	}
 
-	var matches []reference
-	matches = append(matches, scanAllOfType(resources.deployments, secrets)...)
-	matches = append(matches, scanAllOfType(resources.statefulSets, secrets)...)
+	res := new(results)
+	scanAllOfType(res, resources.deployments, secrets)
+	scanAllOfType(res, resources.statefulSets, secrets)
+	scanAllOfType(res, resources.daemonSets, secrets)
+	scanAllOfType(res, resources.cronJobs, secrets)
+	scanAllOfType(res, resources.jobs, secrets)
 
-	return matches, nil
+	return res, nil
 }
 
-func scanAllOfType[T any](rs []resource[T], secrets []secret) []reference {
-	var matches []reference
+func scanAllOfType[T any](res *results, rs []resource[T], secrets []secret) {
 	for _, r := range rs {
-		matches = append(matches, scan(r, secrets)...)
+		if skipsResource(r.annotations) {
+			logs.Info.Printf("%s:%d -- skipping %s %s because it has annotation %s: \"true\" (it does not need to be restarted when Yale secrets change)", r.document.filename, r.document.offset, r.kind, r.name, skipAnnotation)
+			res.ignored++
+			continue
+		}
+
+		matches, referencesSecrets := scan(r, secrets)
+		if len(matches) > 0 {
+			res.failed++
+		} else if referencesSecrets {
+			res.passed++
+		}
+		res.matches = append(res.matches, matches...)
 	}
-	return matches
 }
 
-// here we walk through the document line by line and search for references to Yale secrets
-func scan[T any](r resource[T], secrets []secret) []reference {
+// here we walk through the document line by line and search for references to Yale secrets. Returns the
+// references that will not be reloaded, and whether the document references any Yale secrets at all.
+func scan[T any](r resource[T], secrets []secret) ([]reference, bool) {
 	var matches []reference
+	var referencesSecrets bool
 
 	reloader := parseReloaderAnnotations(r.annotations)
 
@@ -124,13 +165,15 @@ func scan[T any](r resource[T], secrets []secret) []reference {
 			if !s.regexp.Match(line) {
 				continue
 			}
+			referencesSecrets = true
 
 			ref := reference{
-				filename: r.document.filename,
-				lineno:   r.document.offset + lineoffset,
-				kind:     r.kind,
-				name:     r.name,
-				secret:   s.name,
+				filename:  r.document.filename,
+				lineno:    r.document.offset + lineoffset,
+				kind:      r.kind,
+				namespace: r.namespace,
+				name:      r.name,
+				secret:    s.name,
 			}
 
 			reason, reloads := reloader.reloadsOnSecret(s.name)
@@ -138,18 +181,19 @@ func scan[T any](r resource[T], secrets []secret) []reference {
 				logs.Debug.Printf("%s: will reload (%s)", ref.summarize(), reason)
 				continue
 			}
-			logs.Info.Printf("%s: WILL NOT reload on changes", ref.summarize())
+			logs.Info.Printf("%s: WILL NOT reload on changes (%s)", ref.summarize(), reason)
 			if ignore.ignoresSecret(s.name) {
 				logs.Info.Printf("%s: ignoring missing reloader annotation", ref.summarize())
 				continue
 			}
 
+			ref.reason = reason
 			matches = append(matches, ref)
 		}
 		lineoffset++
 	}
 
-	return matches
+	return matches, referencesSecrets
 }
 
 func buildRegexpToMatchSecretName(secretName string) *regexp.Regexp {
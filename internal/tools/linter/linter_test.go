@@ -8,10 +8,17 @@ import (
 	"testing"
 )
 
+const missingAnnotationsReason = "missing one of the annotations reloader.stakater.com/auto, reloader.stakater.com/search, or secret.reloader.stakater.com/reload"
+
+func notInListReason(secret string) string {
+	return "annotation secret.reloader.stakater.com/reload does not include " + secret
+}
+
 func Test_Linter(t *testing.T) {
 	testCases := []struct {
 		name     string
 		expected []reference
+		ignored  int
 	}{
 		{
 			name: "empty",
@@ -25,6 +32,7 @@ func Test_Linter(t *testing.T) {
 					kind:     "Deployment",
 					name:     "deployment-1",
 					secret:   "gsk-1-secret",
+					reason:   missingAnnotationsReason,
 				},
 			},
 		},
@@ -46,6 +54,7 @@ func Test_Linter(t *testing.T) {
 					kind:     "Deployment",
 					name:     "deployment-1",
 					secret:   "gsk-1-secret",
+					reason:   notInListReason("gsk-1-secret"),
 				},
 			},
 		},
@@ -58,6 +67,7 @@ func Test_Linter(t *testing.T) {
 					kind:     "StatefulSet",
 					name:     "sts-1",
 					secret:   "gsk-1-secret",
+					reason:   missingAnnotationsReason,
 				},
 			},
 		},
@@ -70,6 +80,7 @@ func Test_Linter(t *testing.T) {
 					kind:     "Deployment",
 					name:     "deployment-2",
 					secret:   "gsk-2-secret",
+					reason:   notInListReason("gsk-2-secret"),
 				},
 				{
 					filename: "testdata/complex-missing/deployment.yaml",
@@ -77,6 +88,7 @@ func Test_Linter(t *testing.T) {
 					kind:     "Deployment",
 					name:     "deployment-3",
 					secret:   "gsk-2-secret",
+					reason:   notInListReason("gsk-2-secret"),
 				},
 				{
 					filename: "testdata/complex-missing/deployment.yaml",
@@ -84,6 +96,7 @@ func Test_Linter(t *testing.T) {
 					kind:     "Deployment",
 					name:     "deployment-5",
 					secret:   "gsk-1-secret",
+					reason:   missingAnnotationsReason,
 				},
 				{
 					filename: "testdata/complex-missing/deployment.yaml",
@@ -91,6 +104,7 @@ func Test_Linter(t *testing.T) {
 					kind:     "Deployment",
 					name:     "deployment-5",
 					secret:   "gsk-2-secret",
+					reason:   missingAnnotationsReason,
 				},
 				{
 					filename: "testdata/complex-missing/sts.yaml",
@@ -98,6 +112,7 @@ func Test_Linter(t *testing.T) {
 					kind:     "StatefulSet",
 					name:     "sts-1",
 					secret:   "gsk-1-secret",
+					reason:   missingAnnotationsReason,
 				},
 			},
 		},
@@ -107,19 +122,84 @@ func Test_Linter(t *testing.T) {
 		{
 			name: "sts-missing-with-ignore",
 		},
+		{
+			name: "daemonset-missing",
+			expected: []reference{
+				{
+					filename:  "testdata/daemonset-missing/daemonset.yaml",
+					lineno:    13,
+					kind:      "DaemonSet",
+					namespace: "ns-1",
+					name:      "ds-1",
+					secret:    "gsk-1-secret",
+					reason:    missingAnnotationsReason,
+				},
+			},
+		},
+		{
+			name: "daemonset-list-annotation",
+		},
+		{
+			name: "cronjob-missing",
+			expected: []reference{
+				{
+					filename: "testdata/cronjob-missing/cronjob.yaml",
+					lineno:   20,
+					kind:     "CronJob",
+					name:     "cronjob-1",
+					secret:   "gsk-1-secret",
+					reason:   missingAnnotationsReason,
+				},
+			},
+		},
+		{
+			name: "cronjob-auto-annotation",
+		},
+		{
+			name: "job-missing",
+			expected: []reference{
+				{
+					filename: "testdata/job-missing/job.yaml",
+					lineno:   15,
+					kind:     "Job",
+					name:     "job-1",
+					secret:   "gsk-1-secret",
+					reason:   missingAnnotationsReason,
+				},
+			},
+		},
+		{
+			name: "job-search-annotation",
+		},
+		{
+			name: "lint-ignore-annotation",
+			expected: []reference{
+				{
+					filename: "testdata/lint-ignore-annotation/deployment.yaml",
+					lineno:   30,
+					kind:     "Deployment",
+					name:     "deployment-2",
+					secret:   "gsk-1-secret",
+					reason:   missingAnnotationsReason,
+				},
+			},
+			ignored: 2,
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			dir := path.Join("testdata", tc.name)
-			matches, err := Run(dir)
+			res, err := Run(dir)
+			require.NotNil(t, res)
 			if len(tc.expected) == 0 {
 				require.NoError(t, err)
 			} else {
 				assert.Error(t, err)
 				assert.ErrorContains(t, err, fmt.Sprintf("Found %d resources with missing annotations", len(tc.expected)))
 			}
-			assert.Equal(t, tc.expected, matches)
+			assert.Equal(t, tc.expected, res.matches)
+			assert.Equal(t, tc.ignored, res.ignored)
 		})
 	}
 }
@@ -11,6 +11,7 @@ import (
 	"github.com/broadinstitute/yale/internal/yale/crd/api/v1beta1"
 	"github.com/broadinstitute/yale/internal/yale/logs"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -79,11 +80,17 @@ func (p *parser) parseFile(resources *resources, file string) error {
 		}
 
 		if gsk, ok := obj.(*v1beta1.GcpSaKey); ok {
-			resources.gsks = append(resources.gsks, resource[v1beta1.GcpSaKey]{*gsk, doc, gsk.Kind(), gsk.ObjectMeta.Name, gsk.Annotations})
+			resources.gsks = append(resources.gsks, resource[v1beta1.GcpSaKey]{*gsk, doc, gsk.Kind(), gsk.ObjectMeta.Namespace, gsk.ObjectMeta.Name, gsk.Annotations})
 		} else if dep, ok := obj.(*appsv1.Deployment); ok {
-			resources.deployments = append(resources.deployments, resource[appsv1.Deployment]{*dep, doc, dep.Kind, dep.Name, dep.Annotations})
+			resources.deployments = append(resources.deployments, resource[appsv1.Deployment]{*dep, doc, dep.Kind, dep.Namespace, dep.Name, dep.Annotations})
 		} else if sts, ok := obj.(*appsv1.StatefulSet); ok {
-			resources.statefulSets = append(resources.statefulSets, resource[appsv1.StatefulSet]{*sts, doc, sts.Kind, sts.Name, sts.Annotations})
+			resources.statefulSets = append(resources.statefulSets, resource[appsv1.StatefulSet]{*sts, doc, sts.Kind, sts.Namespace, sts.Name, sts.Annotations})
+		} else if ds, ok := obj.(*appsv1.DaemonSet); ok {
+			resources.daemonSets = append(resources.daemonSets, resource[appsv1.DaemonSet]{*ds, doc, ds.Kind, ds.Namespace, ds.Name, ds.Annotations})
+		} else if cj, ok := obj.(*batchv1.CronJob); ok {
+			resources.cronJobs = append(resources.cronJobs, resource[batchv1.CronJob]{*cj, doc, cj.Kind, cj.Namespace, cj.Name, cj.Annotations})
+		} else if job, ok := obj.(*batchv1.Job); ok {
+			resources.jobs = append(resources.jobs, resource[batchv1.Job]{*job, doc, job.Kind, job.Namespace, job.Name, job.Annotations})
 		}
 	}
 
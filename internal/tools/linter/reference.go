@@ -2,7 +2,7 @@ package linter
 
 import "fmt"
 
-// reference represents a reference to a Yale secret in a Deployment or StatefulSet manifest
+// reference represents a reference to a Yale secret in a workload (Deployment, StatefulSet, DaemonSet, CronJob, or Job) manifest
 type reference struct {
 	// filename is the name of the file containing the reference
 	filename string
@@ -10,10 +10,14 @@ type reference struct {
 	lineno int
 	// kind is the kind of resource containing the reference
 	kind string
+	// namespace is the namespace of the resource containing the reference, if set in its manifest
+	namespace string
 	// name is the name of the resource containing the reference
 	name string
 	// secret is the name of the referenced Yale secret
 	secret string
+	// reason describes why the resource will not be restarted when the secret changes
+	reason string
 }
 
 // summarize returns a human-readable summary of the reference
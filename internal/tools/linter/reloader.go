@@ -2,14 +2,14 @@ package linter
 
 import "strings"
 
-// if "true", reloader will reload on all CMs/Secrets used by the Deployment/StatefulSet
+// if "true", reloader will reload on all CMs/Secrets used by the workload
 const autoAnnotation = "reloader.stakater.com/auto"
 
-// if "true", reloader will reload on CMs/Secrets used by the Deployment/StatefulSet that have a "match" annotation.
+// if "true", reloader will reload on CMs/Secrets used by the workload that have a "match" annotation.
 // (Yale adds this annotation to secrets it creates)
 const searchAnnotation = "reloader.stakater.com/search"
 
-// reloader will reload on a configured list of Secrets used by the Deployment/StatefulSet
+// reloader will reload on a configured list of Secrets used by the workload
 const secretListAnnotation = "secret.reloader.stakater.com/reload"
 
 // reloaderCfg describes how reloader is configured to manage a given resource
@@ -19,6 +19,8 @@ type reloaderCfg struct {
 	list   map[string]struct{}
 }
 
+// reloadsOnSecret returns true if reloader will restart the resource when the secret changes, along with a
+// description of why it will or will not
 func (r reloaderCfg) reloadsOnSecret(secretName string) (string, bool) {
 	if r.auto {
 		return "has annotation " + autoAnnotation, true
@@ -30,8 +32,11 @@ func (r reloaderCfg) reloadsOnSecret(secretName string) (string, bool) {
 	if exists {
 		return "annotation " + secretListAnnotation + " includes " + secretName, true
 	}
+	if len(r.list) > 0 {
+		return "annotation " + secretListAnnotation + " does not include " + secretName, false
+	}
 
-	return "", false
+	return "missing one of the annotations " + autoAnnotation + ", " + searchAnnotation + ", or " + secretListAnnotation, false
 }
 
 // parseReloaderAnnotations parses the reloader annotations on a resource and returns a reloaderCfg
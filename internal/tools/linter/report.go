@@ -0,0 +1,57 @@
+package linter
+
+// Report is a structured summary of the linter's findings, suitable for encoding as JSON
+type Report struct {
+	// Findings is the list of resources that reference Yale secrets but will not be restarted when they change
+	Findings []Finding `json:"findings"`
+	// Summary contains aggregate counts for the findings
+	Summary Summary `json:"summary"`
+}
+
+// Finding is a single reference to a Yale secret by a resource that is missing reloader annotations
+type Finding struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Secret    string `json:"secret"`
+	Reason    string `json:"reason"`
+	Filename  string `json:"filename"`
+	Line      int    `json:"line"`
+}
+
+// Summary aggregate counts for a Report
+type Summary struct {
+	// Count is the total number of findings
+	Count int `json:"count"`
+	// Passed is the number of resources that reference Yale secrets and will be restarted when they change
+	Passed int `json:"passed"`
+	// Failed is the number of resources with at least one finding
+	Failed int `json:"failed"`
+	// Ignored is the number of resources skipped because they have the yale.terra.bio/lint-ignore annotation
+	Ignored int `json:"ignored"`
+}
+
+// NewReport builds a Report from the results returned by Run
+func NewReport(res *results) Report {
+	findings := make([]Finding, 0, len(res.matches))
+	for _, m := range res.matches {
+		findings = append(findings, Finding{
+			Kind:      m.kind,
+			Namespace: m.namespace,
+			Name:      m.name,
+			Secret:    m.secret,
+			Reason:    m.reason,
+			Filename:  m.filename,
+			Line:      m.lineno,
+		})
+	}
+	return Report{
+		Findings: findings,
+		Summary: Summary{
+			Count:   len(findings),
+			Passed:  res.passed,
+			Failed:  res.failed,
+			Ignored: res.ignored,
+		},
+	}
+}
@@ -0,0 +1,62 @@
+package linter
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewReport(t *testing.T) {
+	res, err := Run("testdata/daemonset-missing")
+	require.Error(t, err)
+
+	report := NewReport(res)
+	assert.Equal(t, Report{
+		Findings: []Finding{
+			{
+				Kind:      "DaemonSet",
+				Namespace: "ns-1",
+				Name:      "ds-1",
+				Secret:    "gsk-1-secret",
+				Reason:    missingAnnotationsReason,
+				Filename:  "testdata/daemonset-missing/daemonset.yaml",
+				Line:      13,
+			},
+		},
+		Summary: Summary{Count: 1, Failed: 1},
+	}, report)
+
+	encoded, err := json.Marshal(report)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"findings": [{
+			"kind": "DaemonSet",
+			"namespace": "ns-1",
+			"name": "ds-1",
+			"secret": "gsk-1-secret",
+			"reason": "`+missingAnnotationsReason+`",
+			"filename": "testdata/daemonset-missing/daemonset.yaml",
+			"line": 13
+		}],
+		"summary": {"count": 1, "passed": 0, "failed": 1, "ignored": 0}
+	}`, string(encoded))
+}
+
+func Test_NewReportWithNoFindings(t *testing.T) {
+	res, err := Run("testdata/empty")
+	require.NoError(t, err)
+
+	encoded, err := json.Marshal(NewReport(res))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"findings": [], "summary": {"count": 0, "passed": 0, "failed": 0, "ignored": 0}}`, string(encoded))
+}
+
+func Test_NewReportCountsPassedFailedAndIgnoredResources(t *testing.T) {
+	res, err := Run("testdata/lint-ignore-annotation")
+	require.Error(t, err)
+
+	report := NewReport(res)
+	assert.Equal(t, Summary{Count: 1, Passed: 1, Failed: 1, Ignored: 2}, report.Summary)
+}
@@ -0,0 +1,227 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/broadinstitute/yale/internal/yale/cache"
+)
+
+// Stdout path that writes the audit log to stdout instead of a file
+const Stdout = "-"
+
+// Actor value of the actor field of every record, identifying Yale as the party that performed the operation
+const Actor = "yale"
+
+// Operation a mutating operation recorded in the audit log
+type Operation string
+
+const (
+	// Create a new key was issued
+	Create Operation = "create"
+	// Disable a rotated key was disabled
+	Disable Operation = "disable"
+	// Delete a disabled key was deleted
+	Delete Operation = "delete"
+	// Sync the current key was written to a destination (eg. a K8s secret or Vault)
+	Sync Operation = "sync"
+)
+
+// Record a single line of the audit log. Each record includes the hash of the record before it, and its own
+// hash covers every other field, so that editing, removing, or reordering records breaks the chain (see Verify).
+type Record struct {
+	// Timestamp time at which the operation was performed
+	Timestamp time.Time `json:"timestamp"`
+	// Actor always "yale"
+	Actor string `json:"actor"`
+	// Operation what was done: create, disable, delete, or sync
+	Operation Operation `json:"operation"`
+	// Type the type of the cache entry (eg. GcpSaKey)
+	Type string `json:"type"`
+	// Identifier the service account email or Azure application id the key belongs to
+	Identifier string `json:"identifier"`
+	// Scope the GCP project or Azure tenant of the identifier
+	Scope string `json:"scope"`
+	// KeyID id of the key the operation was performed on
+	KeyID string `json:"keyId"`
+	// Destination for syncs, the destination the key was written to (eg. k8s or vault)
+	Destination string `json:"destination,omitempty"`
+	// Resource for syncs, the namespace and name of the resource that was synced
+	Resource string `json:"resource,omitempty"`
+	// PrevHash hash of the previous record in the log, or empty for the first record
+	PrevHash string `json:"prevHash"`
+	// Hash sha256 hash of the record's JSON without this field
+	Hash string `json:"hash,omitempty"`
+}
+
+// Logger records mutating operations. It is safe for concurrent use
+type Logger interface {
+	// Log appends a record of the operation on the entry's key. destination and resource are only set for syncs
+	Log(operation Operation, entry *cache.Entry, keyID string, destination string, resource string)
+	// Flush writes buffered records to the underlying writer, returning any error encountered since the last flush
+	Flush() error
+}
+
+// NewNop returns a Logger that discards every record
+func NewNop() Logger {
+	return nop{}
+}
+
+type nop struct{}
+
+func (nop) Log(_ Operation, _ *cache.Entry, _ string, _ string, _ string) {}
+
+func (nop) Flush() error {
+	return nil
+}
+
+// Open returns a Logger that appends records to the file at path, creating it if it does not exist, or writes
+// them to stdout if path is Stdout. Records appended to an existing file continue its hash chain.
+func Open(path string) (Logger, error) {
+	if path == Stdout {
+		return New(os.Stdout, ""), nil
+	}
+	prevHash, err := lastHash(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("error opening audit log %s: %v", path, err)
+	}
+	return New(file, prevHash), nil
+}
+
+// New returns a Logger that writes records to w, buffering them until Flush is called. prevHash is the hash
+// of the last record already written to w, if any
+func New(w io.Writer, prevHash string) Logger {
+	return &logger{
+		writer:   bufio.NewWriter(w),
+		prevHash: prevHash,
+		now:      time.Now,
+	}
+}
+
+type logger struct {
+	mutex    sync.Mutex
+	writer   *bufio.Writer
+	prevHash string
+	err      error
+	now      func() time.Time
+}
+
+func (l *logger) Log(operation Operation, entry *cache.Entry, keyID string, destination string, resource string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	record := Record{
+		Timestamp:   l.now().UTC(),
+		Actor:       Actor,
+		Operation:   operation,
+		Type:        entry.Type.String(),
+		Identifier:  entry.Identify(),
+		Scope:       entry.Scope(),
+		KeyID:       keyID,
+		Destination: destination,
+		Resource:    resource,
+		PrevHash:    l.prevHash,
+	}
+	line, err := seal(&record)
+	if err == nil {
+		_, err = l.writer.Write(append(line, '\n'))
+	}
+	if err != nil {
+		if l.err == nil {
+			l.err = fmt.Errorf("error writing %s record for key %s of %s to audit log: %v", operation, keyID, entry.Identify(), err)
+		}
+		return
+	}
+	l.prevHash = record.Hash
+}
+
+func (l *logger) Flush() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	err := l.err
+	l.err = nil
+	if flushErr := l.writer.Flush(); flushErr != nil && err == nil {
+		err = fmt.Errorf("error flushing audit log: %v", flushErr)
+	}
+	return err
+}
+
+// seal computes the record's hash and returns its JSON
+func seal(record *Record) ([]byte, error) {
+	record.Hash = ""
+	unsealed, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(unsealed)
+	record.Hash = hex.EncodeToString(sum[:])
+	return json.Marshal(record)
+}
+
+// Verify reads an audit log and returns an error if any record was modified, or if records were removed,
+// inserted, or reordered anywhere but at the end of the log
+func Verify(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	prevHash := ""
+	for line := 1; scanner.Scan(); line++ {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return fmt.Errorf("line %d: invalid audit record: %v", line, err)
+		}
+		if record.PrevHash != prevHash {
+			return fmt.Errorf("line %d: previous hash %q does not match hash of line %d %q", line, record.PrevHash, line-1, prevHash)
+		}
+		hash := record.Hash
+		if _, err := seal(&record); err != nil {
+			return fmt.Errorf("line %d: %v", line, err)
+		}
+		if record.Hash != hash {
+			return fmt.Errorf("line %d: hash %q does not match record contents", line, hash)
+		}
+		prevHash = hash
+	}
+	return scanner.Err()
+}
+
+// lastHash returns the hash of the last record in the audit log at path, or "" if it does not exist or is empty
+func lastHash(path string) (string, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading audit log %s: %v", path, err)
+	}
+	defer file.Close()
+
+	var last []byte
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) > 0 {
+			last = append(last[:0], scanner.Bytes()...)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return "", fmt.Errorf("error reading audit log %s: %v", path, err)
+	}
+	if last == nil {
+		return "", nil
+	}
+	var record Record
+	if err = json.Unmarshal(last, &record); err != nil {
+		return "", fmt.Errorf("error reading audit log %s: last line is not a valid audit record: %v", path, err)
+	}
+	return record.Hash, nil
+}
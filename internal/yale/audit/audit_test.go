@@ -0,0 +1,130 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/broadinstitute/yale/internal/yale/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var entry = &cache.Entry{
+	Identifier: cache.GcpSaKeyEntryIdentifier{
+		Email:   "s1@p.iam.gserviceaccount.com",
+		Project: "p",
+	},
+	Type: cache.GcpSaKey,
+}
+
+var now = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestLogger(buf *bytes.Buffer, prevHash string) Logger {
+	l := New(buf, prevHash).(*logger)
+	l.now = func() time.Time { return now }
+	return l
+}
+
+func parseRecords(t *testing.T, data []byte) []Record {
+	var records []Record
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var record Record
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func Test_Logger_WritesRecordsOnFlush(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf, "")
+
+	l.Log(Create, entry, "key-2", "", "")
+	l.Log(Sync, entry, "key-2", "vault", "ns-1/gsk-1")
+	assert.Empty(t, buf.String())
+
+	require.NoError(t, l.Flush())
+	records := parseRecords(t, buf.Bytes())
+	require.Len(t, records, 2)
+
+	assert.Equal(t, now, records[0].Timestamp)
+	assert.Equal(t, Actor, records[0].Actor)
+	assert.Equal(t, Create, records[0].Operation)
+	assert.Equal(t, "GcpSaKey", records[0].Type)
+	assert.Equal(t, "s1@p.iam.gserviceaccount.com", records[0].Identifier)
+	assert.Equal(t, "p", records[0].Scope)
+	assert.Equal(t, "key-2", records[0].KeyID)
+	assert.Empty(t, records[0].Destination)
+	assert.Empty(t, records[0].PrevHash)
+	assert.NotEmpty(t, records[0].Hash)
+
+	assert.Equal(t, Sync, records[1].Operation)
+	assert.Equal(t, "vault", records[1].Destination)
+	assert.Equal(t, "ns-1/gsk-1", records[1].Resource)
+	assert.Equal(t, records[0].Hash, records[1].PrevHash)
+
+	assert.NoError(t, Verify(&buf))
+}
+
+func Test_Verify_DetectsTampering(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf, "")
+	l.Log(Create, entry, "key-2", "", "")
+	l.Log(Disable, entry, "key-1", "", "")
+	l.Log(Delete, entry, "key-0", "", "")
+	require.NoError(t, l.Flush())
+	lines := strings.SplitAfter(strings.TrimSpace(buf.String()), "\n")
+
+	// modified record
+	modified := strings.Replace(buf.String(), `"keyId":"key-1"`, `"keyId":"key-9"`, 1)
+	assert.ErrorContains(t, Verify(strings.NewReader(modified)), "line 2: hash")
+
+	// removed record
+	removed := lines[0] + lines[2]
+	assert.ErrorContains(t, Verify(strings.NewReader(removed)), "line 2: previous hash")
+
+	// reordered records
+	reordered := lines[1] + lines[0] + lines[2]
+	assert.ErrorContains(t, Verify(strings.NewReader(reordered)), "line 1: previous hash")
+
+	// records truncated from the end of the log can't be detected
+	assert.NoError(t, Verify(strings.NewReader(lines[0]+lines[1])))
+}
+
+func Test_Open_ContinuesHashChainOfExistingLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	l, err := Open(path)
+	require.NoError(t, err)
+	l.Log(Create, entry, "key-1", "", "")
+	require.NoError(t, l.Flush())
+
+	l, err = Open(path)
+	require.NoError(t, err)
+	l.Log(Create, entry, "key-2", "", "")
+	require.NoError(t, l.Flush())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	records := parseRecords(t, data)
+	require.Len(t, records, 2)
+	assert.Equal(t, records[0].Hash, records[1].PrevHash)
+	assert.NoError(t, Verify(bytes.NewReader(data)))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func Test_Open_RejectsFileThatIsNotAnAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("not json\n"), 0600))
+
+	_, err := Open(path)
+	assert.ErrorContains(t, err, "last line is not a valid audit record")
+}
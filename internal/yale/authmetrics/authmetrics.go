@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/broadinstitute/yale/internal/yale/logs"
 	"google.golang.org/api/iam/v1"
+	"strings"
 	"sync"
 	"time"
 
@@ -29,9 +30,10 @@ const lookbackWindow = time.Hour * 24 * 7
 // AuthMetrics returns the last time a service account key was used to authenticate
 type AuthMetrics interface {
 	// LastAuthTime returns the approximate last time a service account key was used to authenticate, based
-	// on data from the Cloud Metrics API.
+	// on data from the Cloud Metrics API. The service account may be given by email or by numeric unique ID;
+	// the latter still identifies it correctly if another service account has since taken its email.
 	// If the key has not been used to authenticate within the last 7 days, nil is returned
-	LastAuthTime(project string, serviceAccountEmail string, keyID string) (*time.Time, error)
+	LastAuthTime(project string, serviceAccount string, keyID string) (*time.Time, error)
 }
 
 func New(metricClient *monitoring.MetricClient, iam *iam.Service) AuthMetrics {
@@ -43,6 +45,7 @@ func newWithClients(metricClient *monitoring.MetricClient, iam *iam.Service, now
 	return &authMetrics{
 		mutex:        sync.Mutex{},
 		lastAuthMap:  make(map[string]map[string]time.Time),
+		uniqueIdMap:  make(map[string]map[string]string),
 		metricClient: metricClient,
 		iam:          iam,
 		now:          now,
@@ -52,12 +55,13 @@ func newWithClients(metricClient *monitoring.MetricClient, iam *iam.Service, now
 type authMetrics struct {
 	mutex        sync.Mutex
 	lastAuthMap  map[string]map[string]time.Time
+	uniqueIdMap  map[string]map[string]string
 	metricClient *monitoring.MetricClient
 	iam          *iam.Service
 	now          time.Time
 }
 
-func (a *authMetrics) LastAuthTime(project string, serviceAccountEmail string, keyID string) (*time.Time, error) {
+func (a *authMetrics) LastAuthTime(project string, serviceAccount string, keyID string) (*time.Time, error) {
 	a.mutex.Lock()
 	defer a.mutex.Unlock()
 
@@ -71,24 +75,37 @@ func (a *authMetrics) LastAuthTime(project string, serviceAccountEmail string, k
 		a.lastAuthMap[project] = m
 	}
 
-	lastAuthTime, exists := m[key(serviceAccountEmail, keyID)]
+	uniqueId := serviceAccount
+	if strings.Contains(serviceAccount, "@") {
+		ids, exists := a.uniqueIdMap[project]
+		if !exists {
+			ids, err = a.buildServiceAccountUniqueIdMap(project)
+			if err != nil {
+				return nil, fmt.Errorf("error building service account ID map for %s: %v", project, err)
+			}
+			a.uniqueIdMap[project] = ids
+		}
+		uniqueId, exists = ids[serviceAccount]
+		if !exists {
+			return nil, nil
+		}
+	}
+
+	lastAuthTime, exists := m[key(uniqueId, keyID)]
 	if !exists {
 		return nil, nil
 	}
 	return &lastAuthTime, nil
 }
 
-// for the given project, build a map of last authentication times for its service account keys
-// eg. { "service-account@project/abcdef0123456789abcdef0123456789": 2020-03-12T07:00:00Z }
-// if a key has not been authenticated within the history window, it will not be in the map
+// for the given project, build a map of last authentication times for its service account keys, keyed by
+// service account unique ID and key ID
+// eg. { "1234567890/abcdef0123456789abcdef0123456789": 2020-03-12T07:00:00Z }
+// if a key has not been authenticated within the history window, it will not be in the map. Keys of service
+// accounts that have since been deleted are included, since they are identified by unique ID rather than email
 //
 // ref https://cloud.google.com/monitoring/custom-metrics/reading-metrics#monitoring_read_timeseries_fields-go
 func (a *authMetrics) buildLastAuthMap(project string) (map[string]time.Time, error) {
-	serviceAccountIds, err := a.buildServiceAccountUniqueIdMap(project)
-	if err != nil {
-		return nil, fmt.Errorf("error building service account ID map for %s: %v", project, err)
-	}
-
 	lastAuthTimes := make(map[string]time.Time)
 
 	startWindow := a.now.UTC().Add(lookbackWindow * -1).Unix()
@@ -119,10 +136,6 @@ func (a *authMetrics) buildLastAuthMap(project string) (map[string]time.Time, er
 		if !exists {
 			return nil, fmt.Errorf("time series %s missing resource label: %s", metricType, uniqueIdLabel)
 		}
-		serviceAccountEmail, exists := serviceAccountIds[serviceAccountId]
-		if !exists {
-			return nil, fmt.Errorf("time series %s labeled with unknown service account id %s", metricType, serviceAccountId)
-		}
 
 		for _, p := range resp.GetPoints() {
 			startTime := p.Interval.StartTime.AsTime()
@@ -139,7 +152,7 @@ func (a *authMetrics) buildLastAuthMap(project string) (map[string]time.Time, er
 				continue
 			}
 
-			mkey := key(serviceAccountEmail, keyId)
+			mkey := key(serviceAccountId, keyId)
 			previousTime, exists := lastAuthTimes[mkey]
 			if !exists || endTime.After(previousTime) {
 				lastAuthTimes[mkey] = endTime
@@ -150,13 +163,13 @@ func (a *authMetrics) buildLastAuthMap(project string) (map[string]time.Time, er
 	return lastAuthTimes, nil
 }
 
-// for the given project, build a map of all its service account emails, keyed by unique ID
-// eg. { "1234567890": "service-account@project" }
+// for the given project, build a map of the unique IDs of all its service accounts, keyed by email
+// eg. { "service-account@project": "1234567890" }
 func (a *authMetrics) buildServiceAccountUniqueIdMap(project string) (map[string]string, error) {
 	m := make(map[string]string)
 	err := a.iam.Projects.ServiceAccounts.List("projects/"+project).Pages(context.Background(), func(response *iam.ListServiceAccountsResponse) error {
 		for _, account := range response.Accounts {
-			m[account.UniqueId] = account.Email
+			m[account.Email] = account.UniqueId
 		}
 		return nil
 	})
@@ -166,7 +179,7 @@ func (a *authMetrics) buildServiceAccountUniqueIdMap(project string) (map[string
 	return m, nil
 }
 
-// key combine sa email and id into a string key for use in lastAuthTime maps
-func key(serviceAccountEmail string, keyID string) string {
-	return serviceAccountEmail + "/" + keyID
+// key combine sa identifier and key id into a string key for use in lastAuthTime maps
+func key(serviceAccount string, keyID string) string {
+	return serviceAccount + "/" + keyID
 }
@@ -61,6 +61,16 @@ func Test_AuthMetrics(t *testing.T) {
 	lastAuth, err = am.LastAuthTime("broad-dsde-dev", "drshub-dev@broad-dsde-dev.iam.gserviceaccount.com", "key-does-not-exist")
 	require.NoError(t, err)
 	assert.Nil(t, lastAuth)
+
+	// service accounts can also be looked up by unique id
+	lastAuth, err = am.LastAuthTime("broad-dsde-dev", "107919014121556430200", "2ac28ba60e2683441fa01ba0909f814560f5f02a")
+	require.NoError(t, err)
+	require.NotNil(t, lastAuth)
+	assert.Equal(t, "2023-05-07 20:50:00 +0000 UTC", (*lastAuth).String(), "cromwell-carbonite-user by unique id")
+
+	lastAuth, err = am.LastAuthTime("broad-dsde-dev", "101170312944114045666", "2ac28ba60e2683441fa01ba0909f814560f5f02a")
+	require.NoError(t, err)
+	assert.Nil(t, lastAuth, "key belongs to a different service account")
 }
 
 func newAuthMetrics(t *testing.T, recordMode bool) *authMetrics {
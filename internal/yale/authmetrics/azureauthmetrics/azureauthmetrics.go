@@ -0,0 +1,131 @@
+package azureauthmetrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/broadinstitute/yale/internal/yale/authmetrics"
+	"github.com/broadinstitute/yale/internal/yale/logs"
+	"github.com/hashicorp/go-azure-sdk/sdk/odata"
+	"github.com/manicminer/hamilton/msgraph"
+)
+
+// lookbackWindow - how far back we should check for sign-ins
+const lookbackWindow = time.Hour * 24 * 7
+
+// signIn is the subset of a Microsoft Graph (beta) sign-in log entry that Yale needs. Hamilton's SignInReport
+// model does not include the ID of the credential a service principal signed in with, so we decode it ourselves.
+type signIn struct {
+	CreatedDateTime                 *time.Time `json:"createdDateTime,omitempty"`
+	ServicePrincipalCredentialKeyId *string    `json:"servicePrincipalCredentialKeyId,omitempty"`
+}
+
+// New returns an AuthMetrics that looks up the last time an Azure client secret was used to sign in, based on
+// the tenant's Entra ID sign-in logs. It reuses the authorizer of the given applications client.
+func New(applicationsClient *msgraph.ApplicationsClient) authmetrics.AuthMetrics {
+	if applicationsClient == nil {
+		return newWithClient(nil, time.Now())
+	}
+	client := msgraph.NewSignInReportsClient().BaseClient
+	client.Authorizer = applicationsClient.BaseClient.Authorizer
+	return newWithClient(&client, time.Now())
+}
+
+// package-private constructor for testing
+func newWithClient(client *msgraph.Client, now time.Time) *azAuthMetrics {
+	return &azAuthMetrics{
+		lastSignInMap: make(map[string]map[string]time.Time),
+		client:        client,
+		now:           now,
+	}
+}
+
+type azAuthMetrics struct {
+	mutex         sync.Mutex
+	lastSignInMap map[string]map[string]time.Time
+	client        *msgraph.Client
+	now           time.Time
+}
+
+// LastAuthTime returns the last time the client secret was used by the application to sign in. If the secret
+// has not been used to sign in within the last 7 days, or sign-in logs are unavailable (eg. because Yale lacks
+// permission to read them), nil is returned.
+func (a *azAuthMetrics) LastAuthTime(tenantID string, applicationID string, keyID string) (*time.Time, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.client == nil {
+		return nil, nil
+	}
+
+	var err error
+	m, exists := a.lastSignInMap[applicationID]
+	if !exists {
+		m, err = a.buildLastSignInMap(applicationID)
+		if err != nil {
+			return nil, fmt.Errorf("error building last sign-in map for client secrets of application %s in tenant %s: %v", applicationID, tenantID, err)
+		}
+		a.lastSignInMap[applicationID] = m
+	}
+
+	lastSignIn, exists := m[keyID]
+	if !exists {
+		return nil, nil
+	}
+	return &lastSignIn, nil
+}
+
+// for the given application, build a map of last service principal sign-in times, keyed by the ID of the client
+// secret used to sign in. If sign-in logs are unavailable, an empty map is returned.
+func (a *azAuthMetrics) buildLastSignInMap(applicationID string) (map[string]time.Time, error) {
+	lastSignIns := make(map[string]time.Time)
+
+	startWindow := a.now.UTC().Add(lookbackWindow * -1).Format(time.RFC3339)
+	resp, status, _, err := a.client.Get(context.Background(), msgraph.GetHttpRequestInput{
+		OData: odata.Query{
+			Filter: fmt.Sprintf("appId eq '%s' and createdDateTime ge %s and signInEventTypes/any(t: t eq 'servicePrincipal')", applicationID, startWindow),
+		},
+		ValidStatusCodes: []int{http.StatusOK},
+		Uri: msgraph.Uri{
+			Entity: "/auditLogs/signIns",
+		},
+	})
+	if status == http.StatusUnauthorized || status == http.StatusForbidden {
+		logs.Warn.Printf("sign-in logs are unavailable for application %s (status %d), so client secret usage is unknown: %v", applicationID, status, err)
+		return lastSignIns, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error listing sign-in logs: %v", err)
+	}
+
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading sign-in logs: %v", err)
+	}
+
+	var data struct {
+		SignIns []signIn `json:"value"`
+	}
+	if err = json.Unmarshal(respBody, &data); err != nil {
+		return nil, fmt.Errorf("error decoding sign-in logs: %v", err)
+	}
+
+	for _, s := range data.SignIns {
+		if s.ServicePrincipalCredentialKeyId == nil || s.CreatedDateTime == nil {
+			continue
+		}
+		keyID := *s.ServicePrincipalCredentialKeyId
+		previousTime, exists := lastSignIns[keyID]
+		if !exists || s.CreatedDateTime.After(previousTime) {
+			lastSignIns[keyID] = *s.CreatedDateTime
+		}
+	}
+
+	return lastSignIns, nil
+}
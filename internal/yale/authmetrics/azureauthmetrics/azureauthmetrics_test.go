@@ -0,0 +1,99 @@
+package azureauthmetrics
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/manicminer/hamilton/msgraph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTenantID = "fake-tenant-id"
+const testApplicationID = "asdf-asdf-asdfa-asdf-asdf"
+const signInsURL = "https://graph.microsoft.com/beta/auditLogs/signIns"
+
+var now = time.Date(2024, 5, 8, 12, 0, 0, 0, time.UTC)
+
+func Test_LastAuthTimeReturnsMostRecentSignInForKey(t *testing.T) {
+	am := setup(t, http.StatusOK, map[string]interface{}{
+		"value": []map[string]interface{}{
+			{"createdDateTime": "2024-05-07T10:00:00Z", "appId": testApplicationID, "servicePrincipalCredentialKeyId": "key-1"},
+			{"createdDateTime": "2024-05-06T10:00:00Z", "appId": testApplicationID, "servicePrincipalCredentialKeyId": "key-2"},
+			{"createdDateTime": "2024-05-05T10:00:00Z", "appId": testApplicationID, "servicePrincipalCredentialKeyId": "key-1"},
+			{"createdDateTime": "2024-05-04T10:00:00Z", "appId": testApplicationID},
+		},
+	})
+
+	lastAuth, err := am.LastAuthTime(testTenantID, testApplicationID, "key-1")
+	require.NoError(t, err)
+	require.NotNil(t, lastAuth)
+	assert.Equal(t, time.Date(2024, 5, 7, 10, 0, 0, 0, time.UTC), lastAuth.UTC())
+
+	lastAuth, err = am.LastAuthTime(testTenantID, testApplicationID, "key-2")
+	require.NoError(t, err)
+	require.NotNil(t, lastAuth)
+	assert.Equal(t, time.Date(2024, 5, 6, 10, 0, 0, 0, time.UTC), lastAuth.UTC())
+
+	lastAuth, err = am.LastAuthTime(testTenantID, testApplicationID, "key-3")
+	require.NoError(t, err)
+	assert.Nil(t, lastAuth, "keys without sign-ins in the lookback window should have unknown usage")
+
+	// sign-in logs are only listed once per application
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+}
+
+func Test_LastAuthTimeReturnsNilIfSignInLogsAreUnavailable(t *testing.T) {
+	am := setup(t, http.StatusForbidden, map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":    "Authentication_RequestFromNonPremiumTenantOrB2CTenant",
+			"message": "Neither tenant is B2C or tenant doesn't have premium license",
+		},
+	})
+
+	lastAuth, err := am.LastAuthTime(testTenantID, testApplicationID, "key-1")
+	require.NoError(t, err)
+	assert.Nil(t, lastAuth)
+}
+
+func Test_LastAuthTimeReturnsErrorOnServerError(t *testing.T) {
+	am := setup(t, http.StatusInternalServerError, map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":    "InternalServerError",
+			"message": "uh-oh",
+		},
+	})
+
+	_, err := am.LastAuthTime(testTenantID, testApplicationID, "key-1")
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "error listing sign-in logs")
+}
+
+func Test_LastAuthTimeReturnsNilWithoutClient(t *testing.T) {
+	am := New(nil)
+
+	lastAuth, err := am.LastAuthTime(testTenantID, testApplicationID, "key-1")
+	require.NoError(t, err)
+	assert.Nil(t, lastAuth)
+}
+
+// setup returns an azAuthMetrics whose Graph client receives the given response for sign-in log requests
+func setup(t *testing.T, status int, response interface{}) *azAuthMetrics {
+	httpClient := &http.Client{}
+	client := msgraph.NewSignInReportsClient().BaseClient
+	client.HttpClient = httpClient
+	client.DisableRetries = true
+
+	httpmock.ActivateNonDefault(httpClient)
+	t.Cleanup(httpmock.DeactivateAndReset)
+
+	httpmock.RegisterResponder(http.MethodGet, signInsURL, func(req *http.Request) (*http.Response, error) {
+		assert.Contains(t, req.URL.Query().Get("$filter"), "appId eq '"+testApplicationID+"'")
+		assert.Contains(t, req.URL.Query().Get("$filter"), "createdDateTime ge 2024-05-01T12:00:00Z")
+		return httpmock.NewJsonResponse(status, response)
+	})
+
+	return newWithClient(&client, now)
+}
@@ -0,0 +1,51 @@
+package authmetrics
+
+import (
+	"sync"
+	"time"
+)
+
+// Memoized wraps an AuthMetrics, caching the result of every successful LastAuthTime lookup so that repeated
+// lookups for the same key don't call the underlying API again. Errors are not cached. Call Reset to clear
+// the cache, eg. at the start of every Yale run.
+type Memoized struct {
+	inner   AuthMetrics
+	mutex   sync.Mutex
+	results map[string]*time.Time
+}
+
+// NewMemoized returns a Memoized that caches lookups from the given AuthMetrics
+func NewMemoized(inner AuthMetrics) *Memoized {
+	return &Memoized{
+		inner:   inner,
+		results: make(map[string]*time.Time),
+	}
+}
+
+func (m *Memoized) LastAuthTime(scope string, identifier string, keyID string) (*time.Time, error) {
+	cacheKey := scope + "/" + key(identifier, keyID)
+
+	m.mutex.Lock()
+	result, exists := m.results[cacheKey]
+	m.mutex.Unlock()
+	if exists {
+		return result, nil
+	}
+
+	result, err := m.inner.LastAuthTime(scope, identifier, keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	m.results[cacheKey] = result
+	m.mutex.Unlock()
+	return result, nil
+}
+
+// Reset clears all cached lookups
+func (m *Memoized) Reset() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.results = make(map[string]*time.Time)
+}
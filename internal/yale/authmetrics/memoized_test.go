@@ -0,0 +1,55 @@
+package authmetrics
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingAuthMetrics records how many times each key was looked up
+type countingAuthMetrics struct {
+	calls  map[string]int
+	result *time.Time
+	err    error
+}
+
+func (c *countingAuthMetrics) LastAuthTime(scope string, identifier string, keyID string) (*time.Time, error) {
+	c.calls[scope+"/"+key(identifier, keyID)]++
+	return c.result, c.err
+}
+
+func Test_MemoizedCachesLookupsUntilReset(t *testing.T) {
+	lastAuth := time.Date(2024, 5, 8, 12, 0, 0, 0, time.UTC)
+	inner := &countingAuthMetrics{calls: make(map[string]int), result: &lastAuth}
+	m := NewMemoized(inner)
+
+	for i := 0; i < 3; i++ {
+		result, err := m.LastAuthTime("my-project", "my-sa@p.com", "key-1")
+		require.NoError(t, err)
+		assert.Equal(t, &lastAuth, result)
+	}
+	_, err := m.LastAuthTime("other-project", "my-sa@p.com", "key-1")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, inner.calls["my-project/"+key("my-sa@p.com", "key-1")])
+	assert.Equal(t, 1, inner.calls["other-project/"+key("my-sa@p.com", "key-1")])
+
+	m.Reset()
+	_, err = m.LastAuthTime("my-project", "my-sa@p.com", "key-1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, inner.calls["my-project/"+key("my-sa@p.com", "key-1")])
+}
+
+func Test_MemoizedDoesNotCacheErrors(t *testing.T) {
+	inner := &countingAuthMetrics{calls: make(map[string]int), err: fmt.Errorf("uh-oh")}
+	m := NewMemoized(inner)
+
+	for i := 0; i < 2; i++ {
+		_, err := m.LastAuthTime("my-project", "my-sa@p.com", "key-1")
+		require.Error(t, err)
+	}
+	assert.Equal(t, 2, inner.calls["my-project/"+key("my-sa@p.com", "key-1")])
+}
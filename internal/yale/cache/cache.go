@@ -1,13 +1,9 @@
 package cache
 
 import (
-	"context"
 	"fmt"
 
 	"github.com/broadinstitute/yale/internal/yale/logs"
-	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -18,58 +14,103 @@ const DefaultCacheNamespace = "yale-cache"
 const labelKey = "yale.terra.bio/cache-entry"
 const labelValue = "true"
 
-// key within the secret where marshaled cache entry data is stored
+// key within the secret where marshaled cache entry data was stored by older versions of Yale,
+// before entries were compressed. Still read for backwards compatibility.
 const secretKey = "value"
 
+// key within the secret where gzip-compressed, marshaled cache entry data is stored
+const compressedSecretKey = "value.gz"
+
+// K8sBackend stores cache entries as Secrets in a K8s namespace
+const K8sBackend = "k8s"
+
+// GSMBackend stores cache entries as secrets in a Google Secret Manager project
+const GSMBackend = "gsm"
+
 // prefix for cache entry secret names
 const secretNamePrefix = "yale-cache-"
 
 type Cache interface {
-	// List returns all cache entries in the cache namespace
+	// List returns all cache entries in the store
 	List() ([]*Entry, error)
 	// GetOrCreate will retrieve the cache entry for the given service account, or create a new empty
 	// cache entry if one doesn't exist
 	GetOrCreate(Identifier) (*Entry, error)
-	// Save persists a cache entry to the cluster
+	// Save persists a cache entry to the store
 	Save(*Entry) error
-	// Delete deletes a cache entry from the cluster
+	// Delete deletes a cache entry from the store
 	Delete(*Entry) error
 }
 
-func New(k8s kubernetes.Interface, namespace string) Cache {
+// Options configures how cache entries are stored
+type Options struct {
+	// ShardThreshold size, in bytes, of compressed cache entry data above which the entry is split across
+	// multiple secrets
+	ShardThreshold int
+	// KMSKeyName resource name of the KMS key used to encrypt cache entries at rest
+	// (projects/<project>/locations/<location>/keyRings/<ring>/cryptoKeys/<key>). If empty, entries are
+	// written in plaintext.
+	KMSKeyName string
+	// KMS client used to wrap and unwrap data encryption keys. Required to write entries if KMSKeyName is
+	// set, and to read entries that were written encrypted.
+	KMS KMS
+}
+
+type Option func(*Options)
+
+// New returns a Cache that stores entries as Secrets in the given namespace
+func New(k8s kubernetes.Interface, namespace string, options ...Option) Cache {
+	return NewWithStore(NewK8sStore(k8s, namespace), options...)
+}
+
+// NewWithStore returns a Cache that stores entries in the given Store
+func NewWithStore(store Store, options ...Option) Cache {
+	opts := Options{
+		ShardThreshold: DefaultShardThreshold,
+	}
+	if _, isGSM := store.(*gsmStore); isGSM {
+		opts.ShardThreshold = GSMShardThreshold
+	}
+	for _, option := range options {
+		option(&opts)
+	}
 	return &cache{
-		namespace: namespace,
-		k8s:       k8s,
+		store:   store,
+		options: opts,
 	}
 }
 
 type cache struct {
-	namespace string
-	k8s       kubernetes.Interface
+	store   Store
+	options Options
 }
 
 func (c *cache) List() ([]*Entry, error) {
-	resp, err := c.k8s.CoreV1().Secrets(c.namespace).List(context.Background(), metav1.ListOptions{
-		LabelSelector: labelSelector(),
-	})
+	records, err := c.store.List(labelKey, labelValue)
 	if err != nil {
-		return nil, fmt.Errorf("error listing secrets in namespace %s: %v", c.namespace, err)
+		return nil, fmt.Errorf("error listing cache entries: %v", err)
 	}
 
 	var entries []*Entry
-	for _, secret := range resp.Items {
+	for _, record := range records {
+		if err = c.reassembleShards(record); err != nil {
+			return nil, err
+		}
+		if err = c.decrypt(record); err != nil {
+			return nil, err
+		}
 		entry := &Entry{}
-		if err = entry.unmarshalFromSecret(&secret); err != nil {
-			return nil, fmt.Errorf("error unmarshaling cache entry secret %s: %v", secret.Name, err)
+		if err = entry.unmarshalFromRecord(record); err != nil {
+			return nil, fmt.Errorf("error unmarshaling cache entry secret %s: %v", record.Name, err)
 		}
 		if entry.Identify() == "" {
-			return nil, fmt.Errorf("invalid cache entry secret %s: missing cache entry identifier (service account email or Application ID)", secret.Name)
+			return nil, fmt.Errorf("invalid cache entry secret %s: missing cache entry identifier (service account email or Application ID)", record.Name)
 		}
 		if entry.Scope() == "" {
-			return nil, fmt.Errorf("invalid cache entry secret %s: missing cache entry identifier scope (google project or Azure Tenant ID)", secret.Name)
+			return nil, fmt.Errorf("invalid cache entry secret %s: missing cache entry identifier scope (google project or Azure Tenant ID)", record.Name)
 		}
-		if secret.Name != entry.cacheSecretName() {
-			return nil, fmt.Errorf("invalid cache entry secret %s: secret name does not match service account, should be %s", secret.Name, entry.cacheSecretName())
+		if record.Name != entry.cacheSecretName() {
+			return nil, fmt.Errorf("invalid cache entry secret %s: secret name does not match service account, should be %s", record.Name, entry.cacheSecretName())
 		}
 		entries = append(entries, entry)
 	}
@@ -78,20 +119,28 @@ func (c *cache) List() ([]*Entry, error) {
 }
 
 func (c *cache) GetOrCreate(identifier Identifier) (*Entry, error) {
-	secret, err := c.k8s.CoreV1().Secrets(c.namespace).Get(context.Background(), identifier.cacheSecretName(), metav1.GetOptions{})
+	record, err := c.store.Get(identifier.cacheSecretName())
 	if err != nil {
-		if !errors.IsNotFound(err) {
-			return nil, fmt.Errorf("error checking for existing cache entry for service account %s: %v", identifier.Identify(), err)
-		}
-
-		logs.Info.Printf("secret %s does not exist in cache namespace %s, creating new cache entry for %s", identifier.cacheSecretName(), c.namespace, identifier.Identify())
+		return nil, fmt.Errorf("error checking for existing cache entry for service account %s: %v", identifier.Identify(), err)
+	}
+	if record == nil {
+		logs.Info.Printf("cache entry secret %s does not exist, creating new cache entry for %s", identifier.cacheSecretName(), identifier.Identify())
 		return c.createAndSaveNewEmptyCacheEntry(identifier)
 	}
+	if err = checkIsCacheRecord(record); err != nil {
+		return nil, fmt.Errorf("error reading cache entry for %s: %v", identifier.Identify(), err)
+	}
 
+	if err = c.reassembleShards(record); err != nil {
+		return nil, err
+	}
+	if err = c.decrypt(record); err != nil {
+		return nil, err
+	}
 	var entry Entry
-	err = (&entry).unmarshalFromSecret(secret)
+	err = (&entry).unmarshalFromRecord(record)
 	if err != nil {
-		return nil, fmt.Errorf("error unmarshalling cache entry secret %s: %v", secret.Name, err)
+		return nil, fmt.Errorf("error unmarshalling cache entry secret %s: %v", record.Name, err)
 	}
 	return &entry, nil
 }
@@ -100,46 +149,89 @@ func (c *cache) Save(entry *Entry) error {
 	identifier := entry.Identify()
 	secretName := entry.cacheSecretName()
 
-	secret, err := c.k8s.CoreV1().Secrets(c.namespace).Get(context.Background(), secretName, metav1.GetOptions{})
+	record, err := c.store.Get(secretName)
 	if err != nil {
 		return fmt.Errorf("error reading existing cache entry for %s: %v", identifier, err)
 	}
-	if err = entry.marshalToSecret(secret); err != nil {
-		return fmt.Errorf("error marshalling cache entry for %s to secret: %v", identifier, err)
+	if record == nil {
+		return fmt.Errorf("error reading existing cache entry for %s: cache entry secret %s does not exist", identifier, secretName)
+	}
+	if err = checkIsCacheRecord(record); err != nil {
+		return fmt.Errorf("error saving cache entry for %s: %v", identifier, err)
 	}
-	_, err = c.k8s.CoreV1().Secrets(c.namespace).Update(context.Background(), secret, metav1.UpdateOptions{})
+	previousShards, err := shardCount(record)
 	if err != nil {
+		return err
+	}
+	if err = entry.marshalToRecord(record); err != nil {
+		return fmt.Errorf("error marshalling cache entry for %s to secret: %v", identifier, err)
+	}
+	if err = c.encrypt(record); err != nil {
+		return fmt.Errorf("error encrypting cache entry for %s: %v", identifier, err)
+	}
+	// write shards before the record that references them, so the entry can always be reassembled
+	shards := c.splitIntoShards(record)
+	if err = c.writeShards(shards); err != nil {
+		return fmt.Errorf("error writing cache entry shards for %s: %v", identifier, err)
+	}
+	if err = c.store.Put(record); err != nil {
 		return fmt.Errorf("error updating existing cache entry for %s: %v", identifier, err)
 	}
+	if err = c.deleteShards(secretName, len(shards), previousShards); err != nil {
+		return fmt.Errorf("error cleaning up orphaned cache entry shards for %s: %v", identifier, err)
+	}
 	return nil
 }
 
 func (c *cache) Delete(entry *Entry) error {
-	if err := c.k8s.CoreV1().Secrets(c.namespace).Delete(context.Background(), entry.cacheSecretName(), metav1.DeleteOptions{}); err != nil {
+	record, err := c.store.Get(entry.cacheSecretName())
+	if err != nil {
+		return fmt.Errorf("error reading cache entry secret %s for %s: %v", entry.cacheSecretName(), entry.Identify(), err)
+	}
+	if record != nil {
+		if err = checkIsCacheRecord(record); err != nil {
+			return fmt.Errorf("error deleting cache entry for %s: %v", entry.Identify(), err)
+		}
+		shards, err := shardCount(record)
+		if err != nil {
+			return err
+		}
+		if err = c.deleteShards(record.Name, 0, shards); err != nil {
+			return fmt.Errorf("error deleting cache entry shards for %s: %v", entry.Identify(), err)
+		}
+	}
+	if err := c.store.Delete(entry.cacheSecretName()); err != nil {
 		return fmt.Errorf("error deleting cache entry secret %s for %s: %v", entry.cacheSecretName(), entry.Identify(), err)
 	}
 	return nil
 }
 
-// create a new empty cache entry and save it to the cluster
+// checkIsCacheRecord returns an error if the record is not labeled as a cache entry, which means an unrelated
+// secret that happens to have the same name as the cache entry lives in the cache namespace. Yale refuses to
+// read, overwrite, or delete it rather than clobbering the other secret.
+func checkIsCacheRecord(record *Record) error {
+	if record.Labels[labelKey] != labelValue {
+		return fmt.Errorf("secret %s exists but does not have the %s=%s label, so it is not a Yale cache entry; refusing to use it. Rename the secret, or move it out of the cache namespace", record.Name, labelKey, labelValue)
+	}
+	return nil
+}
+
+// create a new empty cache entry and save it to the store
 func (c *cache) createAndSaveNewEmptyCacheEntry(identifier Identifier) (*Entry, error) {
 	logs.Info.Printf("creating new cache entry for %s", identifier.Identify())
 	entry := newCacheEntry(identifier)
 
-	var secret corev1.Secret
-	if err := entry.marshalToSecret(&secret); err != nil {
+	var record Record
+	if err := entry.marshalToRecord(&record); err != nil {
 		return nil, fmt.Errorf("error marshalling cache entry for %s to secret: %v", identifier.Identify(), err)
 	}
-	logs.Info.Printf("saving new empty cache entry for %s to secret %s in %s", identifier.Identify(), secret.Name, c.namespace)
-	_, err := c.k8s.CoreV1().Secrets(c.namespace).Create(context.Background(), &secret, metav1.CreateOptions{})
-	if err != nil {
-		return nil, fmt.Errorf("error saving cache entry for %s to secret %s in %s: %v", identifier.Identify(), secret.Name, c.namespace, err)
+	if err := c.encrypt(&record); err != nil {
+		return nil, fmt.Errorf("error encrypting cache entry for %s: %v", identifier.Identify(), err)
+	}
+	logs.Info.Printf("saving new empty cache entry for %s to secret %s", identifier.Identify(), record.Name)
+	if err := c.store.Put(&record); err != nil {
+		return nil, fmt.Errorf("error saving cache entry for %s to secret %s: %v", identifier.Identify(), record.Name, err)
 	}
 
 	return entry, nil
 }
-
-// labelSelector returns a label selector that will match all CacheEntries in a namespace
-func labelSelector() string {
-	return labelKey + "=" + labelValue
-}
@@ -65,14 +65,14 @@ func Test_Cache(t *testing.T) {
 
 	// make sure the underlying secret was created with the attributes we expect
 	secret = readCacheSecret(t, k8s, sa1.cacheSecretName())
-	fmt.Printf("%+v", string(secret.Data[secretKey]))
 	require.NotNil(t, secret)
 	expectedContent, err := json.Marshal(expected)
 	require.NoError(t, err)
 	assert.Equal(t, sa1.cacheSecretName(), secret.Name)
 	assert.Equal(t, namespace, secret.Namespace)
 	assert.Equal(t, labelValue, secret.Labels[labelKey])
-	assert.Equal(t, string(expectedContent), string(secret.Data[secretKey]))
+	assert.Equal(t, string(expectedContent), string(decompressSecretData(t, secret)))
+	assert.NotContains(t, secret.Data, secretKey)
 
 	// reading the entry again should yield a copy of the entry with identical data
 	entryCopy, err := cache.GetOrCreate(sa1)
@@ -90,6 +90,15 @@ func Test_Cache(t *testing.T) {
 	entry.RotatedKeys["key-3"] = now
 	entry.DisabledKeys["key-4"] = now
 	entry.SyncStatus["my-ns/my-gsk"] = "my-sha256-sum:key-1"
+	entry.LastSuccessfulRun = now
+	entry.RotationWarningAt = now
+	entry.RotatedKeysAlertAt = now
+	entry.RecordKeyEvent("key-1", KeyIssued, now)
+	entry.SyncedDestinations["my-ns/my-gsk"] = []SyncedDestination{
+		{Backend: VaultDestination, Path: "secret/foo", EngineVersion: 2},
+		{Backend: GSMDestination, Project: "my-project", Secret: "my-secret"},
+	}
+	entry.SyncedSecrets = map[string][]string{"my-ns/my-gsk": {"my-ns/my-secret"}}
 
 	require.NoError(t, cache.Save(entry))
 
@@ -101,6 +110,12 @@ func Test_Cache(t *testing.T) {
 	assert.Equal(t, now, entry.RotatedKeys["key-3"])
 	assert.Equal(t, now, entry.DisabledKeys["key-4"])
 	assert.Equal(t, "my-sha256-sum:key-1", entry.SyncStatus["my-ns/my-gsk"])
+	assert.Equal(t, now, entry.LastSuccessfulRun)
+	assert.Equal(t, now, entry.RotationWarningAt)
+	assert.Equal(t, now, entry.RotatedKeysAlertAt)
+	assert.Equal(t, []KeyEvent{{KeyID: "key-1", Type: KeyIssued, Timestamp: now}}, entry.History)
+	assert.Len(t, entry.SyncedDestinations["my-ns/my-gsk"], 2)
+	assert.Equal(t, []string{"my-ns/my-secret"}, entry.SyncedSecrets["my-ns/my-gsk"])
 
 	// reading the entry again should yield a copy of the entry with identical data
 	entryCopy, err = cache.GetOrCreate(sa1)
@@ -213,14 +228,14 @@ func Test_cacheWithAzClientSecrets(t *testing.T) {
 
 	// make sure the underlying secret was created with the attributes we expect
 	secret = readCacheSecret(t, k8s, azClientSecret1.cacheSecretName())
-	fmt.Printf("%+v", string(secret.Data[secretKey]))
 	require.NotNil(t, secret)
 	expectedContent, err := json.Marshal(expected)
 	require.NoError(t, err)
 	assert.Equal(t, azClientSecret1.cacheSecretName(), secret.Name)
 	assert.Equal(t, namespace, secret.Namespace)
 	assert.Equal(t, labelValue, secret.Labels[labelKey])
-	assert.Equal(t, string(expectedContent), string(secret.Data[secretKey]))
+	assert.Equal(t, string(expectedContent), string(decompressSecretData(t, secret)))
+	assert.NotContains(t, secret.Data, secretKey)
 
 	// reading the entry again should yield a copy of the entry with identical data
 	entryCopy, err := cache.GetOrCreate(azClientSecret1)
@@ -340,8 +355,163 @@ func Test_cacheWithAzClientSecrets(t *testing.T) {
 	assert.ErrorContains(t, err, "missing cache entry identifier")
 }
 
+func Test_cacheReadsLegacyUncompressedEntries(t *testing.T) {
+	k8s := testutils.NewFakeK8sClient(t)
+	cache := New(k8s, namespace)
+
+	// create a cache entry the way older versions of Yale did, with uncompressed JSON under the legacy key
+	expected := emptyCacheEntry(sa1)
+	expected.CurrentKey.ID = "my-key-id"
+	expected.SyncStatus["my-secret/my-namespace"] = "my-hash"
+	legacyContent, err := json.Marshal(expected)
+	require.NoError(t, err)
+	_, err = k8s.CoreV1().Secrets(namespace).Create(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: sa1.cacheSecretName(),
+			Labels: map[string]string{
+				labelKey: labelValue,
+			},
+		},
+		Data: map[string][]byte{
+			secretKey: legacyContent,
+		},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	entry, err := cache.GetOrCreate(sa1)
+	require.NoError(t, err)
+	assert.Equal(t, &expected, entry)
+
+	entries, err := cache.List()
+	require.NoError(t, err)
+	assert.Equal(t, []*Entry{&expected}, entries)
+
+	// saving the entry should migrate it to the compressed key
+	require.NoError(t, cache.Save(entry))
+	secret := readCacheSecret(t, k8s, sa1.cacheSecretName())
+	require.NotNil(t, secret)
+	assert.NotContains(t, secret.Data, secretKey)
+	assert.Equal(t, string(legacyContent), string(decompressSecretData(t, secret)))
+}
+
+func Test_cacheRefusesToUseSecretsThatAreNotCacheEntries(t *testing.T) {
+	k8s := testutils.NewFakeK8sClient(t)
+	cache := New(k8s, namespace)
+
+	// an app secret that happens to have the same name as sa1's cache entry
+	_, err := k8s.CoreV1().Secrets(namespace).Create(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   sa1.cacheSecretName(),
+			Labels: map[string]string{"app": "my-app"},
+		},
+		Data: map[string][]byte{"password": []byte("hunter2")},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	_, err = cache.GetOrCreate(sa1)
+	assert.ErrorContains(t, err, fmt.Sprintf("secret %s exists but does not have the %s=%s label, so it is not a Yale cache entry", sa1.cacheSecretName(), labelKey, labelValue))
+
+	entry := emptyCacheEntry(sa1)
+	entry.CurrentKey.ID = "key-1"
+	err = cache.Save(&entry)
+	assert.ErrorContains(t, err, "is not a Yale cache entry; refusing to use it")
+
+	err = cache.Delete(&entry)
+	assert.ErrorContains(t, err, "is not a Yale cache entry; refusing to use it")
+
+	// the app secret was left alone
+	secret := readCacheSecret(t, k8s, sa1.cacheSecretName())
+	require.NotNil(t, secret)
+	assert.Equal(t, map[string]string{"app": "my-app"}, secret.Labels)
+	assert.Equal(t, map[string][]byte{"password": []byte("hunter2")}, secret.Data)
+
+	// entries whose names don't collide are unaffected
+	_, err = cache.GetOrCreate(sa2)
+	require.NoError(t, err)
+}
+
+func Test_cacheCompressesLargeEntries(t *testing.T) {
+	k8s := testutils.NewFakeK8sClient(t)
+	cache := New(k8s, namespace)
+
+	entry, err := cache.GetOrCreate(sa1)
+	require.NoError(t, err)
+
+	// synthesize a large entry, with lots of repetitive sync status and destination data
+	for i := 0; i < 5000; i++ {
+		key := fmt.Sprintf("my-secret-%d/my-namespace-%d", i, i)
+		entry.SyncStatus[key] = fmt.Sprintf("sha256-hash-of-spec-number-%d", i)
+		entry.SyncedDestinations[key] = []SyncedDestination{
+			{Backend: VaultDestination, Path: fmt.Sprintf("secret/dsde/my-app/%d/sa-key.json", i)},
+			{Backend: GSMDestination, Project: project, Secret: fmt.Sprintf("my-gsm-secret-%d", i)},
+		}
+	}
+	for i := 0; i < 500; i++ {
+		entry.RotatedKeys[fmt.Sprintf("rotated-key-%d", i)] = time.Date(2024, 1, 1, 0, 0, i, 0, time.UTC)
+	}
+	require.NoError(t, cache.Save(entry))
+
+	content, err := json.Marshal(entry)
+	require.NoError(t, err)
+
+	secret := readCacheSecret(t, k8s, sa1.cacheSecretName())
+	require.NotNil(t, secret)
+	compressed := secret.Data[compressedSecretKey]
+	assert.Greater(t, len(content), 1024*1024, "synthetic entry should be larger than the 1MB secret limit")
+	assert.Less(t, len(compressed), 1024*1024, "compressed entry should fit in a secret")
+	assert.Less(t, len(compressed)*5, len(content), "compression should reduce the entry size substantially")
+
+	// reading the entry back should yield identical data
+	entryCopy, err := cache.GetOrCreate(sa1)
+	require.NoError(t, err)
+	assert.Equal(t, entry, entryCopy)
+}
+
 func Test_cacheSecretName(t *testing.T) {
 	assert.Equal(t, "yale-cache-my-sa1-p.com", sa1.cacheSecretName())
+	assert.Equal(t, "yale-cache-aws-123456789012-legacy-tooling-p.com", AwsIamUserEntryIdentifier{
+		UserName:  "Legacy-Tooling@p.com",
+		AccountID: "123456789012",
+	}.cacheSecretName())
+}
+
+func Test_cacheWithAwsIamUsers(t *testing.T) {
+	k8s := testutils.NewFakeK8sClient(t)
+	cache := New(k8s, namespace)
+	identifier := AwsIamUserEntryIdentifier{
+		UserName:  "legacy-tooling",
+		AccountID: "123456789012",
+	}
+
+	entry, err := cache.GetOrCreate(identifier)
+	require.NoError(t, err)
+	assert.Equal(t, AwsIamAccessKey, entry.Type)
+	assert.Equal(t, "AwsIamAccessKey", entry.Type.String())
+	assert.Equal(t, "legacy-tooling", entry.Identify())
+	assert.Equal(t, "123456789012", entry.Scope())
+
+	entry.CurrentKey.ID = "AKIA1"
+	entry.CurrentKey.JSON = `{"Version": 1, "AccessKeyId": "AKIA1", "SecretAccessKey": "secret"}`
+	require.NoError(t, cache.Save(entry))
+
+	entries, err := cache.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, entry, entries[0])
+	assert.Equal(t, identifier, entries[0].Identifier)
+}
+
+func Test_RecordKeyEventPrunesOldestEvents(t *testing.T) {
+	entry := newCacheEntry(GcpSaKeyEntryIdentifier{Email: "my-sa@p.com", Project: "p"})
+	start := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < MaxHistory+5; i++ {
+		entry.RecordKeyEvent(fmt.Sprintf("key-%d", i), KeyIssued, start.Add(time.Duration(i)*time.Hour))
+	}
+
+	require.Len(t, entry.History, MaxHistory)
+	assert.Equal(t, "key-5", entry.History[0].KeyID)
+	assert.Equal(t, fmt.Sprintf("key-%d", MaxHistory+4), entry.History[MaxHistory-1].KeyID)
 }
 
 func readCacheSecret(t *testing.T, k8s kubernetes.Interface, name string) *corev1.Secret {
@@ -353,6 +523,15 @@ func readCacheSecret(t *testing.T, k8s kubernetes.Interface, name string) *corev
 	return secret
 }
 
+// decompressSecretData returns the uncompressed cache entry JSON stored in the secret
+func decompressSecretData(t *testing.T, secret *corev1.Secret) []byte {
+	compressed, exists := secret.Data[compressedSecretKey]
+	require.True(t, exists, "secret %s should have a %q key", secret.Name, compressedSecretKey)
+	data, err := decompress(compressed)
+	require.NoError(t, err)
+	return data
+}
+
 // represents the expected initial state of a new/empty cache entry
 func emptyCacheEntry(identifier Identifier) Entry {
 	return Entry{
@@ -364,8 +543,9 @@ func emptyCacheEntry(identifier Identifier) Entry {
 			CreatedAt time.Time
 		}{},
 		// we expect _empty_ maps, not nil maps
-		RotatedKeys:  map[string]time.Time{},
-		DisabledKeys: map[string]time.Time{},
-		SyncStatus:   map[string]string{},
+		RotatedKeys:        map[string]time.Time{},
+		DisabledKeys:       map[string]time.Time{},
+		SyncStatus:         map[string]string{},
+		SyncedDestinations: map[string][]SyncedDestination{},
 	}
 }
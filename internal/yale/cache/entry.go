@@ -1,13 +1,16 @@
 package cache
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
+	"io"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/broadinstitute/yale/internal/yale/logs"
-	corev1 "k8s.io/api/core/v1"
 )
 
 // only lower alphanumeric, ., and - are legal in the names of k8s resources
@@ -28,6 +31,9 @@ type Identifier interface {
 type GcpSaKeyEntryIdentifier struct {
 	Email   string
 	Project string
+	// UniqueID numeric unique ID of the service account, if known. Used to tell apart
+	// service accounts that were deleted and recreated with the same email.
+	UniqueID string `json:",omitempty"`
 }
 
 func (gcpIdentifier GcpSaKeyEntryIdentifier) Identify() string {
@@ -73,6 +79,30 @@ func (azureIdentifier AzureClientSecretEntryIdentifier) cacheSecretName() string
 	return secretNamePrefix + normalized
 }
 
+type AwsIamUserEntryIdentifier struct {
+	UserName  string
+	AccountID string
+}
+
+func (awsIdentifier AwsIamUserEntryIdentifier) Identify() string {
+	return awsIdentifier.UserName
+}
+
+func (awsIdentifier AwsIamUserEntryIdentifier) Scope() string {
+	return awsIdentifier.AccountID
+}
+
+func (AwsIamUserEntryIdentifier) Type() EntryType {
+	return AwsIamAccessKey
+}
+
+func (awsIdentifier AwsIamUserEntryIdentifier) cacheSecretName() string {
+	// IAM user names are only unique within an account, and may look like emails, so include the account ID
+	// to avoid colliding with other IAM users or GCP service accounts
+	normalized := illegalK8sNameCharsRegexp.ReplaceAllString(strings.ToLower(awsIdentifier.UserName), "-")
+	return secretNamePrefix + "aws-" + awsIdentifier.AccountID + "-" + normalized
+}
+
 // LastError information relating to the last error that occurred while processing this cache entry/service account
 type LastError struct {
 	// Message is the last error message
@@ -94,21 +124,93 @@ type CurrentKey struct {
 	CreatedAt time.Time
 }
 
+// MaxHistory maximum number of events kept in a cache entry's History. Older events are pruned so that the
+// entry stays well within the size limit of a K8s secret.
+const MaxHistory = 50
+
+// KeyEventType kind of change in a key's lifecycle
+type KeyEventType string
+
+const (
+	KeyIssued   KeyEventType = "issued"
+	KeyRotated  KeyEventType = "rotated"
+	KeyDisabled KeyEventType = "disabled"
+	KeyDeleted  KeyEventType = "deleted"
+)
+
+// KeyEvent records a single change in a key's lifecycle
+type KeyEvent struct {
+	// KeyID id of the key the event happened to
+	KeyID string
+	// Type what happened to the key
+	Type KeyEventType
+	// Timestamp time at which the event happened
+	Timestamp time.Time
+}
+
+// RecordKeyEvent appends an event to the entry's History, pruning the oldest events beyond MaxHistory
+func (e *Entry) RecordKeyEvent(keyID string, eventType KeyEventType, timestamp time.Time) {
+	e.History = append(e.History, KeyEvent{
+		KeyID:     keyID,
+		Type:      eventType,
+		Timestamp: timestamp,
+	})
+	if len(e.History) > MaxHistory {
+		e.History = append([]KeyEvent(nil), e.History[len(e.History)-MaxHistory:]...)
+	}
+}
+
 func newCacheEntry[I Identifier](identifier I) *Entry {
 	return &Entry{
-		Identifier:   identifier,
-		Type:         identifier.Type(),
-		RotatedKeys:  make(map[string]time.Time),
-		DisabledKeys: make(map[string]time.Time),
-		SyncStatus:   make(map[string]string),
+		Identifier:         identifier,
+		Type:               identifier.Type(),
+		RotatedKeys:        make(map[string]time.Time),
+		DisabledKeys:       make(map[string]time.Time),
+		SyncStatus:         make(map[string]string),
+		SyncedDestinations: make(map[string][]SyncedDestination),
 	}
 }
 
+// backends a SyncedDestination can refer to
+const (
+	VaultDestination  = "vault"
+	GSMDestination    = "gsm"
+	GitHubDestination = "github"
+)
+
+// SyncedDestination identifies an external location, outside of K8s, that Yale replicated a key to.
+// Only the fields relevant to the destination's Backend are set.
+type SyncedDestination struct {
+	// Backend the kind of destination: "vault", "gsm", or "github"
+	Backend string
+	// Path Vault path the key was written to
+	Path string `json:",omitempty"`
+	// EngineVersion version of the KV secrets engine mounted at the Vault path
+	EngineVersion int `json:",omitempty"`
+	// Namespace Vault Enterprise namespace of the Vault path
+	Namespace string `json:",omitempty"`
+	// Address address of the Vault server the Vault path is on, if not Yale's default Vault server
+	Address string `json:",omitempty"`
+	// Project GCP project of the GSM secret
+	Project string `json:",omitempty"`
+	// Secret name of the GSM secret or GitHub secret
+	Secret string `json:",omitempty"`
+	// Repo GitHub repo of the secret, in the form "<owner>/<repo>"
+	Repo string `json:",omitempty"`
+	// Environment GitHub deployment environment of the secret
+	Environment string `json:",omitempty"`
+	// Org GitHub organization of an organization-level secret
+	Org string `json:",omitempty"`
+	// Kind kind of GitHub secret, if not an Actions secret (eg. "codespaces")
+	Kind string `json:",omitempty"`
+}
+
 type EntryType int
 
 const (
 	GcpSaKey EntryType = iota + 1
 	AzureClientSecret
+	AwsIamAccessKey
 )
 
 func (e EntryType) String() string {
@@ -117,6 +219,8 @@ func (e EntryType) String() string {
 		return "GcpSaKey"
 	case AzureClientSecret:
 		return "AzureClientSecret"
+	case AwsIamAccessKey:
+		return "AwsIamAccessKey"
 	}
 	return fmt.Sprintf("unknown entry type: %d", e)
 }
@@ -147,6 +251,11 @@ type Entry struct {
 	// Yale determines if it needs to perform a sync for a particular GcpSaKey by computing this value at runtime.
 	// If the computed value does not match the stored value, it performs a key sync and updates the stored value.
 	//
+	// If a sync only succeeds for some of the GcpSaKey's destinations (eg. Vault, but not GSM), the value records
+	// which destinations were synced, in the form "<checksum>:<key id>;synced=<destination>,<destination>", so that
+	// the next sync only retries the destinations that failed. Once every destination is synced, the value goes
+	// back to the "<checksum>:<key id>" form, which means every destination was synced.
+	//
 	// The advantages of this behavior are:
 	// * If Yale fails to sync a value to Vault due to, say, a permissions issue, it will return an error
 	//   and keep re-trying on every run until the sync succeeds
@@ -156,6 +265,27 @@ type Entry struct {
 	SyncStatus map[string]string
 	// LastError information about the most recent error to occur while processing this cache entry
 	LastError LastError
+	// LastSuccessfulRun timestamp at which Yale last finished processing this cache entry without error.
+	// Used to detect entries that Yale has silently stopped reconciling.
+	LastSuccessfulRun time.Time
+	// SyncedDestinations map used to track the external destinations (Vault paths, GSM secrets, GitHub secrets)
+	// each resource using this cache entry was last successfully synced to, keyed the same way as SyncStatus.
+	// Used to find destinations that have been removed from a resource's spec since the last sync.
+	SyncedDestinations map[string][]SyncedDestination
+	// SyncedSecrets map used to track the K8s secrets, in the form "<namespace>/<name>", each resource using this
+	// cache entry was last successfully synced to in the local cluster, keyed the same way as SyncStatus. Used to
+	// delete orphaned secrets once no resources use the cache entry.
+	SyncedSecrets map[string][]string `json:",omitempty"`
+	// RotationWarningAt timestamp at which Yale last warned that the current key is approaching its rotation
+	// cutoff. A warning is only sent once per key, so if this is after the current key's creation time, the
+	// current key has already been warned about.
+	RotationWarningAt time.Time
+	// RotatedKeysAlertAt timestamp at which Yale alerted that too many rotated keys were waiting to be disabled.
+	// The alert is only sent once; this is reset once the backlog clears, so that it is sent again if it recurs.
+	RotatedKeysAlertAt time.Time
+	// History append-only timeline of the keys issued, rotated, disabled, and deleted for this cache entry,
+	// oldest first. Bounded to the most recent MaxHistory events.
+	History []KeyEvent `json:",omitempty"`
 }
 
 // UnmarshalJSON custom unmarshaling logic to account the fact that the data stored in the cache may have a different shape based on
@@ -204,6 +334,13 @@ func (e *Entry) UnmarshalJSON(data []byte) error {
 				return fmt.Errorf("error unmarshaling AzureClientSecretEntryIdentifier: Identifier is not a AzureClientSecretEntryIdentifier")
 			}
 			e.Identifier = identifier
+		case AwsIamAccessKey:
+			var identifier AwsIamUserEntryIdentifier
+			err = json.Unmarshal(identifierData, &identifier)
+			if err != nil {
+				return fmt.Errorf("error unmarshaling AwsIamUserEntryIdentifier: Identifier is not a AwsIamUserEntryIdentifier")
+			}
+			e.Identifier = identifier
 		default:
 			return fmt.Errorf("unsupported Entry type: %v", e.Type)
 		}
@@ -264,10 +401,76 @@ func (e *Entry) UnmarshalJSON(data []byte) error {
 	}
 	e.LastError = lastError
 
+	lastSuccessfulRunData, err := json.Marshal(entryData["LastSuccessfulRun"])
+	if err != nil {
+		return fmt.Errorf("error parsing last successful run data: %v", err)
+	}
+	var lastSuccessfulRun time.Time
+	err = json.Unmarshal(lastSuccessfulRunData, &lastSuccessfulRun)
+	if err != nil {
+		return fmt.Errorf("error unmarshaling LastSuccessfulRun: LastSuccessfulRun is not a time.Time")
+	}
+	e.LastSuccessfulRun = lastSuccessfulRun
+
+	syncedDestinationsData, err := json.Marshal(entryData["SyncedDestinations"])
+	if err != nil {
+		return fmt.Errorf("error parsing synced destinations data: %v", err)
+	}
+	syncedDestinations := make(map[string][]SyncedDestination)
+	err = json.Unmarshal(syncedDestinationsData, &syncedDestinations)
+	if err != nil {
+		return fmt.Errorf("error unmarshaling SyncedDestinations: SyncedDestinations is not a map[string][]SyncedDestination")
+	}
+	e.SyncedDestinations = syncedDestinations
+
+	syncedSecretsData, err := json.Marshal(entryData["SyncedSecrets"])
+	if err != nil {
+		return fmt.Errorf("error parsing synced secrets data: %v", err)
+	}
+	var syncedSecrets map[string][]string
+	err = json.Unmarshal(syncedSecretsData, &syncedSecrets)
+	if err != nil {
+		return fmt.Errorf("error unmarshaling SyncedSecrets: SyncedSecrets is not a map[string][]string")
+	}
+	e.SyncedSecrets = syncedSecrets
+
+	rotationWarningAtData, err := json.Marshal(entryData["RotationWarningAt"])
+	if err != nil {
+		return fmt.Errorf("error parsing rotation warning data: %v", err)
+	}
+	var rotationWarningAt time.Time
+	err = json.Unmarshal(rotationWarningAtData, &rotationWarningAt)
+	if err != nil {
+		return fmt.Errorf("error unmarshaling RotationWarningAt: RotationWarningAt is not a time.Time")
+	}
+	e.RotationWarningAt = rotationWarningAt
+
+	rotatedKeysAlertAtData, err := json.Marshal(entryData["RotatedKeysAlertAt"])
+	if err != nil {
+		return fmt.Errorf("error parsing rotated keys alert data: %v", err)
+	}
+	var rotatedKeysAlertAt time.Time
+	err = json.Unmarshal(rotatedKeysAlertAtData, &rotatedKeysAlertAt)
+	if err != nil {
+		return fmt.Errorf("error unmarshaling RotatedKeysAlertAt: RotatedKeysAlertAt is not a time.Time")
+	}
+	e.RotatedKeysAlertAt = rotatedKeysAlertAt
+
+	historyData, err := json.Marshal(entryData["History"])
+	if err != nil {
+		return fmt.Errorf("error parsing history data: %v", err)
+	}
+	var history []KeyEvent
+	err = json.Unmarshal(historyData, &history)
+	if err != nil {
+		return fmt.Errorf("error unmarshaling History: History is not a []KeyEvent")
+	}
+	e.History = history
+
 	return nil
 }
 
-func (c *Entry) marshalToSecret(s *corev1.Secret) error {
+func (c *Entry) marshalToRecord(s *Record) error {
 	content, err := json.Marshal(c)
 	if err != nil {
 		return fmt.Errorf("error marshalling Entry to JSON: %v", err)
@@ -285,14 +488,25 @@ func (c *Entry) marshalToSecret(s *corev1.Secret) error {
 	if s.Data == nil {
 		s.Data = make(map[string][]byte)
 	}
-	s.Data[secretKey] = content
+	compressed, err := compress(content)
+	if err != nil {
+		return fmt.Errorf("error compressing Entry JSON: %v", err)
+	}
+	s.Data[compressedSecretKey] = compressed
+	// the compressed key supersedes the legacy one, so don't leave stale data behind
+	delete(s.Data, secretKey)
 	return nil
 }
 
-func (c *Entry) unmarshalFromSecret(s *corev1.Secret) error {
-	data, exists := s.Data[secretKey]
-	if !exists {
-		return fmt.Errorf("failed to unmarshal Entry from secret %s (missing %q key)", s.Name, secretKey)
+func (c *Entry) unmarshalFromRecord(s *Record) error {
+	data, exists := s.Data[compressedSecretKey]
+	if exists {
+		var err error
+		if data, err = decompress(data); err != nil {
+			return fmt.Errorf("failed to decompress Entry from secret %s: %v", s.Name, err)
+		}
+	} else if data, exists = s.Data[secretKey]; !exists {
+		return fmt.Errorf("failed to unmarshal Entry from secret %s (missing %q or %q key)", s.Name, compressedSecretKey, secretKey)
 	}
 	if err := json.Unmarshal(data, c); err != nil {
 		return fmt.Errorf("failed to unmarshal Entry from secret %s: %v", s.Name, err)
@@ -306,9 +520,35 @@ func (c *Entry) unmarshalFromSecret(s *corev1.Secret) error {
 	if c.SyncStatus == nil {
 		c.SyncStatus = make(map[string]string)
 	}
+	if c.SyncedDestinations == nil {
+		c.SyncedDestinations = make(map[string][]SyncedDestination)
+	}
 	return nil
 }
 
+// compress gzips the given data, so that large cache entries stay under the 1MB k8s secret size limit
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompress un-gzips the given data
+func decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
 func (e *Entry) handleUnmarshalLegacyCacheEntry(entryData map[string]interface{}) error {
 	// legacy cache entries will not have the Type field set, so we need to set it here
 	// legacy cache entries are guaranteed to be GcpSaKey entries
@@ -0,0 +1,236 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/broadinstitute/yale/internal/yale/logs"
+	"github.com/googleapis/gax-go/v2/apierror"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+)
+
+// GSMShardThreshold size, in bytes, of compressed cache entry data above which entries stored in Google Secret
+// Manager are split across multiple secrets. GSM secret versions are limited to 64KiB, and record data is
+// base64-encoded in the payload, so this leaves room for the encoding overhead and record metadata.
+const GSMShardThreshold = 32 * 1024
+
+// matches characters that are not allowed in GSM label keys and values
+var illegalGSMLabelCharsRegexp = regexp.MustCompile(`[^a-z0-9_-]`)
+
+// NewGSMStore returns a Store that persists records as secrets in the given Google Secret Manager project. Each
+// record is stored as a single secret, whose latest version holds the record; older versions are destroyed
+// when a record is replaced.
+func NewGSMStore(client *secretmanager.Client, project string) Store {
+	return &gsmStore{
+		client:  client,
+		project: project,
+	}
+}
+
+type gsmStore struct {
+	client  *secretmanager.Client
+	project string
+}
+
+// gsmPayload is the JSON-serialized form of a record stored in a GSM secret version
+type gsmPayload struct {
+	Name        string            `json:"name"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Data        map[string][]byte `json:"data,omitempty"`
+}
+
+func (s *gsmStore) Get(name string) (*Record, error) {
+	record, err := s.access(gsmSecretID(name))
+	if err != nil {
+		return nil, fmt.Errorf("error reading GSM secret %s in project %s: %v", gsmSecretID(name), s.project, err)
+	}
+	return record, nil
+}
+
+func (s *gsmStore) Put(record *Record) error {
+	secretID := gsmSecretID(record.Name)
+	payload, err := json.Marshal(gsmPayload{
+		Name:        record.Name,
+		Labels:      record.Labels,
+		Annotations: record.Annotations,
+		Data:        record.Data,
+	})
+	if err != nil {
+		return fmt.Errorf("error marshalling record %s: %v", record.Name, err)
+	}
+
+	version, err := s.addVersion(secretID, payload)
+	if isNotFound(err) {
+		logs.Info.Printf("GSM secret %s does not exist in project %s, creating it", secretID, s.project)
+		_, err = s.client.CreateSecret(context.Background(), &secretmanagerpb.CreateSecretRequest{
+			Parent:   fmt.Sprintf("projects/%s", s.project),
+			SecretId: secretID,
+			Secret: &secretmanagerpb.Secret{
+				Labels: gsmLabels(record.Labels),
+				Replication: &secretmanagerpb.Replication{
+					Replication: &secretmanagerpb.Replication_Automatic_{
+						Automatic: &secretmanagerpb.Replication_Automatic{},
+					},
+				},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("error creating GSM secret %s in project %s: %v", secretID, s.project, err)
+		}
+		version, err = s.addVersion(secretID, payload)
+	}
+	if err != nil {
+		return fmt.Errorf("error adding version to GSM secret %s in project %s: %v", secretID, s.project, err)
+	}
+
+	// previous versions hold stale, possibly sensitive, cache data, so don't leave them behind
+	return s.destroyVersionsExcept(secretID, version.GetName())
+}
+
+func (s *gsmStore) Delete(name string) error {
+	secretID := gsmSecretID(name)
+	err := s.client.DeleteSecret(context.Background(), &secretmanagerpb.DeleteSecretRequest{
+		Name: s.secretName(secretID),
+	})
+	if err != nil && !isNotFound(err) {
+		return fmt.Errorf("error deleting GSM secret %s in project %s: %v", secretID, s.project, err)
+	}
+	return nil
+}
+
+func (s *gsmStore) List(labelKey string, labelValue string) ([]*Record, error) {
+	itr := s.client.ListSecrets(context.Background(), &secretmanagerpb.ListSecretsRequest{
+		Parent: fmt.Sprintf("projects/%s", s.project),
+		Filter: fmt.Sprintf("labels.%s=%s", gsmLabel(labelKey), gsmLabel(labelValue)),
+	})
+
+	var records []*Record
+	for {
+		secret, err := itr.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error listing GSM secrets in project %s: %v", s.project, err)
+		}
+		secretID := secret.GetName()[strings.LastIndex(secret.GetName(), "/")+1:]
+		record, err := s.access(secretID)
+		if err != nil {
+			return nil, fmt.Errorf("error reading GSM secret %s in project %s: %v", secretID, s.project, err)
+		}
+		if record == nil {
+			// the secret was created, but its first version was never added
+			logs.Warn.Printf("GSM secret %s in project %s has no enabled versions, ignoring it", secretID, s.project)
+			continue
+		}
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Name < records[j].Name
+	})
+	return records, nil
+}
+
+// access returns the record stored in the latest version of the secret, or nil if the secret or version
+// does not exist
+func (s *gsmStore) access(secretID string) (*Record, error) {
+	resp, err := s.client.AccessSecretVersion(context.Background(), &secretmanagerpb.AccessSecretVersionRequest{
+		Name: s.secretName(secretID) + "/versions/latest",
+	})
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var payload gsmPayload
+	if err = json.Unmarshal(resp.GetPayload().GetData(), &payload); err != nil {
+		return nil, fmt.Errorf("error unmarshalling record: %v", err)
+	}
+	return &Record{
+		Name:        payload.Name,
+		Labels:      payload.Labels,
+		Annotations: payload.Annotations,
+		Data:        payload.Data,
+	}, nil
+}
+
+func (s *gsmStore) addVersion(secretID string, payload []byte) (*secretmanagerpb.SecretVersion, error) {
+	return s.client.AddSecretVersion(context.Background(), &secretmanagerpb.AddSecretVersionRequest{
+		Parent: s.secretName(secretID),
+		Payload: &secretmanagerpb.SecretPayload{
+			Data: payload,
+		},
+	})
+}
+
+// destroyVersionsExcept destroys all enabled versions of the secret other than the one with the given name
+func (s *gsmStore) destroyVersionsExcept(secretID string, keep string) error {
+	itr := s.client.ListSecretVersions(context.Background(), &secretmanagerpb.ListSecretVersionsRequest{
+		Parent: s.secretName(secretID),
+		Filter: "state:ENABLED",
+	})
+	for {
+		version, err := itr.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error listing versions of GSM secret %s in project %s: %v", secretID, s.project, err)
+		}
+		if version.GetName() == keep || version.GetState() != secretmanagerpb.SecretVersion_ENABLED {
+			continue
+		}
+		logs.Debug.Printf("destroying previous version %s of GSM secret %s", version.GetName(), secretID)
+		_, err = s.client.DestroySecretVersion(context.Background(), &secretmanagerpb.DestroySecretVersionRequest{
+			Name: version.GetName(),
+		})
+		if err != nil {
+			return fmt.Errorf("error destroying GSM secret version %s: %v", version.GetName(), err)
+		}
+	}
+}
+
+func (s *gsmStore) secretName(secretID string) string {
+	return fmt.Sprintf("projects/%s/secrets/%s", s.project, secretID)
+}
+
+// gsmSecretID returns the id of the GSM secret storing the record with the given name. Record names are valid
+// k8s resource names, so replacing "." (which GSM does not allow) with "_" (which k8s does not allow) can't
+// make two names collide.
+func gsmSecretID(name string) string {
+	return strings.ReplaceAll(name, ".", "_")
+}
+
+// gsmLabels returns the record's labels, with keys and values modified to only contain characters GSM allows
+func gsmLabels(labels map[string]string) map[string]string {
+	result := make(map[string]string)
+	for key, value := range labels {
+		result[gsmLabel(key)] = gsmLabel(value)
+	}
+	return result
+}
+
+// gsmLabel lowercases s and replaces any characters that are not allowed in GSM labels with "_"
+func gsmLabel(s string) string {
+	return illegalGSMLabelCharsRegexp.ReplaceAllString(strings.ToLower(s), "_")
+}
+
+// isNotFound returns true if err is a GSM API error indicating the secret or version does not exist
+func isNotFound(err error) bool {
+	var apiErr *apierror.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.HTTPCode() == http.StatusNotFound || apiErr.GRPCStatus().Code() == codes.NotFound
+}
@@ -0,0 +1,162 @@
+package cache
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/broadinstitute/yale/internal/yale/keysync/testutils/gsm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const gsmProject = "my-cache-project"
+
+func Test_GSMStore(t *testing.T) {
+	fakeGsm := gsm.NewInMemoryGsm(t)
+	defer fakeGsm.Close()
+	store := NewGSMStore(fakeGsm.NewClient(), gsmProject)
+
+	// missing records should be nil, not an error
+	record, err := store.Get("my-record.example.com")
+	require.NoError(t, err)
+	assert.Nil(t, record)
+	require.NoError(t, store.Delete("my-record.example.com"))
+
+	expected := &Record{
+		Name:        "my-record.example.com",
+		Labels:      map[string]string{labelKey: labelValue},
+		Annotations: map[string]string{shardCountAnnotation: "2"},
+		Data:        map[string][]byte{compressedSecretKey: []byte("some data")},
+	}
+	require.NoError(t, store.Put(expected))
+
+	// "." is not allowed in GSM secret ids or labels
+	assert.Equal(t, []string{"my-record_example_com"}, fakeGsm.SecretIDs(gsmProject))
+	assert.Equal(t, map[string]string{"yale_terra_bio_cache-entry": "true"}, fakeGsm.Labels(gsmProject, "my-record_example_com"))
+
+	record, err = store.Get("my-record.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, expected, record)
+
+	// replacing the record should destroy the previous version
+	expected.Data[compressedSecretKey] = []byte("some other data")
+	require.NoError(t, store.Put(expected))
+	assert.Equal(t, 1, fakeGsm.EnabledVersions(gsmProject, "my-record_example_com"))
+
+	record, err = store.Get("my-record.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, expected, record)
+
+	// list should only return records with the given label
+	other := &Record{
+		Name:   "my-other-record",
+		Labels: map[string]string{shardLabelKey: shardLabelValue},
+		Data:   map[string][]byte{compressedSecretKey: []byte("shard data")},
+	}
+	require.NoError(t, store.Put(other))
+
+	records, err := store.List(labelKey, labelValue)
+	require.NoError(t, err)
+	assert.Equal(t, []*Record{expected}, records)
+
+	records, err = store.List(shardLabelKey, shardLabelValue)
+	require.NoError(t, err)
+	assert.Equal(t, []*Record{other}, records)
+
+	require.NoError(t, store.Delete("my-record.example.com"))
+	record, err = store.Get("my-record.example.com")
+	require.NoError(t, err)
+	assert.Nil(t, record)
+	assert.Equal(t, []string{"my-other-record"}, fakeGsm.SecretIDs(gsmProject))
+}
+
+func Test_cacheWithGSMStore(t *testing.T) {
+	fakeGsm := gsm.NewInMemoryGsm(t)
+	defer fakeGsm.Close()
+	cache := NewWithStore(NewGSMStore(fakeGsm.NewClient(), gsmProject))
+
+	entries, err := cache.List()
+	require.NoError(t, err)
+	assert.Len(t, entries, 0)
+
+	expected := emptyCacheEntry(sa1)
+	entry, err := cache.GetOrCreate(sa1)
+	require.NoError(t, err)
+	assert.Equal(t, &expected, entry)
+	assert.Equal(t, []string{gsmSecretID(sa1.cacheSecretName())}, fakeGsm.SecretIDs(gsmProject))
+
+	now := time.Now().Round(0).UTC()
+	entry.CurrentKey.ID = "key-1"
+	entry.CurrentKey.CreatedAt = now
+	entry.CurrentKey.JSON = `{"foo":"bar"}`
+	entry.RotatedKeys["key-2"] = now
+	entry.SyncStatus["my-ns/my-gsk"] = "my-sha256-sum:key-1"
+	entry.RecordKeyEvent("key-1", KeyIssued, now)
+	require.NoError(t, cache.Save(entry))
+
+	entryCopy, err := cache.GetOrCreate(sa1)
+	require.NoError(t, err)
+	assert.Equal(t, entry, entryCopy)
+
+	entry2, err := cache.GetOrCreate(sa2)
+	require.NoError(t, err)
+	entry3, err := cache.GetOrCreate(azClientSecret1)
+	require.NoError(t, err)
+
+	entries, err = cache.List()
+	require.NoError(t, err)
+	assert.Equal(t, []*Entry{entry3, entry, entry2}, entries)
+
+	require.NoError(t, cache.Delete(entry2))
+	entries, err = cache.List()
+	require.NoError(t, err)
+	assert.Equal(t, []*Entry{entry3, entry}, entries)
+
+	require.NoError(t, cache.Delete(entry))
+	require.NoError(t, cache.Delete(entry3))
+	entries, err = cache.List()
+	require.NoError(t, err)
+	assert.Len(t, entries, 0)
+	assert.Empty(t, fakeGsm.SecretIDs(gsmProject))
+}
+
+func Test_cacheWithGSMStoreShardsEntriesAboveGSMSizeLimit(t *testing.T) {
+	fakeGsm := gsm.NewInMemoryGsm(t)
+	defer fakeGsm.Close()
+	cache := NewWithStore(NewGSMStore(fakeGsm.NewClient(), gsmProject))
+
+	entry, err := cache.GetOrCreate(sa1)
+	require.NoError(t, err)
+
+	// random data doesn't compress, so this grows the entry well past the GSM shard threshold
+	for i := 0; i < 2000; i++ {
+		entry.SyncStatus[fmt.Sprintf("my-secret-%d/my-namespace-%d", i, i)] = randomHex(t, 32)
+	}
+	require.NoError(t, cache.Save(entry))
+
+	record, err := NewGSMStore(fakeGsm.NewClient(), gsmProject).Get(sa1.cacheSecretName())
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	shards, err := strconv.Atoi(record.Annotations[shardCountAnnotation])
+	require.NoError(t, err)
+	assert.Greater(t, shards, 1)
+	assert.Len(t, fakeGsm.SecretIDs(gsmProject), shards+1)
+
+	entries, err := cache.List()
+	require.NoError(t, err)
+	assert.Equal(t, []*Entry{entry}, entries)
+
+	require.NoError(t, cache.Delete(entry))
+	assert.Empty(t, fakeGsm.SecretIDs(gsmProject))
+}
+
+func randomHex(t *testing.T, n int) string {
+	data := make([]byte, n/2)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+	return hex.EncodeToString(data)
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// NewK8sStore returns a Store that persists records as Secrets in the given namespace
+func NewK8sStore(k8s kubernetes.Interface, namespace string) Store {
+	return &k8sStore{
+		k8s:       k8s,
+		namespace: namespace,
+	}
+}
+
+type k8sStore struct {
+	k8s       kubernetes.Interface
+	namespace string
+}
+
+func (s *k8sStore) Get(name string) (*Record, error) {
+	secret, err := s.k8s.CoreV1().Secrets(s.namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading secret %s in namespace %s: %v", name, s.namespace, err)
+	}
+	return recordFromSecret(secret), nil
+}
+
+func (s *k8sStore) Put(record *Record) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        record.Name,
+			Namespace:   s.namespace,
+			Labels:      record.Labels,
+			Annotations: record.Annotations,
+		},
+		Data: record.Data,
+	}
+	_, err := s.k8s.CoreV1().Secrets(s.namespace).Update(context.Background(), secret, metav1.UpdateOptions{})
+	if errors.IsNotFound(err) {
+		_, err = s.k8s.CoreV1().Secrets(s.namespace).Create(context.Background(), secret, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("error writing secret %s in namespace %s: %v", record.Name, s.namespace, err)
+	}
+	return nil
+}
+
+func (s *k8sStore) Delete(name string) error {
+	err := s.k8s.CoreV1().Secrets(s.namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("error deleting secret %s in namespace %s: %v", name, s.namespace, err)
+	}
+	return nil
+}
+
+func (s *k8sStore) List(labelKey string, labelValue string) ([]*Record, error) {
+	resp, err := s.k8s.CoreV1().Secrets(s.namespace).List(context.Background(), metav1.ListOptions{
+		LabelSelector: labelKey + "=" + labelValue,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing secrets in namespace %s: %v", s.namespace, err)
+	}
+	var records []*Record
+	for i := range resp.Items {
+		records = append(records, recordFromSecret(&resp.Items[i]))
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Name < records[j].Name
+	})
+	return records, nil
+}
+
+// recordFromSecret returns a record with the secret's name, labels, annotations, and data
+func recordFromSecret(secret *corev1.Secret) *Record {
+	return &Record{
+		Name:        secret.Name,
+		Labels:      secret.Labels,
+		Annotations: secret.Annotations,
+		Data:        secret.Data,
+	}
+}
@@ -0,0 +1,148 @@
+package cache
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+
+	"google.golang.org/api/cloudkms/v1"
+)
+
+// annotation added to a cache entry secret whose data is encrypted, recording the name of the KMS key that
+// wraps its data encryption key. Secrets without it are stored in plaintext.
+const kmsKeyAnnotation = "yale.terra.bio/cache-entry-kms-key"
+
+// key within the secret where the KMS-wrapped data encryption key is stored
+const wrappedDEKSecretKey = "dek"
+
+// size, in bytes, of the AES-256 data encryption keys generated for each cache entry write
+const dekSize = 32
+
+// KMS wraps and unwraps the data encryption keys used to encrypt cache entries at rest
+type KMS interface {
+	// Encrypt encrypts plaintext with the KMS key with the given resource name
+	Encrypt(keyName string, plaintext []byte) ([]byte, error)
+	// Decrypt decrypts ciphertext with the KMS key with the given resource name
+	Decrypt(keyName string, ciphertext []byte) ([]byte, error)
+}
+
+// NewKMS returns a KMS backed by Google Cloud KMS
+func NewKMS(service *cloudkms.Service) KMS {
+	return &googleKMS{service: service}
+}
+
+type googleKMS struct {
+	service *cloudkms.Service
+}
+
+func (g *googleKMS) Encrypt(keyName string, plaintext []byte) ([]byte, error) {
+	resp, err := g.service.Projects.Locations.KeyRings.CryptoKeys.Encrypt(keyName, &cloudkms.EncryptRequest{
+		Plaintext: base64.StdEncoding.EncodeToString(plaintext),
+	}).Context(context.Background()).Do()
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting with KMS key %s: %v", keyName, err)
+	}
+	return base64.StdEncoding.DecodeString(resp.Ciphertext)
+}
+
+func (g *googleKMS) Decrypt(keyName string, ciphertext []byte) ([]byte, error) {
+	resp, err := g.service.Projects.Locations.KeyRings.CryptoKeys.Decrypt(keyName, &cloudkms.DecryptRequest{
+		Ciphertext: base64.StdEncoding.EncodeToString(ciphertext),
+	}).Context(context.Background()).Do()
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting with KMS key %s: %v", keyName, err)
+	}
+	return base64.StdEncoding.DecodeString(resp.Plaintext)
+}
+
+// encrypt encrypts the compressed data in the marshalled cache entry secret with a freshly generated data
+// encryption key, which is wrapped with the configured KMS key and stored alongside it. If no KMS key is
+// configured, the secret is left in plaintext and any encryption metadata from a previous write is removed.
+func (c *cache) encrypt(s *Record) error {
+	if c.options.KMSKeyName == "" {
+		delete(s.Annotations, kmsKeyAnnotation)
+		delete(s.Data, wrappedDEKSecretKey)
+		return nil
+	}
+	if c.options.KMS == nil {
+		return fmt.Errorf("KMS key %s is configured for cache encryption, but no KMS client is available", c.options.KMSKeyName)
+	}
+
+	dek := make([]byte, dekSize)
+	if _, err := rand.Read(dek); err != nil {
+		return fmt.Errorf("error generating data encryption key: %v", err)
+	}
+	gcm, err := newGCM(dek)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return fmt.Errorf("error generating nonce: %v", err)
+	}
+	wrappedDEK, err := c.options.KMS.Encrypt(c.options.KMSKeyName, dek)
+	if err != nil {
+		return fmt.Errorf("error wrapping data encryption key: %v", err)
+	}
+
+	s.Data[compressedSecretKey] = gcm.Seal(nonce, nonce, s.Data[compressedSecretKey], nil)
+	s.Data[wrappedDEKSecretKey] = wrappedDEK
+	if s.Annotations == nil {
+		s.Annotations = make(map[string]string)
+	}
+	s.Annotations[kmsKeyAnnotation] = c.options.KMSKeyName
+	return nil
+}
+
+// decrypt decrypts the compressed data in an encrypted cache entry secret, so it can be unmarshalled like
+// any other cache entry secret. Secrets written without encryption are left unchanged.
+func (c *cache) decrypt(s *Record) error {
+	keyName, encrypted := s.Annotations[kmsKeyAnnotation]
+	if !encrypted {
+		return nil
+	}
+	if c.options.KMS == nil {
+		return fmt.Errorf("cache entry secret %s is encrypted with KMS key %s, but no KMS client is available", s.Name, keyName)
+	}
+
+	wrappedDEK, exists := s.Data[wrappedDEKSecretKey]
+	if !exists {
+		return fmt.Errorf("invalid cache entry secret %s: encrypted, but missing %q key", s.Name, wrappedDEKSecretKey)
+	}
+	// unwrap with the key recorded on the secret rather than the configured one, so entries remain readable
+	// while migrating between KMS keys
+	dek, err := c.options.KMS.Decrypt(keyName, wrappedDEK)
+	if err != nil {
+		return fmt.Errorf("error unwrapping data encryption key for cache entry secret %s: %v", s.Name, err)
+	}
+	gcm, err := newGCM(dek)
+	if err != nil {
+		return err
+	}
+	data := s.Data[compressedSecretKey]
+	if len(data) < gcm.NonceSize() {
+		return fmt.Errorf("invalid cache entry secret %s: encrypted data is too short", s.Name)
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return fmt.Errorf("error decrypting cache entry secret %s: %v", s.Name, err)
+	}
+	s.Data[compressedSecretKey] = plaintext
+	return nil
+}
+
+// newGCM returns an AES-GCM cipher for the given data encryption key
+func newGCM(dek []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(dek)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cipher from data encryption key: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("error creating GCM cipher: %v", err)
+	}
+	return gcm, nil
+}
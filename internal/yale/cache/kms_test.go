@@ -0,0 +1,190 @@
+package cache
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/broadinstitute/yale/internal/yale/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const kmsKeyName = "projects/my-project/locations/global/keyRings/yale/cryptoKeys/cache"
+
+// fakeKMS "wraps" data encryption keys by handing out opaque handles, and remembers which key wrapped each one
+type fakeKMS struct {
+	wrapped map[string]fakeWrappedKey
+}
+
+type fakeWrappedKey struct {
+	keyName   string
+	plaintext []byte
+}
+
+func newFakeKMS() *fakeKMS {
+	return &fakeKMS{wrapped: make(map[string]fakeWrappedKey)}
+}
+
+func (f *fakeKMS) Encrypt(keyName string, plaintext []byte) ([]byte, error) {
+	handle := fmt.Sprintf("wrapped-%d", len(f.wrapped))
+	f.wrapped[handle] = fakeWrappedKey{keyName: keyName, plaintext: plaintext}
+	return []byte(handle), nil
+}
+
+func (f *fakeKMS) Decrypt(keyName string, ciphertext []byte) ([]byte, error) {
+	wrapped, exists := f.wrapped[string(ciphertext)]
+	if !exists {
+		return nil, fmt.Errorf("unknown ciphertext %q", ciphertext)
+	}
+	if wrapped.keyName != keyName {
+		return nil, fmt.Errorf("ciphertext %q was encrypted with %s, not %s", ciphertext, wrapped.keyName, keyName)
+	}
+	return wrapped.plaintext, nil
+}
+
+func Test_cacheEncryptsEntriesWithKMS(t *testing.T) {
+	k8s := testutils.NewFakeK8sClient(t)
+	kms := newFakeKMS()
+	cache := New(k8s, namespace, func(options *Options) {
+		options.KMSKeyName = kmsKeyName
+		options.KMS = kms
+	})
+
+	entry, err := cache.GetOrCreate(sa1)
+	require.NoError(t, err)
+	entry.CurrentKey.ID = "my-key-id"
+	entry.CurrentKey.JSON = `{"private_key": "super-secret"}`
+	require.NoError(t, cache.Save(entry))
+
+	secret := readCacheSecret(t, k8s, sa1.cacheSecretName())
+	require.NotNil(t, secret)
+	assert.Equal(t, kmsKeyName, secret.Annotations[kmsKeyAnnotation])
+	require.Contains(t, secret.Data, wrappedDEKSecretKey)
+	assert.Contains(t, kms.wrapped, string(secret.Data[wrappedDEKSecretKey]))
+	_, err = decompress(secret.Data[compressedSecretKey])
+	assert.Error(t, err, "encrypted data should not be readable as plain gzip")
+	assert.False(t, bytes.Contains(secret.Data[compressedSecretKey], []byte("super-secret")))
+
+	entryCopy, err := cache.GetOrCreate(sa1)
+	require.NoError(t, err)
+	assert.Equal(t, entry, entryCopy)
+
+	entries, err := cache.List()
+	require.NoError(t, err)
+	assert.Equal(t, []*Entry{entry}, entries)
+}
+
+func Test_cacheReadsPlaintextEntriesWhenEncryptionIsEnabled(t *testing.T) {
+	k8s := testutils.NewFakeK8sClient(t)
+
+	// write an entry before encryption was enabled
+	plaintextCache := New(k8s, namespace)
+	entry, err := plaintextCache.GetOrCreate(sa1)
+	require.NoError(t, err)
+	entry.CurrentKey.JSON = `{"private_key": "super-secret"}`
+	require.NoError(t, plaintextCache.Save(entry))
+
+	secret := readCacheSecret(t, k8s, sa1.cacheSecretName())
+	require.NotNil(t, secret)
+	assert.NotContains(t, secret.Annotations, kmsKeyAnnotation)
+
+	encryptingCache := New(k8s, namespace, func(options *Options) {
+		options.KMSKeyName = kmsKeyName
+		options.KMS = newFakeKMS()
+	})
+
+	entryCopy, err := encryptingCache.GetOrCreate(sa1)
+	require.NoError(t, err)
+	assert.Equal(t, entry, entryCopy)
+
+	entries, err := encryptingCache.List()
+	require.NoError(t, err)
+	assert.Equal(t, []*Entry{entry}, entries)
+
+	// saving the entry should encrypt it
+	require.NoError(t, encryptingCache.Save(entryCopy))
+	secret = readCacheSecret(t, k8s, sa1.cacheSecretName())
+	require.NotNil(t, secret)
+	assert.Equal(t, kmsKeyName, secret.Annotations[kmsKeyAnnotation])
+
+	entryCopy, err = encryptingCache.GetOrCreate(sa1)
+	require.NoError(t, err)
+	assert.Equal(t, entry, entryCopy)
+}
+
+func Test_cacheDecryptsEntriesWhenEncryptionIsDisabled(t *testing.T) {
+	k8s := testutils.NewFakeK8sClient(t)
+	kms := newFakeKMS()
+
+	encryptingCache := New(k8s, namespace, func(options *Options) {
+		options.KMSKeyName = kmsKeyName
+		options.KMS = kms
+	})
+	entry, err := encryptingCache.GetOrCreate(sa1)
+	require.NoError(t, err)
+	entry.CurrentKey.JSON = `{"private_key": "super-secret"}`
+	require.NoError(t, encryptingCache.Save(entry))
+
+	// without a KMS client, encrypted entries can't be read
+	_, err = New(k8s, namespace).GetOrCreate(sa1)
+	assert.ErrorContains(t, err, "is encrypted with KMS key "+kmsKeyName+", but no KMS client is available")
+
+	// with a KMS client but no key, encrypted entries are read, and written back in plaintext
+	decryptingCache := New(k8s, namespace, func(options *Options) {
+		options.KMS = kms
+	})
+	entryCopy, err := decryptingCache.GetOrCreate(sa1)
+	require.NoError(t, err)
+	assert.Equal(t, entry, entryCopy)
+	require.NoError(t, decryptingCache.Save(entryCopy))
+
+	secret := readCacheSecret(t, k8s, sa1.cacheSecretName())
+	require.NotNil(t, secret)
+	assert.NotContains(t, secret.Annotations, kmsKeyAnnotation)
+	assert.NotContains(t, secret.Data, wrappedDEKSecretKey)
+	assert.Equal(t, entry.CurrentKey.JSON, mustUnmarshalSecret(t, secret).CurrentKey.JSON)
+}
+
+func Test_cacheEncryptsShardedEntries(t *testing.T) {
+	k8s := testutils.NewFakeK8sClient(t)
+	cache := New(k8s, namespace, func(options *Options) {
+		options.KMSKeyName = kmsKeyName
+		options.KMS = newFakeKMS()
+		options.ShardThreshold = 1024
+	})
+
+	entry, err := cache.GetOrCreate(sa1)
+	require.NoError(t, err)
+	entry.CurrentKey.JSON = `{"private_key": "super-secret"}`
+	for i := 0; i < 1000; i++ {
+		entry.SyncStatus[fmt.Sprintf("my-secret-%d/my-namespace-%d", i, i)] = fmt.Sprintf("sha256-hash-of-spec-number-%d", i)
+	}
+	require.NoError(t, cache.Save(entry))
+
+	secret := readCacheSecret(t, k8s, sa1.cacheSecretName())
+	require.NotNil(t, secret)
+	assert.Contains(t, secret.Annotations, shardCountAnnotation)
+	assert.Contains(t, secret.Annotations, kmsKeyAnnotation)
+
+	entries, err := cache.List()
+	require.NoError(t, err)
+	assert.Equal(t, []*Entry{entry}, entries)
+}
+
+func Test_cacheRequiresKMSClientToEncrypt(t *testing.T) {
+	k8s := testutils.NewFakeK8sClient(t)
+	cache := New(k8s, namespace, func(options *Options) {
+		options.KMSKeyName = kmsKeyName
+	})
+	_, err := cache.GetOrCreate(sa1)
+	assert.ErrorContains(t, err, "no KMS client is available")
+}
+
+// mustUnmarshalSecret unmarshals a plaintext cache entry secret
+func mustUnmarshalSecret(t *testing.T, secret *corev1.Secret) *Entry {
+	var entry Entry
+	require.NoError(t, entry.unmarshalFromRecord(recordFromSecret(secret)))
+	return &entry
+}
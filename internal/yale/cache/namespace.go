@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/broadinstitute/yale/internal/yale/logs"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// maxReportedNonCacheSecrets maximum number of non-cache secret names included in the warning about a
+// cache namespace that is not dedicated to Yale's cache
+const maxReportedNonCacheSecrets = 10
+
+// ValidateNamespace returns an error if the K8s cache namespace does not exist, so that a mistyped
+// -cachenamespace fails at startup instead of on the first cache write. If checkDedicated is true, it also
+// warns if the namespace contains secrets other than cache entries, whose names could collide with them.
+// Yale may not be allowed to read namespaces, in which case the existence check is skipped with a warning.
+func ValidateNamespace(k8s kubernetes.Interface, namespace string, checkDedicated bool) error {
+	_, err := k8s.CoreV1().Namespaces().Get(context.Background(), namespace, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return fmt.Errorf("cache namespace %s does not exist", namespace)
+	}
+	if errors.IsForbidden(err) {
+		logs.Warn.Printf("unable to verify that cache namespace %s exists: %v", namespace, err)
+	} else if err != nil {
+		return fmt.Errorf("error checking cache namespace %s: %v", namespace, err)
+	}
+
+	if !checkDedicated {
+		return nil
+	}
+	names, err := nonCacheSecrets(k8s, namespace)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	reported := names
+	if len(reported) > maxReportedNonCacheSecrets {
+		reported = append(reported[:maxReportedNonCacheSecrets:maxReportedNonCacheSecrets], "...")
+	}
+	logs.Warn.Printf("cache namespace %s is not dedicated to Yale's cache: it contains %d other secrets (%s). Their names could collide with cache entries; consider moving them or using a different -cachenamespace", namespace, len(names), strings.Join(reported, ", "))
+	return nil
+}
+
+// nonCacheSecrets returns the sorted names of secrets in the namespace that are neither cache entries nor
+// cache entry shards
+func nonCacheSecrets(k8s kubernetes.Interface, namespace string) ([]string, error) {
+	resp, err := k8s.CoreV1().Secrets(namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing secrets in cache namespace %s: %v", namespace, err)
+	}
+	var names []string
+	for _, secret := range resp.Items {
+		if secret.Labels[labelKey] == labelValue || secret.Labels[shardLabelKey] == shardLabelValue {
+			continue
+		}
+		names = append(names, secret.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/broadinstitute/yale/internal/yale/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+)
+
+func Test_ValidateNamespace(t *testing.T) {
+	k8s := testutils.NewFakeK8sClient(t, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})
+
+	require.NoError(t, ValidateNamespace(k8s, namespace, true))
+
+	err := ValidateNamespace(k8s, "yale-cahce", false)
+	assert.ErrorContains(t, err, "cache namespace yale-cahce does not exist")
+}
+
+func Test_ValidateNamespaceSkipsExistenceCheckIfForbidden(t *testing.T) {
+	k8s := k8sfake.NewSimpleClientset()
+	k8s.PrependReactor("get", "namespaces", func(action ktesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.NewForbidden(schema.GroupResource{Resource: "namespaces"}, namespace, fmt.Errorf("no RBAC policy matched"))
+	})
+
+	require.NoError(t, ValidateNamespace(k8s, namespace, false))
+}
+
+func Test_nonCacheSecrets(t *testing.T) {
+	k8s := testutils.NewFakeK8sClient(t)
+	cache := New(k8s, namespace, func(options *Options) {
+		options.ShardThreshold = 16
+	})
+
+	// a sharded cache entry
+	entry, err := cache.GetOrCreate(sa1)
+	require.NoError(t, err)
+	entry.CurrentKey.JSON = `{"private_key":"some long key data that needs several shards"}`
+	require.NoError(t, cache.Save(entry))
+
+	names, err := nonCacheSecrets(k8s, namespace)
+	require.NoError(t, err)
+	assert.Empty(t, names)
+
+	for _, name := range []string{"my-app-secret", "another-app-secret"} {
+		_, err = k8s.CoreV1().Secrets(namespace).Create(context.Background(), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+		}, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	names, err = nonCacheSecrets(k8s, namespace)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"another-app-secret", "my-app-secret"}, names)
+}
@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/broadinstitute/yale/internal/yale/logs"
+)
+
+// DefaultShardThreshold default size, in bytes, of compressed cache entry data above which the entry is split
+// across multiple secrets. Leaves plenty of headroom under the 1MB k8s secret size limit for secret metadata.
+const DefaultShardThreshold = 768 * 1024
+
+// annotation added to a cache entry secret whose data has been split across shard secrets, recording the
+// number of shards
+const shardCountAnnotation = "yale.terra.bio/cache-entry-shards"
+
+// label key/value pair added to all cache entry shard Secrets. Shards deliberately do not carry the cache entry
+// label, so that List does not mistake them for cache entries.
+const shardLabelKey = "yale.terra.bio/cache-entry-shard"
+const shardLabelValue = "true"
+
+// shardSecretName returns the name of the secret storing the i'th shard of the given cache entry secret
+func shardSecretName(secretName string, i int) string {
+	return fmt.Sprintf("%s-%d", secretName, i)
+}
+
+// shardCount returns the number of shards the cache entry secret's data is split across, or 0 if it is not sharded
+func shardCount(s *Record) (int, error) {
+	value, exists := s.Annotations[shardCountAnnotation]
+	if !exists {
+		return 0, nil
+	}
+	count, err := strconv.Atoi(value)
+	if err != nil || count < 0 {
+		return 0, fmt.Errorf("invalid cache entry secret %s: invalid %s annotation %q", s.Name, shardCountAnnotation, value)
+	}
+	return count, nil
+}
+
+// splitIntoShards splits the compressed data in the marshalled cache entry secret across shard secrets, if it
+// exceeds the shard threshold, and records the number of shards on the cache entry secret. Returns the shard
+// secrets, which have not yet been written to the cluster, or nil if the entry is small enough to not need them.
+func (c *cache) splitIntoShards(s *Record) []*Record {
+	data := s.Data[compressedSecretKey]
+	if len(data) <= c.options.ShardThreshold {
+		delete(s.Annotations, shardCountAnnotation)
+		return nil
+	}
+
+	var shards []*Record
+	for start := 0; start < len(data); start += c.options.ShardThreshold {
+		end := min(start+c.options.ShardThreshold, len(data))
+		shards = append(shards, &Record{
+			Name: shardSecretName(s.Name, len(shards)),
+			Labels: map[string]string{
+				shardLabelKey: shardLabelValue,
+			},
+			Data: map[string][]byte{
+				compressedSecretKey: data[start:end],
+			},
+		})
+	}
+
+	logs.Info.Printf("cache entry secret %s has %d bytes of compressed data, splitting it across %d shards", s.Name, len(data), len(shards))
+	delete(s.Data, compressedSecretKey)
+	if s.Annotations == nil {
+		s.Annotations = make(map[string]string)
+	}
+	s.Annotations[shardCountAnnotation] = strconv.Itoa(len(shards))
+	return shards
+}
+
+// writeShards creates or updates the given shard secrets in the store
+func (c *cache) writeShards(shards []*Record) error {
+	for _, shard := range shards {
+		if err := c.store.Put(shard); err != nil {
+			return fmt.Errorf("error writing shard secret %s: %v", shard.Name, err)
+		}
+	}
+	return nil
+}
+
+// deleteShards deletes shards [from, to) of the given cache entry secret. It is not an error if a shard no
+// longer exists.
+func (c *cache) deleteShards(secretName string, from int, to int) error {
+	for i := from; i < to; i++ {
+		name := shardSecretName(secretName, i)
+		logs.Info.Printf("deleting orphaned cache entry shard secret %s", name)
+		if err := c.store.Delete(name); err != nil {
+			return fmt.Errorf("error deleting shard secret %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// reassembleShards reads the shards of a sharded cache entry secret from the store and concatenates their
+// data back into the secret, so it can be unmarshalled like any other cache entry secret
+func (c *cache) reassembleShards(s *Record) error {
+	count, err := shardCount(s)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+
+	var data []byte
+	for i := 0; i < count; i++ {
+		name := shardSecretName(s.Name, i)
+		shard, err := c.store.Get(name)
+		if err != nil {
+			return fmt.Errorf("error reading shard %d of cache entry secret %s: %v", i, s.Name, err)
+		}
+		if shard == nil {
+			return fmt.Errorf("error reading shard %d of cache entry secret %s: shard secret %s does not exist", i, s.Name, name)
+		}
+		chunk, exists := shard.Data[compressedSecretKey]
+		if !exists {
+			return fmt.Errorf("invalid shard secret %s for cache entry secret %s: missing %q key", name, s.Name, compressedSecretKey)
+		}
+		data = append(data, chunk...)
+	}
+
+	if s.Data == nil {
+		s.Data = make(map[string][]byte)
+	}
+	s.Data[compressedSecretKey] = data
+	return nil
+}
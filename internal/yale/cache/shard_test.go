@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/broadinstitute/yale/internal/yale/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_cacheShardsOversizedEntries(t *testing.T) {
+	k8s := testutils.NewFakeK8sClient(t)
+	cache := New(k8s, namespace, func(options *Options) {
+		options.ShardThreshold = 1024
+	})
+
+	entry, err := cache.GetOrCreate(sa1)
+	require.NoError(t, err)
+	_, err = cache.GetOrCreate(sa2)
+	require.NoError(t, err)
+
+	// small entries should not be sharded
+	secret := readCacheSecret(t, k8s, sa1.cacheSecretName())
+	require.NotNil(t, secret)
+	assert.NotContains(t, secret.Annotations, shardCountAnnotation)
+	assert.Nil(t, readCacheSecret(t, k8s, shardSecretName(sa1.cacheSecretName(), 0)))
+
+	// grow the entry past the shard threshold and save it
+	for i := 0; i < 1000; i++ {
+		entry.SyncStatus[fmt.Sprintf("my-secret-%d/my-namespace-%d", i, i)] = fmt.Sprintf("sha256-hash-of-spec-number-%d", i)
+	}
+	require.NoError(t, cache.Save(entry))
+
+	secret = readCacheSecret(t, k8s, sa1.cacheSecretName())
+	require.NotNil(t, secret)
+	require.Contains(t, secret.Annotations, shardCountAnnotation)
+	assert.NotContains(t, secret.Data, compressedSecretKey)
+	shards, err := strconv.Atoi(secret.Annotations[shardCountAnnotation])
+	require.NoError(t, err)
+	assert.Greater(t, shards, 1)
+	for i := 0; i < shards; i++ {
+		shard := readCacheSecret(t, k8s, shardSecretName(sa1.cacheSecretName(), i))
+		require.NotNil(t, shard, "shard %d should exist", i)
+		assert.LessOrEqual(t, len(shard.Data[compressedSecretKey]), 1024)
+		assert.NotContains(t, shard.Labels, labelKey)
+	}
+	assert.Nil(t, readCacheSecret(t, k8s, shardSecretName(sa1.cacheSecretName(), shards)))
+
+	// the sharded entry should be reassembled on read
+	entryCopy, err := cache.GetOrCreate(sa1)
+	require.NoError(t, err)
+	assert.Equal(t, entry, entryCopy)
+
+	// list should return a single logical entry per sharded entry
+	entries, err := cache.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, sa1.Identify(), entries[0].Identify())
+	assert.Equal(t, entry, entries[0])
+	assert.Equal(t, sa2.Identify(), entries[1].Identify())
+
+	// shrinking the entry should clean up the orphaned shards
+	entry.SyncStatus = map[string]string{"my-secret/my-namespace": "my-hash"}
+	require.NoError(t, cache.Save(entry))
+
+	secret = readCacheSecret(t, k8s, sa1.cacheSecretName())
+	require.NotNil(t, secret)
+	assert.NotContains(t, secret.Annotations, shardCountAnnotation)
+	for i := 0; i < shards; i++ {
+		assert.Nil(t, readCacheSecret(t, k8s, shardSecretName(sa1.cacheSecretName(), i)), "shard %d should be deleted", i)
+	}
+
+	entryCopy, err = cache.GetOrCreate(sa1)
+	require.NoError(t, err)
+	assert.Equal(t, entry, entryCopy)
+}
+
+func Test_cacheDeleteRemovesShards(t *testing.T) {
+	k8s := testutils.NewFakeK8sClient(t)
+	cache := New(k8s, namespace, func(options *Options) {
+		options.ShardThreshold = 1024
+	})
+
+	entry, err := cache.GetOrCreate(sa1)
+	require.NoError(t, err)
+	for i := 0; i < 1000; i++ {
+		entry.SyncStatus[fmt.Sprintf("my-secret-%d/my-namespace-%d", i, i)] = fmt.Sprintf("sha256-hash-of-spec-number-%d", i)
+	}
+	require.NoError(t, cache.Save(entry))
+	require.NotNil(t, readCacheSecret(t, k8s, shardSecretName(sa1.cacheSecretName(), 0)))
+
+	require.NoError(t, cache.Delete(entry))
+
+	secrets, err := k8s.CoreV1().Secrets(namespace).List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, secrets.Items)
+}
+
+func Test_cacheReportsMissingShards(t *testing.T) {
+	k8s := testutils.NewFakeK8sClient(t)
+	cache := New(k8s, namespace, func(options *Options) {
+		options.ShardThreshold = 1024
+	})
+
+	entry, err := cache.GetOrCreate(sa1)
+	require.NoError(t, err)
+	for i := 0; i < 1000; i++ {
+		entry.SyncStatus[fmt.Sprintf("my-secret-%d/my-namespace-%d", i, i)] = fmt.Sprintf("sha256-hash-of-spec-number-%d", i)
+	}
+	require.NoError(t, cache.Save(entry))
+
+	name := shardSecretName(sa1.cacheSecretName(), 0)
+	require.NoError(t, k8s.CoreV1().Secrets(namespace).Delete(context.Background(), name, metav1.DeleteOptions{}))
+
+	_, err = cache.GetOrCreate(sa1)
+	assert.ErrorContains(t, err, "error reading shard 0 of cache entry secret")
+	_, err = cache.List()
+	assert.ErrorContains(t, err, "error reading shard 0 of cache entry secret")
+}
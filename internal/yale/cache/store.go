@@ -0,0 +1,28 @@
+package cache
+
+// Record is the unit of storage for the cache: a named collection of data, along with labels and annotations
+// describing it. Each cache entry is stored as a single record, unless it is large enough to be split across
+// shard records.
+type Record struct {
+	// Name unique name of the record
+	Name string
+	// Labels used to find records with List
+	Labels map[string]string
+	// Annotations metadata describing how the record's data is stored (eg. whether it is sharded or encrypted)
+	Annotations map[string]string
+	// Data the record's data
+	Data map[string][]byte
+}
+
+// Store persists cache records. Stores save and return records as-is; marshalling, compression, encryption and
+// sharding of cache entries are handled by the cache, so they behave the same regardless of backend.
+type Store interface {
+	// Get returns the record with the given name, or nil if it does not exist
+	Get(name string) (*Record, error)
+	// Put creates the record, or replaces it if it already exists
+	Put(record *Record) error
+	// Delete deletes the record with the given name. It is not an error if the record does not exist
+	Delete(name string) error
+	// List returns all records with the given label, sorted by name
+	List(labelKey string, labelValue string) ([]*Record, error)
+}
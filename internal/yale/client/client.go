@@ -5,8 +5,14 @@ package client
 import (
 	secretmanager "cloud.google.com/go/secretmanager/apiv1"
 	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/broadinstitute/yale/internal/yale/keysync/consul"
 	"github.com/broadinstitute/yale/internal/yale/keysync/github"
+	"github.com/broadinstitute/yale/internal/yale/keysync/gitlab"
+	"github.com/broadinstitute/yale/internal/yale/logs"
 	githubapi "github.com/google/go-github/v62/github"
+	"net/http"
 	"os"
 
 	monitoring "cloud.google.com/go/monitoring/apiv3/v2"
@@ -19,9 +25,11 @@ import (
 	"github.com/manicminer/hamilton/msgraph"
 	"golang.org/x/net/context"
 	"golang.org/x/oauth2/google"
+	"google.golang.org/api/cloudkms/v1"
 	"google.golang.org/api/iam/v1"
 	"google.golang.org/api/idtoken"
 	"google.golang.org/api/option"
+	"google.golang.org/api/storage/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
@@ -34,6 +42,13 @@ const vaultSecretIdEnvVar = "VAULT_SECRET_ID"
 
 const githubAuthTokenEnvVar = "GITHUB_AUTH_TOKEN"
 
+const gitlabAuthTokenEnvVar = "GITLAB_AUTH_TOKEN"
+const gitlabURLEnvVar = "GITLAB_URL"
+
+// standard environment variables read by the Consul CLI and API clients
+const consulAddressEnvVar = "CONSUL_HTTP_ADDR"
+const consulTokenEnvVar = "CONSUL_HTTP_TOKEN"
+
 // Clients struct containing the GCP and k8s clients used in this tool
 type Clients struct {
 	iam           *iam.Service
@@ -44,6 +59,11 @@ type Clients struct {
 	secretmanager *secretmanager.Client
 	azure         *msgraph.ApplicationsClient
 	github        github.Client
+	gitlab        gitlab.Client
+	aws           *aws.Config
+	kms           *cloudkms.Service
+	gcs           *storage.Service
+	consul        consul.Client
 }
 
 func NewClients(
@@ -55,6 +75,11 @@ func NewClients(
 	secretManager *secretmanager.Client,
 	azure *msgraph.ApplicationsClient,
 	github github.Client,
+	gitlab gitlab.Client,
+	aws *aws.Config,
+	kms *cloudkms.Service,
+	gcs *storage.Service,
+	consul consul.Client,
 ) *Clients {
 	return &Clients{
 		iam:           iam,
@@ -65,6 +90,11 @@ func NewClients(
 		secretmanager: secretManager,
 		azure:         azure,
 		github:        github,
+		gitlab:        gitlab,
+		aws:           aws,
+		kms:           kms,
+		gcs:           gcs,
+		consul:        consul,
 	}
 }
 
@@ -106,9 +136,58 @@ func (c *Clients) GetGitHub() github.Client {
 	return c.github
 }
 
+func (c *Clients) GetGitLab() gitlab.Client {
+	return c.gitlab
+}
+
+// GetAWS will return the AWS SDK config generated by the builder, or nil if AWS is not configured
+func (c *Clients) GetAWS() *aws.Config {
+	return c.aws
+}
+
+// GetKMS will return a handle to the GCP KMS client generated by the builder
+func (c *Clients) GetKMS() *cloudkms.Service {
+	return c.kms
+}
+
+// GetGCS will return a handle to the gcs client generated by the builder
+func (c *Clients) GetGCS() *storage.Service {
+	return c.gcs
+}
+
+// GetConsul will return the Consul KV client generated by the builder
+func (c *Clients) GetConsul() consul.Client {
+	return c.consul
+}
+
+// Options configuration options for building clients
+type Options struct {
+	// GitHubApp if set, GitHub replications authenticate as this GitHub App installation instead of
+	// with the personal access token in $GITHUB_AUTH_TOKEN
+	GitHubApp *GitHubAppOptions
+}
+
+// GitHubAppOptions GitHub App credentials to authenticate GitHub replications with
+type GitHubAppOptions struct {
+	// AppID id of the GitHub App
+	AppID int64
+	// InstallationID id of the App's installation in the org that secrets are written to
+	InstallationID int64
+	// PrivateKeyFile path to a file (eg. a mounted K8s secret) containing the App's PEM-encoded private key
+	PrivateKeyFile string
+}
+
+// Option function for configuring Options
+type Option func(*Options)
+
 // Build creates the GCP and k8s clients used by this tool
 // and returns both packaged in a single struct
-func Build(local bool, kubeconfig string) (*Clients, error) {
+func Build(local bool, kubeconfig string, options ...Option) (*Clients, error) {
+	var opts Options
+	for _, option := range options {
+		option(&opts)
+	}
+
 	conf, err := buildKubeConfig(local, kubeconfig)
 	if err != nil {
 		return nil, fmt.Errorf("error building kube client: %v", err)
@@ -146,9 +225,72 @@ func Build(local bool, kubeconfig string) (*Clients, error) {
 		return nil, fmt.Errorf("error building Azure Graph client: %v", err)
 	}
 
-	_github := buildGitHubClient()
+	kms, err := buildKMSClient()
+	if err != nil {
+		return nil, fmt.Errorf("error building GCP KMS client: %v", err)
+	}
+
+	_github, err := buildGitHubClient(opts.GitHubApp)
+	if err != nil {
+		return nil, fmt.Errorf("error building GitHub client: %v", err)
+	}
+
+	_gitlab := buildGitLabClient()
+
+	_aws := buildAWSConfig()
+
+	gcs, err := buildGCSClient()
+	if err != nil {
+		return nil, fmt.Errorf("error building GCS client: %v", err)
+	}
+
+	_consul := buildConsulClient()
+
+	return NewClients(_iam, metrics, k8s, crd, vault, secretManager, azure, _github, _gitlab, _aws, kms, gcs, _consul), nil
+}
+
+// BuildK8s creates only the k8s client, for commands that read the cache and should not
+// require GCP or Azure credentials
+func BuildK8s(local bool, kubeconfig string) (kubernetes.Interface, error) {
+	conf, err := buildKubeConfig(local, kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("error building kube client: %v", err)
+	}
+	k8s, err := buildKubeClient(conf)
+	if err != nil {
+		return nil, fmt.Errorf("error building kube client: %v", err)
+	}
+	return k8s, nil
+}
+
+// BuildKMS creates only the GCP KMS client, for commands that need to read encrypted cache entries
+// without building the other clients
+func BuildKMS() (*cloudkms.Service, error) {
+	return buildKMSClient()
+}
+
+// BuildSecretManager creates only the Google Secret Manager client, for commands that need to read cache entries
+// stored in GSM without building the other clients
+func BuildSecretManager() (*secretmanager.Client, error) {
+	return buildSecretManagerClient()
+}
 
-	return NewClients(_iam, metrics, k8s, crd, vault, secretManager, azure, _github), nil
+// BuildTargetClusters creates K8s clients for additional clusters that Yale can sync secrets to,
+// from a map of cluster name to kubeconfig path
+func BuildTargetClusters(kubeconfigs map[string]string) (map[string]kubernetes.Interface, error) {
+	result := make(map[string]kubernetes.Interface)
+	for name, kubeconfig := range kubeconfigs {
+		conf, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("error building k8s config for cluster %s from %s: %v", name, kubeconfig, err)
+		}
+		k8s, err := buildKubeClient(conf)
+		if err != nil {
+			return nil, fmt.Errorf("error building kube client for cluster %s: %v", name, err)
+		}
+		result[name] = k8s
+	}
+	return result, nil
 }
 
 func buildKubeConfig(local bool, kubeconfig string) (*restclient.Config, error) {
@@ -236,9 +378,84 @@ func buildSecretManagerClient() (*secretmanager.Client, error) {
 	return client, nil
 }
 
-func buildGitHubClient() github.Client {
-	gitubapiClient := githubapi.NewClient(nil).WithAuthToken(os.Getenv(githubAuthTokenEnvVar))
-	return github.NewClient(gitubapiClient)
+func buildKMSClient() (*cloudkms.Service, error) {
+	ctx := context.Background()
+	c, err := cloudkms.NewService(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error creating kms api client: %v", err)
+	}
+	return c, nil
+}
+
+func buildGCSClient() (*storage.Service, error) {
+	ctx := context.Background()
+	c, err := storage.NewService(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error creating gcs api client: %v", err)
+	}
+	return c, nil
+}
+
+// buildGitHubClient authenticates as the GitHub App installation if app is set, and otherwise falls back
+// to the token in $GITHUB_AUTH_TOKEN. It returns nil if neither is configured, so that
+// resources with GitHub replications fail with a clear error
+func buildGitHubClient(app *GitHubAppOptions) (github.Client, error) {
+	if app != nil {
+		privateKey, err := os.ReadFile(app.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading GitHub App private key file %s: %v", app.PrivateKeyFile, err)
+		}
+		logs.Info.Printf("Authenticating to GitHub as installation %d of GitHub App %d", app.InstallationID, app.AppID)
+		return github.NewAppClient(github.AppCredentials{
+			AppID:          app.AppID,
+			InstallationID: app.InstallationID,
+			PrivateKey:     privateKey,
+		})
+	}
+	token := os.Getenv(githubAuthTokenEnvVar)
+	if token == "" {
+		logs.Warn.Printf("GitHub replication is unavailable; set `%s` or the -github-app-* flags to enable it", githubAuthTokenEnvVar)
+		return nil, nil
+	}
+	gitubapiClient := githubapi.NewClient(nil).WithAuthToken(token)
+	return github.NewClient(gitubapiClient), nil
+}
+
+// buildGitLabClient returns nil if no GitLab token is configured, so that
+// resources with GitLab replications fail with a clear error
+func buildGitLabClient() gitlab.Client {
+	token := os.Getenv(gitlabAuthTokenEnvVar)
+	if token == "" {
+		logs.Warn.Printf("GitLab replication is unavailable; set `%s` to enable it", gitlabAuthTokenEnvVar)
+		return nil
+	}
+	baseURL := os.Getenv(gitlabURLEnvVar)
+	if baseURL == "" {
+		baseURL = gitlab.DefaultBaseURL
+	}
+	return gitlab.NewClient(baseURL, token, http.DefaultClient)
+}
+
+// buildConsulClient returns a client for the Consul agent at $CONSUL_HTTP_ADDR, or the local agent if it is
+// unset. Replications can override the address, so unlike other backends the client is always built
+func buildConsulClient() consul.Client {
+	address := os.Getenv(consulAddressEnvVar)
+	if address == "" {
+		address = consul.DefaultAddress
+	}
+	return consul.NewClient(address, os.Getenv(consulTokenEnvVar), http.DefaultClient)
+}
+
+// buildAWSConfig loads AWS credentials from the SDK's default chain (environment, shared config, or
+// EKS/EC2 roles). It returns nil if they can't be loaded, so that resources with AWS Secrets Manager
+// replications fail with a clear error
+func buildAWSConfig() *aws.Config {
+	cfg, err := awsconfig.LoadDefaultConfig(context.Background())
+	if err != nil {
+		logs.Warn.Printf("AWS Secrets Manager replication is unavailable; error loading AWS config: %v", err)
+		return nil
+	}
+	return &cfg
 }
 
 const azureFederatedCredentialAudience = "api://AzureADTokenExchange"
@@ -0,0 +1,81 @@
+package yale
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/broadinstitute/yale/internal/yale/logs"
+	"github.com/broadinstitute/yale/internal/yale/resourcemap"
+)
+
+// CompactCacheOptions options for a one-off cache compaction
+type CompactCacheOptions struct {
+	// DryRun if true, log which SyncStatus keys would be removed without saving any changes
+	DryRun bool
+}
+
+// CompactCache removes SyncStatus keys for GcpSaKeys and AzureClientSecrets that no longer exist from every
+// cache entry. Regular runs only prune keys for entries whose resources still exist, so entries whose
+// resources were all deleted can otherwise accumulate dead keys indefinitely. It is safe to run repeatedly.
+// Returns the number of keys removed from each entry, by identifier.
+func (m *Yale) CompactCache(opts CompactCacheOptions) (map[string]int, error) {
+	resources, err := m.resourcemap.Build()
+	if err != nil {
+		return nil, fmt.Errorf("error inspecting cluster for cache entries and GcpSaKey resources: %v", err)
+	}
+
+	var identifiers []string
+	for identifier := range resources {
+		identifiers = append(identifiers, identifier)
+	}
+	sort.Strings(identifiers)
+
+	removed := make(map[string]int)
+	for _, identifier := range identifiers {
+		entry := resources[identifier].Entry
+		keep := existingStatusKeys(resources[identifier])
+
+		var stale []string
+		for key := range entry.SyncStatus {
+			if _, exists := keep[key]; !exists {
+				stale = append(stale, key)
+			}
+		}
+		if len(stale) == 0 {
+			continue
+		}
+		removed[identifier] = len(stale)
+
+		if opts.DryRun {
+			logs.Info.Printf("compact-cache: [dry run] would remove %d of %d SyncStatus keys from %s %s", len(stale), len(entry.SyncStatus), entry.Type, identifier)
+			continue
+		}
+		for _, key := range stale {
+			delete(entry.SyncStatus, key)
+		}
+		if err = m.cache.Save(entry); err != nil {
+			return removed, fmt.Errorf("error saving compacted cache entry for %s %s: %v", entry.Type, identifier, err)
+		}
+		logs.Info.Printf("compact-cache: removed %d SyncStatus keys from %s %s; %d remain", len(stale), entry.Type, identifier, len(entry.SyncStatus))
+	}
+
+	if opts.DryRun {
+		logs.Info.Printf("compact-cache: [dry run] would remove SyncStatus keys from %d of %d cache entries", len(removed), len(identifiers))
+	} else {
+		logs.Info.Printf("compact-cache: removed SyncStatus keys from %d of %d cache entries", len(removed), len(identifiers))
+	}
+	return removed, nil
+}
+
+// existingStatusKeys returns the SyncStatus keys, in the form "<namespace>/<name>", of the GcpSaKeys and
+// AzureClientSecrets in the bundle
+func existingStatusKeys(bundle *resourcemap.Bundle) map[string]struct{} {
+	keys := make(map[string]struct{})
+	for _, gsk := range bundle.GSKs {
+		keys[gsk.Namespace()+"/"+gsk.Name()] = struct{}{}
+	}
+	for _, acs := range bundle.AzClientSecrets {
+		keys[acs.Namespace()+"/"+acs.Name()] = struct{}{}
+	}
+	return keys
+}
@@ -0,0 +1,34 @@
+package yale
+
+import (
+	"errors"
+	"sync"
+)
+
+// maxConcurrentKeyOps limit on the number of keys in a single cache entry that Yale will disable or delete at once
+const maxConcurrentKeyOps = 4
+
+// forEachConcurrently calls fn for every item, running at most limit calls at a time. Every item is
+// processed even if some calls fail; the errors of all failed calls are joined together.
+func forEachConcurrently[T any](limit int, items []T, fn func(T) error) error {
+	if limit < 1 {
+		limit = 1
+	}
+
+	errs := make([]error, len(items))
+	semaphore := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+
+	for i, item := range items {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int, item T) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			errs[i] = fn(item)
+		}(i, item)
+	}
+
+	wg.Wait()
+	return errors.Join(errs...)
+}
@@ -11,9 +11,14 @@ import (
 type AzureClientSecretSpec struct {
 	AzureServicePrincipal           AzureServicePrincipal            `json:"azureServicePrincipal"`
 	Secret                          Secret                           `json:"secret"`
+	AdditionalSecrets               []Secret                         `json:"additionalSecrets,omitempty" yaml:",omitempty"`
 	VaultReplications               []VaultReplication               `json:"vaultReplications"`
 	GoogleSecretManagerReplications []GoogleSecretManagerReplication `json:"googleSecretManagerReplications"`
 	GitHubReplications              []GitHubReplication              `json:"githubReplications"`
+	AWSSecretsManagerReplications   []AWSSecretsManagerReplication   `json:"awsSecretsManagerReplications,omitempty"`
+	GitLabReplications              []GitLabReplication              `json:"gitlabReplications,omitempty"`
+	GCSReplications                 []GCSReplication                 `json:"gcsReplications,omitempty"`
+	ConsulReplications              []ConsulReplication              `json:"consulReplications,omitempty"`
 	KeyRotation                     KeyRotation                      `json:"keyRotation"`
 }
 
@@ -22,11 +27,18 @@ type AzureServicePrincipal struct {
 	ApplicationID string `json:"applicationID"`
 }
 
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Current Key",type=string,JSONPath=`.status.currentKeyID`
+// +kubebuilder:printcolumn:name="Key Age",type=date,JSONPath=`.status.lastRotatedAt`
+// +kubebuilder:printcolumn:name="Last Synced",type=date,JSONPath=`.status.lastSyncedAt`
+// +kubebuilder:printcolumn:name="Error",type=string,JSONPath=`.status.error`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 type AzureClientSecret struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec AzureClientSecretSpec `json:"spec"`
+	Spec   AzureClientSecretSpec `json:"spec"`
+	Status Status                `json:"status,omitempty"`
 }
 
 type AzureClientSecretList struct {
@@ -46,6 +58,7 @@ func (in *AzureClientSecret) DeepCopyInto(out *AzureClientSecret) {
 		Secret:                in.Spec.Secret,
 		KeyRotation:           in.Spec.KeyRotation,
 	}
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopyObject returns a generically typed copy of an object
@@ -98,6 +111,22 @@ func (g AzureClientSecret) GitHubReplications() []GitHubReplication {
 	return g.Spec.GitHubReplications
 }
 
+func (g AzureClientSecret) AWSSecretsManagerReplications() []AWSSecretsManagerReplication {
+	return g.Spec.AWSSecretsManagerReplications
+}
+
+func (g AzureClientSecret) GitLabReplications() []GitLabReplication {
+	return g.Spec.GitLabReplications
+}
+
+func (g AzureClientSecret) GCSReplications() []GCSReplication {
+	return g.Spec.GCSReplications
+}
+
+func (g AzureClientSecret) ConsulReplications() []ConsulReplication {
+	return g.Spec.ConsulReplications
+}
+
 func (g AzureClientSecret) APIVersion() string {
 	return g.TypeMeta.APIVersion
 }
@@ -117,3 +146,7 @@ func (g AzureClientSecret) Labels() map[string]string {
 func (g AzureClientSecret) Secret() Secret {
 	return g.Spec.Secret
 }
+
+func (g AzureClientSecret) AdditionalSecrets() []Secret {
+	return g.Spec.AdditionalSecrets
+}
@@ -0,0 +1,71 @@
+package v1beta1
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// GSMSecretNameVariables variables that can be used in a GSM replication's secret name template
+var GSMSecretNameVariables = []string{".Project", ".Identifier", ".Scope", ".Namespace", ".Name"}
+
+// matches valid GSM secret ids
+var gsmSecretIDRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,255}$`)
+
+// matches characters that are not allowed in GSM secret ids
+var illegalGSMSecretIDCharsRegexp = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// GSMSecretNameContext is the data that GSM replication secret names are rendered with
+type GSMSecretNameContext struct {
+	// Project GCP project the GSM secret is in
+	Project string
+	// Identifier identifier of the cache entry (eg. the service account email, or the Azure application ID)
+	Identifier string
+	// Scope scope of the cache entry (eg. the service account's project, or the Azure tenant ID)
+	Scope string
+	// Namespace namespace of the resource being synced
+	Namespace string
+	// Name name of the resource being synced
+	Name string
+}
+
+// RenderSecretName renders the replication's secret name, which may be a text/template string (eg.
+// "{{.Namespace}}-sa-key"), with the given context. The "sanitize" function replaces any characters that are
+// not allowed in GSM secret ids with "_", for values like service account emails (eg. "{{sanitize .Identifier}}").
+func (r GoogleSecretManagerReplication) RenderSecretName(ctx GSMSecretNameContext) (string, error) {
+	if !strings.Contains(r.Secret, "{{") {
+		return r.Secret, nil
+	}
+	tmpl, err := template.New("secret").Funcs(template.FuncMap{
+		"sanitize": func(s string) string {
+			return illegalGSMSecretIDCharsRegexp.ReplaceAllString(s, "_")
+		},
+	}).Parse(r.Secret)
+	if err != nil {
+		return "", fmt.Errorf("error parsing secret name template %q: %v", r.Secret, err)
+	}
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, ctx); err != nil {
+		return "", fmt.Errorf("error rendering secret name template %q (supported variables are %s): %v", r.Secret, strings.Join(GSMSecretNameVariables, ", "), err)
+	}
+	name := buf.String()
+	if !gsmSecretIDRegexp.MatchString(name) {
+		return "", fmt.Errorf("secret name template %q rendered to %q, which is not a valid GSM secret id (only letters, numbers, \"-\", and \"_\" are allowed)", r.Secret, name)
+	}
+	return name, nil
+}
+
+// validateSecretName checks that the secret name template parses and only uses supported variables, by
+// rendering it with placeholder values
+func (r GoogleSecretManagerReplication) validateSecretName() error {
+	_, err := r.RenderSecretName(GSMSecretNameContext{
+		Project:    "project",
+		Identifier: "identifier",
+		Scope:      "scope",
+		Namespace:  "namespace",
+		Name:       "name",
+	})
+	return err
+}
@@ -17,23 +17,45 @@ type YaleCRD interface {
 type GCPSaKeySpec struct {
 	GoogleServiceAccount            GoogleServiceAccount             `json:"googleServiceAccount"`
 	Secret                          Secret                           `json:"secret"`
+	AdditionalSecrets               []Secret                         `json:"additionalSecrets,omitempty" yaml:",omitempty"`
 	VaultReplications               []VaultReplication               `json:"vaultReplications"`
 	GoogleSecretManagerReplications []GoogleSecretManagerReplication `json:"googleSecretManagerReplications"`
 	GitHubReplications              []GitHubReplication              `json:"githubReplications"`
+	AWSSecretsManagerReplications   []AWSSecretsManagerReplication   `json:"awsSecretsManagerReplications,omitempty"`
+	GitLabReplications              []GitLabReplication              `json:"gitlabReplications,omitempty"`
+	GCSReplications                 []GCSReplication                 `json:"gcsReplications,omitempty"`
+	ConsulReplications              []ConsulReplication              `json:"consulReplications,omitempty"`
 	KeyRotation                     KeyRotation                      `json:"keyRotation"`
+	// KeyAlgorithm Optional key algorithm for new keys (eg. KEY_ALG_RSA_1024); defaults to KEY_ALG_RSA_2048
+	KeyAlgorithm string `json:"keyAlgorithm,omitempty"`
+	// KeyType Optional private key type for new keys; defaults to TYPE_GOOGLE_CREDENTIALS_FILE
+	KeyType string `json:"keyType,omitempty"`
 }
 
 type GoogleServiceAccount struct {
-	Name    string `json:"name"`
-	Project string `json:"project"`
+	Name     string `json:"name"`
+	Project  string `json:"project"`
+	UniqueID string `json:"uniqueId,omitempty"`
 }
 
 type Secret struct {
 	Name        string `json:"name"`
 	PemKeyName  string `json:"pemKeyName"`
 	JsonKeyName string `json:"jsonKeyName"`
+	// Base64KeyName Optional name of a data field to store the base64-encoded service account key JSON in
+	// (eg. for tools that read GOOGLE_CREDENTIALS_B64). Only supported for GCP service account keys
+	Base64KeyName string `json:"base64KeyName,omitempty"`
 	// ClientSecretKeyName Optional field to specify the key name for an azure client secret
 	ClientSecretKeyName string `json:"clientSecretKeyName,omitempty"`
+	// TargetClusters Optional names of additional clusters the secret should be synced to, in addition to the local cluster
+	TargetClusters []string `json:"targetClusters,omitempty"`
+	// Annotations Optional annotations to add to the secret, in addition to the reloader annotation
+	Annotations map[string]string `json:"annotations,omitempty" yaml:",omitempty"`
+	// DisableReloaderAnnotation Optional; if true, Yale will not add the reloader annotation to the secret,
+	// and will remove it if it is already present
+	DisableReloaderAnnotation bool `json:"disableReloaderAnnotation,omitempty"`
+	// Type Optional K8s secret type (eg. "kubernetes.io/dockerconfigjson") to create the secret with. Defaults to Opaque
+	Type string `json:"type,omitempty"`
 }
 
 type KeyRotation struct {
@@ -41,12 +63,45 @@ type KeyRotation struct {
 	DeleteAfter        int  `json:"deleteAfter"`
 	DisableAfter       int  `json:"disableAfter"`
 	IgnoreUsageMetrics bool `json:"ignoreUsageMetrics"`
+	// RotateAfterDuration Optional duration (eg. "12h") to wait before rotating a key. Overrides RotateAfter
+	RotateAfterDuration string `json:"rotateAfterDuration,omitempty"`
+	// DeleteAfterDuration Optional duration (eg. "12h") to wait before deleting a disabled key. Overrides DeleteAfter
+	DeleteAfterDuration string `json:"deleteAfterDuration,omitempty"`
+	// DisableAfterDuration Optional duration (eg. "12h") to wait before disabling a rotated key. Overrides DisableAfter
+	DisableAfterDuration string `json:"disableAfterDuration,omitempty"`
+	// Paused Optional; if true, Yale will not rotate the current key. Keys that were already rotated are
+	// still disabled and deleted
+	Paused bool `json:"paused,omitempty"`
+	// Decommission Optional; if true, Yale will not issue any new keys. The current key is retired (rotated,
+	// then disabled and deleted) once it reaches RotateAfter, as are keys that were already rotated
+	Decommission bool `json:"decommission,omitempty"`
+	// MaxKeyAge Optional number of days after which Yale sends an alert if the current key still hasn't been
+	// rotated (eg. because rotation is blocked by an old key that is still in use). Does not force rotation
+	MaxKeyAge int `json:"maxKeyAge,omitempty"`
+	// SafeToDisableBufferDays Optional number of days a rotated key must have gone unused before Yale considers it
+	// safe to disable. Defaults to, and cannot be less than, 3 days
+	SafeToDisableBufferDays int `json:"safeToDisableBufferDays,omitempty"`
 }
 
 type VaultReplication struct {
 	Path   string            `json:"path"`
 	Format ReplicationFormat `json:"format"`
 	Key    string            `json:"key"`
+	// EngineVersion Optional version of the KV secrets engine mounted at Path, 1 or 2. Defaults to 1
+	EngineVersion int `json:"engineVersion,omitempty"`
+	// Namespace Optional Vault Enterprise namespace Path lives in (eg. "team-a"). Defaults to the client's namespace
+	Namespace string `json:"namespace,omitempty"`
+	// Field Optional top-level field of a GCP service account key (eg. "client_email") to replicate instead of
+	// the whole key. Only supported with the plaintext and base64 formats
+	Field string `json:"field,omitempty"`
+	// Address Optional address of the Vault server to write Path to (eg. "https://vault.enclave.example.com"),
+	// if not Yale's default Vault server. Must be in Yale's allowlist of Vault addresses
+	Address string `json:"address,omitempty"`
+	// Wrap Optional; if true, the key is response-wrapped with Vault's sys/wrapping/wrap endpoint and only the
+	// single-use wrapping token is written to Path, instead of the key itself
+	Wrap bool `json:"wrap,omitempty"`
+	// WrapTTL Optional Go duration (eg. "24h") the wrapping token is valid for, if Wrap is true. Defaults to 24h
+	WrapTTL string `json:"wrapTTL,omitempty"`
 }
 
 type GoogleSecretManagerReplication struct {
@@ -54,6 +109,28 @@ type GoogleSecretManagerReplication struct {
 	Project string            `json:"project"`
 	Format  ReplicationFormat `json:"format"`
 	Key     string            `json:"key"` // if supplied, nest key data in a JSON object { "<key-name>": "<formatted-key>" }
+	// MaxVersions Optional maximum number of versions to keep in a secret created by Yale; after adding a new version,
+	// Yale destroys the oldest versions beyond this limit. Defaults to unlimited
+	MaxVersions int `json:"maxVersions,omitempty"`
+	// Locations Optional GCP locations (eg. "us-central1") to replicate a secret created by Yale to, using
+	// user-managed replication. Defaults to automatic replication
+	Locations []string `json:"locations,omitempty" yaml:",omitempty"`
+	// KmsKeyName Optional Cloud KMS key used to encrypt the replicas of a secret created by Yale (CMEK). Requires Locations
+	KmsKeyName string `json:"kmsKeyName,omitempty"`
+	// Labels Optional labels to add to a secret created by Yale, in addition to the "owned_by: yale" label
+	Labels map[string]string `json:"labels,omitempty" yaml:",omitempty"`
+	// Annotations Optional annotations to add to a secret created by Yale, in addition to the "created-by-yale" annotation
+	Annotations map[string]string `json:"annotations,omitempty" yaml:",omitempty"`
+	// Field Optional top-level field of a GCP service account key (eg. "client_email") to replicate instead of
+	// the whole key. Only supported with the plaintext, base64, and dotenv formats
+	Field string `json:"field,omitempty"`
+}
+
+type AWSSecretsManagerReplication struct {
+	Region     string            `json:"region"`
+	SecretName string            `json:"secretName"`
+	Format     ReplicationFormat `json:"format"`
+	Key        string            `json:"key,omitempty"` // if supplied, nest key data in a JSON object { "<key-name>": "<formatted-key>" }
 }
 
 type GitHubReplication struct {
@@ -61,8 +138,51 @@ type GitHubReplication struct {
 	Repo                 string            `json:"repo"`
 	Format               ReplicationFormat `json:"format"`
 	RequiredByDependabot bool              `json:"requiredByDependabot"` // if supplied, also replicate to Dependabot secrets
+	// Key Optional variable name, required by the dotenv format (eg. GOOGLE_APPLICATION_CREDENTIALS_JSON)
+	Key string `json:"key,omitempty"`
+	// Environment Optional GitHub deployment environment; if supplied, write an Actions environment secret
+	// instead of a repo secret. Cannot be combined with RequiredByDependabot, since Dependabot has no environment secrets
+	Environment string `json:"environment,omitempty"`
+	// Org Optional GitHub organization; if supplied instead of Repo, write an organization-level secret
+	Org string `json:"org,omitempty"`
+	// Visibility Which repos in Org can access the secret: "all", "private", or "selected". Required if Org is set
+	Visibility string `json:"visibility,omitempty"`
+	// SelectedRepositoryIDs IDs of the repos that can access the secret. Required if Visibility is "selected"
+	SelectedRepositoryIDs []int64 `json:"selectedRepositoryIds,omitempty" yaml:",omitempty"`
+	// Kind Optional kind of secret to write: "actions" or "codespaces". Defaults to "actions"
+	Kind string `json:"kind,omitempty"`
+}
+
+type GitLabReplication struct {
+	Project     string            `json:"project"` // full path of the GitLab project, eg. "my-group/my-project"
+	VariableKey string            `json:"variableKey"`
+	Format      ReplicationFormat `json:"format"`
+	Protected   bool              `json:"protected,omitempty"` // if true, only expose the variable to protected branches and tags
+	Masked      bool              `json:"masked,omitempty"`    // if true, mask the variable in job logs
+	// Environment Optional environment scope for the variable; if not supplied, the variable is available to all environments
+	Environment string `json:"environment,omitempty"`
+}
+
+type GCSReplication struct {
+	Bucket string            `json:"bucket"`
+	Object string            `json:"object"` // full name of the object in the bucket, eg. "path/to/key.json"
+	Format ReplicationFormat `json:"format"`
+}
+
+type ConsulReplication struct {
+	// Address Optional address of the Consul agent or server (eg. "https://consul.example.com:8500"); defaults to $CONSUL_HTTP_ADDR
+	Address string            `json:"address,omitempty"`
+	Path    string            `json:"path"` // KV path to write the key to, eg. "my-service/credentials.json"
+	Format  ReplicationFormat `json:"format"`
 }
 
+// visibilities supported for organization-level GitHub secrets
+const (
+	GitHubVisibilityAll      = "all"
+	GitHubVisibilityPrivate  = "private"
+	GitHubVisibilitySelected = "selected"
+)
+
 type ReplicationFormat int64
 
 const (
@@ -71,6 +191,8 @@ const (
 	Base64
 	PEM
 	PlainText
+	YAML
+	Dotenv
 )
 
 // verify format implements expected interfaces
@@ -89,6 +211,10 @@ func (f ReplicationFormat) String() string {
 		return "pem"
 	case PlainText:
 		return "plaintext"
+	case YAML:
+		return "yaml"
+	case Dotenv:
+		return "dotenv"
 	default:
 		return "unknown"
 	}
@@ -96,7 +222,7 @@ func (f ReplicationFormat) String() string {
 
 func (f ReplicationFormat) MarshalText() ([]byte, error) {
 	switch f {
-	case Map, JSON, Base64, PEM, PlainText:
+	case Map, JSON, Base64, PEM, PlainText, YAML, Dotenv:
 		return []byte(f.String()), nil
 	default:
 		return nil, fmt.Errorf("unknown replication format: %#v", f)
@@ -106,7 +232,8 @@ func (f ReplicationFormat) MarshalText() ([]byte, error) {
 func (f *ReplicationFormat) UnmarshalText(data []byte) error {
 	s := string(data)
 	switch s {
-	case "map":
+	case "", "map":
+		// an empty format is treated the same as an omitted one
 		*f = Map
 		return nil
 	case "json":
@@ -121,16 +248,54 @@ func (f *ReplicationFormat) UnmarshalText(data []byte) error {
 	case "plaintext":
 		*f = PlainText
 		return nil
+	case "yaml":
+		*f = YAML
+		return nil
+	case "dotenv":
+		*f = Dotenv
+		return nil
 	default:
 		return fmt.Errorf("unknown replication format: %q", s)
 	}
 }
 
+// Status observed state of a Yale-managed resource, written by Yale at the end of every run that processes it
+type Status struct {
+	// CurrentKeyID ID of the current key
+	CurrentKeyID string `json:"currentKeyID"`
+	// LastRotatedAt time at which the current key was issued
+	LastRotatedAt *metav1.Time `json:"lastRotatedAt"`
+	// LastSyncedAt time at which the current key was last synced to the resource's secret and replications
+	LastSyncedAt *metav1.Time `json:"lastSyncedAt"`
+	// SyncedSecret name of the secret the current key has been synced to, empty if it has not been synced yet
+	SyncedSecret string `json:"syncedSecret"`
+	// Error the error that occurred the last time Yale processed the resource, empty if it succeeded
+	Error string `json:"error"`
+}
+
+// DeepCopyInto copies the status into another status, including the times it points to
+func (in *Status) DeepCopyInto(out *Status) {
+	*out = *in
+	if in.LastRotatedAt != nil {
+		out.LastRotatedAt = in.LastRotatedAt.DeepCopy()
+	}
+	if in.LastSyncedAt != nil {
+		out.LastSyncedAt = in.LastSyncedAt.DeepCopy()
+	}
+}
+
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Current Key",type=string,JSONPath=`.status.currentKeyID`
+// +kubebuilder:printcolumn:name="Key Age",type=date,JSONPath=`.status.lastRotatedAt`
+// +kubebuilder:printcolumn:name="Last Synced",type=date,JSONPath=`.status.lastSyncedAt`
+// +kubebuilder:printcolumn:name="Error",type=string,JSONPath=`.status.error`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 type GcpSaKey struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec GCPSaKeySpec `json:"spec"`
+	Spec   GCPSaKeySpec `json:"spec"`
+	Status Status       `json:"status,omitempty"`
 }
 
 type GCPSaKeyList struct {
@@ -150,6 +315,7 @@ func (in *GcpSaKey) DeepCopyInto(out *GcpSaKey) {
 		Secret:               in.Spec.Secret,
 		KeyRotation:          in.Spec.KeyRotation,
 	}
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopyObject returns a generically typed copy of an object
@@ -202,6 +368,22 @@ func (g GcpSaKey) GitHubReplications() []GitHubReplication {
 	return g.Spec.GitHubReplications
 }
 
+func (g GcpSaKey) AWSSecretsManagerReplications() []AWSSecretsManagerReplication {
+	return g.Spec.AWSSecretsManagerReplications
+}
+
+func (g GcpSaKey) GitLabReplications() []GitLabReplication {
+	return g.Spec.GitLabReplications
+}
+
+func (g GcpSaKey) GCSReplications() []GCSReplication {
+	return g.Spec.GCSReplications
+}
+
+func (g GcpSaKey) ConsulReplications() []ConsulReplication {
+	return g.Spec.ConsulReplications
+}
+
 func (g GcpSaKey) APIVersion() string {
 	return g.TypeMeta.APIVersion
 }
@@ -221,3 +403,7 @@ func (g GcpSaKey) Labels() map[string]string {
 func (g GcpSaKey) Secret() Secret {
 	return g.Spec.Secret
 }
+
+func (g GcpSaKey) AdditionalSecrets() []Secret {
+	return g.Spec.AdditionalSecrets
+}
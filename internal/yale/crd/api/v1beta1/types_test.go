@@ -35,6 +35,14 @@ func Test_ReplicationFormatSerialization(t *testing.T) {
 			str: "plaintext",
 			fmt: PlainText,
 		},
+		{
+			str: "yaml",
+			fmt: YAML,
+		},
+		{
+			str: "dotenv",
+			fmt: Dotenv,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -71,6 +79,19 @@ func Test_ReplicationFormatSerialization(t *testing.T) {
 	}
 }
 
+func Test_ReplicationFormatEmptyDeserialization(t *testing.T) {
+	var r GoogleSecretManagerReplication
+	require.NoError(t, json.Unmarshal([]byte(`{"secret":"foo","format":""}`), &r))
+	assert.Equal(t, Map, r.Format)
+
+	r = GoogleSecretManagerReplication{Format: PEM}
+	require.NoError(t, yaml.Unmarshal([]byte("secret: foo\nformat: \"\"\n"), &r))
+	assert.Equal(t, Map, r.Format)
+
+	var f ReplicationFormat
+	assert.ErrorContains(t, json.Unmarshal([]byte(`"xml"`), &f), `unknown replication format: "xml"`)
+}
+
 func Test_VaultReplicationSerialization(t *testing.T) {
 	v := VaultReplication{
 		Format: PEM,
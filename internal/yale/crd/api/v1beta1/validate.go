@@ -0,0 +1,382 @@
+package v1beta1
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SupportedKeyAlgorithms key algorithms that can be requested when creating new Google SA keys
+var SupportedKeyAlgorithms = []string{"KEY_ALG_RSA_1024", "KEY_ALG_RSA_2048"}
+
+// SupportedKeyTypes private key types that can be requested when creating new Google SA keys. PKCS12 files
+// are not supported, since Yale needs to parse the private key out of the key data when syncing it.
+var SupportedKeyTypes = []string{"TYPE_GOOGLE_CREDENTIALS_FILE"}
+
+// SupportedSecretTypes K8s secret types that synced secrets can be created with
+var SupportedSecretTypes = []string{"Opaque", "kubernetes.io/dockerconfigjson", "kubernetes.io/dockercfg", "kubernetes.io/ssh-auth"}
+
+// requiredSecretDataKeys data keys that K8s requires secrets of each supported type to contain
+var requiredSecretDataKeys = map[string][]string{
+	"kubernetes.io/dockerconfigjson": {".dockerconfigjson"},
+	"kubernetes.io/dockercfg":        {".dockercfg"},
+	"kubernetes.io/ssh-auth":         {"ssh-privatekey"},
+}
+
+// SetDefaults sets the format of any replication that doesn't specify one to JSON. Vault replications are
+// left alone, since the map format (the zero value) has always been their default. Azure client secrets
+// aren't JSON, so AzureClientSecretSpec has no equivalent.
+func (s *GCPSaKeySpec) SetDefaults() {
+	for i := range s.GoogleSecretManagerReplications {
+		defaultFormat(&s.GoogleSecretManagerReplications[i].Format)
+	}
+	for i := range s.GitHubReplications {
+		defaultFormat(&s.GitHubReplications[i].Format)
+	}
+	for i := range s.AWSSecretsManagerReplications {
+		defaultFormat(&s.AWSSecretsManagerReplications[i].Format)
+	}
+	for i := range s.GitLabReplications {
+		defaultFormat(&s.GitLabReplications[i].Format)
+	}
+	for i := range s.GCSReplications {
+		defaultFormat(&s.GCSReplications[i].Format)
+	}
+	for i := range s.ConsulReplications {
+		defaultFormat(&s.ConsulReplications[i].Format)
+	}
+}
+
+// Validate checks the spec for errors that would prevent Yale from managing its keys, returning all of
+// the problems found joined into a single error, or nil if the spec is valid
+func (s *GCPSaKeySpec) Validate() error {
+	var errs []error
+	if s.GoogleServiceAccount.Name == "" {
+		errs = append(errs, fmt.Errorf("missing google service account name"))
+	}
+	if s.GoogleServiceAccount.Project == "" {
+		errs = append(errs, fmt.Errorf("missing google service account project"))
+	}
+	if s.KeyAlgorithm != "" && !slices.Contains(SupportedKeyAlgorithms, s.KeyAlgorithm) {
+		errs = append(errs, fmt.Errorf("unsupported key algorithm %q (must be one of %s)", s.KeyAlgorithm, strings.Join(SupportedKeyAlgorithms, ", ")))
+	}
+	if s.KeyType != "" && !slices.Contains(SupportedKeyTypes, s.KeyType) {
+		errs = append(errs, fmt.Errorf("unsupported key type %q (must be one of %s)", s.KeyType, strings.Join(SupportedKeyTypes, ", ")))
+	}
+	errs = append(errs, s.KeyRotation.validate()...)
+	errs = append(errs, validateSecretTypes(s.Secret, s.AdditionalSecrets, func(secret Secret) []string {
+		return []string{secret.JsonKeyName, secret.PemKeyName, secret.Base64KeyName}
+	})...)
+	errs = append(errs, validateReplications(s.VaultReplications, s.GoogleSecretManagerReplications, s.GitHubReplications, s.GCSReplications, s.ConsulReplications)...)
+	errs = append(errs, validateReplicationFormats(false, s.VaultReplications, s.GoogleSecretManagerReplications, s.GitHubReplications,
+		s.AWSSecretsManagerReplications, s.GitLabReplications, s.GCSReplications, s.ConsulReplications)...)
+	return errors.Join(errs...)
+}
+
+// Validate checks the spec for errors that would prevent Yale from managing its client secrets, returning all
+// of the problems found joined into a single error, or nil if the spec is valid
+func (s *AzureClientSecretSpec) Validate() error {
+	var errs []error
+	if s.AzureServicePrincipal.ApplicationID == "" {
+		errs = append(errs, fmt.Errorf("missing azure service principal application id"))
+	}
+	if s.AzureServicePrincipal.TenantID == "" {
+		errs = append(errs, fmt.Errorf("missing azure service principal tenant id"))
+	}
+	errs = append(errs, s.KeyRotation.validate()...)
+	errs = append(errs, validateSecretTypes(s.Secret, s.AdditionalSecrets, func(secret Secret) []string {
+		return []string{secret.ClientSecretKeyName}
+	})...)
+	errs = append(errs, validateReplications(s.VaultReplications, s.GoogleSecretManagerReplications, s.GitHubReplications, s.GCSReplications, s.ConsulReplications)...)
+	errs = append(errs, validateReplicationFormats(true, s.VaultReplications, s.GoogleSecretManagerReplications, s.GitHubReplications,
+		s.AWSSecretsManagerReplications, s.GitLabReplications, s.GCSReplications, s.ConsulReplications)...)
+	// client secrets are opaque strings, so there are no fields to replicate
+	for i, r := range s.VaultReplications {
+		if r.Field != "" {
+			errs = append(errs, fmt.Errorf("vaultReplications[%d]: field %q is only supported for GCP service account keys", i, r.Field))
+		}
+	}
+	for i, r := range s.GoogleSecretManagerReplications {
+		if r.Field != "" {
+			errs = append(errs, fmt.Errorf("googleSecretManagerReplications[%d]: field %q is only supported for GCP service account keys", i, r.Field))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Validate checks that a replication targets either a single repo or an org with a valid visibility
+func (r GitHubReplication) Validate() error {
+	if r.Repo != "" {
+		if r.Org != "" || r.Visibility != "" || len(r.SelectedRepositoryIDs) > 0 {
+			return fmt.Errorf("repo %q cannot be combined with org, visibility, or selected repository IDs", r.Repo)
+		}
+		tokens := strings.SplitN(r.Repo, "/", 2)
+		if len(tokens) != 2 || tokens[0] == "" || tokens[1] == "" {
+			return fmt.Errorf("invalid repository, expected format \"<org>/<repo>\", got: %q", r.Repo)
+		}
+		if r.Environment != "" && r.RequiredByDependabot {
+			return fmt.Errorf("environment secrets are only supported for GitHub Actions, but requiredByDependabot is set")
+		}
+		return nil
+	}
+
+	if r.Org == "" {
+		return fmt.Errorf("one of repo or org must be specified")
+	}
+	if r.Environment != "" {
+		return fmt.Errorf("environment secrets require a repo, but only org %q is specified", r.Org)
+	}
+
+	switch r.Visibility {
+	case GitHubVisibilityAll, GitHubVisibilityPrivate:
+		if len(r.SelectedRepositoryIDs) > 0 {
+			return fmt.Errorf("selected repository IDs are only supported with visibility %q, got visibility %q", GitHubVisibilitySelected, r.Visibility)
+		}
+	case GitHubVisibilitySelected:
+		if len(r.SelectedRepositoryIDs) == 0 {
+			return fmt.Errorf("visibility %q requires at least one selected repository ID", r.Visibility)
+		}
+	default:
+		return fmt.Errorf("unsupported org secret visibility %q, expected one of %q, %q, or %q", r.Visibility,
+			GitHubVisibilityAll, GitHubVisibilityPrivate, GitHubVisibilitySelected)
+	}
+	return nil
+}
+
+// Validate checks that a replication's secret name template can be rendered, and that it only asks for CMEK
+// along with user-managed replication
+func (r GoogleSecretManagerReplication) Validate() error {
+	if err := r.validateSecretName(); err != nil {
+		return err
+	}
+	if r.KmsKeyName != "" && len(r.Locations) == 0 {
+		return fmt.Errorf("kmsKeyName %q requires at least one location", r.KmsKeyName)
+	}
+	for _, location := range r.Locations {
+		if location == "" {
+			return fmt.Errorf("locations cannot be empty")
+		}
+	}
+	return nil
+}
+
+// Validate checks that a replication names both a bucket and an object
+func (r GCSReplication) Validate() error {
+	if r.Bucket == "" {
+		return fmt.Errorf("bucket cannot be empty")
+	}
+	if r.Object == "" {
+		return fmt.Errorf("object cannot be empty")
+	}
+	return nil
+}
+
+// Validate checks that a replication's Vault address, if it has one, is an http or https URL, and that its
+// wrap TTL, if it has one, is a positive duration and only set for wrapped replications
+func (r VaultReplication) Validate() error {
+	if r.WrapTTL != "" {
+		if !r.Wrap {
+			return fmt.Errorf("wrapTTL requires wrap to be true")
+		}
+		ttl, err := time.ParseDuration(r.WrapTTL)
+		if err != nil {
+			return fmt.Errorf("invalid wrapTTL %q: %v", r.WrapTTL, err)
+		}
+		if ttl < time.Second {
+			return fmt.Errorf("invalid wrapTTL %q, must be at least 1s", r.WrapTTL)
+		}
+	}
+	if r.Address == "" {
+		return nil
+	}
+	u, err := url.Parse(r.Address)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %v", r.Address, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid address %q, expected an http or https URL like \"https://vault.example.com\"", r.Address)
+	}
+	return nil
+}
+
+// Validate checks that a replication names a KV path
+func (r ConsulReplication) Validate() error {
+	if strings.Trim(r.Path, "/") == "" {
+		return fmt.Errorf("path cannot be empty")
+	}
+	return nil
+}
+
+// validateType checks that the secret's type is supported, and that the data keys Yale writes to include every
+// key that K8s requires secrets of that type to contain
+func (s Secret) validateType(dataKeys []string) error {
+	if s.Type == "" {
+		return nil
+	}
+	if !slices.Contains(SupportedSecretTypes, s.Type) {
+		return fmt.Errorf("unsupported secret type %q (must be one of %s)", s.Type, strings.Join(SupportedSecretTypes, ", "))
+	}
+	for _, required := range requiredSecretDataKeys[s.Type] {
+		if !slices.Contains(dataKeys, required) {
+			return fmt.Errorf("secret type %q requires a %q data key, but the key data is written to %q", s.Type, required, dataKeys)
+		}
+	}
+	return nil
+}
+
+// validate checks that the rotation thresholds are not negative, and that any durations can be parsed
+func (k KeyRotation) validate() []error {
+	var errs []error
+	thresholds := []struct {
+		fieldName string
+		days      int
+		duration  string
+	}{
+		{"rotateAfter", k.RotateAfter, k.RotateAfterDuration},
+		{"disableAfter", k.DisableAfter, k.DisableAfterDuration},
+		{"deleteAfter", k.DeleteAfter, k.DeleteAfterDuration},
+	}
+	if k.MaxKeyAge < 0 {
+		errs = append(errs, fmt.Errorf("keyRotation.maxKeyAge cannot be negative: %d", k.MaxKeyAge))
+	}
+	if k.SafeToDisableBufferDays < 0 {
+		errs = append(errs, fmt.Errorf("keyRotation.safeToDisableBufferDays cannot be negative: %d", k.SafeToDisableBufferDays))
+	}
+	for _, t := range thresholds {
+		if t.days < 0 {
+			errs = append(errs, fmt.Errorf("keyRotation.%s cannot be negative: %d", t.fieldName, t.days))
+		}
+		if t.duration == "" {
+			continue
+		}
+		d, err := time.ParseDuration(t.duration)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("keyRotation.%sDuration is invalid: %v", t.fieldName, err))
+		} else if d < 0 {
+			errs = append(errs, fmt.Errorf("keyRotation.%sDuration cannot be negative: %s", t.fieldName, t.duration))
+		}
+	}
+	return errs
+}
+
+// validateSecretTypes validates the type of the resource's secret, if it has one, and its additional secrets.
+// dataKeys returns the data keys Yale writes a secret's key data to
+func validateSecretTypes(secret Secret, additionalSecrets []Secret, dataKeys func(Secret) []string) []error {
+	var errs []error
+	if secret.Name != "" {
+		if err := secret.validateType(dataKeys(secret)); err != nil {
+			errs = append(errs, fmt.Errorf("secret: %v", err))
+		}
+	}
+	for i, s := range additionalSecrets {
+		if err := s.validateType(dataKeys(s)); err != nil {
+			errs = append(errs, fmt.Errorf("additionalSecrets[%d]: %v", i, err))
+		}
+	}
+	return errs
+}
+
+// validateReplications validates every Vault, GSM, GitHub, GCS, and Consul replication, prefixing errors with the replication's index
+func validateReplications(vaultReplications []VaultReplication, gsmReplications []GoogleSecretManagerReplication, githubReplications []GitHubReplication, gcsReplications []GCSReplication, consulReplications []ConsulReplication) []error {
+	var errs []error
+	for i, r := range vaultReplications {
+		if err := r.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("vaultReplications[%d]: %v", i, err))
+		}
+	}
+	for i, r := range gsmReplications {
+		if err := r.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("googleSecretManagerReplications[%d]: %v", i, err))
+		}
+	}
+	for i, r := range githubReplications {
+		if err := r.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("githubReplications[%d]: %v", i, err))
+		}
+	}
+	for i, r := range gcsReplications {
+		if err := r.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("gcsReplications[%d]: %v", i, err))
+		}
+	}
+	for i, r := range consulReplications {
+		if err := r.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("consulReplications[%d]: %v", i, err))
+		}
+	}
+	return errs
+}
+
+// validateReplicationFormats checks that every replication's format is supported by its destination and can be
+// produced from the resource's key data, so that unsupported combinations are reported when the resource is
+// loaded instead of failing when the key is synced. azure is true if the resource is an Azure client secret,
+// which unlike a GCP service account key is an opaque string rather than a JSON object.
+func validateReplicationFormats(azure bool, vaultReplications []VaultReplication, gsmReplications []GoogleSecretManagerReplication,
+	githubReplications []GitHubReplication, awsReplications []AWSSecretsManagerReplication, gitlabReplications []GitLabReplication,
+	gcsReplications []GCSReplication, consulReplications []ConsulReplication) []error {
+	var errs []error
+	errs = append(errs, validateFormats("vaultReplications", vaultReplications, func(r VaultReplication) ReplicationFormat { return r.Format }, true, azure)...)
+	errs = append(errs, validateFormats("googleSecretManagerReplications", gsmReplications, func(r GoogleSecretManagerReplication) ReplicationFormat { return r.Format }, false, azure)...)
+	errs = append(errs, validateFormats("githubReplications", githubReplications, func(r GitHubReplication) ReplicationFormat { return r.Format }, false, azure)...)
+	errs = append(errs, validateFormats("awsSecretsManagerReplications", awsReplications, func(r AWSSecretsManagerReplication) ReplicationFormat { return r.Format }, false, azure)...)
+	errs = append(errs, validateFormats("gitlabReplications", gitlabReplications, func(r GitLabReplication) ReplicationFormat { return r.Format }, false, azure)...)
+	errs = append(errs, validateFormats("gcsReplications", gcsReplications, func(r GCSReplication) ReplicationFormat { return r.Format }, false, azure)...)
+	errs = append(errs, validateFormats("consulReplications", consulReplications, func(r ConsulReplication) ReplicationFormat { return r.Format }, false, azure)...)
+
+	// GSM replications of the whole key in these formats must name the key to nest the formatted key under
+	for i, r := range gsmReplications {
+		if r.Field != "" || r.Key != "" {
+			continue
+		}
+		switch r.Format {
+		case JSON, Base64, PEM:
+			errs = append(errs, fmt.Errorf("googleSecretManagerReplications[%d]: %s format requires a key", i, r.Format))
+		}
+	}
+	return errs
+}
+
+// validateFormats validates the format of each replication, prefixing errors with the field name and the
+// replication's index. vault is true for Vault replications, the only destination that supports the map format
+func validateFormats[R any](fieldName string, replications []R, format func(R) ReplicationFormat, vault bool, azure bool) []error {
+	var errs []error
+	for i, r := range replications {
+		if err := validateFormat(format(r), vault, azure); err != nil {
+			errs = append(errs, fmt.Errorf("%s[%d]: %v", fieldName, i, err))
+		}
+	}
+	return errs
+}
+
+// validateFormat checks that the format is supported by the destination and can be produced from the key data
+func validateFormat(format ReplicationFormat, vault bool, azure bool) error {
+	if _, err := format.MarshalText(); err != nil {
+		return err
+	}
+	if format == Map && !vault {
+		return fmt.Errorf("map format is only supported for Vault replications")
+	}
+	if format == Dotenv && vault {
+		return fmt.Errorf("dotenv format is not supported for Vault replications")
+	}
+	if !azure {
+		return nil
+	}
+	// Vault replications have always written Azure client secrets as-is with the json format, so that's still
+	// allowed there
+	if format == Map || format == PEM || (format == JSON && !vault) {
+		return fmt.Errorf("%s format is only supported for GCP service account keys", format)
+	}
+	return nil
+}
+
+// defaultFormat sets a format that was left empty, which unmarshals to the map format, to JSON. Outside of
+// Vault, map is never a valid choice, so this can't override an explicit format
+func defaultFormat(format *ReplicationFormat) {
+	if *format == Map {
+		*format = JSON
+	}
+}
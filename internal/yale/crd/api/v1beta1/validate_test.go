@@ -0,0 +1,570 @@
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GCPSaKeySpecValidate(t *testing.T) {
+	testCases := []struct {
+		name      string
+		mutate    func(spec *GCPSaKeySpec)
+		expectErr []string
+	}{
+		{
+			name:   "valid spec",
+			mutate: func(spec *GCPSaKeySpec) {},
+		},
+		{
+			name: "valid spec with optional fields",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.KeyAlgorithm = "KEY_ALG_RSA_1024"
+				spec.KeyType = "TYPE_GOOGLE_CREDENTIALS_FILE"
+				spec.KeyRotation.RotateAfterDuration = "12h"
+				spec.GitHubReplications = []GitHubReplication{{Secret: "s", Org: "my-org", Visibility: GitHubVisibilityAll}}
+				spec.GoogleSecretManagerReplications = []GoogleSecretManagerReplication{{Secret: "s", Project: "p", Key: "key.json", Locations: []string{"us-central1"}, KmsKeyName: "k"}}
+			},
+		},
+		{
+			name: "valid formats",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.VaultReplications = []VaultReplication{{Path: "secret/a"}, {Path: "secret/b", Format: PEM, Key: "key.pem"}}
+				spec.GoogleSecretManagerReplications = []GoogleSecretManagerReplication{
+					{Secret: "a", Project: "p", Format: YAML},
+					{Secret: "b", Project: "p", Format: Base64, Field: "client_email"},
+					{Secret: "c", Project: "p", Format: PEM, Key: "key.pem"},
+				}
+				spec.GCSReplications = []GCSReplication{{Bucket: "b", Object: "key.json"}}
+			},
+		},
+		{
+			name: "unknown format",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.ConsulReplications = []ConsulReplication{{Path: "my-service/key.json", Format: ReplicationFormat(42)}}
+			},
+			expectErr: []string{"consulReplications[0]: unknown replication format"},
+		},
+		{
+			name: "vault dotenv format",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.VaultReplications = []VaultReplication{{Path: "secret/foo", Format: Dotenv, Key: "KEY"}}
+			},
+			expectErr: []string{"vaultReplications[0]: dotenv format is not supported for Vault replications"},
+		},
+		{
+			name: "gsm json format without key",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.GoogleSecretManagerReplications = []GoogleSecretManagerReplication{{Secret: "s", Project: "p", Format: JSON}}
+			},
+			expectErr: []string{"googleSecretManagerReplications[0]: json format requires a key"},
+		},
+		{
+			name: "gsm base64 format without key",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.GoogleSecretManagerReplications = []GoogleSecretManagerReplication{{Secret: "s", Project: "p", Format: Base64}}
+			},
+			expectErr: []string{"googleSecretManagerReplications[0]: base64 format requires a key"},
+		},
+		{
+			name: "gsm pem format without key",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.GoogleSecretManagerReplications = []GoogleSecretManagerReplication{{Secret: "s", Project: "p", Format: PEM}}
+			},
+			expectErr: []string{"googleSecretManagerReplications[0]: pem format requires a key"},
+		},
+		{
+			name: "gsm omitted format defaults to json",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.GoogleSecretManagerReplications = []GoogleSecretManagerReplication{{Secret: "s", Project: "p"}}
+			},
+			expectErr: []string{"googleSecretManagerReplications[0]: json format requires a key"},
+		},
+		{
+			name:      "missing service account name",
+			mutate:    func(spec *GCPSaKeySpec) { spec.GoogleServiceAccount.Name = "" },
+			expectErr: []string{"missing google service account name"},
+		},
+		{
+			name:      "missing service account project",
+			mutate:    func(spec *GCPSaKeySpec) { spec.GoogleServiceAccount.Project = "" },
+			expectErr: []string{"missing google service account project"},
+		},
+		{
+			name:      "unsupported key algorithm",
+			mutate:    func(spec *GCPSaKeySpec) { spec.KeyAlgorithm = "KEY_ALG_RSA_4096" },
+			expectErr: []string{`unsupported key algorithm "KEY_ALG_RSA_4096"`},
+		},
+		{
+			name:      "unsupported key type",
+			mutate:    func(spec *GCPSaKeySpec) { spec.KeyType = "TYPE_PKCS12_FILE" },
+			expectErr: []string{`unsupported key type "TYPE_PKCS12_FILE"`},
+		},
+		{
+			name:      "negative rotateAfter",
+			mutate:    func(spec *GCPSaKeySpec) { spec.KeyRotation.RotateAfter = -1 },
+			expectErr: []string{"keyRotation.rotateAfter cannot be negative: -1"},
+		},
+		{
+			name:      "negative disableAfter",
+			mutate:    func(spec *GCPSaKeySpec) { spec.KeyRotation.DisableAfter = -2 },
+			expectErr: []string{"keyRotation.disableAfter cannot be negative: -2"},
+		},
+		{
+			name:      "negative deleteAfter",
+			mutate:    func(spec *GCPSaKeySpec) { spec.KeyRotation.DeleteAfter = -3 },
+			expectErr: []string{"keyRotation.deleteAfter cannot be negative: -3"},
+		},
+		{
+			name:      "negative maxKeyAge",
+			mutate:    func(spec *GCPSaKeySpec) { spec.KeyRotation.MaxKeyAge = -4 },
+			expectErr: []string{"keyRotation.maxKeyAge cannot be negative: -4"},
+		},
+		{
+			name:      "negative safeToDisableBufferDays",
+			mutate:    func(spec *GCPSaKeySpec) { spec.KeyRotation.SafeToDisableBufferDays = -5 },
+			expectErr: []string{"keyRotation.safeToDisableBufferDays cannot be negative: -5"},
+		},
+		{
+			name:      "unparseable rotateAfterDuration",
+			mutate:    func(spec *GCPSaKeySpec) { spec.KeyRotation.RotateAfterDuration = "three days" },
+			expectErr: []string{"keyRotation.rotateAfterDuration is invalid"},
+		},
+		{
+			name:      "negative disableAfterDuration",
+			mutate:    func(spec *GCPSaKeySpec) { spec.KeyRotation.DisableAfterDuration = "-1h" },
+			expectErr: []string{"keyRotation.disableAfterDuration cannot be negative: -1h"},
+		},
+		{
+			name:      "unparseable deleteAfterDuration",
+			mutate:    func(spec *GCPSaKeySpec) { spec.KeyRotation.DeleteAfterDuration = "7" },
+			expectErr: []string{"keyRotation.deleteAfterDuration is invalid"},
+		},
+		{
+			name: "gcs replication missing bucket",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.GCSReplications = []GCSReplication{{Object: "path/to/key.json", Format: JSON}}
+			},
+			expectErr: []string{"gcsReplications[0]: bucket cannot be empty"},
+		},
+		{
+			name: "gcs replication missing object",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.GCSReplications = []GCSReplication{{Bucket: "my-bucket", Format: JSON}}
+			},
+			expectErr: []string{"gcsReplications[0]: object cannot be empty"},
+		},
+		{
+			name: "consul replication missing path",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.ConsulReplications = []ConsulReplication{{Address: "consul.example.com:8500", Path: "/", Format: JSON}}
+			},
+			expectErr: []string{"consulReplications[0]: path cannot be empty"},
+		},
+		{
+			name: "vault replication with valid address",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.VaultReplications = []VaultReplication{{Path: "secret/a", Address: "https://vault.enclave.example.com:8200"}}
+			},
+		},
+		{
+			name: "vault replication with invalid address",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.VaultReplications = []VaultReplication{{Path: "secret/a", Address: "vault.enclave.example.com"}}
+			},
+			expectErr: []string{`vaultReplications[0]: invalid address "vault.enclave.example.com", expected an http or https URL`},
+		},
+		{
+			name: "wrapped vault replication",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.VaultReplications = []VaultReplication{{Path: "secret/a", Wrap: true}, {Path: "secret/b", Wrap: true, WrapTTL: "1h"}}
+			},
+		},
+		{
+			name: "vault replication with invalid wrap ttl",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.VaultReplications = []VaultReplication{
+					{Path: "secret/a", Wrap: true, WrapTTL: "one day"},
+					{Path: "secret/b", Wrap: true, WrapTTL: "0s"},
+					{Path: "secret/c", WrapTTL: "1h"},
+				}
+			},
+			expectErr: []string{
+				`vaultReplications[0]: invalid wrapTTL "one day"`,
+				`vaultReplications[1]: invalid wrapTTL "0s", must be at least 1s`,
+				`vaultReplications[2]: wrapTTL requires wrap to be true`,
+			},
+		},
+		{
+			name: "valid secret types",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.Secret.Type = "Opaque"
+				spec.AdditionalSecrets = []Secret{{Name: "my-docker-config", JsonKeyName: ".dockerconfigjson", PemKeyName: "key.pem", Type: "kubernetes.io/dockerconfigjson"}}
+			},
+		},
+		{
+			name: "unsupported secret type",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.Secret.Type = "kubernetes.io/service-account-token"
+			},
+			expectErr: []string{`secret: unsupported secret type "kubernetes.io/service-account-token"`},
+		},
+		{
+			name: "secret type missing required data key",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.AdditionalSecrets = []Secret{{Name: "my-docker-config", JsonKeyName: "key.json", PemKeyName: "key.pem", Type: "kubernetes.io/dockerconfigjson"}}
+			},
+			expectErr: []string{`additionalSecrets[0]: secret type "kubernetes.io/dockerconfigjson" requires a ".dockerconfigjson" data key`},
+		},
+		{
+			name: "github repo missing org",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.GitHubReplications = []GitHubReplication{{Secret: "s", Repo: "my-repo"}}
+			},
+			expectErr: []string{`githubReplications[0]: invalid repository, expected format "<org>/<repo>", got: "my-repo"`},
+		},
+		{
+			name: "github repo with empty name",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.GitHubReplications = []GitHubReplication{{Secret: "s", Repo: "my-org/"}}
+			},
+			expectErr: []string{`githubReplications[0]: invalid repository`},
+		},
+		{
+			name: "github repo combined with org",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.GitHubReplications = []GitHubReplication{{Secret: "s", Repo: "my-org/my-repo", Org: "my-org"}}
+			},
+			expectErr: []string{`githubReplications[0]: repo "my-org/my-repo" cannot be combined with org`},
+		},
+		{
+			name: "github environment with dependabot",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.GitHubReplications = []GitHubReplication{{Secret: "s", Repo: "my-org/my-repo", Environment: "prod", RequiredByDependabot: true}}
+			},
+			expectErr: []string{"githubReplications[0]: environment secrets are only supported for GitHub Actions"},
+		},
+		{
+			name: "github neither repo nor org",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.GitHubReplications = []GitHubReplication{{Secret: "s"}}
+			},
+			expectErr: []string{"githubReplications[0]: one of repo or org must be specified"},
+		},
+		{
+			name: "github org with environment",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.GitHubReplications = []GitHubReplication{{Secret: "s", Org: "my-org", Visibility: GitHubVisibilityAll, Environment: "prod"}}
+			},
+			expectErr: []string{`githubReplications[0]: environment secrets require a repo, but only org "my-org" is specified`},
+		},
+		{
+			name: "github org with unsupported visibility",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.GitHubReplications = []GitHubReplication{{Secret: "s", Org: "my-org", Visibility: "public"}}
+			},
+			expectErr: []string{`githubReplications[0]: unsupported org secret visibility "public"`},
+		},
+		{
+			name: "github org with selected visibility but no repository IDs",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.GitHubReplications = []GitHubReplication{{Secret: "s", Org: "my-org", Visibility: GitHubVisibilitySelected}}
+			},
+			expectErr: []string{`githubReplications[0]: visibility "selected" requires at least one selected repository ID`},
+		},
+		{
+			name: "github org with repository IDs but not selected visibility",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.GitHubReplications = []GitHubReplication{{Secret: "s", Org: "my-org", Visibility: GitHubVisibilityPrivate, SelectedRepositoryIDs: []int64{1}}}
+			},
+			expectErr: []string{`githubReplications[0]: selected repository IDs are only supported with visibility "selected"`},
+		},
+		{
+			name: "gsm kms key without locations",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.GoogleSecretManagerReplications = []GoogleSecretManagerReplication{{Secret: "s", Project: "p", KmsKeyName: "k"}}
+			},
+			expectErr: []string{`googleSecretManagerReplications[0]: kmsKeyName "k" requires at least one location`},
+		},
+		{
+			name: "gsm secret name template",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.GoogleSecretManagerReplications = []GoogleSecretManagerReplication{{Secret: "{{.Namespace}}-{{sanitize .Identifier}}", Project: "p", Format: YAML}}
+			},
+		},
+		{
+			name: "gsm secret name template with unknown variable",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.GoogleSecretManagerReplications = []GoogleSecretManagerReplication{{Secret: "{{.Region}}-key", Project: "p", Format: YAML}}
+			},
+			expectErr: []string{`googleSecretManagerReplications[0]: error rendering secret name template "{{.Region}}-key" (supported variables are .Project, .Identifier, .Scope, .Namespace, .Name)`},
+		},
+		{
+			name: "gsm secret name template that does not parse",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.GoogleSecretManagerReplications = []GoogleSecretManagerReplication{{Secret: "{{.Namespace", Project: "p", Format: YAML}}
+			},
+			expectErr: []string{`googleSecretManagerReplications[0]: error parsing secret name template "{{.Namespace"`},
+		},
+		{
+			name: "gsm secret name template with illegal characters",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.GoogleSecretManagerReplications = []GoogleSecretManagerReplication{{Secret: "{{.Namespace}}.key", Project: "p", Format: YAML}}
+			},
+			expectErr: []string{`googleSecretManagerReplications[0]: secret name template "{{.Namespace}}.key" rendered to "namespace.key", which is not a valid GSM secret id`},
+		},
+		{
+			name: "gsm empty location",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.GoogleSecretManagerReplications = []GoogleSecretManagerReplication{{Secret: "s", Project: "p", Locations: []string{"us-central1", ""}}}
+			},
+			expectErr: []string{"googleSecretManagerReplications[0]: locations cannot be empty"},
+		},
+		{
+			name: "errors are aggregated",
+			mutate: func(spec *GCPSaKeySpec) {
+				spec.GoogleServiceAccount = GoogleServiceAccount{}
+				spec.KeyRotation.DeleteAfter = -1
+				spec.GitHubReplications = []GitHubReplication{
+					{Secret: "s1", Repo: "my-org/my-repo"},
+					{Secret: "s2", Repo: "my-repo"},
+				}
+			},
+			expectErr: []string{
+				"missing google service account name",
+				"missing google service account project",
+				"keyRotation.deleteAfter cannot be negative",
+				"githubReplications[1]: invalid repository",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := GCPSaKeySpec{
+				GoogleServiceAccount: GoogleServiceAccount{
+					Name:    "my-sa@p.com",
+					Project: "p",
+				},
+				Secret: Secret{
+					Name: "my-secret",
+				},
+				KeyRotation: KeyRotation{
+					RotateAfter:  7,
+					DisableAfter: 7,
+					DeleteAfter:  7,
+				},
+			}
+			tc.mutate(&spec)
+			spec.SetDefaults()
+
+			err := spec.Validate()
+			if len(tc.expectErr) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			for _, expected := range tc.expectErr {
+				assert.ErrorContains(t, err, expected)
+			}
+		})
+	}
+}
+
+func Test_GCPSaKeySpecSetDefaults(t *testing.T) {
+	spec := GCPSaKeySpec{
+		VaultReplications:               []VaultReplication{{Path: "secret/foo"}},
+		GoogleSecretManagerReplications: []GoogleSecretManagerReplication{{Secret: "a"}, {Secret: "b", Format: PEM}},
+		GitHubReplications:              []GitHubReplication{{Secret: "s"}},
+		AWSSecretsManagerReplications:   []AWSSecretsManagerReplication{{SecretName: "s", Format: YAML}},
+		GitLabReplications:              []GitLabReplication{{VariableKey: "v"}},
+		GCSReplications:                 []GCSReplication{{Bucket: "b", Format: Base64}},
+		ConsulReplications:              []ConsulReplication{{Path: "p"}},
+	}
+	spec.SetDefaults()
+
+	// vault replications keep their historical map default; explicit formats are left alone
+	assert.Equal(t, Map, spec.VaultReplications[0].Format)
+	assert.Equal(t, JSON, spec.GoogleSecretManagerReplications[0].Format)
+	assert.Equal(t, PEM, spec.GoogleSecretManagerReplications[1].Format)
+	assert.Equal(t, JSON, spec.GitHubReplications[0].Format)
+	assert.Equal(t, YAML, spec.AWSSecretsManagerReplications[0].Format)
+	assert.Equal(t, JSON, spec.GitLabReplications[0].Format)
+	assert.Equal(t, Base64, spec.GCSReplications[0].Format)
+	assert.Equal(t, JSON, spec.ConsulReplications[0].Format)
+}
+
+func Test_AzureClientSecretSpecValidate(t *testing.T) {
+	testCases := []struct {
+		name      string
+		mutate    func(spec *AzureClientSecretSpec)
+		expectErr []string
+	}{
+		{
+			name:   "valid spec",
+			mutate: func(spec *AzureClientSecretSpec) {},
+		},
+		{
+			name:      "missing application id",
+			mutate:    func(spec *AzureClientSecretSpec) { spec.AzureServicePrincipal.ApplicationID = "" },
+			expectErr: []string{"missing azure service principal application id"},
+		},
+		{
+			name:      "missing tenant id",
+			mutate:    func(spec *AzureClientSecretSpec) { spec.AzureServicePrincipal.TenantID = "" },
+			expectErr: []string{"missing azure service principal tenant id"},
+		},
+		{
+			name:      "unparseable rotateAfterDuration",
+			mutate:    func(spec *AzureClientSecretSpec) { spec.KeyRotation.RotateAfterDuration = "soon" },
+			expectErr: []string{"keyRotation.rotateAfterDuration is invalid"},
+		},
+		{
+			name: "malformed github repo",
+			mutate: func(spec *AzureClientSecretSpec) {
+				spec.GitHubReplications = []GitHubReplication{{Secret: "s", Repo: "a/b"}, {Secret: "s", Repo: "/b"}}
+			},
+			expectErr: []string{"githubReplications[1]: invalid repository"},
+		},
+		{
+			name: "secret type missing required data key",
+			mutate: func(spec *AzureClientSecretSpec) {
+				spec.Secret.ClientSecretKeyName = "client-secret"
+				spec.Secret.Type = "kubernetes.io/ssh-auth"
+			},
+			expectErr: []string{`secret: secret type "kubernetes.io/ssh-auth" requires a "ssh-privatekey" data key`},
+		},
+		{
+			name: "field projection",
+			mutate: func(spec *AzureClientSecretSpec) {
+				spec.VaultReplications = []VaultReplication{{Path: "secret/foo", Format: PlainText, Field: "client_email"}}
+				spec.GoogleSecretManagerReplications = []GoogleSecretManagerReplication{{Secret: "s", Project: "p", Format: PlainText, Field: "client_id"}}
+			},
+			expectErr: []string{
+				`vaultReplications[0]: field "client_email" is only supported for GCP service account keys`,
+				`googleSecretManagerReplications[0]: field "client_id" is only supported for GCP service account keys`,
+			},
+		},
+		{
+			name: "valid formats",
+			mutate: func(spec *AzureClientSecretSpec) {
+				spec.VaultReplications = []VaultReplication{{Path: "secret/a", Format: JSON, Key: "secret"}, {Path: "secret/b", Format: PlainText}}
+				spec.GoogleSecretManagerReplications = []GoogleSecretManagerReplication{{Secret: "a", Project: "p", Format: PlainText}, {Secret: "b", Project: "p", Format: Base64, Key: "secret"}}
+				spec.GitHubReplications = []GitHubReplication{{Secret: "s", Repo: "my-org/my-repo", Format: YAML}}
+			},
+		},
+		{
+			name: "vault map format",
+			mutate: func(spec *AzureClientSecretSpec) {
+				spec.VaultReplications = []VaultReplication{{Path: "secret/foo", Format: Map}}
+			},
+			expectErr: []string{"vaultReplications[0]: map format is only supported for GCP service account keys"},
+		},
+		{
+			name: "vault pem format",
+			mutate: func(spec *AzureClientSecretSpec) {
+				spec.VaultReplications = []VaultReplication{{Path: "secret/foo", Format: PEM, Key: "secret"}}
+			},
+			expectErr: []string{"vaultReplications[0]: pem format is only supported for GCP service account keys"},
+		},
+		{
+			name: "gsm json format",
+			mutate: func(spec *AzureClientSecretSpec) {
+				spec.GoogleSecretManagerReplications = []GoogleSecretManagerReplication{{Secret: "s", Project: "p", Format: JSON, Key: "secret"}}
+			},
+			expectErr: []string{"googleSecretManagerReplications[0]: json format is only supported for GCP service account keys"},
+		},
+		{
+			name: "gsm base64 format without key",
+			mutate: func(spec *AzureClientSecretSpec) {
+				spec.GoogleSecretManagerReplications = []GoogleSecretManagerReplication{{Secret: "s", Project: "p", Format: Base64}}
+			},
+			expectErr: []string{"googleSecretManagerReplications[0]: base64 format requires a key"},
+		},
+		{
+			name: "omitted format is not defaulted",
+			mutate: func(spec *AzureClientSecretSpec) {
+				spec.GitHubReplications = []GitHubReplication{{Secret: "s", Repo: "my-org/my-repo"}}
+			},
+			expectErr: []string{"githubReplications[0]: map format is only supported for Vault replications"},
+		},
+		{
+			name: "pem format for other destinations",
+			mutate: func(spec *AzureClientSecretSpec) {
+				spec.AWSSecretsManagerReplications = []AWSSecretsManagerReplication{{Region: "us-east-1", SecretName: "s", Format: PEM}}
+				spec.GitLabReplications = []GitLabReplication{{Project: "g/p", VariableKey: "v", Format: PEM}}
+				spec.GCSReplications = []GCSReplication{{Bucket: "b", Object: "o", Format: PEM}}
+				spec.ConsulReplications = []ConsulReplication{{Path: "p", Format: PEM}}
+			},
+			expectErr: []string{
+				"awsSecretsManagerReplications[0]: pem format is only supported for GCP service account keys",
+				"gitlabReplications[0]: pem format is only supported for GCP service account keys",
+				"gcsReplications[0]: pem format is only supported for GCP service account keys",
+				"consulReplications[0]: pem format is only supported for GCP service account keys",
+			},
+		},
+		{
+			name: "errors are aggregated",
+			mutate: func(spec *AzureClientSecretSpec) {
+				spec.AzureServicePrincipal = AzureServicePrincipal{}
+				spec.GoogleSecretManagerReplications = []GoogleSecretManagerReplication{{Secret: "s", Project: "p", KmsKeyName: "k"}}
+			},
+			expectErr: []string{
+				"missing azure service principal application id",
+				"missing azure service principal tenant id",
+				"googleSecretManagerReplications[0]: kmsKeyName",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := AzureClientSecretSpec{
+				AzureServicePrincipal: AzureServicePrincipal{
+					TenantID:      "my-tenant",
+					ApplicationID: "my-app",
+				},
+				Secret: Secret{
+					Name: "my-secret",
+				},
+			}
+			tc.mutate(&spec)
+
+			err := spec.Validate()
+			if len(tc.expectErr) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			for _, expected := range tc.expectErr {
+				assert.ErrorContains(t, err, expected)
+			}
+		})
+	}
+}
+
+func Test_GoogleSecretManagerReplicationRenderSecretName(t *testing.T) {
+	ctx := GSMSecretNameContext{
+		Project:    "my-project",
+		Identifier: "my-sa@my-project.iam.gserviceaccount.com",
+		Scope:      "my-project",
+		Namespace:  "my-namespace",
+		Name:       "my-gsk",
+	}
+
+	name, err := GoogleSecretManagerReplication{Secret: "my-secret"}.RenderSecretName(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "my-secret", name)
+
+	name, err = GoogleSecretManagerReplication{Secret: "{{.Namespace}}_{{.Name}}-{{.Scope}}"}.RenderSecretName(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "my-namespace_my-gsk-my-project", name)
+
+	name, err = GoogleSecretManagerReplication{Secret: "{{sanitize .Identifier}}"}.RenderSecretName(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "my-sa_my-project_iam_gserviceaccount_com", name)
+
+	_, err = GoogleSecretManagerReplication{Secret: "{{.Identifier}}"}.RenderSecretName(ctx)
+	assert.ErrorContains(t, err, `rendered to "my-sa@my-project.iam.gserviceaccount.com", which is not a valid GSM secret id`)
+}
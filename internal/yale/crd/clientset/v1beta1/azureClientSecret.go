@@ -5,6 +5,7 @@ import (
 
 	v1 "github.com/broadinstitute/yale/internal/yale/crd/api/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 )
@@ -14,6 +15,8 @@ const azEndpoint = "azureClientSecrets"
 type AzureClientSecretInterface interface {
 	List(ctx context.Context, opts metav1.ListOptions) (*v1.AzureClientSecretList, error)
 	Get(ctx context.Context, name string, options metav1.GetOptions) (*v1.AzureClientSecret, error)
+	// UpdateStatus replaces the status subresource of the AzureClientSecret with the given name in the given namespace
+	UpdateStatus(ctx context.Context, namespace string, name string, status v1.Status) error
 }
 
 type azureClientSecretClient struct {
@@ -44,3 +47,19 @@ func (c *azureClientSecretClient) Get(ctx context.Context, name string, opts met
 
 	return &result, err
 }
+
+func (c *azureClientSecretClient) UpdateStatus(ctx context.Context, namespace string, name string, status v1.Status) error {
+	patch, err := statusPatch(status)
+	if err != nil {
+		return err
+	}
+	return c.restClient.
+		Patch(types.MergePatchType).
+		Namespace(namespace).
+		Resource(azEndpoint).
+		Name(name).
+		SubResource("status").
+		Body(patch).
+		Do(ctx).
+		Error()
+}
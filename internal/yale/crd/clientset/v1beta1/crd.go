@@ -1,6 +1,9 @@
 package v1beta1
 
 import (
+	"encoding/json"
+	"fmt"
+
 	"github.com/broadinstitute/yale/internal/yale/crd/api/v1beta1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -44,3 +47,13 @@ func (c *YaleCRDClient) AzureClientSecrets() AzureClientSecretInterface {
 		restClient: c.restClient,
 	}
 }
+
+// statusPatch returns a JSON merge patch that replaces a resource's status. Every status field is included,
+// so that fields which are now empty are cleared.
+func statusPatch(status v1beta1.Status) ([]byte, error) {
+	patch, err := json.Marshal(map[string]interface{}{"status": status})
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling status patch: %v", err)
+	}
+	return patch, nil
+}
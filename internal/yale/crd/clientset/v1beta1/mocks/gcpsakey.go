@@ -135,6 +135,51 @@ func (_c *GcpSaKeyInterface_List_Call) RunAndReturn(run func(context.Context, v1
 	return _c
 }
 
+// UpdateStatus provides a mock function with given fields: ctx, namespace, name, status
+func (_m *GcpSaKeyInterface) UpdateStatus(ctx context.Context, namespace string, name string, status v1beta1.Status) error {
+	ret := _m.Called(ctx, namespace, name, status)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, v1beta1.Status) error); ok {
+		r0 = rf(ctx, namespace, name, status)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GcpSaKeyInterface_UpdateStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateStatus'
+type GcpSaKeyInterface_UpdateStatus_Call struct {
+	*mock.Call
+}
+
+// UpdateStatus is a helper method to define mock.On call
+//   - ctx context.Context
+//   - namespace string
+//   - name string
+//   - status v1beta1.Status
+func (_e *GcpSaKeyInterface_Expecter) UpdateStatus(ctx interface{}, namespace interface{}, name interface{}, status interface{}) *GcpSaKeyInterface_UpdateStatus_Call {
+	return &GcpSaKeyInterface_UpdateStatus_Call{Call: _e.mock.On("UpdateStatus", ctx, namespace, name, status)}
+}
+
+func (_c *GcpSaKeyInterface_UpdateStatus_Call) Run(run func(ctx context.Context, namespace string, name string, status v1beta1.Status)) *GcpSaKeyInterface_UpdateStatus_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(v1beta1.Status))
+	})
+	return _c
+}
+
+func (_c *GcpSaKeyInterface_UpdateStatus_Call) Return(_a0 error) *GcpSaKeyInterface_UpdateStatus_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *GcpSaKeyInterface_UpdateStatus_Call) RunAndReturn(run func(context.Context, string, string, v1beta1.Status) error) *GcpSaKeyInterface_UpdateStatus_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 type mockConstructorTestingTNewGcpSaKeyInterface interface {
 	mock.TestingT
 	Cleanup(func())
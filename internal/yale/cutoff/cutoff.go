@@ -9,10 +9,15 @@ import (
 )
 
 type thresholds struct {
-	rotateAfter        int
-	disableAfter       int
-	deleteAfter        int
+	rotateAfter        time.Duration
+	disableAfter       time.Duration
+	deleteAfter        time.Duration
 	ignoreUsageMetrics bool
+	paused             bool
+	decommissioned     bool
+	maxKeyAge          time.Duration
+	// safeToDisableBuffer how recently a rotated key can have been used and still be considered safe to disable
+	safeToDisableBuffer time.Duration
 }
 
 // minimums - the minimum supported value for a GSK's RotateAfter/DisableAfter/DeleteAfter
@@ -22,15 +27,35 @@ type thresholds struct {
 // determine if a key is still in use.
 // With Cloud Monitoring Metrics, data can lag up to 6 hours behind realtime; 7 days is a very generous buffer.
 var minimums = thresholds{
-	rotateAfter:  7,
-	disableAfter: 7,
-	deleteAfter:  3,
+	rotateAfter:         7 * oneDay,
+	disableAfter:        7 * oneDay,
+	deleteAfter:         3 * oneDay,
+	safeToDisableBuffer: lastAuthSafeDisableBuffer,
+}
+
+// subDayMinimums - the minimums used instead when the operator opts in to sub-day thresholds, so that keys
+// can be rotated every few hours (eg. in short-lived environments). The safe-to-disable buffer is not relaxed,
+// since it accounts for lag in usage metrics, which doesn't shrink with the thresholds
+var subDayMinimums = thresholds{
+	rotateAfter:         time.Hour,
+	disableAfter:        time.Hour,
+	deleteAfter:         time.Hour,
+	safeToDisableBuffer: lastAuthSafeDisableBuffer,
 }
 
 // oneDay time.Duration representing time in a single day
 const oneDay = 24 * time.Hour
 
-// lastAuthSafeDisableBuffer consider a key safe to disable if it has not been used within this much time
+// Options configures how cutoffs are computed
+type Options struct {
+	// AllowSubDayThresholds if true, relax the minimum rotate/disable/delete thresholds from days to hours
+	AllowSubDayThresholds bool
+}
+
+type Option func(*Options)
+
+// lastAuthSafeDisableBuffer consider a key safe to disable if it has not been used within this much time. This is
+// the default for resources that don't set SafeToDisableBufferDays, and the minimum for those that do
 const lastAuthSafeDisableBuffer = 3 * oneDay
 
 // Cutoffs is responsible for determining when a service account key should be rotated, disabled, or deleted
@@ -43,28 +68,47 @@ type Cutoffs interface {
 	SafeToDisable(lastAuthTime time.Time) bool
 	// ShouldDelete Return true if the key disabled at the given timestamp should be deleted
 	ShouldDelete(disabledAt time.Time) bool
-	// RotateAfterDays Number of days to wait to rotate a key after issuing it (the basis for ShouldRotate)
-	RotateAfterDays() int
-	// DisableAfterDays Number of days to wait to disable a key before rotating it (the basis for ShouldDisable)
-	DisableAfterDays() int
-	// DeleteAfterDays Number of days to wait to delete a key before rotating it (the basis for ShouldDelete)
-	DeleteAfterDays() int
+	// RotateAfter How long to wait to rotate a key after issuing it (the basis for ShouldRotate)
+	RotateAfter() time.Duration
+	// DisableAfter How long to wait to disable a key after rotating it (the basis for ShouldDisable)
+	DisableAfter() time.Duration
+	// DeleteAfter How long to wait to delete a key after disabling it (the basis for ShouldDelete)
+	DeleteAfter() time.Duration
+	// Paused Return true if rotation is paused, in which case ShouldRotate always returns false
+	Paused() bool
+	// Decommissioned Return true if the identity is being decommissioned, in which case Yale should not issue
+	// any new keys for it
+	Decommissioned() bool
+	// ExceedsMaxKeyAge Return true if the key created at the given timestamp is older than the max key age.
+	// Always false if no max key age is configured
+	ExceedsMaxKeyAge(createdAt time.Time) bool
+	// MaxKeyAge How old a current key can get before Yale alerts that it hasn't been rotated (0 if not configured)
+	MaxKeyAge() time.Duration
 }
 
 func NewWithDefaults() Cutoffs {
 	return newWithThresholds(minimums, time.Now())
 }
 
-func New[Y apiv1b1.YaleCRD](yaleCRDs []Y) Cutoffs {
-	return newWithCustomTime(yaleCRDs, time.Now())
+func New[Y apiv1b1.YaleCRD](yaleCRDs []Y, options ...Option) Cutoffs {
+	return newWithCustomTime(yaleCRDs, time.Now(), options...)
 }
 
-func newWithCustomTime[Y apiv1b1.YaleCRD](yaleCRDs []Y, now time.Time) cutoffs {
+func newWithCustomTime[Y apiv1b1.YaleCRD](yaleCRDs []Y, now time.Time, options ...Option) cutoffs {
 	if len(yaleCRDs) < 1 {
 		panic("at least one GcpSaKey or AzureClientSecret must be supplied in order to compute cutoffs")
 	}
 
-	return newWithThresholds(computeThresholds(yaleCRDs), now)
+	var opts Options
+	for _, option := range options {
+		option(&opts)
+	}
+	floors := minimums
+	if opts.AllowSubDayThresholds {
+		floors = subDayMinimums
+	}
+
+	return newWithThresholds(computeThresholds(yaleCRDs, floors), now)
 }
 
 func newWithThresholds(t thresholds, now time.Time) cutoffs {
@@ -81,6 +125,9 @@ type cutoffs struct {
 
 // ShouldRotate Return true if the key created at the given timestamp should be rotated
 func (c cutoffs) ShouldRotate(createdAt time.Time) bool {
+	if c.thresholds.paused {
+		return false
+	}
 	return createdAt.Before(c.rotateCutoff())
 }
 
@@ -99,82 +146,132 @@ func (c cutoffs) ShouldDelete(disabledAt time.Time) bool {
 	return disabledAt.Before(c.deleteCutoff())
 }
 
-func (c cutoffs) RotateAfterDays() int {
+func (c cutoffs) RotateAfter() time.Duration {
 	return c.thresholds.rotateAfter
 }
 
-func (c cutoffs) DisableAfterDays() int {
+func (c cutoffs) DisableAfter() time.Duration {
 	return c.thresholds.disableAfter
 }
 
-func (c cutoffs) DeleteAfterDays() int {
+func (c cutoffs) DeleteAfter() time.Duration {
 	return c.thresholds.deleteAfter
 }
 
+func (c cutoffs) Paused() bool {
+	return c.thresholds.paused
+}
+
+func (c cutoffs) Decommissioned() bool {
+	return c.thresholds.decommissioned
+}
+
+func (c cutoffs) ExceedsMaxKeyAge(createdAt time.Time) bool {
+	if c.thresholds.maxKeyAge == 0 {
+		return false
+	}
+	return createdAt.Before(c.ago(c.thresholds.maxKeyAge))
+}
+
+func (c cutoffs) MaxKeyAge() time.Duration {
+	return c.thresholds.maxKeyAge
+}
+
 // rotateCutoff keys created before this timestamp should be rotated
 func (c cutoffs) rotateCutoff() time.Time {
-	return c.daysAgo(c.RotateAfterDays())
+	return c.ago(c.RotateAfter())
 }
 
 // disableCutoff keys rotated before this timestamp should be disabled (if they are unused)
 func (c cutoffs) disableCutoff() time.Time {
-	return c.daysAgo(c.DisableAfterDays())
+	return c.ago(c.DisableAfter())
 }
 
 // safeToDisableCutoff keys last authenticated before this timestamp should be safe to disable
 func (c cutoffs) safeToDisableCutoff() time.Time {
-	return c.now.Add(-1 * lastAuthSafeDisableBuffer)
+	return c.ago(c.thresholds.safeToDisableBuffer)
 }
 
 // deleteCutoff keys disabled before this timestamp should be deleted
 func (c cutoffs) deleteCutoff() time.Time {
-	return c.daysAgo(c.DeleteAfterDays())
+	return c.ago(c.DeleteAfter())
 }
 
-// daysAgo return a timestamp that is n days in the past
-func (c cutoffs) daysAgo(n int) time.Time {
-	return c.now.Add(-1 * time.Duration(int64(n)*int64(oneDay)))
+// ago return a timestamp that is d in the past
+func (c cutoffs) ago(d time.Duration) time.Time {
+	return c.now.Add(-1 * d)
 }
 
-// computeThresholds take a set of gsks and collapse them into a set of agreed-upon thresholds
-func computeThresholds[Y apiv1b1.YaleCRD](yaleCRDs []Y) thresholds {
+// keyRotationThreshold returns the duration field of a KeyRotation threshold if it is set, since it overrides the
+// day-based field, or the day-based field otherwise. An unparseable duration is ignored in favor of the days.
+func keyRotationThreshold(days int, duration string, resource string, fieldName string) time.Duration {
+	if duration == "" {
+		return time.Duration(days) * oneDay
+	}
+	d, err := time.ParseDuration(duration)
+	if err != nil {
+		logs.Warn.Printf("%s has invalid %sDuration value %q, using %s value of %d days instead: %v", resource, fieldName, duration, fieldName, days, err)
+		return time.Duration(days) * oneDay
+	}
+	return d
+}
+
+// computeThresholds take a set of gsks and collapse them into a set of agreed-upon thresholds,
+// rounding up to the given floors
+func computeThresholds[Y apiv1b1.YaleCRD](yaleCRDs []Y, floors thresholds) thresholds {
 	switch cs := any(&yaleCRDs).(type) {
 	case *[]apiv1b1.GcpSaKey:
 		gsks := *cs
 		t := thresholds{
-			rotateAfter: computeThresholdGSK(gsks, func(gsk apiv1b1.GcpSaKey) int {
-				return gsk.Spec.KeyRotation.RotateAfter
-			}, minimums.rotateAfter, "RotateAfter"),
-			disableAfter: computeThresholdGSK(gsks, func(gsk apiv1b1.GcpSaKey) int {
-				return gsk.Spec.KeyRotation.DisableAfter
-			}, minimums.disableAfter, "DisableAfter"),
-			deleteAfter: computeThresholdGSK(gsks, func(gsk apiv1b1.GcpSaKey) int {
-				return gsk.Spec.KeyRotation.DeleteAfter
-			}, minimums.deleteAfter, "DeleteAfter"),
+			rotateAfter: computeThresholdGSK(gsks, func(gsk apiv1b1.GcpSaKey) time.Duration {
+				return keyRotationThreshold(gsk.Spec.KeyRotation.RotateAfter, gsk.Spec.KeyRotation.RotateAfterDuration, "GcpSaKey "+gsk.Namespace()+"/"+gsk.Name(), "RotateAfter")
+			}, floors.rotateAfter, "RotateAfter"),
+			disableAfter: computeThresholdGSK(gsks, func(gsk apiv1b1.GcpSaKey) time.Duration {
+				return keyRotationThreshold(gsk.Spec.KeyRotation.DisableAfter, gsk.Spec.KeyRotation.DisableAfterDuration, "GcpSaKey "+gsk.Namespace()+"/"+gsk.Name(), "DisableAfter")
+			}, floors.disableAfter, "DisableAfter"),
+			deleteAfter: computeThresholdGSK(gsks, func(gsk apiv1b1.GcpSaKey) time.Duration {
+				return keyRotationThreshold(gsk.Spec.KeyRotation.DeleteAfter, gsk.Spec.KeyRotation.DeleteAfterDuration, "GcpSaKey "+gsk.Namespace()+"/"+gsk.Name(), "DeleteAfter")
+			}, floors.deleteAfter, "DeleteAfter"),
 			ignoreUsageMetrics: computeIgnoreUsageMetricsGSK(gsks),
+			paused:             computePausedGSK(gsks),
+			decommissioned:     computeDecommissionedGSK(gsks),
+		}
+		var rotations []apiv1b1.KeyRotation
+		for _, gsk := range gsks {
+			rotations = append(rotations, gsk.Spec.KeyRotation)
 		}
+		t.maxKeyAge = computeMaxKeyAge(rotations)
+		t.safeToDisableBuffer = computeSafeToDisableBuffer(rotations, floors.safeToDisableBuffer, gsks[0].Spec.GoogleServiceAccount.Name)
 
 		if len(yaleCRDs) > 1 {
-			logs.Info.Printf("computed key rotation thresholds for %s from %d GSKs: rotate after %d days, disable after %d days, delete after %d days", gsks[0].Spec.GoogleServiceAccount.Name, len(gsks), t.rotateAfter, t.disableAfter, t.deleteAfter)
+			logs.Info.Printf("computed key rotation thresholds for %s from %d GSKs: rotate after %s, disable after %s, delete after %s, safe to disable after %s unused", gsks[0].Spec.GoogleServiceAccount.Name, len(gsks), t.rotateAfter, t.disableAfter, t.deleteAfter, t.safeToDisableBuffer)
 		}
 		return t
 	case *[]apiv1b1.AzureClientSecret:
 		azureClientSecrets := *cs
 		t := thresholds{
-			rotateAfter: computeThresholdAzureClientSecret(azureClientSecrets, func(acs apiv1b1.AzureClientSecret) int {
-				return acs.Spec.KeyRotation.RotateAfter
-			}, minimums.rotateAfter, "RotateAfter"),
-			disableAfter: computeThresholdAzureClientSecret(azureClientSecrets, func(acs apiv1b1.AzureClientSecret) int {
-				return acs.Spec.KeyRotation.DisableAfter
-			}, minimums.disableAfter, "DisableAfter"),
-			deleteAfter: computeThresholdAzureClientSecret(azureClientSecrets, func(acs apiv1b1.AzureClientSecret) int {
-				return acs.Spec.KeyRotation.DeleteAfter
-			}, minimums.deleteAfter, "DeleteAfter"),
+			rotateAfter: computeThresholdAzureClientSecret(azureClientSecrets, func(acs apiv1b1.AzureClientSecret) time.Duration {
+				return keyRotationThreshold(acs.Spec.KeyRotation.RotateAfter, acs.Spec.KeyRotation.RotateAfterDuration, "AzureClientSecret "+acs.Namespace()+"/"+acs.Name(), "RotateAfter")
+			}, floors.rotateAfter, "RotateAfter"),
+			disableAfter: computeThresholdAzureClientSecret(azureClientSecrets, func(acs apiv1b1.AzureClientSecret) time.Duration {
+				return keyRotationThreshold(acs.Spec.KeyRotation.DisableAfter, acs.Spec.KeyRotation.DisableAfterDuration, "AzureClientSecret "+acs.Namespace()+"/"+acs.Name(), "DisableAfter")
+			}, floors.disableAfter, "DisableAfter"),
+			deleteAfter: computeThresholdAzureClientSecret(azureClientSecrets, func(acs apiv1b1.AzureClientSecret) time.Duration {
+				return keyRotationThreshold(acs.Spec.KeyRotation.DeleteAfter, acs.Spec.KeyRotation.DeleteAfterDuration, "AzureClientSecret "+acs.Namespace()+"/"+acs.Name(), "DeleteAfter")
+			}, floors.deleteAfter, "DeleteAfter"),
 			ignoreUsageMetrics: computeIgnoreUsageMetricsAzureClientSecret(azureClientSecrets),
+			paused:             computePausedAzureClientSecret(azureClientSecrets),
+			decommissioned:     computeDecommissionedAzureClientSecret(azureClientSecrets),
 		}
+		var rotations []apiv1b1.KeyRotation
+		for _, acs := range azureClientSecrets {
+			rotations = append(rotations, acs.Spec.KeyRotation)
+		}
+		t.maxKeyAge = computeMaxKeyAge(rotations)
+		t.safeToDisableBuffer = computeSafeToDisableBuffer(rotations, floors.safeToDisableBuffer, azureClientSecrets[0].Spec.AzureServicePrincipal.ApplicationID)
 
 		if len(yaleCRDs) > 1 {
-			logs.Info.Printf("computed key rotation thresholds for %s from %d AzureClientSecrets: rotate after %d days, disable after %d days, delete after %d days", azureClientSecrets[0].Spec.AzureServicePrincipal.ApplicationID, len(azureClientSecrets), t.rotateAfter, t.disableAfter, t.deleteAfter)
+			logs.Info.Printf("computed key rotation thresholds for %s from %d AzureClientSecrets: rotate after %s, disable after %s, delete after %s, safe to disable after %s unused", azureClientSecrets[0].Spec.AzureServicePrincipal.ApplicationID, len(azureClientSecrets), t.rotateAfter, t.disableAfter, t.deleteAfter, t.safeToDisableBuffer)
 		}
 		return t
 
@@ -183,41 +280,41 @@ func computeThresholds[Y apiv1b1.YaleCRD](yaleCRDs []Y) thresholds {
 	}
 }
 
-// computeThresholdGSK take the rotate/disable/delete days values from a list of GSKs and return the lowest value,
+// computeThresholdGSK take the rotate/disable/delete/safe-to-disable threshold values from a list of GSKs and return the lowest value,
 // rounding up to the hardcoded minimums/floors for each attribute if necessary
-func computeThresholdGSK(gsks []apiv1b1.GcpSaKey, fieldFn func(apiv1b1.GcpSaKey) int, floor int, fieldName string) int {
+func computeThresholdGSK(gsks []apiv1b1.GcpSaKey, fieldFn func(apiv1b1.GcpSaKey) time.Duration, floor time.Duration, fieldName string) time.Duration {
 	min := gsks[0]
 	for _, gsk := range gsks {
 		v := fieldFn(gsk)
 		minV := fieldFn(min)
 		if v < minV {
-			logs.Warn.Printf("found different %s values in GcpSaKey resources for %s: %s/%s=%d and %s/%s=%d", fieldName, gsk.Spec.GoogleServiceAccount.Name, min.ObjectMeta.Namespace, min.ObjectMeta.Name, minV, gsk.ObjectMeta.Namespace, gsk.ObjectMeta.Name, v)
+			logs.Warn.Printf("found different %s values in GcpSaKey resources for %s: %s/%s=%s and %s/%s=%s", fieldName, gsk.Spec.GoogleServiceAccount.Name, min.ObjectMeta.Namespace, min.ObjectMeta.Name, minV, gsk.ObjectMeta.Namespace, gsk.ObjectMeta.Name, v)
 			min = gsk
 		}
 	}
 
 	minV := fieldFn(min)
 	if minV < floor {
-		logs.Warn.Printf("GcpSaKey %s/%s for %s has invalid %s value %d; rounding up to %d", min.ObjectMeta.Namespace, min.ObjectMeta.Name, min.Spec.GoogleServiceAccount.Name, fieldName, minV, floor)
+		logs.Warn.Printf("GcpSaKey %s/%s for %s has invalid %s value %s; rounding up to %s", min.ObjectMeta.Namespace, min.ObjectMeta.Name, min.Spec.GoogleServiceAccount.Name, fieldName, minV, floor)
 		return floor
 	}
 	return minV
 }
 
-func computeThresholdAzureClientSecret(azureClientSecrets []apiv1b1.AzureClientSecret, fieldFn func(apiv1b1.AzureClientSecret) int, floor int, fieldName string) int {
+func computeThresholdAzureClientSecret(azureClientSecrets []apiv1b1.AzureClientSecret, fieldFn func(apiv1b1.AzureClientSecret) time.Duration, floor time.Duration, fieldName string) time.Duration {
 	min := azureClientSecrets[0]
 	for _, azureClientSecret := range azureClientSecrets {
 		v := fieldFn(azureClientSecret)
 		minV := fieldFn(min)
 		if v < minV {
-			logs.Warn.Printf("found different %s values in AzureClientSecret resources for %s: %s/%s=%d and %s/%s=%d", fieldName, azureClientSecret.Spec.AzureServicePrincipal.ApplicationID, min.Namespace(), min.Name(), minV, azureClientSecret.Namespace(), azureClientSecret.Name(), v)
+			logs.Warn.Printf("found different %s values in AzureClientSecret resources for %s: %s/%s=%s and %s/%s=%s", fieldName, azureClientSecret.Spec.AzureServicePrincipal.ApplicationID, min.Namespace(), min.Name(), minV, azureClientSecret.Namespace(), azureClientSecret.Name(), v)
 			min = azureClientSecret
 		}
 	}
 
 	minV := fieldFn(min)
 	if minV < floor {
-		logs.Warn.Printf("AzureClientSecret %s/%s for %s has invalid %s value %d; rounding up to %d", min.Namespace(), min.Name(), min.Spec.AzureServicePrincipal.ApplicationID, fieldName, minV, floor)
+		logs.Warn.Printf("AzureClientSecret %s/%s for %s has invalid %s value %s; rounding up to %s", min.Namespace(), min.Name(), min.Spec.AzureServicePrincipal.ApplicationID, fieldName, minV, floor)
 		return floor
 	}
 	return minV
@@ -250,3 +347,103 @@ func computeIgnoreUsageMetricsAzureClientSecret(azureClientSecrets []apiv1b1.Azu
 	}
 	return first.Spec.KeyRotation.IgnoreUsageMetrics
 }
+
+func computePausedGSK(gsks []apiv1b1.GcpSaKey) bool {
+	if len(gsks) == 0 {
+		return false
+	}
+	first := gsks[0]
+	for _, gsk := range gsks {
+		if gsk.Spec.KeyRotation.Paused != first.Spec.KeyRotation.Paused {
+			logs.Warn.Printf("`Paused` field differs between GcpSaKey resources for %s: %s/%s=%t and %s/%s=%t; rotation will not be paused", gsk.Spec.GoogleServiceAccount.Name, first.ObjectMeta.Namespace, first.ObjectMeta.Name, first.Spec.KeyRotation.Paused, gsk.ObjectMeta.Namespace, gsk.ObjectMeta.Name, gsk.Spec.KeyRotation.Paused)
+			return false
+		}
+	}
+	return first.Spec.KeyRotation.Paused
+}
+
+func computePausedAzureClientSecret(azureClientSecrets []apiv1b1.AzureClientSecret) bool {
+	if len(azureClientSecrets) == 0 {
+		return false
+	}
+	first := azureClientSecrets[0]
+	for _, azureClientSecret := range azureClientSecrets {
+		if azureClientSecret.Spec.KeyRotation.Paused != first.Spec.KeyRotation.Paused {
+			logs.Warn.Printf("`Paused` field differs between AzureClientSecret resources for %s: %s/%s=%t and %s/%s=%t; rotation will not be paused", azureClientSecret.Spec.AzureServicePrincipal.ApplicationID, first.Namespace(), first.Name(), first.Spec.KeyRotation.Paused, azureClientSecret.Namespace(), azureClientSecret.Name(), azureClientSecret.Spec.KeyRotation.Paused)
+			return false
+		}
+	}
+	return first.Spec.KeyRotation.Paused
+}
+
+func computeDecommissionedGSK(gsks []apiv1b1.GcpSaKey) bool {
+	if len(gsks) == 0 {
+		return false
+	}
+	first := gsks[0]
+	for _, gsk := range gsks {
+		if gsk.Spec.KeyRotation.Decommission != first.Spec.KeyRotation.Decommission {
+			logs.Warn.Printf("`Decommission` field differs between GcpSaKey resources for %s: %s/%s=%t and %s/%s=%t; new keys will still be issued", gsk.Spec.GoogleServiceAccount.Name, first.ObjectMeta.Namespace, first.ObjectMeta.Name, first.Spec.KeyRotation.Decommission, gsk.ObjectMeta.Namespace, gsk.ObjectMeta.Name, gsk.Spec.KeyRotation.Decommission)
+			return false
+		}
+	}
+	return first.Spec.KeyRotation.Decommission
+}
+
+func computeDecommissionedAzureClientSecret(azureClientSecrets []apiv1b1.AzureClientSecret) bool {
+	if len(azureClientSecrets) == 0 {
+		return false
+	}
+	first := azureClientSecrets[0]
+	for _, azureClientSecret := range azureClientSecrets {
+		if azureClientSecret.Spec.KeyRotation.Decommission != first.Spec.KeyRotation.Decommission {
+			logs.Warn.Printf("`Decommission` field differs between AzureClientSecret resources for %s: %s/%s=%t and %s/%s=%t; new keys will still be issued", azureClientSecret.Spec.AzureServicePrincipal.ApplicationID, first.Namespace(), first.Name(), first.Spec.KeyRotation.Decommission, azureClientSecret.Namespace(), azureClientSecret.Name(), azureClientSecret.Spec.KeyRotation.Decommission)
+			return false
+		}
+	}
+	return first.Spec.KeyRotation.Decommission
+}
+
+// computeMaxKeyAge returns the smallest max key age set in the given key rotation specs, since the alert
+// is a failsafe and the strictest resource should win, or 0 if none of them set one
+func computeMaxKeyAge(rotations []apiv1b1.KeyRotation) time.Duration {
+	var result time.Duration
+	for _, rotation := range rotations {
+		if rotation.MaxKeyAge <= 0 {
+			continue
+		}
+		maxKeyAge := time.Duration(rotation.MaxKeyAge) * oneDay
+		if result == 0 || maxKeyAge < result {
+			result = maxKeyAge
+		}
+	}
+	return result
+}
+
+// computeSafeToDisableBuffer returns the smallest safe-to-disable buffer set in the given key rotation specs,
+// rounded up to the given floor. Specs that don't set one are ignored, so that a longer buffer set on one resource
+// isn't dropped because another resource for the same identifier left it unset; the default buffer is only used
+// if none of them set one.
+func computeSafeToDisableBuffer(rotations []apiv1b1.KeyRotation, floor time.Duration, identifier string) time.Duration {
+	var result time.Duration
+	for _, rotation := range rotations {
+		if rotation.SafeToDisableBufferDays <= 0 {
+			continue
+		}
+		buffer := time.Duration(rotation.SafeToDisableBufferDays) * oneDay
+		if result != 0 && buffer != result {
+			logs.Warn.Printf("found different SafeToDisableBufferDays values in resources for %s: %s and %s", identifier, result, buffer)
+		}
+		if result == 0 || buffer < result {
+			result = buffer
+		}
+	}
+	if result == 0 {
+		return lastAuthSafeDisableBuffer
+	}
+	if result < floor {
+		logs.Warn.Printf("%s has SafeToDisableBufferDays value %s; rounding up to %s", identifier, result, floor)
+		return floor
+	}
+	return result
+}
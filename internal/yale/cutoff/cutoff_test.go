@@ -33,6 +33,7 @@ func Test_Cutoffs(t *testing.T) {
 	testCases := []struct {
 		name               string
 		input              v1beta1.KeyRotation
+		options            []Option
 		expectedThresholds thresholds
 		expectedCutoffs    cutoffTimes
 		shouldChecks       []shouldChecks
@@ -45,9 +46,9 @@ func Test_Cutoffs(t *testing.T) {
 				DeleteAfter:  1,
 			},
 			expectedThresholds: thresholds{
-				rotateAfter:  7,
-				disableAfter: 7,
-				deleteAfter:  3,
+				rotateAfter:  7 * oneDay,
+				disableAfter: 7 * oneDay,
+				deleteAfter:  3 * oneDay,
 			},
 			expectedCutoffs: cutoffTimes{
 				rotateCutoff:        "2023-04-21T09:10:11Z",
@@ -94,9 +95,9 @@ func Test_Cutoffs(t *testing.T) {
 				DeleteAfter:  8,
 			},
 			expectedThresholds: thresholds{
-				rotateAfter:  17,
-				disableAfter: 16,
-				deleteAfter:  8,
+				rotateAfter:  17 * oneDay,
+				disableAfter: 16 * oneDay,
+				deleteAfter:  8 * oneDay,
 			},
 			expectedCutoffs: cutoffTimes{
 				rotateCutoff:        "2023-04-11T09:10:11Z",
@@ -151,9 +152,9 @@ func Test_Cutoffs(t *testing.T) {
 				IgnoreUsageMetrics: true,
 			},
 			expectedThresholds: thresholds{
-				rotateAfter:  7,
-				disableAfter: 7,
-				deleteAfter:  3,
+				rotateAfter:  7 * oneDay,
+				disableAfter: 7 * oneDay,
+				deleteAfter:  3 * oneDay,
 			},
 			expectedCutoffs: cutoffTimes{
 				rotateCutoff:        "2023-04-21T09:10:11Z",
@@ -171,6 +172,214 @@ func Test_Cutoffs(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "should never rotate if rotation is paused",
+			input: v1beta1.KeyRotation{
+				RotateAfter:  7,
+				DisableAfter: 7,
+				DeleteAfter:  3,
+				Paused:       true,
+			},
+			expectedThresholds: thresholds{
+				rotateAfter:  7 * oneDay,
+				disableAfter: 7 * oneDay,
+				deleteAfter:  3 * oneDay,
+			},
+			expectedCutoffs: cutoffTimes{
+				rotateCutoff:        "2023-04-21T09:10:11Z",
+				disableCutoff:       "2023-04-21T09:10:11Z",
+				safeToDisableCutoff: "2023-04-25T09:10:11Z",
+				deleteCutoff:        "2023-04-25T09:10:11Z",
+			},
+			shouldChecks: []shouldChecks{
+				{
+					input:       "2023-04-01T00:00:00Z",
+					rotate:      false,
+					disable:     true,
+					safeDisable: true,
+					delete:      true,
+				},
+			},
+		},
+		{
+			name: "should support hour-scale durations when sub-day thresholds are allowed",
+			input: v1beta1.KeyRotation{
+				RotateAfter:          7,
+				DisableAfter:         7,
+				DeleteAfter:          3,
+				RotateAfterDuration:  "12h",
+				DisableAfterDuration: "6h",
+				DeleteAfterDuration:  "90m",
+				IgnoreUsageMetrics:   true,
+			},
+			options: []Option{func(options *Options) {
+				options.AllowSubDayThresholds = true
+			}},
+			expectedThresholds: thresholds{
+				rotateAfter:  12 * time.Hour,
+				disableAfter: 6 * time.Hour,
+				deleteAfter:  90 * time.Minute,
+			},
+			expectedCutoffs: cutoffTimes{
+				rotateCutoff:        "2023-04-27T21:10:11Z",
+				disableCutoff:       "2023-04-28T03:10:11Z",
+				safeToDisableCutoff: "2023-04-25T09:10:11Z",
+				deleteCutoff:        "2023-04-28T07:40:11Z",
+			},
+			shouldChecks: []shouldChecks{
+				{
+					input:       "2023-04-27T21:05:11Z",
+					rotate:      true,
+					disable:     true,
+					safeDisable: true,
+					delete:      true,
+				},
+				{
+					input:       "2023-04-28T05:00:00Z",
+					rotate:      false,
+					disable:     false,
+					safeDisable: true,
+					delete:      true,
+				},
+				{
+					input:       "2023-04-28T08:00:00Z",
+					rotate:      false,
+					disable:     false,
+					safeDisable: true,
+					delete:      false,
+				},
+			},
+		},
+		{
+			name: "should round hour-scale durations up to sub-day minimums when sub-day thresholds are allowed",
+			input: v1beta1.KeyRotation{
+				RotateAfterDuration:  "30m",
+				DisableAfterDuration: "0s",
+				DeleteAfterDuration:  "-1h",
+			},
+			options: []Option{func(options *Options) {
+				options.AllowSubDayThresholds = true
+			}},
+			expectedThresholds: thresholds{
+				rotateAfter:  time.Hour,
+				disableAfter: time.Hour,
+				deleteAfter:  time.Hour,
+			},
+			expectedCutoffs: cutoffTimes{
+				rotateCutoff:        "2023-04-28T08:10:11Z",
+				disableCutoff:       "2023-04-28T08:10:11Z",
+				safeToDisableCutoff: "2023-04-25T09:10:11Z",
+				deleteCutoff:        "2023-04-28T08:10:11Z",
+			},
+		},
+		{
+			name: "should round hour-scale durations up to day minimums unless sub-day thresholds are allowed",
+			input: v1beta1.KeyRotation{
+				RotateAfterDuration:  "12h",
+				DisableAfterDuration: "6h",
+				DeleteAfterDuration:  "90m",
+			},
+			expectedThresholds: thresholds{
+				rotateAfter:  7 * oneDay,
+				disableAfter: 7 * oneDay,
+				deleteAfter:  3 * oneDay,
+			},
+			expectedCutoffs: cutoffTimes{
+				rotateCutoff:        "2023-04-21T09:10:11Z",
+				disableCutoff:       "2023-04-21T09:10:11Z",
+				safeToDisableCutoff: "2023-04-25T09:10:11Z",
+				deleteCutoff:        "2023-04-25T09:10:11Z",
+			},
+		},
+		{
+			name: "should use a custom safe-to-disable buffer",
+			input: v1beta1.KeyRotation{
+				RotateAfter:             7,
+				DisableAfter:            7,
+				DeleteAfter:             3,
+				SafeToDisableBufferDays: 10,
+			},
+			expectedThresholds: thresholds{
+				rotateAfter:  7 * oneDay,
+				disableAfter: 7 * oneDay,
+				deleteAfter:  3 * oneDay,
+			},
+			expectedCutoffs: cutoffTimes{
+				rotateCutoff:        "2023-04-21T09:10:11Z",
+				disableCutoff:       "2023-04-21T09:10:11Z",
+				safeToDisableCutoff: "2023-04-18T09:10:11Z",
+				deleteCutoff:        "2023-04-25T09:10:11Z",
+			},
+			shouldChecks: []shouldChecks{
+				{
+					// last used 5 days ago: safe with the default buffer, but not with this one
+					input:       "2023-04-23T09:10:11Z",
+					rotate:      false,
+					disable:     false,
+					safeDisable: false,
+					delete:      true,
+				},
+				{
+					input:       "2023-04-17T09:10:11Z",
+					rotate:      true,
+					disable:     true,
+					safeDisable: true,
+					delete:      true,
+				},
+			},
+		},
+		{
+			name: "should round a safe-to-disable buffer up to the default",
+			input: v1beta1.KeyRotation{
+				RotateAfter:             7,
+				DisableAfter:            7,
+				DeleteAfter:             3,
+				SafeToDisableBufferDays: 1,
+			},
+			expectedThresholds: thresholds{
+				rotateAfter:  7 * oneDay,
+				disableAfter: 7 * oneDay,
+				deleteAfter:  3 * oneDay,
+			},
+			expectedCutoffs: cutoffTimes{
+				rotateCutoff:        "2023-04-21T09:10:11Z",
+				disableCutoff:       "2023-04-21T09:10:11Z",
+				safeToDisableCutoff: "2023-04-25T09:10:11Z",
+				deleteCutoff:        "2023-04-25T09:10:11Z",
+			},
+			shouldChecks: []shouldChecks{
+				{
+					input:       "2023-04-26T09:10:11Z",
+					rotate:      false,
+					disable:     false,
+					safeDisable: false,
+					delete:      false,
+				},
+			},
+		},
+		{
+			name: "should not round a safe-to-disable buffer down to sub-day minimums when sub-day thresholds are allowed",
+			input: v1beta1.KeyRotation{
+				RotateAfterDuration:     "2h",
+				DisableAfterDuration:    "2h",
+				DeleteAfterDuration:     "2h",
+				SafeToDisableBufferDays: 1,
+			},
+			options: []Option{func(options *Options) {
+				options.AllowSubDayThresholds = true
+			}},
+			expectedThresholds: thresholds{
+				rotateAfter:  2 * time.Hour,
+				disableAfter: 2 * time.Hour,
+				deleteAfter:  2 * time.Hour,
+			},
+			expectedCutoffs: cutoffTimes{
+				rotateCutoff:        "2023-04-28T07:10:11Z",
+				disableCutoff:       "2023-04-28T07:10:11Z",
+				safeToDisableCutoff: "2023-04-25T09:10:11Z",
+				deleteCutoff:        "2023-04-28T07:10:11Z",
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -184,11 +393,11 @@ func Test_Cutoffs(t *testing.T) {
 					KeyRotation: tc.input,
 				},
 			}
-			c := newWithCustomTime([]v1beta1.GcpSaKey{gsk}, now)
+			c := newWithCustomTime([]v1beta1.GcpSaKey{gsk}, now, tc.options...)
 
-			assert.Equal(t, tc.expectedThresholds.rotateAfter, c.RotateAfterDays())
-			assert.Equal(t, tc.expectedThresholds.disableAfter, c.DisableAfterDays())
-			assert.Equal(t, tc.expectedThresholds.deleteAfter, c.DeleteAfterDays())
+			assert.Equal(t, tc.expectedThresholds.rotateAfter, c.RotateAfter())
+			assert.Equal(t, tc.expectedThresholds.disableAfter, c.DisableAfter())
+			assert.Equal(t, tc.expectedThresholds.deleteAfter, c.DeleteAfter())
 
 			assert.Equal(t, tc.expectedCutoffs.rotateCutoff, c.rotateCutoff().Format(layout))
 			assert.Equal(t, tc.expectedCutoffs.disableCutoff, c.disableCutoff().Format(layout))
@@ -219,11 +428,11 @@ func Test_Cutoffs(t *testing.T) {
 					KeyRotation: tc.input,
 				},
 			}
-			c := newWithCustomTime([]v1beta1.AzureClientSecret{azureClientSecret}, now)
+			c := newWithCustomTime([]v1beta1.AzureClientSecret{azureClientSecret}, now, tc.options...)
 
-			assert.Equal(t, tc.expectedThresholds.rotateAfter, c.RotateAfterDays())
-			assert.Equal(t, tc.expectedThresholds.disableAfter, c.DisableAfterDays())
-			assert.Equal(t, tc.expectedThresholds.deleteAfter, c.DeleteAfterDays())
+			assert.Equal(t, tc.expectedThresholds.rotateAfter, c.RotateAfter())
+			assert.Equal(t, tc.expectedThresholds.disableAfter, c.DisableAfter())
+			assert.Equal(t, tc.expectedThresholds.deleteAfter, c.DeleteAfter())
 
 			assert.Equal(t, tc.expectedCutoffs.rotateCutoff, c.rotateCutoff().Format(layout))
 			assert.Equal(t, tc.expectedCutoffs.disableCutoff, c.disableCutoff().Format(layout))
@@ -270,9 +479,10 @@ func Test_computeThresholds(t *testing.T) {
 				},
 			},
 			expected: thresholds{
-				rotateAfter:  7,
-				disableAfter: 8,
-				deleteAfter:  9,
+				rotateAfter:         7 * oneDay,
+				disableAfter:        8 * oneDay,
+				deleteAfter:         9 * oneDay,
+				safeToDisableBuffer: 3 * oneDay,
 			},
 		},
 		{
@@ -296,9 +506,10 @@ func Test_computeThresholds(t *testing.T) {
 				},
 			},
 			expected: thresholds{
-				rotateAfter:  7,
-				disableAfter: 7,
-				deleteAfter:  3,
+				rotateAfter:         7 * oneDay,
+				disableAfter:        7 * oneDay,
+				deleteAfter:         3 * oneDay,
+				safeToDisableBuffer: 3 * oneDay,
 			},
 		},
 		{
@@ -370,16 +581,152 @@ func Test_computeThresholds(t *testing.T) {
 				},
 			},
 			expected: thresholds{
-				rotateAfter:  7,
-				disableAfter: 9,
-				deleteAfter:  3,
+				rotateAfter:         7 * oneDay,
+				disableAfter:        9 * oneDay,
+				deleteAfter:         3 * oneDay,
+				safeToDisableBuffer: 3 * oneDay,
+			},
+		},
+		{
+			name: "should prefer valid duration fields over day fields",
+			input: []v1beta1.GcpSaKey{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-gsk-1",
+						Namespace: "test-namespace",
+					},
+					Spec: v1beta1.GCPSaKeySpec{
+						KeyRotation: v1beta1.KeyRotation{
+							RotateAfter:          7,
+							DisableAfter:         8,
+							DeleteAfter:          9,
+							RotateAfterDuration:  "240h",
+							DisableAfterDuration: "not-a-duration",
+						},
+						GoogleServiceAccount: v1beta1.GoogleServiceAccount{
+							Name: "my-sa@p.com",
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-gsk-2",
+						Namespace: "test-namespace",
+					},
+					Spec: v1beta1.GCPSaKeySpec{
+						KeyRotation: v1beta1.KeyRotation{
+							RotateAfter:         14,
+							DisableAfter:        14,
+							DeleteAfter:         14,
+							DeleteAfterDuration: "100h",
+						},
+						GoogleServiceAccount: v1beta1.GoogleServiceAccount{
+							Name: "my-sa@p.com",
+						},
+					},
+				},
+			},
+			expected: thresholds{
+				rotateAfter:         10 * oneDay,
+				disableAfter:        8 * oneDay,
+				deleteAfter:         100 * time.Hour,
+				safeToDisableBuffer: 3 * oneDay,
+			},
+		},
+		{
+			name: "should choose the smallest safe-to-disable buffer for multiple GSK specs",
+			input: []v1beta1.GcpSaKey{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-gsk-1",
+						Namespace: "test-ns-1",
+					},
+					Spec: v1beta1.GCPSaKeySpec{
+						KeyRotation: v1beta1.KeyRotation{
+							RotateAfter:             7,
+							DisableAfter:            7,
+							DeleteAfter:             3,
+							SafeToDisableBufferDays: 10,
+						},
+						GoogleServiceAccount: v1beta1.GoogleServiceAccount{
+							Name: "my-sa@p.com",
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-gsk-2",
+						Namespace: "test-ns-2",
+					},
+					Spec: v1beta1.GCPSaKeySpec{
+						KeyRotation: v1beta1.KeyRotation{
+							RotateAfter:             7,
+							DisableAfter:            7,
+							DeleteAfter:             3,
+							SafeToDisableBufferDays: 5,
+						},
+						GoogleServiceAccount: v1beta1.GoogleServiceAccount{
+							Name: "my-sa@p.com",
+						},
+					},
+				},
+			},
+			expected: thresholds{
+				rotateAfter:         7 * oneDay,
+				disableAfter:        7 * oneDay,
+				deleteAfter:         3 * oneDay,
+				safeToDisableBuffer: 5 * oneDay,
+			},
+		},
+		{
+			name: "should ignore GSK specs that do not set a safe-to-disable buffer",
+			input: []v1beta1.GcpSaKey{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-gsk-1",
+						Namespace: "test-ns-1",
+					},
+					Spec: v1beta1.GCPSaKeySpec{
+						KeyRotation: v1beta1.KeyRotation{
+							RotateAfter:             7,
+							DisableAfter:            7,
+							DeleteAfter:             3,
+							SafeToDisableBufferDays: 10,
+						},
+						GoogleServiceAccount: v1beta1.GoogleServiceAccount{
+							Name: "my-sa@p.com",
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-gsk-2",
+						Namespace: "test-ns-2",
+					},
+					Spec: v1beta1.GCPSaKeySpec{
+						KeyRotation: v1beta1.KeyRotation{
+							RotateAfter:  7,
+							DisableAfter: 7,
+							DeleteAfter:  3,
+						},
+						GoogleServiceAccount: v1beta1.GoogleServiceAccount{
+							Name: "my-sa@p.com",
+						},
+					},
+				},
+			},
+			expected: thresholds{
+				rotateAfter:         7 * oneDay,
+				disableAfter:        7 * oneDay,
+				deleteAfter:         3 * oneDay,
+				safeToDisableBuffer: 10 * oneDay,
 			},
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			assert.Equal(t, tc.expected, computeThresholds(tc.input))
+			assert.Equal(t, tc.expected, computeThresholds(tc.input, minimums))
 		})
 	}
 }
@@ -412,9 +759,10 @@ func Test_computeThresholdsAzureClientSecrets(t *testing.T) {
 				},
 			},
 			expected: thresholds{
-				rotateAfter:  7,
-				disableAfter: 8,
-				deleteAfter:  9,
+				rotateAfter:         7 * oneDay,
+				disableAfter:        8 * oneDay,
+				deleteAfter:         9 * oneDay,
+				safeToDisableBuffer: 3 * oneDay,
 			},
 		},
 		{
@@ -439,9 +787,10 @@ func Test_computeThresholdsAzureClientSecrets(t *testing.T) {
 				},
 			},
 			expected: thresholds{
-				rotateAfter:  7,
-				disableAfter: 7,
-				deleteAfter:  3,
+				rotateAfter:         7 * oneDay,
+				disableAfter:        7 * oneDay,
+				deleteAfter:         3 * oneDay,
+				safeToDisableBuffer: 3 * oneDay,
 			},
 		},
 		{
@@ -517,16 +866,17 @@ func Test_computeThresholdsAzureClientSecrets(t *testing.T) {
 				},
 			},
 			expected: thresholds{
-				rotateAfter:  7,
-				disableAfter: 9,
-				deleteAfter:  3,
+				rotateAfter:         7 * oneDay,
+				disableAfter:        9 * oneDay,
+				deleteAfter:         3 * oneDay,
+				safeToDisableBuffer: 3 * oneDay,
 			},
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			assert.Equal(t, tc.expected, computeThresholds(tc.input))
+			assert.Equal(t, tc.expected, computeThresholds(tc.input, minimums))
 		})
 	}
 }
@@ -872,3 +1222,236 @@ func Test_computeIgnoreUsageMetricsAzureClientSecrets(t *testing.T) {
 		})
 	}
 }
+
+func Test_computePaused(t *testing.T) {
+	gsk := func(name string, paused bool) v1beta1.GcpSaKey {
+		return v1beta1.GcpSaKey{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "ns-1",
+			},
+			Spec: v1beta1.GCPSaKeySpec{
+				KeyRotation: v1beta1.KeyRotation{
+					Paused: paused,
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		input    []v1beta1.GcpSaKey
+		expected bool
+	}{
+		{
+			name:     "empty",
+			input:    []v1beta1.GcpSaKey{},
+			expected: false,
+		},
+		{
+			name:     "single gsk with paused set to false",
+			input:    []v1beta1.GcpSaKey{gsk("gsk-1", false)},
+			expected: false,
+		},
+		{
+			name:     "single gsk with paused set to true",
+			input:    []v1beta1.GcpSaKey{gsk("gsk-1", true)},
+			expected: true,
+		},
+		{
+			name:     "multiple gsks with paused set to true",
+			input:    []v1beta1.GcpSaKey{gsk("gsk-1", true), gsk("gsk-2", true)},
+			expected: true,
+		},
+		{
+			name:     "multiple gsks with paused set to true and false",
+			input:    []v1beta1.GcpSaKey{gsk("gsk-1", true), gsk("gsk-2", false), gsk("gsk-3", true)},
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, computePausedGSK(tc.input))
+		})
+	}
+}
+
+func Test_computePausedAzureClientSecrets(t *testing.T) {
+	acs := func(name string, paused bool) v1beta1.AzureClientSecret {
+		return v1beta1.AzureClientSecret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "ns-1",
+			},
+			Spec: v1beta1.AzureClientSecretSpec{
+				KeyRotation: v1beta1.KeyRotation{
+					Paused: paused,
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		input    []v1beta1.AzureClientSecret
+		expected bool
+	}{
+		{
+			name:     "empty",
+			input:    []v1beta1.AzureClientSecret{},
+			expected: false,
+		},
+		{
+			name:     "single client secret with paused set to true",
+			input:    []v1beta1.AzureClientSecret{acs("acs-1", true)},
+			expected: true,
+		},
+		{
+			name:     "multiple client secrets with paused set to true and false",
+			input:    []v1beta1.AzureClientSecret{acs("acs-1", true), acs("acs-2", false)},
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, computePausedAzureClientSecret(tc.input))
+		})
+	}
+}
+
+func Test_computeDecommissioned(t *testing.T) {
+	gsk := func(name string, decommission bool) v1beta1.GcpSaKey {
+		return v1beta1.GcpSaKey{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "ns-1",
+			},
+			Spec: v1beta1.GCPSaKeySpec{
+				KeyRotation: v1beta1.KeyRotation{
+					Decommission: decommission,
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		input    []v1beta1.GcpSaKey
+		expected bool
+	}{
+		{
+			name:     "empty",
+			input:    []v1beta1.GcpSaKey{},
+			expected: false,
+		},
+		{
+			name:     "single gsk with decommission set to true",
+			input:    []v1beta1.GcpSaKey{gsk("gsk-1", true)},
+			expected: true,
+		},
+		{
+			name:     "multiple gsks with decommission set to true",
+			input:    []v1beta1.GcpSaKey{gsk("gsk-1", true), gsk("gsk-2", true)},
+			expected: true,
+		},
+		{
+			name:     "multiple gsks with decommission set to true and false",
+			input:    []v1beta1.GcpSaKey{gsk("gsk-1", true), gsk("gsk-2", false)},
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, computeDecommissionedGSK(tc.input))
+		})
+	}
+}
+
+func Test_computeDecommissionedAzureClientSecrets(t *testing.T) {
+	acs := func(name string, decommission bool) v1beta1.AzureClientSecret {
+		return v1beta1.AzureClientSecret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "ns-1",
+			},
+			Spec: v1beta1.AzureClientSecretSpec{
+				KeyRotation: v1beta1.KeyRotation{
+					Decommission: decommission,
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		input    []v1beta1.AzureClientSecret
+		expected bool
+	}{
+		{
+			name:     "single client secret with decommission set to true",
+			input:    []v1beta1.AzureClientSecret{acs("acs-1", true)},
+			expected: true,
+		},
+		{
+			name:     "multiple client secrets with decommission set to true and false",
+			input:    []v1beta1.AzureClientSecret{acs("acs-1", true), acs("acs-2", false)},
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, computeDecommissionedAzureClientSecret(tc.input))
+		})
+	}
+}
+
+func Test_computeMaxKeyAge(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    []v1beta1.KeyRotation
+		expected time.Duration
+	}{
+		{
+			name:     "empty",
+			input:    []v1beta1.KeyRotation{},
+			expected: 0,
+		},
+		{
+			name:     "not configured",
+			input:    []v1beta1.KeyRotation{{RotateAfter: 30}},
+			expected: 0,
+		},
+		{
+			name:     "single resource",
+			input:    []v1beta1.KeyRotation{{MaxKeyAge: 90}},
+			expected: 90 * oneDay,
+		},
+		{
+			name:     "multiple resources use the smallest configured value",
+			input:    []v1beta1.KeyRotation{{MaxKeyAge: 90}, {}, {MaxKeyAge: 60}},
+			expected: 60 * oneDay,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, computeMaxKeyAge(tc.input))
+		})
+	}
+}
+
+func Test_ExceedsMaxKeyAge(t *testing.T) {
+	now := time.Date(2023, 4, 28, 9, 10, 11, 0, time.UTC)
+
+	unconfigured := newWithThresholds(thresholds{}, now)
+	assert.False(t, unconfigured.ExceedsMaxKeyAge(now.Add(-365*oneDay)))
+
+	c := newWithThresholds(thresholds{maxKeyAge: 90 * oneDay}, now)
+	assert.Equal(t, 90*oneDay, c.MaxKeyAge())
+	assert.False(t, c.ExceedsMaxKeyAge(now.Add(-89*oneDay)))
+	assert.True(t, c.ExceedsMaxKeyAge(now.Add(-91*oneDay)))
+}
@@ -0,0 +1,101 @@
+package health
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/broadinstitute/yale/internal/yale/logs"
+)
+
+// Checker tracks the state Yale reports to K8s liveness and readiness probes in daemon mode
+type Checker struct {
+	mutex sync.Mutex
+	// staleAfter how long after the last successful run Yale stops reporting itself as ready
+	staleAfter time.Duration
+	// clientsInitialized true once Yale has built its K8s, GCP, Vault, etc. clients
+	clientsInitialized bool
+	// lastSuccessfulRun time at which the last run completed without error, or zero if none has
+	lastSuccessfulRun time.Time
+}
+
+// New returns a Checker that reports Yale as ready once its clients are initialized, for as long as the last
+// successful run completed within staleAfter
+func New(staleAfter time.Duration) *Checker {
+	return &Checker{staleAfter: staleAfter}
+}
+
+// ClientsInitialized records that Yale's clients have been built
+func (c *Checker) ClientsInitialized() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.clientsInitialized = true
+}
+
+// RecordSuccessfulRun records that a run completed without error at the given time
+func (c *Checker) RecordSuccessfulRun(t time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.lastSuccessfulRun = t
+}
+
+// Ready returns nil if Yale is ready, or an error describing why it is not
+func (c *Checker) Ready() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !c.clientsInitialized {
+		return fmt.Errorf("clients are not initialized")
+	}
+	if c.lastSuccessfulRun.IsZero() {
+		return fmt.Errorf("no run has completed successfully")
+	}
+	if age := time.Since(c.lastSuccessfulRun); age > c.staleAfter {
+		return fmt.Errorf("last successful run completed at %s, %s ago, which is longer than the staleness window of %s", c.lastSuccessfulRun.Format(time.RFC3339), age.Round(time.Second), c.staleAfter)
+	}
+	return nil
+}
+
+// Handler returns an http.Handler that serves /healthz, which succeeds as long as the process is up, and
+// /readyz, which succeeds only if the Checker reports Yale as ready
+func (c *Checker) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		writeStatus(w, http.StatusOK, "ok")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, _ *http.Request) {
+		if err := c.Ready(); err != nil {
+			writeStatus(w, http.StatusServiceUnavailable, "not ready: "+err.Error())
+			return
+		}
+		writeStatus(w, http.StatusOK, "ok")
+	})
+	return mux
+}
+
+// Serve starts an HTTP server in the background that serves the Checker's health endpoints on the given address
+func Serve(addr string, c *Checker) *http.Server {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           c.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		logs.Info.Printf("serving health checks at %s/healthz and %s/readyz", addr, addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logs.Error.Printf("error serving health checks at %s: %v", addr, err)
+		}
+	}()
+
+	return server
+}
+
+func writeStatus(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(code)
+	if _, err := w.Write([]byte(message + "\n")); err != nil {
+		logs.Warn.Printf("error writing health check response: %v", err)
+	}
+}
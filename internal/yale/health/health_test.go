@@ -0,0 +1,81 @@
+package health
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Handler(t *testing.T) {
+	testCases := []struct {
+		name               string
+		clientsInitialized bool
+		lastSuccessfulRun  time.Time
+		readyStatus        int
+		readyBody          string
+	}{
+		{
+			name:               "fresh",
+			clientsInitialized: true,
+			lastSuccessfulRun:  time.Now().Add(-time.Minute),
+			readyStatus:        http.StatusOK,
+			readyBody:          "ok",
+		},
+		{
+			name:               "stale",
+			clientsInitialized: true,
+			lastSuccessfulRun:  time.Now().Add(-2 * time.Hour),
+			readyStatus:        http.StatusServiceUnavailable,
+			readyBody:          "2h0m0s ago, which is longer than the staleness window of 1h0m0s",
+		},
+		{
+			name:               "never run",
+			clientsInitialized: true,
+			readyStatus:        http.StatusServiceUnavailable,
+			readyBody:          "not ready: no run has completed successfully",
+		},
+		{
+			name:              "clients not initialized",
+			lastSuccessfulRun: time.Now().Add(-time.Minute),
+			readyStatus:       http.StatusServiceUnavailable,
+			readyBody:         "not ready: clients are not initialized",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			checker := New(time.Hour)
+			if tc.clientsInitialized {
+				checker.ClientsInitialized()
+			}
+			if !tc.lastSuccessfulRun.IsZero() {
+				checker.RecordSuccessfulRun(tc.lastSuccessfulRun)
+			}
+
+			server := httptest.NewServer(checker.Handler())
+			defer server.Close()
+
+			// liveness only depends on the process being up
+			status, body := get(t, server.URL+"/healthz")
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, "ok\n", body)
+
+			status, body = get(t, server.URL+"/readyz")
+			assert.Equal(t, tc.readyStatus, status)
+			assert.Contains(t, body, tc.readyBody)
+		})
+	}
+}
+
+func get(t *testing.T, url string) (int, string) {
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(body)
+}
@@ -0,0 +1,184 @@
+package awskeyops
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/broadinstitute/yale/internal/yale/keyops"
+	"github.com/broadinstitute/yale/internal/yale/logs"
+)
+
+// maxAccessKeys maximum number of access keys AWS allows an IAM user to have at once
+const maxAccessKeys = 2
+
+// IAMClient the subset of the AWS IAM API used to manage IAM user access keys. Implemented by *iam.Client.
+type IAMClient interface {
+	CreateAccessKey(ctx context.Context, params *iam.CreateAccessKeyInput, optFns ...func(*iam.Options)) (*iam.CreateAccessKeyOutput, error)
+	ListAccessKeys(ctx context.Context, params *iam.ListAccessKeysInput, optFns ...func(*iam.Options)) (*iam.ListAccessKeysOutput, error)
+	UpdateAccessKey(ctx context.Context, params *iam.UpdateAccessKeyInput, optFns ...func(*iam.Options)) (*iam.UpdateAccessKeyOutput, error)
+	DeleteAccessKey(ctx context.Context, params *iam.DeleteAccessKeyInput, optFns ...func(*iam.Options)) (*iam.DeleteAccessKeyOutput, error)
+}
+
+// errNoIAMClient returned when AWS is not configured
+var errNoIAMClient = errors.New("AWS IAM client is not configured; set AWS credentials (eg. `AWS_ACCESS_KEY_ID`) to enable AWS IAM access key rotation")
+
+// credentials is the JSON written for a new access key, in the format expected from an AWS CLI
+// credential_process (https://docs.aws.amazon.com/cli/latest/userguide/cli-configure-sourcing-external.html)
+type credentials struct {
+	Version         int
+	AccessKeyId     string
+	SecretAccessKey string
+}
+
+type awsKeyOps struct {
+	iam IAMClient
+}
+
+// New returns a KeyOps for AWS IAM user access keys. Keys are scoped by AWS account ID and identified by
+// IAM user name. If client is nil, every operation returns an error.
+func New(client IAMClient) keyops.KeyOps {
+	return &awsKeyOps{iam: client}
+}
+
+func (a *awsKeyOps) Create(accountID string, userName string, _ ...keyops.CreateOption) (keyops.Key, []byte, error) {
+	if a.iam == nil {
+		return keyops.Key{}, nil, errNoIAMClient
+	}
+
+	logs.Info.Printf("creating new access key for IAM user %s in account %s...", userName, accountID)
+	resp, err := a.iam.CreateAccessKey(context.Background(), &iam.CreateAccessKeyInput{
+		UserName: aws.String(userName),
+	})
+	if err != nil {
+		var limitErr *types.LimitExceededException
+		if errors.As(err, &limitErr) {
+			return keyops.Key{}, nil, a.maxKeysError(userName, accountID)
+		}
+		return keyops.Key{}, nil, fmt.Errorf("error creating new access key for IAM user %s in account %s: %v", userName, accountID, err)
+	}
+	if resp.AccessKey == nil || resp.AccessKey.AccessKeyId == nil || resp.AccessKey.SecretAccessKey == nil {
+		return keyops.Key{}, nil, fmt.Errorf("error creating new access key for IAM user %s in account %s: response is missing access key id or secret", userName, accountID)
+	}
+
+	keyID := *resp.AccessKey.AccessKeyId
+	logs.Info.Printf("created new access key %s for IAM user %s in account %s", keyID, userName, accountID)
+
+	data, err := json.Marshal(credentials{
+		Version:         1,
+		AccessKeyId:     keyID,
+		SecretAccessKey: *resp.AccessKey.SecretAccessKey,
+	})
+	if err != nil {
+		return keyops.Key{}, nil, fmt.Errorf("error marshalling new access key %s for IAM user %s to JSON: %v", keyID, userName, err)
+	}
+
+	return keyops.Key{
+		Scope:      accountID,
+		Identifier: userName,
+		ID:         keyID,
+	}, data, nil
+}
+
+// maxKeysError returns an error explaining that the user already has as many access keys as AWS allows,
+// which happens when a rotated key hasn't been deleted yet. A new key can be issued once Yale deletes
+// the old one, or it is deleted by hand.
+func (a *awsKeyOps) maxKeysError(userName string, accountID string) error {
+	keys, err := a.listAccessKeys(userName)
+	if err != nil {
+		return fmt.Errorf("IAM user %s in account %s already has the maximum of %d access keys: %v", userName, accountID, maxAccessKeys, err)
+	}
+	var descriptions []string
+	for _, key := range keys {
+		descriptions = append(descriptions, fmt.Sprintf("%s (%s)", aws.ToString(key.AccessKeyId), key.Status))
+	}
+	return fmt.Errorf("IAM user %s in account %s already has the maximum of %d access keys (%s); a new key can't be issued until one is deleted", userName, accountID, maxAccessKeys, strings.Join(descriptions, ", "))
+}
+
+func (a *awsKeyOps) IsDisabled(key keyops.Key) (bool, error) {
+	metadata, err := a.findAccessKey(key)
+	if err != nil {
+		return false, err
+	}
+	return metadata.Status == types.StatusTypeInactive, nil
+}
+
+func (a *awsKeyOps) EnsureDisabled(key keyops.Key) error {
+	disabled, err := a.IsDisabled(key)
+	if err != nil {
+		return err
+	}
+	if disabled {
+		logs.Info.Printf("won't disable access key %s for IAM user %s; key is already inactive", key.ID, key.Identifier)
+		return nil
+	}
+
+	logs.Info.Printf("disabling access key %s for IAM user %s in account %s", key.ID, key.Identifier, key.Scope)
+	_, err = a.iam.UpdateAccessKey(context.Background(), &iam.UpdateAccessKeyInput{
+		AccessKeyId: aws.String(key.ID),
+		UserName:    aws.String(key.Identifier),
+		Status:      types.StatusTypeInactive,
+	})
+	if err != nil {
+		return fmt.Errorf("error disabling access key %s for IAM user %s in account %s: %v", key.ID, key.Identifier, key.Scope, err)
+	}
+	return nil
+}
+
+func (a *awsKeyOps) DeleteIfDisabled(key keyops.Key) error {
+	disabled, err := a.IsDisabled(key)
+	if err != nil {
+		return err
+	}
+	if !disabled {
+		return fmt.Errorf("access key %s for IAM user %s in account %s is not inactive; please manually verify it is not in use and disable it", key.ID, key.Identifier, key.Scope)
+	}
+
+	logs.Info.Printf("deleting access key %s for IAM user %s in account %s", key.ID, key.Identifier, key.Scope)
+	_, err = a.iam.DeleteAccessKey(context.Background(), &iam.DeleteAccessKeyInput{
+		AccessKeyId: aws.String(key.ID),
+		UserName:    aws.String(key.Identifier),
+	})
+	if err != nil {
+		return fmt.Errorf("error deleting access key %s for IAM user %s in account %s: %v", key.ID, key.Identifier, key.Scope, err)
+	}
+	return nil
+}
+
+// findAccessKey returns the metadata for the given access key, or an error if the IAM user has no such key
+func (a *awsKeyOps) findAccessKey(key keyops.Key) (types.AccessKeyMetadata, error) {
+	if a.iam == nil {
+		return types.AccessKeyMetadata{}, errNoIAMClient
+	}
+	keys, err := a.listAccessKeys(key.Identifier)
+	if err != nil {
+		return types.AccessKeyMetadata{}, err
+	}
+	for _, metadata := range keys {
+		if aws.ToString(metadata.AccessKeyId) == key.ID {
+			return metadata, nil
+		}
+	}
+	return types.AccessKeyMetadata{}, fmt.Errorf("IAM user %s in account %s has no access key with id %s", key.Identifier, key.Scope, key.ID)
+}
+
+// listAccessKeys returns the metadata of all the IAM user's access keys
+func (a *awsKeyOps) listAccessKeys(userName string) ([]types.AccessKeyMetadata, error) {
+	var result []types.AccessKeyMetadata
+	paginator := iam.NewListAccessKeysPaginator(a.iam, &iam.ListAccessKeysInput{
+		UserName: aws.String(userName),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("error listing access keys for IAM user %s: %v", userName, err)
+		}
+		result = append(result, page.AccessKeyMetadata...)
+	}
+	return result, nil
+}
@@ -0,0 +1,168 @@
+package awskeyops
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/broadinstitute/yale/internal/yale/keyops"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAccountID = "123456789012"
+const testUserName = "legacy-tooling"
+
+// fakeIAM in-memory fake of the AWS IAM access key API, that enforces the two-key limit like the real API
+type fakeIAM struct {
+	keys    []types.AccessKeyMetadata
+	created int
+	// err if set, returned from every call
+	err error
+}
+
+func (f *fakeIAM) CreateAccessKey(_ context.Context, params *iam.CreateAccessKeyInput, _ ...func(*iam.Options)) (*iam.CreateAccessKeyOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if len(f.keys) >= maxAccessKeys {
+		return nil, &types.LimitExceededException{Message: aws.String("Cannot exceed quota for AccessKeysPerUser: 2")}
+	}
+	f.created++
+	id := fmt.Sprintf("AKIA%d", f.created)
+	f.keys = append(f.keys, types.AccessKeyMetadata{
+		AccessKeyId: aws.String(id),
+		UserName:    params.UserName,
+		Status:      types.StatusTypeActive,
+	})
+	return &iam.CreateAccessKeyOutput{
+		AccessKey: &types.AccessKey{
+			AccessKeyId:     aws.String(id),
+			SecretAccessKey: aws.String("secret-" + id),
+			UserName:        params.UserName,
+			Status:          types.StatusTypeActive,
+		},
+	}, nil
+}
+
+func (f *fakeIAM) ListAccessKeys(_ context.Context, params *iam.ListAccessKeysInput, _ ...func(*iam.Options)) (*iam.ListAccessKeysOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	var result []types.AccessKeyMetadata
+	for _, key := range f.keys {
+		if aws.ToString(key.UserName) == aws.ToString(params.UserName) {
+			result = append(result, key)
+		}
+	}
+	return &iam.ListAccessKeysOutput{AccessKeyMetadata: result}, nil
+}
+
+func (f *fakeIAM) UpdateAccessKey(_ context.Context, params *iam.UpdateAccessKeyInput, _ ...func(*iam.Options)) (*iam.UpdateAccessKeyOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	for i, key := range f.keys {
+		if aws.ToString(key.AccessKeyId) == aws.ToString(params.AccessKeyId) {
+			f.keys[i].Status = params.Status
+			return &iam.UpdateAccessKeyOutput{}, nil
+		}
+	}
+	return nil, &types.NoSuchEntityException{Message: aws.String("no such key")}
+}
+
+func (f *fakeIAM) DeleteAccessKey(_ context.Context, params *iam.DeleteAccessKeyInput, _ ...func(*iam.Options)) (*iam.DeleteAccessKeyOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	for i, key := range f.keys {
+		if aws.ToString(key.AccessKeyId) == aws.ToString(params.AccessKeyId) {
+			f.keys = append(f.keys[:i], f.keys[i+1:]...)
+			return &iam.DeleteAccessKeyOutput{}, nil
+		}
+	}
+	return nil, &types.NoSuchEntityException{Message: aws.String("no such key")}
+}
+
+func Test_Create(t *testing.T) {
+	client := &fakeIAM{}
+	keyOps := New(client)
+
+	key, data, err := keyOps.Create(testAccountID, testUserName)
+	require.NoError(t, err)
+
+	assert.Equal(t, keyops.Key{Scope: testAccountID, Identifier: testUserName, ID: "AKIA1"}, key)
+	assert.JSONEq(t, `{"Version": 1, "AccessKeyId": "AKIA1", "SecretAccessKey": "secret-AKIA1"}`, string(data))
+	require.Len(t, client.keys, 1)
+	assert.Equal(t, testUserName, aws.ToString(client.keys[0].UserName))
+}
+
+func Test_CreateReportsExistingKeysWhenUserHasMaxKeys(t *testing.T) {
+	client := &fakeIAM{}
+	keyOps := New(client)
+
+	key1, _, err := keyOps.Create(testAccountID, testUserName)
+	require.NoError(t, err)
+	_, _, err = keyOps.Create(testAccountID, testUserName)
+	require.NoError(t, err)
+	require.NoError(t, keyOps.EnsureDisabled(key1))
+
+	_, _, err = keyOps.Create(testAccountID, testUserName)
+	assert.EqualError(t, err, "IAM user legacy-tooling in account 123456789012 already has the maximum of 2 access keys (AKIA1 (Inactive), AKIA2 (Active)); a new key can't be issued until one is deleted")
+
+	// once the old key is deleted, a new one can be issued
+	require.NoError(t, keyOps.DeleteIfDisabled(key1))
+	key3, _, err := keyOps.Create(testAccountID, testUserName)
+	require.NoError(t, err)
+	assert.Equal(t, "AKIA3", key3.ID)
+}
+
+func Test_CreateReturnsOtherErrors(t *testing.T) {
+	keyOps := New(&fakeIAM{err: fmt.Errorf("access denied")})
+
+	_, _, err := keyOps.Create(testAccountID, testUserName)
+	assert.ErrorContains(t, err, "error creating new access key for IAM user legacy-tooling in account 123456789012: access denied")
+}
+
+func Test_EnsureDisabledAndDeleteIfDisabled(t *testing.T) {
+	client := &fakeIAM{}
+	keyOps := New(client)
+
+	key, _, err := keyOps.Create(testAccountID, testUserName)
+	require.NoError(t, err)
+
+	disabled, err := keyOps.IsDisabled(key)
+	require.NoError(t, err)
+	assert.False(t, disabled)
+
+	err = keyOps.DeleteIfDisabled(key)
+	assert.ErrorContains(t, err, "access key AKIA1 for IAM user legacy-tooling in account 123456789012 is not inactive")
+	require.Len(t, client.keys, 1)
+
+	require.NoError(t, keyOps.EnsureDisabled(key))
+	disabled, err = keyOps.IsDisabled(key)
+	require.NoError(t, err)
+	assert.True(t, disabled)
+
+	// disabling again is a no-op
+	require.NoError(t, keyOps.EnsureDisabled(key))
+
+	require.NoError(t, keyOps.DeleteIfDisabled(key))
+	assert.Empty(t, client.keys)
+
+	_, err = keyOps.IsDisabled(key)
+	assert.ErrorContains(t, err, "IAM user legacy-tooling in account 123456789012 has no access key with id AKIA1")
+}
+
+func Test_ErrorsIfNotConfigured(t *testing.T) {
+	keyOps := New(nil)
+
+	_, _, err := keyOps.Create(testAccountID, testUserName)
+	assert.ErrorIs(t, err, errNoIAMClient)
+
+	err = keyOps.EnsureDisabled(keyops.Key{Scope: testAccountID, Identifier: testUserName, ID: "AKIA1"})
+	assert.ErrorIs(t, err, errNoIAMClient)
+}
@@ -13,24 +13,39 @@ import (
 
 type azKeyOps struct {
 	applicationsClient *msgraph.ApplicationsClient
+	options            keyops.Options
 }
 
-func New(applicationsClient *msgraph.ApplicationsClient) keyops.KeyOps {
-	return &azKeyOps{applicationsClient: applicationsClient}
+func New(applicationsClient *msgraph.ApplicationsClient, options ...keyops.Option) keyops.KeyOps {
+	return &azKeyOps{
+		applicationsClient: applicationsClient,
+		options:            keyops.NewOptions(options...),
+	}
 }
 
-func (a *azKeyOps) Create(tenantID string, applicationID string) (keyops.Key, []byte, error) {
+func (a *azKeyOps) Create(tenantID string, applicationID string, _ ...keyops.CreateOption) (keyops.Key, []byte, error) {
 	createKeyRequest := msgraph.PasswordCredential{
 		DisplayName: &applicationID,
 	}
 
-	// Set a 30 second timeout for the request
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	// Ensure that the context is canceled to prevent leaking resources
-	defer cancel()
-
 	logs.Info.Printf("creating new client secret for application with id %s...", applicationID)
-	createdKey, statusCode, err := a.applicationsClient.AddPassword(ctx, applicationID, createKeyRequest)
+	var createdKey *msgraph.PasswordCredential
+	var statusCode int
+	isRetryable := func(err error) bool {
+		// the client wraps network errors without preserving them, but a status code of 0 means no
+		// response was received
+		return statusCode == 0 || keyops.IsRetryableStatus(statusCode)
+	}
+	err := keyops.RetryCreate(a.options, fmt.Sprintf("client secret for application with id %s", applicationID), isRetryable, func() error {
+		// Set a 30 second timeout for each attempt
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		// Ensure that the context is canceled to prevent leaking resources
+		defer cancel()
+
+		var err error
+		createdKey, statusCode, err = a.applicationsClient.AddPassword(ctx, applicationID, createKeyRequest)
+		return err
+	})
 	if err != nil {
 		return keyops.Key{}, nil, fmt.Errorf(
 			"error %d issuing new client secret for application with id %s: %v",
@@ -2,6 +2,7 @@ package azurekeyops
 
 import (
 	"context"
+	"net/http"
 	"testing"
 	"time"
 
@@ -39,6 +40,48 @@ func Test_Create(t *testing.T) {
 	assert.Equal(t, testSecret, string(secret))
 }
 
+func Test_CreateRetriesRateLimitedRequests(t *testing.T) {
+	keyOps := setup(t, func(expect msgraphmock.Expect) {
+		expect.AddPassword(context.Background(), testApplicationID, msgraph.PasswordCredential{
+			DisplayName: &testApplicationID,
+		}).
+			Returns(&msgraph.PasswordCredential{
+				DisplayName: &testApplicationID,
+				SecretText:  &testSecret,
+				KeyId:       &testKeyID,
+			}).
+			FailFirst(2, http.StatusTooManyRequests).Times(3)
+	}, func(opts *keyops.Options) {
+		opts.CreateRetryBaseDelay = time.Millisecond
+	})
+
+	key, secret, err := keyOps.Create(testTenantID, testApplicationID)
+	require.NoError(t, err)
+
+	assert.Equal(t, testKeyID, key.ID)
+	assert.Equal(t, testSecret, string(secret))
+}
+
+func Test_CreateDoesNotRetryClientErrors(t *testing.T) {
+	keyOps := setup(t, func(expect msgraphmock.Expect) {
+		expect.AddPassword(context.Background(), testApplicationID, msgraph.PasswordCredential{
+			DisplayName: &testApplicationID,
+		}).
+			Returns(&msgraph.PasswordCredential{
+				DisplayName: &testApplicationID,
+				SecretText:  &testSecret,
+				KeyId:       &testKeyID,
+			}).
+			FailFirst(1, http.StatusBadRequest).Times(1)
+	}, func(opts *keyops.Options) {
+		opts.CreateRetryBaseDelay = time.Millisecond
+	})
+
+	_, _, err := keyOps.Create(testTenantID, testApplicationID)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "error 400")
+}
+
 func Test_CreateErrorsIfResponseLacksKeyID(t *testing.T) {
 	keyOps := setup(t, func(expect msgraphmock.Expect) {
 		expect.AddPassword(context.Background(), testApplicationID, msgraph.PasswordCredential{
@@ -135,7 +178,7 @@ func Test_deleteIfDisabled(t *testing.T) {
 	require.NoError(t, err)
 }
 
-func setup(t *testing.T, expectFn func(msgraphmock.Expect)) keyops.KeyOps {
+func setup(t *testing.T, expectFn func(msgraphmock.Expect), options ...keyops.Option) keyops.KeyOps {
 	mockMsGraph := msgraphmock.NewMockApplicationsClient(expectFn)
 	mockMsGraph.Setup()
 
@@ -143,5 +186,5 @@ func setup(t *testing.T, expectFn func(msgraphmock.Expect)) keyops.KeyOps {
 		mockMsGraph.AssertExpectations(t)
 		mockMsGraph.Cleanup()
 	})
-	return New(mockMsGraph.GetClient())
+	return New(mockMsGraph.GetClient(), options...)
 }
@@ -25,6 +25,8 @@ type Request interface {
 
 	Times(callCount int) Request
 
+	FailFirst(n int, status int) Request
+
 	getMethod() string
 
 	getUrl() string
@@ -42,6 +44,8 @@ type request struct {
 	status       int
 	responseBody interface{}
 	callCount    int
+	failFirst    int
+	failStatus   int
 }
 
 func (r *request) Error(error error) {
@@ -83,6 +87,30 @@ func (r *request) Times(callCount int) Request {
 	return r
 }
 
+// FailFirst makes the first n calls fail with the given status. Failed calls count towards the expected number of calls (see Times)
+func (r *request) FailFirst(n int, status int) Request {
+	r.failFirst = n
+	r.failStatus = status
+	return r
+}
+
+// withFailures wraps a responder so that it returns an error response for the first calls configured with FailFirst
+func (r *request) withFailures(responder httpmock.Responder) httpmock.Responder {
+	calls := 0
+	return func(req *http.Request) (*http.Response, error) {
+		calls++
+		if calls <= r.failFirst {
+			return httpmock.NewJsonResponse(r.failStatus, map[string]interface{}{
+				"error": map[string]interface{}{
+					"code":    http.StatusText(r.failStatus),
+					"message": fmt.Sprintf("mock failure %d of %d", calls, r.failFirst),
+				},
+			})
+		}
+		return responder(req)
+	}
+}
+
 // getMethod returns the method
 func (r *request) getMethod() string {
 	return r.method
@@ -102,9 +130,9 @@ func (r *request) getCallCount() int {
 func (r *request) buildResponder() httpmock.Responder {
 	switch r.method {
 	case http.MethodPost:
-		return buildPostResponder(r)
+		return r.withFailures(buildPostResponder(r))
 	default:
-		return buildGetResponder(r)
+		return r.withFailures(buildGetResponder(r))
 	}
 }
 
@@ -24,6 +24,10 @@ type Key struct {
 	Identifier string
 	// ID alphanumeric ID for the key
 	ID string
+	// UniqueID numeric unique ID of the Google service account the key is for, if known. When set, the key is
+	// addressed by it instead of the service account's email, so that a service account that was deleted and
+	// recreated with the same email is not mistaken for the original one. Ignored by non-GCP implementations.
+	UniqueID string
 }
 
 // KeyOps peforms operations on Google service account keys. It supports
@@ -31,7 +35,7 @@ type Key struct {
 type KeyOps interface {
 	// Create a new service account key for the given service account
 	// returns a Key instance that includes the new key's ID as well as the key's JSON private key data
-	Create(project string, serviceAccountEmail string) (Key, []byte, error)
+	Create(project string, serviceAccountEmail string, options ...CreateOption) (Key, []byte, error)
 	// IsDisabled return true if the given key is enabled, false otherwise
 	IsDisabled(key Key) (bool, error)
 	// EnsureDisabled check if the key is enabled and if so, disable it
@@ -40,26 +44,62 @@ type KeyOps interface {
 	DeleteIfDisabled(key Key) error
 }
 
-func New(iamService *iam.Service) KeyOps {
+// UniqueIDLookup is implemented by KeyOps whose service accounts have a numeric unique ID that survives
+// changes to, and reuse of, their email; ie. the GCP implementation
+type UniqueIDLookup interface {
+	// UniqueID returns the numeric unique ID of the given service account
+	UniqueID(project string, serviceAccountEmail string) (string, error)
+}
+
+// CreateOptions options for creating a new key. Only the GCP implementation supports them; others ignore them.
+type CreateOptions struct {
+	// KeyAlgorithm key algorithm for new Google SA keys, eg. KEY_ALG_RSA_1024. Defaults to KEY_ALG_RSA_2048.
+	KeyAlgorithm string
+	// KeyType private key type for new Google SA keys. Defaults to TYPE_GOOGLE_CREDENTIALS_FILE.
+	KeyType string
+	// UniqueID numeric unique ID of the Google service account, if known. When set, the key is created for the
+	// service account with this ID rather than whichever service account currently has the email.
+	UniqueID string
+}
+
+// CreateOption function for configuring CreateOptions
+type CreateOption func(*CreateOptions)
+
+func New(iamService *iam.Service, options ...Option) KeyOps {
 	return &keyops{
-		iam: iamService,
+		iam:     iamService,
+		options: NewOptions(options...),
 	}
 }
 
 type keyops struct {
-	iam *iam.Service
+	iam     *iam.Service
+	options Options
 }
 
-func (k *keyops) Create(project string, serviceAccountEmail string) (Key, []byte, error) {
-	name := qualifiedServiceAccountName(project, serviceAccountEmail)
+func (k *keyops) Create(project string, serviceAccountEmail string, options ...CreateOption) (Key, []byte, error) {
+	opts := CreateOptions{
+		KeyAlgorithm: keyAlgorithm,
+		KeyType:      keyFormat,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	name := Key{Scope: project, Identifier: serviceAccountEmail, UniqueID: opts.UniqueID}.qualifiedServiceAccountName()
 	ctx := context.Background()
 	request := &iam.CreateServiceAccountKeyRequest{
-		KeyAlgorithm:   keyAlgorithm,
-		PrivateKeyType: keyFormat,
+		KeyAlgorithm:   opts.KeyAlgorithm,
+		PrivateKeyType: opts.KeyType,
 	}
 
 	logs.Info.Printf("creating new service account for %s...", serviceAccountEmail)
-	newKey, err := k.iam.Projects.ServiceAccounts.Keys.Create(name, request).Context(ctx).Do()
+	var newKey *iam.ServiceAccountKey
+	err := RetryCreate(k.options, fmt.Sprintf("service account key for %s", serviceAccountEmail), isRetryableGoogleError, func() error {
+		var err error
+		newKey, err = k.iam.Projects.ServiceAccounts.Keys.Create(name, request).Context(ctx).Do()
+		return err
+	})
 	if err != nil {
 		return Key{}, nil, fmt.Errorf("error creating new service account key for %s: %v", name, err)
 	}
@@ -76,9 +116,19 @@ func (k *keyops) Create(project string, serviceAccountEmail string) (Key, []byte
 		Scope:      project,
 		Identifier: serviceAccountEmail,
 		ID:         keyID,
+		UniqueID:   opts.UniqueID,
 	}, jsonData, nil
 }
 
+func (k *keyops) UniqueID(project string, serviceAccountEmail string) (string, error) {
+	name := qualifiedServiceAccountName(project, serviceAccountEmail)
+	account, err := k.iam.Projects.ServiceAccounts.Get(name).Context(context.Background()).Do()
+	if err != nil {
+		return "", fmt.Errorf("api request for %s failed: %v", name, err)
+	}
+	return account.UniqueId, nil
+}
+
 func (k *keyops) IsDisabled(key Key) (bool, error) {
 	resp, err := k.iam.Projects.ServiceAccounts.Keys.Get(key.qualifiedKeyName()).Context(context.Background()).Do()
 	if err != nil {
@@ -123,9 +173,19 @@ func (k *keyops) DeleteIfDisabled(key Key) error {
 }
 
 // return qualified key name for use in IAM api calls.
-// eg. "projects/my-project/serviceAccounts/my-service-account@my-project/keys/123"
+// eg. "projects/my-project/serviceAccounts/my-service-account@my-project/keys/123",
+// or "projects/-/serviceAccounts/1234567890/keys/123" if the service account's unique ID is known
 func (k Key) qualifiedKeyName() string {
-	return qualifiedKeyName(k.Scope, k.Identifier, k.ID)
+	return fmt.Sprintf("%s/keys/%s", k.qualifiedServiceAccountName(), k.ID)
+}
+
+// return qualified name of the key's service account for use in IAM api calls, addressing it by
+// unique ID if known. The IAM API infers the project from a unique ID, so "-" is used in its place.
+func (k Key) qualifiedServiceAccountName() string {
+	if k.UniqueID != "" {
+		return qualifiedServiceAccountName("-", k.UniqueID)
+	}
+	return qualifiedServiceAccountName(k.Scope, k.Identifier)
 }
 
 // return qualified key name for use in IAM api calls.
@@ -2,7 +2,9 @@ package keyops
 
 import (
 	"encoding/base64"
+	"net/http"
 	"testing"
+	"time"
 
 	mockiam "github.com/broadinstitute/yale/internal/yale/keyops/testutils/iam"
 	"github.com/stretchr/testify/assert"
@@ -13,6 +15,7 @@ import (
 const testProject = "my-project"
 const testServiceAccount = "my-sa@my-project.iam.gserviceaccount.com"
 const testKeyId = "my-key-id"
+const testUniqueId = "1234567890"
 
 func Test_KeyCreate(t *testing.T) {
 	ko := setup(t, func(expect mockiam.Expect) {
@@ -39,6 +42,96 @@ func Test_KeyCreate(t *testing.T) {
 	assert.Equal(t, `{"foo":"bar"}`, string(data))
 }
 
+func Test_KeyCreateWithNonDefaultAlgorithm(t *testing.T) {
+	ko := setup(t, func(expect mockiam.Expect) {
+		expect.CreateServiceAccountKey(testProject, testServiceAccount).
+			With(
+				iam.CreateServiceAccountKeyRequest{
+					KeyAlgorithm:   "KEY_ALG_RSA_1024",
+					PrivateKeyType: keyFormat,
+				},
+			).Returns(
+			iam.ServiceAccountKey{
+				Name:           qualifiedKeyName(testProject, testServiceAccount, testKeyId),
+				PrivateKeyData: base64.StdEncoding.EncodeToString([]byte(`{"foo":"bar"}`)),
+			},
+		)
+	})
+
+	key, data, err := ko.Create(testProject, testServiceAccount, func(opts *CreateOptions) {
+		opts.KeyAlgorithm = "KEY_ALG_RSA_1024"
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, testKeyId, key.ID)
+	assert.Equal(t, `{"foo":"bar"}`, string(data))
+}
+
+func Test_KeyCreateRetriesRateLimitedRequests(t *testing.T) {
+	ko := setup(t, func(expect mockiam.Expect) {
+		expect.CreateServiceAccountKey(testProject, testServiceAccount).
+			With(
+				iam.CreateServiceAccountKeyRequest{
+					KeyAlgorithm:   keyAlgorithm,
+					PrivateKeyType: keyFormat,
+				},
+			).Returns(
+			iam.ServiceAccountKey{
+				Name:           qualifiedKeyName(testProject, testServiceAccount, testKeyId),
+				PrivateKeyData: base64.StdEncoding.EncodeToString([]byte(`{"foo":"bar"}`)),
+			},
+		).FailFirst(2, http.StatusTooManyRequests).Times(3)
+	}, fastRetries)
+
+	key, data, err := ko.Create(testProject, testServiceAccount)
+	require.NoError(t, err)
+
+	assert.Equal(t, testKeyId, key.ID)
+	assert.Equal(t, `{"foo":"bar"}`, string(data))
+}
+
+func Test_KeyCreateGivesUpAfterMaxAttempts(t *testing.T) {
+	ko := setup(t, func(expect mockiam.Expect) {
+		expect.CreateServiceAccountKey(testProject, testServiceAccount).
+			With(
+				iam.CreateServiceAccountKeyRequest{
+					KeyAlgorithm:   keyAlgorithm,
+					PrivateKeyType: keyFormat,
+				},
+			).Returns(
+			iam.ServiceAccountKey{
+				Name:           qualifiedKeyName(testProject, testServiceAccount, testKeyId),
+				PrivateKeyData: base64.StdEncoding.EncodeToString([]byte(`{"foo":"bar"}`)),
+			},
+		).FailFirst(3, http.StatusServiceUnavailable).Times(3)
+	}, fastRetries)
+
+	_, _, err := ko.Create(testProject, testServiceAccount)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "503")
+}
+
+func Test_KeyCreateDoesNotRetryClientErrors(t *testing.T) {
+	ko := setup(t, func(expect mockiam.Expect) {
+		expect.CreateServiceAccountKey(testProject, testServiceAccount).
+			With(
+				iam.CreateServiceAccountKeyRequest{
+					KeyAlgorithm:   keyAlgorithm,
+					PrivateKeyType: keyFormat,
+				},
+			).Returns(
+			iam.ServiceAccountKey{
+				Name:           qualifiedKeyName(testProject, testServiceAccount, testKeyId),
+				PrivateKeyData: base64.StdEncoding.EncodeToString([]byte(`{"foo":"bar"}`)),
+			},
+		).FailFirst(1, http.StatusForbidden).Times(1)
+	}, fastRetries)
+
+	_, _, err := ko.Create(testProject, testServiceAccount)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "403")
+}
+
 func Test_EnsureDisabledDisablesKeyIfEnabled(t *testing.T) {
 	ko := setup(t, func(expect mockiam.Expect) {
 		expect.GetServiceAccountKey(testProject, testServiceAccount, testKeyId).Returns(iam.ServiceAccountKey{
@@ -105,7 +198,71 @@ func Test_DeleteReturnsErrIfKeyNotDisabled(t *testing.T) {
 	assert.ErrorContains(t, err, "is not disabled")
 }
 
-func setup(t *testing.T, expectFn func(mockiam.Expect)) KeyOps {
+func Test_KeyCreateByUniqueID(t *testing.T) {
+	ko := setup(t, func(expect mockiam.Expect) {
+		expect.CreateServiceAccountKey("-", testUniqueId).
+			With(
+				iam.CreateServiceAccountKeyRequest{
+					KeyAlgorithm:   keyAlgorithm,
+					PrivateKeyType: keyFormat,
+				},
+			).Returns(
+			iam.ServiceAccountKey{
+				Name:           qualifiedKeyName(testProject, testServiceAccount, testKeyId),
+				PrivateKeyData: base64.StdEncoding.EncodeToString([]byte(`{"foo":"bar"}`)),
+			},
+		)
+	})
+
+	key, _, err := ko.Create(testProject, testServiceAccount, func(opts *CreateOptions) {
+		opts.UniqueID = testUniqueId
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, testServiceAccount, key.Identifier)
+	assert.Equal(t, testKeyId, key.ID)
+	assert.Equal(t, testUniqueId, key.UniqueID)
+}
+
+func Test_EnsureDisabledByUniqueID(t *testing.T) {
+	ko := setup(t, func(expect mockiam.Expect) {
+		expect.GetServiceAccountKey("-", testUniqueId, testKeyId).Returns(iam.ServiceAccountKey{
+			Name:     qualifiedKeyName(testProject, testServiceAccount, testKeyId),
+			Disabled: false,
+		})
+		expect.DisableServiceAccountKey("-", testUniqueId, testKeyId).
+			With(iam.DisableServiceAccountKeyRequest{}).Returns()
+	})
+
+	err := ko.EnsureDisabled(Key{
+		Scope:      testProject,
+		Identifier: testServiceAccount,
+		ID:         testKeyId,
+		UniqueID:   testUniqueId,
+	})
+
+	assert.NoError(t, err)
+}
+
+func Test_UniqueID(t *testing.T) {
+	ko := setup(t, func(expect mockiam.Expect) {
+		expect.GetServiceAccount(testProject, testServiceAccount).Returns(iam.ServiceAccount{
+			Email:    testServiceAccount,
+			UniqueId: testUniqueId,
+		})
+	})
+
+	uniqueID, err := ko.(UniqueIDLookup).UniqueID(testProject, testServiceAccount)
+	require.NoError(t, err)
+	assert.Equal(t, testUniqueId, uniqueID)
+}
+
+// fastRetries shortens the retry delay so tests exercising retries run quickly
+func fastRetries(opts *Options) {
+	opts.CreateRetryBaseDelay = time.Millisecond
+}
+
+func setup(t *testing.T, expectFn func(mockiam.Expect), options ...Option) KeyOps {
 	mockIam := mockiam.NewMockIAMService(expectFn)
 
 	mockIam.Setup()
@@ -114,5 +271,5 @@ func setup(t *testing.T, expectFn func(mockiam.Expect)) KeyOps {
 		mockIam.Cleanup()
 	})
 
-	return New(mockIam.GetClient())
+	return New(mockIam.GetClient(), options...)
 }
@@ -20,32 +20,39 @@ func (_m *KeyOps) EXPECT() *KeyOps_Expecter {
 	return &KeyOps_Expecter{mock: &_m.Mock}
 }
 
-// Create provides a mock function with given fields: project, serviceAccountEmail
-func (_m *KeyOps) Create(project string, serviceAccountEmail string) (keyops.Key, []byte, error) {
-	ret := _m.Called(project, serviceAccountEmail)
+// Create provides a mock function with given fields: project, serviceAccountEmail, options
+func (_m *KeyOps) Create(project string, serviceAccountEmail string, options ...keyops.CreateOption) (keyops.Key, []byte, error) {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, project, serviceAccountEmail)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
 
 	var r0 keyops.Key
 	var r1 []byte
 	var r2 error
-	if rf, ok := ret.Get(0).(func(string, string) (keyops.Key, []byte, error)); ok {
-		return rf(project, serviceAccountEmail)
+	if rf, ok := ret.Get(0).(func(string, string, ...keyops.CreateOption) (keyops.Key, []byte, error)); ok {
+		return rf(project, serviceAccountEmail, options...)
 	}
-	if rf, ok := ret.Get(0).(func(string, string) keyops.Key); ok {
-		r0 = rf(project, serviceAccountEmail)
+	if rf, ok := ret.Get(0).(func(string, string, ...keyops.CreateOption) keyops.Key); ok {
+		r0 = rf(project, serviceAccountEmail, options...)
 	} else {
 		r0 = ret.Get(0).(keyops.Key)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string) []byte); ok {
-		r1 = rf(project, serviceAccountEmail)
+	if rf, ok := ret.Get(1).(func(string, string, ...keyops.CreateOption) []byte); ok {
+		r1 = rf(project, serviceAccountEmail, options...)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).([]byte)
 		}
 	}
 
-	if rf, ok := ret.Get(2).(func(string, string) error); ok {
-		r2 = rf(project, serviceAccountEmail)
+	if rf, ok := ret.Get(2).(func(string, string, ...keyops.CreateOption) error); ok {
+		r2 = rf(project, serviceAccountEmail, options...)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -61,13 +68,21 @@ type KeyOps_Create_Call struct {
 // Create is a helper method to define mock.On call
 //   - project string
 //   - serviceAccountEmail string
-func (_e *KeyOps_Expecter) Create(project interface{}, serviceAccountEmail interface{}) *KeyOps_Create_Call {
-	return &KeyOps_Create_Call{Call: _e.mock.On("Create", project, serviceAccountEmail)}
+//   - options ...keyops.CreateOption
+func (_e *KeyOps_Expecter) Create(project interface{}, serviceAccountEmail interface{}, options ...interface{}) *KeyOps_Create_Call {
+	return &KeyOps_Create_Call{Call: _e.mock.On("Create",
+		append([]interface{}{project, serviceAccountEmail}, options...)...)}
 }
 
-func (_c *KeyOps_Create_Call) Run(run func(project string, serviceAccountEmail string)) *KeyOps_Create_Call {
+func (_c *KeyOps_Create_Call) Run(run func(project string, serviceAccountEmail string, options ...keyops.CreateOption)) *KeyOps_Create_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(string))
+		variadicArgs := make([]keyops.CreateOption, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(keyops.CreateOption)
+			}
+		}
+		run(args[0].(string), args[1].(string), variadicArgs...)
 	})
 	return _c
 }
@@ -77,7 +92,7 @@ func (_c *KeyOps_Create_Call) Return(_a0 keyops.Key, _a1 []byte, _a2 error) *Key
 	return _c
 }
 
-func (_c *KeyOps_Create_Call) RunAndReturn(run func(string, string) (keyops.Key, []byte, error)) *KeyOps_Create_Call {
+func (_c *KeyOps_Create_Call) RunAndReturn(run func(string, string, ...keyops.CreateOption) (keyops.Key, []byte, error)) *KeyOps_Create_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -0,0 +1,87 @@
+package keyops
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/broadinstitute/yale/internal/yale/logs"
+	"google.golang.org/api/googleapi"
+)
+
+// DefaultCreateMaxAttempts default number of times Yale will attempt to create a new key before giving up
+const DefaultCreateMaxAttempts = 3
+
+// DefaultCreateRetryBaseDelay default delay before the first retry of a failed key creation; the delay
+// doubles with every subsequent retry
+const DefaultCreateRetryBaseDelay = time.Second
+
+// Options configuration options for KeyOps implementations
+type Options struct {
+	// CreateMaxAttempts maximum number of times a key creation that fails with a 429, 5xx, or network error
+	// will be attempted
+	CreateMaxAttempts int
+	// CreateRetryBaseDelay delay before the first retry of a failed key creation, doubled for each subsequent retry
+	CreateRetryBaseDelay time.Duration
+}
+
+// Option function for configuring Options
+type Option func(*Options)
+
+// NewOptions returns Options with defaults, modified by the given options
+func NewOptions(options ...Option) Options {
+	opts := Options{
+		CreateMaxAttempts:    DefaultCreateMaxAttempts,
+		CreateRetryBaseDelay: DefaultCreateRetryBaseDelay,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+	return opts
+}
+
+// RetryCreate calls create, retrying with exponential backoff as long as it fails with an error isRetryable
+// accepts, until the configured maximum number of attempts is reached
+func RetryCreate(opts Options, description string, isRetryable func(error) bool, create func() error) error {
+	delay := opts.CreateRetryBaseDelay
+	for attempt := 1; ; attempt++ {
+		err := create()
+		if err == nil {
+			return nil
+		}
+		if attempt >= opts.CreateMaxAttempts || !isRetryable(err) {
+			return err
+		}
+		logs.Warn.Printf("attempt %d of %d to create %s failed, retrying in %s: %v", attempt, opts.CreateMaxAttempts, description, delay, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// IsRetryableStatus returns true if the HTTP status code indicates a rate limit or server error
+func IsRetryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// isTransientNetworkError returns true if the error was caused by a network failure, such as a timeout or a
+// refused connection, rather than an error response
+func isTransientNetworkError(err error) bool {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// isRetryableGoogleError returns true if the error is a 429 or 5xx response from the Google API, or a
+// transient network error
+func isRetryableGoogleError(err error) bool {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return IsRetryableStatus(apiErr.Code)
+	}
+	return isTransientNetworkError(err)
+}
@@ -17,6 +17,8 @@ type Expect interface {
 	DisableServiceAccountKey(project string, serviceAccountEmail string, keyId string) DisableServiceAccountKeyRequest
 	// DeleteServiceAccountKey configures the mock to expect a request that deletes a service account key
 	DeleteServiceAccountKey(project string, serviceAccountEmail string, keyId string) DeleteServiceAccountKeyRequest
+	// GetServiceAccount configures the mock to expect a request to get a service account
+	GetServiceAccount(project string, serviceAccountEmail string) GetServiceAccountRequest
 }
 
 func newExpect() *expect {
@@ -64,6 +66,15 @@ func (e *expect) DeleteServiceAccountKey(project string, serviceAccountEmail str
 	return r
 }
 
+// GetServiceAccount
+// see https://cloud.google.com/iam/docs/reference/rest/v1/projects.serviceAccounts/get
+func (e *expect) GetServiceAccount(project string, serviceAccountEmail string) GetServiceAccountRequest {
+	url := fmt.Sprintf("%s/projects/%s/serviceAccounts/%s", gcpIamURL, project, serviceAccountEmail)
+	r := newGetServiceAccountRequest(methodGet, url)
+	e.addNewRequest(r)
+	return r
+}
+
 func (e *expect) addNewRequest(r Request) {
 	e.requests = append(e.requests, r)
 }
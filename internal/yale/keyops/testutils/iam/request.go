@@ -31,6 +31,8 @@ type Request interface {
 	ResponseBody(responseBody interface{}) Request
 	// Times sets the number of times this request should be expected
 	Times(callCount int) Request
+	// FailFirst makes the first n calls fail with the given status. Failed calls count towards the expected number of calls (see Times)
+	FailFirst(n int, status int) Request
 	// getMethod returns the method
 	getMethod() string
 	// getUrl returns the url
@@ -50,6 +52,8 @@ type request struct {
 	status       int
 	responseBody interface{}
 	callCount    int
+	failFirst    int
+	failStatus   int
 }
 
 func (r *request) Error(error *googleapi.Error) {
@@ -92,13 +96,36 @@ func (r *request) Times(callCount int) Request {
 	return r
 }
 
+// FailFirst makes the first n calls fail with the given status. Failed calls count towards the expected number of calls (see Times)
+func (r *request) FailFirst(n int, status int) Request {
+	r.failFirst = n
+	r.failStatus = status
+	return r
+}
+
+// withFailures wraps a responder so that it returns an error response for the first calls configured with FailFirst
+func (r *request) withFailures(responder httpmock.Responder) httpmock.Responder {
+	calls := 0
+	return func(req *http.Request) (*http.Response, error) {
+		calls++
+		if calls <= r.failFirst {
+			return httpmock.NewJsonResponse(r.failStatus, map[string]interface{}{
+				"error": map[string]interface{}{
+					"code":    r.failStatus,
+					"message": fmt.Sprintf("mock failure %d of %d", calls, r.failFirst),
+				},
+			})
+		}
+		return responder(req)
+	}
+}
+
 func (r *request) buildResponder() httpmock.Responder {
 	switch r.method {
 	case methodPost:
-		return buildPostResponder(r)
-
+		return r.withFailures(buildPostResponder(r))
 	default:
-		return buildResponder(r)
+		return r.withFailures(buildResponder(r))
 	}
 }
 
@@ -103,3 +103,24 @@ func (r *deleteServiceAccountKeyRequest) Returns() DeleteServiceAccountKeyReques
 	}{})
 	return r
 }
+
+// get service account
+type GetServiceAccountRequest interface {
+	Returns(account iam.ServiceAccount) GetServiceAccountRequest
+	Request
+}
+
+type getServiceAccountRequest struct {
+	request
+}
+
+func newGetServiceAccountRequest(method string, url string) GetServiceAccountRequest {
+	return &getServiceAccountRequest{
+		request: *newRequest(method, url),
+	}
+}
+
+func (r *getServiceAccountRequest) Returns(account iam.ServiceAccount) GetServiceAccountRequest {
+	r.ResponseBody(account)
+	return r
+}
@@ -0,0 +1,39 @@
+package keysync
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	awstypes "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+)
+
+// awsSecretsManagerClient returns a Secrets Manager client for the given region
+func (k *keysync) awsSecretsManagerClient(region string) *secretsmanager.Client {
+	return secretsmanager.NewFromConfig(*k.options.AWSConfig, func(o *secretsmanager.Options) {
+		if region != "" {
+			o.Region = region
+		}
+	})
+}
+
+// awsSecretExists returns true if a secret with the given name exists in Secrets Manager
+func awsSecretExists(client *secretsmanager.Client, name string) (bool, error) {
+	_, err := client.DescribeSecret(context.Background(), &secretsmanager.DescribeSecretInput{
+		SecretId: aws.String(name),
+	})
+	if err == nil {
+		return true, nil
+	}
+	if isAWSResourceNotFound(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+// isAWSResourceNotFound returns true if the error was returned because a secret or secret version does not exist
+func isAWSResourceNotFound(err error) bool {
+	var notFound *awstypes.ResourceNotFoundException
+	return errors.As(err, &notFound)
+}
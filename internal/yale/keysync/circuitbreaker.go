@@ -0,0 +1,82 @@
+package keysync
+
+import (
+	"errors"
+
+	"github.com/broadinstitute/yale/internal/yale/logs"
+)
+
+// DefaultMaxDestinationFailures default number of sync errors against a single external destination in a run,
+// after which Yale stops writing to that destination for the rest of the run
+const DefaultMaxDestinationFailures = 10
+
+// ErrDestinationUnhealthy is wrapped by the errors returned for replications that were skipped because their
+// destination failed too many times earlier in the run
+var ErrDestinationUnhealthy = errors.New("destination appears unhealthy after repeated errors this run, skipping")
+
+func (k *keysync) StartRun() {
+	k.healthMutex.Lock()
+	defer k.healthMutex.Unlock()
+	k.destinationFailures = make(map[string]int)
+}
+
+func (k *keysync) UnhealthyDestinations() map[string]int {
+	k.healthMutex.Lock()
+	defer k.healthMutex.Unlock()
+	result := make(map[string]int)
+	for destination, failures := range k.destinationFailures {
+		if k.tripped(failures) {
+			result[destination] = failures
+		}
+	}
+	return result
+}
+
+// destinationUnhealthy returns true if Yale should stop writing to the destination for the rest of the run
+func (k *keysync) destinationUnhealthy(destination string) bool {
+	k.healthMutex.Lock()
+	defer k.healthMutex.Unlock()
+	return k.tripped(k.destinationFailures[destination])
+}
+
+// recordDestinationFailure counts a sync error against the destination, logging when the destination is
+// first considered unhealthy
+func (k *keysync) recordDestinationFailure(destination string) {
+	k.healthMutex.Lock()
+	defer k.healthMutex.Unlock()
+	if k.destinationFailures == nil {
+		k.destinationFailures = make(map[string]int)
+	}
+	k.destinationFailures[destination]++
+	if failures := k.destinationFailures[destination]; failures == k.options.MaxDestinationFailures {
+		logs.Error.Printf("%d errors syncing to %s this run, it appears to be unhealthy; not syncing to it for the rest of the run", failures, destination)
+	}
+}
+
+func (k *keysync) tripped(failures int) bool {
+	return k.options.MaxDestinationFailures > 0 && failures >= k.options.MaxDestinationFailures
+}
+
+// IsDestinationUnhealthyError returns true if err consists only of errors for replications that were skipped
+// because their destination was unhealthy, so that callers can avoid reporting every skipped replication
+// individually
+func IsDestinationUnhealthyError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if err == ErrDestinationUnhealthy {
+		return true
+	}
+	switch e := err.(type) {
+	case interface{ Unwrap() []error }:
+		for _, inner := range e.Unwrap() {
+			if !IsDestinationUnhealthyError(inner) {
+				return false
+			}
+		}
+		return len(e.Unwrap()) > 0
+	case interface{ Unwrap() error }:
+		return IsDestinationUnhealthyError(e.Unwrap())
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package keysync
+
+import (
+	"errors"
+	"sync"
+)
+
+// DefaultMaxConcurrentReplications default limit on the number of replications to a single backend
+// that Yale will perform at once for a syncable
+const DefaultMaxConcurrentReplications = 4
+
+// forEachConcurrently calls fn for every item, running at most limit calls at a time. Every item is
+// processed even if some calls fail; the errors of all failed calls are joined together.
+func forEachConcurrently[T any](limit int, items []T, fn func(T) error) error {
+	if limit < 1 {
+		limit = 1
+	}
+
+	errs := make([]error, len(items))
+	semaphore := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+
+	for i, item := range items {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int, item T) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			errs[i] = fn(item)
+		}(i, item)
+	}
+
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// joinErrors wraps errors.Join for files that import the K8s api errors package as errors
+func joinErrors(errs ...error) error {
+	return errors.Join(errs...)
+}
@@ -0,0 +1,61 @@
+package keysync
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/broadinstitute/yale/internal/yale/cache"
+	"github.com/broadinstitute/yale/internal/yale/logs"
+)
+
+// replicateKeyToConsul writes the formatted key to every Consul KV path in the syncable's spec. Paths that
+// already contain the formatted key are left untouched, so that watches on the path don't fire needlessly.
+func (k *keysync) replicateKeyToConsul(entry *cache.Entry, syncable Syncable) error {
+	if k.options.DisableConsulReplication {
+		return nil
+	}
+	if len(syncable.ConsulReplications()) == 0 {
+		// no replications to perform
+		return nil
+	}
+	if k.options.Consul == nil {
+		return errNoConsulClient
+	}
+
+	for _, spec := range syncable.ConsulReplications() {
+		msg := fmt.Sprintf("replicating key %s for %s (format %s) to Consul KV path %s%s",
+			entry.CurrentKey.ID, entry.Identify(), spec.Format, spec.Path, consulAddressSuffix(spec.Address))
+		logs.Info.Print(msg)
+
+		formatted, err := formatSecretForGitHubOrGSM(entry, spec.Format, "")
+		if err != nil {
+			return fmt.Errorf("error %s: formatting failed: %v", msg, err)
+		}
+
+		current, err := k.options.Consul.Get(spec.Address, spec.Path)
+		if err != nil {
+			return fmt.Errorf("error retrieving Consul KV path %s%s: %v", spec.Path, consulAddressSuffix(spec.Address), err)
+		}
+		if current != nil && bytes.Equal(current, formatted) {
+			logs.Debug.Printf("Consul KV path %s%s already contains the desired data, won't write it again", spec.Path, consulAddressSuffix(spec.Address))
+			continue
+		}
+
+		if err = k.options.Consul.Put(spec.Address, spec.Path, formatted); err != nil {
+			return fmt.Errorf("error writing Consul KV path %s%s: %v", spec.Path, consulAddressSuffix(spec.Address), err)
+		}
+	}
+
+	logs.Info.Printf("replicated key %s for %s to %d Consul KV paths", entry.CurrentKey.ID, entry.Identify(), len(syncable.ConsulReplications()))
+
+	return nil
+}
+
+// consulAddressSuffix returns " at <address>" for replications to a specific Consul address, or an empty
+// string for those that use the default address
+func consulAddressSuffix(address string) string {
+	if address == "" {
+		return ""
+	}
+	return " at " + address
+}
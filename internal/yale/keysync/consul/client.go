@@ -0,0 +1,119 @@
+package consul
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/broadinstitute/yale/internal/yale/logs"
+)
+
+// DefaultAddress address of the local Consul agent, used when neither the replication nor the environment
+// specifies one
+const DefaultAddress = "http://127.0.0.1:8500"
+
+// NewClient returns a Client for the Consul KV HTTP API. defaultAddress (eg. http://consul.example.com:8500) is
+// used for replications that don't specify their own address; if token is non-empty, it is sent with every request
+func NewClient(defaultAddress string, token string, httpClient *http.Client) Client {
+	return &client{
+		defaultAddress: normalizeAddress(defaultAddress),
+		token:          token,
+		httpClient:     httpClient,
+	}
+}
+
+type Client interface {
+	// Get returns the value stored at the given KV path, or nil if the path does not exist. If address is empty,
+	// the client's default address is used
+	Get(address string, path string) ([]byte, error)
+	// Put writes the value to the given KV path, replacing any existing value. If address is empty, the
+	// client's default address is used
+	Put(address string, path string, value []byte) error
+}
+
+type client struct {
+	defaultAddress string
+	token          string
+	httpClient     *http.Client
+}
+
+func (c *client) Get(address string, path string) ([]byte, error) {
+	req, err := c.newRequest(http.MethodGet, c.kvURL(address, path)+"?raw", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error reading Consul KV path %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		value, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("error reading Consul KV path %s: %v", path, err)
+		}
+		return value, nil
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, unexpectedResponse(req, resp)
+	}
+}
+
+func (c *client) Put(address string, path string, value []byte) error {
+	req, err := c.newRequest(http.MethodPut, c.kvURL(address, path), bytes.NewReader(value))
+	if err != nil {
+		return err
+	}
+	logs.Info.Printf("Writing Consul KV path %s at %s", path, c.address(address))
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error writing Consul KV path %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return unexpectedResponse(req, resp)
+	}
+	return nil
+}
+
+func (c *client) newRequest(method string, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing Consul API request: %v", err)
+	}
+	if c.token != "" {
+		req.Header.Set("X-Consul-Token", c.token)
+	}
+	return req, nil
+}
+
+func (c *client) address(address string) string {
+	if address == "" {
+		return c.defaultAddress
+	}
+	return normalizeAddress(address)
+}
+
+func (c *client) kvURL(address string, path string) string {
+	return fmt.Sprintf("%s/v1/kv/%s", c.address(address), strings.TrimPrefix(path, "/"))
+}
+
+// normalizeAddress adds a scheme to addresses that don't have one (eg. "consul.example.com:8500"), since
+// CONSUL_HTTP_ADDR is commonly set without one, and removes any trailing slash
+func normalizeAddress(address string) string {
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+	return strings.TrimSuffix(address, "/")
+}
+
+func unexpectedResponse(req *http.Request, resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+	return fmt.Errorf("unexpected response from Consul: %s %s returned %d: %s", req.Method, req.URL.Path, resp.StatusCode, string(body))
+}
@@ -0,0 +1,88 @@
+package consul
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConsul returns a test server backed by an in-memory KV store
+func fakeConsul(t *testing.T, kv map[string]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "my-token", r.Header.Get("X-Consul-Token"))
+		require.True(t, strings.HasPrefix(r.URL.Path, "/v1/kv/"))
+		key := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+
+		switch r.Method {
+		case http.MethodGet:
+			assert.True(t, r.URL.Query().Has("raw"))
+			value, exists := kv[key]
+			if !exists {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(value))
+		case http.MethodPut:
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			kv[key] = string(body)
+			_, _ = w.Write([]byte("true"))
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func Test_Client_GetReturnsNilForMissingPath(t *testing.T) {
+	server := fakeConsul(t, map[string]string{})
+	_client := NewClient(server.URL, "my-token", server.Client())
+
+	value, err := _client.Get("", "my/missing/key")
+	require.NoError(t, err)
+	assert.Nil(t, value)
+}
+
+func Test_Client_PutsAndGetsValues(t *testing.T) {
+	kv := map[string]string{}
+	server := fakeConsul(t, kv)
+	_client := NewClient(server.URL, "my-token", server.Client())
+
+	require.NoError(t, _client.Put("", "/my/key", []byte("some data")))
+	assert.Equal(t, map[string]string{"my/key": "some data"}, kv)
+
+	value, err := _client.Get("", "my/key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("some data"), value)
+}
+
+func Test_Client_UsesReplicationAddressOverDefault(t *testing.T) {
+	kv := map[string]string{}
+	server := fakeConsul(t, kv)
+	_client := NewClient("http://127.0.0.1:1", "my-token", server.Client())
+
+	// the address may be given without a scheme, as it commonly is in CONSUL_HTTP_ADDR
+	require.NoError(t, _client.Put(strings.TrimPrefix(server.URL, "http://"), "my/key", []byte("some data")))
+	assert.Equal(t, map[string]string{"my/key": "some data"}, kv)
+}
+
+func Test_Client_ReturnsErrorForUnexpectedResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte("Permission denied"))
+	}))
+	t.Cleanup(server.Close)
+	_client := NewClient(server.URL, "", server.Client())
+
+	err := _client.Put("", "my/key", []byte("some data"))
+	assert.ErrorContains(t, err, "PUT /v1/kv/my/key returned 403: Permission denied")
+
+	_, err = _client.Get("", "my/key")
+	assert.ErrorContains(t, err, "GET /v1/kv/my/key returned 403: Permission denied")
+}
@@ -0,0 +1,3 @@
+package mocks
+
+//go:generate mockery --with-expecter --dir=.. --name=Client --output=. --outpkg=mocks --filename=client.go
@@ -0,0 +1,85 @@
+package keysync
+
+import (
+	"fmt"
+	"github.com/broadinstitute/yale/internal/yale/cache"
+	"github.com/broadinstitute/yale/internal/yale/logs"
+)
+
+// logIntendedWrites logs every write that syncing the key to the syncable's destinations would perform,
+// without performing any of them
+func (k *keysync) logIntendedWrites(entry *cache.Entry, syncable Syncable) {
+	prefix := fmt.Sprintf("dry run: %s %s in %s: would write key %s to", entry.Type, syncable.Name(), syncable.Namespace(), entry.CurrentKey.ID)
+
+	for _, spec := range AllSecrets(syncable) {
+		logs.Info.Printf("%s K8s secret %s", prefix, secretKeyForGsk(syncable, spec))
+		for _, cluster := range spec.TargetClusters {
+			logs.Info.Printf("%s K8s secret %s%s", prefix, secretKeyForGsk(syncable, spec), inCluster(cluster))
+		}
+	}
+
+	if !k.options.DisableVaultReplication {
+		for _, spec := range syncable.VaultReplications() {
+			if spec.Address != "" {
+				logs.Info.Printf("%s Vault path %s on %s (format %s, key %s)", prefix, spec.Path, spec.Address, spec.Format, spec.Key)
+				continue
+			}
+			logs.Info.Printf("%s Vault path %s (format %s, key %s)", prefix, spec.Path, spec.Format, spec.Key)
+		}
+	}
+
+	for _, spec := range syncable.GoogleSecretManagerReplications() {
+		secretName, err := gsmSecretName(entry, syncable, spec)
+		if err != nil {
+			logs.Warn.Printf("dry run: %s %s in %s: %v", entry.Type, syncable.Name(), syncable.Namespace(), err)
+			continue
+		}
+		logs.Info.Printf("%s GSM secret %s in project %s (format %s)", prefix, secretName, spec.Project, spec.Format)
+	}
+
+	if !k.options.DisableGitHubReplication {
+		for _, r := range syncable.GitHubReplications() {
+			switch {
+			case r.Repo == "":
+				logs.Info.Printf("%s GitHub org secret %s in org %s (format %s, visibility %s)", prefix, r.Secret, r.Org, r.Format, r.Visibility)
+			case r.Environment != "":
+				logs.Info.Printf("%s GitHub secret %s in environment %s of repo %s (format %s)", prefix, r.Secret, r.Environment, r.Repo, r.Format)
+			default:
+				logs.Info.Printf("%s GitHub secret %s in repo %s (format %s)", prefix, r.Secret, r.Repo, r.Format)
+			}
+		}
+	}
+
+	if !k.options.DisableGitLabReplication {
+		for _, r := range syncable.GitLabReplications() {
+			logs.Info.Printf("%s GitLab CI/CD variable %s in project %s (format %s)", prefix, r.VariableKey, r.Project, r.Format)
+		}
+	}
+
+	for _, spec := range syncable.AWSSecretsManagerReplications() {
+		logs.Info.Printf("%s AWS secret %s in region %s (format %s)", prefix, spec.SecretName, spec.Region, spec.Format)
+	}
+
+	if !k.options.DisableGCSReplication {
+		for _, spec := range syncable.GCSReplications() {
+			logs.Info.Printf("%s GCS object gs://%s/%s (format %s)", prefix, spec.Bucket, spec.Object, spec.Format)
+		}
+	}
+
+	if !k.options.DisableConsulReplication {
+		for _, spec := range syncable.ConsulReplications() {
+			logs.Info.Printf("%s Consul KV path %s%s (format %s)", prefix, spec.Path, consulAddressSuffix(spec.Address), spec.Format)
+		}
+	}
+
+	if k.options.PruneRemovedReplications {
+		current, err := syncedDestinations(entry, syncable)
+		if err != nil {
+			logs.Warn.Printf("dry run: %s %s in %s: can't determine removed replications: %v", entry.Type, syncable.Name(), syncable.Namespace(), err)
+			return
+		}
+		for _, destination := range removedDestinations(entry.SyncedDestinations[statusKey(syncable)], current) {
+			logs.Info.Printf("dry run: %s %s in %s: would delete removed %s replication %+v", entry.Type, syncable.Name(), syncable.Namespace(), destination.Backend, destination)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package keysync
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/broadinstitute/yale/internal/yale/cache"
+	"github.com/broadinstitute/yale/internal/yale/logs"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/storage/v1"
+)
+
+// replicateKeyToGCS writes the formatted key to every GCS object in the syncable's spec. Objects that already
+// contain the formatted key, as determined by comparing md5 hashes, are left untouched.
+func (k *keysync) replicateKeyToGCS(entry *cache.Entry, syncable Syncable) error {
+	if k.options.DisableGCSReplication {
+		return nil
+	}
+	if len(syncable.GCSReplications()) == 0 {
+		// no replications to perform
+		return nil
+	}
+	if k.options.GCS == nil {
+		return errNoGCSClient
+	}
+
+	for _, spec := range syncable.GCSReplications() {
+		msg := fmt.Sprintf("replicating key %s for %s (format %s) to GCS object gs://%s/%s",
+			entry.CurrentKey.ID, entry.Identify(), spec.Format, spec.Bucket, spec.Object)
+		logs.Info.Print(msg)
+
+		formatted, err := formatSecretForGitHubOrGSM(entry, spec.Format, "")
+		if err != nil {
+			return fmt.Errorf("error %s: formatting failed: %v", msg, err)
+		}
+
+		current, err := k.getGCSObject(spec.Bucket, spec.Object)
+		if err != nil {
+			return fmt.Errorf("error retrieving GCS object gs://%s/%s: %v", spec.Bucket, spec.Object, err)
+		}
+
+		// a generation of 0 means the upload only succeeds if the object does not exist; otherwise, only
+		// overwrite the generation we compared against, so a concurrent write is not silently clobbered
+		var generation int64
+		if current != nil {
+			if current.Md5Hash == gcsMD5Hash(formatted) {
+				logs.Debug.Printf("GCS object gs://%s/%s already contains the desired data, won't upload a new generation", spec.Bucket, spec.Object)
+				continue
+			}
+			generation = current.Generation
+		}
+
+		uploaded, err := k.options.GCS.Objects.Insert(spec.Bucket, &storage.Object{Name: spec.Object}).
+			Media(bytes.NewReader(formatted)).
+			IfGenerationMatch(generation).
+			Context(context.Background()).
+			Do()
+		if err != nil {
+			return fmt.Errorf("error uploading GCS object gs://%s/%s: %v", spec.Bucket, spec.Object, err)
+		}
+		logs.Info.Printf("uploaded GCS object gs://%s/%s (generation %d)", spec.Bucket, spec.Object, uploaded.Generation)
+	}
+
+	logs.Info.Printf("replicated key %s for %s to %d GCS objects", entry.CurrentKey.ID, entry.Identify(), len(syncable.GCSReplications()))
+
+	return nil
+}
+
+// getGCSObject returns the metadata for the given object, or nil if it does not exist
+func (k *keysync) getGCSObject(bucket string, object string) (*storage.Object, error) {
+	obj, err := k.options.GCS.Objects.Get(bucket, object).Context(context.Background()).Do()
+	if err == nil {
+		return obj, nil
+	}
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+		return nil, nil
+	}
+	return nil, err
+}
+
+// gcsMD5Hash returns the base64-encoded md5 hash of the data, in the form GCS reports in object metadata
+func gcsMD5Hash(data []byte) string {
+	sum := md5.Sum(data)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,211 @@
+package github
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/broadinstitute/yale/internal/yale/logs"
+	"github.com/google/go-github/v62/github"
+)
+
+// DefaultAPIURL base URL of the GitHub REST API that installation tokens are minted from
+const DefaultAPIURL = "https://api.github.com/"
+
+// appJWTLifetime how long the JWTs used to mint installation tokens are valid for; GitHub rejects JWTs
+// that expire more than 10 minutes in the future
+const appJWTLifetime = 9 * time.Minute
+
+// appJWTClockSkew how far in the past JWTs are issued, to allow for clock drift between Yale and GitHub
+const appJWTClockSkew = time.Minute
+
+// installationTokenRefreshLead installation tokens are refreshed once they are within this long of expiring,
+// so that a token never expires partway through a sync
+const installationTokenRefreshLead = 5 * time.Minute
+
+// AppCredentials credentials for authenticating to GitHub as a GitHub App installation
+type AppCredentials struct {
+	// AppID id of the GitHub App
+	AppID int64
+	// InstallationID id of the App's installation in the org that secrets are written to
+	InstallationID int64
+	// PrivateKey PEM-encoded private key generated for the App
+	PrivateKey []byte
+}
+
+// NewAppClient returns a client that authenticates as a GitHub App installation, minting short-lived
+// installation tokens as needed, instead of with a personal access token
+func NewAppClient(credentials AppCredentials) (Client, error) {
+	transport, err := NewAppTransport(credentials, http.DefaultTransport)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(github.NewClient(&http.Client{Transport: transport})), nil
+}
+
+// NewAppTransport returns an http.RoundTripper that authenticates requests with an installation token for
+// the given GitHub App installation, minting a new token via base whenever the current one is close to expiring
+func NewAppTransport(credentials AppCredentials, base http.RoundTripper) (http.RoundTripper, error) {
+	if credentials.AppID == 0 || credentials.InstallationID == 0 {
+		return nil, fmt.Errorf("GitHub App id and installation id are required")
+	}
+	key, err := parsePrivateKey(credentials.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing GitHub App private key: %v", err)
+	}
+	return &appTransport{
+		appID:          credentials.AppID,
+		installationID: credentials.InstallationID,
+		key:            key,
+		base:           base,
+		apiURL:         DefaultAPIURL,
+		now:            time.Now,
+	}, nil
+}
+
+// appTransport adds an installation token to every request, caching it until it is close to expiring
+type appTransport struct {
+	appID          int64
+	installationID int64
+	key            *rsa.PrivateKey
+	base           http.RoundTripper
+	apiURL         string
+	now            func() time.Time
+
+	mutex     sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+func (t *appTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.installationToken()
+	if err != nil {
+		return nil, err
+	}
+	// RoundTrippers must not modify the request they are given
+	authenticated := req.Clone(req.Context())
+	authenticated.Header.Set("Authorization", "token "+token)
+	return t.base.RoundTrip(authenticated)
+}
+
+// installationToken returns the cached installation token, minting a new one if it is missing or about to expire
+func (t *appTransport) installationToken() (string, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.token != "" && t.now().Add(installationTokenRefreshLead).Before(t.expiresAt) {
+		return t.token, nil
+	}
+	token, expiresAt, err := t.mintInstallationToken()
+	if err != nil {
+		return "", err
+	}
+	logs.Debug.Printf("minted GitHub App installation token for installation %d, expires at %s", t.installationID, expiresAt)
+	t.token = token
+	t.expiresAt = expiresAt
+	return token, nil
+}
+
+// mintInstallationToken exchanges a JWT signed with the App's private key for a new installation token
+func (t *appTransport) mintInstallationToken() (string, time.Time, error) {
+	jwt, err := t.signJWT()
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("error signing GitHub App JWT: %v", err)
+	}
+
+	url := fmt.Sprintf("%sapp/installations/%d/access_tokens", t.apiURL, t.installationID)
+	req, err := http.NewRequest(http.MethodPost, url, nil)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("error building GitHub App installation token request: %v", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+jwt)
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("error minting GitHub App installation token for installation %d: %v", t.installationID, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("error reading GitHub App installation token response: %v", err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return "", time.Time{}, fmt.Errorf("error minting GitHub App installation token for installation %d: %s: %s", t.installationID, resp.Status, string(body))
+	}
+
+	var parsed struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err = json.Unmarshal(body, &parsed); err != nil {
+		return "", time.Time{}, fmt.Errorf("error decoding GitHub App installation token response: %v", err)
+	}
+	if parsed.Token == "" {
+		return "", time.Time{}, fmt.Errorf("GitHub App installation token response for installation %d has no token", t.installationID)
+	}
+	return parsed.Token, parsed.ExpiresAt, nil
+}
+
+// signJWT returns an RS256 JWT identifying the App, as described in
+// https://docs.github.com/en/apps/creating-github-apps/authenticating-with-a-github-app/generating-a-json-web-token-jwt-for-a-github-app
+func (t *appTransport) signJWT() (string, error) {
+	now := t.now()
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-appJWTClockSkew).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": strconv.FormatInt(t.appID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var unsigned bytes.Buffer
+	unsigned.WriteString(base64.RawURLEncoding.EncodeToString(header))
+	unsigned.WriteString(".")
+	unsigned.WriteString(base64.RawURLEncoding.EncodeToString(claims))
+
+	digest := sha256.Sum256(unsigned.Bytes())
+	signature, err := rsa.SignPKCS1v15(rand.Reader, t.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned.String() + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// parsePrivateKey parses a PEM-encoded RSA private key in PKCS#1 (the format GitHub generates) or PKCS#8 form
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("not a PKCS#1 or PKCS#8 private key: %v", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("expected an RSA private key, got %T", parsed)
+	}
+	return key, nil
+}
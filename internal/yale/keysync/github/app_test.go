@@ -0,0 +1,148 @@
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockTransport responds to installation token requests with a new token each time, and records the
+// Authorization header of every other request
+type mockTransport struct {
+	t            *testing.T
+	key          *rsa.PublicKey
+	now          *time.Time
+	tokenStatus  int
+	tokensMinted int
+	authHeaders  []string
+}
+
+func (m *mockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodPost && req.URL.Path == "/app/installations/456/access_tokens" {
+		m.verifyJWT(strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "))
+		if m.tokenStatus != 0 {
+			return m.respond(m.tokenStatus, `{"message":"Bad credentials"}`), nil
+		}
+		m.tokensMinted++
+		expiresAt := m.now.Add(time.Hour).UTC().Format(time.RFC3339)
+		return m.respond(http.StatusCreated, fmt.Sprintf(`{"token":"ghs_token%d","expires_at":"%s"}`, m.tokensMinted, expiresAt)), nil
+	}
+	m.authHeaders = append(m.authHeaders, req.Header.Get("Authorization"))
+	return m.respond(http.StatusOK, `{}`), nil
+}
+
+func (m *mockTransport) respond(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+// verifyJWT checks that the JWT is signed with the App's private key and identifies the App
+func (m *mockTransport) verifyJWT(jwt string) {
+	parts := strings.Split(jwt, ".")
+	require.Len(m.t, parts, 3)
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(m.t, err)
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	require.NoError(m.t, rsa.VerifyPKCS1v15(m.key, crypto.SHA256, digest[:], signature))
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(m.t, err)
+	var claims struct {
+		Iat int64  `json:"iat"`
+		Exp int64  `json:"exp"`
+		Iss string `json:"iss"`
+	}
+	require.NoError(m.t, json.Unmarshal(payload, &claims))
+	assert.Equal(m.t, "123", claims.Iss)
+	assert.Less(m.t, claims.Iat, m.now.Unix())
+	assert.LessOrEqual(m.t, claims.Exp, m.now.Add(10*time.Minute).Unix())
+}
+
+func newTestAppTransport(t *testing.T) (*appTransport, *mockTransport) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	mock := &mockTransport{t: t, key: &key.PublicKey, now: &now}
+
+	transport, err := NewAppTransport(AppCredentials{AppID: 123, InstallationID: 456, PrivateKey: privateKey}, mock)
+	require.NoError(t, err)
+	_transport := transport.(*appTransport)
+	_transport.now = func() time.Time { return now }
+	return _transport, mock
+}
+
+func get(t *testing.T, transport http.RoundTripper) error {
+	req, err := http.NewRequest(http.MethodGet, DefaultAPIURL+"repos/my-org/my-repo/actions/secrets/public-key", nil)
+	require.NoError(t, err)
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func Test_AppTransport_MintsAndReusesInstallationToken(t *testing.T) {
+	transport, mock := newTestAppTransport(t)
+
+	require.NoError(t, get(t, transport))
+	require.NoError(t, get(t, transport))
+
+	assert.Equal(t, 1, mock.tokensMinted)
+	assert.Equal(t, []string{"token ghs_token1", "token ghs_token1"}, mock.authHeaders)
+}
+
+func Test_AppTransport_RefreshesInstallationTokenBeforeItExpires(t *testing.T) {
+	transport, mock := newTestAppTransport(t)
+
+	require.NoError(t, get(t, transport))
+
+	// still well within the token's hour-long lifetime
+	*mock.now = mock.now.Add(50 * time.Minute)
+	require.NoError(t, get(t, transport))
+	assert.Equal(t, 1, mock.tokensMinted)
+
+	// within the refresh lead of the token expiring
+	*mock.now = mock.now.Add(6 * time.Minute)
+	require.NoError(t, get(t, transport))
+	assert.Equal(t, 2, mock.tokensMinted)
+
+	assert.Equal(t, []string{"token ghs_token1", "token ghs_token1", "token ghs_token2"}, mock.authHeaders)
+}
+
+func Test_AppTransport_ReturnsErrorIfTokenCannotBeMinted(t *testing.T) {
+	transport, mock := newTestAppTransport(t)
+	mock.tokenStatus = http.StatusUnauthorized
+
+	err := get(t, transport)
+	assert.ErrorContains(t, err, "error minting GitHub App installation token for installation 456: 401 Unauthorized")
+	assert.Empty(t, mock.authHeaders)
+}
+
+func Test_NewAppTransport_RejectsInvalidCredentials(t *testing.T) {
+	_, err := NewAppTransport(AppCredentials{AppID: 123, InstallationID: 456, PrivateKey: []byte("not a key")}, http.DefaultTransport)
+	assert.ErrorContains(t, err, "error parsing GitHub App private key: no PEM data found")
+
+	_, err = NewAppTransport(AppCredentials{AppID: 123}, http.DefaultTransport)
+	assert.ErrorContains(t, err, "GitHub App id and installation id are required")
+}
@@ -3,6 +3,8 @@ package github
 import (
 	"context"
 	"fmt"
+	"net/http"
+
 	"github.com/broadinstitute/yale/internal/yale/logs"
 	"github.com/google/go-github/v62/github"
 )
@@ -13,56 +15,281 @@ func NewClient(c *github.Client) Client {
 	}
 }
 
+// SecretKind identifies which GitHub secrets API a secret is written to
+type SecretKind string
+
+const (
+	ActionsSecret    SecretKind = "actions"
+	DependabotSecret SecretKind = "dependabot"
+	CodespacesSecret SecretKind = "codespaces"
+)
+
 type Client interface {
-	WriteSecret(owner string, repo string, secretName string, requiredByDependabot bool, content []byte) error
+	// WriteSecret writes a repo secret of the given kind
+	WriteSecret(owner string, repo string, secretName string, kind SecretKind, content []byte) error
+	// WriteEnvironmentSecret writes a GitHub Actions secret scoped to a deployment environment in the given repo
+	WriteEnvironmentSecret(owner string, repo string, environment string, secretName string, content []byte) error
+	// WriteOrgSecret writes an organization-level secret of the given kind, visible to repos in the org according to visibility
+	WriteOrgSecret(org string, secretName string, kind SecretKind, visibility string, content []byte, selectedRepoIDs []int64) error
+	// DeleteSecret deletes the Actions and Dependabot secrets with the given name from the repo, if they exist
+	DeleteSecret(owner string, repo string, secretName string) error
+	// DeleteEnvironmentSecret deletes the Actions secret with the given name from a deployment environment in the repo, if it exists
+	DeleteEnvironmentSecret(owner string, repo string, environment string, secretName string) error
+	// DeleteOrgSecret deletes the Actions and Dependabot organization-level secrets with the given name, if they exist
+	DeleteOrgSecret(org string, secretName string) error
+	// DeleteCodespacesSecret deletes the Codespaces secret with the given name from the repo, if it exists
+	DeleteCodespacesSecret(owner string, repo string, secretName string) error
+	// DeleteCodespacesOrgSecret deletes the Codespaces organization-level secret with the given name, if it exists
+	DeleteCodespacesOrgSecret(org string, secretName string) error
 }
 
 type client struct {
 	github *github.Client
 }
 
-func (c *client) WriteSecret(owner string, repo string, secretName string, requiredByDependabot bool, content []byte) error {
-	pubkey, _, err := c.github.Actions.GetRepoPublicKey(context.Background(), owner, repo)
+func (c *client) WriteSecret(owner string, repo string, secretName string, kind SecretKind, content []byte) error {
+	switch kind {
+	case ActionsSecret:
+		pubkey, _, err := c.github.Actions.GetRepoPublicKey(context.Background(), owner, repo)
+		if err != nil {
+			return fmt.Errorf("error retrieving actions public key for %s/%s: %v", owner, repo, err)
+		}
+
+		encryptedSecret, err := Encrypt(*pubkey.Key, string(content))
+		if err != nil {
+			return fmt.Errorf("error encrypting actions secret for %s/%s: %v", owner, repo, err)
+		}
+
+		logs.Info.Printf("Writing to GitHub Actions secret %s in repo %s/%s", secretName, owner, repo)
+		_, err = c.github.Actions.CreateOrUpdateRepoSecret(context.Background(), owner, repo, &github.EncryptedSecret{
+			Name:           secretName,
+			KeyID:          *pubkey.KeyID,
+			EncryptedValue: encryptedSecret,
+		})
+		if err != nil {
+			return fmt.Errorf("error pushing encrypted GitHub Actions secret %s %s/%s: %v", secretName, owner, repo, err)
+		}
+	case DependabotSecret:
+		pubkey, _, err := c.github.Dependabot.GetRepoPublicKey(context.Background(), owner, repo)
+		if err != nil {
+			return fmt.Errorf("error retrieving dependabot public key for %s/%s: %v", owner, repo, err)
+		}
+
+		encryptedSecret, err := Encrypt(*pubkey.Key, string(content))
+		if err != nil {
+			return fmt.Errorf("error encrypting dependabot secret for %s/%s: %v", owner, repo, err)
+		}
+
+		logs.Info.Printf("Writing to GitHub Dependabot secret %s in repo %s/%s", secretName, owner, repo)
+		_, err = c.github.Dependabot.CreateOrUpdateRepoSecret(context.Background(), owner, repo, &github.DependabotEncryptedSecret{
+			Name:           secretName,
+			KeyID:          *pubkey.KeyID,
+			EncryptedValue: encryptedSecret,
+		})
+		if err != nil {
+			return fmt.Errorf("error pushing encrypted GitHub Dependabot secret %s %s/%s: %v", secretName, owner, repo, err)
+		}
+	case CodespacesSecret:
+		pubkey, _, err := c.github.Codespaces.GetRepoPublicKey(context.Background(), owner, repo)
+		if err != nil {
+			return fmt.Errorf("error retrieving codespaces public key for %s/%s: %v", owner, repo, err)
+		}
+
+		encryptedSecret, err := Encrypt(*pubkey.Key, string(content))
+		if err != nil {
+			return fmt.Errorf("error encrypting codespaces secret for %s/%s: %v", owner, repo, err)
+		}
+
+		logs.Info.Printf("Writing to GitHub Codespaces secret %s in repo %s/%s", secretName, owner, repo)
+		_, err = c.github.Codespaces.CreateOrUpdateRepoSecret(context.Background(), owner, repo, &github.EncryptedSecret{
+			Name:           secretName,
+			KeyID:          *pubkey.KeyID,
+			EncryptedValue: encryptedSecret,
+		})
+		if err != nil {
+			return fmt.Errorf("error pushing encrypted GitHub Codespaces secret %s %s/%s: %v", secretName, owner, repo, err)
+		}
+	default:
+		return fmt.Errorf("unsupported GitHub secret kind: %q", kind)
+	}
+	return nil
+}
+
+func (c *client) WriteEnvironmentSecret(owner string, repo string, environment string, secretName string, content []byte) error {
+	// the environment secrets API identifies repos by numeric ID rather than name
+	repository, _, err := c.github.Repositories.Get(context.Background(), owner, repo)
+	if err != nil {
+		return fmt.Errorf("error retrieving repo %s/%s: %v", owner, repo, err)
+	}
+	repoID := int(repository.GetID())
+
+	pubkey, _, err := c.github.Actions.GetEnvPublicKey(context.Background(), repoID, environment)
 	if err != nil {
-		return fmt.Errorf("error retrieving actions public key for %s/%s: %v", owner, repo, err)
+		return fmt.Errorf("error retrieving actions public key for environment %s in %s/%s: %v", environment, owner, repo, err)
 	}
 
 	encryptedSecret, err := Encrypt(*pubkey.Key, string(content))
 	if err != nil {
-		return fmt.Errorf("error encrypting actions secret for %s/%s: %v", owner, repo, err)
+		return fmt.Errorf("error encrypting actions secret for environment %s in %s/%s: %v", environment, owner, repo, err)
 	}
 
-	logs.Info.Printf("Writing to GitHub Actions secret %s in repo %s/%s", secretName, owner, repo)
-	_, err = c.github.Actions.CreateOrUpdateRepoSecret(context.Background(), owner, repo, &github.EncryptedSecret{
+	logs.Info.Printf("Writing to GitHub Actions secret %s in environment %s of repo %s/%s", secretName, environment, owner, repo)
+	_, err = c.github.Actions.CreateOrUpdateEnvSecret(context.Background(), repoID, environment, &github.EncryptedSecret{
 		Name:           secretName,
 		KeyID:          *pubkey.KeyID,
 		EncryptedValue: encryptedSecret,
 	})
 	if err != nil {
-		return fmt.Errorf("error pushing encrypted GitHub Actions secret %s %s/%s: %v", secretName, owner, repo, err)
+		return fmt.Errorf("error pushing encrypted GitHub Actions secret %s to environment %s in %s/%s: %v", secretName, environment, owner, repo, err)
 	}
 
-	if requiredByDependabot {
-		pubkey, _, err = c.github.Dependabot.GetRepoPublicKey(context.Background(), owner, repo)
+	return nil
+}
+
+func (c *client) WriteOrgSecret(org string, secretName string, kind SecretKind, visibility string, content []byte, selectedRepoIDs []int64) error {
+	switch kind {
+	case ActionsSecret:
+		pubkey, _, err := c.github.Actions.GetOrgPublicKey(context.Background(), org)
 		if err != nil {
-			return fmt.Errorf("error retrieving dependabot public key for %s/%s: %v", owner, repo, err)
+			return fmt.Errorf("error retrieving actions public key for org %s: %v", org, err)
 		}
 
-		encryptedSecret, err = Encrypt(*pubkey.Key, string(content))
+		encryptedSecret, err := Encrypt(*pubkey.Key, string(content))
 		if err != nil {
-			return fmt.Errorf("error encrypting dependabot secret for %s/%s: %v", owner, repo, err)
+			return fmt.Errorf("error encrypting actions secret for org %s: %v", org, err)
 		}
 
-		logs.Info.Printf("Writing to GitHub Dependabot secret %s in repo %s/%s", secretName, owner, repo)
-		_, err = c.github.Dependabot.CreateOrUpdateRepoSecret(context.Background(), owner, repo, &github.DependabotEncryptedSecret{
-			Name:           secretName,
-			KeyID:          *pubkey.KeyID,
-			EncryptedValue: encryptedSecret,
+		logs.Info.Printf("Writing to GitHub Actions org secret %s in org %s (visibility: %s)", secretName, org, visibility)
+		_, err = c.github.Actions.CreateOrUpdateOrgSecret(context.Background(), org, &github.EncryptedSecret{
+			Name:                  secretName,
+			KeyID:                 *pubkey.KeyID,
+			EncryptedValue:        encryptedSecret,
+			Visibility:            visibility,
+			SelectedRepositoryIDs: selectedRepoIDs,
 		})
 		if err != nil {
-			return fmt.Errorf("error pushing encrypted GitHub Actions secret %s %s/%s: %v", secretName, owner, repo, err)
+			return fmt.Errorf("error pushing encrypted GitHub Actions org secret %s to org %s: %v", secretName, org, err)
+		}
+	case DependabotSecret:
+		pubkey, _, err := c.github.Dependabot.GetOrgPublicKey(context.Background(), org)
+		if err != nil {
+			return fmt.Errorf("error retrieving dependabot public key for org %s: %v", org, err)
+		}
+
+		encryptedSecret, err := Encrypt(*pubkey.Key, string(content))
+		if err != nil {
+			return fmt.Errorf("error encrypting dependabot secret for org %s: %v", org, err)
+		}
+
+		logs.Info.Printf("Writing to GitHub Dependabot org secret %s in org %s (visibility: %s)", secretName, org, visibility)
+		_, err = c.github.Dependabot.CreateOrUpdateOrgSecret(context.Background(), org, &github.DependabotEncryptedSecret{
+			Name:                  secretName,
+			KeyID:                 *pubkey.KeyID,
+			EncryptedValue:        encryptedSecret,
+			Visibility:            visibility,
+			SelectedRepositoryIDs: selectedRepoIDs,
+		})
+		if err != nil {
+			return fmt.Errorf("error pushing encrypted GitHub Dependabot org secret %s to org %s: %v", secretName, org, err)
+		}
+	case CodespacesSecret:
+		pubkey, _, err := c.github.Codespaces.GetOrgPublicKey(context.Background(), org)
+		if err != nil {
+			return fmt.Errorf("error retrieving codespaces public key for org %s: %v", org, err)
+		}
+
+		encryptedSecret, err := Encrypt(*pubkey.Key, string(content))
+		if err != nil {
+			return fmt.Errorf("error encrypting codespaces secret for org %s: %v", org, err)
+		}
+
+		logs.Info.Printf("Writing to GitHub Codespaces org secret %s in org %s (visibility: %s)", secretName, org, visibility)
+		_, err = c.github.Codespaces.CreateOrUpdateOrgSecret(context.Background(), org, &github.EncryptedSecret{
+			Name:                  secretName,
+			KeyID:                 *pubkey.KeyID,
+			EncryptedValue:        encryptedSecret,
+			Visibility:            visibility,
+			SelectedRepositoryIDs: selectedRepoIDs,
+		})
+		if err != nil {
+			return fmt.Errorf("error pushing encrypted GitHub Codespaces org secret %s to org %s: %v", secretName, org, err)
+		}
+	default:
+		return fmt.Errorf("unsupported GitHub secret kind: %q", kind)
+	}
+	return nil
+}
+
+func (c *client) DeleteSecret(owner string, repo string, secretName string) error {
+	logs.Info.Printf("Deleting GitHub Actions secret %s in repo %s/%s", secretName, owner, repo)
+	resp, err := c.github.Actions.DeleteRepoSecret(context.Background(), owner, repo, secretName)
+	if err != nil && !isNotFound(resp) {
+		return fmt.Errorf("error deleting GitHub Actions secret %s in %s/%s: %v", secretName, owner, repo, err)
+	}
+
+	logs.Info.Printf("Deleting GitHub Dependabot secret %s in repo %s/%s", secretName, owner, repo)
+	resp, err = c.github.Dependabot.DeleteRepoSecret(context.Background(), owner, repo, secretName)
+	if err != nil && !isNotFound(resp) {
+		return fmt.Errorf("error deleting GitHub Dependabot secret %s in %s/%s: %v", secretName, owner, repo, err)
+	}
+
+	return nil
+}
+
+func (c *client) DeleteEnvironmentSecret(owner string, repo string, environment string, secretName string) error {
+	// the environment secrets API identifies repos by numeric ID rather than name
+	repository, resp, err := c.github.Repositories.Get(context.Background(), owner, repo)
+	if err != nil {
+		if isNotFound(resp) {
+			return nil
 		}
+		return fmt.Errorf("error retrieving repo %s/%s: %v", owner, repo, err)
+	}
+
+	logs.Info.Printf("Deleting GitHub Actions secret %s in environment %s of repo %s/%s", secretName, environment, owner, repo)
+	resp, err = c.github.Actions.DeleteEnvSecret(context.Background(), int(repository.GetID()), environment, secretName)
+	if err != nil && !isNotFound(resp) {
+		return fmt.Errorf("error deleting GitHub Actions secret %s in environment %s of %s/%s: %v", secretName, environment, owner, repo, err)
+	}
+
+	return nil
+}
+
+func (c *client) DeleteOrgSecret(org string, secretName string) error {
+	logs.Info.Printf("Deleting GitHub Actions org secret %s in org %s", secretName, org)
+	resp, err := c.github.Actions.DeleteOrgSecret(context.Background(), org, secretName)
+	if err != nil && !isNotFound(resp) {
+		return fmt.Errorf("error deleting GitHub Actions org secret %s in org %s: %v", secretName, org, err)
+	}
+
+	logs.Info.Printf("Deleting GitHub Dependabot org secret %s in org %s", secretName, org)
+	resp, err = c.github.Dependabot.DeleteOrgSecret(context.Background(), org, secretName)
+	if err != nil && !isNotFound(resp) {
+		return fmt.Errorf("error deleting GitHub Dependabot org secret %s in org %s: %v", secretName, org, err)
+	}
+
+	return nil
+}
+
+func (c *client) DeleteCodespacesSecret(owner string, repo string, secretName string) error {
+	logs.Info.Printf("Deleting GitHub Codespaces secret %s in repo %s/%s", secretName, owner, repo)
+	resp, err := c.github.Codespaces.DeleteRepoSecret(context.Background(), owner, repo, secretName)
+	if err != nil && !isNotFound(resp) {
+		return fmt.Errorf("error deleting GitHub Codespaces secret %s in %s/%s: %v", secretName, owner, repo, err)
 	}
+	return nil
+}
 
+func (c *client) DeleteCodespacesOrgSecret(org string, secretName string) error {
+	logs.Info.Printf("Deleting GitHub Codespaces org secret %s in org %s", secretName, org)
+	resp, err := c.github.Codespaces.DeleteOrgSecret(context.Background(), org, secretName)
+	if err != nil && !isNotFound(resp) {
+		return fmt.Errorf("error deleting GitHub Codespaces org secret %s in org %s: %v", secretName, org, err)
+	}
 	return nil
 }
+
+// isNotFound returns true if the GitHub API responded with a 404, meaning the thing being deleted is already gone
+func isNotFound(resp *github.Response) bool {
+	return resp != nil && resp.StatusCode == http.StatusNotFound
+}
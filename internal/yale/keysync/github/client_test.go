@@ -1,12 +1,19 @@
 package github
 
 import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
 	"github.com/google/go-github/v62/github"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/dnaeon/go-vcr.v3/cassette"
 	"gopkg.in/dnaeon/go-vcr.v3/recorder"
-	"strings"
-	"testing"
 )
 
 const githubToken = "<add your PAT here while recording>"
@@ -44,5 +51,39 @@ func Test_Client_WritesSecret(t *testing.T) {
 	_client := NewClient(githubClient)
 
 	// write the secret
-	require.NoError(t, _client.WriteSecret(repo, org, secretName, true, []byte("some data")))
+	require.NoError(t, _client.WriteSecret(repo, org, secretName, ActionsSecret, []byte("some data")))
+	require.NoError(t, _client.WriteSecret(repo, org, secretName, DependabotSecret, []byte("some data")))
+}
+
+func Test_Client_WritesCodespacesSecret(t *testing.T) {
+	var written github.EncryptedSecret
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/my-org/my-repo/codespaces/secrets/public-key":
+			_, _ = w.Write([]byte(`{"key_id":"my-key-id","key":"` + mockValidPublicKey + `"}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/repos/my-org/my-repo/codespaces/secrets/MY_SECRET":
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			require.NoError(t, json.Unmarshal(body, &written))
+			w.WriteHeader(http.StatusCreated)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	githubClient := github.NewClient(nil)
+	baseURL, err := url.Parse(server.URL + "/")
+	require.NoError(t, err)
+	githubClient.BaseURL = baseURL
+
+	require.NoError(t, NewClient(githubClient).WriteSecret("my-org", "my-repo", "MY_SECRET", CodespacesSecret, []byte("some data")))
+	assert.Equal(t, "my-key-id", written.KeyID)
+	assert.NotEmpty(t, written.EncryptedValue)
+}
+
+func Test_Client_RejectsUnknownSecretKind(t *testing.T) {
+	err := NewClient(github.NewClient(nil)).WriteSecret("my-org", "my-repo", "MY_SECRET", SecretKind("copilot"), []byte("some data"))
+	assert.ErrorContains(t, err, `unsupported GitHub secret kind: "copilot"`)
 }
@@ -2,7 +2,10 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	github "github.com/broadinstitute/yale/internal/yale/keysync/github"
+	mock "github.com/stretchr/testify/mock"
+)
 
 // Client is an autogenerated mock type for the Client type
 type Client struct {
@@ -17,17 +20,357 @@ func (_m *Client) EXPECT() *Client_Expecter {
 	return &Client_Expecter{mock: &_m.Mock}
 }
 
-// WriteSecret provides a mock function with given fields: owner, repo, secretName, requiredByDependabot, content
-func (_m *Client) WriteSecret(owner string, repo string, secretName string, requiredByDependabot bool, content []byte) error {
-	ret := _m.Called(owner, repo, secretName, requiredByDependabot, content)
+// DeleteCodespacesOrgSecret provides a mock function with given fields: org, secretName
+func (_m *Client) DeleteCodespacesOrgSecret(org string, secretName string) error {
+	ret := _m.Called(org, secretName)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteCodespacesOrgSecret")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(org, secretName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Client_DeleteCodespacesOrgSecret_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteCodespacesOrgSecret'
+type Client_DeleteCodespacesOrgSecret_Call struct {
+	*mock.Call
+}
+
+// DeleteCodespacesOrgSecret is a helper method to define mock.On call
+//   - org string
+//   - secretName string
+func (_e *Client_Expecter) DeleteCodespacesOrgSecret(org interface{}, secretName interface{}) *Client_DeleteCodespacesOrgSecret_Call {
+	return &Client_DeleteCodespacesOrgSecret_Call{Call: _e.mock.On("DeleteCodespacesOrgSecret", org, secretName)}
+}
+
+func (_c *Client_DeleteCodespacesOrgSecret_Call) Run(run func(org string, secretName string)) *Client_DeleteCodespacesOrgSecret_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Client_DeleteCodespacesOrgSecret_Call) Return(_a0 error) *Client_DeleteCodespacesOrgSecret_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Client_DeleteCodespacesOrgSecret_Call) RunAndReturn(run func(string, string) error) *Client_DeleteCodespacesOrgSecret_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteCodespacesSecret provides a mock function with given fields: owner, repo, secretName
+func (_m *Client) DeleteCodespacesSecret(owner string, repo string, secretName string) error {
+	ret := _m.Called(owner, repo, secretName)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteCodespacesSecret")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string) error); ok {
+		r0 = rf(owner, repo, secretName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Client_DeleteCodespacesSecret_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteCodespacesSecret'
+type Client_DeleteCodespacesSecret_Call struct {
+	*mock.Call
+}
+
+// DeleteCodespacesSecret is a helper method to define mock.On call
+//   - owner string
+//   - repo string
+//   - secretName string
+func (_e *Client_Expecter) DeleteCodespacesSecret(owner interface{}, repo interface{}, secretName interface{}) *Client_DeleteCodespacesSecret_Call {
+	return &Client_DeleteCodespacesSecret_Call{Call: _e.mock.On("DeleteCodespacesSecret", owner, repo, secretName)}
+}
+
+func (_c *Client_DeleteCodespacesSecret_Call) Run(run func(owner string, repo string, secretName string)) *Client_DeleteCodespacesSecret_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *Client_DeleteCodespacesSecret_Call) Return(_a0 error) *Client_DeleteCodespacesSecret_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Client_DeleteCodespacesSecret_Call) RunAndReturn(run func(string, string, string) error) *Client_DeleteCodespacesSecret_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteEnvironmentSecret provides a mock function with given fields: owner, repo, environment, secretName
+func (_m *Client) DeleteEnvironmentSecret(owner string, repo string, environment string, secretName string) error {
+	ret := _m.Called(owner, repo, environment, secretName)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteEnvironmentSecret")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string, string) error); ok {
+		r0 = rf(owner, repo, environment, secretName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Client_DeleteEnvironmentSecret_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteEnvironmentSecret'
+type Client_DeleteEnvironmentSecret_Call struct {
+	*mock.Call
+}
+
+// DeleteEnvironmentSecret is a helper method to define mock.On call
+//   - owner string
+//   - repo string
+//   - environment string
+//   - secretName string
+func (_e *Client_Expecter) DeleteEnvironmentSecret(owner interface{}, repo interface{}, environment interface{}, secretName interface{}) *Client_DeleteEnvironmentSecret_Call {
+	return &Client_DeleteEnvironmentSecret_Call{Call: _e.mock.On("DeleteEnvironmentSecret", owner, repo, environment, secretName)}
+}
+
+func (_c *Client_DeleteEnvironmentSecret_Call) Run(run func(owner string, repo string, environment string, secretName string)) *Client_DeleteEnvironmentSecret_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].(string), args[3].(string))
+	})
+	return _c
+}
+
+func (_c *Client_DeleteEnvironmentSecret_Call) Return(_a0 error) *Client_DeleteEnvironmentSecret_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Client_DeleteEnvironmentSecret_Call) RunAndReturn(run func(string, string, string, string) error) *Client_DeleteEnvironmentSecret_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteOrgSecret provides a mock function with given fields: org, secretName
+func (_m *Client) DeleteOrgSecret(org string, secretName string) error {
+	ret := _m.Called(org, secretName)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteOrgSecret")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(org, secretName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Client_DeleteOrgSecret_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteOrgSecret'
+type Client_DeleteOrgSecret_Call struct {
+	*mock.Call
+}
+
+// DeleteOrgSecret is a helper method to define mock.On call
+//   - org string
+//   - secretName string
+func (_e *Client_Expecter) DeleteOrgSecret(org interface{}, secretName interface{}) *Client_DeleteOrgSecret_Call {
+	return &Client_DeleteOrgSecret_Call{Call: _e.mock.On("DeleteOrgSecret", org, secretName)}
+}
+
+func (_c *Client_DeleteOrgSecret_Call) Run(run func(org string, secretName string)) *Client_DeleteOrgSecret_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Client_DeleteOrgSecret_Call) Return(_a0 error) *Client_DeleteOrgSecret_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Client_DeleteOrgSecret_Call) RunAndReturn(run func(string, string) error) *Client_DeleteOrgSecret_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteSecret provides a mock function with given fields: owner, repo, secretName
+func (_m *Client) DeleteSecret(owner string, repo string, secretName string) error {
+	ret := _m.Called(owner, repo, secretName)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteSecret")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string) error); ok {
+		r0 = rf(owner, repo, secretName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Client_DeleteSecret_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteSecret'
+type Client_DeleteSecret_Call struct {
+	*mock.Call
+}
+
+// DeleteSecret is a helper method to define mock.On call
+//   - owner string
+//   - repo string
+//   - secretName string
+func (_e *Client_Expecter) DeleteSecret(owner interface{}, repo interface{}, secretName interface{}) *Client_DeleteSecret_Call {
+	return &Client_DeleteSecret_Call{Call: _e.mock.On("DeleteSecret", owner, repo, secretName)}
+}
+
+func (_c *Client_DeleteSecret_Call) Run(run func(owner string, repo string, secretName string)) *Client_DeleteSecret_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *Client_DeleteSecret_Call) Return(_a0 error) *Client_DeleteSecret_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Client_DeleteSecret_Call) RunAndReturn(run func(string, string, string) error) *Client_DeleteSecret_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// WriteEnvironmentSecret provides a mock function with given fields: owner, repo, environment, secretName, content
+func (_m *Client) WriteEnvironmentSecret(owner string, repo string, environment string, secretName string, content []byte) error {
+	ret := _m.Called(owner, repo, environment, secretName, content)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WriteEnvironmentSecret")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string, string, []byte) error); ok {
+		r0 = rf(owner, repo, environment, secretName, content)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Client_WriteEnvironmentSecret_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WriteEnvironmentSecret'
+type Client_WriteEnvironmentSecret_Call struct {
+	*mock.Call
+}
+
+// WriteEnvironmentSecret is a helper method to define mock.On call
+//   - owner string
+//   - repo string
+//   - environment string
+//   - secretName string
+//   - content []byte
+func (_e *Client_Expecter) WriteEnvironmentSecret(owner interface{}, repo interface{}, environment interface{}, secretName interface{}, content interface{}) *Client_WriteEnvironmentSecret_Call {
+	return &Client_WriteEnvironmentSecret_Call{Call: _e.mock.On("WriteEnvironmentSecret", owner, repo, environment, secretName, content)}
+}
+
+func (_c *Client_WriteEnvironmentSecret_Call) Run(run func(owner string, repo string, environment string, secretName string, content []byte)) *Client_WriteEnvironmentSecret_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].(string), args[3].(string), args[4].([]byte))
+	})
+	return _c
+}
+
+func (_c *Client_WriteEnvironmentSecret_Call) Return(_a0 error) *Client_WriteEnvironmentSecret_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Client_WriteEnvironmentSecret_Call) RunAndReturn(run func(string, string, string, string, []byte) error) *Client_WriteEnvironmentSecret_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// WriteOrgSecret provides a mock function with given fields: org, secretName, kind, visibility, content, selectedRepoIDs
+func (_m *Client) WriteOrgSecret(org string, secretName string, kind github.SecretKind, visibility string, content []byte, selectedRepoIDs []int64) error {
+	ret := _m.Called(org, secretName, kind, visibility, content, selectedRepoIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WriteOrgSecret")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, github.SecretKind, string, []byte, []int64) error); ok {
+		r0 = rf(org, secretName, kind, visibility, content, selectedRepoIDs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Client_WriteOrgSecret_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WriteOrgSecret'
+type Client_WriteOrgSecret_Call struct {
+	*mock.Call
+}
+
+// WriteOrgSecret is a helper method to define mock.On call
+//   - org string
+//   - secretName string
+//   - kind github.SecretKind
+//   - visibility string
+//   - content []byte
+//   - selectedRepoIDs []int64
+func (_e *Client_Expecter) WriteOrgSecret(org interface{}, secretName interface{}, kind interface{}, visibility interface{}, content interface{}, selectedRepoIDs interface{}) *Client_WriteOrgSecret_Call {
+	return &Client_WriteOrgSecret_Call{Call: _e.mock.On("WriteOrgSecret", org, secretName, kind, visibility, content, selectedRepoIDs)}
+}
+
+func (_c *Client_WriteOrgSecret_Call) Run(run func(org string, secretName string, kind github.SecretKind, visibility string, content []byte, selectedRepoIDs []int64)) *Client_WriteOrgSecret_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].(github.SecretKind), args[3].(string), args[4].([]byte), args[5].([]int64))
+	})
+	return _c
+}
+
+func (_c *Client_WriteOrgSecret_Call) Return(_a0 error) *Client_WriteOrgSecret_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Client_WriteOrgSecret_Call) RunAndReturn(run func(string, string, github.SecretKind, string, []byte, []int64) error) *Client_WriteOrgSecret_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// WriteSecret provides a mock function with given fields: owner, repo, secretName, kind, content
+func (_m *Client) WriteSecret(owner string, repo string, secretName string, kind github.SecretKind, content []byte) error {
+	ret := _m.Called(owner, repo, secretName, kind, content)
 
 	if len(ret) == 0 {
 		panic("no return value specified for WriteSecret")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string, string, bool, []byte) error); ok {
-		r0 = rf(owner, repo, secretName, requiredByDependabot, content)
+	if rf, ok := ret.Get(0).(func(string, string, string, github.SecretKind, []byte) error); ok {
+		r0 = rf(owner, repo, secretName, kind, content)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -44,15 +387,15 @@ type Client_WriteSecret_Call struct {
 //   - owner string
 //   - repo string
 //   - secretName string
-//   - requiredByDependabot bool
+//   - kind github.SecretKind
 //   - content []byte
-func (_e *Client_Expecter) WriteSecret(owner interface{}, repo interface{}, secretName interface{}, requiredByDependabot interface{}, content interface{}) *Client_WriteSecret_Call {
-	return &Client_WriteSecret_Call{Call: _e.mock.On("WriteSecret", owner, repo, secretName, requiredByDependabot, content)}
+func (_e *Client_Expecter) WriteSecret(owner interface{}, repo interface{}, secretName interface{}, kind interface{}, content interface{}) *Client_WriteSecret_Call {
+	return &Client_WriteSecret_Call{Call: _e.mock.On("WriteSecret", owner, repo, secretName, kind, content)}
 }
 
-func (_c *Client_WriteSecret_Call) Run(run func(owner string, repo string, secretName string, requiredByDependabot bool, content []byte)) *Client_WriteSecret_Call {
+func (_c *Client_WriteSecret_Call) Run(run func(owner string, repo string, secretName string, kind github.SecretKind, content []byte)) *Client_WriteSecret_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(string), args[2].(string), args[3].(bool), args[4].([]byte))
+		run(args[0].(string), args[1].(string), args[2].(string), args[3].(github.SecretKind), args[4].([]byte))
 	})
 	return _c
 }
@@ -62,7 +405,7 @@ func (_c *Client_WriteSecret_Call) Return(_a0 error) *Client_WriteSecret_Call {
 	return _c
 }
 
-func (_c *Client_WriteSecret_Call) RunAndReturn(run func(string, string, string, bool, []byte) error) *Client_WriteSecret_Call {
+func (_c *Client_WriteSecret_Call) RunAndReturn(run func(string, string, string, github.SecretKind, []byte) error) *Client_WriteSecret_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -0,0 +1,130 @@
+package gitlab
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/broadinstitute/yale/internal/yale/logs"
+)
+
+// DefaultBaseURL base URL of the hosted GitLab instance
+const DefaultBaseURL = "https://gitlab.com"
+
+// defaultEnvironmentScope environment scope GitLab assigns to variables that are available to all environments
+const defaultEnvironmentScope = "*"
+
+// NewClient returns a Client for the GitLab instance at baseURL (eg. https://gitlab.com), authenticating with
+// the given access token
+func NewClient(baseURL string, token string, httpClient *http.Client) Client {
+	return &client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		token:      token,
+		httpClient: httpClient,
+	}
+}
+
+type Client interface {
+	// WriteVariable creates or updates a CI/CD variable in the given project (eg. "my-group/my-project").
+	// If environment is empty, the variable is available to all environments
+	WriteVariable(project string, variableKey string, environment string, protected bool, masked bool, content []byte) error
+}
+
+type client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+func (c *client) WriteVariable(project string, variableKey string, environment string, protected bool, masked bool, content []byte) error {
+	scope := environment
+	if scope == "" {
+		scope = defaultEnvironmentScope
+	}
+
+	exists, err := c.variableExists(project, variableKey, scope)
+	if err != nil {
+		return err
+	}
+
+	form := url.Values{}
+	form.Set("value", string(content))
+	form.Set("protected", strconv.FormatBool(protected))
+	form.Set("masked", strconv.FormatBool(masked))
+
+	if exists {
+		logs.Info.Printf("Updating GitLab CI/CD variable %s in project %s (environment scope: %s)", variableKey, project, scope)
+		return c.do(http.MethodPut, c.variableURL(project, variableKey, scope), form, http.StatusOK)
+	}
+
+	form.Set("key", variableKey)
+	form.Set("environment_scope", scope)
+	logs.Info.Printf("Creating GitLab CI/CD variable %s in project %s (environment scope: %s)", variableKey, project, scope)
+	return c.do(http.MethodPost, c.variablesURL(project), form, http.StatusCreated)
+}
+
+// variableExists returns true if the project has a variable with the given key in the given environment scope
+func (c *client) variableExists(project string, variableKey string, scope string) (bool, error) {
+	req, err := c.newRequest(http.MethodGet, c.variableURL(project, variableKey, scope), nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("error retrieving GitLab CI/CD variable %s in project %s: %v", variableKey, project, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, unexpectedResponse(req, resp)
+	}
+}
+
+func (c *client) do(method string, url string, form url.Values, expectedStatus int) error {
+	req, err := c.newRequest(method, url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error writing GitLab CI/CD variable: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expectedStatus {
+		return unexpectedResponse(req, resp)
+	}
+	return nil
+}
+
+func (c *client) newRequest(method string, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing GitLab API request: %v", err)
+	}
+	req.Header.Set("PRIVATE-TOKEN", c.token)
+	return req, nil
+}
+
+func (c *client) variablesURL(project string) string {
+	return fmt.Sprintf("%s/api/v4/projects/%s/variables", c.baseURL, url.PathEscape(project))
+}
+
+func (c *client) variableURL(project string, variableKey string, scope string) string {
+	return fmt.Sprintf("%s/%s?%s", c.variablesURL(project), url.PathEscape(variableKey), url.Values{"filter[environment_scope]": {scope}}.Encode())
+}
+
+func unexpectedResponse(req *http.Request, resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+	return fmt.Errorf("unexpected response from GitLab: %s %s returned %d: %s", req.Method, req.URL.Path, resp.StatusCode, string(body))
+}
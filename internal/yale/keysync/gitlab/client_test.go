@@ -0,0 +1,112 @@
+package gitlab
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordedRequest struct {
+	method string
+	path   string
+	query  string
+	form   map[string]string
+}
+
+// fakeGitLab returns a test server that records requests and reports whether the variable exists
+func fakeGitLab(t *testing.T, variableExists bool) (*httptest.Server, *[]recordedRequest) {
+	var requests []recordedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "my-token", r.Header.Get("PRIVATE-TOKEN"))
+		require.NoError(t, r.ParseForm())
+
+		form := make(map[string]string)
+		for key := range r.PostForm {
+			form[key] = r.PostForm.Get(key)
+		}
+		requests = append(requests, recordedRequest{
+			method: r.Method,
+			path:   r.URL.EscapedPath(),
+			query:  r.URL.RawQuery,
+			form:   form,
+		})
+
+		switch r.Method {
+		case http.MethodGet:
+			if variableExists {
+				w.WriteHeader(http.StatusOK)
+			} else {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodPut:
+			w.WriteHeader(http.StatusOK)
+		}
+		_, _ = w.Write([]byte("{}"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func Test_Client_CreatesNewVariable(t *testing.T) {
+	server, requests := fakeGitLab(t, false)
+	_client := NewClient(server.URL, "my-token", server.Client())
+
+	require.NoError(t, _client.WriteVariable("my-group/my-project", "MY_KEY", "production", true, false, []byte("some data")))
+
+	require.Len(t, *requests, 2)
+	assert.Equal(t, recordedRequest{
+		method: "GET",
+		path:   "/api/v4/projects/my-group%2Fmy-project/variables/MY_KEY",
+		query:  "filter%5Benvironment_scope%5D=production",
+		form:   map[string]string{},
+	}, (*requests)[0])
+	assert.Equal(t, recordedRequest{
+		method: "POST",
+		path:   "/api/v4/projects/my-group%2Fmy-project/variables",
+		form: map[string]string{
+			"key":               "MY_KEY",
+			"value":             "some data",
+			"environment_scope": "production",
+			"protected":         "true",
+			"masked":            "false",
+		},
+	}, (*requests)[1])
+}
+
+func Test_Client_UpdatesExistingVariable(t *testing.T) {
+	server, requests := fakeGitLab(t, true)
+	_client := NewClient(server.URL+"/", "my-token", server.Client())
+
+	require.NoError(t, _client.WriteVariable("my-group/my-project", "MY_KEY", "", false, true, []byte("c29tZSBkYXRh")))
+
+	require.Len(t, *requests, 2)
+	assert.Equal(t, "GET", (*requests)[0].method)
+	assert.Equal(t, "filter%5Benvironment_scope%5D=%2A", (*requests)[0].query)
+	assert.Equal(t, recordedRequest{
+		method: "PUT",
+		path:   "/api/v4/projects/my-group%2Fmy-project/variables/MY_KEY",
+		query:  "filter%5Benvironment_scope%5D=%2A",
+		form: map[string]string{
+			"value":     "c29tZSBkYXRh",
+			"protected": "false",
+			"masked":    "true",
+		},
+	}, (*requests)[1])
+}
+
+func Test_Client_ReturnsErrorForUnexpectedResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"message":"403 Forbidden"}`))
+	}))
+	defer server.Close()
+	_client := NewClient(server.URL, "my-token", server.Client())
+
+	err := _client.WriteVariable("my-group/my-project", "MY_KEY", "", false, false, []byte("some data"))
+	assert.ErrorContains(t, err, "returned 403")
+}
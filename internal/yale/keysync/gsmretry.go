@@ -0,0 +1,66 @@
+package keysync
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/broadinstitute/yale/internal/yale/logs"
+	"github.com/googleapis/gax-go/v2/apierror"
+	"google.golang.org/grpc/codes"
+)
+
+// DefaultGSMMaxAttempts default number of times Yale will attempt a GSM call that fails because GSM is
+// rate limiting requests or unavailable
+const DefaultGSMMaxAttempts = 5
+
+// DefaultGSMRetryBaseDelay default delay before the first retry of a failed GSM call; the delay doubles with
+// every subsequent retry, plus up to 50% random jitter
+const DefaultGSMRetryBaseDelay = time.Second
+
+// retryGSM calls fn, retrying with exponential backoff as long as it fails with a RESOURCE_EXHAUSTED or
+// UNAVAILABLE error. Replications run concurrently, so the delay is jittered to keep calls that were throttled
+// together from being retried in lockstep and throttled again.
+func (k *keysync) retryGSM(description string, fn func() error) error {
+	delay := k.options.GSMRetryBaseDelay
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if attempt >= k.options.GSMMaxAttempts || !isRetryableGSMError(err) {
+			return err
+		}
+		wait := delay + jitter(delay)
+		logs.Warn.Printf("attempt %d of %d to %s failed, retrying in %s: %v", attempt, k.options.GSMMaxAttempts, description, wait, err)
+		time.Sleep(wait)
+		delay *= 2
+	}
+}
+
+// jitter returns a random duration between 0 and half of d
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d / 2)))
+}
+
+// isRetryableGSMError returns true if the error is a RESOURCE_EXHAUSTED (429) or UNAVAILABLE (503) error
+// from the GSM API, rather than an error that will recur on retry, such as a permission error
+func isRetryableGSMError(err error) bool {
+	var apiErr *apierror.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.HTTPCode() {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	}
+	switch apiErr.GRPCStatus().Code() {
+	case codes.ResourceExhausted, codes.Unavailable:
+		return true
+	}
+	return false
+}
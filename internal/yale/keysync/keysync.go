@@ -9,29 +9,119 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	awstypes "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/broadinstitute/yale/internal/yale/keysync/consul"
 	"github.com/broadinstitute/yale/internal/yale/keysync/github"
+	"github.com/broadinstitute/yale/internal/yale/keysync/gitlab"
 	"google.golang.org/api/iterator"
+	"google.golang.org/api/storage/v1"
+	"reflect"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/broadinstitute/yale/internal/yale/audit"
 	"github.com/broadinstitute/yale/internal/yale/cache"
 	apiv1b1 "github.com/broadinstitute/yale/internal/yale/crd/api/v1beta1"
 	"github.com/broadinstitute/yale/internal/yale/logs"
+	"github.com/broadinstitute/yale/internal/yale/metrics"
 	vaultapi "github.com/hashicorp/vault/api"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
 )
 
 const defaultVaultReplicationSecretKey = "sa-key"
 
+// gsmCreatedByYaleAnnotation annotation Yale adds to the GSM secrets it creates
+const gsmCreatedByYaleAnnotation = "created-by-yale"
+
+// gsmOwnedByLabel label Yale adds to the GSM secrets it creates
+const gsmOwnedByLabel = "owned_by"
+
+// gsmOwnerAnnotation annotation Yale adds to the GSM secrets it creates, recording the identifier (eg. service
+// account email) whose key the secret holds
+const gsmOwnerAnnotation = "yale-owner"
+
+// reloaderAnnotation annotation that tells Reloader to restart workloads that use a secret when it changes
+const reloaderAnnotation = "reloader.stakater.com/match"
+
+// KeyIDAnnotation annotation Yale adds to the K8s secrets it syncs, recording the id of the key they hold.
+// Used to recover cache entries from the secrets they were synced to
+const KeyIDAnnotation = "yale.terra.bio/key-id"
+
+// contentHashAnnotation annotation Yale adds to the K8s secrets it syncs, recording a sha256 checksum of the
+// secret's data, so consumers can detect that the content changed without comparing it
+const contentHashAnnotation = "yale.terra.bio/content-hash"
+
+// errors returned when a resource is configured to replicate to a backend whose client failed to initialize,
+// so that Yale can still manage resources that don't need that backend
+var errNoVaultClient = fmt.Errorf("Vault replication configured but Yale has no Vault client")
+var errNoSecretManagerClient = fmt.Errorf("Google Secret Manager replication configured but Yale has no Google Secret Manager client")
+var errNoGitHubClient = fmt.Errorf("GitHub replication configured but Yale has no GitHub client")
+var errNoGitLabClient = fmt.Errorf("GitLab replication configured but Yale has no GitLab client")
+var errNoAWSClient = fmt.Errorf("AWS Secrets Manager replication configured but Yale has no AWS config")
+var errNoGCSClient = fmt.Errorf("GCS replication configured but Yale has no GCS client")
+var errNoConsulClient = fmt.Errorf("Consul replication configured but Yale has no Consul client")
+
 type Option func(*Options)
 
 type Options struct {
 	DisableVaultReplication  bool
 	DisableGitHubReplication bool
+	DisableGitLabReplication bool
+	DisableGCSReplication    bool
+	DisableConsulReplication bool
+	// VerifyVaultReplications if true, read back every Vault path a syncable replicates to, even if its
+	// sync status is up-to-date, and force a sync if the data at the path has drifted from the current key
+	VerifyVaultReplications bool
+	// ForceResync if true, sync every syncable, even if its sync status is up-to-date. The sync status is
+	// still updated afterward, so later runs without this option go back to syncing only what has changed
+	ForceResync bool
+	// TargetClusters K8s clients for additional clusters, by name, that secrets may be synced to
+	TargetClusters map[string]kubernetes.Interface
+	// AWSConfig AWS SDK config used to build a Secrets Manager client for each region replicated to
+	AWSConfig *aws.Config
+	// GCS client used to write GCS replications
+	GCS *storage.Service
+	// Consul client used to write Consul KV replications
+	Consul consul.Client
+	// MaxConcurrentReplications maximum number of Vault, GSM, or GitHub replications for a syncable
+	// that will be performed at once
+	MaxConcurrentReplications int
+	// VaultWriteMaxAttempts maximum number of times a Vault write that fails with a 5xx or network error will be attempted
+	VaultWriteMaxAttempts int
+	// VaultWriteRetryBaseDelay delay before the first retry of a failed Vault write, doubled for each subsequent retry
+	VaultWriteRetryBaseDelay time.Duration
+	// GSMMaxAttempts maximum number of times a GSM call that fails with RESOURCE_EXHAUSTED or UNAVAILABLE will be attempted
+	GSMMaxAttempts int
+	// GSMRetryBaseDelay delay before the first retry of a failed GSM call, doubled for each subsequent retry
+	GSMRetryBaseDelay time.Duration
+	// DryRun if true, log the writes a key sync would perform instead of performing them, and leave
+	// the cache entry's sync statuses untouched
+	DryRun bool
+	// AdoptUnownedSecrets if true, add versions to pre-existing GSM secrets that do not have the owned_by: yale
+	// label. By default, Yale refuses to write to them, since they may be managed by someone else
+	AdoptUnownedSecrets bool
+	// PruneRemovedReplications if true, delete Vault paths, GSM secrets, and GitHub secrets that a syncable was
+	// synced to on a previous run but are no longer in its spec
+	PruneRemovedReplications bool
+	// MaxDestinationFailures number of sync errors against a single external destination (eg. Vault) after
+	// which Yale stops writing to it until the next run, so that an outage doesn't produce an error for every
+	// resource. 0 for no limit
+	MaxDestinationFailures int
+	// AllowedVaultAddresses addresses of Vault servers, other than the shared Vault client's, that a Vault
+	// replication may write to with its address field. Replications to any other address are rejected
+	AllowedVaultAddresses []string
+	// AuditLog records every destination the key is synced to
+	AuditLog audit.Logger
 }
 
 // KeySync is responsible for propagating the current service account key from the Yale cache to destinations
@@ -43,6 +133,23 @@ type KeySync interface {
 	// Note that this function will update the cache entry's SyncStatus map to reflect any sync's it performs,
 	// but it WILL NOT save the entry to the cache -- that's the caller's responsibility!
 	SyncIfNeeded(entry *cache.Entry, gsks []Syncable) error
+	// PruneTargetClusterSecrets deletes secrets that Yale synced to additional clusters from one of the given
+	// syncables, but which that syncable no longer references
+	PruneTargetClusterSecrets(syncables []Syncable) error
+	// StartRun resets the sync error counts used to stop writing to unhealthy destinations. It should be called
+	// at the start of every run
+	StartRun()
+	// UnhealthyDestinations returns the external destinations (eg. "vault") that Yale stopped writing to since
+	// StartRun was last called, because they failed MaxDestinationFailures times, with their error counts
+	UnhealthyDestinations() map[string]int
+	// DeleteOrphanedSecrets deletes the K8s secrets recorded in the cache entry as synced by resources that no
+	// longer exist, and clears the record. It should only be called for entries that no resources use. Secrets
+	// that are now owned by a different resource are left alone.
+	DeleteOrphanedSecrets(entry *cache.Entry) error
+	// CheckNamespaces returns a problem for every secret of the given syncables that would be written to a
+	// namespace that does not exist, in the local cluster or in any additional cluster the secret targets,
+	// or that targets a cluster Yale has no kubeconfig for. It makes no changes.
+	CheckNamespaces(syncables []Syncable) ([]error, error)
 }
 
 // Syncable is an interface for objects that can be synced to a Kubernetes secret
@@ -53,10 +160,15 @@ type Syncable interface {
 	Namespace() string
 	SecretName() string
 	Secret() apiv1b1.Secret
+	AdditionalSecrets() []apiv1b1.Secret
 	SpecBytes() ([]byte, error)
 	VaultReplications() []apiv1b1.VaultReplication
 	GoogleSecretManagerReplications() []apiv1b1.GoogleSecretManagerReplication
 	GitHubReplications() []apiv1b1.GitHubReplication
+	AWSSecretsManagerReplications() []apiv1b1.AWSSecretsManagerReplication
+	GitLabReplications() []apiv1b1.GitLabReplication
+	GCSReplications() []apiv1b1.GCSReplication
+	ConsulReplications() []apiv1b1.ConsulReplication
 	APIVersion() string
 	Kind() string
 	UID() types.UID
@@ -79,9 +191,16 @@ func AzureClientSecretsToSyncable(acs []apiv1b1.AzureClientSecret) []Syncable {
 	return result
 }
 
-func New(k8s kubernetes.Interface, vault *vaultapi.Client, secretManager *secretmanager.Client, github github.Client, cache cache.Cache, options ...Option) KeySync {
+func New(k8s kubernetes.Interface, vault *vaultapi.Client, secretManager *secretmanager.Client, github github.Client, gitlab gitlab.Client, cache cache.Cache, options ...Option) KeySync {
 	opts := Options{
-		DisableVaultReplication: false,
+		DisableVaultReplication:   false,
+		MaxConcurrentReplications: DefaultMaxConcurrentReplications,
+		VaultWriteMaxAttempts:     DefaultVaultWriteMaxAttempts,
+		VaultWriteRetryBaseDelay:  DefaultVaultWriteRetryBaseDelay,
+		GSMMaxAttempts:            DefaultGSMMaxAttempts,
+		GSMRetryBaseDelay:         DefaultGSMRetryBaseDelay,
+		MaxDestinationFailures:    DefaultMaxDestinationFailures,
+		AuditLog:                  audit.NewNop(),
 	}
 	for _, option := range options {
 		option(&opts)
@@ -92,44 +211,64 @@ func New(k8s kubernetes.Interface, vault *vaultapi.Client, secretManager *secret
 		vault:         vault,
 		secretManager: secretManager,
 		github:        github,
+		gitlab:        gitlab,
 		cache:         cache,
 	}
 }
 
 type keysync struct {
-	options        Options
-	vault          *vaultapi.Client
-	secretManager  *secretmanager.Client
-	github         github.Client
-	k8s            kubernetes.Interface
-	cache          cache.Cache
-	mutex          sync.Mutex
-	clusterSecrets map[string]struct{}
+	options       Options
+	vault         *vaultapi.Client
+	secretManager *secretmanager.Client
+	github        github.Client
+	gitlab        gitlab.Client
+	k8s           kubernetes.Interface
+	cache         cache.Cache
+	mutex         sync.Mutex
+	// clusterSecrets memoized set of the names of the secrets in each namespace that has been listed
+	clusterSecrets map[string]map[string]struct{}
+	healthMutex    sync.Mutex
+	// destinationFailures number of sync errors against each external destination since the run started
+	destinationFailures map[string]int
 }
 
 func (k *keysync) SyncIfNeeded(entry *cache.Entry, syncables []Syncable) error {
+	// list the secrets in every namespace we're syncing to up front, so each namespace is listed once
+	for _, namespace := range syncableNamespaces(syncables) {
+		if _, err := k.getNamespaceSecrets(namespace); err != nil {
+			return err
+		}
+	}
+
 	for _, syncable := range syncables {
-		syncRequired, statusHash, err := k.syncRequired(entry, syncable)
+		syncRequired, status, err := k.syncRequired(entry, syncable)
 		if err != nil {
 			return err
 		}
 		if !syncRequired {
 			continue
 		}
-		logs.Info.Printf("%s %s in %s: starting key sync", entry.Type, syncable.Name(), syncable.Namespace())
-		if err = k.syncToK8sSecret(entry, syncable); err != nil {
-			return fmt.Errorf("%s %s in %s: error syncing to K8s secret: %v", entry.Type, syncable.Name(), syncable.Namespace(), err)
-		}
-		if err = k.replicateKeyToVault(entry, syncable); err != nil {
-			return fmt.Errorf("%s %s in %s: error syncing to Vault: %v", entry.Type, syncable.Name(), syncable.Namespace(), err)
-		}
-		if err = k.replicateKeyToGSM(entry, syncable); err != nil {
-			return fmt.Errorf("%s %s in %s: error syncing to GSM: %v", entry.Type, syncable.Name(), syncable.Namespace(), err)
+		if k.options.DryRun {
+			k.logIntendedWrites(entry, syncable)
+			continue
 		}
-		if err = k.replicateKeyToGitHub(entry, syncable); err != nil {
-			return fmt.Errorf("%s %s in %s: error syncing to GitHub: %v", entry.Type, syncable.Name(), syncable.Namespace(), err)
+		logs.Info.WithFields(logs.Fields{
+			"type":      entry.Type,
+			"namespace": syncable.Namespace(),
+			"name":      syncable.Name(),
+			"event":     "key_sync",
+		}).Printf("%s %s in %s: starting key sync", entry.Type, syncable.Name(), syncable.Namespace())
+		err = k.syncToDestinations(entry, syncable, status)
+		// record the destinations that were synced even if others failed, so the next attempt only retries the
+		// ones that failed. The entry is saved by the caller when it reports the error.
+		entry.SyncStatus[statusKey(syncable)] = status.encode(syncable)
+		if err != nil {
+			return err
 		}
-		entry.SyncStatus[statusKey(syncable)] = statusHash
+	}
+
+	if k.options.DryRun {
+		return nil
 	}
 
 	pruneOldSyncStatuses(entry, syncables...)
@@ -141,75 +280,256 @@ func (k *keysync) SyncIfNeeded(entry *cache.Entry, syncables []Syncable) error {
 	return nil
 }
 
+// syncToDestinations syncs the key to each of the syncable's destinations that the status does not mark as
+// synced, marking each one as synced as it succeeds. Replications that were removed from the syncable's spec
+// are only pruned once every destination has been synced.
+func (k *keysync) syncToDestinations(entry *cache.Entry, syncable Syncable, status syncStatus) error {
+	if !status.isSynced(metrics.K8sDestination) {
+		if err := k.syncToK8sSecret(entry, syncable); err != nil {
+			metrics.SyncErrors.WithLabelValues(metrics.K8sDestination).Inc()
+			return fmt.Errorf("%s %s in %s: error syncing to K8s secret: %v", entry.Type, syncable.Name(), syncable.Namespace(), err)
+		}
+		status.markSynced(metrics.K8sDestination)
+		if len(AllSecrets(syncable)) > 0 {
+			k.auditSync(entry, syncable, metrics.K8sDestination)
+		}
+	}
+	recordSyncedSecrets(entry, syncable)
+	if !status.isSynced(metrics.TargetClusterDestination) {
+		if err := k.syncToTargetClusters(entry, syncable); err != nil {
+			metrics.SyncErrors.WithLabelValues(metrics.TargetClusterDestination).Inc()
+			return fmt.Errorf("%s %s in %s: error syncing to target cluster: %v", entry.Type, syncable.Name(), syncable.Namespace(), err)
+		}
+		status.markSynced(metrics.TargetClusterDestination)
+		if hasTargetClusters(syncable) {
+			k.auditSync(entry, syncable, metrics.TargetClusterDestination)
+		}
+	}
+	if err := k.replicateKeyToExternalBackends(entry, syncable, status); err != nil {
+		return err
+	}
+	return k.pruneRemovedReplications(entry, syncable)
+}
+
+// externalBackend a backend outside of K8s that a syncable's key can be replicated to
+type externalBackend struct {
+	name        string
+	destination string
+	configured  bool
+	replicate   func(*keysync, *cache.Entry, Syncable) error
+}
+
+// externalBackends returns every backend outside of K8s, and whether the syncable has replications to it
+func externalBackends(syncable Syncable) []externalBackend {
+	return []externalBackend{
+		{"Vault", metrics.VaultDestination, len(syncable.VaultReplications()) > 0, (*keysync).replicateKeyToVault},
+		{"GSM", metrics.GSMDestination, len(syncable.GoogleSecretManagerReplications()) > 0, (*keysync).replicateKeyToGSM},
+		{"GitHub", metrics.GitHubDestination, len(syncable.GitHubReplications()) > 0, (*keysync).replicateKeyToGitHub},
+		{"GitLab", metrics.GitLabDestination, len(syncable.GitLabReplications()) > 0, (*keysync).replicateKeyToGitLab},
+		{"AWS Secrets Manager", metrics.AWSDestination, len(syncable.AWSSecretsManagerReplications()) > 0, (*keysync).replicateKeyToAWS},
+		{"GCS", metrics.GCSDestination, len(syncable.GCSReplications()) > 0, (*keysync).replicateKeyToGCS},
+		{"Consul", metrics.ConsulDestination, len(syncable.ConsulReplications()) > 0, (*keysync).replicateKeyToConsul},
+	}
+}
+
+// replicateKeyToExternalBackends replicates the key to every backend outside of K8s that the status does not
+// mark as synced. A failure replicating to one backend does not prevent replication to the others; all errors
+// are returned together, and only the backends that succeeded are marked as synced.
+func (k *keysync) replicateKeyToExternalBackends(entry *cache.Entry, syncable Syncable, status syncStatus) error {
+	var errs []error
+	for _, backend := range externalBackends(syncable) {
+		if status.isSynced(backend.destination) {
+			continue
+		}
+		if backend.configured && k.destinationUnhealthy(backend.destination) {
+			errs = append(errs, fmt.Errorf("%s %s in %s: not syncing to %s: %w", entry.Type, syncable.Name(), syncable.Namespace(), backend.name, ErrDestinationUnhealthy))
+			continue
+		}
+		if err := backend.replicate(k, entry, syncable); err != nil {
+			metrics.SyncErrors.WithLabelValues(backend.destination).Inc()
+			k.recordDestinationFailure(backend.destination)
+			errs = append(errs, fmt.Errorf("%s %s in %s: error syncing to %s: %v", entry.Type, syncable.Name(), syncable.Namespace(), backend.name, err))
+			continue
+		}
+		if backend.configured {
+			status.markSynced(backend.destination)
+			k.auditSync(entry, syncable, backend.destination)
+		}
+	}
+	return joinErrors(errs...)
+}
+
+// auditSync records in the audit log that the current key was synced to the destination for the syncable
+func (k *keysync) auditSync(entry *cache.Entry, syncable Syncable, destination string) {
+	k.options.AuditLog.Log(audit.Sync, entry, entry.CurrentKey.ID, destination, syncable.Namespace()+"/"+syncable.Name())
+}
+
+// hasTargetClusters returns true if any of the syncable's secrets are synced to additional clusters
+func hasTargetClusters(syncable Syncable) bool {
+	for _, spec := range AllSecrets(syncable) {
+		if len(spec.TargetClusters) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // syncRequired determine if a gsk needs to be synced from its cache entry to its k8s secret.
 // this is true if:
 // - the secret does not exist
+// - the secret does not exist in one of the additional clusters it targets
 // - the secret exists, but the gsk's spec has changed since the last sync
 // - the secret exists, but the service account key has been rotated since the last sync
+// - the last sync only succeeded for some of the gsk's destinations (only those that failed are synced again)
+// - read-verification is enabled and one of the gsk's Vault paths has drifted from the current key
+// - the ForceResync option is enabled
 //
-// note that the latter two conditions are detected by computing the gsk's status hash and comparing
-// it to the one stored in the cache entry's status map.
+// note that the spec, key, and partial sync conditions are detected by computing the gsk's status hash and
+// comparing it to the one stored in the cache entry's status map.
 //
-// this method also returns the computed status hash, which is used to update the cache entry's SyncStatus map
-// after a successful sync
-func (k *keysync) syncRequired(entry *cache.Entry, syncable Syncable) (bool, string, error) {
+// this method also returns the gsk's sync status for the computed status hash, in which the destinations that
+// are already up-to-date are marked as synced. It is used to skip those destinations, and to update the cache
+// entry's SyncStatus map after the sync.
+func (k *keysync) syncRequired(entry *cache.Entry, syncable Syncable) (bool, syncStatus, error) {
 	// compute the statusHash for the gsk
 	computedHash, err := computeStatusHash(entry, syncable)
 	if err != nil {
-		return false, "", err
+		return false, syncStatus{}, err
 	}
 
-	// first, check if the secret exists. If it was deleted (eg. manually in the UI),
+	if k.options.ForceResync {
+		logs.Info.Printf("%s %s in %s: force resync is enabled, key sync is needed", entry.Type, syncable.Name(), syncable.Namespace())
+		return true, newSyncStatus(computedHash), nil
+	}
+
+	// first, check if the secrets exist. If one was deleted (eg. manually in the UI),
 	// Yale should absolutely perform a sync
-	secretExists, err := k.clusterHasSecret(syncable)
+	for _, secret := range AllSecrets(syncable) {
+		secretExists, err := k.clusterHasSecret(syncable, secret)
+		if err != nil {
+			return false, syncStatus{}, err
+		}
+		if !secretExists {
+			logs.Info.Printf("%s %s in %s: secret %s does not exist, key sync is needed", entry.Type, syncable.Name(), syncable.Namespace(), secret.Name)
+			return true, newSyncStatus(computedHash), nil
+		}
+		secretExists, err = k.targetClustersHaveSecret(syncable, secret)
+		if err != nil {
+			return false, syncStatus{}, err
+		}
+		if !secretExists {
+			logs.Info.Printf("%s %s in %s: secret %s does not exist in all target clusters, key sync is needed", entry.Type, syncable.Name(), syncable.Namespace(), secret.Name)
+			return true, newSyncStatus(computedHash), nil
+		}
+	}
+
+	cachedStatus := entry.SyncStatus[statusKey(syncable)]
+
+	logs.Debug.Printf("%s %s in %s: sync status should be %q, is %q", entry.Type, syncable.Name(), syncable.Namespace(), computedHash, cachedStatus)
+	status := parseSyncStatus(cachedStatus, syncable).forHash(computedHash)
+	if !status.complete(syncable) {
+		return true, status, nil
+	}
+
+	drifted, err := k.vaultReplicationsDrifted(entry, syncable)
 	if err != nil {
-		return false, "", err
+		return false, syncStatus{}, err
 	}
-	if !secretExists {
-		logs.Info.Printf("%s %s in %s: secret %s does not exist, key sync is needed", entry.Type, syncable.Name(), syncable.Namespace(), syncable.SecretName())
-		return true, computedHash, nil
+	if drifted {
+		return true, newSyncStatus(computedHash), nil
 	}
+	return false, status, nil
+}
 
-	cachedHash := entry.SyncStatus[statusKey(syncable)]
+// vaultReplicationsDrifted returns true if read-verification of Vault replications is enabled
+// and any of the syncable's Vault paths do not contain the data Yale expects to find there
+// (eg. because someone manually overwrote the path)
+func (k *keysync) vaultReplicationsDrifted(entry *cache.Entry, syncable Syncable) (bool, error) {
+	if !k.options.VerifyVaultReplications || k.options.DisableVaultReplication {
+		return false, nil
+	}
+	if len(syncable.VaultReplications()) > 0 && k.vault == nil {
+		return false, fmt.Errorf("%s %s in %s: %v", entry.Type, syncable.Name(), syncable.Namespace(), errNoVaultClient)
+	}
 
-	logs.Info.Printf("%s %s in %s: sync status should be %q, is %q", entry.Type, syncable.Name(), syncable.Namespace(), computedHash, cachedHash)
-	if cachedHash == computedHash {
-		return false, computedHash, nil
+	for _, spec := range syncable.VaultReplications() {
+		if spec.Wrap {
+			// the wrapped key can only be read back by unwrapping it, which would consume the token,
+			// so only check that a wrapping token is still there
+			actual, err := k.readVaultReplication(spec)
+			if err != nil {
+				return false, fmt.Errorf("%s %s in %s: error reading Vault path %s: %v", entry.Type, syncable.Name(), syncable.Namespace(), spec.Path, err)
+			}
+			if actual == nil || actual[vaultWrappingTokenField] == nil {
+				logs.Info.Printf("%s %s in %s: Vault path %s does not contain a wrapping token, key sync is needed", entry.Type, syncable.Name(), syncable.Namespace(), spec.Path)
+				return true, nil
+			}
+			continue
+		}
+		expected, err := prepareVaultSecret(entry, spec)
+		if err != nil {
+			return false, fmt.Errorf("%s %s in %s: error decoding key for Vault path %s: %v", entry.Type, syncable.Name(), syncable.Namespace(), spec.Path, err)
+		}
+		actual, err := k.readVaultReplication(spec)
+		if err != nil {
+			return false, fmt.Errorf("%s %s in %s: error reading Vault path %s: %v", entry.Type, syncable.Name(), syncable.Namespace(), spec.Path, err)
+		}
+		if actual == nil || !reflect.DeepEqual(actual, expected) {
+			logs.Info.Printf("%s %s in %s: Vault path %s does not contain the current key, key sync is needed", entry.Type, syncable.Name(), syncable.Namespace(), spec.Path)
+			return true, nil
+		}
 	}
-	return true, computedHash, nil
+	return false, nil
 }
 
+// syncToK8sSecret writes the current key to the syncable's secret, as well as any additional secrets in its spec.
+// It is a no-op if the syncable has no secret and no additional secrets.
 func (k *keysync) syncToK8sSecret(entry *cache.Entry, syncable Syncable) error {
+	for _, spec := range AllSecrets(syncable) {
+		if err := writeK8sSecret(k.k8s, entry, syncable, spec, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeK8sSecret writes the current key to the given secret of the syncable using the given K8s client. If targetCluster
+// is empty, the secret is written to the local cluster and is owned by the syncable; otherwise, it is labeled
+// and annotated so it can be pruned later, since owner references don't work across clusters.
+func writeK8sSecret(k8s kubernetes.Interface, entry *cache.Entry, syncable Syncable, spec apiv1b1.Secret, targetCluster string) error {
 	namespace := syncable.Namespace()
 
-	secret, err := k.k8s.CoreV1().Secrets(namespace).Get(context.Background(), syncable.SecretName(), metav1.GetOptions{})
+	secret, err := k8s.CoreV1().Secrets(namespace).Get(context.Background(), spec.Name, metav1.GetOptions{})
 	var create bool
 
 	if err != nil {
 		if errors.IsNotFound(err) {
-			// Create ownership reference
-			// https://kubernetes.io/docs/concepts/overview/working-with-objects/owners-dependents
-			var ownerRef = []metav1.OwnerReference{
-				{
-					APIVersion: syncable.APIVersion(),
-					Kind:       syncable.Kind(),
-					Name:       syncable.Name(),
-					UID:        syncable.UID(),
-				},
-			}
-
 			secret = &corev1.Secret{
 				ObjectMeta: metav1.ObjectMeta{
-					Namespace:       syncable.Namespace(),
-					Name:            syncable.SecretName(),
-					OwnerReferences: ownerRef,
+					Namespace: syncable.Namespace(),
+					Name:      spec.Name,
 				},
-				Type: corev1.SecretTypeOpaque,
+				Type: secretType(spec),
+			}
+			if targetCluster == "" {
+				// Create ownership reference
+				// https://kubernetes.io/docs/concepts/overview/working-with-objects/owners-dependents
+				secret.OwnerReferences = []metav1.OwnerReference{
+					{
+						APIVersion: syncable.APIVersion(),
+						Kind:       syncable.Kind(),
+						Name:       syncable.Name(),
+						UID:        syncable.UID(),
+					},
+				}
 			}
 			create = true
 		} else {
-			return fmt.Errorf("%s %s in %s: error retrieving referenced secret %s: %v", entry.Type, syncable.Name(), syncable.Namespace(), syncable.SecretName(), err)
+			return fmt.Errorf("%s %s in %s: error retrieving referenced secret %s: %v", entry.Type, syncable.Name(), syncable.Namespace(), spec.Name, err)
 		}
+	} else if spec.Type != "" && secret.Type != secretType(spec) {
+		// K8s does not allow changing the type of an existing secret
+		return fmt.Errorf("%s %s in %s: secret %s%s has type %s, but the spec requires type %s; delete the secret so Yale can recreate it", entry.Type, syncable.Name(), syncable.Namespace(), spec.Name, inCluster(targetCluster), secret.Type, spec.Type)
 	}
 
 	// add labels and annotations to the secret if they aren't already there
@@ -220,11 +540,24 @@ func (k *keysync) syncToK8sSecret(entry *cache.Entry, syncable Syncable) error {
 		secret.Labels[k] = v
 	}
 
-	// make sure reloader annotations are added to the secret
+	// add user-supplied annotations, then make sure reloader annotations are added to the secret, unless disabled
 	if secret.Annotations == nil {
 		secret.Annotations = make(map[string]string)
 	}
-	secret.Annotations["reloader.stakater.com/match"] = "true"
+	if spec.DisableReloaderAnnotation {
+		delete(secret.Annotations, reloaderAnnotation)
+	}
+	for k, v := range spec.Annotations {
+		secret.Annotations[k] = v
+	}
+	if !spec.DisableReloaderAnnotation {
+		secret.Annotations[reloaderAnnotation] = "true"
+	}
+
+	if targetCluster != "" {
+		secret.Labels[targetClusterLabelKey] = targetClusterLabelValue
+		secret.Annotations[targetClusterOwnerAnnotation] = ownerReference(syncable)
+	}
 
 	// add the key data to the secret
 	if secret.Data == nil {
@@ -241,24 +574,52 @@ func (k *keysync) syncToK8sSecret(entry *cache.Entry, syncable Syncable) error {
 		if secret.Data == nil {
 			secret.Data = make(map[string][]byte)
 		}
-		secret.Data[syncable.Secret().JsonKeyName] = []byte(entry.CurrentKey.JSON)
-		secret.Data[syncable.Secret().PemKeyName] = []byte(pemFormatted)
+		secret.Data[spec.JsonKeyName] = []byte(entry.CurrentKey.JSON)
+		secret.Data[spec.PemKeyName] = []byte(pemFormatted)
+		if spec.Base64KeyName != "" {
+			secret.Data[spec.Base64KeyName] = []byte(base64.StdEncoding.EncodeToString([]byte(entry.CurrentKey.JSON)))
+		}
 	} else if entry.Type == cache.AzureClientSecret {
-		secret.Data[syncable.Secret().ClientSecretKeyName] = []byte(entry.CurrentKey.JSON)
+		secret.Data[spec.ClientSecretKeyName] = []byte(entry.CurrentKey.JSON)
 	}
 
+	hash, err := secretContentHash(secret.Data)
+	if err != nil {
+		return fmt.Errorf("%s %s in %s: error computing content hash for secret %s: %v", entry.Type, syncable.Name(), syncable.Namespace(), spec.Name, err)
+	}
+	secret.Annotations[KeyIDAnnotation] = entry.CurrentKey.ID
+	secret.Annotations[contentHashAnnotation] = hash
+
 	if create {
-		_, err = k.k8s.CoreV1().Secrets(syncable.Namespace()).Create(context.Background(), secret, metav1.CreateOptions{})
+		_, err = k8s.CoreV1().Secrets(syncable.Namespace()).Create(context.Background(), secret, metav1.CreateOptions{})
 	} else {
-		_, err = k.k8s.CoreV1().Secrets(syncable.Namespace()).Update(context.Background(), secret, metav1.UpdateOptions{})
+		_, err = k8s.CoreV1().Secrets(syncable.Namespace()).Update(context.Background(), secret, metav1.UpdateOptions{})
 	}
 	if err != nil {
-		return fmt.Errorf("error syncing %s %s to secret %s/%s: %v", entry.Type, entry.CurrentKey.ID, syncable.Namespace(), secret.Name, err)
+		return fmt.Errorf("error syncing %s %s to secret %s/%s%s: %v", entry.Type, entry.CurrentKey.ID, syncable.Namespace(), secret.Name, inCluster(targetCluster), err)
 	}
-	logs.Info.Printf("synced %s %s to secret %s/%s", entry.Type, entry.CurrentKey.ID, syncable.Namespace(), syncable.SecretName())
+	logs.Info.Printf("synced %s %s to secret %s/%s%s", entry.Type, entry.CurrentKey.ID, syncable.Namespace(), spec.Name, inCluster(targetCluster))
 	return nil
 }
 
+// secretContentHash returns the sha256 checksum of a secret's data, JSON-marshalled so that the checksum does
+// not depend on map ordering
+func secretContentHash(data map[string][]byte) (string, error) {
+	marshalled, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling secret data to JSON: %v", err)
+	}
+	return sha256Sum(marshalled)
+}
+
+// secretType returns the type that the given secret should be created with, defaulting to Opaque
+func secretType(spec apiv1b1.Secret) corev1.SecretType {
+	if spec.Type == "" {
+		return corev1.SecretTypeOpaque
+	}
+	return corev1.SecretType(spec.Type)
+}
+
 func (k *keysync) replicateKeyToVault(entry *cache.Entry, syncable Syncable) error {
 	if k.options.DisableVaultReplication {
 		return nil
@@ -268,8 +629,11 @@ func (k *keysync) replicateKeyToVault(entry *cache.Entry, syncable Syncable) err
 		// no replications to perform
 		return nil
 	}
+	if k.vault == nil {
+		return errNoVaultClient
+	}
 
-	for _, spec := range syncable.VaultReplications() {
+	err := forEachConcurrently(k.options.MaxConcurrentReplications, syncable.VaultReplications(), func(spec apiv1b1.VaultReplication) error {
 		msg := fmt.Sprintf("replicating key %s for %s to Vault (format %s, path %s, key %s)",
 			entry.CurrentKey.ID, entry.Identify(), spec.Format, spec.Path, spec.Key)
 		logs.Info.Print(msg)
@@ -278,9 +642,26 @@ func (k *keysync) replicateKeyToVault(entry *cache.Entry, syncable Syncable) err
 			return fmt.Errorf("error %s: decoding failed: %v", msg, err)
 		}
 
-		if _, err = k.vault.Logical().Write(spec.Path, secretData); err != nil {
+		client, err := k.vaultClientFor(spec)
+		if err != nil {
+			return fmt.Errorf("error %s: %v", msg, err)
+		}
+		if spec.Wrap {
+			if secretData, err = wrapVaultSecret(client, spec, secretData); err != nil {
+				return fmt.Errorf("error %s: %v", msg, err)
+			}
+		}
+		path, payload, err := vaultWriteRequest(client, spec, secretData)
+		if err != nil {
+			return fmt.Errorf("error %s: %v", msg, err)
+		}
+		if err = k.writeToVault(client, path, payload); err != nil {
 			return fmt.Errorf("error %s: write failed: %v", msg, err)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	logs.Info.Printf("replicated key %s for %s to %d Vault paths", entry.CurrentKey.ID, entry.Identify(), len(syncable.VaultReplications()))
@@ -288,6 +669,105 @@ func (k *keysync) replicateKeyToVault(entry *cache.Entry, syncable Syncable) err
 	return nil
 }
 
+// vaultWriteRequest returns the path and payload that should be written to Vault for the replication.
+// For KV v2 mounts, the path is rewritten to <mount>/data/<path> and the data is nested under a "data" key
+func vaultWriteRequest(client *vaultapi.Client, spec apiv1b1.VaultReplication, secretData map[string]interface{}) (string, map[string]interface{}, error) {
+	switch spec.EngineVersion {
+	case 0, 1:
+		return spec.Path, secretData, nil
+	case 2:
+		path, err := vaultKVv2DataPath(client, spec.Path)
+		if err != nil {
+			return "", nil, err
+		}
+		return path, map[string]interface{}{"data": secretData}, nil
+	default:
+		return "", nil, fmt.Errorf("unsupported KV secrets engine version: %d", spec.EngineVersion)
+	}
+}
+
+// readVaultReplication reads back the data Yale wrote for the replication, or nil if there is none
+func (k *keysync) readVaultReplication(spec apiv1b1.VaultReplication) (map[string]interface{}, error) {
+	client, err := k.vaultClientFor(spec)
+	if err != nil {
+		return nil, err
+	}
+	if !isKVv2(spec) {
+		secret, err := client.Logical().Read(spec.Path)
+		if err != nil || secret == nil {
+			return nil, err
+		}
+		return secret.Data, nil
+	}
+
+	path, err := vaultKVv2DataPath(client, spec.Path)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := client.Logical().Read(path)
+	if err != nil || secret == nil {
+		return nil, err
+	}
+	data, _ := secret.Data["data"].(map[string]interface{})
+	return data, nil
+}
+
+// vaultKVv2DataPath converts a KV v2 secret path, like "kv/my/secret", into the path for its data
+// endpoint, like "kv/data/my/secret", by looking up the path's mount in Vault
+func vaultKVv2DataPath(client *vaultapi.Client, path string) (string, error) {
+	return vaultKVv2EndpointPath(client, path, "data")
+}
+
+// vaultKVv2EndpointPath converts a KV v2 secret path into the path for the given endpoint (eg. "data"
+// or "metadata") by looking up the path's mount in Vault
+func vaultKVv2EndpointPath(client *vaultapi.Client, path string, endpoint string) (string, error) {
+	mountInfo, err := client.Logical().Read("sys/internal/ui/mounts/" + path)
+	if err != nil {
+		return "", fmt.Errorf("error looking up Vault mount for %s: %v", path, err)
+	}
+	if mountInfo == nil {
+		return "", fmt.Errorf("error looking up Vault mount for %s: no mount found", path)
+	}
+	mount, ok := mountInfo.Data["path"].(string)
+	if !ok || mount == "" {
+		return "", fmt.Errorf("error looking up Vault mount for %s: mount path missing from response", path)
+	}
+	if !strings.HasSuffix(mount, "/") {
+		mount += "/"
+	}
+	return mount + endpoint + "/" + strings.TrimPrefix(path, mount), nil
+}
+
+// vaultClientFor returns a Vault client for the replication's Vault server and Vault Enterprise namespace
+func (k *keysync) vaultClientFor(spec apiv1b1.VaultReplication) (*vaultapi.Client, error) {
+	return k.vaultClient(spec.Address, spec.Namespace)
+}
+
+// vaultClient returns a Vault client for the given Vault server address and Vault Enterprise namespace, either of
+// which may be empty to use the shared client's. The shared client is copied rather than modified, since
+// replications are performed concurrently
+func (k *keysync) vaultClient(address string, namespace string) (*vaultapi.Client, error) {
+	client, err := k.vaultClientForAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	return vaultClientForNamespace(client, namespace), nil
+}
+
+// vaultClientForNamespace returns a copy of the client that sends requests to the given namespace, or the
+// client itself if no namespace is given
+func vaultClientForNamespace(client *vaultapi.Client, namespace string) *vaultapi.Client {
+	if namespace == "" {
+		return client
+	}
+	return client.WithNamespace(namespace)
+}
+
+// isKVv2 returns true if the replication is to a KV v2 secrets engine
+func isKVv2(spec apiv1b1.VaultReplication) bool {
+	return spec.EngineVersion == 2
+}
+
 func prepareVaultSecret(entry *cache.Entry, spec apiv1b1.VaultReplication) (map[string]interface{}, error) {
 	currentKey := []byte(entry.CurrentKey.JSON)
 	base64Encoded := base64.StdEncoding.EncodeToString(currentKey)
@@ -306,6 +786,18 @@ func prepareVaultSecret(entry *cache.Entry, spec apiv1b1.VaultReplication) (map[
 		secretKey = defaultVaultReplicationSecretKey
 	}
 
+	if spec.Field != "" {
+		if spec.Format == apiv1b1.Dotenv {
+			return nil, fmt.Errorf("dotenv format is not supported for Vault replications")
+		}
+		value, err := formatKeyField(entry, spec.Field, spec.Format, "")
+		if err != nil {
+			return nil, err
+		}
+		secret[secretKey] = string(value)
+		return secret, nil
+	}
+
 	switch spec.Format {
 	case apiv1b1.Map:
 		if entry.Type == cache.AzureClientSecret {
@@ -327,6 +819,14 @@ func prepareVaultSecret(entry *cache.Entry, spec apiv1b1.VaultReplication) (map[
 			return nil, fmt.Errorf("error decoding client secret to PEM: Azure client secret is not a JSON object. PEM type vault replication is only supported for GCP service account keys")
 		}
 		secret[secretKey] = asPem
+	case apiv1b1.YAML:
+		asYaml, err := formatKeyAsYaml(entry)
+		if err != nil {
+			return nil, err
+		}
+		secret[secretKey] = asYaml
+	case apiv1b1.Dotenv:
+		return nil, fmt.Errorf("dotenv format is not supported for Vault replications")
 	default:
 		panic(fmt.Errorf("unsupported Vault replication format: %#v", spec.Format))
 	}
@@ -339,8 +839,21 @@ func (k *keysync) replicateKeyToGSM(entry *cache.Entry, syncable Syncable) error
 		// no replications to perform
 		return nil
 	}
+	if k.secretManager == nil {
+		return errNoSecretManagerClient
+	}
+
+	err := forEachConcurrently(k.options.MaxConcurrentReplications, syncable.GoogleSecretManagerReplications(), func(spec apiv1b1.GoogleSecretManagerReplication) error {
+		if err := spec.Validate(); err != nil {
+			return fmt.Errorf("%s/%s: invalid GSM replication for secret %s: %v", syncable.Namespace(), syncable.Name(), spec.Secret, err)
+		}
+
+		secretName, err := gsmSecretName(entry, syncable, spec)
+		if err != nil {
+			return fmt.Errorf("%s/%s: %v", syncable.Namespace(), syncable.Name(), err)
+		}
+		spec.Secret = secretName
 
-	for _, spec := range syncable.GoogleSecretManagerReplications() {
 		msg := fmt.Sprintf("replicating key %s for %s (format %s) to GSM (project %s, secret %s)",
 			entry.CurrentKey.ID, entry.Identify(), spec.Format, spec.Project, spec.Secret)
 		logs.Info.Print(msg)
@@ -350,76 +863,95 @@ func (k *keysync) replicateKeyToGSM(entry *cache.Entry, syncable Syncable) error
 			return fmt.Errorf("error %s: decoding failed: %v", msg, err)
 		}
 
-		itr := k.secretManager.ListSecrets(context.Background(), &secretmanagerpb.ListSecretsRequest{
-			Parent: fmt.Sprintf("projects/%s", spec.Project),
-			Filter: fmt.Sprintf("name:%s", spec.Secret),
-		})
+		existing, err := k.findGSMSecret(spec.Project, spec.Secret)
+		if err != nil {
+			return err
+		}
 
-		// there can only be between 0 and 1 secrets that match the filter
-		var secrets []*secretmanagerpb.Secret
-		for {
-			secret, err := itr.Next()
-			if err == iterator.Done {
-				break
-			}
-			if err != nil {
-				return fmt.Errorf("error searching GSM API for secret %s in project %s: %v", spec.Secret, spec.Project, err)
+		if existing != nil && gsmSecretOwnedByYale(existing) {
+			if owner := existing.GetAnnotations()[gsmOwnerAnnotation]; owner != "" && owner != entry.Identify() {
+				return fmt.Errorf("%s/%s: conflict: GSM secret %s in project %s holds the key for %s, refusing to replicate the key for %s to it (is another resource using the same secret?)",
+					syncable.Namespace(), syncable.Name(), spec.Secret, spec.Project, owner, entry.Identify())
 			}
-			secrets = append(secrets, secret)
 		}
 
-		if len(secrets) == 0 {
+		// only prune versions from secrets Yale created, since someone else may depend on older versions
+		createdByYale := existing == nil || gsmSecretCreatedByYale(existing)
+
+		if existing == nil {
 			logs.Info.Printf("found no secret %s in project %s, creating...",
 				spec.Secret, spec.Project)
 
-			_, err = k.secretManager.CreateSecret(context.Background(), &secretmanagerpb.CreateSecretRequest{
-				Parent:   fmt.Sprintf("projects/%s", spec.Project),
-				SecretId: spec.Secret,
-				Secret: &secretmanagerpb.Secret{
-					Name: spec.Secret,
-					Annotations: map[string]string{
-						"created-by-yale": "true",
+			err = k.retryGSM(fmt.Sprintf("create GSM secret %s in project %s", spec.Secret, spec.Project), func() error {
+				_, err := k.secretManager.CreateSecret(context.Background(), &secretmanagerpb.CreateSecretRequest{
+					Parent:   fmt.Sprintf("projects/%s", spec.Project),
+					SecretId: spec.Secret,
+					Secret: &secretmanagerpb.Secret{
+						Name:        spec.Secret,
+						Annotations: gsmSecretAnnotations(entry, spec),
+						Labels:      gsmSecretLabels(spec),
+						Replication: gsmReplicationPolicy(spec),
 					},
-					Labels: map[string]string{
-						"owned_by": "yale",
-					},
-					Replication: &secretmanagerpb.Replication{
-						Replication: &secretmanagerpb.Replication_Automatic_{
-							Automatic: &secretmanagerpb.Replication_Automatic{},
-						},
-					},
-				},
+				})
+				return err
 			})
 			if err != nil {
 				return fmt.Errorf("error creating new GSM secret %s in project %s: %v", spec.Secret, spec.Project, err)
 			}
 		}
 
-		logs.Info.Printf("pulling latest GSM secret version for %s in project %s", spec.Secret, spec.Project)
-		secretVersion, err := k.secretManager.AccessSecretVersion(context.Background(), &secretmanagerpb.AccessSecretVersionRequest{
-			Name: fmt.Sprintf("projects/%s/secrets/%s/versions/latest", spec.Project, spec.Secret),
+		logs.Debug.Printf("pulling latest GSM secret version for %s in project %s", spec.Secret, spec.Project)
+		var secretVersion *secretmanagerpb.AccessSecretVersionResponse
+		err = k.retryGSM(fmt.Sprintf("access latest version of GSM secret %s in project %s", spec.Secret, spec.Project), func() error {
+			secretVersion, err = k.secretManager.AccessSecretVersion(context.Background(), &secretmanagerpb.AccessSecretVersionRequest{
+				Name: fmt.Sprintf("projects/%s/secrets/%s/versions/latest", spec.Project, spec.Secret),
+			})
+			return err
 		})
 		if err != nil {
 			logs.Info.Printf("received error pulling latest GSM secret version for %s in %s; assuming secret has no versions: %v", spec.Secret, spec.Project, err)
 		} else {
 			if bytes.Equal(secretVersion.GetPayload().GetData(), secretData) {
-				logs.Info.Printf("GSM secret %s in %s already contains the desired data, won't create a new secret version", spec.Secret, spec.Project)
-				continue
+				logs.Debug.Printf("GSM secret %s in %s already contains the desired data, won't create a new secret version", spec.Secret, spec.Project)
+				return nil
+			}
+		}
+
+		if existing != nil && !gsmSecretOwnedByYale(existing) {
+			if !k.options.AdoptUnownedSecrets {
+				return fmt.Errorf("GSM secret %s in project %s already exists, but does not have the %s: yale label, so it may be managed by something else; refusing to add a new version (add the label to the secret to let Yale manage it)", spec.Secret, spec.Project, gsmOwnedByLabel)
 			}
+			logs.Warn.Printf("GSM secret %s in project %s does not have the %s: yale label, adopting it anyway", spec.Secret, spec.Project, gsmOwnedByLabel)
 		}
 
 		logs.Info.Printf("creating new GSM secret version for %s in project %s", spec.Secret, spec.Project)
-		newVersion, err := k.secretManager.AddSecretVersion(context.Background(), &secretmanagerpb.AddSecretVersionRequest{
-			Parent: fmt.Sprintf("projects/%s/secrets/%s", spec.Project, spec.Secret),
-			Payload: &secretmanagerpb.SecretPayload{
-				Data: secretData,
-			},
+		var newVersion *secretmanagerpb.SecretVersion
+		err = k.retryGSM(fmt.Sprintf("add version to GSM secret %s in project %s", spec.Secret, spec.Project), func() error {
+			newVersion, err = k.secretManager.AddSecretVersion(context.Background(), &secretmanagerpb.AddSecretVersionRequest{
+				Parent: fmt.Sprintf("projects/%s/secrets/%s", spec.Project, spec.Secret),
+				Payload: &secretmanagerpb.SecretPayload{
+					Data: secretData,
+				},
+			})
+			return err
 		})
 		if err != nil {
 			return fmt.Errorf("error creating new GSM secret version for %s in project %s: %v", spec.Secret, spec.Project, err)
 		}
 
 		logs.Info.Printf("created new GSM secret version for %s in project %s: %s", spec.Secret, spec.Project, newVersion.Name)
+
+		if spec.MaxVersions > 0 {
+			if !createdByYale {
+				logs.Warn.Printf("GSM secret %s in project %s was not created by Yale, won't prune versions beyond the limit of %d", spec.Secret, spec.Project, spec.MaxVersions)
+				return nil
+			}
+			return k.pruneGSMSecretVersions(spec)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	logs.Info.Printf("replicated key %s for %s to %d GSM secrets", entry.CurrentKey.ID, entry.Identify(), len(syncable.GoogleSecretManagerReplications()))
@@ -427,12 +959,183 @@ func (k *keysync) replicateKeyToGSM(entry *cache.Entry, syncable Syncable) error
 	return nil
 }
 
+// gsmSecretName returns the name of the GSM secret the replication writes to, rendering it with the entry and
+// syncable if it is a template
+func gsmSecretName(entry *cache.Entry, syncable Syncable, spec apiv1b1.GoogleSecretManagerReplication) (string, error) {
+	return spec.RenderSecretName(apiv1b1.GSMSecretNameContext{
+		Project:    spec.Project,
+		Identifier: entry.Identify(),
+		Scope:      entry.Scope(),
+		Namespace:  syncable.Namespace(),
+		Name:       syncable.Name(),
+	})
+}
+
+// gsmSecretLabels returns the replication's labels, plus the label Yale adds to every GSM secret it creates,
+// which takes precedence over a user label with the same key
+func gsmSecretLabels(spec apiv1b1.GoogleSecretManagerReplication) map[string]string {
+	labels := make(map[string]string)
+	for key, value := range spec.Labels {
+		labels[key] = value
+	}
+	labels[gsmOwnedByLabel] = "yale"
+	return labels
+}
+
+// gsmSecretAnnotations returns the replication's annotations, plus the annotations Yale uses to recognize the
+// GSM secrets it created and whose key they hold, which take precedence over user annotations with the same keys
+func gsmSecretAnnotations(entry *cache.Entry, spec apiv1b1.GoogleSecretManagerReplication) map[string]string {
+	annotations := make(map[string]string)
+	for key, value := range spec.Annotations {
+		annotations[key] = value
+	}
+	annotations[gsmCreatedByYaleAnnotation] = "true"
+	annotations[gsmOwnerAnnotation] = entry.Identify()
+	return annotations
+}
+
+// gsmReplicationPolicy returns the replication policy for a GSM secret created by Yale: user-managed replication
+// to the replication's locations (encrypted with its KMS key, if any) if it has locations, automatic otherwise
+func gsmReplicationPolicy(spec apiv1b1.GoogleSecretManagerReplication) *secretmanagerpb.Replication {
+	if len(spec.Locations) == 0 {
+		return &secretmanagerpb.Replication{
+			Replication: &secretmanagerpb.Replication_Automatic_{
+				Automatic: &secretmanagerpb.Replication_Automatic{},
+			},
+		}
+	}
+
+	var replicas []*secretmanagerpb.Replication_UserManaged_Replica
+	for _, location := range spec.Locations {
+		replica := &secretmanagerpb.Replication_UserManaged_Replica{
+			Location: location,
+		}
+		if spec.KmsKeyName != "" {
+			replica.CustomerManagedEncryption = &secretmanagerpb.CustomerManagedEncryption{
+				KmsKeyName: spec.KmsKeyName,
+			}
+		}
+		replicas = append(replicas, replica)
+	}
+	return &secretmanagerpb.Replication{
+		Replication: &secretmanagerpb.Replication_UserManaged_{
+			UserManaged: &secretmanagerpb.Replication_UserManaged{
+				Replicas: replicas,
+			},
+		},
+	}
+}
+
+// findGSMSecret returns the GSM secret with the given name in the project, or nil if there is none
+func (k *keysync) findGSMSecret(project string, secret string) (*secretmanagerpb.Secret, error) {
+	// there can only be between 0 and 1 secrets that match the filter
+	var secrets []*secretmanagerpb.Secret
+	err := k.retryGSM(fmt.Sprintf("list GSM secret %s in project %s", secret, project), func() error {
+		itr := k.secretManager.ListSecrets(context.Background(), &secretmanagerpb.ListSecretsRequest{
+			Parent: fmt.Sprintf("projects/%s", project),
+			Filter: fmt.Sprintf("name:%s", secret),
+		})
+		secrets = nil
+		for {
+			s, err := itr.Next()
+			if err == iterator.Done {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			secrets = append(secrets, s)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error searching GSM API for secret %s in project %s: %v", secret, project, err)
+	}
+
+	if len(secrets) == 0 {
+		return nil, nil
+	}
+	return secrets[0], nil
+}
+
+// gsmSecretCreatedByYale returns true if the GSM secret has the annotation Yale adds to the secrets it creates
+func gsmSecretCreatedByYale(secret *secretmanagerpb.Secret) bool {
+	return secret.GetAnnotations()[gsmCreatedByYaleAnnotation] == "true"
+}
+
+// gsmSecretOwnedByYale returns true if the GSM secret has the label Yale adds to the secrets it creates
+func gsmSecretOwnedByYale(secret *secretmanagerpb.Secret) bool {
+	return secret.GetLabels()[gsmOwnedByLabel] == "yale"
+}
+
+// pruneGSMSecretVersions destroys the oldest versions of the GSM secret beyond the replication's version limit
+func (k *keysync) pruneGSMSecretVersions(spec apiv1b1.GoogleSecretManagerReplication) error {
+	itr := k.secretManager.ListSecretVersions(context.Background(), &secretmanagerpb.ListSecretVersionsRequest{
+		Parent: fmt.Sprintf("projects/%s/secrets/%s", spec.Project, spec.Secret),
+	})
+
+	var versions []*secretmanagerpb.SecretVersion
+	for {
+		version, err := itr.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("error listing versions of GSM secret %s in project %s: %v", spec.Secret, spec.Project, err)
+		}
+		if version.GetState() == secretmanagerpb.SecretVersion_DESTROYED {
+			continue
+		}
+		versions = append(versions, version)
+	}
+
+	if len(versions) <= spec.MaxVersions {
+		return nil
+	}
+
+	// newest first, so everything past the limit is old enough to destroy
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].GetCreateTime().AsTime().After(versions[j].GetCreateTime().AsTime())
+	})
+
+	for _, version := range versions[spec.MaxVersions:] {
+		logs.Info.Printf("destroying GSM secret version %s, which is beyond the limit of %d versions", version.GetName(), spec.MaxVersions)
+		_, err := k.secretManager.DestroySecretVersion(context.Background(), &secretmanagerpb.DestroySecretVersionRequest{
+			Name: version.GetName(),
+		})
+		if err != nil {
+			return fmt.Errorf("error destroying GSM secret version %s: %v", version.GetName(), err)
+		}
+	}
+	return nil
+}
+
 func prepareGoogleSecretManagerSecret(entry *cache.Entry, spec apiv1b1.GoogleSecretManagerReplication) ([]byte, error) {
-	formattedBytes, err := formatSecretForGitHubOrGSM(entry, spec.Format)
+	if spec.Field == "" {
+		return prepareKeyedSecret(entry, spec.Format, spec.Key)
+	}
+	value, err := formatKeyField(entry, spec.Field, spec.Format, spec.Key)
 	if err != nil {
 		return nil, err
 	}
-	if spec.Key == "" {
+	if spec.Key == "" || spec.Format == apiv1b1.Dotenv {
+		return value, nil
+	}
+	keyed, err := json.Marshal(map[string]string{spec.Key: string(value)})
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling keyed secret to JSON: %v", err)
+	}
+	return keyed, nil
+}
+
+// prepareKeyedSecret formats the entry's current key, and if a key name is given, nests the
+// formatted key in a JSON object under that name
+func prepareKeyedSecret(entry *cache.Entry, format apiv1b1.ReplicationFormat, key string) ([]byte, error) {
+	formattedBytes, err := formatSecretForGitHubOrGSM(entry, format, key)
+	if err != nil {
+		return nil, err
+	}
+	if key == "" || format == apiv1b1.Dotenv {
+		// the dotenv format uses the key as its variable name, so there's nothing to nest
 		return formattedBytes, nil
 	}
 
@@ -442,58 +1145,227 @@ func prepareGoogleSecretManagerSecret(entry *cache.Entry, spec apiv1b1.GoogleSec
 	// }
 	var keyedMap map[string]interface{}
 
-	if spec.Format == apiv1b1.JSON {
+	if format == apiv1b1.JSON {
 		var unmarshalled map[string]interface{}
 		if err := json.Unmarshal(formattedBytes, &unmarshalled); err != nil {
 			return nil, fmt.Errorf("error unmarshalling GCP key to JSON: %v", err)
 		}
 		keyedMap = map[string]interface{}{
-			spec.Key: unmarshalled,
+			key: unmarshalled,
 		}
 	} else {
 		keyedMap = map[string]interface{}{
-			spec.Key: string(formattedBytes),
+			key: string(formattedBytes),
 		}
 	}
 
 	keyedMapJSON, err := json.Marshal(keyedMap)
 	if err != nil {
-		return nil, fmt.Errorf("error marshalling GSM secret to JSON: %v", err)
+		return nil, fmt.Errorf("error marshalling keyed secret to JSON: %v", err)
 	}
 	return keyedMapJSON, nil
 }
 
+func (k *keysync) replicateKeyToAWS(entry *cache.Entry, syncable Syncable) error {
+	if len(syncable.AWSSecretsManagerReplications()) == 0 {
+		// no replications to perform
+		return nil
+	}
+	if k.options.AWSConfig == nil {
+		return errNoAWSClient
+	}
+
+	for _, spec := range syncable.AWSSecretsManagerReplications() {
+		msg := fmt.Sprintf("replicating key %s for %s (format %s) to AWS Secrets Manager (region %s, secret %s)",
+			entry.CurrentKey.ID, entry.Identify(), spec.Format, spec.Region, spec.SecretName)
+		logs.Info.Print(msg)
+
+		secretData, err := prepareKeyedSecret(entry, spec.Format, spec.Key)
+		if err != nil {
+			return fmt.Errorf("error %s: decoding failed: %v", msg, err)
+		}
+
+		client := k.awsSecretsManagerClient(spec.Region)
+
+		exists, err := awsSecretExists(client, spec.SecretName)
+		if err != nil {
+			return fmt.Errorf("error describing AWS secret %s in region %s: %v", spec.SecretName, spec.Region, err)
+		}
+
+		if !exists {
+			logs.Info.Printf("found no AWS secret %s in region %s, creating...", spec.SecretName, spec.Region)
+			_, err = client.CreateSecret(context.Background(), &secretsmanager.CreateSecretInput{
+				Name:         aws.String(spec.SecretName),
+				SecretString: aws.String(string(secretData)),
+				Tags: []awstypes.Tag{
+					{Key: aws.String("owned_by"), Value: aws.String("yale")},
+				},
+			})
+			if err != nil {
+				return fmt.Errorf("error creating new AWS secret %s in region %s: %v", spec.SecretName, spec.Region, err)
+			}
+			logs.Info.Printf("created AWS secret %s in region %s", spec.SecretName, spec.Region)
+			continue
+		}
+
+		logs.Debug.Printf("pulling current AWS secret value for %s in region %s", spec.SecretName, spec.Region)
+		current, err := client.GetSecretValue(context.Background(), &secretsmanager.GetSecretValueInput{
+			SecretId: aws.String(spec.SecretName),
+		})
+		if err != nil {
+			if !isAWSResourceNotFound(err) {
+				return fmt.Errorf("error retrieving current value of AWS secret %s in region %s: %v", spec.SecretName, spec.Region, err)
+			}
+			logs.Info.Printf("AWS secret %s in region %s has no current value", spec.SecretName, spec.Region)
+		} else if aws.ToString(current.SecretString) == string(secretData) {
+			logs.Debug.Printf("AWS secret %s in region %s already contains the desired data, won't create a new secret version", spec.SecretName, spec.Region)
+			continue
+		}
+
+		logs.Info.Printf("creating new AWS secret version for %s in region %s", spec.SecretName, spec.Region)
+		newVersion, err := client.PutSecretValue(context.Background(), &secretsmanager.PutSecretValueInput{
+			SecretId:     aws.String(spec.SecretName),
+			SecretString: aws.String(string(secretData)),
+		})
+		if err != nil {
+			return fmt.Errorf("error creating new AWS secret version for %s in region %s: %v", spec.SecretName, spec.Region, err)
+		}
+
+		logs.Info.Printf("created new AWS secret version for %s in region %s: %s", spec.SecretName, spec.Region, aws.ToString(newVersion.VersionId))
+	}
+
+	logs.Info.Printf("replicated key %s for %s to %d AWS secrets", entry.CurrentKey.ID, entry.Identify(), len(syncable.AWSSecretsManagerReplications()))
+
+	return nil
+}
+
 func (k *keysync) replicateKeyToGitHub(entry *cache.Entry, syncable Syncable) error {
 	if k.options.DisableGitHubReplication {
 		return nil
 	}
+	if len(syncable.GitHubReplications()) > 0 && k.github == nil {
+		return errNoGitHubClient
+	}
 
-	for _, r := range syncable.GitHubReplications() {
-		tokens := strings.SplitN(r.Repo, "/", 2)
-		if tokens[0] == "" || tokens[1] == "" {
-			return fmt.Errorf("invalid repository specified in %s/%s, expected format \"<org>/<repo>\", got: %q", syncable.Namespace(), syncable.Name(), r.Repo)
+	return forEachConcurrently(k.options.MaxConcurrentReplications, syncable.GitHubReplications(), func(r apiv1b1.GitHubReplication) error {
+		if err := r.Validate(); err != nil {
+			return fmt.Errorf("%s/%s: invalid GitHub replication for secret %s: %v", syncable.Namespace(), syncable.Name(), r.Secret, err)
+		}
+
+		kinds, err := gitHubSecretKinds(r)
+		if err != nil {
+			return fmt.Errorf("%s/%s: invalid GitHub replication for secret %s: %v", syncable.Namespace(), syncable.Name(), r.Secret, err)
 		}
 
+		if r.Repo == "" {
+			return k.replicateKeyToGitHubOrg(entry, syncable, r, kinds)
+		}
+
+		tokens := strings.SplitN(r.Repo, "/", 2)
 		org := tokens[0]
 		repo := tokens[1]
 
-		formatted, err := formatSecretForGitHubOrGSM(entry, r.Format)
+		formatted, err := formatSecretForGitHubOrGSM(entry, r.Format, r.Key)
 		if err != nil {
 			return fmt.Errorf("%s/%s: error formatting secret for %s/%s: %v", syncable.Namespace(), syncable.Name(), org, repo, err)
 		}
 
-		logs.Info.Printf("Writing secret for %s/%s to GitHub secret %s in repo %s (format: %s)", syncable.Namespace(), syncable.Name(), r.Secret, r.Repo, r.Format)
+		if r.Environment != "" {
+			logs.Info.Printf("Writing secret for %s/%s to GitHub secret %s in environment %s of repo %s (format: %s)", syncable.Namespace(), syncable.Name(), r.Secret, r.Environment, r.Repo, r.Format)
+
+			err = k.github.WriteEnvironmentSecret(org, repo, r.Environment, r.Secret, formatted)
+			if err != nil {
+				return fmt.Errorf("%s/%s: error writing GitHub secret %s in environment %s of repo %s/%s: %v", syncable.Namespace(), syncable.Name(), r.Secret, r.Environment, org, repo, err)
+			}
+			return nil
+		}
+
+		for _, kind := range kinds {
+			logs.Info.Printf("Writing secret for %s/%s to GitHub %s secret %s in repo %s (format: %s)", syncable.Namespace(), syncable.Name(), kind, r.Secret, r.Repo, r.Format)
+
+			err = k.github.WriteSecret(org, repo, r.Secret, kind, formatted)
+			if err != nil {
+				return fmt.Errorf("%s/%s: error writing GitHub %s secret %s in repo %s/%s: %v", syncable.Namespace(), syncable.Name(), kind, r.Secret, org, repo, err)
+			}
+		}
+		return nil
+	})
+}
+
+// gitHubSecretKinds returns the kinds of GitHub secret a replication writes: its kind (Actions by default), and a
+// matching Dependabot secret if required. Returns an error for unknown kinds, and for environment secrets of any
+// kind other than Actions, since only Actions supports them
+func gitHubSecretKinds(r apiv1b1.GitHubReplication) ([]github.SecretKind, error) {
+	var kinds []github.SecretKind
+	switch kind := github.SecretKind(r.Kind); kind {
+	case "", github.ActionsSecret:
+		kinds = append(kinds, github.ActionsSecret)
+	case github.CodespacesSecret:
+		if r.Environment != "" {
+			return nil, fmt.Errorf("environment secrets are only supported for GitHub Actions, but kind is %q", r.Kind)
+		}
+		kinds = append(kinds, kind)
+	default:
+		return nil, fmt.Errorf("unsupported GitHub secret kind %q, expected one of %q or %q", r.Kind, github.ActionsSecret, github.CodespacesSecret)
+	}
+	if r.RequiredByDependabot {
+		kinds = append(kinds, github.DependabotSecret)
+	}
+	return kinds, nil
+}
+
+// replicateKeyToGitHubOrg writes organization-level GitHub secrets of the given kinds
+func (k *keysync) replicateKeyToGitHubOrg(entry *cache.Entry, syncable Syncable, r apiv1b1.GitHubReplication, kinds []github.SecretKind) error {
+	formatted, err := formatSecretForGitHubOrGSM(entry, r.Format, r.Key)
+	if err != nil {
+		return fmt.Errorf("%s/%s: error formatting secret for org %s: %v", syncable.Namespace(), syncable.Name(), r.Org, err)
+	}
+
+	for _, kind := range kinds {
+		logs.Info.Printf("Writing secret for %s/%s to GitHub %s org secret %s in org %s (format: %s, visibility: %s)", syncable.Namespace(), syncable.Name(), kind, r.Secret, r.Org, r.Format, r.Visibility)
+
+		err = k.github.WriteOrgSecret(r.Org, r.Secret, kind, r.Visibility, formatted, r.SelectedRepositoryIDs)
+		if err != nil {
+			return fmt.Errorf("%s/%s: error writing GitHub %s org secret %s in org %s: %v", syncable.Namespace(), syncable.Name(), kind, r.Secret, r.Org, err)
+		}
+	}
+	return nil
+}
+
+func (k *keysync) replicateKeyToGitLab(entry *cache.Entry, syncable Syncable) error {
+	if k.options.DisableGitLabReplication {
+		return nil
+	}
+	if len(syncable.GitLabReplications()) > 0 && k.gitlab == nil {
+		return errNoGitLabClient
+	}
 
-		err = k.github.WriteSecret(org, repo, r.Secret, r.RequiredByDependabot, formatted)
+	for _, r := range syncable.GitLabReplications() {
+		formatted, err := formatSecretForGitHubOrGSM(entry, r.Format, "")
 		if err != nil {
-			return fmt.Errorf("%s/%s: error writing GitHub secret %s in repo %s/%s: %v", syncable.Namespace(), syncable.Name(), r.Secret, org, repo, err)
+			return fmt.Errorf("%s/%s: error formatting secret for GitLab project %s: %v", syncable.Namespace(), syncable.Name(), r.Project, err)
+		}
+
+		logs.Info.Printf("Writing secret for %s/%s to GitLab CI/CD variable %s in project %s (format: %s)", syncable.Namespace(), syncable.Name(), r.VariableKey, r.Project, r.Format)
+
+		err = k.gitlab.WriteVariable(r.Project, r.VariableKey, r.Environment, r.Protected, r.Masked, formatted)
+		if err != nil {
+			return fmt.Errorf("%s/%s: error writing GitLab CI/CD variable %s in project %s: %v", syncable.Namespace(), syncable.Name(), r.VariableKey, r.Project, err)
 		}
 	}
 
 	return nil
 }
 
-func formatSecretForGitHubOrGSM(entry *cache.Entry, format apiv1b1.ReplicationFormat) ([]byte, error) {
+// FormatCurrentKey returns the entry's current key in the given format, using the same formatting
+// as GSM and GitHub replications. The Map and Dotenv formats are not supported.
+func FormatCurrentKey(entry *cache.Entry, format apiv1b1.ReplicationFormat) ([]byte, error) {
+	return formatSecretForGitHubOrGSM(entry, format, "")
+}
+
+// formatSecretForGitHubOrGSM returns the entry's current key in the given format. key is only used by the
+// dotenv format, as the variable name
+func formatSecretForGitHubOrGSM(entry *cache.Entry, format apiv1b1.ReplicationFormat, key string) ([]byte, error) {
 	asJSONString := entry.CurrentKey.JSON
 	asJSONBytes := []byte(asJSONString)
 	var asPem string
@@ -524,6 +1396,18 @@ func formatSecretForGitHubOrGSM(entry *cache.Entry, format apiv1b1.ReplicationFo
 			return nil, fmt.Errorf("error decoding client secret to PEM: Azure client secret is not a JSON object. PEM type vault replication is only supported for GCP service account keys")
 		}
 		encodedValue = asPem
+	case apiv1b1.YAML:
+		asYaml, err := formatKeyAsYaml(entry)
+		if err != nil {
+			return nil, err
+		}
+		encodedValue = asYaml
+	case apiv1b1.Dotenv:
+		asDotenv, err := formatDotenv(key, asJSONString)
+		if err != nil {
+			return nil, err
+		}
+		encodedValue = asDotenv
 	default:
 		panic(fmt.Errorf("unsupported replication format for GSM and GitHub: %#v", format.String()))
 	}
@@ -531,6 +1415,37 @@ func formatSecretForGitHubOrGSM(entry *cache.Entry, format apiv1b1.ReplicationFo
 	return []byte(encodedValue), nil
 }
 
+// return a cache entry's JSON-formatted SA key re-serialized as YAML. Azure client secrets
+// aren't JSON objects, so they are returned as-is, like the plaintext format
+func formatKeyAsYaml(entry *cache.Entry) (string, error) {
+	if entry.Type == cache.AzureClientSecret {
+		return entry.CurrentKey.JSON, nil
+	}
+	asYaml, err := yaml.JSONToYAML([]byte(entry.CurrentKey.JSON))
+	if err != nil {
+		return "", fmt.Errorf("error converting private key to YAML: %v", err)
+	}
+	return string(asYaml), nil
+}
+
+// dotenvVariableName matches valid environment variable names
+var dotenvVariableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// dotenvEscaper escapes characters that are special inside a double-quoted dotenv value
+var dotenvEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, `$`, `\$`)
+
+// return a `<KEY>="<value>"` dotenv line, with the value quoted and escaped so that multi-line
+// keys fit on a single line
+func formatDotenv(key string, value string) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("dotenv format requires a key to use as the variable name")
+	}
+	if !dotenvVariableName.MatchString(key) {
+		return "", fmt.Errorf("invalid dotenv variable name %q: must contain only letters, digits, and underscores, and not start with a digit", key)
+	}
+	return fmt.Sprintf("%s=\"%s\"", key, dotenvEscaper.Replace(value)), nil
+}
+
 // return the PEM-formatted private_key field from a cache entry's JSON-formatted SA key
 func extractPemKey(entry *cache.Entry) (string, error) {
 	asJson := []byte(entry.CurrentKey.JSON)
@@ -545,6 +1460,47 @@ func extractPemKey(entry *cache.Entry) (string, error) {
 	return k.PrivateKey, nil
 }
 
+// return the value of a top-level field of a cache entry's JSON-formatted SA key, for replications of just
+// part of the key. String values are returned as-is, and any other values as JSON
+func extractKeyField(entry *cache.Entry, field string) (string, error) {
+	if entry.Type != cache.GcpSaKey {
+		return "", fmt.Errorf("field %q is only supported for GCP service account keys, but %s is a %s", field, entry.Identify(), entry.Type)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(entry.CurrentKey.JSON), &fields); err != nil {
+		return "", fmt.Errorf("failed to decode key %s (%s) from JSON: %v", entry.CurrentKey.ID, entry.Identify(), err)
+	}
+	raw, exists := fields[field]
+	if !exists {
+		return "", fmt.Errorf("key %s (%s) has no field %q", entry.CurrentKey.ID, entry.Identify(), field)
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return string(raw), nil
+	}
+	return value, nil
+}
+
+// return the value of a top-level field of a cache entry's SA key in the given format. Only the formats that
+// apply to a single value are supported. key is only used by the dotenv format, as the variable name
+func formatKeyField(entry *cache.Entry, field string, format apiv1b1.ReplicationFormat, key string) ([]byte, error) {
+	value, err := extractKeyField(entry, field)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case apiv1b1.PlainText:
+		return []byte(value), nil
+	case apiv1b1.Base64:
+		return []byte(base64.StdEncoding.EncodeToString([]byte(value))), nil
+	case apiv1b1.Dotenv:
+		formatted, err := formatDotenv(key, value)
+		return []byte(formatted), err
+	default:
+		return nil, fmt.Errorf("%s format is not supported when replicating field %q; use plaintext or base64", format, field)
+	}
+}
+
 // prune references to old gsks that no longer exists from the sync status map
 // We do this because K8s imposes a size limit of 1mb on secrets, and in
 // BEE clusters new BEEs with unique names are constantly being created and deleted
@@ -564,6 +1520,18 @@ func pruneOldSyncStatuses(entry *cache.Entry, syncables ...Syncable) {
 			delete(entry.SyncStatus, key)
 		}
 	}
+	for key := range entry.SyncedDestinations {
+		_, exists := keepKeys[key]
+		if !exists {
+			delete(entry.SyncedDestinations, key)
+		}
+	}
+	for key := range entry.SyncedSecrets {
+		_, exists := keepKeys[key]
+		if !exists {
+			delete(entry.SyncedSecrets, key)
+		}
+	}
 }
 
 // compute the expected status map value for a given gsk, which is the sha256 checksum
@@ -597,9 +1565,24 @@ func statusKey(syncable Syncable) string {
 	return qualifiedName(syncable.Namespace(), syncable.Name())
 }
 
-// return the key for a secret in the secrets map in the form "<namespace>/<name>"
-func secretKeyForGsk(syncable Syncable) string {
-	return qualifiedName(syncable.Namespace(), syncable.SecretName())
+// return the key for one of a gsk's secrets in the secrets map in the form "<namespace>/<name>"
+func secretKeyForGsk(syncable Syncable, spec apiv1b1.Secret) string {
+	return qualifiedName(syncable.Namespace(), spec.Name)
+}
+
+// AllSecrets returns the syncable's secret, unless it omits one, followed by any additional secrets in its spec
+func AllSecrets(syncable Syncable) []apiv1b1.Secret {
+	var secrets []apiv1b1.Secret
+	if hasK8sSecret(syncable) {
+		secrets = append(secrets, syncable.Secret())
+	}
+	return append(secrets, syncable.AdditionalSecrets()...)
+}
+
+// hasK8sSecret returns false if the syncable omits its secret, meaning its key should only be
+// replicated to external backends (Vault, GSM, etc.)
+func hasK8sSecret(syncable Syncable) bool {
+	return syncable.Secret().Name != ""
 }
 
 // return the key for a secret in the secrets map in the form "<namespace>/<name>"
@@ -612,38 +1595,58 @@ func qualifiedName(namespace string, name string) string {
 	return namespace + "/" + name
 }
 
-// clusterHasSecret returns true if the secret specified in the gsk's secret spec
-// exists in the cluster, false otherwise
-func (k *keysync) clusterHasSecret(syncable Syncable) (bool, error) {
-	secrets, err := k.getClusterSecrets()
+// clusterHasSecret returns true if the given secret of the gsk
+// exists in the cluster, false otherwise. A secret with no name is not applicable,
+// so it is treated as existing.
+func (k *keysync) clusterHasSecret(syncable Syncable, spec apiv1b1.Secret) (bool, error) {
+	if spec.Name == "" {
+		return true, nil
+	}
+	secrets, err := k.getNamespaceSecrets(syncable.Namespace())
 	if err != nil {
 		return false, err
 	}
-	_, exists := secrets[secretKeyForGsk(syncable)]
+	_, exists := secrets[secretKeyForGsk(syncable, spec)]
 	return exists, nil
 }
 
-// getClusterSecrets memoized method that returns a set of the names of all secrets in the cluster,
+// getNamespaceSecrets memoized method that returns a set of the names of all secrets in the namespace,
 // as a map with keys in the form "<namespace>/<name>"
-func (k *keysync) getClusterSecrets() (map[string]struct{}, error) {
+func (k *keysync) getNamespaceSecrets(namespace string) (map[string]struct{}, error) {
 	k.mutex.Lock()
 	defer k.mutex.Unlock()
 
-	if k.clusterSecrets != nil {
-		return k.clusterSecrets, nil
+	if secrets, exists := k.clusterSecrets[namespace]; exists {
+		return secrets, nil
 	}
 
-	// we intentionally use `""` for the namespace here, because we want to list all secrets in all namespaces
-	list, err := k.k8s.CoreV1().Secrets("").List(context.Background(), metav1.ListOptions{})
+	list, err := k.k8s.CoreV1().Secrets(namespace).List(context.Background(), metav1.ListOptions{})
 	if err != nil {
-		return nil, fmt.Errorf("keysync: error listing secrets in cluster: %v", err)
+		return nil, fmt.Errorf("keysync: error listing secrets in namespace %s: %v", namespace, err)
 	}
 
 	m := make(map[string]struct{})
 	for _, secret := range list.Items {
 		m[secretKey(secret)] = struct{}{}
 	}
-	k.clusterSecrets = m
+	if k.clusterSecrets == nil {
+		k.clusterSecrets = make(map[string]map[string]struct{})
+	}
+	k.clusterSecrets[namespace] = m
 
 	return m, nil
 }
+
+// syncableNamespaces returns the distinct namespaces of the given syncables, in the order they first appear
+func syncableNamespaces(syncables []Syncable) []string {
+	seen := make(map[string]struct{})
+	var namespaces []string
+	for _, syncable := range syncables {
+		if _, exists := seen[syncable.Namespace()]; exists {
+			continue
+		}
+		seen[syncable.Namespace()] = struct{}{}
+		namespaces = append(namespaces, syncable.Namespace())
+	}
+	return namespaces
+}
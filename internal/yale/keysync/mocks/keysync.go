@@ -22,6 +22,176 @@ func (_m *KeySync) EXPECT() *KeySync_Expecter {
 	return &KeySync_Expecter{mock: &_m.Mock}
 }
 
+// CheckNamespaces provides a mock function with given fields: syncables
+func (_m *KeySync) CheckNamespaces(syncables []keysync.Syncable) ([]error, error) {
+	ret := _m.Called(syncables)
+
+	var r0 []error
+	var r1 error
+	if rf, ok := ret.Get(0).(func([]keysync.Syncable) ([]error, error)); ok {
+		return rf(syncables)
+	}
+	if rf, ok := ret.Get(0).(func([]keysync.Syncable) []error); ok {
+		r0 = rf(syncables)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]error)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func([]keysync.Syncable) error); ok {
+		r1 = rf(syncables)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// KeySync_CheckNamespaces_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CheckNamespaces'
+type KeySync_CheckNamespaces_Call struct {
+	*mock.Call
+}
+
+// CheckNamespaces is a helper method to define mock.On call
+//   - syncables []keysync.Syncable
+func (_e *KeySync_Expecter) CheckNamespaces(syncables interface{}) *KeySync_CheckNamespaces_Call {
+	return &KeySync_CheckNamespaces_Call{Call: _e.mock.On("CheckNamespaces", syncables)}
+}
+
+func (_c *KeySync_CheckNamespaces_Call) Run(run func(syncables []keysync.Syncable)) *KeySync_CheckNamespaces_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]keysync.Syncable))
+	})
+	return _c
+}
+
+func (_c *KeySync_CheckNamespaces_Call) Return(_a0 []error, _a1 error) *KeySync_CheckNamespaces_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *KeySync_CheckNamespaces_Call) RunAndReturn(run func([]keysync.Syncable) ([]error, error)) *KeySync_CheckNamespaces_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteOrphanedSecrets provides a mock function with given fields: entry
+func (_m *KeySync) DeleteOrphanedSecrets(entry *cache.Entry) error {
+	ret := _m.Called(entry)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*cache.Entry) error); ok {
+		r0 = rf(entry)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// KeySync_DeleteOrphanedSecrets_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteOrphanedSecrets'
+type KeySync_DeleteOrphanedSecrets_Call struct {
+	*mock.Call
+}
+
+// DeleteOrphanedSecrets is a helper method to define mock.On call
+//   - entry *cache.Entry
+func (_e *KeySync_Expecter) DeleteOrphanedSecrets(entry interface{}) *KeySync_DeleteOrphanedSecrets_Call {
+	return &KeySync_DeleteOrphanedSecrets_Call{Call: _e.mock.On("DeleteOrphanedSecrets", entry)}
+}
+
+func (_c *KeySync_DeleteOrphanedSecrets_Call) Run(run func(entry *cache.Entry)) *KeySync_DeleteOrphanedSecrets_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*cache.Entry))
+	})
+	return _c
+}
+
+func (_c *KeySync_DeleteOrphanedSecrets_Call) Return(_a0 error) *KeySync_DeleteOrphanedSecrets_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *KeySync_DeleteOrphanedSecrets_Call) RunAndReturn(run func(*cache.Entry) error) *KeySync_DeleteOrphanedSecrets_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PruneTargetClusterSecrets provides a mock function with given fields: syncables
+func (_m *KeySync) PruneTargetClusterSecrets(syncables []keysync.Syncable) error {
+	ret := _m.Called(syncables)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]keysync.Syncable) error); ok {
+		r0 = rf(syncables)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// KeySync_PruneTargetClusterSecrets_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PruneTargetClusterSecrets'
+type KeySync_PruneTargetClusterSecrets_Call struct {
+	*mock.Call
+}
+
+// PruneTargetClusterSecrets is a helper method to define mock.On call
+//   - syncables []keysync.Syncable
+func (_e *KeySync_Expecter) PruneTargetClusterSecrets(syncables interface{}) *KeySync_PruneTargetClusterSecrets_Call {
+	return &KeySync_PruneTargetClusterSecrets_Call{Call: _e.mock.On("PruneTargetClusterSecrets", syncables)}
+}
+
+func (_c *KeySync_PruneTargetClusterSecrets_Call) Run(run func(syncables []keysync.Syncable)) *KeySync_PruneTargetClusterSecrets_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]keysync.Syncable))
+	})
+	return _c
+}
+
+func (_c *KeySync_PruneTargetClusterSecrets_Call) Return(_a0 error) *KeySync_PruneTargetClusterSecrets_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *KeySync_PruneTargetClusterSecrets_Call) RunAndReturn(run func([]keysync.Syncable) error) *KeySync_PruneTargetClusterSecrets_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// StartRun provides a mock function with given fields:
+func (_m *KeySync) StartRun() {
+	_m.Called()
+}
+
+// KeySync_StartRun_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StartRun'
+type KeySync_StartRun_Call struct {
+	*mock.Call
+}
+
+// StartRun is a helper method to define mock.On call
+func (_e *KeySync_Expecter) StartRun() *KeySync_StartRun_Call {
+	return &KeySync_StartRun_Call{Call: _e.mock.On("StartRun")}
+}
+
+func (_c *KeySync_StartRun_Call) Run(run func()) *KeySync_StartRun_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *KeySync_StartRun_Call) Return() *KeySync_StartRun_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *KeySync_StartRun_Call) RunAndReturn(run func()) *KeySync_StartRun_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // SyncIfNeeded provides a mock function with given fields: entry, gsks
 func (_m *KeySync) SyncIfNeeded(entry *cache.Entry, gsks ...keysync.Syncable) error {
 	_va := make([]interface{}, len(gsks))
@@ -79,6 +249,49 @@ func (_c *KeySync_SyncIfNeeded_Call) RunAndReturn(run func(*cache.Entry, ...keys
 	return _c
 }
 
+// UnhealthyDestinations provides a mock function with given fields:
+func (_m *KeySync) UnhealthyDestinations() map[string]int {
+	ret := _m.Called()
+
+	var r0 map[string]int
+	if rf, ok := ret.Get(0).(func() map[string]int); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]int)
+		}
+	}
+
+	return r0
+}
+
+// KeySync_UnhealthyDestinations_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UnhealthyDestinations'
+type KeySync_UnhealthyDestinations_Call struct {
+	*mock.Call
+}
+
+// UnhealthyDestinations is a helper method to define mock.On call
+func (_e *KeySync_Expecter) UnhealthyDestinations() *KeySync_UnhealthyDestinations_Call {
+	return &KeySync_UnhealthyDestinations_Call{Call: _e.mock.On("UnhealthyDestinations")}
+}
+
+func (_c *KeySync_UnhealthyDestinations_Call) Run(run func()) *KeySync_UnhealthyDestinations_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *KeySync_UnhealthyDestinations_Call) Return(_a0 map[string]int) *KeySync_UnhealthyDestinations_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *KeySync_UnhealthyDestinations_Call) RunAndReturn(run func() map[string]int) *KeySync_UnhealthyDestinations_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 type mockConstructorTestingTNewKeySync interface {
 	mock.TestingT
 	Cleanup(func())
@@ -0,0 +1,58 @@
+package keysync
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+func (k *keysync) CheckNamespaces(syncables []Syncable) ([]error, error) {
+	var problems []error
+
+	// memoized existence of each namespace, by cluster ("" for the local cluster)
+	exists := make(map[string]map[string]bool)
+	check := func(client kubernetes.Interface, cluster string, namespace string) (bool, error) {
+		if exists[cluster] == nil {
+			exists[cluster] = make(map[string]bool)
+		}
+		if result, checked := exists[cluster][namespace]; checked {
+			return result, nil
+		}
+		_, err := client.CoreV1().Namespaces().Get(context.Background(), namespace, metav1.GetOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return false, fmt.Errorf("error retrieving namespace %s%s: %v", namespace, inCluster(cluster), err)
+		}
+		exists[cluster][namespace] = err == nil
+		return err == nil, nil
+	}
+
+	for _, syncable := range syncables {
+		for _, spec := range AllSecrets(syncable) {
+			found, err := check(k.k8s, "", syncable.Namespace())
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				problems = append(problems, fmt.Errorf("%s: namespace %s for secret %s does not exist", qualifiedName(syncable.Namespace(), syncable.Name()), syncable.Namespace(), spec.Name))
+			}
+			for _, cluster := range spec.TargetClusters {
+				client, err := k.targetClusterClient(cluster)
+				if err != nil {
+					problems = append(problems, fmt.Errorf("%s: %v", qualifiedName(syncable.Namespace(), syncable.Name()), err))
+					continue
+				}
+				found, err = check(client, cluster, syncable.Namespace())
+				if err != nil {
+					return nil, err
+				}
+				if !found {
+					problems = append(problems, fmt.Errorf("%s: namespace %s for secret %s does not exist%s", qualifiedName(syncable.Namespace(), syncable.Name()), syncable.Namespace(), spec.Name, inCluster(cluster)))
+				}
+			}
+		}
+	}
+	return problems, nil
+}
@@ -0,0 +1,86 @@
+package keysync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/broadinstitute/yale/internal/yale/cache"
+	"github.com/broadinstitute/yale/internal/yale/logs"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// recordSyncedSecrets records the syncable's K8s secrets in the local cluster in the cache entry, so they can
+// be deleted if they are orphaned
+func recordSyncedSecrets(entry *cache.Entry, syncable Syncable) {
+	var secrets []string
+	for _, spec := range AllSecrets(syncable) {
+		secrets = append(secrets, secretKeyForGsk(syncable, spec))
+	}
+	if entry.SyncedSecrets == nil {
+		entry.SyncedSecrets = make(map[string][]string)
+	}
+	if len(secrets) == 0 {
+		delete(entry.SyncedSecrets, statusKey(syncable))
+		return
+	}
+	entry.SyncedSecrets[statusKey(syncable)] = secrets
+}
+
+func (k *keysync) DeleteOrphanedSecrets(entry *cache.Entry) error {
+	owners := make([]string, 0, len(entry.SyncedSecrets))
+	for owner := range entry.SyncedSecrets {
+		owners = append(owners, owner)
+	}
+	sort.Strings(owners)
+
+	var errs []error
+	for _, owner := range owners {
+		_, ownerName, _ := strings.Cut(owner, "/")
+		for _, secret := range entry.SyncedSecrets[owner] {
+			namespace, name, _ := strings.Cut(secret, "/")
+			if err := k.deleteOrphanedSecret(entry, ownerName, namespace, name); err != nil {
+				errs = append(errs, fmt.Errorf("%s %s: error deleting orphaned secret %s: %v", entry.Type, owner, secret, err))
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	if !k.options.DryRun {
+		entry.SyncedSecrets = nil
+	}
+	return nil
+}
+
+// deleteOrphanedSecret deletes the secret, unless it no longer exists or has an owner reference to something
+// other than the resource that synced it
+func (k *keysync) deleteOrphanedSecret(entry *cache.Entry, ownerName string, namespace string, name string) error {
+	secret, err := k.k8s.CoreV1().Secrets(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		logs.Debug.Printf("orphaned secret %s/%s for %s %s no longer exists", namespace, name, entry.Type, entry.Identify())
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, ref := range secret.OwnerReferences {
+		// the kind is only set if the resource's type metadata was populated when the secret was created
+		if ref.Name != ownerName || (ref.Kind != "" && ref.Kind != entry.Type.String()) {
+			logs.Warn.Printf("not deleting orphaned secret %s/%s for %s %s because it is now owned by %s %s", namespace, name, entry.Type, entry.Identify(), ref.Kind, ref.Name)
+			return nil
+		}
+	}
+	if k.options.DryRun {
+		logs.Info.Printf("[dry run] would delete orphaned secret %s/%s for %s %s", namespace, name, entry.Type, entry.Identify())
+		return nil
+	}
+	if err = k.k8s.CoreV1().Secrets(namespace).Delete(context.Background(), name, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	logs.Info.Printf("deleted orphaned secret %s/%s for %s %s", namespace, name, entry.Type, entry.Identify())
+	return nil
+}
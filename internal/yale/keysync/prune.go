@@ -0,0 +1,209 @@
+package keysync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/broadinstitute/yale/internal/yale/cache"
+	"github.com/broadinstitute/yale/internal/yale/keysync/github"
+	"github.com/broadinstitute/yale/internal/yale/logs"
+	"github.com/googleapis/gax-go/v2/apierror"
+	"google.golang.org/grpc/codes"
+)
+
+// pruneRemovedReplications deletes the destinations the syncable was synced to on its last sync, but which are
+// no longer in its spec, and then records the syncable's current destinations in the cache entry. Deletion only
+// happens if the PruneRemovedReplications option is enabled; destinations are recorded either way.
+func (k *keysync) pruneRemovedReplications(entry *cache.Entry, syncable Syncable) error {
+	current, err := syncedDestinations(entry, syncable)
+	if err != nil {
+		return err
+	}
+	key := statusKey(syncable)
+
+	if k.options.PruneRemovedReplications {
+		var errs []error
+		for _, destination := range removedDestinations(entry.SyncedDestinations[key], current) {
+			if err := k.deleteDestination(destination); err != nil {
+				errs = append(errs, fmt.Errorf("%s %s in %s: error deleting removed %s replication: %v", entry.Type, syncable.Name(), syncable.Namespace(), destination.Backend, err))
+			}
+		}
+		if err := errors.Join(errs...); err != nil {
+			return err
+		}
+	}
+
+	if entry.SyncedDestinations == nil {
+		entry.SyncedDestinations = make(map[string][]cache.SyncedDestination)
+	}
+	entry.SyncedDestinations[key] = current
+	return nil
+}
+
+// syncedDestinations returns the Vault, GSM, and GitHub destinations in the syncable's spec, with GSM secret
+// names rendered for the entry
+func syncedDestinations(entry *cache.Entry, syncable Syncable) ([]cache.SyncedDestination, error) {
+	var result []cache.SyncedDestination
+	for _, spec := range syncable.VaultReplications() {
+		result = append(result, cache.SyncedDestination{
+			Backend:       cache.VaultDestination,
+			Path:          spec.Path,
+			EngineVersion: spec.EngineVersion,
+			Namespace:     spec.Namespace,
+			Address:       spec.Address,
+		})
+	}
+	for _, spec := range syncable.GoogleSecretManagerReplications() {
+		secretName, err := gsmSecretName(entry, syncable, spec)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, cache.SyncedDestination{
+			Backend: cache.GSMDestination,
+			Project: spec.Project,
+			Secret:  secretName,
+		})
+	}
+	for _, spec := range syncable.GitHubReplications() {
+		destination := cache.SyncedDestination{
+			Backend:     cache.GitHubDestination,
+			Secret:      spec.Secret,
+			Repo:        spec.Repo,
+			Environment: spec.Environment,
+			Org:         spec.Org,
+		}
+		// Actions secrets are recorded without a kind, as they were before other kinds were supported
+		if github.SecretKind(spec.Kind) != github.ActionsSecret {
+			destination.Kind = spec.Kind
+		}
+		result = append(result, destination)
+	}
+	return result, nil
+}
+
+// removedDestinations returns the destinations in previous that are not in current
+func removedDestinations(previous []cache.SyncedDestination, current []cache.SyncedDestination) []cache.SyncedDestination {
+	keep := make(map[cache.SyncedDestination]struct{})
+	for _, destination := range current {
+		keep[destination] = struct{}{}
+	}
+	var removed []cache.SyncedDestination
+	for _, destination := range previous {
+		if _, exists := keep[destination]; !exists {
+			removed = append(removed, destination)
+		}
+	}
+	return removed
+}
+
+// deleteDestination deletes the key from a destination that was removed from a syncable's spec. It is not an
+// error if the destination no longer exists.
+func (k *keysync) deleteDestination(destination cache.SyncedDestination) error {
+	switch destination.Backend {
+	case cache.VaultDestination:
+		return k.deleteVaultDestination(destination)
+	case cache.GSMDestination:
+		return k.deleteGSMDestination(destination)
+	case cache.GitHubDestination:
+		return k.deleteGitHubDestination(destination)
+	}
+	return fmt.Errorf("unsupported destination backend: %q", destination.Backend)
+}
+
+func (k *keysync) deleteVaultDestination(destination cache.SyncedDestination) error {
+	if k.options.DisableVaultReplication {
+		logs.Info.Printf("Vault replication is disabled, won't delete removed Vault path %s", destination.Path)
+		return nil
+	}
+
+	if k.vault == nil {
+		return errNoVaultClient
+	}
+
+	client, err := k.vaultClient(destination.Address, destination.Namespace)
+	if err != nil {
+		return err
+	}
+	path := destination.Path
+	if destination.EngineVersion == 2 {
+		// deleting the metadata removes every version of the secret, not just the latest
+		path, err = vaultKVv2EndpointPath(client, destination.Path, "metadata")
+		if err != nil {
+			return err
+		}
+	}
+
+	logs.Info.Printf("deleting removed Vault path %s", path)
+	if _, err := client.Logical().Delete(path); err != nil {
+		return fmt.Errorf("error deleting Vault path %s: %v", path, err)
+	}
+	return nil
+}
+
+func (k *keysync) deleteGSMDestination(destination cache.SyncedDestination) error {
+	existing, err := k.findGSMSecret(destination.Project, destination.Secret)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		logs.Info.Printf("removed GSM secret %s in project %s no longer exists, nothing to delete", destination.Secret, destination.Project)
+		return nil
+	}
+	// someone else may depend on a secret Yale did not create, so leave it alone
+	if !gsmSecretCreatedByYale(existing) {
+		logs.Warn.Printf("removed GSM secret %s in project %s was not created by Yale, won't delete it", destination.Secret, destination.Project)
+		return nil
+	}
+
+	logs.Info.Printf("deleting removed GSM secret %s in project %s", destination.Secret, destination.Project)
+	err = k.secretManager.DeleteSecret(context.Background(), &secretmanagerpb.DeleteSecretRequest{
+		Name: fmt.Sprintf("projects/%s/secrets/%s", destination.Project, destination.Secret),
+	})
+	if err != nil && !isGSMNotFound(err) {
+		return fmt.Errorf("error deleting GSM secret %s in project %s: %v", destination.Secret, destination.Project, err)
+	}
+	return nil
+}
+
+func (k *keysync) deleteGitHubDestination(destination cache.SyncedDestination) error {
+	if k.options.DisableGitHubReplication {
+		logs.Info.Printf("GitHub replication is disabled, won't delete removed GitHub secret %s", destination.Secret)
+		return nil
+	}
+	if k.github == nil {
+		return errNoGitHubClient
+	}
+
+	codespaces := github.SecretKind(destination.Kind) == github.CodespacesSecret
+	if destination.Repo == "" {
+		if codespaces {
+			return k.github.DeleteCodespacesOrgSecret(destination.Org, destination.Secret)
+		}
+		return k.github.DeleteOrgSecret(destination.Org, destination.Secret)
+	}
+
+	tokens := strings.SplitN(destination.Repo, "/", 2)
+	if len(tokens) != 2 {
+		return fmt.Errorf("invalid GitHub repo %q, expected <owner>/<repo>", destination.Repo)
+	}
+	if codespaces {
+		return k.github.DeleteCodespacesSecret(tokens[0], tokens[1], destination.Secret)
+	}
+	if destination.Environment != "" {
+		return k.github.DeleteEnvironmentSecret(tokens[0], tokens[1], destination.Environment, destination.Secret)
+	}
+	return k.github.DeleteSecret(tokens[0], tokens[1], destination.Secret)
+}
+
+// isGSMNotFound returns true if the error was returned because a GSM resource does not exist
+func isGSMNotFound(err error) bool {
+	var apiErr *apierror.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.HTTPCode() == http.StatusNotFound || apiErr.GRPCStatus().Code() == codes.NotFound
+}
@@ -0,0 +1,118 @@
+package keysync
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/broadinstitute/yale/internal/yale/metrics"
+)
+
+// partialSyncMarker separates the status hash from the list of destinations that were synced, in the SyncStatus
+// value of a syncable whose last sync only succeeded for some of its destinations.
+// eg. "<sha-256-sum>:<key-id>;synced=k8s,target_cluster,vault"
+const partialSyncMarker = ";synced="
+
+// syncStatus is the decoded SyncStatus value for a single syncable: the status hash it was last synced with, and
+// which of its destinations have been synced with that hash
+type syncStatus struct {
+	hash   string
+	synced map[string]struct{}
+}
+
+// newSyncStatus returns a status for the given hash in which no destinations have been synced
+func newSyncStatus(hash string) syncStatus {
+	return syncStatus{hash: hash, synced: make(map[string]struct{})}
+}
+
+// DecodeSyncStatus splits a SyncStatus value into the status hash ("<checksum>:<key id>") and, if the last sync
+// only succeeded for some of the syncable's destinations, the destinations that were synced. partial is false
+// if every destination was synced, in which case synced is nil.
+func DecodeSyncStatus(value string) (hash string, synced []string, partial bool) {
+	hash, destinations, partial := strings.Cut(value, partialSyncMarker)
+	if !partial {
+		return hash, nil, false
+	}
+	for _, destination := range strings.Split(destinations, ",") {
+		if destination != "" {
+			synced = append(synced, destination)
+		}
+	}
+	return hash, synced, true
+}
+
+// parseSyncStatus decodes a syncable's SyncStatus value. A value with no list of synced destinations (which
+// is how fully-synced syncables are recorded, and how every sync was recorded before per-destination status
+// was tracked) means every one of the syncable's destinations was synced.
+func parseSyncStatus(value string, syncable Syncable) syncStatus {
+	hash, destinations, partial := DecodeSyncStatus(value)
+	status := newSyncStatus(hash)
+	if !partial {
+		if hash != "" {
+			for _, destination := range syncDestinations(syncable) {
+				status.synced[destination] = struct{}{}
+			}
+		}
+		return status
+	}
+	for _, destination := range destinations {
+		status.synced[destination] = struct{}{}
+	}
+	return status
+}
+
+// forHash returns the status if it was recorded with the given hash, or an empty status for the hash if it
+// was not, since none of the syncable's destinations have been synced with it yet
+func (s syncStatus) forHash(hash string) syncStatus {
+	if s.hash == hash {
+		return s
+	}
+	return newSyncStatus(hash)
+}
+
+// isSynced returns true if the destination has been synced with the status hash
+func (s syncStatus) isSynced(destination string) bool {
+	_, exists := s.synced[destination]
+	return exists
+}
+
+// markSynced records that the destination has been synced with the status hash
+func (s syncStatus) markSynced(destination string) {
+	s.synced[destination] = struct{}{}
+}
+
+// complete returns true if every one of the syncable's destinations has been synced with the status hash
+func (s syncStatus) complete(syncable Syncable) bool {
+	for _, destination := range syncDestinations(syncable) {
+		if !s.isSynced(destination) {
+			return false
+		}
+	}
+	return true
+}
+
+// encode returns the SyncStatus value for the status. Complete statuses are recorded as just the status hash,
+// so they are readable by older versions of Yale and by anything that checks which key a syncable was synced with.
+func (s syncStatus) encode(syncable Syncable) string {
+	if s.complete(syncable) {
+		return s.hash
+	}
+	var destinations []string
+	for destination := range s.synced {
+		destinations = append(destinations, destination)
+	}
+	sort.Strings(destinations)
+	return s.hash + partialSyncMarker + strings.Join(destinations, ",")
+}
+
+// syncDestinations returns the destinations a syncable is synced to, named after the destination label used
+// in sync metrics. Syncing to the K8s and target cluster destinations is a no-op if the syncable has no
+// secrets or target clusters, so they are always included.
+func syncDestinations(syncable Syncable) []string {
+	destinations := []string{metrics.K8sDestination, metrics.TargetClusterDestination}
+	for _, backend := range externalBackends(syncable) {
+		if backend.configured {
+			destinations = append(destinations, backend.destination)
+		}
+	}
+	return destinations
+}
@@ -0,0 +1,136 @@
+package keysync
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/broadinstitute/yale/internal/yale/cache"
+	apiv1b1 "github.com/broadinstitute/yale/internal/yale/crd/api/v1beta1"
+	"github.com/broadinstitute/yale/internal/yale/logs"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// targetClusterLabelKey label added to secrets Yale syncs to additional clusters, so they can be found for pruning
+const targetClusterLabelKey = "yale.terra.bio/target-cluster-secret"
+const targetClusterLabelValue = "true"
+
+// targetClusterOwnerAnnotation annotation identifying the Yale resource a target cluster secret was synced from
+const targetClusterOwnerAnnotation = "yale.terra.bio/owner"
+
+// syncToTargetClusters writes each of the syncable's secrets to every additional cluster listed in that secret's spec
+func (k *keysync) syncToTargetClusters(entry *cache.Entry, syncable Syncable) error {
+	for _, spec := range AllSecrets(syncable) {
+		for _, cluster := range spec.TargetClusters {
+			client, err := k.targetClusterClient(cluster)
+			if err != nil {
+				return err
+			}
+			if err = writeK8sSecret(client, entry, syncable, spec, cluster); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// targetClustersHaveSecret returns true if the given secret of the syncable exists in every additional cluster
+// listed in the secret's spec
+func (k *keysync) targetClustersHaveSecret(syncable Syncable, spec apiv1b1.Secret) (bool, error) {
+	for _, cluster := range spec.TargetClusters {
+		client, err := k.targetClusterClient(cluster)
+		if err != nil {
+			return false, err
+		}
+		_, err = client.CoreV1().Secrets(syncable.Namespace()).Get(context.Background(), spec.Name, metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return false, nil
+			}
+			return false, fmt.Errorf("error retrieving secret %s%s: %v", secretKeyForGsk(syncable, spec), inCluster(cluster), err)
+		}
+	}
+	return true, nil
+}
+
+// PruneTargetClusterSecrets deletes secrets in target clusters whose owner is one of the given syncables, but which
+// the owner no longer references. Secrets whose owner isn't among the syncables are left alone: the owner may have
+// been skipped this run (eg. for an invalid spec, a filtered namespace, or another instance's label selector), and
+// deleting its secrets would cause an outage in the target cluster.
+func (k *keysync) PruneTargetClusterSecrets(syncables []Syncable) error {
+	// build a set of the secrets that should exist in each target cluster, and of the owners they were listed for
+	expected := make(map[string]map[string]struct{})
+	owners := make(map[string]struct{})
+	for _, syncable := range syncables {
+		owners[ownerReference(syncable)] = struct{}{}
+		for _, spec := range AllSecrets(syncable) {
+			for _, cluster := range spec.TargetClusters {
+				if expected[cluster] == nil {
+					expected[cluster] = make(map[string]struct{})
+				}
+				expected[cluster][secretKeyForGsk(syncable, spec)] = struct{}{}
+			}
+		}
+	}
+
+	// process clusters in a stable order so logs are easy to follow
+	var clusters []string
+	for cluster := range k.options.TargetClusters {
+		clusters = append(clusters, cluster)
+	}
+	sort.Strings(clusters)
+
+	for _, cluster := range clusters {
+		client := k.options.TargetClusters[cluster]
+		list, err := client.CoreV1().Secrets("").List(context.Background(), metav1.ListOptions{
+			LabelSelector: targetClusterLabelKey + "=" + targetClusterLabelValue,
+		})
+		if err != nil {
+			return fmt.Errorf("keysync: error listing secrets%s: %v", inCluster(cluster), err)
+		}
+		for _, secret := range list.Items {
+			if _, exists := expected[cluster][secretKey(secret)]; exists {
+				continue
+			}
+			owner := secret.Annotations[targetClusterOwnerAnnotation]
+			if _, listed := owners[owner]; !listed {
+				logs.Debug.Printf("won't delete secret %s%s; it was synced from %q, which was not processed in this run", secretKey(secret), inCluster(cluster), owner)
+				continue
+			}
+			if k.options.DryRun {
+				logs.Info.Printf("dry run: would delete secret %s%s; it was synced from %s, which no longer references it", secretKey(secret), inCluster(cluster), owner)
+				continue
+			}
+			logs.Info.Printf("deleting secret %s%s; it was synced from %s, which no longer references it", secretKey(secret), inCluster(cluster), owner)
+			err = client.CoreV1().Secrets(secret.Namespace).Delete(context.Background(), secret.Name, metav1.DeleteOptions{})
+			if err != nil && !errors.IsNotFound(err) {
+				return fmt.Errorf("keysync: error deleting secret %s%s: %v", secretKey(secret), inCluster(cluster), err)
+			}
+		}
+	}
+	return nil
+}
+
+// targetClusterClient returns the K8s client for the named additional cluster
+func (k *keysync) targetClusterClient(cluster string) (kubernetes.Interface, error) {
+	client, exists := k.options.TargetClusters[cluster]
+	if !exists {
+		return nil, fmt.Errorf("secret targets cluster %q, but Yale has no kubeconfig for it", cluster)
+	}
+	return client, nil
+}
+
+// ownerReference returns a string identifying the syncable, in the form "<kind>/<namespace>/<name>"
+func ownerReference(syncable Syncable) string {
+	return syncable.Kind() + "/" + qualifiedName(syncable.Namespace(), syncable.Name())
+}
+
+// inCluster returns a suffix for log and error messages identifying the target cluster, if any
+func inCluster(cluster string) string {
+	if cluster == "" {
+		return ""
+	}
+	return fmt.Sprintf(" in cluster %s", cluster)
+}
@@ -0,0 +1,216 @@
+package awssm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/broadinstitute/yale/internal/yale/logs"
+	"github.com/stretchr/testify/require"
+)
+
+// targetPrefix prefix of the X-Amz-Target header the AWS SDK sets on Secrets Manager requests
+const targetPrefix = "secretsmanager."
+
+type expectedRequest struct {
+	operation          string
+	requestBodyMatcher func(body map[string]interface{}) bool
+	responseCode       int
+	responseBody       []byte
+}
+
+// FakeAWSServer hand-rolled fake AWS Secrets Manager server that uses httptest and the AWS SDK's
+// configurable endpoint to intercept and fake requests, similar to the fake GSM server.
+//
+// Like the fake GSM server, requests may arrive in any order; each request is matched against the
+// first pending expectation it satisfies.
+type FakeAWSServer struct {
+	t                *testing.T
+	mutex            sync.Mutex
+	expectedRequests []expectedRequest
+	server           *httptest.Server
+}
+
+// ExpectDescribeSecret expect a DescribeSecret request for the given secret. If exists is false, the
+// fake will return a ResourceNotFoundException
+func (f *FakeAWSServer) ExpectDescribeSecret(secret string, exists bool) {
+	request := expectedRequest{
+		operation:          "DescribeSecret",
+		requestBodyMatcher: f.matchField("SecretId", secret),
+	}
+	if exists {
+		request.responseCode = 200
+		request.responseBody = f.marshal(map[string]interface{}{
+			"ARN":  arn(secret),
+			"Name": secret,
+		})
+	} else {
+		request.responseCode, request.responseBody = f.notFound(secret)
+	}
+	f.addExpectedRequest(request)
+}
+
+// ExpectCreateSecret expect a CreateSecret request for the given secret with the given value
+func (f *FakeAWSServer) ExpectCreateSecret(secret string, value []byte) {
+	f.addExpectedRequest(expectedRequest{
+		operation: "CreateSecret",
+		requestBodyMatcher: func(body map[string]interface{}) bool {
+			return f.matchField("Name", secret)(body) && f.matchField("SecretString", string(value))(body)
+		},
+		responseCode: 200,
+		responseBody: f.marshal(map[string]interface{}{
+			"ARN":       arn(secret),
+			"Name":      secret,
+			"VersionId": "v1",
+		}),
+	})
+}
+
+// ExpectGetSecretValue expect a GetSecretValue request for the given secret. If value is nil, the
+// fake will return a ResourceNotFoundException, as AWS does for a secret with no current version
+func (f *FakeAWSServer) ExpectGetSecretValue(secret string, value []byte) {
+	request := expectedRequest{
+		operation:          "GetSecretValue",
+		requestBodyMatcher: f.matchField("SecretId", secret),
+	}
+	if value != nil {
+		request.responseCode = 200
+		request.responseBody = f.marshal(map[string]interface{}{
+			"ARN":          arn(secret),
+			"Name":         secret,
+			"SecretString": string(value),
+			"VersionId":    "v1",
+		})
+	} else {
+		request.responseCode, request.responseBody = f.notFound(secret)
+	}
+	f.addExpectedRequest(request)
+}
+
+// ExpectPutSecretValue expect a PutSecretValue request for the given secret with the given value
+func (f *FakeAWSServer) ExpectPutSecretValue(secret string, value []byte) {
+	f.addExpectedRequest(expectedRequest{
+		operation: "PutSecretValue",
+		requestBodyMatcher: func(body map[string]interface{}) bool {
+			return f.matchField("SecretId", secret)(body) && f.matchField("SecretString", string(value))(body)
+		},
+		responseCode: 200,
+		responseBody: f.marshal(map[string]interface{}{
+			"ARN":       arn(secret),
+			"Name":      secret,
+			"VersionId": "v2",
+		}),
+	})
+}
+
+func (f *FakeAWSServer) addExpectedRequest(request expectedRequest) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.expectedRequests = append(f.expectedRequests, request)
+}
+
+func (f *FakeAWSServer) Close() {
+	f.server.Close()
+}
+
+func (f *FakeAWSServer) AssertExpectations() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	require.Empty(f.t, f.expectedRequests, "%d unmet expectations: %#v", len(f.expectedRequests), f.expectedRequests)
+}
+
+// NewConfig returns an AWS config that directs requests to the fake server
+func (f *FakeAWSServer) NewConfig() *aws.Config {
+	return &aws.Config{
+		Region:           "us-east-1",
+		Credentials:      credentials.NewStaticCredentialsProvider("fake-access-key", "fake-secret-key", ""),
+		BaseEndpoint:     aws.String(f.server.URL),
+		HTTPClient:       f.server.Client(),
+		RetryMaxAttempts: 1,
+	}
+}
+
+func (f *FakeAWSServer) httpHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target := r.Header.Get("X-Amz-Target")
+		logs.Info.Printf("received request: %s %s (%s)", r.Method, r.URL, target)
+
+		content, err := io.ReadAll(r.Body)
+		if err != nil {
+			f.t.Errorf("fake aws server: error reading request body: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		logs.Debug.Printf("Matching request body: %s", string(content))
+		var body map[string]interface{}
+		if err = json.Unmarshal(content, &body); err != nil {
+			f.t.Errorf("fake aws server: error unmarshalling request body %s: %v", string(content), err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+
+		for i, expected := range f.expectedRequests {
+			if targetPrefix+expected.operation != target {
+				continue
+			}
+			if expected.requestBodyMatcher != nil && !expected.requestBodyMatcher(body) {
+				continue
+			}
+
+			f.expectedRequests = append(f.expectedRequests[:i:i], f.expectedRequests[i+1:]...)
+
+			logs.Info.Printf("writing %d response", expected.responseCode)
+			w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+			w.WriteHeader(expected.responseCode)
+			if _, err = w.Write(expected.responseBody); err != nil {
+				f.t.Errorf("error writing response body: %v", err)
+			}
+			return
+		}
+
+		f.t.Errorf("received request %s with body %s, but it matches none of the %d pending expectations", target, string(content), len(f.expectedRequests))
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+}
+
+func (f *FakeAWSServer) matchField(field string, expected string) func(map[string]interface{}) bool {
+	return func(body map[string]interface{}) bool {
+		return body[field] == expected
+	}
+}
+
+func (f *FakeAWSServer) notFound(secret string) (int, []byte) {
+	return 400, f.marshal(map[string]interface{}{
+		"__type":  "ResourceNotFoundException",
+		"message": fmt.Sprintf("Secrets Manager can't find the specified secret: %s", secret),
+	})
+}
+
+func (f *FakeAWSServer) marshal(v interface{}) []byte {
+	content, err := json.Marshal(v)
+	require.NoError(f.t, err)
+	return content
+}
+
+func arn(secret string) string {
+	return fmt.Sprintf("arn:aws:secretsmanager:us-east-1:123456789012:secret:%s", secret)
+}
+
+func NewFakeAWSSecretsManager(t *testing.T) *FakeAWSServer {
+	fake := &FakeAWSServer{
+		t: t,
+	}
+
+	server := httptest.NewServer(fake.httpHandler())
+	fake.server = server
+	return fake
+}
@@ -0,0 +1,208 @@
+package gcs
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/broadinstitute/yale/internal/yale/logs"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+	"google.golang.org/api/storage/v1"
+)
+
+const objectsPathPrefix = "/storage/v1/b/"
+const uploadPathPrefix = "/upload/storage/v1/b/"
+
+type object struct {
+	content    []byte
+	generation int64
+}
+
+// FakeGCSServer hand-rolled fake GCS JSON API server that uses httptest and the storage client's configurable
+// endpoint to serve object metadata reads and multipart uploads.
+//
+// Unlike the fake GSM and AWS servers, it is stateful rather than expectation-based: objects are stored in
+// memory, so tests can seed objects, run a sync, and then inspect what was uploaded.
+type FakeGCSServer struct {
+	t       *testing.T
+	mutex   sync.Mutex
+	objects map[string]*object
+	uploads int
+	server  *httptest.Server
+}
+
+// SetObject creates or overwrites an object in the fake server
+func (f *FakeGCSServer) SetObject(bucket string, name string, content []byte) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.putObject(bucket, name, content)
+}
+
+// GetObject returns the content of an object in the fake server, and whether it exists
+func (f *FakeGCSServer) GetObject(bucket string, name string) ([]byte, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	obj, exists := f.objects[objectKey(bucket, name)]
+	if !exists {
+		return nil, false
+	}
+	return obj.content, true
+}
+
+// Uploads returns the number of uploads the fake server has received
+func (f *FakeGCSServer) Uploads() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.uploads
+}
+
+func (f *FakeGCSServer) Close() {
+	f.server.Close()
+}
+
+// NewService returns a storage client that directs requests to the fake server
+func (f *FakeGCSServer) NewService() *storage.Service {
+	service, err := storage.NewService(context.Background(),
+		option.WithoutAuthentication(),
+		option.WithEndpoint(f.server.URL+"/storage/v1/"),
+		option.WithHTTPClient(f.server.Client()),
+	)
+	require.NoError(f.t, err)
+	return service
+}
+
+func (f *FakeGCSServer) httpHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logs.Info.Printf("received request: %s %s", r.Method, r.URL)
+
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+
+		switch {
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, objectsPathPrefix):
+			f.handleGet(w, r)
+		case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, uploadPathPrefix):
+			f.handleUpload(w, r)
+		default:
+			f.t.Errorf("fake gcs server: unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+}
+
+// handleGet serves GET /storage/v1/b/<bucket>/o/<object>
+func (f *FakeGCSServer) handleGet(w http.ResponseWriter, r *http.Request) {
+	bucket, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, objectsPathPrefix), "/")
+	name := strings.TrimPrefix(rest, "o/")
+
+	obj, exists := f.objects[objectKey(bucket, name)]
+	if !exists {
+		f.writeError(w, http.StatusNotFound, "No such object: "+bucket+"/"+name)
+		return
+	}
+	f.writeObject(w, bucket, name, obj)
+}
+
+// handleUpload serves multipart uploads to POST /upload/storage/v1/b/<bucket>/o
+func (f *FakeGCSServer) handleUpload(w http.ResponseWriter, r *http.Request) {
+	bucket, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, uploadPathPrefix), "/")
+
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	require.NoError(f.t, err)
+	reader := multipart.NewReader(r.Body, params["boundary"])
+
+	metadataPart, err := reader.NextPart()
+	require.NoError(f.t, err)
+	var metadata storage.Object
+	require.NoError(f.t, json.NewDecoder(metadataPart).Decode(&metadata))
+
+	contentPart, err := reader.NextPart()
+	require.NoError(f.t, err)
+	content, err := io.ReadAll(contentPart)
+	require.NoError(f.t, err)
+
+	if match := r.URL.Query().Get("ifGenerationMatch"); match != "" {
+		expected, err := strconv.ParseInt(match, 10, 64)
+		require.NoError(f.t, err)
+		var current int64
+		if obj, exists := f.objects[objectKey(bucket, metadata.Name)]; exists {
+			current = obj.generation
+		}
+		if current != expected {
+			f.writeError(w, http.StatusPreconditionFailed, "At least one of the pre-conditions you specified did not hold.")
+			return
+		}
+	}
+
+	f.uploads++
+	obj := f.putObject(bucket, metadata.Name, content)
+	f.writeObject(w, bucket, metadata.Name, obj)
+}
+
+func (f *FakeGCSServer) putObject(bucket string, name string, content []byte) *object {
+	key := objectKey(bucket, name)
+	var generation int64 = 1
+	if existing, exists := f.objects[key]; exists {
+		generation = existing.generation + 1
+	}
+	obj := &object{content: content, generation: generation}
+	f.objects[key] = obj
+	return obj
+}
+
+func (f *FakeGCSServer) writeObject(w http.ResponseWriter, bucket string, name string, obj *object) {
+	sum := md5.Sum(obj.content)
+	f.writeJSON(w, http.StatusOK, storage.Object{
+		Bucket:     bucket,
+		Name:       name,
+		Generation: obj.generation,
+		Size:       uint64(len(obj.content)),
+		Md5Hash:    base64.StdEncoding.EncodeToString(sum[:]),
+	})
+}
+
+func (f *FakeGCSServer) writeError(w http.ResponseWriter, code int, message string) {
+	f.writeJSON(w, code, map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":    code,
+			"message": message,
+		},
+	})
+}
+
+func (f *FakeGCSServer) writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	content, err := json.Marshal(body)
+	require.NoError(f.t, err)
+	logs.Info.Printf("writing %d response", code)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if _, err = w.Write(content); err != nil {
+		f.t.Errorf("error writing response body: %v", err)
+	}
+}
+
+func objectKey(bucket string, name string) string {
+	return bucket + "/" + name
+}
+
+func NewFakeGCSServer(t *testing.T) *FakeGCSServer {
+	fake := &FakeGCSServer{
+		t:       t,
+		objects: make(map[string]*object),
+	}
+
+	server := httptest.NewServer(fake.httpHandler())
+	fake.server = server
+	return fake
+}
@@ -9,9 +9,12 @@ import (
 	"github.com/broadinstitute/yale/internal/yale/logs"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/api/option"
+	"google.golang.org/protobuf/encoding/protojson"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -26,10 +29,39 @@ type expectedRequest struct {
 
 // FakeGsmServer hand-rolled fake Google Secret Manager server that uses httptest and the GSM library's http support
 // to intercept and fake requests. I really wish I could just generate a mock :'(
+//
+// Requests may arrive in any order, since Yale performs replications concurrently; each request is
+// matched against the first pending expectation it satisfies.
 type FakeGsmServer struct {
 	t                *testing.T
+	mutex            sync.Mutex
 	expectedRequests []expectedRequest
 	server           *httptest.Server
+	// failures number of upcoming requests whose path ends in failurePath to fail with failureCode, instead of
+	// matching them against expectations
+	failures    int
+	failurePath string
+	failureCode int
+	// requests number of requests received, including failed ones
+	requests int
+}
+
+// FailRequests configures the server to respond to the next n requests whose path ends in pathSuffix (eg.
+// ":addVersion", or "" for any request) with the given status code (eg. 429 to simulate GSM rate limiting),
+// instead of matching them against expectations
+func (f *FakeGsmServer) FailRequests(pathSuffix string, n int, statusCode int) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.failures = n
+	f.failurePath = pathSuffix
+	f.failureCode = statusCode
+}
+
+// RequestCount returns the number of requests the server has received, including failed ones
+func (f *FakeGsmServer) RequestCount() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.requests
 }
 
 func (f *FakeGsmServer) ExpectListSecretWithNameFilter(project string, secret string, result *secretmanagerpb.Secret) {
@@ -53,7 +85,30 @@ func (f *FakeGsmServer) ExpectListSecretWithNameFilter(project string, secret st
 
 	request.responseBody = responseBody
 
-	f.expectedRequests = append(f.expectedRequests, request)
+	f.addExpectedRequest(request)
+}
+
+// ExpectListExistingSecret expect a list request that returns a pre-existing secret. If ownedByYale is true,
+// the secret has the owned_by label and created-by-yale annotation Yale adds to the secrets it creates
+func (f *FakeGsmServer) ExpectListExistingSecret(project string, secret string, ownedByYale bool) {
+	result := &secretmanagerpb.Secret{
+		Name: fmt.Sprintf("projects/%s/secrets/%s", project, secret),
+	}
+	if ownedByYale {
+		result.Labels = map[string]string{"owned_by": "yale"}
+		result.Annotations = map[string]string{"created-by-yale": "true"}
+	}
+	f.ExpectListSecretWithNameFilter(project, secret, result)
+}
+
+// ExpectListSecretOwnedBy expect a list request that returns a pre-existing secret created by Yale, whose yale-owner
+// annotation names the given identifier
+func (f *FakeGsmServer) ExpectListSecretOwnedBy(project string, secret string, owner string) {
+	f.ExpectListSecretWithNameFilter(project, secret, &secretmanagerpb.Secret{
+		Name:        fmt.Sprintf("projects/%s/secrets/%s", project, secret),
+		Labels:      map[string]string{"owned_by": "yale"},
+		Annotations: map[string]string{"created-by-yale": "true", "yale-owner": owner},
+	})
 }
 
 func (f *FakeGsmServer) ExpectCreateNewSecret(project string, secret string, requestMatcher func(*secretmanagerpb.Secret) bool, result *secretmanagerpb.Secret) {
@@ -64,11 +119,14 @@ func (f *FakeGsmServer) ExpectCreateNewSecret(project string, secret string, req
 	}
 
 	request.requestBodyMatcher = func(content []byte) (bool, error) {
+		// the request body is protojson, which encoding/json can't unmarshal oneof fields like Replication from
 		var r secretmanagerpb.Secret
-		if err := json.Unmarshal(content, &r); err != nil {
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(content, &r); err != nil {
 			return false, fmt.Errorf("error unmarshalling request body to CreateSecretRequest: %v", err)
 		}
-		require.Equal(f.t, secret, r.Name, "expected secret.name to equal %s", secret)
+		if r.Name != secret {
+			return false, nil
+		}
 		if requestMatcher == nil {
 			return true, nil
 		}
@@ -79,7 +137,7 @@ func (f *FakeGsmServer) ExpectCreateNewSecret(project string, secret string, req
 	require.NoError(f.t, err)
 	request.responseBody = responseBody
 
-	f.expectedRequests = append(f.expectedRequests, request)
+	f.addExpectedRequest(request)
 }
 
 func (f *FakeGsmServer) ExpectAccessSecretVersion(project string, secret string, version string, payload []byte) {
@@ -102,7 +160,7 @@ func (f *FakeGsmServer) ExpectAccessSecretVersion(project string, secret string,
 		request.responseBody = responseBody
 	}
 
-	f.expectedRequests = append(f.expectedRequests, request)
+	f.addExpectedRequest(request)
 }
 
 func (f *FakeGsmServer) ExpectCreateNewSecretVersion(project string, secret string, payload []byte, result *secretmanagerpb.SecretVersion) {
@@ -117,9 +175,10 @@ func (f *FakeGsmServer) ExpectCreateNewSecretVersion(project string, secret stri
 		if err := json.Unmarshal(content, &r); err != nil {
 			return false, fmt.Errorf("error unmarshalling add secret version request: %v", err)
 		}
-		require.Equal(f.t, fmt.Sprintf("projects/%s/secrets/%s", project, secret), r.Parent)
-		require.Equal(f.t, string(payload), string(r.GetPayload().GetData()))
-		return true, nil
+		if r.Parent != fmt.Sprintf("projects/%s/secrets/%s", project, secret) {
+			return false, nil
+		}
+		return string(payload) == string(r.GetPayload().GetData()), nil
 	}
 
 	responseBody, err := json.Marshal(result)
@@ -127,6 +186,55 @@ func (f *FakeGsmServer) ExpectCreateNewSecretVersion(project string, secret stri
 
 	request.responseBody = responseBody
 
+	f.addExpectedRequest(request)
+}
+
+// ExpectListSecretVersions expect a request to list the versions of a secret, returning the given versions
+func (f *FakeGsmServer) ExpectListSecretVersions(project string, secret string, versions []*secretmanagerpb.SecretVersion) {
+	responseBody, err := protojson.Marshal(&secretmanagerpb.ListSecretVersionsResponse{
+		Versions:  versions,
+		TotalSize: int32(len(versions)),
+	})
+	require.NoError(f.t, err)
+
+	f.addExpectedRequest(expectedRequest{
+		requestMethod: "GET",
+		requestPath:   fmt.Sprintf("/v1/projects/%s/secrets/%s/versions", project, secret),
+		responseCode:  200,
+		responseBody:  responseBody,
+	})
+}
+
+// ExpectDestroySecretVersion expect a request to destroy a version of a secret
+func (f *FakeGsmServer) ExpectDestroySecretVersion(project string, secret string, version string) {
+	name := fmt.Sprintf("projects/%s/secrets/%s/versions/%s", project, secret, version)
+	responseBody, err := protojson.Marshal(&secretmanagerpb.SecretVersion{
+		Name:  name,
+		State: secretmanagerpb.SecretVersion_DESTROYED,
+	})
+	require.NoError(f.t, err)
+
+	f.addExpectedRequest(expectedRequest{
+		requestMethod: "POST",
+		requestPath:   fmt.Sprintf("/v1/%s:destroy", name),
+		responseCode:  200,
+		responseBody:  responseBody,
+	})
+}
+
+// ExpectDeleteSecret expect a request to delete a secret
+func (f *FakeGsmServer) ExpectDeleteSecret(project string, secret string) {
+	f.addExpectedRequest(expectedRequest{
+		requestMethod: "DELETE",
+		requestPath:   fmt.Sprintf("/v1/projects/%s/secrets/%s", project, secret),
+		responseCode:  200,
+		responseBody:  []byte("{}"),
+	})
+}
+
+func (f *FakeGsmServer) addExpectedRequest(request expectedRequest) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
 	f.expectedRequests = append(f.expectedRequests, request)
 }
 
@@ -135,6 +243,8 @@ func (f *FakeGsmServer) Close() {
 }
 
 func (f *FakeGsmServer) AssertExpectations() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
 	require.Empty(f.t, f.expectedRequests, "%d unmet expectationts: %#v", len(f.expectedRequests), f.expectedRequests)
 }
 
@@ -152,43 +262,88 @@ func (f *FakeGsmServer) httpHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		logs.Info.Printf("received request: %s %s", r.Method, r.URL)
 
-		if len(f.expectedRequests) == 0 {
-			f.t.Errorf("received request %s %s, but no expectations are set", r.Method, r.URL.Path)
-			f.t.FailNow()
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			f.t.Errorf("fake gsm server: error reading request body: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
 		}
 
-		nextRequest := f.expectedRequests[0]
-		f.expectedRequests = f.expectedRequests[1:]
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
 
-		require.Equal(f.t, nextRequest.requestMethod, r.Method, "expected request %v, got %s %s", nextRequest, r.Method, r.URL)
-		require.Equal(f.t, nextRequest.requestPath, r.URL.Path, "expected request %v, got %s %s", nextRequest, r.Method, r.URL)
+		f.requests++
+		if f.failures > 0 && strings.HasSuffix(r.URL.Path, f.failurePath) {
+			f.failures--
+			logs.Info.Printf("failing request with %d response", f.failureCode)
+			writeErrorResponse(w, f.failureCode)
+			return
+		}
 
-		if nextRequest.requestQueryParameters != nil {
-			for name, value := range nextRequest.requestQueryParameters {
-				actual := r.URL.Query().Get(name)
-				logs.Debug.Printf("Matching query parameter %s (expect %s, got %s)", name, value, actual)
-				require.Equal(f.t, value, actual, "expected request query parameter %q to have value %s, got %s", name, value, actual)
+		for i, expected := range f.expectedRequests {
+			matches, err := f.matches(expected, r, body)
+			if err != nil {
+				f.t.Errorf("error matching request %s %s: %v", r.Method, r.URL, err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			if !matches {
+				continue
 			}
-		}
 
-		if nextRequest.requestBodyMatcher != nil {
-			body, err := io.ReadAll(r.Body)
-			require.NoError(f.t, err, "fake gsm server: error reading request body", string(body))
-			logs.Debug.Printf("Matching request body: %s", string(body))
-			matches, err := nextRequest.requestBodyMatcher(body)
-			require.NoError(f.t, err, "error matching request body", string(body))
-			require.True(f.t, matches, "request body did not match expectation: %s", string(body))
-		}
+			f.expectedRequests = append(f.expectedRequests[:i:i], f.expectedRequests[i+1:]...)
 
-		logs.Info.Printf("writing %d response", nextRequest.responseCode)
-		w.WriteHeader(nextRequest.responseCode)
-		if _, err := w.Write(nextRequest.responseBody); err != nil {
-			f.t.Errorf("error writing response body: %v", err)
-			f.t.FailNow()
+			logs.Info.Printf("writing %d response", expected.responseCode)
+			w.WriteHeader(expected.responseCode)
+			if _, err := w.Write(expected.responseBody); err != nil {
+				f.t.Errorf("error writing response body: %v", err)
+			}
+			return
 		}
+
+		f.t.Errorf("received request %s %s with body %s, but it matches none of the %d pending expectations", r.Method, r.URL, string(body), len(f.expectedRequests))
+		w.WriteHeader(http.StatusInternalServerError)
 	})
 }
 
+// writeErrorResponse writes a Google API error response with the given status code, and the gRPC status
+// GSM returns along with it
+func writeErrorResponse(w http.ResponseWriter, statusCode int) {
+	status := "UNKNOWN"
+	switch statusCode {
+	case http.StatusForbidden:
+		status = "PERMISSION_DENIED"
+	case http.StatusNotFound:
+		status = "NOT_FOUND"
+	case http.StatusTooManyRequests:
+		status = "RESOURCE_EXHAUSTED"
+	case http.StatusServiceUnavailable:
+		status = "UNAVAILABLE"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_, _ = fmt.Fprintf(w, `{"error":{"code":%d,"message":"fake gsm error","status":"%s"}}`, statusCode, status)
+}
+
+// matches returns true if the request satisfies the expectation
+func (f *FakeGsmServer) matches(expected expectedRequest, r *http.Request, body []byte) (bool, error) {
+	if expected.requestMethod != r.Method || expected.requestPath != r.URL.Path {
+		return false, nil
+	}
+	for name, value := range expected.requestQueryParameters {
+		actual := r.URL.Query().Get(name)
+		logs.Debug.Printf("Matching query parameter %s (expect %s, got %s)", name, value, actual)
+		if actual != value {
+			return false, nil
+		}
+	}
+	if expected.requestBodyMatcher != nil {
+		logs.Debug.Printf("Matching request body: %s", string(body))
+		return expected.requestBodyMatcher(body)
+	}
+	return true, nil
+}
+
 func NewFakeGsm(t *testing.T) *FakeGsmServer {
 	fakeGsm := &FakeGsmServer{
 		t: t,
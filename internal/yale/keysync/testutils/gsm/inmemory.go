@@ -0,0 +1,300 @@
+package gsm
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/broadinstitute/yale/internal/yale/logs"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// InMemoryGsmServer stateful fake Google Secret Manager server, for tests that care about what ends up stored in
+// GSM rather than the exact sequence of requests made. Supports creating, listing (with a single label filter),
+// and deleting secrets, and adding, accessing, listing, and destroying secret versions.
+type InMemoryGsmServer struct {
+	t       *testing.T
+	mutex   sync.Mutex
+	secrets map[string]*inMemorySecret
+	server  *httptest.Server
+}
+
+type inMemorySecret struct {
+	secret   *secretmanagerpb.Secret
+	versions []*inMemoryVersion
+}
+
+type inMemoryVersion struct {
+	version *secretmanagerpb.SecretVersion
+	data    []byte
+}
+
+func NewInMemoryGsm(t *testing.T) *InMemoryGsmServer {
+	f := &InMemoryGsmServer{
+		t:       t,
+		secrets: make(map[string]*inMemorySecret),
+	}
+	f.server = httptest.NewServer(f.httpHandler())
+	return f
+}
+
+func (f *InMemoryGsmServer) NewClient() *secretmanager.Client {
+	return (&FakeGsmServer{t: f.t, server: f.server}).NewClient()
+}
+
+func (f *InMemoryGsmServer) Close() {
+	f.server.Close()
+}
+
+// SecretIDs returns the ids of all secrets in the given project, sorted
+func (f *InMemoryGsmServer) SecretIDs(project string) []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	var ids []string
+	prefix := fmt.Sprintf("projects/%s/secrets/", project)
+	for name := range f.secrets {
+		if strings.HasPrefix(name, prefix) {
+			ids = append(ids, strings.TrimPrefix(name, prefix))
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Labels returns the labels of the given secret, or nil if it does not exist
+func (f *InMemoryGsmServer) Labels(project string, secretID string) map[string]string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	s, exists := f.secrets[fmt.Sprintf("projects/%s/secrets/%s", project, secretID)]
+	if !exists {
+		return nil
+	}
+	return s.secret.GetLabels()
+}
+
+// EnabledVersions returns the number of versions of the given secret that have not been destroyed
+func (f *InMemoryGsmServer) EnabledVersions(project string, secretID string) int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	s, exists := f.secrets[fmt.Sprintf("projects/%s/secrets/%s", project, secretID)]
+	if !exists {
+		return 0
+	}
+	count := 0
+	for _, v := range s.versions {
+		if v.version.GetState() == secretmanagerpb.SecretVersion_ENABLED {
+			count++
+		}
+	}
+	return count
+}
+
+func (f *InMemoryGsmServer) httpHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logs.Debug.Printf("in-memory gsm received request: %s %s", r.Method, r.URL)
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			f.t.Errorf("in-memory gsm server: error reading request body: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+
+		path := strings.TrimPrefix(r.URL.Path, "/v1/")
+		resource, verb, _ := strings.Cut(path, ":")
+		parts := strings.Split(resource, "/")
+
+		switch {
+		// projects/P/secrets
+		case len(parts) == 3 && r.Method == http.MethodPost:
+			f.createSecret(w, resource, r.URL.Query().Get("secretId"), body)
+		case len(parts) == 3 && r.Method == http.MethodGet:
+			f.listSecrets(w, resource, r.URL.Query().Get("filter"))
+		// projects/P/secrets/S
+		case len(parts) == 4 && r.Method == http.MethodDelete:
+			f.deleteSecret(w, resource)
+		case len(parts) == 4 && verb == "addVersion":
+			f.addVersion(w, resource, body)
+		// projects/P/secrets/S/versions
+		case len(parts) == 5 && r.Method == http.MethodGet:
+			f.listVersions(w, strings.Join(parts[:4], "/"))
+		// projects/P/secrets/S/versions/V
+		case len(parts) == 6 && verb == "access":
+			f.accessVersion(w, strings.Join(parts[:4], "/"), parts[5])
+		case len(parts) == 6 && verb == "destroy":
+			f.destroyVersion(w, strings.Join(parts[:4], "/"), parts[5])
+		default:
+			f.t.Errorf("in-memory gsm server: unsupported request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotImplemented)
+		}
+	})
+}
+
+func (f *InMemoryGsmServer) createSecret(w http.ResponseWriter, parent string, secretID string, body []byte) {
+	name := parent + "/" + secretID
+	if _, exists := f.secrets[name]; exists {
+		f.writeError(w, http.StatusConflict, "ALREADY_EXISTS", name)
+		return
+	}
+	var secret secretmanagerpb.Secret
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(body, &secret); err != nil {
+		f.t.Errorf("in-memory gsm server: error unmarshalling secret: %v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	secret.Name = name
+	f.secrets[name] = &inMemorySecret{secret: &secret}
+	f.writeResponse(w, &secret)
+}
+
+func (f *InMemoryGsmServer) listSecrets(w http.ResponseWriter, parent string, filter string) {
+	var labelKey, labelValue string
+	if filter != "" {
+		var found bool
+		labelKey, labelValue, found = strings.Cut(strings.TrimPrefix(filter, "labels."), "=")
+		if !found || !strings.HasPrefix(filter, "labels.") {
+			f.t.Errorf("in-memory gsm server: unsupported secret filter %q", filter)
+			w.WriteHeader(http.StatusNotImplemented)
+			return
+		}
+	}
+
+	response := &secretmanagerpb.ListSecretsResponse{}
+	for name, s := range f.secrets {
+		if !strings.HasPrefix(name, parent+"/") {
+			continue
+		}
+		if filter != "" && s.secret.GetLabels()[labelKey] != labelValue {
+			continue
+		}
+		response.Secrets = append(response.Secrets, s.secret)
+	}
+	sort.Slice(response.Secrets, func(i, j int) bool {
+		return response.Secrets[i].GetName() < response.Secrets[j].GetName()
+	})
+	response.TotalSize = int32(len(response.Secrets))
+	f.writeResponse(w, response)
+}
+
+func (f *InMemoryGsmServer) deleteSecret(w http.ResponseWriter, name string) {
+	if _, exists := f.secrets[name]; !exists {
+		f.writeError(w, http.StatusNotFound, "NOT_FOUND", name)
+		return
+	}
+	delete(f.secrets, name)
+	f.writeResponse(w, &secretmanagerpb.Secret{})
+}
+
+func (f *InMemoryGsmServer) addVersion(w http.ResponseWriter, name string, body []byte) {
+	s, exists := f.secrets[name]
+	if !exists {
+		f.writeError(w, http.StatusNotFound, "NOT_FOUND", name)
+		return
+	}
+	var request secretmanagerpb.AddSecretVersionRequest
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(body, &request); err != nil {
+		f.t.Errorf("in-memory gsm server: error unmarshalling add version request: %v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	version := &secretmanagerpb.SecretVersion{
+		Name:  fmt.Sprintf("%s/versions/%d", name, len(s.versions)+1),
+		State: secretmanagerpb.SecretVersion_ENABLED,
+	}
+	s.versions = append(s.versions, &inMemoryVersion{version: version, data: request.GetPayload().GetData()})
+	f.writeResponse(w, version)
+}
+
+func (f *InMemoryGsmServer) listVersions(w http.ResponseWriter, name string) {
+	s, exists := f.secrets[name]
+	if !exists {
+		f.writeError(w, http.StatusNotFound, "NOT_FOUND", name)
+		return
+	}
+	response := &secretmanagerpb.ListSecretVersionsResponse{}
+	for _, v := range s.versions {
+		response.Versions = append(response.Versions, v.version)
+	}
+	response.TotalSize = int32(len(response.Versions))
+	f.writeResponse(w, response)
+}
+
+func (f *InMemoryGsmServer) accessVersion(w http.ResponseWriter, name string, versionID string) {
+	v := f.findVersion(name, versionID)
+	if v == nil || v.version.GetState() != secretmanagerpb.SecretVersion_ENABLED {
+		f.writeError(w, http.StatusNotFound, "NOT_FOUND", name+"/versions/"+versionID)
+		return
+	}
+	f.writeResponse(w, &secretmanagerpb.AccessSecretVersionResponse{
+		Name:    v.version.GetName(),
+		Payload: &secretmanagerpb.SecretPayload{Data: v.data},
+	})
+}
+
+func (f *InMemoryGsmServer) destroyVersion(w http.ResponseWriter, name string, versionID string) {
+	v := f.findVersion(name, versionID)
+	if v == nil {
+		f.writeError(w, http.StatusNotFound, "NOT_FOUND", name+"/versions/"+versionID)
+		return
+	}
+	v.version.State = secretmanagerpb.SecretVersion_DESTROYED
+	v.data = nil
+	f.writeResponse(w, v.version)
+}
+
+// findVersion returns the given version of the secret, or nil if it does not exist. "latest" refers to the
+// most recently added version that has not been destroyed.
+func (f *InMemoryGsmServer) findVersion(name string, versionID string) *inMemoryVersion {
+	s, exists := f.secrets[name]
+	if !exists {
+		return nil
+	}
+	if versionID == "latest" {
+		for i := len(s.versions) - 1; i >= 0; i-- {
+			if s.versions[i].version.GetState() == secretmanagerpb.SecretVersion_ENABLED {
+				return s.versions[i]
+			}
+		}
+		return nil
+	}
+	n, err := strconv.Atoi(versionID)
+	if err != nil || n < 1 || n > len(s.versions) {
+		return nil
+	}
+	return s.versions[n-1]
+}
+
+func (f *InMemoryGsmServer) writeResponse(w http.ResponseWriter, message proto.Message) {
+	body, err := protojson.Marshal(message)
+	if err != nil {
+		f.t.Errorf("in-memory gsm server: error marshalling response: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(body); err != nil {
+		f.t.Errorf("in-memory gsm server: error writing response body: %v", err)
+	}
+}
+
+func (f *InMemoryGsmServer) writeError(w http.ResponseWriter, code int, status string, name string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	body := fmt.Sprintf(`{"error":{"code":%d,"message":"%s: %s","status":"%s"}}`, code, status, name, status)
+	if _, err := w.Write([]byte(body)); err != nil {
+		f.t.Errorf("in-memory gsm server: error writing response body: %v", err)
+	}
+}
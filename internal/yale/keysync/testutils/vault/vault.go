@@ -9,8 +9,11 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 const secretPrefix = "secret/"
@@ -18,7 +21,9 @@ const secretPrefix = "secret/"
 // NewFakeVaultServer returns a new fake vault server that can be used to fake vault secret lookups
 func NewFakeVaultServer(t *testing.T) *FakeVaultServer {
 	_state := &state{
-		secrets: make(map[string]map[string]interface{}),
+		secrets:    make(map[string]map[string]interface{}),
+		namespaces: make(map[string]string),
+		wrapped:    make(map[string]wrappedSecret),
 	}
 
 	mux := http.NewServeMux()
@@ -26,13 +31,16 @@ func NewFakeVaultServer(t *testing.T) *FakeVaultServer {
 	mux.Handle("/v1/auth/github/login", toHttpHandler(_state.handleGithubLogin))
 	mux.Handle("/v1/auth/token/lookup-self", toHttpHandler(_state.handleTokenLookup))
 	mux.Handle("/v1/secret/", toHttpHandler(_state.handleSecret))
+	mux.Handle("/v1/sys/internal/ui/mounts/", toHttpHandler(_state.handleMountLookup))
+	mux.Handle("/v1/sys/wrapping/wrap", toHttpHandler(_state.handleWrap))
 	mux.Handle("/", toHttpHandler(_state.handleUnmatchedRequest))
 
-	server := httptest.NewTLSServer(mux)
+	server := httptest.NewTLSServer(_state.synchronized(mux))
 	t.Cleanup(server.Close)
 
 	return &FakeVaultServer{
 		server: server,
+		mux:    mux,
 		state:  _state,
 		t:      t,
 	}
@@ -40,6 +48,7 @@ func NewFakeVaultServer(t *testing.T) *FakeVaultServer {
 
 type FakeVaultServer struct {
 	server *httptest.Server
+	mux    *http.ServeMux
 	state  *state
 	t      *testing.T
 }
@@ -47,9 +56,30 @@ type FakeVaultServer struct {
 // simplified http.Handler
 type vaultApiHandler func(r *http.Request) (*vaultapi.Secret, error)
 
+// vaultNamespaceHeader header Vault Enterprise clients use to select a namespace
+const vaultNamespaceHeader = "X-Vault-Namespace"
+
+// vaultWrapTTLHeader header clients use to request that a response be wrapped, with the given TTL
+const vaultWrapTTLHeader = "X-Vault-Wrap-TTL"
+
+// wrappedSecret data wrapped with sys/wrapping/wrap, and the wrap TTL header it was wrapped with
+type wrappedSecret struct {
+	data    map[string]interface{}
+	wrapTTL string
+}
+
 // represents state of the fake server
 type state struct {
-	secrets     map[string]map[string]interface{}
+	// guards all fields below, since Yale may issue requests concurrently
+	mutex      sync.Mutex
+	secrets    map[string]map[string]interface{}
+	namespaces map[string]string
+	wrapped    map[string]wrappedSecret
+	kvv2Mounts []string
+	failures   struct {
+		remaining  int
+		statusCode int
+	}
 	expectLogin struct {
 		enabled     bool
 		githubToken string
@@ -79,24 +109,68 @@ func (s *FakeVaultServer) Server() *httptest.Server {
 
 // ExpectGithubLogin configures the server to expect a github login with a specific Github token (by default any token is expected)
 func (s *FakeVaultServer) ExpectGithubLogin(githubToken string, vaultToken string) {
+	s.state.mutex.Lock()
+	defer s.state.mutex.Unlock()
 	s.state.expectLogin.enabled = true
 	s.state.expectLogin.githubToken = githubToken
 	s.state.expectLogin.vaultToken = vaultToken
 }
 
+// FailRequests configures the server to respond to the next n requests with the given status code
+// (eg. 503 to simulate a sealed Vault), instead of handling them
+func (s *FakeVaultServer) FailRequests(n int, statusCode int) {
+	s.state.mutex.Lock()
+	defer s.state.mutex.Unlock()
+	s.state.failures.remaining = n
+	s.state.failures.statusCode = statusCode
+}
+
+// EnableKVv2 mounts a fake KV v2 secrets engine at the given path (eg. "kv"). Secrets written
+// to it are stored under their logical path (eg. "kv/my/secret"), without the "data/" segment
+func (s *FakeVaultServer) EnableKVv2(mount string) {
+	s.state.mutex.Lock()
+	defer s.state.mutex.Unlock()
+	s.state.kvv2Mounts = append(s.state.kvv2Mounts, mount)
+	s.mux.Handle("/v1/"+mount+"/", toHttpHandler(func(r *http.Request) (*vaultapi.Secret, error) {
+		return s.state.handleKVv2Secret(mount, r)
+	}))
+}
+
 // SetSecret adds a secret to the fake server
 func (s *FakeVaultServer) SetSecret(path string, data map[string]interface{}) {
 	// remove secret/ prefix from key
 	path = strings.TrimPrefix(path, secretPrefix)
+	s.state.mutex.Lock()
+	defer s.state.mutex.Unlock()
 	s.state.secrets[path] = data
 }
 
 // GetSecret retrieves a secret from the fake server's storage
 func (s *FakeVaultServer) GetSecret(path string) map[string]interface{} {
 	path = strings.TrimPrefix(path, secretPrefix)
+	s.state.mutex.Lock()
+	defer s.state.mutex.Unlock()
 	return s.state.secrets[path]
 }
 
+// GetSecretNamespace returns the Vault Enterprise namespace (the X-Vault-Namespace header) of the last
+// request that wrote the secret, or "" if the request had no namespace
+func (s *FakeVaultServer) GetSecretNamespace(path string) string {
+	path = strings.TrimPrefix(path, secretPrefix)
+	s.state.mutex.Lock()
+	defer s.state.mutex.Unlock()
+	return s.state.namespaces[path]
+}
+
+// GetWrappedSecret returns the data wrapped with the given wrapping token, and the X-Vault-Wrap-TTL header
+// of the request that wrapped it. Unlike a real Vault server, this does not consume the token
+func (s *FakeVaultServer) GetWrappedSecret(token string) (map[string]interface{}, string) {
+	s.state.mutex.Lock()
+	defer s.state.mutex.Unlock()
+	wrapped := s.state.wrapped[token]
+	return wrapped.data, wrapped.wrapTTL
+}
+
 func (s *state) handleGithubLogin(r *http.Request) (*vaultapi.Secret, error) {
 	if r.Method != http.MethodPost &&
 		r.Method != http.MethodPut {
@@ -142,6 +216,7 @@ func (s *state) handleSecret(r *http.Request) (*vaultapi.Secret, error) {
 		}
 		logs.Info.Printf("setting secret %s to %v", secretPath, data)
 		s.secrets[secretPath] = data
+		s.namespaces[secretPath] = r.Header.Get(vaultNamespaceHeader)
 
 		var secret vaultapi.Secret
 		secret.Data = data
@@ -162,9 +237,122 @@ func (s *state) handleSecret(r *http.Request) (*vaultapi.Secret, error) {
 		return &secret, nil
 	}
 
+	if r.Method == http.MethodDelete {
+		logs.Info.Printf("deleting secret %s", secretPath)
+		delete(s.secrets, secretPath)
+		return &vaultapi.Secret{}, nil
+	}
+
 	return nil, fmt.Errorf("invalid method for secrets api: %s", r.Method)
 }
 
+func (s *state) handleWrap(r *http.Request) (*vaultapi.Secret, error) {
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		return nil, fmt.Errorf("invalid method for wrapping api: %s", r.Method)
+	}
+	wrapTTL := r.Header.Get(vaultWrapTTLHeader)
+	if wrapTTL == "" {
+		return nil, fmt.Errorf("wrapping requests must set the %s header", vaultWrapTTLHeader)
+	}
+	ttl, err := strconv.Atoi(wrapTTL)
+	if err != nil {
+		return nil, fmt.Errorf("fake server only supports %s in seconds: %q", vaultWrapTTLHeader, wrapTTL)
+	}
+	var data map[string]interface{}
+	if err = parseJsonRequestBody(r, &data); err != nil {
+		return nil, err
+	}
+
+	token := fmt.Sprintf("hvs.wrapping-token-%d", len(s.wrapped)+1)
+	logs.Info.Printf("wrapping %v as %s with ttl %s", data, token, wrapTTL)
+	s.wrapped[token] = wrappedSecret{data: data, wrapTTL: wrapTTL}
+
+	return &vaultapi.Secret{
+		WrapInfo: &vaultapi.SecretWrapInfo{
+			Token:        token,
+			Accessor:     fmt.Sprintf("wrapping-accessor-%d", len(s.wrapped)),
+			TTL:          ttl,
+			CreationTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			CreationPath: "sys/wrapping/wrap",
+		},
+	}, nil
+}
+
+func (s *state) handleMountLookup(r *http.Request) (*vaultapi.Secret, error) {
+	if r.Method != http.MethodGet {
+		return nil, fmt.Errorf("invalid method for mount lookup api: %s", r.Method)
+	}
+	secretPath := strings.TrimPrefix(r.URL.Path, "/v1/sys/internal/ui/mounts/")
+
+	for _, mount := range s.kvv2Mounts {
+		if strings.HasPrefix(secretPath, mount+"/") {
+			return mountInfo(mount+"/", "2"), nil
+		}
+	}
+	if strings.HasPrefix(secretPath, secretPrefix) {
+		return mountInfo(secretPrefix, "1"), nil
+	}
+	return nil, nil
+}
+
+func mountInfo(path string, version string) *vaultapi.Secret {
+	return &vaultapi.Secret{
+		Data: map[string]interface{}{
+			"path": path,
+			"type": "kv",
+			"options": map[string]interface{}{
+				"version": version,
+			},
+		},
+	}
+}
+
+func (s *state) handleKVv2Secret(mount string, r *http.Request) (*vaultapi.Secret, error) {
+	relativePath := strings.TrimPrefix(r.URL.Path, "/v1/"+mount+"/")
+	if strings.HasPrefix(relativePath, "metadata/") && r.Method == http.MethodDelete {
+		secretPath := mount + "/" + strings.TrimPrefix(relativePath, "metadata/")
+		logs.Info.Printf("deleting all versions of KV v2 secret %s", secretPath)
+		delete(s.secrets, secretPath)
+		return &vaultapi.Secret{}, nil
+	}
+	if !strings.HasPrefix(relativePath, "data/") {
+		return nil, fmt.Errorf("KV v2 requests must use the data/ endpoint: %s", r.URL.Path)
+	}
+	secretPath := mount + "/" + strings.TrimPrefix(relativePath, "data/")
+
+	if r.Method == http.MethodPost || r.Method == http.MethodPut {
+		var body struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		if err := parseJsonRequestBody(r, &body); err != nil {
+			return nil, err
+		}
+		if body.Data == nil {
+			return nil, fmt.Errorf("KV v2 write to %s is missing data", secretPath)
+		}
+		logs.Info.Printf("setting KV v2 secret %s to %v", secretPath, body.Data)
+		s.secrets[secretPath] = body.Data
+		s.namespaces[secretPath] = r.Header.Get(vaultNamespaceHeader)
+		return &vaultapi.Secret{Data: map[string]interface{}{"version": 1}}, nil
+	}
+
+	if r.Method == http.MethodGet {
+		data, exists := s.secrets[secretPath]
+		if !exists {
+			logs.Info.Printf("KV v2 secret %s does not exist, returning 404", secretPath)
+			return nil, nil
+		}
+		return &vaultapi.Secret{
+			Data: map[string]interface{}{
+				"data":     data,
+				"metadata": map[string]interface{}{"version": 1},
+			},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("invalid method for KV v2 secrets api: %s", r.Method)
+}
+
 func (s *state) handleUnmatchedRequest(r *http.Request) (*vaultapi.Secret, error) {
 	panic(fmt.Errorf("no handler for request: %s %s", r.Method, r.URL.Path))
 }
@@ -181,6 +369,22 @@ func writeSecretToResponseBody(secret *vaultapi.Secret, w http.ResponseWriter) {
 	}
 }
 
+// synchronized wraps the handler so that requests are served one at a time, and fails requests
+// if configured with FailRequests
+func (s *state) synchronized(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		if s.failures.remaining > 0 {
+			s.failures.remaining--
+			logs.Info.Printf("%s %s: failing request with %d (%d more failures configured)", r.Method, r.URL.Path, s.failures.statusCode, s.failures.remaining)
+			http.Error(w, "fake vault server configured to fail request", s.failures.statusCode)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
 // convert vaultApiHandler to http.Handler
 func toHttpHandler(handler vaultApiHandler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
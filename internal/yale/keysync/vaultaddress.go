@@ -0,0 +1,47 @@
+package keysync
+
+import (
+	"fmt"
+	"strings"
+
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// vaultClientForAddress returns the shared Vault client if address is empty or is the shared client's own
+// address. Otherwise it returns a short-lived copy of the shared client pointed at address, with the same token
+// and headers, as long as address is in the allowlist of Vault addresses.
+func (k *keysync) vaultClientForAddress(address string) (*vaultapi.Client, error) {
+	if address == "" || normalizeVaultAddress(address) == normalizeVaultAddress(k.vault.Address()) {
+		return k.vault, nil
+	}
+	if !k.vaultAddressAllowed(address) {
+		return nil, fmt.Errorf("Vault address %s is not allowed; Yale only writes to its default Vault server and the addresses in its allowlist (%s)", address, strings.Join(k.options.AllowedVaultAddresses, ", "))
+	}
+
+	client, err := k.vault.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("error creating Vault client for %s: %v", address, err)
+	}
+	if err = client.SetAddress(address); err != nil {
+		return nil, fmt.Errorf("error creating Vault client for %s: %v", address, err)
+	}
+	client.SetToken(k.vault.Token())
+	client.SetHeaders(k.vault.Headers())
+	return client, nil
+}
+
+// vaultAddressAllowed returns true if the address is in the allowlist of Vault addresses
+func (k *keysync) vaultAddressAllowed(address string) bool {
+	for _, allowed := range k.options.AllowedVaultAddresses {
+		if normalizeVaultAddress(allowed) == normalizeVaultAddress(address) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeVaultAddress strips the trailing slash from a Vault address, so that "https://vault.example.com/"
+// and "https://vault.example.com" are treated as the same server
+func normalizeVaultAddress(address string) string {
+	return strings.TrimSuffix(strings.ToLower(address), "/")
+}
@@ -0,0 +1,45 @@
+package keysync
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/broadinstitute/yale/internal/yale/logs"
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// DefaultVaultWriteMaxAttempts default number of times Yale will attempt a Vault write before giving up
+const DefaultVaultWriteMaxAttempts = 3
+
+// DefaultVaultWriteRetryBaseDelay default delay before the first retry of a failed Vault write; the delay
+// doubles with every subsequent retry
+const DefaultVaultWriteRetryBaseDelay = 500 * time.Millisecond
+
+// writeToVault writes the payload to the given Vault path, retrying with exponential backoff if the write
+// fails with a 5xx response or a network error
+func (k *keysync) writeToVault(client *vaultapi.Client, path string, payload map[string]interface{}) error {
+	delay := k.options.VaultWriteRetryBaseDelay
+	for attempt := 1; ; attempt++ {
+		_, err := client.Logical().Write(path, payload)
+		if err == nil {
+			return nil
+		}
+		if attempt >= k.options.VaultWriteMaxAttempts || !isRetryableVaultError(err) {
+			return err
+		}
+		logs.Warn.Printf("attempt %d of %d to write Vault path %s failed, retrying in %s: %v", attempt, k.options.VaultWriteMaxAttempts, path, delay, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// isRetryableVaultError returns true if the error is a 5xx response from Vault or a network error,
+// rather than a client error such as a 403 or 404
+func isRetryableVaultError(err error) bool {
+	var responseErr *vaultapi.ResponseError
+	if errors.As(err, &responseErr) {
+		return responseErr.StatusCode >= http.StatusInternalServerError
+	}
+	return true
+}
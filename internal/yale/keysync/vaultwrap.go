@@ -0,0 +1,79 @@
+package keysync
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	apiv1b1 "github.com/broadinstitute/yale/internal/yale/crd/api/v1beta1"
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// DefaultVaultWrapTTL default TTL of the wrapping token written for a wrapped Vault replication
+const DefaultVaultWrapTTL = 24 * time.Hour
+
+// vaultWrapPath Vault endpoint that response-wraps the data written to it
+const vaultWrapPath = "sys/wrapping/wrap"
+
+// Fields of the secret Yale writes to the path of a wrapped Vault replication. Consumers must:
+//
+//   - read the path and pass wrapping_token to Vault's sys/wrapping/unwrap endpoint (eg. `vault unwrap <token>`),
+//     which returns the same data an unwrapped replication with the same format and key would have written
+//   - do so before the token expires, wrapping_ttl seconds after creation_time. Yale only writes a new token when
+//     the key changes, so the TTL should be long enough for every consumer to unwrap the key after a rotation
+//   - treat the token as single-use: unwrapping consumes it, so only one consumer can read the key from the path,
+//     and a failed unwrap of a token that has not expired means someone else unwrapped it first, which should be
+//     investigated as a possible leak
+//
+// wrapping_accessor can be used to look up or revoke the token without unwrapping it.
+const (
+	vaultWrappingTokenField    = "wrapping_token"
+	vaultWrappingAccessorField = "wrapping_accessor"
+	vaultWrappingTTLField      = "wrapping_ttl"
+	vaultWrappingCreatedField  = "creation_time"
+)
+
+// vaultWrapTTL returns the TTL of the replication's wrapping token
+func vaultWrapTTL(spec apiv1b1.VaultReplication) (time.Duration, error) {
+	if spec.WrapTTL == "" {
+		return DefaultVaultWrapTTL, nil
+	}
+	ttl, err := time.ParseDuration(spec.WrapTTL)
+	if err != nil {
+		return 0, fmt.Errorf("invalid wrapTTL %q: %v", spec.WrapTTL, err)
+	}
+	return ttl, nil
+}
+
+// wrapVaultSecret response-wraps the secret data, returning the fields to write to the replication's path in
+// its place. The request sets the X-Vault-Wrap-TTL header via a wrapping lookup func on a copy of the client,
+// since the client is shared by replications that are performed concurrently.
+func wrapVaultSecret(client *vaultapi.Client, spec apiv1b1.VaultReplication, secretData map[string]interface{}) (map[string]interface{}, error) {
+	ttl, err := vaultWrapTTL(spec)
+	if err != nil {
+		return nil, err
+	}
+	wrapTTL := strconv.Itoa(int(ttl.Seconds()))
+
+	wrapping := client.WithNamespace(client.Namespace())
+	wrapping.SetWrappingLookupFunc(func(_ string, path string) string {
+		if path == vaultWrapPath {
+			return wrapTTL
+		}
+		return ""
+	})
+
+	secret, err := wrapping.Logical().Write(vaultWrapPath, secretData)
+	if err != nil {
+		return nil, fmt.Errorf("error wrapping key: %v", err)
+	}
+	if secret == nil || secret.WrapInfo == nil || secret.WrapInfo.Token == "" {
+		return nil, fmt.Errorf("error wrapping key: Vault response did not include a wrapping token")
+	}
+	return map[string]interface{}{
+		vaultWrappingTokenField:    secret.WrapInfo.Token,
+		vaultWrappingAccessorField: secret.WrapInfo.Accessor,
+		vaultWrappingTTLField:      secret.WrapInfo.TTL,
+		vaultWrappingCreatedField:  secret.WrapInfo.CreationTime.UTC().Format(time.RFC3339),
+	}, nil
+}
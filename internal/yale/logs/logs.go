@@ -1,31 +1,209 @@
 package logs
 
 import (
+	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"os"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
 const debugEnvVar = "YALE_DEBUG_ENABLED"
 
+// Format output format for log lines
+type Format string
+
+const (
+	// TextFormat plain text log lines, eg. "[INFO] 2023/01/02 15:04:05 message"
+	TextFormat Format = "text"
+	// JSONFormat one JSON object per line, with level, ts, and msg keys plus any contextual fields
+	JSONFormat Format = "json"
+)
+
+// Level minimum severity of log lines to write
+type Level string
+
+const (
+	DebugLevel Level = "debug"
+	InfoLevel  Level = "info"
+	WarnLevel  Level = "warn"
+	ErrorLevel Level = "error"
+)
+
+// severities orders levels from least to most severe
+var severities = map[Level]int32{
+	DebugLevel: 0,
+	InfoLevel:  1,
+	WarnLevel:  2,
+	ErrorLevel: 3,
+}
+
 var (
-	Debug = log.New(chooseDebugOutput(), "[DEBUG] ", log.Ldate|log.Ltime)
+	// Debug Poor man's debug level logger
+	Debug = newLogger(DebugLevel, os.Stdout, "[DEBUG] ")
 	// Info Poor man's info level logger
-	Info = log.New(os.Stdout, "[INFO] ", log.Ldate|log.Ltime)
+	Info = newLogger(InfoLevel, os.Stdout, "[INFO] ")
 	// Error Poor man's error level logger
-	Error = log.New(os.Stderr, "[ERROR] ", log.Ldate|log.Ltime)
+	Error = newLogger(ErrorLevel, os.Stderr, "[ERROR] ")
 	// Warn Poor man's warn level logger
-	Warn = log.New(os.Stdout, "[WARN] ", log.Ldate|log.Ltime)
+	Warn = newLogger(WarnLevel, os.Stdout, "[WARN] ")
 )
 
-func chooseDebugOutput() io.Writer {
+// minSeverity severity of the least severe log lines that are written
+var minSeverity atomic.Int32
+
+func init() {
+	minSeverity.Store(severities[defaultLevel()])
+}
+
+// SetLevel suppresses log lines less severe than the given level, returning an error if the level is not supported
+func SetLevel(level string) error {
+	severity, exists := severities[Level(level)]
+	if !exists {
+		return fmt.Errorf("unsupported log level %q (must be %s, %s, %s, or %s)", level, DebugLevel, InfoLevel, WarnLevel, ErrorLevel)
+	}
+	minSeverity.Store(severity)
+	return nil
+}
+
+// GetLevel returns the current log level
+func GetLevel() Level {
+	severity := minSeverity.Load()
+	for level, s := range severities {
+		if s == severity {
+			return level
+		}
+	}
+	return InfoLevel
+}
+
+// jsonEnabled true if log lines should be written in JSON format
+var jsonEnabled atomic.Bool
+
+// SetFormat switches every logger to the given format, returning an error if the format is not supported
+func SetFormat(format string) error {
+	switch Format(format) {
+	case TextFormat:
+		jsonEnabled.Store(false)
+	case JSONFormat:
+		jsonEnabled.Store(true)
+	default:
+		return fmt.Errorf("unsupported log format %q (must be %s or %s)", format, TextFormat, JSONFormat)
+	}
+	return nil
+}
+
+// Fields contextual fields to include in a log line, eg. the identifier or namespace of the resource being processed.
+// They are written as top-level keys in JSON format, and left out of text format, whose messages already include them.
+type Fields map[string]interface{}
+
+// Logger writes log lines at a single level. Its methods mirror the subset of *log.Logger that Yale uses.
+type Logger struct {
+	level  Level
+	text   *log.Logger
+	fields Fields
+}
+
+func newLogger(level Level, out io.Writer, prefix string) *Logger {
+	return &Logger{
+		level: level,
+		text:  log.New(out, prefix, log.Ldate|log.Ltime),
+	}
+}
+
+// WithFields returns a logger that adds the given fields to every line it writes, in addition to this logger's fields.
+// The returned logger shares this logger's output.
+func (l *Logger) WithFields(fields Fields) *Logger {
+	merged := make(Fields, len(l.fields)+len(fields))
+	for key, value := range l.fields {
+		merged[key] = value
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	return &Logger{
+		level:  l.level,
+		text:   l.text,
+		fields: merged,
+	}
+}
+
+// SetOutput sets the destination for this logger, and every logger derived from it with WithFields
+func (l *Logger) SetOutput(w io.Writer) {
+	l.text.SetOutput(w)
+}
+
+func (l *Logger) Print(v ...interface{}) {
+	l.output(fmt.Sprint(v...))
+}
+
+func (l *Logger) Printf(format string, v ...interface{}) {
+	l.output(fmt.Sprintf(format, v...))
+}
+
+func (l *Logger) Println(v ...interface{}) {
+	l.output(fmt.Sprintln(v...))
+}
+
+// Fatal is equivalent to Print followed by a call to os.Exit(1)
+func (l *Logger) Fatal(v ...interface{}) {
+	l.output(fmt.Sprint(v...))
+	os.Exit(1)
+}
+
+// Fatalf is equivalent to Printf followed by a call to os.Exit(1)
+func (l *Logger) Fatalf(format string, v ...interface{}) {
+	l.output(fmt.Sprintf(format, v...))
+	os.Exit(1)
+}
+
+func (l *Logger) output(msg string) {
+	if severities[l.level] < minSeverity.Load() {
+		return
+	}
+	msg = strings.TrimSuffix(msg, "\n")
+	if jsonEnabled.Load() {
+		l.outputJSON(msg)
+		return
+	}
+	if err := l.text.Output(3, msg); err != nil {
+		fmt.Fprintf(os.Stderr, "error writing log line: %v\n", err)
+	}
+}
+
+func (l *Logger) outputJSON(msg string) {
+	line := make(map[string]interface{}, len(l.fields)+3)
+	for key, value := range l.fields {
+		line[key] = value
+	}
+	line["level"] = l.level
+	line["ts"] = time.Now().UTC().Format(time.RFC3339Nano)
+	line["msg"] = msg
+
+	content, err := json.Marshal(line)
+	if err != nil {
+		content, _ = json.Marshal(map[string]interface{}{
+			"level": l.level,
+			"ts":    line["ts"],
+			"msg":   fmt.Sprintf("%s (error encoding log fields: %v)", msg, err),
+		})
+	}
+	if _, err = l.text.Writer().Write(append(content, '\n')); err != nil {
+		fmt.Fprintf(os.Stderr, "error writing log line: %v\n", err)
+	}
+}
+
+// defaultLevel returns debug if debug logging is enabled via environment variable, info otherwise
+func defaultLevel() Level {
 	val := os.Getenv(debugEnvVar)
 	val = strings.ToLower(strings.TrimSpace(val))
 
 	if val == "true" {
-		return os.Stdout
+		return DebugLevel
 	} else {
-		return io.Discard
+		return InfoLevel
 	}
 }
@@ -0,0 +1,69 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTextFormat(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newLogger("info", &buf, "[INFO] ")
+
+	logger.WithFields(Fields{"identifier": "my-sa@p.iam.gserviceaccount.com"}).Printf("hello %s", "world")
+
+	assert.Regexp(t, `^\[INFO\] \d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2} hello world\n$`, buf.String())
+}
+
+func TestJSONFormat(t *testing.T) {
+	require.NoError(t, SetFormat("json"))
+	t.Cleanup(func() {
+		require.NoError(t, SetFormat("text"))
+	})
+
+	var buf bytes.Buffer
+	logger := newLogger("warn", &buf, "[WARN] ")
+
+	logger.WithFields(Fields{"identifier": "my-sa@p.iam.gserviceaccount.com", "event": "key_issued"}).
+		WithFields(Fields{"keyID": "key-1", "msg": "overridden"}).
+		Println("issued", "key-1")
+
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "warn", line["level"])
+	assert.Equal(t, "issued key-1", line["msg"])
+	assert.Equal(t, "my-sa@p.iam.gserviceaccount.com", line["identifier"])
+	assert.Equal(t, "key_issued", line["event"])
+	assert.Equal(t, "key-1", line["keyID"])
+	assert.NotEmpty(t, line["ts"])
+}
+
+func TestSetFormatRejectsUnsupportedFormats(t *testing.T) {
+	assert.ErrorContains(t, SetFormat("yaml"), `unsupported log format "yaml"`)
+}
+
+func TestSetLevelSuppressesLessSevereLines(t *testing.T) {
+	require.NoError(t, SetLevel("warn"))
+	t.Cleanup(func() {
+		require.NoError(t, SetLevel("info"))
+	})
+	assert.Equal(t, WarnLevel, GetLevel())
+
+	var buf bytes.Buffer
+	newLogger(DebugLevel, &buf, "[DEBUG] ").Print("debug")
+	newLogger(InfoLevel, &buf, "[INFO] ").Print("info")
+	newLogger(WarnLevel, &buf, "[WARN] ").Print("warn")
+	newLogger(ErrorLevel, &buf, "[ERROR] ").Print("error")
+
+	assert.NotContains(t, buf.String(), "debug")
+	assert.NotContains(t, buf.String(), "info")
+	assert.Contains(t, buf.String(), "[WARN]")
+	assert.Contains(t, buf.String(), "[ERROR]")
+}
+
+func TestSetLevelRejectsUnsupportedLevels(t *testing.T) {
+	assert.ErrorContains(t, SetLevel("trace"), `unsupported log level "trace"`)
+}
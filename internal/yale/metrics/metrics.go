@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/broadinstitute/yale/internal/yale/logs"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry all of Yale's metrics are registered with. A dedicated registry is used instead of the
+// Prometheus default so that only Yale's metrics are exposed
+var Registry = prometheus.NewRegistry()
+
+var (
+	// KeysIssued number of keys issued, by entry type (eg. GcpSaKey)
+	KeysIssued = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "yale_keys_issued_total",
+		Help: "Number of keys issued by Yale",
+	}, []string{"type"})
+	// KeysDisabled number of keys disabled, by entry type
+	KeysDisabled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "yale_keys_disabled_total",
+		Help: "Number of keys disabled by Yale",
+	}, []string{"type"})
+	// KeysDeleted number of keys deleted, by entry type
+	KeysDeleted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "yale_keys_deleted_total",
+		Help: "Number of keys deleted by Yale",
+	}, []string{"type"})
+	// RotatedKeysAccumulating number of times a cache entry's rotated keys piled up past the alert threshold
+	// without being disabled, by entry type
+	RotatedKeysAccumulating = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "yale_rotated_keys_accumulating_total",
+		Help: "Number of times rotated keys accumulated past the alert threshold without being disabled",
+	}, []string{"type"})
+	// SyncErrors number of errors syncing a key to a destination, by destination (eg. vault, gsm)
+	SyncErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "yale_sync_errors_total",
+		Help: "Number of errors syncing keys to destinations",
+	}, []string{"destination"})
+	// RunDuration time taken by each Yale run
+	RunDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "yale_run_duration_seconds",
+		Help:    "Duration of Yale runs",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 12),
+	})
+	// OldestSuccessfulRun timestamp of the least recent successful run among all cache entries, used to alert
+	// on entries that Yale has silently stopped reconciling
+	OldestSuccessfulRun = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "yale_oldest_successful_run_timestamp_seconds",
+		Help: "Unix timestamp of the least recent successful run among all cache entries",
+	})
+)
+
+// destinations that SyncErrors may be labeled with
+const (
+	K8sDestination           = "k8s"
+	TargetClusterDestination = "target_cluster"
+	VaultDestination         = "vault"
+	GSMDestination           = "gsm"
+	GitHubDestination        = "github"
+	GitLabDestination        = "gitlab"
+	AWSDestination           = "aws"
+	GCSDestination           = "gcs"
+	ConsulDestination        = "consul"
+)
+
+func init() {
+	Registry.MustRegister(KeysIssued, KeysDisabled, KeysDeleted, RotatedKeysAccumulating, SyncErrors, RunDuration, OldestSuccessfulRun)
+}
+
+// Handler returns an http.Handler that serves the metrics in Registry
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// Serve starts an HTTP server in the background that exposes the metrics in Registry at /metrics on the given address
+func Serve(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		logs.Info.Printf("serving metrics at %s/metrics", addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logs.Error.Printf("error serving metrics at %s: %v", addr, err)
+		}
+	}()
+
+	return server
+}
@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Handler_ServesYaleMetrics(t *testing.T) {
+	KeysIssued.WithLabelValues("GcpSaKey").Inc()
+	SyncErrors.WithLabelValues(VaultDestination).Inc()
+	RunDuration.Observe(3)
+
+	server := httptest.NewServer(Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `yale_keys_issued_total{type="GcpSaKey"} 1`)
+	assert.Contains(t, string(body), `yale_sync_errors_total{destination="vault"} 1`)
+	assert.Contains(t, string(body), `yale_run_duration_seconds_count 1`)
+}
+
+func Test_Registry_OnlyContainsYaleMetrics(t *testing.T) {
+	KeysDeleted.WithLabelValues("AzureClientSecret").Inc()
+
+	count, err := testutil.GatherAndCount(Registry)
+	require.NoError(t, err)
+	assert.Greater(t, count, 0)
+
+	families, err := Registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		assert.Regexp(t, "^yale_", family.GetName())
+	}
+}
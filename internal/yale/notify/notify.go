@@ -0,0 +1,99 @@
+package notify
+
+import (
+	"errors"
+	"time"
+
+	"github.com/broadinstitute/yale/internal/yale/cache"
+)
+
+// Notifier reports key lifecycle events and errors to a chat backend (eg. Slack or Microsoft Teams)
+type Notifier interface {
+	// Error reports an error message
+	Error(entry *cache.Entry, message string) error
+	// ErrorDigest reports the errors for several cache entries in a single message
+	ErrorDigest(entries []*cache.Entry) error
+	// KeyIssued reports a key issued event
+	KeyIssued(entry *cache.Entry, id string) error
+	// KeyDisabled reports a key disabled event
+	KeyDisabled(entry *cache.Entry, id string) error
+	// KeyDeleted reports a key deleted event
+	KeyDeleted(entry *cache.Entry, id string) error
+	// KeyApproachingRotation reports that a key will be rotated at rotateAt
+	KeyApproachingRotation(entry *cache.Entry, id string, rotateAt time.Time) error
+	// DestinationUnhealthy reports that Yale stopped syncing keys to a destination (eg. Vault) for the rest
+	// of the run, after failures errors syncing to it
+	DestinationUnhealthy(destination string, failures int) error
+	// RotatedKeysAccumulating reports that count rotated keys are waiting to be disabled for the entry, more than
+	// Yale expects, which usually means a consumer of the entry's keys is still using an old one
+	RotatedKeysAccumulating(entry *cache.Entry, count int) error
+}
+
+// NewComposite returns a Notifier that sends every notification to all of the given notifiers. A notifier
+// that fails does not prevent the others from being notified; all errors are returned together.
+func NewComposite(notifiers ...Notifier) Notifier {
+	if len(notifiers) == 1 {
+		return notifiers[0]
+	}
+	return composite(notifiers)
+}
+
+type composite []Notifier
+
+func (c composite) KeyIssued(entry *cache.Entry, id string) error {
+	return c.notifyAll(func(n Notifier) error {
+		return n.KeyIssued(entry, id)
+	})
+}
+
+func (c composite) KeyDisabled(entry *cache.Entry, id string) error {
+	return c.notifyAll(func(n Notifier) error {
+		return n.KeyDisabled(entry, id)
+	})
+}
+
+func (c composite) KeyDeleted(entry *cache.Entry, id string) error {
+	return c.notifyAll(func(n Notifier) error {
+		return n.KeyDeleted(entry, id)
+	})
+}
+
+func (c composite) KeyApproachingRotation(entry *cache.Entry, id string, rotateAt time.Time) error {
+	return c.notifyAll(func(n Notifier) error {
+		return n.KeyApproachingRotation(entry, id, rotateAt)
+	})
+}
+
+func (c composite) Error(entry *cache.Entry, message string) error {
+	return c.notifyAll(func(n Notifier) error {
+		return n.Error(entry, message)
+	})
+}
+
+func (c composite) ErrorDigest(entries []*cache.Entry) error {
+	return c.notifyAll(func(n Notifier) error {
+		return n.ErrorDigest(entries)
+	})
+}
+
+func (c composite) DestinationUnhealthy(destination string, failures int) error {
+	return c.notifyAll(func(n Notifier) error {
+		return n.DestinationUnhealthy(destination, failures)
+	})
+}
+
+func (c composite) RotatedKeysAccumulating(entry *cache.Entry, count int) error {
+	return c.notifyAll(func(n Notifier) error {
+		return n.RotatedKeysAccumulating(entry, count)
+	})
+}
+
+func (c composite) notifyAll(fn func(Notifier) error) error {
+	var errs []error
+	for _, n := range c {
+		if err := fn(n); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,88 @@
+package notify
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/broadinstitute/yale/internal/yale/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewComposite_ReturnsSingleNotifier(t *testing.T) {
+	n := &recordingNotifier{}
+	assert.Same(t, n, NewComposite(n))
+}
+
+func Test_Composite_NotifiesAll(t *testing.T) {
+	first := &recordingNotifier{}
+	second := &recordingNotifier{}
+	c := NewComposite(first, second)
+
+	entry := &cache.Entry{Type: cache.GcpSaKey}
+	require.NoError(t, c.KeyIssued(entry, "1"))
+	require.NoError(t, c.KeyDisabled(entry, "2"))
+	require.NoError(t, c.KeyDeleted(entry, "3"))
+	require.NoError(t, c.KeyApproachingRotation(entry, "4", time.Date(2023, 4, 28, 9, 10, 11, 0, time.UTC)))
+	require.NoError(t, c.Error(entry, "uh-oh"))
+	require.NoError(t, c.ErrorDigest([]*cache.Entry{entry, entry}))
+	require.NoError(t, c.DestinationUnhealthy("vault", 5))
+	require.NoError(t, c.RotatedKeysAccumulating(entry, 4))
+
+	expected := []string{"issued 1", "disabled 2", "deleted 3", "approaching rotation 4 at 2023-04-28T09:10:11Z", "error uh-oh", "digest 2", "unhealthy vault 5", "accumulating 4"}
+	assert.Equal(t, expected, first.events)
+	assert.Equal(t, expected, second.events)
+}
+
+func Test_Composite_NotifiesAllEvenIfOneFails(t *testing.T) {
+	failing := &recordingNotifier{err: fmt.Errorf("webhook is down")}
+	working := &recordingNotifier{}
+	c := NewComposite(failing, working)
+
+	err := c.Error(&cache.Entry{Type: cache.GcpSaKey}, "uh-oh")
+	assert.ErrorContains(t, err, "webhook is down")
+	assert.Equal(t, []string{"error uh-oh"}, working.events)
+}
+
+type recordingNotifier struct {
+	events []string
+	err    error
+}
+
+func (r *recordingNotifier) KeyIssued(_ *cache.Entry, id string) error {
+	return r.record("issued " + id)
+}
+
+func (r *recordingNotifier) KeyDisabled(_ *cache.Entry, id string) error {
+	return r.record("disabled " + id)
+}
+
+func (r *recordingNotifier) KeyDeleted(_ *cache.Entry, id string) error {
+	return r.record("deleted " + id)
+}
+
+func (r *recordingNotifier) KeyApproachingRotation(_ *cache.Entry, id string, rotateAt time.Time) error {
+	return r.record("approaching rotation " + id + " at " + rotateAt.Format(time.RFC3339))
+}
+
+func (r *recordingNotifier) Error(_ *cache.Entry, message string) error {
+	return r.record("error " + message)
+}
+
+func (r *recordingNotifier) ErrorDigest(entries []*cache.Entry) error {
+	return r.record(fmt.Sprintf("digest %d", len(entries)))
+}
+
+func (r *recordingNotifier) DestinationUnhealthy(destination string, failures int) error {
+	return r.record(fmt.Sprintf("unhealthy %s %d", destination, failures))
+}
+
+func (r *recordingNotifier) RotatedKeysAccumulating(_ *cache.Entry, count int) error {
+	return r.record(fmt.Sprintf("accumulating %d", count))
+}
+
+func (r *recordingNotifier) record(event string) error {
+	r.events = append(r.events, event)
+	return r.err
+}
@@ -73,6 +73,68 @@ func (_c *Mapper_Build_Call) RunAndReturn(run func() (map[string]*resourcemap.Bu
 	return _c
 }
 
+// Check provides a mock function with given fields:
+func (_m *Mapper) Check() (map[string]*resourcemap.Bundle, []error, error) {
+	ret := _m.Called()
+
+	var r0 map[string]*resourcemap.Bundle
+	var r1 []error
+	var r2 error
+	if rf, ok := ret.Get(0).(func() (map[string]*resourcemap.Bundle, []error, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() map[string]*resourcemap.Bundle); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]*resourcemap.Bundle)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() []error); ok {
+		r1 = rf()
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]error)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func() error); ok {
+		r2 = rf()
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Mapper_Check_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Check'
+type Mapper_Check_Call struct {
+	*mock.Call
+}
+
+// Check is a helper method to define mock.On call
+func (_e *Mapper_Expecter) Check() *Mapper_Check_Call {
+	return &Mapper_Check_Call{Call: _e.mock.On("Check")}
+}
+
+func (_c *Mapper_Check_Call) Run(run func()) *Mapper_Check_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Mapper_Check_Call) Return(_a0 map[string]*resourcemap.Bundle, _a1 []error, _a2 error) *Mapper_Check_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *Mapper_Check_Call) RunAndReturn(run func() (map[string]*resourcemap.Bundle, []error, error)) *Mapper_Check_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 type mockConstructorTestingTNewMapper interface {
 	mock.TestingT
 	Cleanup(func())
@@ -0,0 +1,146 @@
+package resourcemap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/broadinstitute/yale/internal/yale/cache"
+	"github.com/broadinstitute/yale/internal/yale/keysync"
+	"github.com/broadinstitute/yale/internal/yale/logs"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// secretRef a K8s secret that a bundle's resources sync their key to, and the data field the key is stored in
+type secretRef struct {
+	namespace string
+	name      string
+	keyName   string
+}
+
+// recoverEntryFromSecrets restores the current key of a cache entry that has never held a key (eg. because
+// its cache secret was deleted and Yale re-created it empty) from the K8s secrets the bundle's resources sync
+// to, so that Yale keeps using the live key instead of issuing a new one and orphaning it. Only secrets with
+// the key id annotation Yale adds when syncing are considered, and recovery is skipped if they disagree.
+func (m *mapper) recoverEntryFromSecrets(bundle *Bundle) error {
+	if bundle.Entry == nil || !neverHeldKey(bundle.Entry) {
+		return nil
+	}
+	identifier := bundle.Entry.Identify()
+
+	var recovered *cache.CurrentKey
+	var source string
+	for _, ref := range secretRefs(bundle) {
+		current, err := m.readKeyFromSecret(bundle.Entry, ref)
+		if err != nil {
+			return fmt.Errorf("error recovering cache entry for %s from secret %s/%s: %v", identifier, ref.namespace, ref.name, err)
+		}
+		if current == nil {
+			continue
+		}
+		if recovered != nil && recovered.ID != current.ID {
+			logs.Warn.Printf("secrets %s and %s/%s hold different keys (%s and %s) for %s; won't recover cache entry", source, ref.namespace, ref.name, recovered.ID, current.ID, identifier)
+			return nil
+		}
+		if recovered == nil {
+			recovered = current
+			source = ref.namespace + "/" + ref.name
+		}
+	}
+	if recovered == nil {
+		return nil
+	}
+
+	logs.Info.Printf("recovering cache entry for %s from secret %s: current key is %s", identifier, source, recovered.ID)
+	bundle.Entry.CurrentKey = *recovered
+	if err := m.cache.Save(bundle.Entry); err != nil {
+		return fmt.Errorf("error saving recovered cache entry for %s: %v", identifier, err)
+	}
+	return nil
+}
+
+// readKeyFromSecret returns the key held by the referenced secret, or nil if the secret does not exist, was not
+// synced by Yale, or does not hold a parseable key
+func (m *mapper) readKeyFromSecret(entry *cache.Entry, ref secretRef) (*cache.CurrentKey, error) {
+	secret, err := m.options.K8s.CoreV1().Secrets(ref.namespace).Get(context.Background(), ref.name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	keyID := secret.Annotations[keysync.KeyIDAnnotation]
+	if keyID == "" {
+		logs.Debug.Printf("secret %s/%s has no %s annotation; won't use it to recover cache entry for %s", ref.namespace, ref.name, keysync.KeyIDAnnotation, entry.Identify())
+		return nil, nil
+	}
+	data := secret.Data[ref.keyName]
+	if err = validateRecoveredKey(entry.Type, keyID, data); err != nil {
+		logs.Warn.Printf("secret %s/%s does not hold a usable key for %s; won't use it to recover cache entry: %v", ref.namespace, ref.name, entry.Identify(), err)
+		return nil, nil
+	}
+	// the secret can't be older than the key it was first synced with, and rotating a recovered key early is
+	// harmless, so its creation time is a safe stand-in for when the key was issued
+	createdAt := secret.CreationTimestamp.Time
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	return &cache.CurrentKey{
+		ID:        keyID,
+		JSON:      string(data),
+		CreatedAt: createdAt,
+	}, nil
+}
+
+// validateRecoveredKey returns an error if data is not a usable key with the given id
+func validateRecoveredKey(entryType cache.EntryType, keyID string, data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("key data is empty")
+	}
+	if entryType != cache.GcpSaKey {
+		return nil
+	}
+	var parsed struct {
+		PrivateKeyID string `json:"private_key_id"`
+		PrivateKey   string `json:"private_key"`
+	}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return fmt.Errorf("failed to decode key JSON: %v", err)
+	}
+	if parsed.PrivateKey == "" {
+		return fmt.Errorf("key JSON has no private key")
+	}
+	if parsed.PrivateKeyID != "" && parsed.PrivateKeyID != keyID {
+		return fmt.Errorf("key JSON has private key id %s, but the secret is annotated with key id %s", parsed.PrivateKeyID, keyID)
+	}
+	return nil
+}
+
+// neverHeldKey returns true if the cache entry has no record of any key, which is the case for entries that
+// were just created
+func neverHeldKey(entry *cache.Entry) bool {
+	return entry.CurrentKey.ID == "" &&
+		len(entry.RotatedKeys) == 0 &&
+		len(entry.DisabledKeys) == 0 &&
+		len(entry.History) == 0 &&
+		entry.LastSuccessfulRun.IsZero()
+}
+
+// secretRefs returns the local K8s secrets the bundle's resources sync their key to. Resources that only
+// replicate to external backends have no secret of their own, so only their additional secrets are included
+func secretRefs(bundle *Bundle) []secretRef {
+	var refs []secretRef
+	for _, gsk := range bundle.GSKs {
+		for _, spec := range keysync.AllSecrets(gsk) {
+			refs = append(refs, secretRef{namespace: gsk.ObjectMeta.Namespace, name: spec.Name, keyName: spec.JsonKeyName})
+		}
+	}
+	for _, acs := range bundle.AzClientSecrets {
+		for _, spec := range keysync.AllSecrets(acs) {
+			refs = append(refs, secretRef{namespace: acs.ObjectMeta.Namespace, name: spec.Name, keyName: spec.ClientSecretKeyName})
+		}
+	}
+	return refs
+}
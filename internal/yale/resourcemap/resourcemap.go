@@ -3,12 +3,14 @@ package resourcemap
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	"github.com/broadinstitute/yale/internal/yale/cache"
 	"github.com/broadinstitute/yale/internal/yale/crd/api/v1beta1"
 	v1beta1client "github.com/broadinstitute/yale/internal/yale/crd/clientset/v1beta1"
 	"github.com/broadinstitute/yale/internal/yale/logs"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 )
 
 // Bundle represents a bundle of resources associated with a specific service account
@@ -32,29 +34,74 @@ type Mapper interface {
 	// (say, different GcpSaKeys and/or the cache entry reference different projects),
 	// BuildMap will log a warning and exclude the service account from the resulting map.
 	Build() (map[string]*Bundle, error)
+	// Check is like Build, but makes no changes to the cache: bundles without a cache entry are left without
+	// one, instead of getting a new empty entry, and unique IDs are not recorded on existing entries. It also
+	// returns the problems that Build logs as warnings, namely invalid GcpSaKey and AzureClientSecret specs
+	// and service accounts whose resources conflict with each other or with their cache entry.
+	Check() (map[string]*Bundle, []error, error)
 }
 
-func New(crd v1beta1client.YaleCRDInterface, cache cache.Cache) Mapper {
-	return &mapper{crd, cache}
+// Options configures which resources the mapper includes in bundles
+type Options struct {
+	// IncludeNamespaces if not empty, only GcpSaKeys and AzureClientSecrets in these namespaces are bundled
+	IncludeNamespaces []string
+	// ExcludeNamespaces GcpSaKeys and AzureClientSecrets in these namespaces are never bundled, even if they
+	// are also in IncludeNamespaces
+	ExcludeNamespaces []string
+	// LabelSelector if not empty, only GcpSaKeys and AzureClientSecrets matching this label selector
+	// (eg. "tier=critical") are listed
+	LabelSelector string
+	// RecoverCacheFromSecrets if true, cache entries that have never held a key get their current key from the
+	// K8s secrets their resources sync to, if Yale synced a key to them before (see recoverEntryFromSecrets)
+	RecoverCacheFromSecrets bool
+	// K8s client used to read secrets when RecoverCacheFromSecrets is true
+	K8s kubernetes.Interface
+}
+
+type Option func(*Options)
+
+func New(crd v1beta1client.YaleCRDInterface, cache cache.Cache, options ...Option) Mapper {
+	var opts Options
+	for _, option := range options {
+		option(&opts)
+	}
+	return &mapper{crd, cache, opts}
 }
 
 type mapper struct {
-	crd   v1beta1client.YaleCRDInterface
-	cache cache.Cache
+	crd     v1beta1client.YaleCRDInterface
+	cache   cache.Cache
+	options Options
 }
 
 func (m *mapper) Build() (map[string]*Bundle, error) {
+	result, _, err := m.build(false)
+	return result, err
+}
+
+func (m *mapper) Check() (map[string]*Bundle, []error, error) {
+	return m.build(true)
+}
+
+// build inspects the cluster and organizes its resources into bundles, returning the problems that caused
+// resources or bundles to be skipped. If readOnly is true, the cache is not modified.
+func (m *mapper) build(readOnly bool) (map[string]*Bundle, []error, error) {
 	result := make(map[string]*Bundle)
+	var problems []error
+
+	// identifiers with at least one resource that was skipped, because its spec is invalid or it is in a namespace
+	// that is filtered out
+	skipped := make(map[string]struct{})
 
 	// gskList GSKs and organize them into bundles, by service account email
-	gskList, err := m.listGcpSaKeys()
+	gskList, err := m.listGcpSaKeys(skipped, &problems)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	acsList, err := m.listAzureClientSecrets()
+	acsList, err := m.listAzureClientSecrets(skipped, &problems)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	for _, gsk := range gskList {
@@ -83,7 +130,7 @@ func (m *mapper) Build() (map[string]*Bundle, error) {
 	// add cache entries to the bundle
 	cacheEntries, err := m.cache.List()
 	if err != nil {
-		return nil, fmt.Errorf("error listing cache entries: %v", err)
+		return nil, nil, fmt.Errorf("error listing cache entries: %v", err)
 	}
 
 	for _, entry := range cacheEntries {
@@ -96,23 +143,41 @@ func (m *mapper) Build() (map[string]*Bundle, error) {
 		bundle.Entry = entry
 	}
 
+	// skip cache entries whose resources were all skipped. Processing them as if they had no resources in the
+	// cluster would retire keys, and delete secrets, that are still in use
+	for identifier, bundle := range result {
+		if _, exists := skipped[identifier]; !exists {
+			continue
+		}
+		if isEmpty(bundle.GSKs) && isEmpty(bundle.AzClientSecrets) {
+			logs.Info.Printf("all resources for %s are invalid or in filtered namespaces, won't process", identifier)
+			delete(result, identifier)
+		}
+	}
+
 	// filter invalid bundles
 	for identifier, bundle := range result {
 		if err = validateResourceBundle(bundle); err != nil {
 			logs.Warn.Printf("invalid cluster resources for service account %s, won't process: %v", identifier, err)
+			problems = append(problems, fmt.Errorf("invalid cluster resources for service account %s: %v", identifier, err))
 			delete(result, identifier)
 		}
 	}
 
+	if readOnly {
+		return result, problems, nil
+	}
+
 	// add new empty cache entries for any bundles that don't have one
 	for identifier, bundle := range result {
 		if bundle.Entry == nil && bundle.GSKs != nil {
 			entry, err := m.cache.GetOrCreate(cache.GcpSaKeyEntryIdentifier{
-				Email:   identifier,
-				Project: bundle.GSKs[0].Spec.GoogleServiceAccount.Project,
+				Email:    identifier,
+				Project:  bundle.GSKs[0].Spec.GoogleServiceAccount.Project,
+				UniqueID: bundle.GSKs[0].Spec.GoogleServiceAccount.UniqueID,
 			})
 			if err != nil {
-				return nil, fmt.Errorf("error creating new empty cache entry for service account %s: %v", identifier, err)
+				return nil, nil, fmt.Errorf("error creating new empty cache entry for service account %s: %v", identifier, err)
 			}
 			bundle.Entry = entry
 		} else if bundle.Entry == nil && bundle.AzClientSecrets != nil {
@@ -121,18 +186,66 @@ func (m *mapper) Build() (map[string]*Bundle, error) {
 				TenantID:      bundle.AzClientSecrets[0].Spec.AzureServicePrincipal.TenantID,
 			})
 			if err != nil {
-				return nil, fmt.Errorf("error creating new empty cache entry for az client secret %s: %v", identifier, err)
+				return nil, nil, fmt.Errorf("error creating new empty cache entry for az client secret %s: %v", identifier, err)
 			}
 			bundle.Entry = entry
 		}
 	}
 
-	return result, nil
+	// recover the current key of empty cache entries from the secrets their key was synced to
+	if m.options.RecoverCacheFromSecrets {
+		for _, bundle := range result {
+			if err = m.recoverEntryFromSecrets(bundle); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	// record unique IDs on existing cache entries that predate them
+	for identifier, bundle := range result {
+		if err = m.backfillUniqueID(bundle); err != nil {
+			return nil, nil, fmt.Errorf("error recording unique id for service account %s: %v", identifier, err)
+		}
+	}
+
+	return result, problems, nil
 }
 
-// listGcpSaKeys retrieves a list of GcpSaKey resources in the cluster, discarding any invalid ones
-func (m *mapper) listGcpSaKeys() ([]v1beta1.GcpSaKey, error) {
-	list, err := m.crd.GcpSaKeys().List(context.Background(), metav1.ListOptions{})
+// backfillUniqueID records the unique ID from a bundle's GcpSaKeys on its cache entry,
+// if the entry does not have one yet
+func (m *mapper) backfillUniqueID(bundle *Bundle) error {
+	if isEmpty(bundle.GSKs) || bundle.Entry == nil {
+		return nil
+	}
+	uniqueID := gskUniqueID(bundle.GSKs)
+	if uniqueID == "" {
+		return nil
+	}
+	identifier, ok := bundle.Entry.Identifier.(cache.GcpSaKeyEntryIdentifier)
+	if !ok || identifier.UniqueID != "" {
+		return nil
+	}
+	logs.Info.Printf("recording unique id %s for service account %s", uniqueID, identifier.Email)
+	identifier.UniqueID = uniqueID
+	bundle.Entry.Identifier = identifier
+	return m.cache.Save(bundle.Entry)
+}
+
+// gskUniqueID returns the first non-empty unique ID in the list of GcpSaKeys
+func gskUniqueID(gsks []v1beta1.GcpSaKey) string {
+	for _, gsk := range gsks {
+		if gsk.Spec.GoogleServiceAccount.UniqueID != "" {
+			return gsk.Spec.GoogleServiceAccount.UniqueID
+		}
+	}
+	return ""
+}
+
+// listGcpSaKeys retrieves a list of GcpSaKey resources in the cluster, discarding any invalid ones and adding
+// their validation errors to problems. Resources in filtered namespaces are also discarded. The service account
+// emails of discarded resources are added to skipped.
+func (m *mapper) listGcpSaKeys(skipped map[string]struct{}, problems *[]error) ([]v1beta1.GcpSaKey, error) {
+	list, err := m.crd.GcpSaKeys().List(context.Background(), m.listOptions())
 	if err != nil {
 		return nil, fmt.Errorf("error retrieving list of Yale CRDs from cluster: %v", err)
 	}
@@ -140,12 +253,16 @@ func (m *mapper) listGcpSaKeys() ([]v1beta1.GcpSaKey, error) {
 	var result []v1beta1.GcpSaKey
 
 	for _, gsk := range list.Items {
-		if gsk.Spec.GoogleServiceAccount.Name == "" {
-			logs.Warn.Printf("GcpSaKey resource %s/%s has invalid spec: missing google service account name", gsk.ObjectMeta.Namespace, gsk.ObjectMeta.Name)
+		gsk.Spec.SetDefaults()
+		if err = gsk.Spec.Validate(); err != nil {
+			logs.Warn.Printf("GcpSaKey resource %s/%s has invalid spec: %v", gsk.ObjectMeta.Namespace, gsk.ObjectMeta.Name, err)
+			*problems = append(*problems, fmt.Errorf("GcpSaKey resource %s/%s has invalid spec: %v", gsk.ObjectMeta.Namespace, gsk.ObjectMeta.Name, err))
+			skipped[gsk.Spec.GoogleServiceAccount.Name] = struct{}{}
 			continue
 		}
-		if gsk.Spec.GoogleServiceAccount.Project == "" {
-			logs.Warn.Printf("GcpSaKey resource %s/%s has invalid spec: missing google service account project", gsk.ObjectMeta.Namespace, gsk.ObjectMeta.Name)
+		if !m.namespaceIncluded(gsk.ObjectMeta.Namespace) {
+			logs.Debug.Printf("GcpSaKey resource %s/%s is in a filtered namespace, skipping", gsk.ObjectMeta.Namespace, gsk.ObjectMeta.Name)
+			skipped[gsk.Spec.GoogleServiceAccount.Name] = struct{}{}
 			continue
 		}
 		result = append(result, gsk)
@@ -155,20 +272,25 @@ func (m *mapper) listGcpSaKeys() ([]v1beta1.GcpSaKey, error) {
 }
 
 // listAzureClientSecrets retrieves a list of AzureClientSecret resources in the cluster, discarding any invalid ones
-func (m *mapper) listAzureClientSecrets() ([]v1beta1.AzureClientSecret, error) {
-	list, err := m.crd.AzureClientSecrets().List(context.Background(), metav1.ListOptions{})
+// and adding their validation errors to problems. Resources in filtered namespaces are also discarded. The
+// application IDs of discarded resources are added to skipped.
+func (m *mapper) listAzureClientSecrets(skipped map[string]struct{}, problems *[]error) ([]v1beta1.AzureClientSecret, error) {
+	list, err := m.crd.AzureClientSecrets().List(context.Background(), m.listOptions())
 	if err != nil {
 		return nil, fmt.Errorf("error retrieving list of AzureClientSecret CRDs from cluster: %v", err)
 	}
 
 	var result []v1beta1.AzureClientSecret
 	for _, azureClientSecret := range list.Items {
-		if azureClientSecret.Spec.AzureServicePrincipal.ApplicationID == "" {
-			logs.Warn.Printf("AzureClientSecret resource %s/%s has invalid spec: missing azure service principal application id", azureClientSecret.Namespace(), azureClientSecret.Name())
+		if err = azureClientSecret.Spec.Validate(); err != nil {
+			logs.Warn.Printf("AzureClientSecret resource %s/%s has invalid spec: %v", azureClientSecret.Namespace(), azureClientSecret.Name(), err)
+			*problems = append(*problems, fmt.Errorf("AzureClientSecret resource %s/%s has invalid spec: %v", azureClientSecret.Namespace(), azureClientSecret.Name(), err))
+			skipped[azureClientSecret.Spec.AzureServicePrincipal.ApplicationID] = struct{}{}
 			continue
 		}
-		if azureClientSecret.Spec.AzureServicePrincipal.TenantID == "" {
-			logs.Warn.Printf("AzureClientSecret resource %s/%s has invalid spec: missing azure service principal tenant id", azureClientSecret.Namespace(), azureClientSecret.Name())
+		if !m.namespaceIncluded(azureClientSecret.Namespace()) {
+			logs.Debug.Printf("AzureClientSecret resource %s/%s is in a filtered namespace, skipping", azureClientSecret.Namespace(), azureClientSecret.Name())
+			skipped[azureClientSecret.Spec.AzureServicePrincipal.ApplicationID] = struct{}{}
 			continue
 		}
 		result = append(result, azureClientSecret)
@@ -177,6 +299,24 @@ func (m *mapper) listAzureClientSecrets() ([]v1beta1.AzureClientSecret, error) {
 	return result, nil
 }
 
+// listOptions returns the options used to list GcpSaKeys and AzureClientSecrets, so that both are filtered
+// by the same label selector
+func (m *mapper) listOptions() metav1.ListOptions {
+	return metav1.ListOptions{LabelSelector: m.options.LabelSelector}
+}
+
+// namespaceIncluded returns true if resources in the namespace should be bundled. Exclusions take precedence
+// over inclusions.
+func (m *mapper) namespaceIncluded(namespace string) bool {
+	if slices.Contains(m.options.ExcludeNamespaces, namespace) {
+		return false
+	}
+	if len(m.options.IncludeNamespaces) == 0 {
+		return true
+	}
+	return slices.Contains(m.options.IncludeNamespaces, namespace)
+}
+
 // validateResourceBundle verifies that the GcpSaKeys and cache entry in the bundle don't conflict with each other
 func validateResourceBundle(bundle *Bundle) error {
 	// A bundle shouldn't have both GSKs and AzureClientSecrets
@@ -189,6 +329,8 @@ func validateResourceBundle(bundle *Bundle) error {
 	if !isEmpty(bundle.GSKs) {
 		// we have at least one GSK - use first as "source of truth" for comparison with other resources
 		cmp := bundle.GSKs[0]
+		// unique ids are optional, so compare against the first one that is set
+		uniqueID := gskUniqueID(bundle.GSKs)
 
 		// we have at least 2 GSKs, make sure they all match each other
 		if len(bundle.GSKs) > 1 {
@@ -198,6 +340,15 @@ func validateResourceBundle(bundle *Bundle) error {
 						gsk.ObjectMeta.Namespace, gsk.ObjectMeta.Name, gsk.Spec.GoogleServiceAccount.Name, gsk.Spec.GoogleServiceAccount.Project,
 						cmp.ObjectMeta.Namespace, cmp.ObjectMeta.Name, cmp.Spec.GoogleServiceAccount.Project)
 				}
+				if gsk.Spec.KeyAlgorithm != cmp.Spec.KeyAlgorithm || gsk.Spec.KeyType != cmp.Spec.KeyType {
+					return fmt.Errorf("key format mismatch: GcpSaKey resource %s/%s for %s has invalid spec: key algorithm %q and type %q do not match %s/%s key algorithm %q and type %q",
+						gsk.ObjectMeta.Namespace, gsk.ObjectMeta.Name, gsk.Spec.GoogleServiceAccount.Name, gsk.Spec.KeyAlgorithm, gsk.Spec.KeyType,
+						cmp.ObjectMeta.Namespace, cmp.ObjectMeta.Name, cmp.Spec.KeyAlgorithm, cmp.Spec.KeyType)
+				}
+				if gsk.Spec.GoogleServiceAccount.UniqueID != "" && gsk.Spec.GoogleServiceAccount.UniqueID != uniqueID {
+					return fmt.Errorf("unique id mismatch: GcpSaKey resource %s/%s for %s has invalid spec: unique id %s does not match unique id %s of other GcpSaKey resources",
+						gsk.ObjectMeta.Namespace, gsk.ObjectMeta.Name, gsk.Spec.GoogleServiceAccount.Name, gsk.Spec.GoogleServiceAccount.UniqueID, uniqueID)
+				}
 			}
 		}
 
@@ -212,6 +363,14 @@ func validateResourceBundle(bundle *Bundle) error {
 				bundle.Entry.Identify(), bundle.Entry.Scope(),
 				cmp.ObjectMeta.Namespace, cmp.ObjectMeta.Name, cmp.Spec.GoogleServiceAccount.Project)
 		}
+
+		// make sure cache entry was not created for a different service account with the same email
+		if identifier, ok := bundle.Entry.Identifier.(cache.GcpSaKeyEntryIdentifier); ok {
+			if uniqueID != "" && identifier.UniqueID != "" && identifier.UniqueID != uniqueID {
+				return fmt.Errorf("unique id mismatch: cache entry for service account %s has unique id %s, but GcpSaKey resources have unique id %s; the service account may have been deleted and recreated",
+					bundle.Entry.Identify(), identifier.UniqueID, uniqueID)
+			}
+		}
 		return nil
 
 		// we have at least one AzureClientSecret - use first as "source of truth" for comparison with other resources
@@ -1,8 +1,10 @@
 package resourcemap
 
 import (
+	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/broadinstitute/yale/internal/yale/cache"
 	cachemocks "github.com/broadinstitute/yale/internal/yale/cache/mocks"
@@ -11,7 +13,11 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 var gsk1a = v1beta1.GcpSaKey{
@@ -79,6 +85,36 @@ var gsk2bBroken = v1beta1.GcpSaKey{
 	},
 }
 
+var gsk1cUnsupportedAlgorithm = v1beta1.GcpSaKey{
+	ObjectMeta: metav1.ObjectMeta{
+		Name:      "gsk-1",
+		Namespace: "ns-c",
+	},
+	Spec: v1beta1.GCPSaKeySpec{
+		GoogleServiceAccount: v1beta1.GoogleServiceAccount{
+			Name:    "sa-1@p.com",
+			Project: "p",
+		},
+		KeyAlgorithm: "KEY_ALG_RSA_4096", // not a valid algorithm - gsk will be ignored
+	},
+}
+
+var gsk1dMalformedRepo = v1beta1.GcpSaKey{
+	ObjectMeta: metav1.ObjectMeta{
+		Name:      "gsk-1",
+		Namespace: "ns-d",
+	},
+	Spec: v1beta1.GCPSaKeySpec{
+		GoogleServiceAccount: v1beta1.GoogleServiceAccount{
+			Name:    "sa-1@p.com",
+			Project: "p",
+		},
+		GitHubReplications: []v1beta1.GitHubReplication{
+			{Secret: "my-secret", Repo: "no-org"}, // not in <org>/<repo> format - gsk will be ignored
+		},
+	},
+}
+
 var gsk4a = v1beta1.GcpSaKey{
 	ObjectMeta: metav1.ObjectMeta{
 		Name:      "gsk-4",
@@ -372,6 +408,30 @@ func Test_Build(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:                 "gsk with unsupported key algorithm should be ignored",
+			gsks:                 []v1beta1.GcpSaKey{gsk1a, gsk1cUnsupportedAlgorithm},
+			azClientSecrets:      []v1beta1.AzureClientSecret{},
+			existingCacheEntries: []*cache.Entry{entry1},
+			expected: map[string]*Bundle{
+				"sa-1@p.com": {
+					Entry: entry1,
+					GSKs:  []v1beta1.GcpSaKey{gsk1a},
+				},
+			},
+		},
+		{
+			name:                 "gsk with malformed github replication repo should be ignored",
+			gsks:                 []v1beta1.GcpSaKey{gsk1a, gsk1dMalformedRepo},
+			azClientSecrets:      []v1beta1.AzureClientSecret{},
+			existingCacheEntries: []*cache.Entry{entry1},
+			expected: map[string]*Bundle{
+				"sa-1@p.com": {
+					Entry: entry1,
+					GSKs:  []v1beta1.GcpSaKey{gsk1a},
+				},
+			},
+		},
 		{
 			name:                 "broken acs should lead service account to be skipped",
 			gsks:                 []v1beta1.GcpSaKey{},
@@ -749,6 +809,193 @@ func Test_validateResourceBundle(t *testing.T) {
 			},
 			errContains: "application id mismatch",
 		},
+		{
+			name: "should not error if bundle and gsk unique ids match",
+			input: &Bundle{
+				Entry: &cache.Entry{
+					Identifier: cache.GcpSaKeyEntryIdentifier{
+						Email:    "my-sa@p.com",
+						Project:  "p",
+						UniqueID: "1234",
+					},
+				},
+				GSKs: []v1beta1.GcpSaKey{
+					{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "gsk-1",
+							Namespace: "ns-1",
+						},
+						Spec: v1beta1.GCPSaKeySpec{
+							GoogleServiceAccount: v1beta1.GoogleServiceAccount{
+								Project:  "p",
+								UniqueID: "1234",
+							},
+						},
+					},
+				},
+			},
+			errContains: "",
+		},
+		{
+			name: "should not error if cache entry has no unique id",
+			input: &Bundle{
+				Entry: &cache.Entry{
+					Identifier: cache.GcpSaKeyEntryIdentifier{
+						Email:   "my-sa@p.com",
+						Project: "p",
+					},
+				},
+				GSKs: []v1beta1.GcpSaKey{
+					{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "gsk-1",
+							Namespace: "ns-1",
+						},
+						Spec: v1beta1.GCPSaKeySpec{
+							GoogleServiceAccount: v1beta1.GoogleServiceAccount{
+								Project:  "p",
+								UniqueID: "1234",
+							},
+						},
+					},
+				},
+			},
+			errContains: "",
+		},
+		{
+			name: "should not error if only some gsks have a unique id",
+			input: &Bundle{
+				Entry: &cache.Entry{
+					Identifier: cache.GcpSaKeyEntryIdentifier{
+						Email:    "my-sa@p.com",
+						Project:  "p",
+						UniqueID: "1234",
+					},
+				},
+				GSKs: []v1beta1.GcpSaKey{
+					{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "gsk-1",
+							Namespace: "ns-1",
+						},
+						Spec: v1beta1.GCPSaKeySpec{
+							GoogleServiceAccount: v1beta1.GoogleServiceAccount{
+								Project: "p",
+							},
+						},
+					},
+					{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "gsk-2",
+							Namespace: "ns-2",
+						},
+						Spec: v1beta1.GCPSaKeySpec{
+							GoogleServiceAccount: v1beta1.GoogleServiceAccount{
+								Project:  "p",
+								UniqueID: "1234",
+							},
+						},
+					},
+				},
+			},
+			errContains: "",
+		},
+		{
+			name: "should error if bundle and gsk unique ids do not match",
+			input: &Bundle{
+				Entry: &cache.Entry{
+					Identifier: cache.GcpSaKeyEntryIdentifier{
+						Email:    "my-sa@p.com",
+						Project:  "p",
+						UniqueID: "1234",
+					},
+				},
+				GSKs: []v1beta1.GcpSaKey{
+					{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "gsk-1",
+							Namespace: "ns-1",
+						},
+						Spec: v1beta1.GCPSaKeySpec{
+							GoogleServiceAccount: v1beta1.GoogleServiceAccount{
+								Project:  "p",
+								UniqueID: "5678",
+							},
+						},
+					},
+				},
+			},
+			errContains: "unique id mismatch",
+		},
+		{
+			name: "should error if gsk unique ids do not all match",
+			input: &Bundle{
+				Entry: &cache.Entry{
+					Identifier: cache.GcpSaKeyEntryIdentifier{
+						Email:   "my-sa@p.com",
+						Project: "p",
+					},
+				},
+				GSKs: []v1beta1.GcpSaKey{
+					{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "gsk-1",
+							Namespace: "ns-1",
+						},
+						Spec: v1beta1.GCPSaKeySpec{
+							GoogleServiceAccount: v1beta1.GoogleServiceAccount{
+								Project:  "p",
+								UniqueID: "1234",
+							},
+						},
+					},
+					{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "gsk-2",
+							Namespace: "ns-2",
+						},
+						Spec: v1beta1.GCPSaKeySpec{
+							GoogleServiceAccount: v1beta1.GoogleServiceAccount{
+								Project:  "p",
+								UniqueID: "5678",
+							},
+						},
+					},
+				},
+			},
+			errContains: "unique id mismatch",
+		},
+		{
+			name: "should error if gsk key algorithms do not all match",
+			input: &Bundle{
+				GSKs: []v1beta1.GcpSaKey{
+					{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "gsk-1",
+							Namespace: "ns-1",
+						},
+						Spec: v1beta1.GCPSaKeySpec{
+							GoogleServiceAccount: v1beta1.GoogleServiceAccount{
+								Project: "p",
+							},
+							KeyAlgorithm: "KEY_ALG_RSA_1024",
+						},
+					},
+					{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "gsk-2",
+							Namespace: "ns-2",
+						},
+						Spec: v1beta1.GCPSaKeySpec{
+							GoogleServiceAccount: v1beta1.GoogleServiceAccount{
+								Project: "p",
+							},
+						},
+					},
+				},
+			},
+			errContains: "key format mismatch",
+		},
 		{
 			name: "should error if bundle contains both gsks and AzClientSecrets",
 			input: &Bundle{
@@ -791,3 +1038,494 @@ func Test_validateResourceBundle(t *testing.T) {
 		})
 	}
 }
+
+func Test_Build_RecordsUniqueIDOnExistingCacheEntry(t *testing.T) {
+	gsk := gsk1a
+	gsk.Spec.GoogleServiceAccount.UniqueID = "1234"
+
+	entry := &cache.Entry{
+		Type: cache.GcpSaKey,
+		Identifier: cache.GcpSaKeyEntryIdentifier{
+			Email:   "sa-1@p.com",
+			Project: "p",
+		},
+	}
+
+	_cache := cachemocks.NewCache(t)
+	_cache.EXPECT().List().Return([]*cache.Entry{entry}, nil)
+	_cache.EXPECT().Save(mock.MatchedBy(func(e *cache.Entry) bool {
+		return e.Identifier.(cache.GcpSaKeyEntryIdentifier).UniqueID == "1234"
+	})).Return(nil)
+
+	gskEndpoint := crdmocks.NewGcpSaKeyInterface(t)
+	acsEndpoint := crdmocks.NewAzureClientSecretInterface(t)
+	crd := crdmocks.NewYaleCRDInterface(t)
+	crd.EXPECT().GcpSaKeys().Return(gskEndpoint)
+	crd.EXPECT().AzureClientSecrets().Return(acsEndpoint)
+	gskEndpoint.EXPECT().List(mock.Anything, metav1.ListOptions{}).Return(&v1beta1.GCPSaKeyList{
+		Items: []v1beta1.GcpSaKey{gsk},
+	}, nil)
+	acsEndpoint.EXPECT().List(mock.Anything, metav1.ListOptions{}).Return(&v1beta1.AzureClientSecretList{}, nil)
+
+	result, err := New(crd, _cache).Build()
+	require.NoError(t, err)
+	require.Contains(t, result, "sa-1@p.com")
+	assert.Equal(t, cache.GcpSaKeyEntryIdentifier{
+		Email:    "sa-1@p.com",
+		Project:  "p",
+		UniqueID: "1234",
+	}, result["sa-1@p.com"].Entry.Identifier)
+}
+
+func Test_Build_FiltersNamespaces(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		options              Options
+		existingCacheEntries []*cache.Entry
+		newCacheEntries      []*cache.Entry
+		expected             map[string]*Bundle
+	}{
+		{
+			name:                 "include only",
+			options:              Options{IncludeNamespaces: []string{"ns-a"}},
+			existingCacheEntries: []*cache.Entry{entry2},
+			newCacheEntries:      []*cache.Entry{entry1},
+			expected: map[string]*Bundle{
+				"sa-1@p.com": {
+					Entry: entry1,
+					GSKs:  []v1beta1.GcpSaKey{gsk1a},
+				},
+				"sa-2@p.com": {
+					Entry: entry2,
+					GSKs:  []v1beta1.GcpSaKey{gsk2a},
+				},
+				"app-id-1": {
+					Entry:           acsEntry1,
+					AzClientSecrets: []v1beta1.AzureClientSecret{acs1a},
+				},
+			},
+		},
+		{
+			name:                 "exclude",
+			options:              Options{ExcludeNamespaces: []string{"ns-a"}},
+			existingCacheEntries: []*cache.Entry{entry1, entry2},
+			expected: map[string]*Bundle{
+				// entry1's only GcpSaKey is excluded, so it is skipped rather than treated as having no resources
+				"sa-2@p.com": {
+					Entry: entry2,
+					GSKs:  []v1beta1.GcpSaKey{gsk2b},
+				},
+			},
+		},
+		{
+			name: "exclusions take precedence over inclusions",
+			options: Options{
+				IncludeNamespaces: []string{"ns-a", "ns-b"},
+				ExcludeNamespaces: []string{"ns-a"},
+			},
+			existingCacheEntries: []*cache.Entry{entry2},
+			expected: map[string]*Bundle{
+				"sa-2@p.com": {
+					Entry: entry2,
+					GSKs:  []v1beta1.GcpSaKey{gsk2b},
+				},
+			},
+		},
+		{
+			name:                 "cache entries without any resources are still processed",
+			options:              Options{IncludeNamespaces: []string{"ns-b"}},
+			existingCacheEntries: []*cache.Entry{entry2, entry3},
+			expected: map[string]*Bundle{
+				"sa-2@p.com": {
+					Entry: entry2,
+					GSKs:  []v1beta1.GcpSaKey{gsk2b},
+				},
+				"sa-3@p.com": {
+					Entry: entry3,
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_cache := cachemocks.NewCache(t)
+			_cache.EXPECT().List().Return(tc.existingCacheEntries, nil)
+			for _, entry := range tc.newCacheEntries {
+				_cache.EXPECT().GetOrCreate(cache.GcpSaKeyEntryIdentifier{
+					Email:   entry.Identify(),
+					Project: entry.Scope(),
+				}).Return(entry, nil)
+			}
+			if _, exists := tc.expected["app-id-1"]; exists {
+				_cache.EXPECT().GetOrCreate(cache.AzureClientSecretEntryIdentifier{
+					ApplicationID: acsEntry1.Identify(),
+					TenantID:      acsEntry1.Scope(),
+				}).Return(acsEntry1, nil)
+			}
+
+			gskEndpoint := crdmocks.NewGcpSaKeyInterface(t)
+			acsEndpoint := crdmocks.NewAzureClientSecretInterface(t)
+			crd := crdmocks.NewYaleCRDInterface(t)
+			crd.EXPECT().GcpSaKeys().Return(gskEndpoint)
+			crd.EXPECT().AzureClientSecrets().Return(acsEndpoint)
+			gskEndpoint.EXPECT().List(mock.Anything, metav1.ListOptions{}).Return(&v1beta1.GCPSaKeyList{
+				Items: []v1beta1.GcpSaKey{gsk1a, gsk2a, gsk2b},
+			}, nil)
+			acsEndpoint.EXPECT().List(mock.Anything, metav1.ListOptions{}).Return(&v1beta1.AzureClientSecretList{
+				Items: []v1beta1.AzureClientSecret{acs1a},
+			}, nil)
+
+			result, err := New(crd, _cache, func(opts *Options) {
+				*opts = tc.options
+			}).Build()
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func Test_Build_SkipsCacheEntriesWhoseResourcesAreAllInvalid(t *testing.T) {
+	// sa-2's only GcpSaKey has an invalid spec, so its cache entry is skipped rather than treated as having no
+	// resources, which would retire its keys and delete its secrets
+	invalid := gsk2a
+	invalid.Spec.KeyRotation.SafeToDisableBufferDays = -1
+
+	_cache := cachemocks.NewCache(t)
+	_cache.EXPECT().List().Return([]*cache.Entry{entry1, entry2}, nil)
+
+	gskEndpoint := crdmocks.NewGcpSaKeyInterface(t)
+	acsEndpoint := crdmocks.NewAzureClientSecretInterface(t)
+	crd := crdmocks.NewYaleCRDInterface(t)
+	crd.EXPECT().GcpSaKeys().Return(gskEndpoint)
+	crd.EXPECT().AzureClientSecrets().Return(acsEndpoint)
+	gskEndpoint.EXPECT().List(mock.Anything, metav1.ListOptions{}).Return(&v1beta1.GCPSaKeyList{
+		Items: []v1beta1.GcpSaKey{gsk1a, invalid},
+	}, nil)
+	acsEndpoint.EXPECT().List(mock.Anything, metav1.ListOptions{}).Return(&v1beta1.AzureClientSecretList{}, nil)
+
+	result, err := New(crd, _cache).Build()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]*Bundle{
+		"sa-1@p.com": {
+			Entry: entry1,
+			GSKs:  []v1beta1.GcpSaKey{gsk1a},
+		},
+	}, result)
+}
+
+func Test_Build_PassesLabelSelectorToBothListCalls(t *testing.T) {
+	_cache := cachemocks.NewCache(t)
+	_cache.EXPECT().List().Return([]*cache.Entry{entry1, acsEntry1}, nil)
+
+	listOptions := metav1.ListOptions{LabelSelector: "tier=critical"}
+
+	gskEndpoint := crdmocks.NewGcpSaKeyInterface(t)
+	acsEndpoint := crdmocks.NewAzureClientSecretInterface(t)
+	crd := crdmocks.NewYaleCRDInterface(t)
+	crd.EXPECT().GcpSaKeys().Return(gskEndpoint)
+	crd.EXPECT().AzureClientSecrets().Return(acsEndpoint)
+	gskEndpoint.EXPECT().List(mock.Anything, listOptions).Return(&v1beta1.GCPSaKeyList{
+		Items: []v1beta1.GcpSaKey{gsk1a},
+	}, nil)
+	acsEndpoint.EXPECT().List(mock.Anything, listOptions).Return(&v1beta1.AzureClientSecretList{
+		Items: []v1beta1.AzureClientSecret{acs1a},
+	}, nil)
+
+	result, err := New(crd, _cache, func(opts *Options) {
+		opts.LabelSelector = "tier=critical"
+	}).Build()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]*Bundle{
+		"sa-1@p.com": {
+			Entry: entry1,
+			GSKs:  []v1beta1.GcpSaKey{gsk1a},
+		},
+		"app-id-1": {
+			Entry:           acsEntry1,
+			AzClientSecrets: []v1beta1.AzureClientSecret{acs1a},
+		},
+	}, result)
+}
+
+func Test_Check_ReportsProblemsWithoutModifyingCache(t *testing.T) {
+	// unique id should not be recorded on the existing cache entry
+	withUniqueID := gsk1a
+	withUniqueID.Spec.GoogleServiceAccount.UniqueID = "1234"
+
+	// no cache entry should be created for this service account
+	withoutEntry := gsk1a
+	withoutEntry.ObjectMeta.Name = "gsk-2"
+	withoutEntry.Spec.GoogleServiceAccount.Name = "sa-2@p.com"
+
+	invalidSpec := gsk1a
+	invalidSpec.ObjectMeta.Name = "gsk-3"
+	invalidSpec.Spec.GoogleServiceAccount.Project = ""
+
+	wrongProject := gsk1a
+	wrongProject.ObjectMeta.Name = "gsk-4"
+	wrongProject.Spec.GoogleServiceAccount.Name = "sa-4@p.com"
+
+	entry := &cache.Entry{
+		Type: cache.GcpSaKey,
+		Identifier: cache.GcpSaKeyEntryIdentifier{
+			Email:   "sa-1@p.com",
+			Project: "p",
+		},
+	}
+	mismatchedEntry := &cache.Entry{
+		Type: cache.GcpSaKey,
+		Identifier: cache.GcpSaKeyEntryIdentifier{
+			Email:   "sa-4@p.com",
+			Project: "other-project",
+		},
+	}
+
+	_cache := cachemocks.NewCache(t)
+	_cache.EXPECT().List().Return([]*cache.Entry{entry, mismatchedEntry}, nil)
+
+	gskEndpoint := crdmocks.NewGcpSaKeyInterface(t)
+	acsEndpoint := crdmocks.NewAzureClientSecretInterface(t)
+	crd := crdmocks.NewYaleCRDInterface(t)
+	crd.EXPECT().GcpSaKeys().Return(gskEndpoint)
+	crd.EXPECT().AzureClientSecrets().Return(acsEndpoint)
+	gskEndpoint.EXPECT().List(mock.Anything, metav1.ListOptions{}).Return(&v1beta1.GCPSaKeyList{
+		Items: []v1beta1.GcpSaKey{withUniqueID, withoutEntry, invalidSpec, wrongProject},
+	}, nil)
+	acsEndpoint.EXPECT().List(mock.Anything, metav1.ListOptions{}).Return(&v1beta1.AzureClientSecretList{}, nil)
+
+	result, problems, err := New(crd, _cache).Check()
+	require.NoError(t, err)
+
+	require.Len(t, result, 2)
+	assert.Equal(t, entry, result["sa-1@p.com"].Entry)
+	assert.Equal(t, "", entry.Identifier.(cache.GcpSaKeyEntryIdentifier).UniqueID)
+	assert.Nil(t, result["sa-2@p.com"].Entry)
+	assert.Equal(t, []v1beta1.GcpSaKey{withoutEntry}, result["sa-2@p.com"].GSKs)
+
+	require.Len(t, problems, 2)
+	assert.ErrorContains(t, problems[0], "GcpSaKey resource ns-a/gsk-3 has invalid spec: missing google service account project")
+	assert.ErrorContains(t, problems[1], "invalid cluster resources for service account sa-4@p.com: project mismatch")
+}
+
+func Test_Check_DefaultsAndValidatesReplicationFormats(t *testing.T) {
+	withDefaultFormat := gsk1a
+	withDefaultFormat.Spec.GitHubReplications = []v1beta1.GitHubReplication{{Secret: "KEY", Repo: "my-org/my-repo"}}
+
+	invalidFormat := acs1a
+	invalidFormat.Spec.VaultReplications = []v1beta1.VaultReplication{{Path: "secret/foo", Format: v1beta1.PEM}}
+
+	_cache := cachemocks.NewCache(t)
+	_cache.EXPECT().List().Return([]*cache.Entry{entry1}, nil)
+
+	gskEndpoint := crdmocks.NewGcpSaKeyInterface(t)
+	acsEndpoint := crdmocks.NewAzureClientSecretInterface(t)
+	crd := crdmocks.NewYaleCRDInterface(t)
+	crd.EXPECT().GcpSaKeys().Return(gskEndpoint)
+	crd.EXPECT().AzureClientSecrets().Return(acsEndpoint)
+	gskEndpoint.EXPECT().List(mock.Anything, metav1.ListOptions{}).Return(&v1beta1.GCPSaKeyList{
+		Items: []v1beta1.GcpSaKey{withDefaultFormat},
+	}, nil)
+	acsEndpoint.EXPECT().List(mock.Anything, metav1.ListOptions{}).Return(&v1beta1.AzureClientSecretList{
+		Items: []v1beta1.AzureClientSecret{invalidFormat},
+	}, nil)
+
+	result, problems, err := New(crd, _cache).Check()
+	require.NoError(t, err)
+
+	require.Len(t, result, 1)
+	require.Len(t, result["sa-1@p.com"].GSKs, 1)
+	assert.Equal(t, v1beta1.JSON, result["sa-1@p.com"].GSKs[0].Spec.GitHubReplications[0].Format)
+
+	require.Len(t, problems, 1)
+	assert.ErrorContains(t, problems[0], "has invalid spec: vaultReplications[0]: pem format is only supported for GCP service account keys")
+}
+
+func Test_Build_RecoversCacheEntryFromSecrets(t *testing.T) {
+	const keyJSON = `{"private_key_id":"key-1","private_key":"my-private-key"}`
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	gsk := gsk1a
+	gsk.Spec.Secret = v1beta1.Secret{Name: "my-secret", JsonKeyName: "key.json", PemKeyName: "key.pem"}
+
+	secret := func(annotations map[string]string, data string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "my-secret",
+				Namespace:         "ns-a",
+				Annotations:       annotations,
+				CreationTimestamp: metav1.NewTime(createdAt),
+			},
+			Data: map[string][]byte{"key.json": []byte(data)},
+		}
+	}
+	annotated := map[string]string{"yale.terra.bio/key-id": "key-1"}
+
+	testCases := []struct {
+		name     string
+		recover  bool
+		secret   *corev1.Secret
+		entry    *cache.Entry
+		expected cache.CurrentKey
+	}{
+		{
+			name:    "recovers the key from the secret",
+			recover: true,
+			secret:  secret(annotated, keyJSON),
+			expected: cache.CurrentKey{
+				ID:        "key-1",
+				JSON:      keyJSON,
+				CreatedAt: createdAt,
+			},
+		},
+		{
+			name:    "does not recover by default",
+			recover: false,
+			secret:  secret(annotated, keyJSON),
+		},
+		{
+			name:    "does not recover from a secret without the key id annotation",
+			recover: true,
+			secret:  secret(nil, keyJSON),
+		},
+		{
+			name:    "does not recover a key that doesn't match the key id annotation",
+			recover: true,
+			secret:  secret(annotated, `{"private_key_id":"key-2","private_key":"my-private-key"}`),
+		},
+		{
+			name:    "does not recover a key that can't be parsed",
+			recover: true,
+			secret:  secret(annotated, "not json"),
+		},
+		{
+			name:    "does not recover if the secret does not exist",
+			recover: true,
+		},
+		{
+			name:    "does not recover an entry that has held a key before",
+			recover: true,
+			secret:  secret(annotated, keyJSON),
+			entry: &cache.Entry{
+				Type: cache.GcpSaKey,
+				Identifier: cache.GcpSaKeyEntryIdentifier{
+					Email:   "sa-1@p.com",
+					Project: "p",
+				},
+				DisabledKeys: map[string]time.Time{"key-0": createdAt},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			k8s := k8sfake.NewSimpleClientset()
+			if tc.secret != nil {
+				_, err := k8s.CoreV1().Secrets("ns-a").Create(context.Background(), tc.secret, metav1.CreateOptions{})
+				require.NoError(t, err)
+			}
+
+			_cache := cachemocks.NewCache(t)
+			entry := tc.entry
+			if entry == nil {
+				entry = &cache.Entry{
+					Type: cache.GcpSaKey,
+					Identifier: cache.GcpSaKeyEntryIdentifier{
+						Email:   "sa-1@p.com",
+						Project: "p",
+					},
+				}
+				_cache.EXPECT().List().Return(nil, nil)
+				_cache.EXPECT().GetOrCreate(cache.GcpSaKeyEntryIdentifier{
+					Email:   "sa-1@p.com",
+					Project: "p",
+				}).Return(entry, nil)
+			} else {
+				_cache.EXPECT().List().Return([]*cache.Entry{entry}, nil)
+			}
+			if tc.expected.ID != "" {
+				_cache.EXPECT().Save(entry).Return(nil)
+			}
+
+			gskEndpoint := crdmocks.NewGcpSaKeyInterface(t)
+			acsEndpoint := crdmocks.NewAzureClientSecretInterface(t)
+			crd := crdmocks.NewYaleCRDInterface(t)
+			crd.EXPECT().GcpSaKeys().Return(gskEndpoint)
+			crd.EXPECT().AzureClientSecrets().Return(acsEndpoint)
+			gskEndpoint.EXPECT().List(mock.Anything, metav1.ListOptions{}).Return(&v1beta1.GCPSaKeyList{
+				Items: []v1beta1.GcpSaKey{gsk},
+			}, nil)
+			acsEndpoint.EXPECT().List(mock.Anything, metav1.ListOptions{}).Return(&v1beta1.AzureClientSecretList{}, nil)
+
+			result, err := New(crd, _cache, func(opts *Options) {
+				opts.RecoverCacheFromSecrets = tc.recover
+				opts.K8s = k8s
+			}).Build()
+			require.NoError(t, err)
+			require.Contains(t, result, "sa-1@p.com")
+			assert.Equal(t, tc.expected, result["sa-1@p.com"].Entry.CurrentKey)
+		})
+	}
+}
+
+func Test_Build_RecoversCacheEntryFromAdditionalSecretsOfExternalOnlyResource(t *testing.T) {
+	const keyJSON = `{"private_key_id":"key-1","private_key":"my-private-key"}`
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	// the resource omits its own secret, and only syncs its key to an additional secret
+	gsk := gsk1a
+	gsk.Spec.AdditionalSecrets = []v1beta1.Secret{{Name: "my-secret", JsonKeyName: "key.json"}}
+
+	k8s := k8sfake.NewSimpleClientset()
+	// unlike the fake clientset, the API server rejects requests for secrets with no name
+	k8s.PrependReactor("get", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.(k8stesting.GetAction).GetName() == "" {
+			return true, nil, fmt.Errorf("resource name may not be empty")
+		}
+		return false, nil, nil
+	})
+	_, err := k8s.CoreV1().Secrets("ns-a").Create(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "my-secret",
+			Namespace:         "ns-a",
+			Annotations:       map[string]string{"yale.terra.bio/key-id": "key-1"},
+			CreationTimestamp: metav1.NewTime(createdAt),
+		},
+		Data: map[string][]byte{"key.json": []byte(keyJSON)},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	entry := &cache.Entry{
+		Type: cache.GcpSaKey,
+		Identifier: cache.GcpSaKeyEntryIdentifier{
+			Email:   "sa-1@p.com",
+			Project: "p",
+		},
+	}
+	_cache := cachemocks.NewCache(t)
+	_cache.EXPECT().List().Return(nil, nil)
+	_cache.EXPECT().GetOrCreate(entry.Identifier).Return(entry, nil)
+	_cache.EXPECT().Save(entry).Return(nil)
+
+	gskEndpoint := crdmocks.NewGcpSaKeyInterface(t)
+	acsEndpoint := crdmocks.NewAzureClientSecretInterface(t)
+	crd := crdmocks.NewYaleCRDInterface(t)
+	crd.EXPECT().GcpSaKeys().Return(gskEndpoint)
+	crd.EXPECT().AzureClientSecrets().Return(acsEndpoint)
+	gskEndpoint.EXPECT().List(mock.Anything, metav1.ListOptions{}).Return(&v1beta1.GCPSaKeyList{
+		Items: []v1beta1.GcpSaKey{gsk},
+	}, nil)
+	acsEndpoint.EXPECT().List(mock.Anything, metav1.ListOptions{}).Return(&v1beta1.AzureClientSecretList{}, nil)
+
+	result, err := New(crd, _cache, func(opts *Options) {
+		opts.RecoverCacheFromSecrets = true
+		opts.K8s = k8s
+	}).Build()
+	require.NoError(t, err)
+	require.Contains(t, result, "sa-1@p.com")
+	assert.Equal(t, cache.CurrentKey{
+		ID:        "key-1",
+		JSON:      keyJSON,
+		CreatedAt: createdAt,
+	}, result["sa-1@p.com"].Entry.CurrentKey)
+}
@@ -0,0 +1,131 @@
+package yale
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/broadinstitute/yale/internal/yale/cache"
+	apiv1b1 "github.com/broadinstitute/yale/internal/yale/crd/api/v1beta1"
+	"github.com/broadinstitute/yale/internal/yale/logs"
+	"github.com/broadinstitute/yale/internal/yale/resourcemap"
+)
+
+// RotateAllOptions options for a one-off bulk rotation
+type RotateAllOptions struct {
+	// OlderThan rotate any current key that was created more than this long ago
+	OlderThan time.Duration
+	// DryRun if true, log which keys would be rotated without rotating them
+	DryRun bool
+	// MaxRotations maximum number of keys to rotate in a single invocation; 0 means no limit
+	MaxRotations int
+}
+
+// RotateAll forces rotation of every current key older than an operator-chosen age, regardless of the
+// rotation thresholds configured in the CRDs. It is intended for one-off compliance sweeps, eg.
+// "rotate everything older than 30 days now". Old keys are moved to RotatedKeys so that they are
+// disabled and deleted by subsequent runs as usual.
+func (m *Yale) RotateAll(opts RotateAllOptions) error {
+	resources, err := m.resourcemap.Build()
+	if err != nil {
+		return fmt.Errorf("error inspecting cluster for cache entries and GcpSaKey resources: %v", err)
+	}
+
+	// process identifiers in a stable order so that the rotation cap is applied predictably
+	var identifiers []string
+	for identifier := range resources {
+		identifiers = append(identifiers, identifier)
+	}
+	sort.Strings(identifiers)
+
+	cutoff := currentTime().Add(-1 * opts.OlderThan)
+	rotations := 0
+	errors := make(map[string]error)
+
+	for _, identifier := range identifiers {
+		bundle := resources[identifier]
+		entry := bundle.Entry
+
+		if entry.CurrentKey.ID == "" {
+			continue
+		}
+		if !entry.CurrentKey.CreatedAt.Before(cutoff) {
+			logs.Info.Printf("rotate-all: %s %s: current key %s was created at %s; too new to rotate", entry.Type, identifier, entry.CurrentKey.ID, entry.CurrentKey.CreatedAt)
+			continue
+		}
+		if !bundleHasCRDs(bundle) {
+			logs.Info.Printf("rotate-all: %s %s: no %s resources in cluster; will not issue new key", entry.Type, identifier, entry.Type)
+			continue
+		}
+		if bundleDecommissioned(m, bundle) {
+			logs.Info.Printf("rotate-all: %s %s: decommissioning; will not issue new key", entry.Type, identifier)
+			continue
+		}
+		if opts.MaxRotations > 0 && rotations >= opts.MaxRotations {
+			logs.Warn.Printf("rotate-all: reached limit of %d rotations; will not rotate %s %s", opts.MaxRotations, entry.Type, identifier)
+			continue
+		}
+		rotations++
+
+		if opts.DryRun {
+			logs.Info.Printf("rotate-all: [dry run] would rotate current key %s for %s %s (created at %s)", entry.CurrentKey.ID, entry.Type, identifier, entry.CurrentKey.CreatedAt)
+			continue
+		}
+
+		logs.Info.Printf("rotate-all: rotating current key %s for %s %s (created at %s)", entry.CurrentKey.ID, entry.Type, identifier, entry.CurrentKey.CreatedAt)
+		if entry.Type == cache.GcpSaKey {
+			err = forceRotateYaleResource(m, entry, m.options.SyncDelayAfterIssue, false, bundle.GSKs)
+		} else {
+			err = forceRotateYaleResource(m, entry, m.options.SyncDelayAfterIssue, false, bundle.AzClientSecrets)
+		}
+		if err != nil {
+			logs.Error.Printf("rotate-all: error rotating %s %s: %v", entry.Type, identifier, err)
+			errors[identifier] = err
+		}
+	}
+
+	if len(errors) > 0 {
+		var sb strings.Builder
+		for identifier, err := range errors {
+			sb.WriteString(fmt.Sprintf("%s: %v\n", identifier, err))
+		}
+		return fmt.Errorf("error rotating keys for %d identifiers: %s", len(errors), sb.String())
+	}
+
+	return nil
+}
+
+// forceRotateYaleResource issues a new key for the cache entry, moving the current key to RotatedKeys,
+// and syncs the new key to its destinations once it is at least syncDelay old. Unless allowDecommissioned is
+// true, it refuses to issue a key for an identity that its resources are decommissioning.
+func forceRotateYaleResource[Y apiv1b1.YaleCRD](m *Yale, entry *cache.Entry, syncDelay time.Duration, allowDecommissioned bool, yaleCRDs []Y) error {
+	keyOpsType, err := keyOpsTypeFor(entry)
+	if err != nil {
+		return err
+	}
+	if !allowDecommissioned && computeCutoffs(entry, yaleCRDs, m.options.AllowSubDayThresholds).Decommissioned() {
+		return fmt.Errorf("%s %s is being decommissioned; will not issue new key", entry.Type, entry.Identify())
+	}
+	if err = checkTrackedKeyLimit(entry, m.options.MaxTrackedKeys); err != nil {
+		return err
+	}
+	if err = issueNewYaleResource(m.keyops[keyOpsType], m.cache, m.notifier, m.options.AuditLog, entry, keyCreateOptions(yaleCRDs)...); err != nil {
+		return err
+	}
+	return syncYaleResourceIfReady(m.keysync, entry, syncDelay, yaleCRDs)
+}
+
+// bundleDecommissioned returns true if the bundle's resources are decommissioning its identity, in which case
+// Yale should not issue new keys for it
+func bundleDecommissioned(m *Yale, bundle *resourcemap.Bundle) bool {
+	if bundle.Entry.Type == cache.GcpSaKey {
+		return computeCutoffs(bundle.Entry, bundle.GSKs, m.options.AllowSubDayThresholds).Decommissioned()
+	}
+	return computeCutoffs(bundle.Entry, bundle.AzClientSecrets, m.options.AllowSubDayThresholds).Decommissioned()
+}
+
+// bundleHasCRDs returns true if the bundle has at least one GcpSaKey or AzureClientSecret
+func bundleHasCRDs(bundle *resourcemap.Bundle) bool {
+	return len(bundle.GSKs) > 0 || len(bundle.AzClientSecrets) > 0
+}
@@ -0,0 +1,58 @@
+package yale
+
+import (
+	"fmt"
+
+	"github.com/broadinstitute/yale/internal/yale/cache"
+	"github.com/broadinstitute/yale/internal/yale/logs"
+)
+
+// RotateNowOptions options for an immediate rotation of a single identifier's key
+type RotateNowOptions struct {
+	// AllowDecommissioned if true, issue a new key even if the identifier's resources are decommissioning it
+	AllowDecommissioned bool
+}
+
+// RotateNow immediately rotates the current key for a single identifier (a service account email or Azure
+// application ID), eg. because the key is suspected to be compromised. The rotation thresholds and windows
+// configured for the identifier are ignored, and the new key is synced to its destinations right away, even
+// if SyncDelayAfterIssue is set. The old key is moved to RotatedKeys, so it is disabled and deleted by
+// subsequent runs as usual. An identifier that is being decommissioned is refused unless
+// opts.AllowDecommissioned is set.
+func (m *Yale) RotateNow(identifier string, opts RotateNowOptions) error {
+	resources, err := m.resourcemap.Build()
+	if err != nil {
+		return fmt.Errorf("error inspecting cluster for cache entries and GcpSaKey resources: %v", err)
+	}
+
+	bundle, exists := resources[identifier]
+	if !exists || bundle.Entry == nil {
+		return fmt.Errorf("no cache entry or resources found for %s", identifier)
+	}
+	entry := bundle.Entry
+	if !bundleHasCRDs(bundle) {
+		return fmt.Errorf("%s %s: no %s resources in cluster; will not issue new key", entry.Type, identifier, entry.Type)
+	}
+	if bundleDecommissioned(m, bundle) {
+		if !opts.AllowDecommissioned {
+			return fmt.Errorf("%s %s is being decommissioned; refusing to issue new key", entry.Type, identifier)
+		}
+		logs.Warn.Printf("rotate-now: %s %s is being decommissioned, but issuing a new key anyway", entry.Type, identifier)
+	}
+
+	if entry.CurrentKey.ID == "" {
+		logs.Info.Printf("rotate-now: %s %s has no current key, issuing one", entry.Type, identifier)
+	} else {
+		logs.Info.Printf("rotate-now: rotating current key %s for %s %s (created at %s)", entry.CurrentKey.ID, entry.Type, identifier, entry.CurrentKey.CreatedAt)
+	}
+	if entry.Type == cache.GcpSaKey {
+		err = forceRotateYaleResource(m, entry, 0, opts.AllowDecommissioned, bundle.GSKs)
+	} else {
+		err = forceRotateYaleResource(m, entry, 0, opts.AllowDecommissioned, bundle.AzClientSecrets)
+	}
+	if err != nil {
+		return fmt.Errorf("error rotating %s %s: %v", entry.Type, identifier, err)
+	}
+	logs.Info.Printf("rotate-now: %s %s: issued and synced new key %s", entry.Type, identifier, entry.CurrentKey.ID)
+	return nil
+}
@@ -0,0 +1,259 @@
+package selftest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/broadinstitute/yale/internal/yale/keyops"
+	"github.com/broadinstitute/yale/internal/yale/logs"
+	vaultapi "github.com/hashicorp/vault/api"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DefaultVaultPath default scratch Vault path that the self-test writes the test key to
+const DefaultVaultPath = "secret/yale/selftest"
+
+// secretNamePrefix prefix for the temporary K8s secret the self-test creates
+const secretNamePrefix = "yale-selftest-"
+
+// secretKey key within the temporary K8s secret / scratch Vault secret where the test key is stored
+const secretKey = "key.json"
+
+// names of the self-test stages, in the order they are run
+const (
+	issueStage   = "issue key"
+	k8sStage     = "sync to K8s secret"
+	vaultStage   = "replicate to Vault"
+	disableStage = "disable key"
+	deleteStage  = "delete key"
+	cleanupStage = "clean up"
+)
+
+type Options struct {
+	// Project google project the test service account lives in
+	Project string
+	// ServiceAccount email of a disposable service account the self-test can issue keys for
+	ServiceAccount string
+	// Namespace namespace the temporary K8s secret is created in
+	Namespace string
+	// VaultPath scratch Vault path the test key is replicated to
+	VaultPath string
+}
+
+// SelfTest exercises Yale's full key lifecycle against a disposable service account, so that
+// permissions and connectivity can be validated after deploying Yale to a new environment
+type SelfTest interface {
+	// Run issues a key for the test service account, syncs it to a temporary K8s secret and a scratch
+	// Vault path, verifies both, then disables and deletes the key and cleans up after itself.
+	Run() Report
+}
+
+// Stage result of a single self-test stage
+type Stage struct {
+	// Name of the stage
+	Name string
+	// Err is the error the stage failed with, if any
+	Err error
+	// Skipped is true if the stage did not run because an earlier stage failed
+	Skipped bool
+}
+
+// Report results of a self-test run
+type Report struct {
+	Stages []Stage
+}
+
+// Passed returns true if every stage in the report ran and succeeded
+func (r Report) Passed() bool {
+	for _, stage := range r.Stages {
+		if stage.Err != nil || stage.Skipped {
+			return false
+		}
+	}
+	return true
+}
+
+// String renders the report as one "<status> <stage>" line per stage
+func (r Report) String() string {
+	var sb strings.Builder
+	for _, stage := range r.Stages {
+		if stage.Skipped {
+			sb.WriteString(fmt.Sprintf("[SKIP] %s\n", stage.Name))
+		} else if stage.Err != nil {
+			sb.WriteString(fmt.Sprintf("[FAIL] %s: %v\n", stage.Name, stage.Err))
+		} else {
+			sb.WriteString(fmt.Sprintf("[PASS] %s\n", stage.Name))
+		}
+	}
+	return sb.String()
+}
+
+func New(k8s kubernetes.Interface, vault *vaultapi.Client, _keyops keyops.KeyOps, options Options) SelfTest {
+	if options.VaultPath == "" {
+		options.VaultPath = DefaultVaultPath
+	}
+	return &selftest{
+		options: options,
+		k8s:     k8s,
+		vault:   vault,
+		keyops:  _keyops,
+	}
+}
+
+type selftest struct {
+	options Options
+	k8s     kubernetes.Interface
+	vault   *vaultapi.Client
+	keyops  keyops.KeyOps
+}
+
+func (s *selftest) Run() Report {
+	var report Report
+	failed := false
+
+	// run executes a stage unless an earlier stage failed, and records the result in the report
+	run := func(name string, fn func() error) {
+		if failed {
+			report.Stages = append(report.Stages, Stage{Name: name, Skipped: true})
+			return
+		}
+		logs.Info.Printf("selftest: running stage %q...", name)
+		err := fn()
+		if err != nil {
+			logs.Error.Printf("selftest: stage %q failed: %v", name, err)
+			failed = true
+		}
+		report.Stages = append(report.Stages, Stage{Name: name, Err: err})
+	}
+
+	var key keyops.Key
+	var data []byte
+	var issued, k8sCreated, vaultWritten bool
+
+	run(issueStage, func() error {
+		var err error
+		key, data, err = s.keyops.Create(s.options.Project, s.options.ServiceAccount)
+		if err != nil {
+			return err
+		}
+		issued = true
+		return nil
+	})
+	run(k8sStage, func() error {
+		var err error
+		k8sCreated, err = s.syncToK8sSecret(data)
+		return err
+	})
+	run(vaultStage, func() error {
+		vaultWritten = true
+		return s.replicateToVault(data)
+	})
+	run(disableStage, func() error {
+		return s.keyops.EnsureDisabled(key)
+	})
+	run(deleteStage, func() error {
+		if err := s.keyops.DeleteIfDisabled(key); err != nil {
+			return err
+		}
+		issued = false
+		return nil
+	})
+
+	// always attempt to clean up whatever was created, even if an earlier stage failed
+	failed = false
+	run(cleanupStage, func() error {
+		return s.cleanup(key, issued, k8sCreated, vaultWritten)
+	})
+
+	return report
+}
+
+// syncToK8sSecret writes the test key to a temporary K8s secret and reads it back to verify it, returning
+// whether the secret was created (and so needs to be cleaned up)
+func (s *selftest) syncToK8sSecret(data []byte) (bool, error) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: s.options.Namespace,
+			Name:      s.secretName(),
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			secretKey: data,
+		},
+	}
+	if _, err := s.k8s.CoreV1().Secrets(s.options.Namespace).Create(context.Background(), secret, metav1.CreateOptions{}); err != nil {
+		return false, fmt.Errorf("error creating secret %s/%s: %v", s.options.Namespace, s.secretName(), err)
+	}
+
+	readBack, err := s.k8s.CoreV1().Secrets(s.options.Namespace).Get(context.Background(), s.secretName(), metav1.GetOptions{})
+	if err != nil {
+		return true, fmt.Errorf("error reading back secret %s/%s: %v", s.options.Namespace, s.secretName(), err)
+	}
+	if !bytes.Equal(readBack.Data[secretKey], data) {
+		return true, fmt.Errorf("secret %s/%s does not contain the issued key", s.options.Namespace, s.secretName())
+	}
+	return true, nil
+}
+
+// replicateToVault writes the test key to the scratch Vault path and reads it back to verify it
+func (s *selftest) replicateToVault(data []byte) error {
+	if _, err := s.vault.Logical().Write(s.options.VaultPath, map[string]interface{}{
+		secretKey: string(data),
+	}); err != nil {
+		return fmt.Errorf("error writing to Vault path %s: %v", s.options.VaultPath, err)
+	}
+
+	readBack, err := s.vault.Logical().Read(s.options.VaultPath)
+	if err != nil {
+		return fmt.Errorf("error reading back Vault path %s: %v", s.options.VaultPath, err)
+	}
+	if readBack == nil {
+		return fmt.Errorf("no secret at Vault path %s after write", s.options.VaultPath)
+	}
+	if readBack.Data[secretKey] != string(data) {
+		return fmt.Errorf("secret at Vault path %s does not contain the issued key", s.options.VaultPath)
+	}
+	return nil
+}
+
+// cleanup removes the temporary K8s secret and scratch Vault secret, and deletes the test key
+// if a failure in an earlier stage left it behind
+func (s *selftest) cleanup(key keyops.Key, issued bool, k8sCreated bool, vaultWritten bool) error {
+	var errs []string
+
+	if k8sCreated {
+		err := s.k8s.CoreV1().Secrets(s.options.Namespace).Delete(context.Background(), s.secretName(), metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			errs = append(errs, fmt.Sprintf("error deleting secret %s/%s: %v", s.options.Namespace, s.secretName(), err))
+		}
+	}
+	if vaultWritten {
+		if _, err := s.vault.Logical().Delete(s.options.VaultPath); err != nil {
+			errs = append(errs, fmt.Sprintf("error deleting Vault path %s: %v", s.options.VaultPath, err))
+		}
+	}
+	if issued {
+		logs.Warn.Printf("selftest: key %s for %s was not deleted by an earlier stage; disabling and deleting it", key.ID, key.Identifier)
+		if err := s.keyops.EnsureDisabled(key); err != nil {
+			errs = append(errs, fmt.Sprintf("error disabling key %s: %v", key.ID, err))
+		} else if err = s.keyops.DeleteIfDisabled(key); err != nil {
+			errs = append(errs, fmt.Sprintf("error deleting key %s: %v", key.ID, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// secretName name of the temporary K8s secret, derived from the test service account's name
+func (s *selftest) secretName() string {
+	name := strings.SplitN(s.options.ServiceAccount, "@", 2)[0]
+	return secretNamePrefix + strings.ToLower(name)
+}
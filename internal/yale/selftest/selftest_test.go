@@ -0,0 +1,114 @@
+package selftest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/broadinstitute/yale/internal/yale/keyops"
+	keyopsmocks "github.com/broadinstitute/yale/internal/yale/keyops/mocks"
+	vaultutils "github.com/broadinstitute/yale/internal/yale/keysync/testutils/vault"
+	"github.com/broadinstitute/yale/internal/yale/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testProject = "my-project"
+const testServiceAccount = "yale-selftest@my-project.iam.gserviceaccount.com"
+const testNamespace = "yale-cache"
+const testKeyJSON = `{"email":"yale-selftest@my-project.iam.gserviceaccount.com","private_key":"foobar"}`
+
+var testKey = keyops.Key{
+	Scope:      testProject,
+	Identifier: testServiceAccount,
+	ID:         "my-key-id",
+}
+
+func Test_SelfTestPassesAndCleansUp(t *testing.T) {
+	k8s := testutils.NewFakeK8sClient(t)
+	vaultServer := vaultutils.NewFakeVaultServer(t)
+	_keyops := keyopsmocks.NewKeyOps(t)
+
+	_keyops.EXPECT().Create(testProject, testServiceAccount).Return(testKey, []byte(testKeyJSON), nil)
+	_keyops.EXPECT().EnsureDisabled(testKey).Return(nil)
+	_keyops.EXPECT().DeleteIfDisabled(testKey).Return(nil)
+
+	report := New(k8s, vaultServer.NewClient(), _keyops, Options{
+		Project:        testProject,
+		ServiceAccount: testServiceAccount,
+		Namespace:      testNamespace,
+	}).Run()
+
+	assert.True(t, report.Passed(), report.String())
+	assert.Len(t, report.Stages, 6)
+
+	// make sure the temporary secret and scratch Vault path were removed
+	_, err := k8s.CoreV1().Secrets(testNamespace).Get(context.Background(), "yale-selftest-yale-selftest", metav1.GetOptions{})
+	require.Error(t, err)
+	assert.True(t, errors.IsNotFound(err))
+	assert.Nil(t, vaultServer.GetSecret(DefaultVaultPath))
+}
+
+func Test_SelfTestSkipsRemainingStagesAndDeletesKeyOnFailure(t *testing.T) {
+	k8s := testutils.NewFakeK8sClient(t)
+	vaultServer := vaultutils.NewFakeVaultServer(t)
+	_keyops := keyopsmocks.NewKeyOps(t)
+
+	_keyops.EXPECT().Create(testProject, testServiceAccount).Return(testKey, []byte(testKeyJSON), nil)
+	// first disable attempt fails; cleanup should retry disable + delete
+	_keyops.EXPECT().EnsureDisabled(testKey).Return(fmt.Errorf("permission denied")).Once()
+	_keyops.EXPECT().EnsureDisabled(testKey).Return(nil).Once()
+	_keyops.EXPECT().DeleteIfDisabled(testKey).Return(nil)
+
+	report := New(k8s, vaultServer.NewClient(), _keyops, Options{
+		Project:        testProject,
+		ServiceAccount: testServiceAccount,
+		Namespace:      testNamespace,
+	}).Run()
+
+	assert.False(t, report.Passed())
+	require.Len(t, report.Stages, 6)
+	assert.NoError(t, report.Stages[0].Err)
+	assert.NoError(t, report.Stages[1].Err)
+	assert.NoError(t, report.Stages[2].Err)
+	assert.ErrorContains(t, report.Stages[3].Err, "permission denied")
+	assert.True(t, report.Stages[4].Skipped)
+	assert.NoError(t, report.Stages[5].Err)
+	assert.Contains(t, report.String(), "[FAIL] disable key: permission denied")
+	assert.Contains(t, report.String(), "[SKIP] delete key")
+}
+
+func Test_SelfTestDoesNotDeleteSecretItDidNotCreate(t *testing.T) {
+	k8s := testutils.NewFakeK8sClient(t)
+	vaultServer := vaultutils.NewFakeVaultServer(t)
+	_keyops := keyopsmocks.NewKeyOps(t)
+
+	// a secret with the same name already exists, eg. left over from a concurrent self-test
+	_, err := k8s.CoreV1().Secrets(testNamespace).Create(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      "yale-selftest-yale-selftest",
+		},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	_keyops.EXPECT().Create(testProject, testServiceAccount).Return(testKey, []byte(testKeyJSON), nil)
+	// cleanup disables and deletes the key left behind by the failed k8s stage
+	_keyops.EXPECT().EnsureDisabled(testKey).Return(nil)
+	_keyops.EXPECT().DeleteIfDisabled(testKey).Return(nil)
+
+	report := New(k8s, vaultServer.NewClient(), _keyops, Options{
+		Project:        testProject,
+		ServiceAccount: testServiceAccount,
+		Namespace:      testNamespace,
+	}).Run()
+
+	assert.False(t, report.Passed())
+	assert.ErrorContains(t, report.Stages[1].Err, "already exists")
+
+	_, err = k8s.CoreV1().Secrets(testNamespace).Get(context.Background(), "yale-selftest-yale-selftest", metav1.GetOptions{})
+	assert.NoError(t, err)
+}
@@ -5,6 +5,8 @@ package mocks
 import (
 	cache "github.com/broadinstitute/yale/internal/yale/cache"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // SlackNotifier is an autogenerated mock type for the SlackNotifier type
@@ -20,6 +22,49 @@ func (_m *SlackNotifier) EXPECT() *SlackNotifier_Expecter {
 	return &SlackNotifier_Expecter{mock: &_m.Mock}
 }
 
+// DestinationUnhealthy provides a mock function with given fields: destination, failures
+func (_m *SlackNotifier) DestinationUnhealthy(destination string, failures int) error {
+	ret := _m.Called(destination, failures)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, int) error); ok {
+		r0 = rf(destination, failures)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SlackNotifier_DestinationUnhealthy_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DestinationUnhealthy'
+type SlackNotifier_DestinationUnhealthy_Call struct {
+	*mock.Call
+}
+
+// DestinationUnhealthy is a helper method to define mock.On call
+//   - destination string
+//   - failures int
+func (_e *SlackNotifier_Expecter) DestinationUnhealthy(destination interface{}, failures interface{}) *SlackNotifier_DestinationUnhealthy_Call {
+	return &SlackNotifier_DestinationUnhealthy_Call{Call: _e.mock.On("DestinationUnhealthy", destination, failures)}
+}
+
+func (_c *SlackNotifier_DestinationUnhealthy_Call) Run(run func(destination string, failures int)) *SlackNotifier_DestinationUnhealthy_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *SlackNotifier_DestinationUnhealthy_Call) Return(_a0 error) *SlackNotifier_DestinationUnhealthy_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *SlackNotifier_DestinationUnhealthy_Call) RunAndReturn(run func(string, int) error) *SlackNotifier_DestinationUnhealthy_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Error provides a mock function with given fields: entry, message
 func (_m *SlackNotifier) Error(entry *cache.Entry, message string) error {
 	ret := _m.Called(entry, message)
@@ -63,6 +108,92 @@ func (_c *SlackNotifier_Error_Call) RunAndReturn(run func(*cache.Entry, string)
 	return _c
 }
 
+// ErrorDigest provides a mock function with given fields: entries
+func (_m *SlackNotifier) ErrorDigest(entries []*cache.Entry) error {
+	ret := _m.Called(entries)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]*cache.Entry) error); ok {
+		r0 = rf(entries)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SlackNotifier_ErrorDigest_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ErrorDigest'
+type SlackNotifier_ErrorDigest_Call struct {
+	*mock.Call
+}
+
+// ErrorDigest is a helper method to define mock.On call
+//   - entries []*cache.Entry
+func (_e *SlackNotifier_Expecter) ErrorDigest(entries interface{}) *SlackNotifier_ErrorDigest_Call {
+	return &SlackNotifier_ErrorDigest_Call{Call: _e.mock.On("ErrorDigest", entries)}
+}
+
+func (_c *SlackNotifier_ErrorDigest_Call) Run(run func(entries []*cache.Entry)) *SlackNotifier_ErrorDigest_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]*cache.Entry))
+	})
+	return _c
+}
+
+func (_c *SlackNotifier_ErrorDigest_Call) Return(_a0 error) *SlackNotifier_ErrorDigest_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *SlackNotifier_ErrorDigest_Call) RunAndReturn(run func([]*cache.Entry) error) *SlackNotifier_ErrorDigest_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// KeyApproachingRotation provides a mock function with given fields: entry, id, rotateAt
+func (_m *SlackNotifier) KeyApproachingRotation(entry *cache.Entry, id string, rotateAt time.Time) error {
+	ret := _m.Called(entry, id, rotateAt)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*cache.Entry, string, time.Time) error); ok {
+		r0 = rf(entry, id, rotateAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SlackNotifier_KeyApproachingRotation_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'KeyApproachingRotation'
+type SlackNotifier_KeyApproachingRotation_Call struct {
+	*mock.Call
+}
+
+// KeyApproachingRotation is a helper method to define mock.On call
+//   - entry *cache.Entry
+//   - id string
+//   - rotateAt time.Time
+func (_e *SlackNotifier_Expecter) KeyApproachingRotation(entry interface{}, id interface{}, rotateAt interface{}) *SlackNotifier_KeyApproachingRotation_Call {
+	return &SlackNotifier_KeyApproachingRotation_Call{Call: _e.mock.On("KeyApproachingRotation", entry, id, rotateAt)}
+}
+
+func (_c *SlackNotifier_KeyApproachingRotation_Call) Run(run func(entry *cache.Entry, id string, rotateAt time.Time)) *SlackNotifier_KeyApproachingRotation_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*cache.Entry), args[1].(string), args[2].(time.Time))
+	})
+	return _c
+}
+
+func (_c *SlackNotifier_KeyApproachingRotation_Call) Return(_a0 error) *SlackNotifier_KeyApproachingRotation_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *SlackNotifier_KeyApproachingRotation_Call) RunAndReturn(run func(*cache.Entry, string, time.Time) error) *SlackNotifier_KeyApproachingRotation_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // KeyDeleted provides a mock function with given fields: entry, id
 func (_m *SlackNotifier) KeyDeleted(entry *cache.Entry, id string) error {
 	ret := _m.Called(entry, id)
@@ -192,6 +323,49 @@ func (_c *SlackNotifier_KeyIssued_Call) RunAndReturn(run func(*cache.Entry, stri
 	return _c
 }
 
+// RotatedKeysAccumulating provides a mock function with given fields: entry, count
+func (_m *SlackNotifier) RotatedKeysAccumulating(entry *cache.Entry, count int) error {
+	ret := _m.Called(entry, count)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*cache.Entry, int) error); ok {
+		r0 = rf(entry, count)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SlackNotifier_RotatedKeysAccumulating_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RotatedKeysAccumulating'
+type SlackNotifier_RotatedKeysAccumulating_Call struct {
+	*mock.Call
+}
+
+// RotatedKeysAccumulating is a helper method to define mock.On call
+//   - entry *cache.Entry
+//   - count int
+func (_e *SlackNotifier_Expecter) RotatedKeysAccumulating(entry interface{}, count interface{}) *SlackNotifier_RotatedKeysAccumulating_Call {
+	return &SlackNotifier_RotatedKeysAccumulating_Call{Call: _e.mock.On("RotatedKeysAccumulating", entry, count)}
+}
+
+func (_c *SlackNotifier_RotatedKeysAccumulating_Call) Run(run func(entry *cache.Entry, count int)) *SlackNotifier_RotatedKeysAccumulating_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*cache.Entry), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *SlackNotifier_RotatedKeysAccumulating_Call) Return(_a0 error) *SlackNotifier_RotatedKeysAccumulating_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *SlackNotifier_RotatedKeysAccumulating_Call) RunAndReturn(run func(*cache.Entry, int) error) *SlackNotifier_RotatedKeysAccumulating_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 type mockConstructorTestingTNewSlackNotifier interface {
 	mock.TestingT
 	Cleanup(func())
@@ -0,0 +1,64 @@
+package slack
+
+import (
+	"time"
+
+	"github.com/broadinstitute/yale/internal/yale/cache"
+)
+
+// NewRouter returns a SlackNotifier that routes notifications by severity:
+// informational events (key issued, disabled, and deleted) are sent to infoWebhookUrl,
+// while errors are sent to alertsWebhookUrl. If only one of the webhooks is
+// configured, all notifications are sent to it.
+func NewRouter(infoWebhookUrl string, alertsWebhookUrl string, options ...Option) SlackNotifier {
+	if infoWebhookUrl == "" {
+		infoWebhookUrl = alertsWebhookUrl
+	}
+	if alertsWebhookUrl == "" {
+		alertsWebhookUrl = infoWebhookUrl
+	}
+	if infoWebhookUrl == alertsWebhookUrl {
+		return New(infoWebhookUrl, options...)
+	}
+	return &router{
+		info:   New(infoWebhookUrl, options...),
+		alerts: New(alertsWebhookUrl, options...),
+	}
+}
+
+type router struct {
+	info   SlackNotifier
+	alerts SlackNotifier
+}
+
+func (r *router) KeyIssued(entry *cache.Entry, id string) error {
+	return r.info.KeyIssued(entry, id)
+}
+
+func (r *router) KeyDisabled(entry *cache.Entry, id string) error {
+	return r.info.KeyDisabled(entry, id)
+}
+
+func (r *router) KeyDeleted(entry *cache.Entry, id string) error {
+	return r.info.KeyDeleted(entry, id)
+}
+
+func (r *router) KeyApproachingRotation(entry *cache.Entry, id string, rotateAt time.Time) error {
+	return r.info.KeyApproachingRotation(entry, id, rotateAt)
+}
+
+func (r *router) Error(entry *cache.Entry, message string) error {
+	return r.alerts.Error(entry, message)
+}
+
+func (r *router) ErrorDigest(entries []*cache.Entry) error {
+	return r.alerts.ErrorDigest(entries)
+}
+
+func (r *router) DestinationUnhealthy(destination string, failures int) error {
+	return r.alerts.DestinationUnhealthy(destination, failures)
+}
+
+func (r *router) RotatedKeysAccumulating(entry *cache.Entry, count int) error {
+	return r.alerts.RotatedKeysAccumulating(entry, count)
+}
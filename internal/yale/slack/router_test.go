@@ -0,0 +1,50 @@
+package slack
+
+import (
+	"testing"
+
+	"github.com/broadinstitute/yale/internal/yale/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Router_RoutesBySeverity(t *testing.T) {
+	infoClient := newMockClient(t)
+	alertsClient := newMockClient(t)
+
+	r := &router{
+		info:   &slackNotifier{client: infoClient},
+		alerts: &slackNotifier{client: alertsClient},
+	}
+
+	entry := &cache.Entry{
+		Type: cache.GcpSaKey,
+		Identifier: cache.GcpSaKeyEntryIdentifier{
+			Email:   "sa1@p.com",
+			Project: "p",
+		},
+	}
+
+	infoClient.On(postWebhookMethod, mock.Anything).Return(nil).Times(3)
+	alertsClient.On(postWebhookMethod, mock.Anything).Return(nil).Times(3)
+
+	require.NoError(t, r.KeyIssued(entry, "1234"))
+	require.NoError(t, r.KeyDisabled(entry, "1234"))
+	require.NoError(t, r.KeyDeleted(entry, "1234"))
+	require.NoError(t, r.Error(entry, "uh-oh"))
+	require.NoError(t, r.DestinationUnhealthy("vault", 5))
+	require.NoError(t, r.RotatedKeysAccumulating(entry, 4))
+}
+
+func Test_NewRouter_FallsBackToSingleWebhook(t *testing.T) {
+	assert.Equal(t, New("https://info"), NewRouter("https://info", ""))
+	assert.Equal(t, New("https://alerts"), NewRouter("", "https://alerts"))
+	assert.Equal(t, New("https://same"), NewRouter("https://same", "https://same"))
+	assert.Equal(t, New(""), NewRouter("", ""))
+
+	assert.Equal(t, &router{
+		info:   New("https://info"),
+		alerts: New("https://alerts"),
+	}, NewRouter("https://info", "https://alerts"))
+}
@@ -2,6 +2,9 @@ package slack
 
 import (
 	"fmt"
+	"sort"
+	"text/template"
+	"time"
 
 	"github.com/broadinstitute/yale/internal/yale/cache"
 	"github.com/slack-go/slack"
@@ -9,6 +12,7 @@ import (
 
 const okColor = "#32a852"
 const errorColor = "#a32f2f"
+const warningColor = "#e0a526"
 
 type event int64
 
@@ -17,51 +21,147 @@ const (
 	keyDisabledEvent
 	keyDeletedEvent
 	errorEvent
+	keyApproachingRotationEvent
 )
 
 type SlackNotifier interface {
 	// Error reports an error message via Slack webhook
 	Error(entry *cache.Entry, message string) error
+	// ErrorDigest reports the errors for several cache entries in a single message via Slack webhook
+	ErrorDigest(entries []*cache.Entry) error
 	// KeyIssued reports a key issued event via Slack webhook
 	KeyIssued(entry *cache.Entry, id string) error
 	// KeyDisabled reports a key issued event via Slack webhook
 	KeyDisabled(entry *cache.Entry, id string) error
 	// KeyDeleted reports a key deleted event via Slack webhook
 	KeyDeleted(entry *cache.Entry, id string) error
+	// KeyApproachingRotation reports that a key will be rotated soon via Slack webhook
+	KeyApproachingRotation(entry *cache.Entry, id string, rotateAt time.Time) error
+	// DestinationUnhealthy reports that Yale stopped syncing keys to a destination for the rest of the run via Slack webhook
+	DestinationUnhealthy(destination string, failures int) error
+	// RotatedKeysAccumulating reports that an entry has more rotated keys waiting to be disabled than expected via Slack webhook
+	RotatedKeysAccumulating(entry *cache.Entry, count int) error
 }
 
-func New(webhookUrl string) SlackNotifier {
+// Options configures a SlackNotifier
+type Options struct {
+	// MessageTemplates text/template strings, keyed by event (keyIssued, keyDisabled, keyDeleted,
+	// keyApproachingRotation, error), that replace the default message text for the event. Templates are
+	// rendered with a MessageContext.
+	MessageTemplates map[string]string
+}
+
+type Option func(*Options)
+
+// New returns a SlackNotifier that sends notifications to the given webhook. It panics if the message templates
+// are invalid, so callers should check them with ValidateTemplates first.
+func New(webhookUrl string, options ...Option) SlackNotifier {
+	var opts Options
+	for _, option := range options {
+		option(&opts)
+	}
+	templates, err := parseTemplates(opts.MessageTemplates)
+	if err != nil {
+		panic(err)
+	}
 	return &slackNotifier{
-		client: newSlackClient(webhookUrl),
+		client:    newSlackClient(webhookUrl),
+		templates: templates,
 	}
 }
 
 type slackNotifier struct {
-	client slackClient
+	client    slackClient
+	templates map[event]*template.Template
 }
 
 func (s *slackNotifier) KeyIssued(entry *cache.Entry, id string) error {
-	return s.buildAndSendMessage(keyIssuedEvent, entry, keyIdField(id))
+	return s.buildAndSendMessage(keyIssuedEvent, entry, MessageContext{KeyID: id}, keyIdField(id))
 }
 
 func (s *slackNotifier) KeyDisabled(entry *cache.Entry, id string) error {
-	return s.buildAndSendMessage(keyDisabledEvent, entry, keyIdField(id))
+	return s.buildAndSendMessage(keyDisabledEvent, entry, MessageContext{KeyID: id}, keyIdField(id))
 }
 
 func (s *slackNotifier) KeyDeleted(entry *cache.Entry, id string) error {
-	return s.buildAndSendMessage(keyDeletedEvent, entry, keyIdField(id))
+	return s.buildAndSendMessage(keyDeletedEvent, entry, MessageContext{KeyID: id}, keyIdField(id))
+}
+
+func (s *slackNotifier) KeyApproachingRotation(entry *cache.Entry, id string, rotateAt time.Time) error {
+	return s.buildAndSendMessage(keyApproachingRotationEvent, entry, MessageContext{KeyID: id, RotateAt: rotateAt}, keyIdField(id))
 }
 
 func (s *slackNotifier) Error(entry *cache.Entry, message string) error {
-	return s.buildAndSendMessage(errorEvent, entry, errorField(message))
+	return s.buildAndSendMessage(errorEvent, entry, MessageContext{Error: message}, errorField(message))
+}
+
+func (s *slackNotifier) ErrorDigest(entries []*cache.Entry) error {
+	attachment := slack.Attachment{
+		Color: errorColor,
+		Title: "Errors",
+		Text:  fmt.Sprintf("Yale encountered errors processing %d resources", len(entries)),
+	}
+	for _, entry := range entries {
+		linker := serviceAccountLinker{entry: entry}
+		attachment.Fields = append(attachment.Fields, slack.AttachmentField{
+			Title: fmt.Sprintf("%s %s", entry.Type, entry.Identify()),
+			Value: fmt.Sprintf("%s in `%s`: %s", linker.hyperlink(), entry.Scope(), entry.LastError.Message),
+			Short: false,
+		})
+	}
+
+	msg := slack.WebhookMessage{
+		Attachments: []slack.Attachment{attachment},
+	}
+	if err := s.client.PostWebhook(&msg); err != nil {
+		return fmt.Errorf("error sending slack notification: %v", err)
+	}
+	return nil
+}
+
+func (s *slackNotifier) DestinationUnhealthy(destination string, failures int) error {
+	msg := slack.WebhookMessage{
+		Attachments: []slack.Attachment{{
+			Color: errorColor,
+			Title: "Destination Unhealthy",
+			Text:  fmt.Sprintf("Yale stopped syncing keys to `%s` for the rest of the run after %d errors; it appears to be down", destination, failures),
+		}},
+	}
+	if err := s.client.PostWebhook(&msg); err != nil {
+		return fmt.Errorf("error sending slack notification: %v", err)
+	}
+	return nil
+}
+
+func (s *slackNotifier) RotatedKeysAccumulating(entry *cache.Entry, count int) error {
+	linker := serviceAccountLinker{entry: entry}
+	msg := slack.WebhookMessage{
+		Attachments: []slack.Attachment{{
+			Color:     errorColor,
+			Title:     fmt.Sprintf("%s Rotated Keys Accumulating", entry.Type),
+			TitleLink: linker.url(),
+			Text:      fmt.Sprintf("%d rotated keys for a %s in `%s` are still waiting to be disabled; a consumer is probably still using an old key", count, linker.hyperlink(), entry.Scope()),
+			Fields: []slack.AttachmentField{{
+				Title: "Email",
+				Value: entry.Identify(),
+			}},
+		}},
+	}
+	if err := s.client.PostWebhook(&msg); err != nil {
+		return fmt.Errorf("error sending slack notification: %v", err)
+	}
+	return nil
 }
 
 // build a slack message to report an event
-func (s *slackNotifier) buildAndSendMessage(evt event, entry *cache.Entry, fields map[string]string) error {
+func (s *slackNotifier) buildAndSendMessage(evt event, entry *cache.Entry, ctx MessageContext, fields map[string]string) error {
 	attachment := slack.Attachment{}
-	if evt == errorEvent {
+	switch evt {
+	case errorEvent:
 		attachment.Color = errorColor
-	} else {
+	case keyApproachingRotationEvent:
+		attachment.Color = warningColor
+	default:
 		attachment.Color = okColor
 	}
 
@@ -81,6 +181,19 @@ func (s *slackNotifier) buildAndSendMessage(evt event, entry *cache.Entry, field
 	case errorEvent:
 		attachment.Title = "Error"
 		attachment.Text = fmt.Sprintf("Error processing %s in `%s`", linker.hyperlink(), entry.Scope())
+	case keyApproachingRotationEvent:
+		attachment.Title = fmt.Sprintf("%s Approaching Rotation", entry.Type)
+		attachment.Text = fmt.Sprintf("A %s in `%s` will be rotated at %s", linker.hyperlink(), entry.Scope(), ctx.RotateAt.Format(time.RFC3339))
+	}
+
+	ctx.Entry = entry
+	ctx.Identifier = entry.Identify()
+	text, rendered, err := s.render(evt, ctx)
+	if err != nil {
+		return err
+	}
+	if rendered {
+		attachment.Text = text
 	}
 
 	attachment.Fields = append(attachment.Fields, slack.AttachmentField{
@@ -89,10 +202,15 @@ func (s *slackNotifier) buildAndSendMessage(evt event, entry *cache.Entry, field
 		Short: false,
 	})
 
-	for name, value := range fields {
+	var names []string
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
 		attachment.Fields = append(attachment.Fields, slack.AttachmentField{
 			Title: name,
-			Value: value,
+			Value: fields[name],
 			Short: false,
 		})
 	}
@@ -101,7 +219,7 @@ func (s *slackNotifier) buildAndSendMessage(evt event, entry *cache.Entry, field
 		Attachments: []slack.Attachment{attachment},
 	}
 
-	err := s.client.PostWebhook(&msg)
+	err = s.client.PostWebhook(&msg)
 	if err != nil {
 		return fmt.Errorf("error sending slack notification: %v", err)
 	}
@@ -2,6 +2,7 @@ package slack
 
 import (
 	"testing"
+	"time"
 
 	"github.com/broadinstitute/yale/internal/yale/cache"
 	"github.com/slack-go/slack"
@@ -167,6 +168,155 @@ func Test_SlackNotifier_Error(t *testing.T) {
 	}, "something went wrong"))
 }
 
+func Test_SlackNotifier_KeyApproachingRotation(t *testing.T) {
+	client := newMockClient(t)
+
+	s := &slackNotifier{
+		client: client,
+	}
+
+	client.On(
+		postWebhookMethod,
+		&slack.WebhookMessage{
+			Attachments: []slack.Attachment{
+				{
+					Color:     warningColor,
+					Title:     "GcpSaKey Approaching Rotation",
+					TitleLink: "https://console.cloud.google.com/iam-admin/serviceaccounts/details/sa1@p.com?project=p",
+					Text:      "A <https://console.cloud.google.com/iam-admin/serviceaccounts/details/sa1@p.com?project=p|GcpSaKey> in `p` will be rotated at 2023-04-28T09:10:11Z",
+					Fields: []slack.AttachmentField{
+						{
+							Title: "Email",
+							Value: "sa1@p.com",
+						}, {
+							Title: "Key ID",
+							Value: "`1234`",
+						},
+					},
+				},
+			},
+		},
+	).Return(nil)
+
+	require.NoError(t, s.KeyApproachingRotation(&cache.Entry{
+		Type: cache.GcpSaKey,
+		Identifier: cache.GcpSaKeyEntryIdentifier{
+			Email:   "sa1@p.com",
+			Project: "p",
+		},
+	}, "1234", time.Date(2023, 4, 28, 9, 10, 11, 0, time.UTC)))
+}
+
+func Test_SlackNotifier_ErrorDigest(t *testing.T) {
+	client := newMockClient(t)
+
+	s := &slackNotifier{
+		client: client,
+	}
+
+	entry1 := &cache.Entry{
+		Type: cache.GcpSaKey,
+		Identifier: cache.GcpSaKeyEntryIdentifier{
+			Email:   "sa1@p.com",
+			Project: "p",
+		},
+	}
+	entry1.LastError.Message = "uh-oh"
+	entry2 := &cache.Entry{
+		Type: cache.GcpSaKey,
+		Identifier: cache.GcpSaKeyEntryIdentifier{
+			Email:   "sa2@p.com",
+			Project: "p",
+		},
+	}
+	entry2.LastError.Message = "oh noes"
+
+	client.On(
+		postWebhookMethod,
+		&slack.WebhookMessage{
+			Attachments: []slack.Attachment{
+				{
+					Color: errorColor,
+					Title: "Errors",
+					Text:  "Yale encountered errors processing 2 resources",
+					Fields: []slack.AttachmentField{
+						{
+							Title: "GcpSaKey sa1@p.com",
+							Value: "<https://console.cloud.google.com/iam-admin/serviceaccounts/details/sa1@p.com?project=p|GcpSaKey> in `p`: uh-oh",
+						}, {
+							Title: "GcpSaKey sa2@p.com",
+							Value: "<https://console.cloud.google.com/iam-admin/serviceaccounts/details/sa2@p.com?project=p|GcpSaKey> in `p`: oh noes",
+						},
+					},
+				},
+			},
+		},
+	).Return(nil)
+
+	require.NoError(t, s.ErrorDigest([]*cache.Entry{entry1, entry2}))
+}
+
+func Test_SlackNotifier_DestinationUnhealthy(t *testing.T) {
+	client := newMockClient(t)
+
+	s := &slackNotifier{
+		client: client,
+	}
+
+	client.On(
+		postWebhookMethod,
+		&slack.WebhookMessage{
+			Attachments: []slack.Attachment{
+				{
+					Color: errorColor,
+					Title: "Destination Unhealthy",
+					Text:  "Yale stopped syncing keys to `vault` for the rest of the run after 5 errors; it appears to be down",
+				},
+			},
+		},
+	).Return(nil)
+
+	require.NoError(t, s.DestinationUnhealthy("vault", 5))
+}
+
+func Test_SlackNotifier_RotatedKeysAccumulating(t *testing.T) {
+	client := newMockClient(t)
+
+	s := &slackNotifier{
+		client: client,
+	}
+
+	entry := &cache.Entry{
+		Type: cache.GcpSaKey,
+		Identifier: cache.GcpSaKeyEntryIdentifier{
+			Email:   "sa1@p.com",
+			Project: "p",
+		},
+	}
+
+	client.On(
+		postWebhookMethod,
+		&slack.WebhookMessage{
+			Attachments: []slack.Attachment{
+				{
+					Color:     errorColor,
+					Title:     "GcpSaKey Rotated Keys Accumulating",
+					TitleLink: "https://console.cloud.google.com/iam-admin/serviceaccounts/details/sa1@p.com?project=p",
+					Text:      "4 rotated keys for a <https://console.cloud.google.com/iam-admin/serviceaccounts/details/sa1@p.com?project=p|GcpSaKey> in `p` are still waiting to be disabled; a consumer is probably still using an old key",
+					Fields: []slack.AttachmentField{
+						{
+							Title: "Email",
+							Value: "sa1@p.com",
+						},
+					},
+				},
+			},
+		},
+	).Return(nil)
+
+	require.NoError(t, s.RotatedKeysAccumulating(entry, 4))
+}
+
 func newMockClient(t *testing.T) *mockClient {
 	m := &mockClient{}
 	t.Cleanup(func() {
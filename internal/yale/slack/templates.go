@@ -0,0 +1,89 @@
+package slack
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/broadinstitute/yale/internal/yale/cache"
+)
+
+// Names of the events whose messages can be customized with Options.MessageTemplates
+const (
+	KeyIssuedTemplate   = "keyIssued"
+	KeyDisabledTemplate = "keyDisabled"
+	KeyDeletedTemplate  = "keyDeleted"
+	ErrorTemplate       = "error"
+	// KeyApproachingRotationTemplate template for warnings that a key will be rotated soon
+	KeyApproachingRotationTemplate = "keyApproachingRotation"
+)
+
+var templateEvents = map[string]event{
+	KeyIssuedTemplate:              keyIssuedEvent,
+	KeyDisabledTemplate:            keyDisabledEvent,
+	KeyDeletedTemplate:             keyDeletedEvent,
+	ErrorTemplate:                  errorEvent,
+	KeyApproachingRotationTemplate: keyApproachingRotationEvent,
+}
+
+// MessageContext is the data that message templates are rendered with
+type MessageContext struct {
+	// Entry the cache entry the notification is about
+	Entry *cache.Entry
+	// KeyID ID of the key that was issued, disabled, or deleted. Empty for errors
+	KeyID string
+	// Identifier identifier of the cache entry (eg. the service account email)
+	Identifier string
+	// Error the error message. Only set for errors
+	Error string
+	// RotateAt time at which the key will be rotated. Only set for rotation warnings
+	RotateAt time.Time
+}
+
+// ValidateTemplates returns an error if any of the message templates is for an unknown event or fails to parse
+func ValidateTemplates(templates map[string]string) error {
+	_, err := parseTemplates(templates)
+	return err
+}
+
+// parseTemplates parses text/template strings keyed by event name into templates keyed by event
+func parseTemplates(templates map[string]string) (map[event]*template.Template, error) {
+	result := make(map[event]*template.Template)
+	for name, text := range templates {
+		evt, exists := templateEvents[name]
+		if !exists {
+			return nil, fmt.Errorf("unknown message template %q, must be one of: %s", name, strings.Join(templateNames(), ", "))
+		}
+		tmpl, err := template.New(name).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s message template: %v", name, err)
+		}
+		result[evt] = tmpl
+	}
+	return result, nil
+}
+
+func templateNames() []string {
+	var names []string
+	for name := range templateEvents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// render renders the template for the event, returning false if there is no template for it
+func (s *slackNotifier) render(evt event, ctx MessageContext) (string, bool, error) {
+	tmpl, exists := s.templates[evt]
+	if !exists {
+		return "", false, nil
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, ctx); err != nil {
+		return "", false, fmt.Errorf("error rendering %s message template: %v", tmpl.Name(), err)
+	}
+	return buf.String(), true, nil
+}
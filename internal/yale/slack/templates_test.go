@@ -0,0 +1,131 @@
+package slack
+
+import (
+	"testing"
+	"time"
+
+	"github.com/broadinstitute/yale/internal/yale/cache"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SlackNotifier_RendersMessageTemplates(t *testing.T) {
+	templates := map[string]string{
+		KeyIssuedTemplate:              "issued {{ .KeyID }} for {{ .Identifier }} ({{ .Entry.Type }}, created {{ .Entry.CurrentKey.CreatedAt.Format \"2006-01-02\" }})",
+		KeyDisabledTemplate:            "disabled {{ .KeyID }} for {{ .Identifier }}",
+		KeyDeletedTemplate:             "deleted {{ .KeyID }} for {{ .Identifier }}",
+		ErrorTemplate:                  "{{ .Identifier }} in {{ .Entry.Scope }} failed: {{ .Error }}",
+		KeyApproachingRotationTemplate: "{{ .KeyID }} rotates at {{ .RotateAt.Format \"2006-01-02 15:04\" }}",
+	}
+
+	entry := &cache.Entry{
+		Type: cache.GcpSaKey,
+		Identifier: cache.GcpSaKeyEntryIdentifier{
+			Email:   "sa1@p.com",
+			Project: "p",
+		},
+	}
+	entry.CurrentKey.CreatedAt = time.Date(2023, 4, 28, 9, 10, 11, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		notify   func(s SlackNotifier) error
+		expected string
+	}{
+		{
+			name:     KeyIssuedTemplate,
+			notify:   func(s SlackNotifier) error { return s.KeyIssued(entry, "1234") },
+			expected: "issued 1234 for sa1@p.com (GcpSaKey, created 2023-04-28)",
+		},
+		{
+			name:     KeyDisabledTemplate,
+			notify:   func(s SlackNotifier) error { return s.KeyDisabled(entry, "1234") },
+			expected: "disabled 1234 for sa1@p.com",
+		},
+		{
+			name:     KeyDeletedTemplate,
+			notify:   func(s SlackNotifier) error { return s.KeyDeleted(entry, "1234") },
+			expected: "deleted 1234 for sa1@p.com",
+		},
+		{
+			name:     ErrorTemplate,
+			notify:   func(s SlackNotifier) error { return s.Error(entry, "uh-oh") },
+			expected: "sa1@p.com in p failed: uh-oh",
+		},
+		{
+			name: KeyApproachingRotationTemplate,
+			notify: func(s SlackNotifier) error {
+				return s.KeyApproachingRotation(entry, "1234", time.Date(2023, 5, 5, 9, 10, 11, 0, time.UTC))
+			},
+			expected: "1234 rotates at 2023-05-05 09:10",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newMockClient(t)
+			parsed, err := parseTemplates(templates)
+			require.NoError(t, err)
+			s := &slackNotifier{client: client, templates: parsed}
+
+			client.On(postWebhookMethod, mock.MatchedBy(func(msg *slack.WebhookMessage) bool {
+				return msg.Attachments[0].Text == tc.expected
+			})).Return(nil)
+
+			require.NoError(t, tc.notify(s))
+		})
+	}
+}
+
+func Test_SlackNotifier_FallsBackToDefaultMessageWithoutTemplate(t *testing.T) {
+	client := newMockClient(t)
+	parsed, err := parseTemplates(map[string]string{
+		ErrorTemplate: "failed: {{ .Error }}",
+	})
+	require.NoError(t, err)
+	s := &slackNotifier{client: client, templates: parsed}
+
+	client.On(postWebhookMethod, mock.MatchedBy(func(msg *slack.WebhookMessage) bool {
+		return msg.Attachments[0].Text == "A new <https://console.cloud.google.com/iam-admin/serviceaccounts/details/sa1@p.com?project=p|GcpSaKey> was issued in `p`"
+	})).Return(nil)
+
+	require.NoError(t, s.KeyIssued(&cache.Entry{
+		Type: cache.GcpSaKey,
+		Identifier: cache.GcpSaKeyEntryIdentifier{
+			Email:   "sa1@p.com",
+			Project: "p",
+		},
+	}, "1234"))
+}
+
+func Test_SlackNotifier_ReturnsErrorIfTemplateFailsToRender(t *testing.T) {
+	parsed, err := parseTemplates(map[string]string{
+		KeyIssuedTemplate: "{{ .NoSuchField }}",
+	})
+	require.NoError(t, err)
+	s := &slackNotifier{client: newMockClient(t), templates: parsed}
+
+	err = s.KeyIssued(&cache.Entry{Type: cache.GcpSaKey, Identifier: cache.GcpSaKeyEntryIdentifier{Email: "sa1@p.com"}}, "1234")
+	assert.ErrorContains(t, err, "error rendering keyIssued message template")
+}
+
+func Test_ValidateTemplates(t *testing.T) {
+	assert.NoError(t, ValidateTemplates(nil))
+	assert.NoError(t, ValidateTemplates(map[string]string{KeyIssuedTemplate: "issued {{ .KeyID }}"}))
+
+	err := ValidateTemplates(map[string]string{"keyRotated": "rotated"})
+	assert.ErrorContains(t, err, `unknown message template "keyRotated", must be one of: error, keyApproachingRotation, keyDeleted, keyDisabled, keyIssued`)
+
+	err = ValidateTemplates(map[string]string{ErrorTemplate: "{{ .Error "})
+	assert.ErrorContains(t, err, "error parsing error message template")
+}
+
+func Test_New_PanicsOnInvalidTemplates(t *testing.T) {
+	assert.Panics(t, func() {
+		New("", func(options *Options) {
+			options.MessageTemplates = map[string]string{ErrorTemplate: "{{ .Error "}
+		})
+	})
+}
@@ -0,0 +1,75 @@
+package yale
+
+import (
+	"context"
+	"strings"
+
+	"github.com/broadinstitute/yale/internal/yale/cache"
+	apiv1b1 "github.com/broadinstitute/yale/internal/yale/crd/api/v1beta1"
+	"github.com/broadinstitute/yale/internal/yale/logs"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// updateYaleResourceStatuses writes the state of the cache entry's current key to the status subresource of
+// every Yale CRD that shares the entry, so that `kubectl get` shows key age and sync state. syncStatusBefore
+// is a copy of the entry's sync status taken before the entry was processed, and is used to tell whether
+// a CRD was synced during this run. Errors writing status are logged but do not fail the run.
+func updateYaleResourceStatuses[Y apiv1b1.YaleCRD](yale *Yale, entry *cache.Entry, yaleCRDs []Y, syncStatusBefore map[string]string, processErr error) {
+	if yale.crd == nil || yale.options.DryRun {
+		return
+	}
+
+	switch crds := any(&yaleCRDs).(type) {
+	case *[]apiv1b1.GcpSaKey:
+		for _, gsk := range *crds {
+			status := computeStatus(entry, gsk.Namespace(), gsk.Name(), gsk.SecretName(), gsk.Status, syncStatusBefore, processErr)
+			if equality.Semantic.DeepEqual(status, gsk.Status) {
+				continue
+			}
+			if err := yale.crd.GcpSaKeys().UpdateStatus(context.Background(), gsk.Namespace(), gsk.Name(), status); err != nil {
+				logs.Warn.Printf("error updating status for %s %s in %s: %v", entry.Type, gsk.Name(), gsk.Namespace(), err)
+			}
+		}
+	case *[]apiv1b1.AzureClientSecret:
+		for _, acs := range *crds {
+			status := computeStatus(entry, acs.Namespace(), acs.Name(), acs.SecretName(), acs.Status, syncStatusBefore, processErr)
+			if equality.Semantic.DeepEqual(status, acs.Status) {
+				continue
+			}
+			if err := yale.crd.AzureClientSecrets().UpdateStatus(context.Background(), acs.Namespace(), acs.Name(), status); err != nil {
+				logs.Warn.Printf("error updating status for %s %s in %s: %v", entry.Type, acs.Name(), acs.Namespace(), err)
+			}
+		}
+	}
+}
+
+// computeStatus returns the status that should be reported for a single Yale CRD, given its previous status
+func computeStatus(entry *cache.Entry, namespace string, name string, secretName string, previous apiv1b1.Status, syncStatusBefore map[string]string, processErr error) apiv1b1.Status {
+	var status apiv1b1.Status
+
+	if entry.CurrentKey.ID != "" {
+		status.CurrentKeyID = entry.CurrentKey.ID
+		rotatedAt := metav1.NewTime(entry.CurrentKey.CreatedAt).Rfc3339Copy()
+		status.LastRotatedAt = &rotatedAt
+	}
+
+	statusKey := namespace + "/" + name
+	syncStatus := entry.SyncStatus[statusKey]
+	if entry.CurrentKey.ID != "" && strings.HasSuffix(syncStatus, ":"+entry.CurrentKey.ID) {
+		status.SyncedSecret = secretName
+		if syncStatus != syncStatusBefore[statusKey] || previous.LastSyncedAt == nil {
+			syncedAt := metav1.NewTime(currentTime()).Rfc3339Copy()
+			status.LastSyncedAt = &syncedAt
+		} else {
+			status.LastSyncedAt = previous.LastSyncedAt
+		}
+	} else {
+		status.LastSyncedAt = previous.LastSyncedAt
+	}
+
+	if processErr != nil {
+		status.Error = processErr.Error()
+	}
+	return status
+}
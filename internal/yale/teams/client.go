@@ -0,0 +1,47 @@
+package teams
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const WebhookEnvVar = "YALE_TEAMS_WEBHOOK_URL"
+
+// teamsClient is an interface for sending messages via Teams incoming webhooks
+// it exists to allow for mocking in tests
+type teamsClient interface {
+	PostWebhook(message *webhookMessage) error
+}
+
+func newTeamsClient(webhookUrl string) teamsClient {
+	return realClient{
+		webhookUrl: webhookUrl,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type realClient struct {
+	webhookUrl string
+	httpClient *http.Client
+}
+
+func (r realClient) PostWebhook(message *webhookMessage) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("error marshalling Teams message: %v", err)
+	}
+	resp, err := r.httpClient.Post(r.webhookUrl, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
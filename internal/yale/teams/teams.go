@@ -0,0 +1,198 @@
+package teams
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/broadinstitute/yale/internal/yale/cache"
+	"github.com/broadinstitute/yale/internal/yale/notify"
+)
+
+const adaptiveCardContentType = "application/vnd.microsoft.card.adaptive"
+const adaptiveCardSchema = "http://adaptivecards.io/schemas/adaptive-card.json"
+const adaptiveCardVersion = "1.4"
+
+const okColor = "Good"
+const errorColor = "Attention"
+const warningColor = "Warning"
+
+type event int64
+
+const (
+	keyIssuedEvent event = iota
+	keyDisabledEvent
+	keyDeletedEvent
+	errorEvent
+)
+
+// webhookMessage is the payload for a Teams incoming webhook, wrapping a single Adaptive Card
+type webhookMessage struct {
+	Type        string       `json:"type"`
+	Attachments []attachment `json:"attachments"`
+}
+
+type attachment struct {
+	ContentType string       `json:"contentType"`
+	Content     adaptiveCard `json:"content"`
+}
+
+type adaptiveCard struct {
+	Schema  string    `json:"$schema"`
+	Type    string    `json:"type"`
+	Version string    `json:"version"`
+	Body    []element `json:"body"`
+	Actions []action  `json:"actions,omitempty"`
+}
+
+// element is an Adaptive Card body element; only the fields for TextBlock and FactSet elements are supported
+type element struct {
+	Type   string `json:"type"`
+	Text   string `json:"text,omitempty"`
+	Weight string `json:"weight,omitempty"`
+	Size   string `json:"size,omitempty"`
+	Color  string `json:"color,omitempty"`
+	Wrap   bool   `json:"wrap,omitempty"`
+	Facts  []fact `json:"facts,omitempty"`
+}
+
+type fact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+type action struct {
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// New returns a Notifier that posts Adaptive Cards to the given Microsoft Teams incoming webhook
+func New(webhookUrl string) notify.Notifier {
+	return &teamsNotifier{
+		client: newTeamsClient(webhookUrl),
+	}
+}
+
+type teamsNotifier struct {
+	client teamsClient
+}
+
+func (t *teamsNotifier) KeyIssued(entry *cache.Entry, id string) error {
+	return t.buildAndSendMessage(keyIssuedEvent, entry, fact{Title: "Key ID", Value: id})
+}
+
+func (t *teamsNotifier) KeyDisabled(entry *cache.Entry, id string) error {
+	return t.buildAndSendMessage(keyDisabledEvent, entry, fact{Title: "Key ID", Value: id})
+}
+
+func (t *teamsNotifier) KeyDeleted(entry *cache.Entry, id string) error {
+	return t.buildAndSendMessage(keyDeletedEvent, entry, fact{Title: "Key ID", Value: id})
+}
+
+func (t *teamsNotifier) KeyApproachingRotation(entry *cache.Entry, id string, rotateAt time.Time) error {
+	url := serviceAccountUrl(entry)
+	return t.send([]element{
+		{Type: "TextBlock", Text: fmt.Sprintf("%s Approaching Rotation", entry.Type), Weight: "Bolder", Size: "Medium", Color: warningColor},
+		{Type: "TextBlock", Text: fmt.Sprintf("A [%s](%s) in `%s` will be rotated at %s", entry.Type, url, entry.Scope(), rotateAt.Format(time.RFC3339)), Wrap: true},
+		{Type: "FactSet", Facts: []fact{{Title: "Email", Value: entry.Identify()}, {Title: "Key ID", Value: id}}},
+	}, []action{
+		{Type: "Action.OpenUrl", Title: "View in Cloud Console", URL: url},
+	})
+}
+
+func (t *teamsNotifier) Error(entry *cache.Entry, message string) error {
+	return t.buildAndSendMessage(errorEvent, entry, fact{Title: "Error", Value: message})
+}
+
+func (t *teamsNotifier) ErrorDigest(entries []*cache.Entry) error {
+	var facts []fact
+	for _, entry := range entries {
+		facts = append(facts, fact{Title: fmt.Sprintf("%s %s", entry.Type, entry.Identify()), Value: entry.LastError.Message})
+	}
+	return t.send([]element{
+		{Type: "TextBlock", Text: "Errors", Weight: "Bolder", Size: "Medium", Color: errorColor},
+		{Type: "TextBlock", Text: fmt.Sprintf("Yale encountered errors processing %d resources", len(entries)), Wrap: true},
+		{Type: "FactSet", Facts: facts},
+	}, nil)
+}
+
+func (t *teamsNotifier) DestinationUnhealthy(destination string, failures int) error {
+	return t.send([]element{
+		{Type: "TextBlock", Text: "Destination Unhealthy", Weight: "Bolder", Size: "Medium", Color: errorColor},
+		{Type: "TextBlock", Text: fmt.Sprintf("Yale stopped syncing keys to `%s` for the rest of the run after %d errors; it appears to be down", destination, failures), Wrap: true},
+	}, nil)
+}
+
+func (t *teamsNotifier) RotatedKeysAccumulating(entry *cache.Entry, count int) error {
+	url := serviceAccountUrl(entry)
+	return t.send([]element{
+		{Type: "TextBlock", Text: fmt.Sprintf("%s Rotated Keys Accumulating", entry.Type), Weight: "Bolder", Size: "Medium", Color: errorColor},
+		{Type: "TextBlock", Text: fmt.Sprintf("%d rotated keys for a [%s](%s) in `%s` are still waiting to be disabled; a consumer is probably still using an old key", count, entry.Type, url, entry.Scope()), Wrap: true},
+		{Type: "FactSet", Facts: []fact{{Title: "Email", Value: entry.Identify()}}},
+	}, []action{
+		{Type: "Action.OpenUrl", Title: "View in Cloud Console", URL: url},
+	})
+}
+
+// build an Adaptive Card to report an event
+func (t *teamsNotifier) buildAndSendMessage(evt event, entry *cache.Entry, detail fact) error {
+	color := okColor
+	if evt == errorEvent {
+		color = errorColor
+	}
+
+	url := serviceAccountUrl(entry)
+	link := fmt.Sprintf("[%s](%s)", entry.Type, url)
+
+	var title, text string
+	switch evt {
+	case keyIssuedEvent:
+		title = fmt.Sprintf("%s Issued", entry.Type)
+		text = fmt.Sprintf("A new %s was issued in `%s`", link, entry.Scope())
+	case keyDisabledEvent:
+		title = fmt.Sprintf("%s Disabled", entry.Type)
+		text = fmt.Sprintf("A %s was disabled in `%s`", link, entry.Scope())
+	case keyDeletedEvent:
+		title = fmt.Sprintf("%s Deleted", entry.Type)
+		text = fmt.Sprintf("A %s was deleted in `%s`", link, entry.Scope())
+	case errorEvent:
+		title = "Error"
+		text = fmt.Sprintf("Error processing %s in `%s`", link, entry.Scope())
+	}
+
+	return t.send([]element{
+		{Type: "TextBlock", Text: title, Weight: "Bolder", Size: "Medium", Color: color},
+		{Type: "TextBlock", Text: text, Wrap: true},
+		{Type: "FactSet", Facts: []fact{{Title: "Email", Value: entry.Identify()}, detail}},
+	}, []action{
+		{Type: "Action.OpenUrl", Title: "View in Cloud Console", URL: url},
+	})
+}
+
+// send an Adaptive Card with the given body and actions
+func (t *teamsNotifier) send(body []element, actions []action) error {
+	msg := webhookMessage{
+		Type: "message",
+		Attachments: []attachment{
+			{
+				ContentType: adaptiveCardContentType,
+				Content: adaptiveCard{
+					Schema:  adaptiveCardSchema,
+					Type:    "AdaptiveCard",
+					Version: adaptiveCardVersion,
+					Body:    body,
+					Actions: actions,
+				},
+			},
+		},
+	}
+
+	if err := t.client.PostWebhook(&msg); err != nil {
+		return fmt.Errorf("error sending Teams notification: %v", err)
+	}
+	return nil
+}
+
+func serviceAccountUrl(entry *cache.Entry) string {
+	return fmt.Sprintf("https://console.cloud.google.com/iam-admin/serviceaccounts/details/%s?project=%s", entry.Identify(), entry.Scope())
+}
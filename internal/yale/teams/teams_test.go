@@ -0,0 +1,194 @@
+package teams
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/broadinstitute/yale/internal/yale/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const postWebhookMethod = "PostWebhook"
+
+var entry = &cache.Entry{
+	Type: cache.GcpSaKey,
+	Identifier: cache.GcpSaKeyEntryIdentifier{
+		Email:   "sa1@p.com",
+		Project: "p",
+	},
+}
+
+const consoleUrl = "https://console.cloud.google.com/iam-admin/serviceaccounts/details/sa1@p.com?project=p"
+
+func Test_TeamsNotifier_KeyIssued(t *testing.T) {
+	client := newMockClient(t)
+	n := &teamsNotifier{client: client}
+
+	client.On(postWebhookMethod, expectedMessage(okColor, "GcpSaKey Issued", "A new [GcpSaKey]("+consoleUrl+") was issued in `p`", fact{Title: "Key ID", Value: "1234"})).Return(nil)
+
+	require.NoError(t, n.KeyIssued(entry, "1234"))
+}
+
+func Test_TeamsNotifier_KeyDisabled(t *testing.T) {
+	client := newMockClient(t)
+	n := &teamsNotifier{client: client}
+
+	client.On(postWebhookMethod, expectedMessage(okColor, "GcpSaKey Disabled", "A [GcpSaKey]("+consoleUrl+") was disabled in `p`", fact{Title: "Key ID", Value: "1234"})).Return(nil)
+
+	require.NoError(t, n.KeyDisabled(entry, "1234"))
+}
+
+func Test_TeamsNotifier_KeyDeleted(t *testing.T) {
+	client := newMockClient(t)
+	n := &teamsNotifier{client: client}
+
+	client.On(postWebhookMethod, expectedMessage(okColor, "GcpSaKey Deleted", "A [GcpSaKey]("+consoleUrl+") was deleted in `p`", fact{Title: "Key ID", Value: "1234"})).Return(nil)
+
+	require.NoError(t, n.KeyDeleted(entry, "1234"))
+}
+
+func Test_TeamsNotifier_Error(t *testing.T) {
+	client := newMockClient(t)
+	n := &teamsNotifier{client: client}
+
+	client.On(postWebhookMethod, expectedMessage(errorColor, "Error", "Error processing [GcpSaKey]("+consoleUrl+") in `p`", fact{Title: "Error", Value: "something went wrong"})).Return(nil)
+
+	require.NoError(t, n.Error(entry, "something went wrong"))
+}
+
+func Test_TeamsNotifier_KeyApproachingRotation(t *testing.T) {
+	client := newMockClient(t)
+	n := &teamsNotifier{client: client}
+
+	expected := expectedMessage(warningColor, "GcpSaKey Approaching Rotation", "A [GcpSaKey]("+consoleUrl+") in `p` will be rotated at 2023-04-28T09:10:11Z", fact{Title: "Key ID", Value: "1234"})
+	client.On(postWebhookMethod, expected).Return(nil)
+
+	require.NoError(t, n.KeyApproachingRotation(entry, "1234", time.Date(2023, 4, 28, 9, 10, 11, 0, time.UTC)))
+}
+
+func Test_TeamsNotifier_ErrorDigest(t *testing.T) {
+	client := newMockClient(t)
+	n := &teamsNotifier{client: client}
+
+	failing := &cache.Entry{
+		Type: cache.GcpSaKey,
+		Identifier: cache.GcpSaKeyEntryIdentifier{
+			Email:   "sa2@p.com",
+			Project: "p",
+		},
+	}
+	failing.LastError.Message = "uh-oh"
+
+	client.On(postWebhookMethod, mock.MatchedBy(func(msg *webhookMessage) bool {
+		body := msg.Attachments[0].Content.Body
+		return body[1].Text == "Yale encountered errors processing 1 resources" &&
+			assert.ObjectsAreEqual([]fact{{Title: "GcpSaKey sa2@p.com", Value: "uh-oh"}}, body[2].Facts)
+	})).Return(nil)
+
+	require.NoError(t, n.ErrorDigest([]*cache.Entry{failing}))
+}
+
+func Test_TeamsNotifier_DestinationUnhealthy(t *testing.T) {
+	client := newMockClient(t)
+	n := &teamsNotifier{client: client}
+
+	client.On(postWebhookMethod, mock.MatchedBy(func(msg *webhookMessage) bool {
+		body := msg.Attachments[0].Content.Body
+		return body[0].Text == "Destination Unhealthy" && body[0].Color == errorColor &&
+			body[1].Text == "Yale stopped syncing keys to `vault` for the rest of the run after 5 errors; it appears to be down"
+	})).Return(nil)
+
+	require.NoError(t, n.DestinationUnhealthy("vault", 5))
+}
+
+func Test_TeamsNotifier_RotatedKeysAccumulating(t *testing.T) {
+	client := newMockClient(t)
+	n := &teamsNotifier{client: client}
+
+	client.On(postWebhookMethod, mock.MatchedBy(func(msg *webhookMessage) bool {
+		body := msg.Attachments[0].Content.Body
+		return body[0].Text == "GcpSaKey Rotated Keys Accumulating" && body[0].Color == errorColor &&
+			body[1].Text == "4 rotated keys for a [GcpSaKey]("+consoleUrl+") in `p` are still waiting to be disabled; a consumer is probably still using an old key"
+	})).Return(nil)
+
+	require.NoError(t, n.RotatedKeysAccumulating(entry, 4))
+}
+
+func Test_RealClient_PostsAdaptiveCard(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	require.NoError(t, New(server.URL).KeyIssued(entry, "1234"))
+
+	assert.Equal(t, "message", received["type"])
+	attachments := received["attachments"].([]interface{})
+	require.Len(t, attachments, 1)
+	assert.Equal(t, adaptiveCardContentType, attachments[0].(map[string]interface{})["contentType"])
+	content := attachments[0].(map[string]interface{})["content"].(map[string]interface{})
+	assert.Equal(t, "AdaptiveCard", content["type"])
+	assert.Equal(t, adaptiveCardSchema, content["$schema"])
+}
+
+func Test_RealClient_ReturnsErrorOnFailureStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("bad payload"))
+	}))
+	defer server.Close()
+
+	err := New(server.URL).Error(entry, "uh-oh")
+	assert.ErrorContains(t, err, "error sending Teams notification: webhook returned status 400: bad payload")
+}
+
+func expectedMessage(color string, title string, text string, detail fact) *webhookMessage {
+	return &webhookMessage{
+		Type: "message",
+		Attachments: []attachment{
+			{
+				ContentType: adaptiveCardContentType,
+				Content: adaptiveCard{
+					Schema:  adaptiveCardSchema,
+					Type:    "AdaptiveCard",
+					Version: adaptiveCardVersion,
+					Body: []element{
+						{Type: "TextBlock", Text: title, Weight: "Bolder", Size: "Medium", Color: color},
+						{Type: "TextBlock", Text: text, Wrap: true},
+						{Type: "FactSet", Facts: []fact{{Title: "Email", Value: "sa1@p.com"}, detail}},
+					},
+					Actions: []action{
+						{Type: "Action.OpenUrl", Title: "View in Cloud Console", URL: consoleUrl},
+					},
+				},
+			},
+		},
+	}
+}
+
+func newMockClient(t *testing.T) *mockClient {
+	m := &mockClient{}
+	t.Cleanup(func() {
+		m.AssertExpectations(t)
+	})
+	return m
+}
+
+// mock implementation of teamsClient
+type mockClient struct {
+	mock.Mock
+}
+
+func (f *mockClient) PostWebhook(message *webhookMessage) error {
+	args := f.Called(message)
+	return args.Error(0)
+}
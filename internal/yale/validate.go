@@ -0,0 +1,52 @@
+package yale
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/broadinstitute/yale/internal/yale/keysync"
+	"github.com/broadinstitute/yale/internal/yale/logs"
+)
+
+// Validate checks the GcpSaKeys, AzureClientSecrets, and cache entries in the cluster for problems, without
+// issuing, rotating, or disabling keys, writing secrets, or modifying the cache. It reports
+//   - resources with invalid specs, and service accounts whose resources conflict with each other or with
+//     their cache entry (eg. a project or tenant mismatch), which regular runs skip with a warning
+//   - cache entries that don't match any GcpSaKey or AzureClientSecret resource
+//   - secrets that would be written to namespaces that don't exist, locally or in a target cluster
+//
+// Returns the problems found, or an error if the checks could not be completed.
+func (m *Yale) Validate() ([]error, error) {
+	resources, problems, err := m.resourcemap.Check()
+	if err != nil {
+		return nil, fmt.Errorf("error inspecting cluster for cache entries and GcpSaKey resources: %v", err)
+	}
+
+	var identifiers []string
+	for identifier := range resources {
+		identifiers = append(identifiers, identifier)
+	}
+	sort.Strings(identifiers)
+
+	var syncables []keysync.Syncable
+	for _, identifier := range identifiers {
+		bundle := resources[identifier]
+		if bundle.Entry != nil && len(bundle.GSKs) == 0 && len(bundle.AzClientSecrets) == 0 {
+			problems = append(problems, fmt.Errorf("cache entry for %s %s does not match any GcpSaKey or AzureClientSecret resource", bundle.Entry.Type, identifier))
+		}
+		syncables = append(syncables, keysync.GcpSaKeysToSyncable(bundle.GSKs)...)
+		syncables = append(syncables, keysync.AzureClientSecretsToSyncable(bundle.AzClientSecrets)...)
+	}
+
+	namespaceProblems, err := m.keysync.CheckNamespaces(syncables)
+	if err != nil {
+		return nil, fmt.Errorf("error checking secret namespaces: %v", err)
+	}
+	problems = append(problems, namespaceProblems...)
+
+	for _, problem := range problems {
+		logs.Warn.Printf("validate: %v", problem)
+	}
+	logs.Info.Printf("validate: checked %d service accounts, found %d problems", len(identifiers), len(problems))
+	return problems, nil
+}
@@ -0,0 +1,117 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/broadinstitute/yale/internal/yale/cache"
+	"github.com/broadinstitute/yale/internal/yale/logs"
+	"github.com/broadinstitute/yale/internal/yale/notify"
+)
+
+// timeout how long to wait for the webhook to respond. Delivery is best-effort, so a slow endpoint
+// should not hold up key rotation
+const timeout = 5 * time.Second
+
+// Event is the JSON payload posted to the webhook for each key lifecycle event
+type Event struct {
+	// Event what happened to the key: issued, disabled, or deleted
+	Event cache.KeyEventType `json:"event"`
+	// Type the type of the cache entry (eg. GcpSaKey)
+	Type string `json:"type"`
+	// Identifier the service account email or Azure application id the key belongs to
+	Identifier string `json:"identifier"`
+	// Scope the GCP project or Azure tenant of the identifier
+	Scope string `json:"scope"`
+	// KeyID id of the key the event happened to
+	KeyID string `json:"keyId"`
+	// Timestamp time at which the event was sent
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// New returns a Notifier that POSTs key issued, disabled, and deleted events to the given URL as JSON. Errors
+// and warnings are not sent. Delivery is best-effort: failures are logged, but never returned.
+func New(url string) notify.Notifier {
+	return &webhookNotifier{
+		url:        url,
+		httpClient: &http.Client{Timeout: timeout},
+		now:        time.Now,
+	}
+}
+
+type webhookNotifier struct {
+	url        string
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+func (w *webhookNotifier) KeyIssued(entry *cache.Entry, id string) error {
+	w.send(cache.KeyIssued, entry, id)
+	return nil
+}
+
+func (w *webhookNotifier) KeyDisabled(entry *cache.Entry, id string) error {
+	w.send(cache.KeyDisabled, entry, id)
+	return nil
+}
+
+func (w *webhookNotifier) KeyDeleted(entry *cache.Entry, id string) error {
+	w.send(cache.KeyDeleted, entry, id)
+	return nil
+}
+
+func (w *webhookNotifier) KeyApproachingRotation(_ *cache.Entry, _ string, _ time.Time) error {
+	return nil
+}
+
+func (w *webhookNotifier) Error(_ *cache.Entry, _ string) error {
+	return nil
+}
+
+func (w *webhookNotifier) ErrorDigest(_ []*cache.Entry) error {
+	return nil
+}
+
+func (w *webhookNotifier) DestinationUnhealthy(_ string, _ int) error {
+	return nil
+}
+
+func (w *webhookNotifier) RotatedKeysAccumulating(_ *cache.Entry, _ int) error {
+	return nil
+}
+
+// send posts the event to the webhook, logging a warning if it could not be delivered
+func (w *webhookNotifier) send(eventType cache.KeyEventType, entry *cache.Entry, id string) {
+	event := Event{
+		Event:      eventType,
+		Type:       entry.Type.String(),
+		Identifier: entry.Identify(),
+		Scope:      entry.Scope(),
+		KeyID:      id,
+		Timestamp:  w.now().UTC(),
+	}
+	if err := w.post(event); err != nil {
+		logs.Warn.Printf("%s %s: error sending key %s event for %s to webhook: %v", entry.Type, entry.Identify(), eventType, id, err)
+	}
+}
+
+func (w *webhookNotifier) post(event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error marshalling webhook event: %v", err)
+	}
+	resp, err := w.httpClient.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/broadinstitute/yale/internal/yale/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var entry = &cache.Entry{
+	Type: cache.GcpSaKey,
+	Identifier: cache.GcpSaKeyEntryIdentifier{
+		Email:   "sa1@p.com",
+		Project: "p",
+	},
+}
+
+var now = time.Date(2023, 4, 28, 9, 10, 11, 0, time.UTC)
+
+func Test_Webhook_PostsKeyEvents(t *testing.T) {
+	var received []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var payload map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		received = append(received, payload)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	n := newTestNotifier(server.URL)
+	require.NoError(t, n.KeyIssued(entry, "key-1"))
+	require.NoError(t, n.KeyDisabled(entry, "key-2"))
+	require.NoError(t, n.KeyDeleted(entry, "key-3"))
+
+	expected := func(event string, keyID string) map[string]interface{} {
+		return map[string]interface{}{
+			"event":      event,
+			"type":       "GcpSaKey",
+			"identifier": "sa1@p.com",
+			"scope":      "p",
+			"keyId":      keyID,
+			"timestamp":  "2023-04-28T09:10:11Z",
+		}
+	}
+	assert.Equal(t, []map[string]interface{}{
+		expected("issued", "key-1"),
+		expected("disabled", "key-2"),
+		expected("deleted", "key-3"),
+	}, received)
+}
+
+func Test_Webhook_DoesNotPostErrorsOrWarnings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to webhook: %s %s", r.Method, r.URL)
+	}))
+	defer server.Close()
+
+	n := newTestNotifier(server.URL)
+	require.NoError(t, n.Error(entry, "uh-oh"))
+	require.NoError(t, n.ErrorDigest([]*cache.Entry{entry}))
+	require.NoError(t, n.KeyApproachingRotation(entry, "key-1", now))
+}
+
+func Test_Webhook_DoesNotReturnDeliveryErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	require.NoError(t, newTestNotifier(server.URL).KeyIssued(entry, "key-1"))
+
+	// unreachable webhook
+	server.Close()
+	require.NoError(t, newTestNotifier(server.URL).KeyIssued(entry, "key-1"))
+}
+
+func newTestNotifier(url string) *webhookNotifier {
+	n := New(url).(*webhookNotifier)
+	n.now = func() time.Time {
+		return now
+	}
+	return n
+}
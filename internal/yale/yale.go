@@ -2,131 +2,473 @@ package yale
 
 import (
 	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"errors"
 	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsiam "github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/broadinstitute/yale/internal/yale/keysync/consul"
 	"github.com/broadinstitute/yale/internal/yale/keysync/github"
+	"github.com/broadinstitute/yale/internal/yale/keysync/gitlab"
+	"maps"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	monitoring "cloud.google.com/go/monitoring/apiv3/v2"
+	"github.com/broadinstitute/yale/internal/yale/audit"
 	"github.com/broadinstitute/yale/internal/yale/authmetrics"
+	"github.com/broadinstitute/yale/internal/yale/authmetrics/azureauthmetrics"
 	"github.com/broadinstitute/yale/internal/yale/cache"
 	"github.com/broadinstitute/yale/internal/yale/client"
 	apiv1b1 "github.com/broadinstitute/yale/internal/yale/crd/api/v1beta1"
 	"github.com/broadinstitute/yale/internal/yale/crd/clientset/v1beta1"
 	"github.com/broadinstitute/yale/internal/yale/cutoff"
 	"github.com/broadinstitute/yale/internal/yale/keyops"
+	"github.com/broadinstitute/yale/internal/yale/keyops/awskeyops"
 	"github.com/broadinstitute/yale/internal/yale/keyops/azurekeyops"
 	"github.com/broadinstitute/yale/internal/yale/keysync"
 	"github.com/broadinstitute/yale/internal/yale/logs"
+	"github.com/broadinstitute/yale/internal/yale/metrics"
+	"github.com/broadinstitute/yale/internal/yale/notify"
 	"github.com/broadinstitute/yale/internal/yale/resourcemap"
 	"github.com/broadinstitute/yale/internal/yale/slack"
+	"github.com/broadinstitute/yale/internal/yale/teams"
+	"github.com/broadinstitute/yale/internal/yale/webhook"
 	vaultapi "github.com/hashicorp/vault/api"
 	"github.com/manicminer/hamilton/msgraph"
+	"google.golang.org/api/cloudkms/v1"
 	"google.golang.org/api/iam/v1"
+	"google.golang.org/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
 const (
 	gcpKeyops   = "gcp"
 	azureKeyops = "azure"
+	awsKeyops   = "aws"
 )
 
+// DefaultMaxTrackedKeys default limit on the number of rotated and disabled keys a cache entry may track.
+// GCP allows at most 10 keys per service account, including disabled keys, so this leaves room for the
+// current key and one new one.
+const DefaultMaxTrackedKeys = 8
+
+// DefaultMaxRotatedKeys default number of rotated keys a cache entry may have waiting to be disabled before Yale
+// alerts about them. A key is normally disabled within a few days of rotation, so more than a couple means a
+// consumer is stuck on an old key.
+const DefaultMaxRotatedKeys = 3
+
+// DefaultMaxConcurrentIdentifiers default number of cache entries Yale processes at once. Entries are processed
+// one at a time by default.
+const DefaultMaxConcurrentIdentifiers = 1
+
+// DefaultMinRotationInterval default minimum age of a current key before Yale will rotate it, so that a
+// misconfigured or repeatedly edited resource can't make Yale rotate continuously
+const DefaultMinRotationInterval = time.Hour
+
+// ForceDisableKeyAnnotation annotation on a GcpSaKey or AzureClientSecret naming a rotated key that Yale should
+// disable even though it is still in use, eg. because the remaining authentications are known to come from a
+// decommissioned system
+const ForceDisableKeyAnnotation = "yale.terra.bio/force-disable-key"
+
 type Yale struct { // Yale config
 	options     Options
 	cache       cache.Cache
 	resourcemap resourcemap.Mapper
+	crd         v1beta1.YaleCRDInterface
 	keyops      map[string]keyops.KeyOps
 	keysync     keysync.KeySync
-	authmetrics authmetrics.AuthMetrics
-	slack       slack.SlackNotifier
+	authmetrics map[string]authmetrics.AuthMetrics
+	notifier    notify.Notifier
+	// digest entries with errors to report in the error digest at the end of the current run
+	digest []*cache.Entry
+	// digestMutex guards digest, since cache entries may be processed concurrently
+	digestMutex sync.Mutex
 }
 
-type RotateWindow struct {
-	Enabled   bool
+// TimeWindow a period of time between StartTime and EndTime. If StartTime is after EndTime, the window wraps
+// around midnight (eg. 22:00 - 06:00).
+type TimeWindow struct {
 	StartTime time.Time
 	EndTime   time.Time
 }
 
+// Contains returns true if t falls inside the window (inclusive of its boundaries)
+func (w TimeWindow) Contains(t time.Time) bool {
+	if w.StartTime.After(w.EndTime) {
+		return !t.Before(w.StartTime) || !t.After(w.EndTime)
+	}
+	return !t.Before(w.StartTime) && !t.After(w.EndTime)
+}
+
+func (w TimeWindow) String() string {
+	return fmt.Sprintf("%s - %s", w.StartTime, w.EndTime)
+}
+
+// RotateWindow restricts key rotation operations to one or more daily time windows
+type RotateWindow struct {
+	Enabled bool
+	Windows []TimeWindow
+}
+
+// Contains returns true if t falls inside any of the windows
+func (w RotateWindow) Contains(t time.Time) bool {
+	for _, window := range w.Windows {
+		if window.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w RotateWindow) String() string {
+	var windows []string
+	for _, window := range w.Windows {
+		windows = append(windows, window.String())
+	}
+	return strings.Join(windows, ", ")
+}
+
 type Options struct {
-	// CacheNamespace namespace where Yale will store its cache entries
+	// CacheBackend where Yale will store its cache entries: cache.K8sBackend (Secrets in CacheNamespace) or
+	// cache.GSMBackend (Google Secret Manager secrets in CacheGSMProject)
+	CacheBackend string
+	// CacheNamespace namespace where Yale will store its cache entries, if using the k8s cache backend
 	CacheNamespace string
+	// CacheGSMProject GCP project where Yale will store its cache entries, if using the gsm cache backend
+	CacheGSMProject string
+	// CacheKMSKeyName resource name of the KMS key used to encrypt cache entries at rest. If empty,
+	// entries are written in plaintext.
+	CacheKMSKeyName string
 	// IgnoreUsageMetrics if true, Yale will NOT check if a service account is in use before disabling it
 	IgnoreUsageMetrics bool
 	// SlackWebhookUrl if set, Yale will send Slack notifications to this webhook
 	SlackWebhookUrl string
-	// RotateWindow if enabled, restrict key rotation operations to a specific time of day
+	// SlackInfoWebhookUrl if set, Yale will send informational Slack notifications (keys issued, disabled, deleted) to this webhook instead of SlackWebhookUrl
+	SlackInfoWebhookUrl string
+	// SlackAlertsWebhookUrl if set, Yale will send error Slack notifications to this webhook instead of SlackWebhookUrl
+	SlackAlertsWebhookUrl string
+	// MessageTemplates text/template strings, keyed by event (keyIssued, keyDisabled, keyDeleted,
+	// keyApproachingRotation, error), that customize the text of Slack notifications. Events without a template
+	// use the default message.
+	MessageTemplates map[string]string
+	// ErrorDigest if true, Yale will report all the errors from a run in a single notification at the end of
+	// the run, instead of one notification per failing resource
+	ErrorDigest bool
+	// RotationWarningLead if greater than zero, Yale will send a notification when a current key is within this
+	// long of being rotated, so that teams can make sure consumers of the key are ready. Sent once per key.
+	RotationWarningLead time.Duration
+	// TeamsWebhookUrl if set, Yale will also send notifications to this Microsoft Teams incoming webhook
+	TeamsWebhookUrl string
+	// EventWebhookUrl if set, Yale will POST a JSON payload to this URL whenever a key is issued, disabled, or deleted
+	EventWebhookUrl string
+	// AuditLog if set, Yale will record every key it issues, disables, or deletes, and every destination it syncs
+	// a key to, in this audit log. It is flushed at the end of every run
+	AuditLog audit.Logger
+	// RotateWindow if enabled, restrict key rotation operations to a specific time of day. Disabling and deleting
+	// old keys is also restricted to it, unless DisableWindow is enabled
 	RotateWindow RotateWindow
+	// DisableWindow if enabled, restrict disabling and deleting old keys to a specific time of day, independently of
+	// RotateWindow, so that a key that is unexpectedly still in use can only cause an outage when someone is around
+	// to respond
+	DisableWindow RotateWindow
 	// DisableVaultReplication if true, Yale will not perform any Vault replications
 	DisableVaultReplication bool
 	// DisableGitHubReplication if true, Yale will not perform any GitHub replications
 	DisableGitHubReplication bool
+	// DisableGitLabReplication if true, Yale will not perform any GitLab replications
+	DisableGitLabReplication bool
+	// DisableGCSReplication if true, Yale will not perform any GCS replications
+	DisableGCSReplication bool
+	// DisableConsulReplication if true, Yale will not perform any Consul replications
+	DisableConsulReplication bool
+	// AllowedVaultAddresses addresses of Vault servers, other than the default one, that Vault replications may
+	// write to with their address field
+	AllowedVaultAddresses []string
+	// VerifyVaultReplications if true, Yale will read back Vault paths on every run and re-sync any that have drifted
+	VerifyVaultReplications bool
+	// ForceResync if true, Yale will sync every resource to all of its destinations, even if its sync status is up-to-date
+	ForceResync bool
+	// SyncDelayAfterIssue if greater than zero, Yale will not sync a newly-issued key to its destinations until
+	// the key is at least this old, giving it time to propagate. The key will be synced on a subsequent run.
+	SyncDelayAfterIssue time.Duration
+	// TargetClusters K8s clients for additional clusters, by name, that secrets may be synced to
+	TargetClusters map[string]kubernetes.Interface
+	// MaxTrackedKeys if greater than zero, Yale will refuse to rotate a key if the cache entry is already
+	// tracking this many rotated and disabled keys, and report an error until the backlog clears
+	MaxTrackedKeys int
+	// MinRotationInterval if greater than zero, Yale will not rotate a current key that was issued less than this
+	// long ago, even if it is due for rotation. Capped at the resource's RotateAfter
+	MinRotationInterval time.Duration
+	// MaxRotatedKeys if greater than zero, Yale will send a single notification when a cache entry has more than
+	// this many rotated keys waiting to be disabled, in addition to the error reported for each key still in use
+	MaxRotatedKeys int
+	// MaxConcurrentIdentifiers maximum number of cache entries (ie. service accounts or Azure applications, along
+	// with their resources) that Yale will process at once during a run
+	MaxConcurrentIdentifiers int
+	// MaxConcurrentReplications maximum number of Vault, GSM, or GitHub replications for a resource that Yale
+	// will perform at once
+	MaxConcurrentReplications int
+	// MaxDestinationFailures number of sync errors against a single external destination (eg. Vault) in a run
+	// after which Yale stops writing to it for the rest of the run and sends a single notification, instead of
+	// an error for every resource. 0 for no limit
+	MaxDestinationFailures int
+	// DryRun if true, Yale will log the writes key sync would perform to K8s, Vault, GSM, and GitHub instead of
+	// performing them. Note that this does not affect key rotation.
+	DryRun bool
+	// PruneRemovedReplications if true, Yale will delete the Vault paths, GSM secrets, and GitHub secrets that were
+	// removed from a resource's replications since its last sync
+	PruneRemovedReplications bool
+	// DeleteOrphanedSecrets if true, Yale will delete the K8s secrets it previously synced for a cache entry once no
+	// resources use it. K8s normally garbage-collects them through their owner references, but not if the owner
+	// references were lost or changed (eg. when a secret is migrated between resources by GitOps tooling)
+	DeleteOrphanedSecrets bool
+	// RecoverCacheFromSecrets if true, Yale will restore the current key of a cache entry that has never held a key
+	// (eg. because its cache secret was accidentally deleted) from the K8s secrets it was synced to, instead of
+	// issuing a new key and orphaning the one that is still in use
+	RecoverCacheFromSecrets bool
+	// AdoptUnownedSecrets if true, Yale will add versions to pre-existing GSM secrets that do not have the
+	// owned_by: yale label, instead of returning an error
+	AdoptUnownedSecrets bool
+	// AllowSubDayThresholds if true, Yale will allow rotate/disable/delete thresholds shorter than the usual
+	// minimums of several days, down to an hour
+	AllowSubDayThresholds bool
+	// KeyCreateMaxAttempts maximum number of times Yale will attempt to create a new GCP or Azure key when creation
+	// fails with a 429, 5xx, or network error
+	KeyCreateMaxAttempts int
+	// KeyCreateRetryBaseDelay delay before the first retry of a failed key creation, doubled for each subsequent retry
+	KeyCreateRetryBaseDelay time.Duration
+	// IncludeNamespaces if not empty, Yale will only process GcpSaKeys and AzureClientSecrets in these namespaces
+	IncludeNamespaces []string
+	// ExcludeNamespaces Yale will not process GcpSaKeys and AzureClientSecrets in these namespaces, even if they are
+	// also in IncludeNamespaces
+	ExcludeNamespaces []string
+	// ErrorRepostInterval minimum time between repeat notifications for a resource's error. Defaults to
+	// DefaultErrorRepostInterval if not set.
+	ErrorRepostInterval time.Duration
+	// KeyInUseErrorRepostInterval if set, overrides ErrorRepostInterval for errors about keys that have reached their
+	// disable cutoff but are still in use, so that operators can be re-notified about them sooner
+	KeyInUseErrorRepostInterval time.Duration
+	// CRDLabelSelector if not empty, Yale will only process GcpSaKeys and AzureClientSecrets matching this label
+	// selector, so that multiple Yale instances can divide resources between them
+	CRDLabelSelector string
 }
 
 // NewYale /* Construct a new Yale Manager */
 func NewYale(clients *client.Clients, opts ...func(*Options)) *Yale {
-	return newYaleFromClients(clients.GetK8s(), clients.GetCRDs(), clients.GetIAM(), clients.GetMetrics(), clients.GetVault(), clients.GetGoogleSecretManager(), clients.GetAzure(), clients.GetGitHub(), opts...)
+	return newYaleFromClients(clients.GetK8s(), clients.GetCRDs(), clients.GetIAM(), clients.GetMetrics(), clients.GetVault(), clients.GetGoogleSecretManager(), clients.GetAzure(), clients.GetGitHub(), clients.GetGitLab(), clients.GetAWS(), clients.GetKMS(), clients.GetGCS(), clients.GetConsul(), opts...)
 }
 
-func newYaleFromClients(k8s kubernetes.Interface, crd v1beta1.YaleCRDInterface, iam *iam.Service, metrics *monitoring.MetricClient, vault *vaultapi.Client, secretManager *secretmanager.Client, azure *msgraph.ApplicationsClient, _github github.Client, opts ...func(*Options)) *Yale {
+func newYaleFromClients(k8s kubernetes.Interface, crd v1beta1.YaleCRDInterface, iam *iam.Service, metrics *monitoring.MetricClient, vault *vaultapi.Client, secretManager *secretmanager.Client, azure *msgraph.ApplicationsClient, _github github.Client, _gitlab gitlab.Client, awsConfig *aws.Config, kms *cloudkms.Service, gcs *storage.Service, _consul consul.Client, opts ...func(*Options)) *Yale {
 	options := Options{
-		CacheNamespace:           cache.DefaultCacheNamespace,
-		IgnoreUsageMetrics:       false,
-		DisableVaultReplication:  false,
-		DisableGitHubReplication: false,
+		CacheBackend:              cache.K8sBackend,
+		CacheNamespace:            cache.DefaultCacheNamespace,
+		IgnoreUsageMetrics:        false,
+		DisableVaultReplication:   false,
+		DisableGitHubReplication:  false,
+		MaxTrackedKeys:            DefaultMaxTrackedKeys,
+		MinRotationInterval:       DefaultMinRotationInterval,
+		MaxRotatedKeys:            DefaultMaxRotatedKeys,
+		MaxConcurrentIdentifiers:  DefaultMaxConcurrentIdentifiers,
+		MaxConcurrentReplications: keysync.DefaultMaxConcurrentReplications,
+		MaxDestinationFailures:    keysync.DefaultMaxDestinationFailures,
+		KeyCreateMaxAttempts:      keyops.DefaultCreateMaxAttempts,
+		KeyCreateRetryBaseDelay:   keyops.DefaultCreateRetryBaseDelay,
 	}
 	for _, opt := range opts {
 		opt(&options)
 	}
+	keyopsOptions := func(opts *keyops.Options) {
+		opts.CreateMaxAttempts = options.KeyCreateMaxAttempts
+		opts.CreateRetryBaseDelay = options.KeyCreateRetryBaseDelay
+	}
 	_keyops := make(map[string]keyops.KeyOps)
-	_keyops[gcpKeyops] = keyops.New(iam)
-	_keyops[azureKeyops] = azurekeyops.New(azure)
-
-	_authmetrics := authmetrics.New(metrics, iam)
-	_cache := cache.New(k8s, options.CacheNamespace)
-	_keysync := keysync.New(k8s, vault, secretManager, _github, _cache, func(opts *keysync.Options) {
+	_keyops[gcpKeyops] = keyops.New(iam, keyopsOptions)
+	_keyops[azureKeyops] = azurekeyops.New(azure, keyopsOptions)
+	var awsIAM awskeyops.IAMClient
+	if awsConfig != nil {
+		awsIAM = awsiam.NewFromConfig(*awsConfig)
+	}
+	_keyops[awsKeyops] = awskeyops.New(awsIAM)
+
+	_authmetrics := make(map[string]authmetrics.AuthMetrics)
+	_authmetrics[gcpKeyops] = authmetrics.New(metrics, iam)
+	_authmetrics[azureKeyops] = azureauthmetrics.New(azure)
+	cacheStore := cache.NewK8sStore(k8s, options.CacheNamespace)
+	if options.CacheBackend == cache.GSMBackend {
+		cacheStore = cache.NewGSMStore(secretManager, options.CacheGSMProject)
+	}
+	_cache := cache.NewWithStore(cacheStore, func(opts *cache.Options) {
+		opts.KMSKeyName = options.CacheKMSKeyName
+		if kms != nil {
+			opts.KMS = cache.NewKMS(kms)
+		}
+	})
+	_keysync := keysync.New(k8s, vault, secretManager, _github, _gitlab, _cache, func(opts *keysync.Options) {
 		opts.DisableVaultReplication = options.DisableVaultReplication
 		opts.DisableGitHubReplication = options.DisableGitHubReplication
+		opts.DisableGitLabReplication = options.DisableGitLabReplication
+		opts.DisableGCSReplication = options.DisableGCSReplication
+		opts.DisableConsulReplication = options.DisableConsulReplication
+		opts.VerifyVaultReplications = options.VerifyVaultReplications
+		opts.ForceResync = options.ForceResync
+		opts.TargetClusters = options.TargetClusters
+		opts.AWSConfig = awsConfig
+		opts.GCS = gcs
+		opts.Consul = _consul
+		opts.MaxConcurrentReplications = options.MaxConcurrentReplications
+		opts.MaxDestinationFailures = options.MaxDestinationFailures
+		opts.DryRun = options.DryRun
+		opts.PruneRemovedReplications = options.PruneRemovedReplications
+		opts.AdoptUnownedSecrets = options.AdoptUnownedSecrets
+		opts.AllowedVaultAddresses = options.AllowedVaultAddresses
+		if options.AuditLog != nil {
+			opts.AuditLog = options.AuditLog
+		}
+	})
+	_resourcemap := resourcemap.New(crd, _cache, func(opts *resourcemap.Options) {
+		opts.IncludeNamespaces = options.IncludeNamespaces
+		opts.ExcludeNamespaces = options.ExcludeNamespaces
+		opts.LabelSelector = options.CRDLabelSelector
+		opts.RecoverCacheFromSecrets = options.RecoverCacheFromSecrets
+		opts.K8s = k8s
 	})
-	_resourcemap := resourcemap.New(crd, _cache)
-	_slack := slack.New(options.SlackWebhookUrl)
+	notifiers := []notify.Notifier{
+		slack.NewRouter(
+			firstNonEmpty(options.SlackInfoWebhookUrl, options.SlackWebhookUrl),
+			firstNonEmpty(options.SlackAlertsWebhookUrl, options.SlackWebhookUrl),
+			func(opts *slack.Options) {
+				opts.MessageTemplates = options.MessageTemplates
+			},
+		),
+	}
+	if options.TeamsWebhookUrl != "" {
+		notifiers = append(notifiers, teams.New(options.TeamsWebhookUrl))
+	}
+	if options.EventWebhookUrl != "" {
+		notifiers = append(notifiers, webhook.New(options.EventWebhookUrl))
+	}
 
-	return newYaleFromComponents(options, _cache, _resourcemap, _authmetrics, _keyops, _keysync, _slack)
+	return newYaleFromComponents(options, _cache, _resourcemap, crd, _authmetrics, _keyops, _keysync, notify.NewComposite(notifiers...))
 }
 
-func newYaleFromComponents(options Options, _cache cache.Cache, resourcemapper resourcemap.Mapper, _authmetrics authmetrics.AuthMetrics, _keyops map[string]keyops.KeyOps, _keysync keysync.KeySync, _slack slack.SlackNotifier) *Yale {
+// firstNonEmpty returns the first of its arguments that is not the empty string
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func newYaleFromComponents(options Options, _cache cache.Cache, resourcemapper resourcemap.Mapper, crd v1beta1.YaleCRDInterface, _authmetrics map[string]authmetrics.AuthMetrics, _keyops map[string]keyops.KeyOps, _keysync keysync.KeySync, notifier notify.Notifier) *Yale {
+	if options.AuditLog == nil {
+		options.AuditLog = audit.NewNop()
+	}
 	return &Yale{
 		options:     options,
 		cache:       _cache,
 		resourcemap: resourcemapper,
-		authmetrics: _authmetrics,
+		crd:         crd,
+		authmetrics: memoizeAuthMetrics(_authmetrics),
 		keyops:      _keyops,
 		keysync:     _keysync,
-		slack:       _slack,
+		notifier:    notifier,
+	}
+}
+
+// memoizeAuthMetrics wraps every AuthMetrics backend so that repeated lookups for the same key within a run
+// only query the underlying API once
+func memoizeAuthMetrics(backends map[string]authmetrics.AuthMetrics) map[string]authmetrics.AuthMetrics {
+	memoized := make(map[string]authmetrics.AuthMetrics, len(backends))
+	for keyOpsType, backend := range backends {
+		memoized[keyOpsType] = authmetrics.NewMemoized(backend)
+	}
+	return memoized
+}
+
+// resetAuthMetrics clears memoized auth metrics lookups, so that every run (eg. in daemon mode) sees fresh results
+func (m *Yale) resetAuthMetrics() {
+	for _, backend := range m.authmetrics {
+		if memoized, ok := backend.(*authmetrics.Memoized); ok {
+			memoized.Reset()
+		}
 	}
 }
 
 // Run is the main entrypoint for Yale, and will perform a full sync of all yale-managed resources in the cluster
 func (m *Yale) Run() error {
+	start := time.Now()
+	defer func() {
+		metrics.RunDuration.Observe(time.Since(start).Seconds())
+	}()
+
 	resources, err := m.resourcemap.Build()
 	if err != nil {
 		return fmt.Errorf("error inspecting cluster for cache entries and GcpSaKey resources: %v", err)
 	}
 
+	m.digest = nil
+	m.resetAuthMetrics()
+	m.keysync.StartRun()
 	errors := make(map[string]error)
+	var errorsMutex sync.Mutex
+	var syncables []keysync.Syncable
+	var identifiers []string
 	for identifier, bundle := range resources {
-		logs.Info.Printf("processing %s %s", bundle.Entry.Type, identifier)
+		syncables = append(syncables, keysync.GcpSaKeysToSyncable(bundle.GSKs)...)
+		syncables = append(syncables, keysync.AzureClientSecretsToSyncable(bundle.AzClientSecrets)...)
+		identifiers = append(identifiers, identifier)
+	}
+	sort.Strings(identifiers)
+
+	// cache entries are independent of each other, so they can be processed concurrently; errors are
+	// collected in the errors map rather than returned, so that every entry is processed
+	_ = forEachConcurrently(m.options.MaxConcurrentIdentifiers, identifiers, func(identifier string) error {
+		bundle := resources[identifier]
+		logs.Debug.WithFields(logFields(bundle.Entry, "process")).Printf("processing %s %s", bundle.Entry.Type, identifier)
+		var err error
 		if bundle.Entry.Identifier.Type() == cache.GcpSaKey {
-			if err = processYaleResourceAndReportErrors(m, bundle.Entry, bundle.GSKs); err != nil {
-				logs.Error.Printf("error processing %s %s: %v", bundle.Entry.Type, identifier, err)
-				errors[identifier] = err
-			}
+			err = processYaleResourceAndReportErrors(m, bundle.Entry, bundle.GSKs)
 		} else if bundle.Entry.Identifier.Type() == cache.AzureClientSecret {
-
-			if err = processYaleResourceAndReportErrors(m, bundle.Entry, bundle.AzClientSecrets); err != nil {
-				logs.Error.Printf("error processing %s %s: %v", bundle.Entry.Type, identifier, err)
-				errors[identifier] = err
-			}
+			err = processYaleResourceAndReportErrors(m, bundle.Entry, bundle.AzClientSecrets)
+		} else {
+			// there is no resource type for the entry's keys (eg. AwsIamAccessKey), so there are no thresholds
+			// to process it with; report it instead of skipping it, since its keys won't be disabled or deleted
+			err = fmt.Errorf("unsupported entry type %s: no resources can manage its keys, so they will not be rotated, disabled, or deleted", bundle.Entry.Type)
+		}
+		if err != nil {
+			logs.Error.WithFields(logFields(bundle.Entry, "error")).Printf("error processing %s %s: %v", bundle.Entry.Type, identifier, err)
+			errorsMutex.Lock()
+			errors[identifier] = err
+			errorsMutex.Unlock()
 		}
+		return nil
+	})
+
+	recordOldestSuccessfulRun(resources)
+
+	if err = m.keysync.PruneTargetClusterSecrets(syncables); err != nil {
+		logs.Error.Printf("error pruning secrets in target clusters: %v", err)
+		errors["target clusters"] = err
+	}
+
+	if err = m.sendErrorDigest(); err != nil {
+		logs.Error.Printf("error sending error digest: %v", err)
+		errors["error digest"] = err
+	}
+
+	if err = m.reportUnhealthyDestinations(); err != nil {
+		logs.Error.Printf("error reporting unhealthy destinations: %v", err)
+		errors["unhealthy destinations"] = err
+	}
+
+	if err = m.options.AuditLog.Flush(); err != nil {
+		logs.Error.Printf("error flushing audit log: %v", err)
+		errors["audit log"] = err
 	}
 
 	if len(errors) > 0 {
@@ -140,9 +482,30 @@ func (m *Yale) Run() error {
 	return nil
 }
 
+// recordOldestSuccessfulRun sets the oldest successful run gauge to the least recent successful run among the
+// cache entries that have had one
+func recordOldestSuccessfulRun(resources map[string]*resourcemap.Bundle) {
+	var oldest time.Time
+	for _, bundle := range resources {
+		lastRun := bundle.Entry.LastSuccessfulRun
+		if lastRun.IsZero() {
+			continue
+		}
+		if oldest.IsZero() || lastRun.Before(oldest) {
+			oldest = lastRun
+		}
+	}
+	if !oldest.IsZero() {
+		metrics.OldestSuccessfulRun.Set(float64(oldest.Unix()))
+	}
+}
+
 // processYaleResourceAndReportErrors is a helper function that will process a Yale-managed resource, and report any errors that occur
 func processYaleResourceAndReportErrors[Y apiv1b1.YaleCRD](yale *Yale, entry *cache.Entry, yaleCRDs []Y) error {
-	if err := processYaleResource(yale, entry, yaleCRDs); err != nil {
+	syncStatusBefore := maps.Clone(entry.SyncStatus)
+	err := processYaleResource(yale, entry, yaleCRDs)
+	updateYaleResourceStatuses(yale, entry, yaleCRDs, syncStatusBefore, err)
+	if err != nil {
 		if reportErr := yale.reportError(entry, err); reportErr != nil {
 			logs.Error.Printf("error reporting error for %s: %v", entry.Identify(), reportErr)
 		}
@@ -152,67 +515,211 @@ func processYaleResourceAndReportErrors[Y apiv1b1.YaleCRD](yale *Yale, entry *ca
 }
 
 // processYaleResource is a helper function that will process a Yale-managed resource
-func processYaleResource[Y apiv1b1.YaleCRD](yale *Yale, entry *cache.Entry, yaleCRDs []Y) error {
-	var err error
-	var keyOpsType string
-	if entry.Type == cache.GcpSaKey {
-		keyOpsType = gcpKeyops
-	} else if entry.Type == cache.AzureClientSecret {
-		keyOpsType = azureKeyops
-	} else {
-		return fmt.Errorf("unknown entry type %T", entry.Type)
+func processYaleResource[Y apiv1b1.YaleCRD](yale *Yale, entry *cache.Entry, yaleCRDs []Y) (err error) {
+	keyOpsType, err := keyOpsTypeFor(entry)
+	if err != nil {
+		return err
 	}
 
-	cutoffs := computeCutoffs(entry, yaleCRDs)
+	if err = verifyUniqueID(yale.keyops[keyOpsType], yale.cache, entry); err != nil {
+		return err
+	}
 
-	if err = syncYaleResourceIfReady(yale.keysync, entry, yaleCRDs); err != nil {
+	cutoffs := computeCutoffs(entry, yaleCRDs, yale.options.AllowSubDayThresholds)
+
+	// check the current key's age once processing is done, so that a key rotated during this run isn't
+	// reported, but a key whose rotation failed or was blocked is. If processing otherwise succeeds, the
+	// check is made by completeRun instead, so that an entry whose key is too old isn't recorded as a
+	// successful run
+	completed := false
+	defer func() {
+		if !completed {
+			err = errors.Join(err, checkMaxKeyAge(entry, cutoffs))
+		}
+	}()
+	completeRun := func() error {
+		completed = true
+		if err := checkMaxKeyAge(entry, cutoffs); err != nil {
+			return err
+		}
+		return recordSuccessfulRun(yale.cache, entry)
+	}
+
+	if err = deleteOrphanedSecretsIfNeeded(yale.keysync, entry, yale.options.DeleteOrphanedSecrets, yaleCRDs); err != nil {
+		return err
+	}
+
+	if err = syncYaleResourceIfReady(yale.keysync, entry, yale.options.SyncDelayAfterIssue, yaleCRDs); err != nil {
 		return err
 	}
 
-	if err = issueNewYaleResourceIfNoCurrent(yale.keyops[keyOpsType], yale.cache, yale.keysync, yale.slack, entry, yaleCRDs); err != nil {
+	if err = issueNewYaleResourceIfNoCurrent(yale.keyops[keyOpsType], yale.cache, yale.keysync, yale.notifier, yale.options.AuditLog, entry, cutoffs, yale.options.SyncDelayAfterIssue, yaleCRDs); err != nil {
 		return err
 	}
 
+	if err = warnIfApproachingRotation(yale.cache, yale.notifier, entry, cutoffs, yale.options.RotationWarningLead); err != nil {
+		return err
+	}
+
+	// the rotate and disable windows are evaluated independently, so that eg. keys can be rotated during business
+	// hours and old keys disabled overnight. Without a disable window, disabling follows the rotate window
+	now := currentTime()
 	window := yale.options.RotateWindow
-	if window.Enabled {
-		if currentTime().Before(window.StartTime) || currentTime().After(window.EndTime) {
-			logs.Info.Printf("won't attempt key rotations for %s %s because we are outside the rotation window (%s - %s)", entry.Type, entry.Identifier, window.StartTime, window.EndTime)
-			return nil
+	rotateWindowOpen := !window.Enabled || window.Contains(now)
+	disableWindow := yale.options.DisableWindow
+	disableWindowOpen := rotateWindowOpen
+	if disableWindow.Enabled {
+		disableWindowOpen = disableWindow.Contains(now)
+	}
+
+	if disableWindowOpen {
+		if err = yale.deleteOldKeys(yale.keyops[keyOpsType], entry, cutoffs); err != nil {
+			return err
+		}
+		if err = yale.disableOldKeys(yale.keyops[keyOpsType], entry, cutoffs, forceDisableKeyIDs(yaleCRDs)); err != nil {
+			return err
 		}
+	} else if disableWindow.Enabled {
+		logs.Debug.Printf("won't attempt to disable or delete old keys for %s %s because we are outside the disable window (%s)", entry.Type, entry.Identifier, disableWindow)
 	}
 
-	if err = yale.deleteOldKeys(yale.keyops[keyOpsType], entry, cutoffs); err != nil {
-		return err
+	if !rotateWindowOpen {
+		logs.Debug.Printf("won't attempt key rotations for %s %s because we are outside the rotation window (%s)", entry.Type, entry.Identifier, window)
+		return completeRun()
 	}
-	if err = yale.disableOldKeys(yale.keyops[keyOpsType], entry, cutoffs); err != nil {
+	if err = rotateYaleResourceIfNeeded(yale.keyops[keyOpsType], yale.cache, yale.keysync, yale.notifier, yale.options.AuditLog, entry, cutoffs, yale.options.MaxTrackedKeys, yale.options.MinRotationInterval, yale.options.SyncDelayAfterIssue, yaleCRDs); err != nil {
 		return err
 	}
-	if err = rotateYaleResourceIfNeeded(yale.keyops[keyOpsType], yale.cache, yale.keysync, yale.slack, entry, cutoffs, yaleCRDs); err != nil {
+	retired, err := retireCacheEntryIfNeeded(yale.cache, entry, yaleCRDs)
+	if err != nil {
 		return err
 	}
-	if err = retireCacheEntryIfNeeded(yale.cache, entry, yaleCRDs); err != nil {
-		return err
+	if retired {
+		return nil
 	}
 
+	return completeRun()
+}
+
+// recordSuccessfulRun stamps the cache entry with the time at which Yale last processed it without error
+func recordSuccessfulRun(yaleCache cache.Cache, entry *cache.Entry) error {
+	entry.LastSuccessfulRun = currentTime()
+	if err := yaleCache.Save(entry); err != nil {
+		return fmt.Errorf("error saving cache entry for %s after successful run: %v", entry.Identify(), err)
+	}
 	return nil
 }
 
+// verifyUniqueID looks up the unique ID of a GCP service account entry's service account. If the entry has no
+// unique ID yet, it is recorded, so that keys are managed by unique ID from then on; if it has a different one,
+// the service account was deleted and recreated with the same email, and an error is returned rather than
+// managing the new service account's keys as if they were the old one's
+func verifyUniqueID(_keyops keyops.KeyOps, yaleCache cache.Cache, entry *cache.Entry) error {
+	identifier, ok := entry.Identifier.(cache.GcpSaKeyEntryIdentifier)
+	if !ok {
+		return nil
+	}
+	lookup, ok := _keyops.(keyops.UniqueIDLookup)
+	if !ok {
+		return nil
+	}
+	uniqueID, err := lookup.UniqueID(identifier.Project, identifier.Email)
+	if err != nil {
+		return fmt.Errorf("error looking up unique id for service account %s: %v", identifier.Email, err)
+	}
+	if identifier.UniqueID == uniqueID {
+		return nil
+	}
+	if identifier.UniqueID != "" {
+		return fmt.Errorf("unique id mismatch: cache entry for service account %s has unique id %s, but the service account has unique id %s; it was deleted and recreated, so Yale will not manage its keys until the cache entry is removed",
+			identifier.Email, identifier.UniqueID, uniqueID)
+	}
+	logs.Info.Printf("recording unique id %s for service account %s", uniqueID, identifier.Email)
+	identifier.UniqueID = uniqueID
+	entry.Identifier = identifier
+	if err = yaleCache.Save(entry); err != nil {
+		return fmt.Errorf("error saving cache entry for %s after recording unique id: %v", identifier.Email, err)
+	}
+	return nil
+}
+
+// entryUniqueID returns the unique ID recorded on a GCP service account entry, or "" if there is none
+func entryUniqueID(entry *cache.Entry) string {
+	if identifier, ok := entry.Identifier.(cache.GcpSaKeyEntryIdentifier); ok {
+		return identifier.UniqueID
+	}
+	return ""
+}
+
+// entryKey returns the keyops.Key for one of the entry's keys
+func entryKey(entry *cache.Entry, keyId string) keyops.Key {
+	return keyops.Key{
+		Scope:      entry.Scope(),
+		Identifier: entry.Identify(),
+		ID:         keyId,
+		UniqueID:   entryUniqueID(entry),
+	}
+}
+
+// keyOpsTypeFor returns the key in the keyops map that should be used to manage the entry's keys
+func keyOpsTypeFor(entry *cache.Entry) (string, error) {
+	switch entry.Type {
+	case cache.GcpSaKey:
+		return gcpKeyops, nil
+	case cache.AzureClientSecret:
+		return azureKeyops, nil
+	case cache.AwsIamAccessKey:
+		return awsKeyops, nil
+	default:
+		return "", fmt.Errorf("unknown entry type %T", entry.Type)
+	}
+}
+
+// forceDisableKeyIDs returns the key IDs named by ForceDisableKeyAnnotation on the resources, mapped to the
+// namespace/name of the resource that has the annotation
+func forceDisableKeyIDs[Y apiv1b1.YaleCRD](yaleCRDs []Y) map[string]string {
+	keyIDs := make(map[string]string)
+	add := func(meta metav1.ObjectMeta) {
+		if keyID := strings.TrimSpace(meta.Annotations[ForceDisableKeyAnnotation]); keyID != "" {
+			keyIDs[keyID] = meta.Namespace + "/" + meta.Name
+		}
+	}
+	switch crds := any(&yaleCRDs).(type) {
+	case *[]apiv1b1.GcpSaKey:
+		for _, gsk := range *crds {
+			add(gsk.ObjectMeta)
+		}
+	case *[]apiv1b1.AzureClientSecret:
+		for _, acs := range *crds {
+			add(acs.ObjectMeta)
+		}
+	}
+	return keyIDs
+}
+
 // computeCutoffs computes the cutoffs for key rotation/disabling/deletion based on the GcpSaKey resources
 // for this service account
-func computeCutoffs[Y apiv1b1.YaleCRD](entry *cache.Entry, yaleCRDs []Y) cutoff.Cutoffs {
+func computeCutoffs[Y apiv1b1.YaleCRD](entry *cache.Entry, yaleCRDs []Y, allowSubDayThresholds bool) cutoff.Cutoffs {
 	if len(yaleCRDs) == 0 {
 		logs.Info.Printf("cache entry for %s has no corresponding %T resources in the cluster; will use Yale's default cutoffs to retire old keys", entry.Identify(), yaleCRDs)
 		return cutoff.NewWithDefaults()
 	}
-	return cutoff.New(yaleCRDs)
+	return cutoff.New(yaleCRDs, func(options *cutoff.Options) {
+		options.AllowSubDayThresholds = allowSubDayThresholds
+	})
 }
 
-// syncYaleResourceIfReady will sync the active key for a cache entry if it exists to the keysync destination
-func syncYaleResourceIfReady[Y apiv1b1.YaleCRD](_keysync keysync.KeySync, entry *cache.Entry, yaleCRDs []Y) error {
+// syncYaleResourceIfReady will sync the active key for a cache entry if it exists to the keysync destination,
+// once the key is at least syncDelay old
+func syncYaleResourceIfReady[Y apiv1b1.YaleCRD](_keysync keysync.KeySync, entry *cache.Entry, syncDelay time.Duration, yaleCRDs []Y) error {
 	if len(entry.CurrentKey.ID) == 0 {
 		// nothing to sync yet
 		return nil
 	}
+	if syncDelay > 0 && currentTime().Sub(entry.CurrentKey.CreatedAt) < syncDelay {
+		logs.Debug.Printf("%s %s: current secret %s was issued at %s; will not sync until it is at least %s old", entry.Type, entry.Identify(), entry.CurrentKey.ID, entry.CurrentKey.CreatedAt, syncDelay)
+		return nil
+	}
 	switch crds := any(&yaleCRDs).(type) {
 	case *[]apiv1b1.GcpSaKey:
 		return _keysync.SyncIfNeeded(entry, keysync.GcpSaKeysToSyncable(*crds))
@@ -223,14 +730,45 @@ func syncYaleResourceIfReady[Y apiv1b1.YaleCRD](_keysync keysync.KeySync, entry
 	}
 }
 
+// warnIfApproachingRotation sends a notification if the cache entry's current key will be rotated within the lead
+// time. The warning is only sent once per key.
+func warnIfApproachingRotation(yaleCache cache.Cache, notifier notify.Notifier, entry *cache.Entry, cutoffs cutoff.Cutoffs, lead time.Duration) error {
+	if lead <= 0 || entry.CurrentKey.ID == "" || cutoffs.Paused() || cutoffs.ShouldRotate(entry.CurrentKey.CreatedAt) {
+		return nil
+	}
+	rotateAt := entry.CurrentKey.CreatedAt.Add(cutoffs.RotateAfter())
+	if currentTime().Add(lead).Before(rotateAt) {
+		return nil
+	}
+	if entry.RotationWarningAt.After(entry.CurrentKey.CreatedAt) {
+		// already warned about this key
+		return nil
+	}
+
+	logs.Info.Printf("%s %s: current secret %s will be rotated at %s; sending warning", entry.Type, entry.Identify(), entry.CurrentKey.ID, rotateAt)
+	if err := notifier.KeyApproachingRotation(entry, entry.CurrentKey.ID, rotateAt); err != nil {
+		return fmt.Errorf("error sending rotation warning for %s: %v", entry.Identify(), err)
+	}
+
+	entry.RotationWarningAt = currentTime()
+	if err := yaleCache.Save(entry); err != nil {
+		return fmt.Errorf("error saving cache entry for %s after sending rotation warning: %v", entry.Identify(), err)
+	}
+	return nil
+}
+
 // rotateYaleResourceIfNeeded if a cache entry needs rotation, rotate it and kick off a keysync
 func rotateYaleResourceIfNeeded[Y apiv1b1.YaleCRD](
 	keyops keyops.KeyOps,
 	yaleCache cache.Cache,
 	keysync keysync.KeySync,
-	slack slack.SlackNotifier,
+	notifier notify.Notifier,
+	auditLog audit.Logger,
 	entry *cache.Entry,
 	cutoffs cutoff.Cutoffs,
+	maxTrackedKeys int,
+	minRotationInterval time.Duration,
+	syncDelay time.Duration,
 	yaleCRDs []Y,
 ) error {
 	identifier := entry.Identify()
@@ -241,35 +779,79 @@ func rotateYaleResourceIfNeeded[Y apiv1b1.YaleCRD](
 			logs.Info.Printf("%s %s: no %T resources in cluster; will not issue new key", entry.Type, identifier, yaleCRDs)
 			return nil
 		}
+		if cutoffs.Decommissioned() {
+			logs.Info.Printf("%s %s: decommissioning; will not issue new key", entry.Type, identifier)
+			return nil
+		}
 		logs.Info.Printf("%s %s: no current secret; will issue new key", entry.Type, identifier)
 	} else {
 		// there IS a current key already, so check if it needs rotation
-		logs.Info.Printf("%s %s: checking if current secret %s needs rotation (created at %s; rotation age is %d days)", entry.Type, identifier, entry.CurrentKey.ID, entry.CurrentKey.CreatedAt, cutoffs.RotateAfterDays())
+		if cutoffs.Paused() {
+			logs.Info.Printf("%s %s: key rotation is paused; will not rotate current secret %s", entry.Type, identifier, entry.CurrentKey.ID)
+			return nil
+		}
+		logs.Debug.Printf("%s %s: checking if current secret %s needs rotation (created at %s; rotation age is %s)", entry.Type, identifier, entry.CurrentKey.ID, entry.CurrentKey.CreatedAt, cutoffs.RotateAfter())
 		if !cutoffs.ShouldRotate(entry.CurrentKey.CreatedAt) {
-			logs.Info.Printf("%s %s: current secret %s does not need rotation; will not issue new key", entry.Type, identifier, entry.CurrentKey.ID)
+			logs.Debug.Printf("%s %s: current secret %s does not need rotation; will not issue new key", entry.Type, identifier, entry.CurrentKey.ID)
 			return nil
 		}
-		// key is expired, but no CRDs in the cluster, so mark it rotated *without* issuing a new key
-		if len(yaleCRDs) == 0 {
+		if interval := effectiveMinRotationInterval(minRotationInterval, cutoffs); currentTime().Sub(entry.CurrentKey.CreatedAt) < interval {
+			logs.Info.Printf("%s %s: current secret %s was issued at %s, less than the minimum rotation interval (%s) ago; will not rotate it yet", entry.Type, identifier, entry.CurrentKey.ID, entry.CurrentKey.CreatedAt, interval)
+			return nil
+		}
+		// key is expired, but no CRDs in the cluster or the identity is being decommissioned, so mark it
+		// rotated *without* issuing a new key
+		if len(yaleCRDs) == 0 || cutoffs.Decommissioned() {
 			// mark the current key for rotation
-			logs.Info.Printf("%s %s: no %T resources in cluster; moving expired current key to rotated", entry.Type, identifier, yaleCRDs)
-			entry.RotatedKeys = map[string]time.Time{entry.CurrentKey.ID: currentTime()}
+			if len(yaleCRDs) == 0 {
+				logs.Info.Printf("%s %s: no %T resources in cluster; moving expired current key to rotated", entry.Type, identifier, yaleCRDs)
+			} else {
+				logs.Info.Printf("%s %s: decommissioning; moving expired current key to rotated without issuing a new key", entry.Type, identifier)
+			}
+			entry.RotatedKeys[entry.CurrentKey.ID] = currentTime()
+			entry.RecordKeyEvent(entry.CurrentKey.ID, cache.KeyRotated, currentTime())
 			entry.CurrentKey = cache.CurrentKey{}
 			if err := yaleCache.Save(entry); err != nil {
 				return fmt.Errorf("error saving cache entry for %s: %v", identifier, err)
 			}
 			return nil
 		}
+		if err := checkTrackedKeyLimit(entry, maxTrackedKeys); err != nil {
+			return err
+		}
 		logs.Info.Printf("%s %s: current secret %s needs rotation; will issue new key", entry.Type, identifier, entry.CurrentKey.ID)
 	}
 
 	// issue new key
 	logs.Info.Printf("%s %s: issuing new key", entry.Type, identifier)
-	if err := issueNewYaleResource(keyops, yaleCache, slack, entry); err != nil {
+	if err := issueNewYaleResource(keyops, yaleCache, notifier, auditLog, entry, keyCreateOptions(yaleCRDs)...); err != nil {
 		return fmt.Errorf("error issuing new secret for %s: %v", identifier, err)
 	}
 
-	return syncYaleResourceIfReady(keysync, entry, yaleCRDs)
+	return syncYaleResourceIfReady(keysync, entry, syncDelay, yaleCRDs)
+}
+
+// effectiveMinRotationInterval returns the minimum age a current key must reach before it is rotated: the
+// configured interval, capped at the rotation age so that it never delays a rotation the resource asks for
+func effectiveMinRotationInterval(minRotationInterval time.Duration, cutoffs cutoff.Cutoffs) time.Duration {
+	if minRotationInterval > cutoffs.RotateAfter() {
+		return cutoffs.RotateAfter()
+	}
+	return minRotationInterval
+}
+
+// checkTrackedKeyLimit returns an error if rotating the entry's current key would push the number of
+// rotated and disabled keys it tracks past maxTrackedKeys. This keeps Yale from exhausting the
+// key quota for an identity when old keys are stuck, eg. because they are still in use.
+func checkTrackedKeyLimit(entry *cache.Entry, maxTrackedKeys int) error {
+	if maxTrackedKeys <= 0 || entry.CurrentKey.ID == "" {
+		return nil
+	}
+	tracked := len(entry.RotatedKeys) + len(entry.DisabledKeys)
+	if tracked >= maxTrackedKeys {
+		return fmt.Errorf("refusing to rotate current key %s for %s: already tracking %d rotated and disabled keys (limit is %d); old keys must be disabled and deleted before rotation can continue", entry.CurrentKey.ID, entry.Identify(), tracked, maxTrackedKeys)
+	}
+	return nil
 }
 
 // issueNewYaleResourceIfNoCurrent if cache entry has no current value, issue a new secret and kick off a keysync
@@ -277,8 +859,11 @@ func issueNewYaleResourceIfNoCurrent[Y apiv1b1.YaleCRD](
 	keyops keyops.KeyOps,
 	yaleCache cache.Cache,
 	keysync keysync.KeySync,
-	slack slack.SlackNotifier,
+	notifier notify.Notifier,
+	auditLog audit.Logger,
 	entry *cache.Entry,
+	cutoffs cutoff.Cutoffs,
+	syncDelay time.Duration,
 	yaleCRDs []Y,
 ) error {
 	identifier := entry.Identify()
@@ -291,117 +876,248 @@ func issueNewYaleResourceIfNoCurrent[Y apiv1b1.YaleCRD](
 		logs.Info.Printf("%s %s: no current secret, but no %T resources in cluster; will not issue new key", entry.Type, identifier, yaleCRDs)
 		return nil
 	}
+	if cutoffs.Decommissioned() {
+		logs.Info.Printf("%s %s: no current secret, but decommissioning; will not issue new key", entry.Type, identifier)
+		return nil
+	}
 
 	logs.Info.Printf("%s %s: no current secret; will issue new key", entry.Type, identifier)
-	if err := issueNewYaleResource(keyops, yaleCache, slack, entry); err != nil {
+	if err := issueNewYaleResource(keyops, yaleCache, notifier, auditLog, entry, keyCreateOptions(yaleCRDs)...); err != nil {
 		return fmt.Errorf("%s %s: error issuing new secret: %v", entry.Type, identifier, err)
 	}
-	return syncYaleResourceIfReady(keysync, entry, yaleCRDs)
+	return syncYaleResourceIfReady(keysync, entry, syncDelay, yaleCRDs)
 }
 
-// issueNewYaleResource issues a new secret, adds it to the cache entry,
-// saves the updated cache entry to k8s, and sends a Slack notification
+// keyCreateOptions returns the options for creating new keys requested by the Yale CRDs. The resource map
+// verifies that all GcpSaKeys for a service account request the same key format, so the first one is used.
+func keyCreateOptions[Y apiv1b1.YaleCRD](yaleCRDs []Y) []keyops.CreateOption {
+	if len(yaleCRDs) == 0 {
+		return nil
+	}
+	gsk, ok := any(yaleCRDs[0]).(apiv1b1.GcpSaKey)
+	if !ok || (gsk.Spec.KeyAlgorithm == "" && gsk.Spec.KeyType == "") {
+		return nil
+	}
+	return []keyops.CreateOption{func(opts *keyops.CreateOptions) {
+		if gsk.Spec.KeyAlgorithm != "" {
+			opts.KeyAlgorithm = gsk.Spec.KeyAlgorithm
+		}
+		if gsk.Spec.KeyType != "" {
+			opts.KeyType = gsk.Spec.KeyType
+		}
+	}}
+}
+
+// uniqueIDCreateOptions returns the options for creating new keys for the entry's service account by its unique
+// ID, if known
+func uniqueIDCreateOptions(entry *cache.Entry) []keyops.CreateOption {
+	uniqueID := entryUniqueID(entry)
+	if uniqueID == "" {
+		return nil
+	}
+	return []keyops.CreateOption{func(opts *keyops.CreateOptions) {
+		opts.UniqueID = uniqueID
+	}}
+}
+
+// issueNewYaleResource issues a new secret, records it in the audit log, adds it to the cache entry,
+// saves the updated cache entry to k8s, and sends a notification
 func issueNewYaleResource(
 	keyops keyops.KeyOps,
 	yaleCache cache.Cache,
-	slack slack.SlackNotifier,
+	notifier notify.Notifier,
+	auditLog audit.Logger,
 	entry *cache.Entry,
+	createOptions ...keyops.CreateOption,
 ) error {
 	identifier := entry.Identify()
 	scope := entry.Scope()
 
 	// issue new key
 	logs.Info.Printf("%s %s: issuing new secret...", entry.Type, identifier)
-	newKey, secret, err := keyops.Create(scope, identifier)
+	createOptions = append(createOptions, uniqueIDCreateOptions(entry)...)
+	newKey, secret, err := keyops.Create(scope, identifier, createOptions...)
 	if err != nil {
 		return fmt.Errorf("error issuing new secret for %s: %v", identifier, err)
 	}
-	logs.Info.Printf("%s %s: issued new secret %s", entry.Type, identifier, newKey.ID)
+	// the key exists from here on, so it is audited even if saving the cache entry fails
+	auditLog.Log(audit.Create, entry, newKey.ID, "", "")
+	logs.Info.WithFields(logFields(entry, "key_issued")).WithFields(logs.Fields{"keyID": newKey.ID}).Printf("%s %s: issued new secret %s", entry.Type, identifier, newKey.ID)
 
 	// update the cache entry with our new secret
 	if entry.CurrentKey.ID != "" {
 		// mark the current key for rotation if there is one
 		entry.RotatedKeys[entry.CurrentKey.ID] = currentTime()
+		entry.RecordKeyEvent(entry.CurrentKey.ID, cache.KeyRotated, currentTime())
 	}
 	entry.CurrentKey = cache.CurrentKey{
 		ID:        newKey.ID,
 		JSON:      string(secret),
 		CreatedAt: currentTime(),
 	}
+	entry.RecordKeyEvent(newKey.ID, cache.KeyIssued, currentTime())
 	if err = yaleCache.Save(entry); err != nil {
 		return fmt.Errorf("error saving cache entry for %s after key rotation: %v", identifier, err)
 	}
+	metrics.KeysIssued.WithLabelValues(entry.Type.String()).Inc()
 
-	// send Slack notification that we issued a new key
-	if err = slack.KeyIssued(entry, entry.CurrentKey.ID); err != nil {
+	// send notification that we issued a new key
+	if err = notifier.KeyIssued(entry, entry.CurrentKey.ID); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (m *Yale) disableOldKeys(keyops keyops.KeyOps, entry *cache.Entry, cutoffs cutoff.Cutoffs) error {
-	for keyId, rotatedAt := range entry.RotatedKeys {
-		if err := m.disableOneKey(keyops, keyId, rotatedAt, entry, cutoffs); err != nil {
-			return err
+// disableOldKeys disables the entry's rotated keys that have reached the disable cutoff, then alerts if too
+// many rotated keys are still left over
+func (m *Yale) disableOldKeys(_keyops keyops.KeyOps, entry *cache.Entry, cutoffs cutoff.Cutoffs, forceDisable map[string]string) error {
+	err := m.disableRotatedKeys(_keyops, entry, cutoffs, forceDisable)
+	if alertErr := m.alertIfRotatedKeysAccumulating(entry); alertErr != nil {
+		err = errors.Join(err, alertErr)
+	}
+	return err
+}
+
+// disableRotatedKeys disables the entry's rotated keys that have reached the disable cutoff, a few at a time. A key
+// that fails to disable does not stop the others; the cache entry is saved once, after every key has been
+// processed, so that the progress made is preserved even if some keys failed.
+func (m *Yale) disableRotatedKeys(_keyops keyops.KeyOps, entry *cache.Entry, cutoffs cutoff.Cutoffs, forceDisable map[string]string) error {
+	var mutex sync.Mutex
+	var disabled []string
+	err := forEachConcurrently(maxConcurrentKeyOps, sortedKeyIDs(entry.RotatedKeys), func(keyId string) error {
+		ok, err := m.disableOneKey(_keyops, keyId, entry.RotatedKeys[keyId], entry, cutoffs, forceDisable)
+		if ok {
+			mutex.Lock()
+			disabled = append(disabled, keyId)
+			mutex.Unlock()
+		}
+		return err
+	})
+	if len(disabled) == 0 {
+		return err
+	}
+
+	// update cache entry to reflect that the keys were successfully disabled. History is recorded here, rather
+	// than in disableOneKey, since keys are disabled concurrently
+	sort.Strings(disabled)
+	for _, keyId := range disabled {
+		delete(entry.RotatedKeys, keyId)
+		entry.DisabledKeys[keyId] = currentTime()
+		entry.RecordKeyEvent(keyId, cache.KeyDisabled, currentTime())
+	}
+	if saveErr := m.cache.Save(entry); saveErr != nil {
+		return errors.Join(err, fmt.Errorf("error saving cache entry after key disable: %v", saveErr))
+	}
+
+	for _, keyId := range disabled {
+		metrics.KeysDisabled.WithLabelValues(entry.Type.String()).Inc()
+		if notifyErr := m.notifier.KeyDisabled(entry, keyId); notifyErr != nil {
+			err = errors.Join(err, notifyErr)
 		}
 	}
+	return err
+}
+
+// alertIfRotatedKeysAccumulating sends a single notification when the entry has more rotated keys waiting to be
+// disabled than MaxRotatedKeys, which usually means a consumer is still using an old key and every one of them is
+// failing the in-use check. The alert is sent again only if the backlog clears and then recurs.
+func (m *Yale) alertIfRotatedKeysAccumulating(entry *cache.Entry) error {
+	limit := m.options.MaxRotatedKeys
+	if limit <= 0 {
+		return nil
+	}
+	count := len(entry.RotatedKeys)
+	if count <= limit {
+		if entry.RotatedKeysAlertAt.IsZero() {
+			return nil
+		}
+		entry.RotatedKeysAlertAt = time.Time{}
+		if err := m.cache.Save(entry); err != nil {
+			return fmt.Errorf("error saving cache entry for %s after rotated keys cleared: %v", entry.Identify(), err)
+		}
+		return nil
+	}
+	if !entry.RotatedKeysAlertAt.IsZero() {
+		// already alerted about this backlog
+		return nil
+	}
+
+	logs.Warn.WithFields(logFields(entry, "rotated_keys_accumulating")).WithFields(logs.Fields{"rotatedKeys": count}).
+		Printf("%s %s has %d rotated keys waiting to be disabled (limit is %d); a consumer may still be using an old key", entry.Type, entry.Identify(), count, limit)
+	metrics.RotatedKeysAccumulating.WithLabelValues(entry.Type.String()).Inc()
+	if err := m.notifier.RotatedKeysAccumulating(entry, count); err != nil {
+		return fmt.Errorf("error sending rotated keys alert for %s: %v", entry.Identify(), err)
+	}
+
+	entry.RotatedKeysAlertAt = currentTime()
+	if err := m.cache.Save(entry); err != nil {
+		return fmt.Errorf("error saving cache entry for %s after sending rotated keys alert: %v", entry.Identify(), err)
+	}
 	return nil
 }
 
-func (m *Yale) disableOneKey(_keyops keyops.KeyOps, keyId string, rotatedAt time.Time, entry *cache.Entry, cutoffs cutoff.Cutoffs) error {
+// disableOneKey disables the key if it has reached the disable cutoff and is no longer in use. Returns true if
+// the key was disabled. It does not modify the cache entry, so it is safe to call for several keys at once.
+func (m *Yale) disableOneKey(_keyops keyops.KeyOps, keyId string, rotatedAt time.Time, entry *cache.Entry, cutoffs cutoff.Cutoffs, forceDisable map[string]string) (bool, error) {
 	// has enough time passed since rotation? if not, do nothing
 
-	logs.Info.Printf("key %s (%s %s) was rotated at %s, disable cutoff is %d days", keyId, entry.Type, entry.Identify(), rotatedAt, cutoffs.DisableAfterDays())
+	logs.Debug.Printf("key %s (%s %s) was rotated at %s, disable cutoff is %s", keyId, entry.Type, entry.Identify(), rotatedAt, cutoffs.DisableAfter())
 	if !cutoffs.ShouldDisable(rotatedAt) {
-		logs.Info.Printf("key %s (%s %s): too early to disable", keyId, entry.Type, entry.Identify())
-		return nil
+		logs.Debug.Printf("key %s (%s %s): too early to disable", keyId, entry.Type, entry.Identify())
+		return false, nil
 	}
 
 	// check if the key is still in use
 	lastAuthTime, err := m.lastAuthTime(keyId, entry)
 	if err != nil {
-		return err
+		return false, err
 	}
 	if lastAuthTime != nil {
 		if !cutoffs.SafeToDisable(*lastAuthTime) {
-			return fmt.Errorf("key %s (%s %s) was rotated at %s but was last used to authenticate at %s; please find out what's still using this key and fix it", keyId, entry.Type, entry.Identify(), rotatedAt, *lastAuthTime)
+			resource, forced := forceDisable[keyId]
+			if !forced {
+				return false, &keyInUseError{keyID: keyId, entry: entry, rotatedAt: rotatedAt, lastAuthTime: *lastAuthTime}
+			}
+			logs.Warn.WithFields(logFields(entry, "key_force_disabled")).WithFields(logs.Fields{"keyID": keyId}).
+				Printf("OVERRIDING SAFETY CHECK: key %s (%s %s) was last used to authenticate at %s, but %s has the %s annotation for it; disabling it anyway", keyId, entry.Type, entry.Identify(), *lastAuthTime, resource, ForceDisableKeyAnnotation)
 		}
 	}
 
 	// disable the key
 	logs.Info.Printf("disabling key %s (%s %s)...", keyId, entry.Type, entry.Identify())
-	if err = _keyops.EnsureDisabled(keyops.Key{
-		Scope:      entry.Scope(),
-		Identifier: entry.Identify(),
-		ID:         keyId,
-	}); err != nil {
-		return fmt.Errorf("error disabling key %s (%s %s): %v", keyId, entry.Type, entry.Identify(), err)
+	if err = _keyops.EnsureDisabled(entryKey(entry, keyId)); err != nil {
+		return false, fmt.Errorf("error disabling key %s (%s %s): %v", keyId, entry.Type, entry.Identify(), err)
 	}
-
-	// update cache entry to reflect that the key was successfully disabled
-	delete(entry.RotatedKeys, keyId)
-	entry.DisabledKeys[keyId] = currentTime()
-	if err = m.cache.Save(entry); err != nil {
-		return fmt.Errorf("error saving cache entry after key disable: %v", err)
-	}
-
-	return m.slack.KeyDisabled(entry, keyId)
+	m.options.AuditLog.Log(audit.Disable, entry, keyId, "", "")
+	logs.Info.WithFields(logFields(entry, "key_disabled")).WithFields(logs.Fields{"keyID": keyId}).Printf("disabled key %s (%s %s)", keyId, entry.Type, entry.Identify())
+	return true, nil
 }
 
 func (m *Yale) lastAuthTime(keyId string, entry *cache.Entry) (*time.Time, error) {
-	// Azure does not support usage metrics so if we are dealing with an
-	// AzureClientSecret, skip this by just returning nil
-	if entry.Type == cache.AzureClientSecret {
+	if m.options.IgnoreUsageMetrics {
 		return nil, nil
 	}
 
-	if m.options.IgnoreUsageMetrics {
+	// usage metrics are available for GCP SA keys (Cloud Monitoring) and Azure client secrets (sign-in logs),
+	// but not AWS access keys, so skip this by just returning nil if there is no source for the entry's type
+	keyOpsType, err := keyOpsTypeFor(entry)
+	if err != nil {
+		return nil, err
+	}
+	_authmetrics, exists := m.authmetrics[keyOpsType]
+	if !exists {
 		return nil, nil
 	}
 
 	logs.Info.Printf("key %s (%s %s) has reached disable cutoff; checking if still in use", keyId, entry.Type, entry.Identify())
-	lastAuthTime, err := m.authmetrics.LastAuthTime(entry.Scope(), entry.Identify(), keyId)
+	// look the key up by its service account's unique id if known, so that a recreated service account's
+	// usage isn't mistaken for the original's
+	serviceAccount := entry.Identify()
+	if uniqueID := entryUniqueID(entry); uniqueID != "" {
+		serviceAccount = uniqueID
+	}
+	lastAuthTime, err := _authmetrics.LastAuthTime(entry.Scope(), serviceAccount, keyId)
 	if err != nil {
 		return nil, fmt.Errorf("error determining last authentication time for key %s (%s %s): %v", keyId, entry.Type, entry.Identify(), err)
 	}
@@ -409,76 +1125,182 @@ func (m *Yale) lastAuthTime(keyId string, entry *cache.Entry) (*time.Time, error
 		logs.Info.Printf("could not identify last authentication time for key %s (%s %s); assuming key is not in use", keyId, entry.Type, entry.Identify())
 		return nil, nil
 	}
-	logs.Info.Printf("last authentication time for key %s (%s %s): %s", keyId, entry.Type, entry.Identify(), *lastAuthTime)
+	logs.Debug.Printf("last authentication time for key %s (%s %s): %s", keyId, entry.Type, entry.Identify(), *lastAuthTime)
 	return lastAuthTime, nil
 }
 
-// deleteOldKeys will delete old service account keys
-func (m *Yale) deleteOldKeys(keyops keyops.KeyOps, entry *cache.Entry, cutoffs cutoff.Cutoffs) error {
-	for keyId, disabledAt := range entry.DisabledKeys {
-		if err := m.deleteOneKey(keyops, keyId, disabledAt, entry, cutoffs); err != nil {
-			return err
+// deleteOldKeys deletes the entry's disabled keys that have reached the delete cutoff, a few at a time. A key
+// that fails to delete does not stop the others; the cache entry is saved once, after every key has been
+// processed, so that the progress made is preserved even if some keys failed.
+func (m *Yale) deleteOldKeys(_keyops keyops.KeyOps, entry *cache.Entry, cutoffs cutoff.Cutoffs) error {
+	var mutex sync.Mutex
+	var deleted []string
+	err := forEachConcurrently(maxConcurrentKeyOps, sortedKeyIDs(entry.DisabledKeys), func(keyId string) error {
+		ok, err := m.deleteOneKey(_keyops, keyId, entry.DisabledKeys[keyId], entry, cutoffs)
+		if ok {
+			mutex.Lock()
+			deleted = append(deleted, keyId)
+			mutex.Unlock()
 		}
+		return err
+	})
+	if len(deleted) == 0 {
+		return err
 	}
-	return nil
+
+	// delete keys from cache entry. History is recorded here, rather than in deleteOneKey, since keys are
+	// deleted concurrently
+	sort.Strings(deleted)
+	for _, keyId := range deleted {
+		delete(entry.DisabledKeys, keyId)
+		entry.RecordKeyEvent(keyId, cache.KeyDeleted, currentTime())
+	}
+	if saveErr := m.cache.Save(entry); saveErr != nil {
+		return errors.Join(err, fmt.Errorf("error updating cache entry for %s after key deletion: %v", entry.Identify(), saveErr))
+	}
+
+	for _, keyId := range deleted {
+		metrics.KeysDeleted.WithLabelValues(entry.Type.String()).Inc()
+		if notifyErr := m.notifier.KeyDeleted(entry, keyId); notifyErr != nil {
+			err = errors.Join(err, notifyErr)
+		}
+	}
+	return err
 }
 
-func (m *Yale) deleteOneKey(_keyops keyops.KeyOps, keyId string, disabledAt time.Time, entry *cache.Entry, cutoffs cutoff.Cutoffs) error {
+// deleteOneKey deletes the key if it has reached the delete cutoff. Returns true if the key was deleted. It does
+// not modify the cache entry, so it is safe to call for several keys at once.
+func (m *Yale) deleteOneKey(_keyops keyops.KeyOps, keyId string, disabledAt time.Time, entry *cache.Entry, cutoffs cutoff.Cutoffs) (bool, error) {
 	// has enough time passed since this key was disabled? if not, do nothing
-	logs.Info.Printf("key %s (%s %s) was disabled at %s, delete cutoff is %d days", keyId, entry.Type, entry.Identify(), disabledAt, cutoffs.DisableAfterDays())
+	logs.Debug.Printf("key %s (%s %s) was disabled at %s, delete cutoff is %s", keyId, entry.Type, entry.Identify(), disabledAt, cutoffs.DeleteAfter())
 	if !cutoffs.ShouldDelete(disabledAt) {
-		logs.Info.Printf("key %s (%s %s): too early to delete", keyId, entry.Type, entry.Identify())
-		return nil
+		logs.Debug.Printf("key %s (%s %s): too early to delete", keyId, entry.Type, entry.Identify())
+		return false, nil
 	}
 
-	key := keyops.Key{
-		Scope:      entry.Scope(),
-		Identifier: entry.Identify(),
-		ID:         keyId,
-	}
+	key := entryKey(entry, keyId)
 
 	// delete key from GCP
 	logs.Info.Printf("key %s (%s %s) has reached delete cutoff; deleting it", key.ID, entry.Type, key.Identifier)
 	if err := _keyops.DeleteIfDisabled(key); err != nil {
-		return fmt.Errorf("error deleting key %s (%s %s): %v", keyId, entry.Type, entry.Identify(), err)
+		return false, fmt.Errorf("error deleting key %s (%s %s): %v", keyId, entry.Type, entry.Identify(), err)
 	}
+	m.options.AuditLog.Log(audit.Delete, entry, keyId, "", "")
 
-	// delete key from cache entry
-	delete(entry.DisabledKeys, keyId)
-	if err := m.cache.Save(entry); err != nil {
-		return fmt.Errorf("error updating cache entry for %s after key deletion: %v", entry.Identify(), err)
-	}
+	logs.Info.WithFields(logFields(entry, "key_deleted")).WithFields(logs.Fields{"keyID": key.ID}).Printf("deleted key %s (%s %s)", key.ID, entry.Type, key.Identifier)
+	return true, nil
+}
 
-	logs.Info.Printf("deleted key %s (%s %s)", key.ID, entry.Type, key.Identifier)
-	return m.slack.KeyDeleted(entry, key.ID)
+// sortedKeyIDs returns the IDs of the keys in the map, in sorted order
+func sortedKeyIDs(keys map[string]time.Time) []string {
+	var ids []string
+	for id := range keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
 }
 
-func retireCacheEntryIfNeeded[Y apiv1b1.YaleCRD](yaleCache cache.Cache, entry *cache.Entry, yaleCRDs []Y) error {
+// retireCacheEntryIfNeeded deletes the cache entry if it has no corresponding CRDs and no keys left to manage.
+// Returns true if the entry was deleted
+func retireCacheEntryIfNeeded[Y apiv1b1.YaleCRD](yaleCache cache.Cache, entry *cache.Entry, yaleCRDs []Y) (bool, error) {
 	if len(yaleCRDs) > 0 {
-		return nil
+		return false, nil
 	}
 	if len(entry.CurrentKey.ID) > 0 {
-		logs.Info.Printf("cache entry for %s has no corresponding %s resources in the cluster; will not delete it because it still has a current key", entry.Identify(), entry.Type)
-		return nil
+		logs.Debug.Printf("cache entry for %s has no corresponding %s resources in the cluster; will not delete it because it still has a current key", entry.Identify(), entry.Type)
+		return false, nil
 	}
 	if len(entry.RotatedKeys) > 0 {
-		logs.Info.Printf("cache entry for %s has no corresponding %s resources in the cluster; will not delete it because it still has keys to disable", entry.Identify(), entry.Type)
-		return nil
+		logs.Debug.Printf("cache entry for %s has no corresponding %s resources in the cluster; will not delete it because it still has keys to disable", entry.Identify(), entry.Type)
+		return false, nil
 	}
 	if len(entry.DisabledKeys) > 0 {
-		logs.Info.Printf("cache entry for %s has no corresponding %s resources in the cluster; will not delete it because it still has keys to delete", entry.Identify(), entry.Type)
-		return nil
+		logs.Debug.Printf("cache entry for %s has no corresponding %s resources in the cluster; will not delete it because it still has keys to delete", entry.Identify(), entry.Type)
+		return false, nil
 	}
 
 	logs.Info.Printf("cache entry for %s is empty and has no corresponding %s resources in the cluster; deleting it", entry.Identify(), entry.Type)
-	return yaleCache.Delete(entry)
+	if err := yaleCache.Delete(entry); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
-const errorRepostDuration = 4 * time.Hour
+// deleteOrphanedSecretsIfNeeded deletes the K8s secrets recorded in the cache entry as previously synced, if the
+// entry has no corresponding resources in the cluster and deleting orphaned secrets is enabled. This runs before
+// the entry is synced, since syncing an entry with no resources clears the record. Entries whose resources were
+// all skipped by the resource mapper (eg. for an invalid spec) are not processed at all, so they never get here.
+func deleteOrphanedSecretsIfNeeded[Y apiv1b1.YaleCRD](_keysync keysync.KeySync, entry *cache.Entry, enabled bool, yaleCRDs []Y) error {
+	if !enabled || len(yaleCRDs) > 0 || len(entry.SyncedSecrets) == 0 {
+		return nil
+	}
+	logs.Info.Printf("cache entry for %s has no corresponding %s resources in the cluster; deleting the secrets previously synced for it", entry.Identify(), entry.Type)
+	return _keysync.DeleteOrphanedSecrets(entry)
+}
+
+// DefaultErrorRepostInterval default minimum time between repeat notifications for a resource's error
+const DefaultErrorRepostInterval = 4 * time.Hour
+
+// keyInUseError error returned when a key has reached its disable cutoff but is still being used to authenticate.
+// These are more serious than most errors, since the key stays enabled until someone finds out what is using it.
+type keyInUseError struct {
+	keyID        string
+	entry        *cache.Entry
+	rotatedAt    time.Time
+	lastAuthTime time.Time
+}
+
+func (e *keyInUseError) Error() string {
+	return fmt.Sprintf("key %s (%s %s) was rotated at %s but was last used to authenticate at %s; please find out what's still using this key and fix it", e.keyID, e.entry.Type, e.entry.Identify(), e.rotatedAt, e.lastAuthTime)
+}
+
+// maxKeyAgeError error returned when the current key is older than the max key age configured for it, which
+// means rotation has been failing or blocked for a long time. It is reported even if the resource was otherwise
+// processed successfully.
+type maxKeyAgeError struct {
+	keyID     string
+	entry     *cache.Entry
+	createdAt time.Time
+	maxKeyAge time.Duration
+}
 
-// reportError report an error on Slack
+func (e *maxKeyAgeError) Error() string {
+	return fmt.Sprintf("current key %s (%s %s) was created at %s and has exceeded the max key age of %s without being rotated; please find out what's blocking rotation and fix it", e.keyID, e.entry.Type, e.entry.Identify(), e.createdAt, e.maxKeyAge)
+}
+
+// checkMaxKeyAge returns a maxKeyAgeError if the entry's current key is older than the max key age
+func checkMaxKeyAge(entry *cache.Entry, cutoffs cutoff.Cutoffs) error {
+	if entry.CurrentKey.ID == "" || !cutoffs.ExceedsMaxKeyAge(entry.CurrentKey.CreatedAt) {
+		return nil
+	}
+	logs.Error.WithFields(logFields(entry, "max_key_age_exceeded")).Printf("%s %s: current secret %s was created at %s and has exceeded the max key age of %s", entry.Type, entry.Identify(), entry.CurrentKey.ID, entry.CurrentKey.CreatedAt, cutoffs.MaxKeyAge())
+	return &maxKeyAgeError{
+		keyID:     entry.CurrentKey.ID,
+		entry:     entry,
+		createdAt: entry.CurrentKey.CreatedAt,
+		maxKeyAge: cutoffs.MaxKeyAge(),
+	}
+}
+
+// errorRepostInterval returns the minimum time between repeat notifications for the error
+func (m *Yale) errorRepostInterval(err error) time.Duration {
+	var inUse *keyInUseError
+	if errors.As(err, &inUse) && m.options.KeyInUseErrorRepostInterval > 0 {
+		return m.options.KeyInUseErrorRepostInterval
+	}
+	if m.options.ErrorRepostInterval > 0 {
+		return m.options.ErrorRepostInterval
+	}
+	return DefaultErrorRepostInterval
+}
+
+// reportError report an error via the configured notifiers
 func (m *Yale) reportError(entry *cache.Entry, err error) error {
 	now := currentTime()
+	repostInterval := m.errorRepostInterval(err)
+	// skipped syncs to unhealthy destinations are reported once for the whole run by reportUnhealthyDestinations
+	skippedSyncsOnly := keysync.IsDestinationUnhealthyError(err)
 
 	entry.LastError.Message = err.Error()
 	entry.LastError.Timestamp = now
@@ -487,12 +1309,24 @@ func (m *Yale) reportError(entry *cache.Entry, err error) error {
 		return fmt.Errorf("error saving cache entry after recording error: %v", err)
 	}
 
-	if time.Since(entry.LastError.LastNotificationAt) < errorRepostDuration {
+	if skippedSyncsOnly {
 		return nil
 	}
 
-	if err = m.slack.Error(entry, entry.LastError.Message); err != nil {
-		return fmt.Errorf("error reporting error to Slack: %v", err)
+	if time.Since(entry.LastError.LastNotificationAt) < repostInterval {
+		return nil
+	}
+
+	if m.options.ErrorDigest {
+		// reported at the end of the run by sendErrorDigest
+		m.digestMutex.Lock()
+		m.digest = append(m.digest, entry)
+		m.digestMutex.Unlock()
+		return nil
+	}
+
+	if err = m.notifier.Error(entry, entry.LastError.Message); err != nil {
+		return fmt.Errorf("error sending error notification: %v", err)
 	}
 
 	entry.LastError.LastNotificationAt = now
@@ -503,6 +1337,55 @@ func (m *Yale) reportError(entry *cache.Entry, err error) error {
 	return nil
 }
 
+// reportUnhealthyDestinations sends a single notification for each destination key sync stopped writing to
+// during this run because it failed too many times
+func (m *Yale) reportUnhealthyDestinations() error {
+	unhealthy := m.keysync.UnhealthyDestinations()
+	var destinations []string
+	for destination := range unhealthy {
+		destinations = append(destinations, destination)
+	}
+	sort.Strings(destinations)
+
+	var errs []error
+	for _, destination := range destinations {
+		logs.Error.Printf("%s appears to be unhealthy; stopped syncing to it after %d errors this run", destination, unhealthy[destination])
+		if err := m.notifier.DestinationUnhealthy(destination, unhealthy[destination]); err != nil {
+			errs = append(errs, fmt.Errorf("error sending unhealthy destination notification for %s: %v", destination, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sendErrorDigest reports the errors collected by reportError during this run in a single notification
+func (m *Yale) sendErrorDigest() error {
+	if len(m.digest) == 0 {
+		return nil
+	}
+
+	if err := m.notifier.ErrorDigest(m.digest); err != nil {
+		return fmt.Errorf("error sending error digest notification: %v", err)
+	}
+
+	now := currentTime()
+	for _, entry := range m.digest {
+		entry.LastError.LastNotificationAt = now
+		if err := m.cache.Save(entry); err != nil {
+			return fmt.Errorf("error saving cache entry for %s after reporting error: %v", entry.Identify(), err)
+		}
+	}
+	return nil
+}
+
+// logFields returns contextual fields for structured log lines about a cache entry
+func logFields(entry *cache.Entry, event string) logs.Fields {
+	return logs.Fields{
+		"type":       entry.Type,
+		"identifier": entry.Identify(),
+		"event":      event,
+	}
+}
+
 // time.Now, but in a standard way that is nicer-looking in log messages and easier to test
 func currentTime() time.Time {
 	return time.Now().UTC().Round(0)
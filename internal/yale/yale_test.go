@@ -1,31 +1,44 @@
 package yale
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/broadinstitute/yale/internal/yale/keysync/testutils/gsm"
+	"maps"
+	"net/http"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/broadinstitute/yale/internal/yale/audit"
+	"github.com/broadinstitute/yale/internal/yale/authmetrics"
 	authmetricsmocks "github.com/broadinstitute/yale/internal/yale/authmetrics/mocks"
 	"github.com/broadinstitute/yale/internal/yale/cache"
 	apiv1b1 "github.com/broadinstitute/yale/internal/yale/crd/api/v1beta1"
 	crdmocks "github.com/broadinstitute/yale/internal/yale/crd/clientset/v1beta1/mocks"
+	"github.com/broadinstitute/yale/internal/yale/cutoff"
 	"github.com/broadinstitute/yale/internal/yale/keyops"
 	keyopsmocks "github.com/broadinstitute/yale/internal/yale/keyops/mocks"
 	"github.com/broadinstitute/yale/internal/yale/keysync"
 	vaultutils "github.com/broadinstitute/yale/internal/yale/keysync/testutils/vault"
+	"github.com/broadinstitute/yale/internal/yale/metrics"
+	"github.com/broadinstitute/yale/internal/yale/notify"
 	"github.com/broadinstitute/yale/internal/yale/resourcemap"
 	"github.com/broadinstitute/yale/internal/yale/slack"
 	slackmocks "github.com/broadinstitute/yale/internal/yale/slack/mocks"
 	"github.com/broadinstitute/yale/internal/yale/testutils"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
 )
 
 const cacheNamespace = cache.DefaultCacheNamespace
@@ -38,6 +51,7 @@ type YaleSuite struct {
 	k8s                    kubernetes.Interface
 	gskEndpoint            *crdmocks.GcpSaKeyInterface
 	azClientSecretEndpoint *crdmocks.AzureClientSecretInterface
+	crd                    *crdmocks.YaleCRDInterface
 	vaultServer            *vaultutils.FakeVaultServer
 	gsmServer              *gsm.FakeGsmServer
 	cache                  cache.Cache
@@ -47,6 +61,8 @@ type YaleSuite struct {
 	keysync                keysync.KeySync
 	slack                  slack.SlackNotifier
 	yale                   *Yale
+	// statuses written to GcpSaKeys and AzureClientSecrets during the test, keyed by "<namespace>/<name>"
+	statuses map[string]apiv1b1.Status
 }
 
 func (suite *YaleSuite) SetupTest() {
@@ -54,9 +70,17 @@ func (suite *YaleSuite) SetupTest() {
 	suite.k8s = testutils.NewFakeK8sClient(suite.T())
 	suite.gskEndpoint = crdmocks.NewGcpSaKeyInterface(suite.T())
 	suite.azClientSecretEndpoint = crdmocks.NewAzureClientSecretInterface(suite.T())
-	crd := crdmocks.NewYaleCRDInterface(suite.T())
-	crd.EXPECT().GcpSaKeys().Return(suite.gskEndpoint)
-	crd.EXPECT().AzureClientSecrets().Return(suite.azClientSecretEndpoint)
+	suite.crd = crdmocks.NewYaleCRDInterface(suite.T())
+	suite.crd.EXPECT().GcpSaKeys().Return(suite.gskEndpoint)
+	suite.crd.EXPECT().AzureClientSecrets().Return(suite.azClientSecretEndpoint)
+
+	// record the statuses Yale writes, so tests can verify them
+	suite.statuses = make(map[string]apiv1b1.Status)
+	recordStatus := func(_ context.Context, namespace string, name string, status apiv1b1.Status) {
+		suite.statuses[namespace+"/"+name] = status
+	}
+	suite.gskEndpoint.EXPECT().UpdateStatus(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(recordStatus).Return(nil).Maybe()
+	suite.azClientSecretEndpoint.EXPECT().UpdateStatus(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(recordStatus).Return(nil).Maybe()
 
 	suite.vaultServer = vaultutils.NewFakeVaultServer(suite.T())
 	suite.gsmServer = gsm.NewFakeGsm(suite.T())
@@ -66,7 +90,7 @@ func (suite *YaleSuite) SetupTest() {
 	// the intermediate cache entry writes during a Yale run,
 	// it's much easier just to verify cache state at the end
 	suite.cache = cache.New(suite.k8s, cacheNamespace)
-	suite.resourcemapper = resourcemap.New(crd, suite.cache)
+	suite.resourcemapper = resourcemap.New(suite.crd, suite.cache)
 
 	// use mocks for these, since mocking gcp api calls is a pain
 	suite.authmetrics = authmetricsmocks.NewAuthMetrics(suite.T())
@@ -74,7 +98,7 @@ func (suite *YaleSuite) SetupTest() {
 
 	// use real keysync so we can verify the state of Vault server/K8s secrets
 	// after the yale run finishes, without mocking every individual call
-	suite.keysync = keysync.New(suite.k8s, suite.vaultServer.NewClient(), suite.gsmServer.NewClient(), nil, suite.cache)
+	suite.keysync = keysync.New(suite.k8s, suite.vaultServer.NewClient(), suite.gsmServer.NewClient(), nil, nil, suite.cache)
 
 	// use noop slack notifier
 	suite.slack = slack.New("")
@@ -93,13 +117,16 @@ func (suite *YaleSuite) SetupTest() {
 			RotateWindow: RotateWindow{
 				Enabled: true,
 				// Make sure the current time is inside the rotation window
-				StartTime: currentTime().Add(-1 * time.Hour),
-				EndTime:   currentTime().Add(time.Hour),
+				Windows: []TimeWindow{{
+					StartTime: currentTime().Add(-1 * time.Hour),
+					EndTime:   currentTime().Add(time.Hour),
+				}},
 			},
 		},
 		suite.cache,
 		suite.resourcemapper,
-		suite.authmetrics,
+		suite.crd,
+		map[string]authmetrics.AuthMetrics{gcpKeyops: suite.authmetrics},
 		_keyops,
 		suite.keysync,
 		suite.slack,
@@ -171,6 +198,12 @@ var sa2key1 = key{
 	pem: "cat",
 }
 
+var sa2key2 = key{
+	id:  "s2-key2",
+	sa:  sa2,
+	pem: "cow",
+}
+
 var sa3key1 = key{
 	id:  "s3-key1",
 	sa:  sa3,
@@ -356,6 +389,7 @@ func (suite *YaleSuite) TestYaleIssuesNewKeyForNewGcpSaKey() {
 	assert.Equal(suite.T(), sa1key1.id, entry.CurrentKey.ID)
 	assert.Equal(suite.T(), sa1key1.json(), entry.CurrentKey.JSON)
 	suite.assertNow(entry.CurrentKey.CreatedAt)
+	suite.assertNow(entry.LastSuccessfulRun)
 
 	// make sure the new key was replicated to the secret in the gsk spec
 	suite.assertSecretHasData("ns-1", "s1-secret", map[string]string{
@@ -364,6 +398,93 @@ func (suite *YaleSuite) TestYaleIssuesNewKeyForNewGcpSaKey() {
 	})
 }
 
+func (suite *YaleSuite) TestYaleIssuesNewKeyWithGcpSaKeyAlgorithm() {
+	gsk := gsk1
+	gsk.Spec.KeyAlgorithm = "KEY_ALG_RSA_1024"
+	suite.seedGsks(gsk)
+	suite.seedAzureClientSecrets()
+
+	suite.keyops.EXPECT().Create(sa1key1.sa.Scope(), sa1key1.sa.Identify(), mock.Anything).
+		Run(func(_ string, _ string, options ...keyops.CreateOption) {
+			var opts keyops.CreateOptions
+			for _, option := range options {
+				option(&opts)
+			}
+			assert.Equal(suite.T(), "KEY_ALG_RSA_1024", opts.KeyAlgorithm)
+			assert.Empty(suite.T(), opts.KeyType)
+		}).
+		Return(sa1key1.keyopsFormat(), []byte(sa1key1.json()), nil)
+
+	require.NoError(suite.T(), suite.yale.Run())
+
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), sa1key1.id, entry.CurrentKey.ID)
+}
+
+func (suite *YaleSuite) TestYaleRecordsMetrics() {
+	suite.seedGsks(gsk1)
+	suite.seedAzureClientSecrets()
+
+	suite.expectCreateKey(sa1key1)
+
+	issuedBefore := testutil.ToFloat64(metrics.KeysIssued.WithLabelValues(cache.GcpSaKey.String()))
+	runsBefore := suite.gatherRunCount()
+
+	require.NoError(suite.T(), suite.yale.Run())
+
+	assert.Equal(suite.T(), issuedBefore+1, testutil.ToFloat64(metrics.KeysIssued.WithLabelValues(cache.GcpSaKey.String())))
+	assert.Equal(suite.T(), runsBefore+1, suite.gatherRunCount())
+
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), float64(entry.LastSuccessfulRun.Unix()), testutil.ToFloat64(metrics.OldestSuccessfulRun))
+}
+
+func (suite *YaleSuite) TestYaleDefersSyncOfNewKeyUntilSyncDelayHasPassed() {
+	suite.seedGsks(gsk1)
+	suite.seedAzureClientSecrets()
+
+	suite.yale.options.SyncDelayAfterIssue = time.Hour
+
+	suite.expectCreateKey(sa1key1)
+
+	require.NoError(suite.T(), suite.yale.Run())
+
+	// make sure the cache contains the new key, but it has not been synced yet
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), sa1key1.id, entry.CurrentKey.ID)
+	assert.Empty(suite.T(), entry.SyncStatus)
+
+	_, err = suite.k8s.CoreV1().Secrets("ns-1").Get(context.Background(), "s1-secret", metav1.GetOptions{})
+	assert.True(suite.T(), errors.IsNotFound(err), "secret should not exist yet")
+}
+
+func (suite *YaleSuite) TestYaleSyncsKeyOnceSyncDelayHasPassed() {
+	suite.seedGsks(gsk1)
+	suite.seedAzureClientSecrets()
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa1,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa1key1.id,
+			JSON:      sa1key1.json(),
+			CreatedAt: fourHoursAgo,
+		},
+	})
+
+	suite.yale.options.SyncDelayAfterIssue = time.Hour
+
+	require.NoError(suite.T(), suite.yale.Run())
+
+	suite.assertSecretHasData("ns-1", "s1-secret", map[string]string{
+		"key.pem":  sa1key1.pem,
+		"key.json": sa1key1.json(),
+	})
+}
+
 func (suite *YaleSuite) TestYaleIssuesNewSecretButDoesNotRotateIfOutsideRotationWindow() {
 	_keyops := make(map[string]keyops.KeyOps)
 	// use mock implementations for both keyops instances
@@ -375,14 +496,17 @@ func (suite *YaleSuite) TestYaleIssuesNewSecretButDoesNotRotateIfOutsideRotation
 			CacheNamespace:     cache.DefaultCacheNamespace,
 			IgnoreUsageMetrics: false,
 			RotateWindow: RotateWindow{
-				Enabled:   true,
-				StartTime: currentTime().Add(1 * time.Hour),
-				EndTime:   currentTime().Add(2 * time.Hour),
+				Enabled: true,
+				Windows: []TimeWindow{
+					{StartTime: currentTime().Add(1 * time.Hour), EndTime: currentTime().Add(2 * time.Hour)},
+					{StartTime: currentTime().Add(-2 * time.Hour), EndTime: currentTime().Add(-1 * time.Hour)},
+				},
 			},
 		},
 		suite.cache,
 		suite.resourcemapper,
-		suite.authmetrics,
+		suite.crd,
+		map[string]authmetrics.AuthMetrics{gcpKeyops: suite.authmetrics},
 		_keyops,
 		suite.keysync,
 		suite.slack,
@@ -440,6 +564,69 @@ func (suite *YaleSuite) TestYaleIssuesNewClientSecretForNewAzureClientSecret() {
 	})
 }
 
+func (suite *YaleSuite) TestYaleReportsCurrentKeyAndSyncStatusOnGcpSaKey() {
+	suite.seedGsks(gsk1)
+	suite.seedAzureClientSecrets()
+
+	suite.expectCreateKey(sa1key1)
+
+	require.NoError(suite.T(), suite.yale.Run())
+
+	status, exists := suite.statuses["ns-1/s1-gsk"]
+	require.True(suite.T(), exists, "status should be written to the gsk")
+	assert.Equal(suite.T(), sa1key1.id, status.CurrentKeyID)
+	assert.Equal(suite.T(), "s1-secret", status.SyncedSecret)
+	require.NotNil(suite.T(), status.LastRotatedAt)
+	suite.assertNow(status.LastRotatedAt.Time)
+	require.NotNil(suite.T(), status.LastSyncedAt)
+	suite.assertNow(status.LastSyncedAt.Time)
+	assert.Empty(suite.T(), status.Error)
+}
+
+func (suite *YaleSuite) TestYaleReportsCurrentKeyAndSyncStatusOnAzureClientSecret() {
+	suite.seedGsks()
+	suite.seedAzureClientSecrets(acs1)
+	suite.expectCreateKey(clientSecret1Key1)
+
+	require.NoError(suite.T(), suite.yale.Run())
+
+	status, exists := suite.statuses["ns-1/clientsecret1-acs"]
+	require.True(suite.T(), exists, "status should be written to the azure client secret")
+	assert.Equal(suite.T(), clientSecret1Key1.id, status.CurrentKeyID)
+	assert.Equal(suite.T(), "clientsecret1-secret", status.SyncedSecret)
+	require.NotNil(suite.T(), status.LastSyncedAt)
+	suite.assertNow(status.LastSyncedAt.Time)
+	assert.Empty(suite.T(), status.Error)
+}
+
+func (suite *YaleSuite) TestYaleReportsProcessingErrorOnGcpSaKeyStatus() {
+	suite.seedGsks(gsk1)
+	suite.seedAzureClientSecrets()
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa1,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa1key2.id,
+			JSON:      sa1key2.json(),
+			CreatedAt: now,
+		},
+		RotatedKeys: map[string]time.Time{
+			sa1key1.id: eightDaysAgo,
+		},
+	})
+
+	suite.expectLastAuthTime(sa1key1, fourHoursAgo)
+
+	require.Error(suite.T(), suite.yale.Run())
+
+	status, exists := suite.statuses["ns-1/s1-gsk"]
+	require.True(suite.T(), exists, "status should be written to the gsk")
+	assert.Equal(suite.T(), sa1key2.id, status.CurrentKeyID)
+	assert.Equal(suite.T(), "s1-secret", status.SyncedSecret)
+	assert.Contains(suite.T(), status.Error, "please find out what's still using this key")
+}
+
 func (suite *YaleSuite) TestYaleIssuesNewSecretsForMultipleResourceTypes() {
 	suite.seedGsks(gsk1)
 	suite.seedAzureClientSecrets(acs1)
@@ -474,6 +661,53 @@ func (suite *YaleSuite) TestYaleIssuesNewSecretsForMultipleResourceTypes() {
 	})
 }
 
+func (suite *YaleSuite) TestYaleProcessesIdentifiersConcurrently() {
+	suite.yale.options.MaxConcurrentIdentifiers = 3
+
+	suite.seedGsks(gsk1, gsk2, gsk3)
+	suite.seedAzureClientSecrets(acs1)
+
+	suite.expectCreateKey(sa1key1)
+	suite.expectCreateKey(sa2key1)
+	suite.expectCreateKey(sa3key1)
+	suite.expectCreateKey(clientSecret1Key1)
+
+	require.NoError(suite.T(), suite.yale.Run())
+
+	// every identifier should have been issued a key and synced, as if they had been processed one at a time
+	for _, k := range []key{sa1key1, sa2key1, sa3key1, clientSecret1Key1} {
+		entry, err := suite.cache.GetOrCreate(k.sa)
+		require.NoError(suite.T(), err)
+		assert.Equal(suite.T(), k.id, entry.CurrentKey.ID)
+		assert.Equal(suite.T(), k.json(), entry.CurrentKey.JSON)
+		suite.assertNow(entry.LastSuccessfulRun)
+	}
+	suite.assertSecretHasData("ns-1", "s1-secret", map[string]string{"key.json": sa1key1.json()})
+	suite.assertSecretHasData("ns-2", "s2-secret", map[string]string{"key.json": sa2key1.json()})
+	suite.assertSecretHasData("ns-3", "s3-secret", map[string]string{"key.json": sa3key1.json()})
+	suite.assertSecretHasData("ns-1", "clientsecret1-secret", map[string]string{"clientsecret-key": clientSecret1Key1.json()})
+}
+
+func (suite *YaleSuite) TestYaleReturnsErrorForUnsupportedEntryType() {
+	suite.seedGsks()
+	suite.seedAzureClientSecrets()
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: cache.AwsIamUserEntryIdentifier{
+			UserName:  "legacy-user",
+			AccountID: "123456789012",
+		},
+		Type: cache.AwsIamAccessKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        "AKIAEXAMPLE",
+			CreatedAt: eightDaysAgo,
+		},
+	})
+
+	err := suite.yale.Run()
+	assert.ErrorContains(suite.T(), err, "legacy-user: unsupported entry type AwsIamAccessKey")
+}
+
 func (suite *YaleSuite) TestYaleRotatesOldKey() {
 	suite.seedGsks(gsk1)
 	suite.seedAzureClientSecrets(acs1)
@@ -538,6 +772,148 @@ func (suite *YaleSuite) TestYaleRotatesOldKey() {
 	})
 }
 
+func (suite *YaleSuite) TestYaleDoesNotRotateOldKeyIfRotationIsPaused() {
+	gsk := gsk1
+	gsk.Spec.KeyRotation.Paused = true
+	suite.seedGsks(gsk)
+	suite.seedAzureClientSecrets()
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa1,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa1key2.id,
+			JSON:      sa1key2.json(),
+			CreatedAt: eightDaysAgo,
+		},
+		RotatedKeys: map[string]time.Time{
+			sa1key1.id: eightDaysAgo,
+		},
+	})
+
+	// already-rotated keys are still disabled
+	suite.expectLastAuthTime(sa1key1, fourDaysAgo)
+	suite.expectDisableKey(sa1key1)
+
+	require.NoError(suite.T(), suite.yale.Run())
+
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), sa1key2.id, entry.CurrentKey.ID)
+	assert.Equal(suite.T(), eightDaysAgo, entry.CurrentKey.CreatedAt)
+	assert.Empty(suite.T(), entry.RotatedKeys)
+	assert.Equal(suite.T(), []string{sa1key1.id}, sortedKeyIDs(entry.DisabledKeys))
+}
+
+func (suite *YaleSuite) TestYaleDoesNotIssueNewKeyWhenDecommissioning() {
+	gsk := gsk1
+	gsk.Spec.KeyRotation.Decommission = true
+	suite.seedGsks(gsk)
+	suite.seedAzureClientSecrets()
+
+	// no key should be created
+	require.NoError(suite.T(), suite.yale.Run())
+
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), entry.CurrentKey.ID)
+	assert.Empty(suite.T(), entry.RotatedKeys)
+}
+
+func (suite *YaleSuite) TestYaleRetiresKeysWithoutIssuingNewKeyWhenDecommissioning() {
+	gsk := gsk1
+	gsk.Spec.KeyRotation.Decommission = true
+	suite.seedGsks(gsk)
+	suite.seedAzureClientSecrets()
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa1,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa1key2.id,
+			JSON:      sa1key2.json(),
+			CreatedAt: eightDaysAgo,
+		},
+		RotatedKeys: map[string]time.Time{
+			sa1key1.id: eightDaysAgo,
+		},
+	})
+
+	// the old rotated key is still disabled, but no key is created to replace the expired current key
+	suite.expectLastAuthTime(sa1key1, fourDaysAgo)
+	suite.expectDisableKey(sa1key1)
+
+	require.NoError(suite.T(), suite.yale.Run())
+
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), entry.CurrentKey.ID)
+	assert.Equal(suite.T(), []string{sa1key2.id}, sortedKeyIDs(entry.RotatedKeys))
+	suite.assertNow(entry.RotatedKeys[sa1key2.id])
+	assert.Equal(suite.T(), []string{sa1key1.id}, sortedKeyIDs(entry.DisabledKeys))
+}
+
+func (suite *YaleSuite) TestYaleKeepsPendingRotatedKeysWhenRetiringCurrentKeyForDecommissioning() {
+	gsk := gsk1
+	gsk.Spec.KeyRotation.Decommission = true
+	suite.seedGsks(gsk)
+	suite.seedAzureClientSecrets()
+
+	// sa1key1 was rotated too recently to be disabled yet
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa1,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa1key2.id,
+			JSON:      sa1key2.json(),
+			CreatedAt: eightDaysAgo,
+		},
+		RotatedKeys: map[string]time.Time{
+			sa1key1.id: fourDaysAgo,
+		},
+	})
+
+	require.NoError(suite.T(), suite.yale.Run())
+
+	// the retired current key joins the pending rotated key, so that both are disabled and deleted later
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), entry.CurrentKey.ID)
+	assert.Equal(suite.T(), []string{sa1key1.id, sa1key2.id}, sortedKeyIDs(entry.RotatedKeys))
+	assert.Equal(suite.T(), fourDaysAgo, entry.RotatedKeys[sa1key1.id])
+	suite.assertNow(entry.RotatedKeys[sa1key2.id])
+	assert.Empty(suite.T(), entry.DisabledKeys)
+}
+
+func (suite *YaleSuite) TestYaleRotatesOldKeyIfNotAllResourcesArePaused() {
+	paused := gsk1
+	paused.Spec.KeyRotation.Paused = true
+	unpaused := gsk1
+	unpaused.ObjectMeta.Name = "s1-gsk-unpaused"
+	unpaused.Spec.Secret.Name = "s1-secret-unpaused"
+	suite.seedGsks(paused, unpaused)
+	suite.seedAzureClientSecrets()
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa1,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa1key1.id,
+			JSON:      sa1key1.json(),
+			CreatedAt: eightDaysAgo,
+		},
+	})
+
+	suite.expectCreateKey(sa1key2)
+
+	require.NoError(suite.T(), suite.yale.Run())
+
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), sa1key2.id, entry.CurrentKey.ID)
+	assert.Contains(suite.T(), entry.RotatedKeys, sa1key1.id)
+}
+
 func (suite *YaleSuite) TestYaleDisablesOldKeyIfNotInUse() {
 	suite.seedGsks(gsk1)
 	suite.seedAzureClientSecrets(acs1)
@@ -658,12 +1034,38 @@ func (suite *YaleSuite) TestYaleDisablesOldKeyIfNoUsageDataAvailable() {
 	suite.assertNow(t)
 }
 
-func (suite *YaleSuite) TestYaleReturnsErrorIfOldRotatedKeyIsStillInUse() {
+func (suite *YaleSuite) TestYaleRecordsUniqueIDAndIssuesNewKeyByIt() {
+	suite.useUniqueIDs(map[string]string{sa1.Email: "111"})
+	suite.seedGsks(gsk1)
+	suite.seedAzureClientSecrets()
+
+	suite.keyops.EXPECT().Create(sa1.Scope(), sa1.Identify(), mock.Anything).
+		Run(func(_ string, _ string, options ...keyops.CreateOption) {
+			var opts keyops.CreateOptions
+			for _, option := range options {
+				option(&opts)
+			}
+			assert.Equal(suite.T(), "111", opts.UniqueID)
+		}).
+		Return(sa1key1.keyopsFormat(), []byte(sa1key1.json()), nil)
+
+	require.NoError(suite.T(), suite.yale.Run())
+
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "111", entry.Identifier.(cache.GcpSaKeyEntryIdentifier).UniqueID)
+	assert.Equal(suite.T(), sa1key1.id, entry.CurrentKey.ID)
+}
+
+func (suite *YaleSuite) TestYaleDisablesOldKeyByUniqueID() {
+	suite.useUniqueIDs(map[string]string{sa1.Email: "111"})
 	suite.seedGsks(gsk1)
 	suite.seedAzureClientSecrets()
 
+	withUniqueID := sa1
+	withUniqueID.UniqueID = "111"
 	suite.seedCacheEntries(&cache.Entry{
-		Identifier: sa1,
+		Identifier: withUniqueID,
 		Type:       cache.GcpSaKey,
 		CurrentKey: cache.CurrentKey{
 			ID:        sa1key2.id,
@@ -675,28 +1077,184 @@ func (suite *YaleSuite) TestYaleReturnsErrorIfOldRotatedKeyIsStillInUse() {
 		},
 	})
 
-	suite.expectLastAuthTime(sa1key1, fourHoursAgo)
-	// There is no support for usage data in azure so we don't have a test case for it here
+	disabledKey := sa1key1.keyopsFormat()
+	disabledKey.UniqueID = "111"
+	suite.authmetrics.EXPECT().LastAuthTime(sa1.Scope(), "111", sa1key1.id).Return(&fourDaysAgo, nil)
+	suite.keyops.EXPECT().EnsureDisabled(disabledKey).Return(nil)
 
-	err := suite.yale.Run()
-	require.Error(suite.T(), err)
-	assert.ErrorContains(suite.T(), err, "please find out what's still using this key")
+	require.NoError(suite.T(), suite.yale.Run())
 
-	// make sure the cache still includes this key in the rotated section, not disabled
 	entry, err := suite.cache.GetOrCreate(sa1)
 	require.NoError(suite.T(), err)
-
-	// make sure the cache entry's rotated section does not include the old key
-	t, exists := entry.RotatedKeys[sa1key1.id]
-	assert.True(suite.T(), exists)
-	assert.Equal(suite.T(), eightDaysAgo, t)
-
-	// make sure the cache entry's disabled section includes the old key
-	_, exists = entry.DisabledKeys[sa1key1.id]
-	assert.False(suite.T(), exists)
+	assert.NotContains(suite.T(), entry.RotatedKeys, sa1key1.id)
+	assert.Contains(suite.T(), entry.DisabledKeys, sa1key1.id)
 }
 
-func (suite *YaleSuite) TestYaleDoesNotCheckIfRotatedKeyIsStillInUseIfIgnoreUsageMetricsIsTrue() {
+func (suite *YaleSuite) TestYaleDoesNotManageKeysOfRecreatedServiceAccount() {
+	// the service account was deleted and recreated with the same email, so it has a new unique id
+	suite.useUniqueIDs(map[string]string{sa1.Email: "222"})
+	suite.seedGsks(gsk1)
+	suite.seedAzureClientSecrets()
+
+	withUniqueID := sa1
+	withUniqueID.UniqueID = "111"
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: withUniqueID,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa1key2.id,
+			JSON:      sa1key2.json(),
+			CreatedAt: now,
+		},
+		RotatedKeys: map[string]time.Time{
+			sa1key1.id: eightDaysAgo,
+		},
+	})
+
+	// no keyops or authmetrics calls are expected
+	err := suite.yale.Run()
+	require.Error(suite.T(), err)
+	assert.ErrorContains(suite.T(), err, "deleted and recreated")
+
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "111", entry.Identifier.(cache.GcpSaKeyEntryIdentifier).UniqueID)
+	assert.Contains(suite.T(), entry.RotatedKeys, sa1key1.id)
+}
+
+func (suite *YaleSuite) TestYaleReturnsErrorIfOldRotatedKeyIsStillInUse() {
+	suite.seedGsks(gsk1)
+	suite.seedAzureClientSecrets()
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa1,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa1key2.id,
+			JSON:      sa1key2.json(),
+			CreatedAt: now,
+		},
+		RotatedKeys: map[string]time.Time{
+			sa1key1.id: eightDaysAgo,
+		},
+	})
+
+	suite.expectLastAuthTime(sa1key1, fourHoursAgo)
+	// see TestYaleReturnsErrorIfOldClientSecretIsStillInUse for azure
+
+	err := suite.yale.Run()
+	require.Error(suite.T(), err)
+	assert.ErrorContains(suite.T(), err, "please find out what's still using this key")
+
+	// make sure the cache still includes this key in the rotated section, not disabled
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+
+	// make sure the cache entry's rotated section does not include the old key
+	t, exists := entry.RotatedKeys[sa1key1.id]
+	assert.True(suite.T(), exists)
+	assert.Equal(suite.T(), eightDaysAgo, t)
+
+	// make sure the cache entry's disabled section includes the old key
+	_, exists = entry.DisabledKeys[sa1key1.id]
+	assert.False(suite.T(), exists)
+}
+
+func (suite *YaleSuite) TestYaleForceDisablesKeyStillInUseIfAnnotated() {
+	gsk := gsk1
+	gsk.Annotations = map[string]string{ForceDisableKeyAnnotation: sa1key1.id}
+	suite.seedGsks(gsk)
+	suite.seedAzureClientSecrets()
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa1,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa1key2.id,
+			JSON:      sa1key2.json(),
+			CreatedAt: now,
+		},
+		RotatedKeys: map[string]time.Time{
+			sa1key1.id: eightDaysAgo,
+		},
+	})
+
+	suite.expectLastAuthTime(sa1key1, fourHoursAgo)
+	suite.expectDisableKey(sa1key1)
+
+	require.NoError(suite.T(), suite.yale.Run())
+
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.NotContains(suite.T(), entry.RotatedKeys, sa1key1.id)
+	assert.Contains(suite.T(), entry.DisabledKeys, sa1key1.id)
+}
+
+func (suite *YaleSuite) TestYaleDoesNotForceDisableKeyStillInUseIfAnnotationNamesAnotherKey() {
+	gsk := gsk1
+	gsk.Annotations = map[string]string{ForceDisableKeyAnnotation: "some-other-key"}
+	suite.seedGsks(gsk)
+	suite.seedAzureClientSecrets()
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa1,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa1key2.id,
+			JSON:      sa1key2.json(),
+			CreatedAt: now,
+		},
+		RotatedKeys: map[string]time.Time{
+			sa1key1.id: eightDaysAgo,
+		},
+	})
+
+	suite.expectLastAuthTime(sa1key1, fourHoursAgo)
+
+	err := suite.yale.Run()
+	require.Error(suite.T(), err)
+	assert.ErrorContains(suite.T(), err, "please find out what's still using this key")
+
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), entry.RotatedKeys, sa1key1.id)
+	assert.NotContains(suite.T(), entry.DisabledKeys, sa1key1.id)
+}
+
+func (suite *YaleSuite) TestYaleReturnsErrorIfOldClientSecretIsStillInUse() {
+	suite.seedGsks()
+	suite.seedAzureClientSecrets(acs1)
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: clientSecret1,
+		Type:       cache.AzureClientSecret,
+		CurrentKey: cache.CurrentKey{
+			ID:        clientSecret1Key2.id,
+			JSON:      clientSecret1Key2.json(),
+			CreatedAt: now,
+		},
+		RotatedKeys: map[string]time.Time{
+			clientSecret1Key1.id: eightDaysAgo,
+		},
+	})
+
+	// azure usage data comes from sign-in logs rather than cloud monitoring, so it has its own source
+	azureAuthMetrics := authmetricsmocks.NewAuthMetrics(suite.T())
+	azureAuthMetrics.EXPECT().LastAuthTime(clientSecret1.Scope(), clientSecret1.Identify(), clientSecret1Key1.id).Return(&fourHoursAgo, nil)
+	suite.yale.authmetrics[azureKeyops] = azureAuthMetrics
+
+	err := suite.yale.Run()
+	require.Error(suite.T(), err)
+	assert.ErrorContains(suite.T(), err, "please find out what's still using this key")
+
+	// make sure the cache still includes this key in the rotated section, not disabled
+	entry, err := suite.cache.GetOrCreate(clientSecret1)
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), entry.RotatedKeys, clientSecret1Key1.id)
+	assert.NotContains(suite.T(), entry.DisabledKeys, clientSecret1Key1.id)
+}
+
+func (suite *YaleSuite) TestYaleDoesNotCheckIfRotatedKeyIsStillInUseIfIgnoreUsageMetricsIsTrue() {
 	_keyops := make(map[string]keyops.KeyOps)
 	_keyops[gcpKeyops] = suite.keyops
 	_keyops[azureKeyops] = suite.keyops
@@ -708,7 +1266,8 @@ func (suite *YaleSuite) TestYaleDoesNotCheckIfRotatedKeyIsStillInUseIfIgnoreUsag
 		},
 		suite.cache,
 		suite.resourcemapper,
-		suite.authmetrics,
+		suite.crd,
+		map[string]authmetrics.AuthMetrics{gcpKeyops: suite.authmetrics},
 		_keyops,
 		suite.keysync,
 		suite.slack,
@@ -774,9 +1333,17 @@ func (suite *YaleSuite) TestYaleDoesNotCheckIfRotatedKeyIsStillInUseIfIgnoreUsag
 	suite.assertNow(t)
 }
 
-func (suite *YaleSuite) TestYaleDoesNotRotateDisableOrDeleteKeysThatAreNotOldEnough() {
+func (suite *YaleSuite) TestYaleDisablesAndDeletesOldKeysInsideDisableWindow() {
+	suite.yale.options.DisableWindow = RotateWindow{
+		Enabled: true,
+		Windows: []TimeWindow{{
+			StartTime: currentTime().Add(-1 * time.Hour),
+			EndTime:   currentTime().Add(time.Hour),
+		}},
+	}
+
 	suite.seedGsks(gsk1)
-	suite.seedAzureClientSecrets(acs1)
+	suite.seedAzureClientSecrets()
 
 	suite.seedCacheEntries(&cache.Entry{
 		Identifier: sa1,
@@ -787,146 +1354,367 @@ func (suite *YaleSuite) TestYaleDoesNotRotateDisableOrDeleteKeysThatAreNotOldEno
 			CreatedAt: now,
 		},
 		RotatedKeys: map[string]time.Time{
-			sa1key2.id: now,
+			sa1key2.id: eightDaysAgo,
 		},
 		DisabledKeys: map[string]time.Time{
-			sa1key1.id: now,
+			sa1key1.id: eightDaysAgo,
 		},
 	})
 
-	suite.seedCacheEntries(&cache.Entry{
-		Identifier: clientSecret1,
-		Type:       cache.AzureClientSecret,
-		CurrentKey: cache.CurrentKey{
-			ID:        clientSecret1Key3.id,
-			JSON:      clientSecret1Key3.json(),
-			CreatedAt: now,
-		},
-		RotatedKeys: map[string]time.Time{
-			clientSecret1Key2.id: now,
-		},
-		DisabledKeys: map[string]time.Time{
-			clientSecret1Key1.id: now,
-		},
-	})
+	suite.expectLastAuthTime(sa1key2, fourDaysAgo)
+	suite.expectDisableKey(sa1key2)
+	suite.expectDeleteKey(sa1key1)
 
 	require.NoError(suite.T(), suite.yale.Run())
 
-	// validate cache entry
 	entry, err := suite.cache.GetOrCreate(sa1)
 	require.NoError(suite.T(), err)
-
-	entryAcs, err := suite.cache.GetOrCreate(clientSecret1)
-	require.NoError(suite.T(), err)
-
-	// make sure the cache entry's rotated section still includes key2
-	t, exists := entry.RotatedKeys[sa1key2.id]
-	assert.True(suite.T(), exists)
-	suite.assertNow(t)
-
-	t, exists = entryAcs.RotatedKeys[clientSecret1Key2.id]
-	assert.True(suite.T(), exists)
-	suite.assertNow(t)
-
-	// make sure the cache entry's disabled section still includes key1
-	t, exists = entry.DisabledKeys[sa1key1.id]
-	assert.True(suite.T(), exists)
-	suite.assertNow(t)
-
-	t, exists = entryAcs.DisabledKeys[clientSecret1Key1.id]
-	assert.True(suite.T(), exists)
-	suite.assertNow(t)
+	assert.Empty(suite.T(), entry.RotatedKeys)
+	assert.Equal(suite.T(), []string{sa1key2.id}, sortedKeyIDs(entry.DisabledKeys))
 }
 
-func (suite *YaleSuite) TestYaleDeletesOldKeys() {
+func (suite *YaleSuite) TestYaleDisablesAndDeletesOldKeysInsideDisableWindowWhenOutsideRotateWindow() {
+	// rotate during the day and disable overnight; the windows don't overlap
+	suite.yale.options.RotateWindow = RotateWindow{
+		Enabled: true,
+		Windows: []TimeWindow{{
+			StartTime: currentTime().Add(1 * time.Hour),
+			EndTime:   currentTime().Add(2 * time.Hour),
+		}},
+	}
+	suite.yale.options.DisableWindow = RotateWindow{
+		Enabled: true,
+		Windows: []TimeWindow{{
+			StartTime: currentTime().Add(-1 * time.Hour),
+			EndTime:   currentTime().Add(time.Hour),
+		}},
+	}
+
 	suite.seedGsks(gsk1)
-	suite.seedAzureClientSecrets(acs1)
+	suite.seedAzureClientSecrets()
 
 	suite.seedCacheEntries(&cache.Entry{
 		Identifier: sa1,
 		Type:       cache.GcpSaKey,
 		CurrentKey: cache.CurrentKey{
-			ID:        sa1key2.id,
-			JSON:      sa1key2.json(),
-			CreatedAt: now,
-		},
-		DisabledKeys: map[string]time.Time{
-			sa1key1.id: eightDaysAgo,
+			ID:        sa1key3.id,
+			JSON:      sa1key3.json(),
+			CreatedAt: eightDaysAgo,
 		},
-	})
-
-	suite.seedCacheEntries(&cache.Entry{
-		Identifier: clientSecret1,
-		Type:       cache.AzureClientSecret,
-		CurrentKey: cache.CurrentKey{
-			ID:        clientSecret1Key2.id,
-			JSON:      clientSecret1Key2.json(),
-			CreatedAt: now,
+		RotatedKeys: map[string]time.Time{
+			sa1key2.id: eightDaysAgo,
 		},
 		DisabledKeys: map[string]time.Time{
-			clientSecret1Key1.id: eightDaysAgo,
+			sa1key1.id: eightDaysAgo,
 		},
 	})
 
+	suite.expectLastAuthTime(sa1key2, fourDaysAgo)
+	suite.expectDisableKey(sa1key2)
 	suite.expectDeleteKey(sa1key1)
-	suite.expectDeleteKey(clientSecret1Key1)
+	// Note: we do NOT expect the current key to be rotated, because we are outside the rotation window
 
 	require.NoError(suite.T(), suite.yale.Run())
 
-	// validate cache entry
 	entry, err := suite.cache.GetOrCreate(sa1)
 	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), sa1key3.id, entry.CurrentKey.ID)
+	assert.Empty(suite.T(), entry.RotatedKeys)
+	assert.Equal(suite.T(), []string{sa1key2.id}, sortedKeyIDs(entry.DisabledKeys))
+}
 
-	entryAcs, err := suite.cache.GetOrCreate(clientSecret1)
-	require.NoError(suite.T(), err)
+func (suite *YaleSuite) TestYaleWritesAuditLogForIssueSyncAndDisable() {
+	var buf bytes.Buffer
+	auditLog := audit.New(&buf, "")
 
-	// make sure the cache entry's disabled section is empty
-	assert.Empty(suite.T(), entry.DisabledKeys)
-	assert.Empty(suite.T(), entryAcs.DisabledKeys)
-}
+	_keyops := make(map[string]keyops.KeyOps)
+	_keyops[gcpKeyops] = suite.keyops
+	_keyops[azureKeyops] = suite.keyops
+	// overwrite default yale instance with one that writes an audit log
+	suite.yale = newYaleFromComponents(
+		Options{
+			CacheNamespace: cache.DefaultCacheNamespace,
+			RotateWindow:   suite.yale.options.RotateWindow,
+			AuditLog:       auditLog,
+		},
+		suite.cache,
+		suite.resourcemapper,
+		suite.crd,
+		map[string]authmetrics.AuthMetrics{gcpKeyops: suite.authmetrics},
+		_keyops,
+		keysync.New(suite.k8s, suite.vaultServer.NewClient(), suite.gsmServer.NewClient(), nil, nil, suite.cache, func(options *keysync.Options) {
+			options.AuditLog = auditLog
+		}),
+		suite.slack,
+	)
 
-func (suite *YaleSuite) TestYaleCorrectlyProcessesCacheEntryWithNoMatchingYaleCRDs() {
-	suite.seedGsks()
+	suite.seedGsks(gsk1)
 	suite.seedAzureClientSecrets()
 
 	suite.seedCacheEntries(&cache.Entry{
 		Identifier: sa1,
 		Type:       cache.GcpSaKey,
 		CurrentKey: cache.CurrentKey{
-			ID:        sa1key1.id,
-			JSON:      sa1key1.json(),
+			ID:        sa1key2.id,
+			JSON:      sa1key2.json(),
 			CreatedAt: eightDaysAgo,
 		},
 		RotatedKeys: map[string]time.Time{
-			sa1key2.id: eightDaysAgo,
-		},
-		DisabledKeys: map[string]time.Time{
-			sa1key3.id: eightDaysAgo,
+			sa1key1.id: eightDaysAgo,
 		},
 	})
 
-	suite.seedCacheEntries(&cache.Entry{
-		Identifier: clientSecret1,
-		Type:       cache.AzureClientSecret,
-		CurrentKey: cache.CurrentKey{
-			ID:        clientSecret1Key1.id,
-			JSON:      clientSecret1Key1.json(),
-			CreatedAt: eightDaysAgo,
-		},
-		RotatedKeys: map[string]time.Time{
-			clientSecret1Key2.id: eightDaysAgo,
-		},
-		DisabledKeys: map[string]time.Time{
-			clientSecret1Key3.id: eightDaysAgo,
+	suite.expectCreateKey(sa1key3)
+	suite.expectLastAuthTime(sa1key1, fourDaysAgo)
+	suite.expectDisableKey(sa1key1)
+
+	require.NoError(suite.T(), suite.yale.Run())
+
+	// the log is flushed at the end of the run, and its hash chain is intact
+	require.NoError(suite.T(), audit.Verify(bytes.NewReader(buf.Bytes())))
+
+	var records []audit.Record
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record audit.Record
+		require.NoError(suite.T(), json.Unmarshal([]byte(line), &record))
+		assert.Equal(suite.T(), audit.Actor, record.Actor)
+		assert.Equal(suite.T(), cache.GcpSaKey.String(), record.Type)
+		assert.Equal(suite.T(), sa1.Email, record.Identifier)
+		assert.Equal(suite.T(), sa1.Project, record.Scope)
+		suite.assertNow(record.Timestamp)
+		records = append(records, audit.Record{
+			Operation:   record.Operation,
+			KeyID:       record.KeyID,
+			Destination: record.Destination,
+			Resource:    record.Resource,
+		})
+	}
+
+	// the seeded current key has never been synced, so it's synced before the old key is disabled and the
+	// new key is issued and synced in its place
+	assert.Equal(suite.T(), []audit.Record{
+		{Operation: audit.Sync, KeyID: sa1key2.id, Destination: "k8s", Resource: "ns-1/s1-gsk"},
+		{Operation: audit.Disable, KeyID: sa1key1.id},
+		{Operation: audit.Create, KeyID: sa1key3.id},
+		{Operation: audit.Sync, KeyID: sa1key3.id, Destination: "k8s", Resource: "ns-1/s1-gsk"},
+	}, records)
+}
+
+// failingSaveCache is a cache whose Save always fails
+type failingSaveCache struct {
+	cache.Cache
+}
+
+func (c failingSaveCache) Save(_ *cache.Entry) error {
+	return fmt.Errorf("cache unavailable")
+}
+
+func (suite *YaleSuite) TestYaleWritesAuditLogEvenIfCacheSaveFails() {
+	var buf bytes.Buffer
+	auditLog := audit.New(&buf, "")
+
+	_keyops := make(map[string]keyops.KeyOps)
+	_keyops[gcpKeyops] = suite.keyops
+	_keyops[azureKeyops] = suite.keyops
+	// overwrite default yale instance with one whose cache saves fail after keys are created, disabled, or deleted
+	suite.yale = newYaleFromComponents(
+		Options{
+			CacheNamespace: cache.DefaultCacheNamespace,
+			RotateWindow:   suite.yale.options.RotateWindow,
+			AuditLog:       auditLog,
+		},
+		failingSaveCache{suite.cache},
+		suite.resourcemapper,
+		suite.crd,
+		map[string]authmetrics.AuthMetrics{gcpKeyops: suite.authmetrics},
+		_keyops,
+		keysync.New(suite.k8s, suite.vaultServer.NewClient(), suite.gsmServer.NewClient(), nil, nil, suite.cache),
+		notify.NewComposite(),
+	)
+
+	suite.seedGsks(gsk1, gsk2, gsk3)
+	suite.seedAzureClientSecrets()
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa1,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa1key3.id,
+			JSON:      sa1key3.json(),
+			CreatedAt: now,
+		},
+		RotatedKeys: map[string]time.Time{
+			sa1key2.id: eightDaysAgo,
+		},
+	})
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa2,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa2key2.id,
+			JSON:      sa2key2.json(),
+			CreatedAt: now,
+		},
+		DisabledKeys: map[string]time.Time{
+			sa2key1.id: eightDaysAgo,
 		},
 	})
 
-	suite.expectLastAuthTime(sa1key2, eightDaysAgo)
+	suite.expectLastAuthTime(sa1key2, fourDaysAgo)
 	suite.expectDisableKey(sa1key2)
-	suite.expectDeleteKey(sa1key3)
+	suite.expectDeleteKey(sa2key1)
+	// sa3 has no cache entry, so a key is issued for it
+	suite.expectCreateKey(sa3key1)
+
+	require.Error(suite.T(), suite.yale.Run())
+
+	require.NoError(suite.T(), audit.Verify(bytes.NewReader(buf.Bytes())))
+	var records []audit.Record
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record audit.Record
+		require.NoError(suite.T(), json.Unmarshal([]byte(line), &record))
+		records = append(records, audit.Record{
+			Operation:  record.Operation,
+			Identifier: record.Identifier,
+			KeyID:      record.KeyID,
+		})
+	}
+	assert.ElementsMatch(suite.T(), []audit.Record{
+		{Operation: audit.Disable, Identifier: sa1.Email, KeyID: sa1key2.id},
+		{Operation: audit.Delete, Identifier: sa2.Email, KeyID: sa2key1.id},
+		{Operation: audit.Create, Identifier: sa3.Email, KeyID: sa3key1.id},
+	}, records)
+}
 
-	suite.expectDisableKey(clientSecret1Key2)
-	suite.expectDeleteKey(clientSecret1Key3)
+func (suite *YaleSuite) TestYaleRecordsKeyHistory() {
+	suite.seedGsks(gsk1)
+	suite.seedAzureClientSecrets()
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa1,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa1key3.id,
+			JSON:      sa1key3.json(),
+			CreatedAt: now,
+		},
+		RotatedKeys: map[string]time.Time{
+			sa1key2.id: eightDaysAgo,
+		},
+		DisabledKeys: map[string]time.Time{
+			sa1key1.id: eightDaysAgo,
+		},
+	})
+
+	suite.expectLastAuthTime(sa1key2, fourDaysAgo)
+	suite.expectDisableKey(sa1key2)
+	suite.expectDeleteKey(sa1key1)
+
+	require.NoError(suite.T(), suite.yale.Run())
+
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+
+	events := make(map[string]cache.KeyEventType)
+	for _, event := range entry.History {
+		events[event.KeyID] = event.Type
+		suite.assertNow(event.Timestamp)
+	}
+	assert.Equal(suite.T(), map[string]cache.KeyEventType{
+		sa1key2.id: cache.KeyDisabled,
+		sa1key1.id: cache.KeyDeleted,
+	}, events)
+}
+
+func (suite *YaleSuite) TestYaleRecordsKeyIssuedInHistory() {
+	suite.seedGsks(gsk1)
+	suite.seedAzureClientSecrets()
+
+	suite.expectCreateKey(sa1key1)
+
+	require.NoError(suite.T(), suite.yale.Run())
+
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), entry.History, 1)
+	assert.Equal(suite.T(), sa1key1.id, entry.History[0].KeyID)
+	assert.Equal(suite.T(), cache.KeyIssued, entry.History[0].Type)
+	suite.assertNow(entry.History[0].Timestamp)
+}
+
+func (suite *YaleSuite) TestYaleDoesNotDisableOrDeleteOldKeysOutsideDisableWindow() {
+	suite.yale.options.DisableWindow = RotateWindow{
+		Enabled: true,
+		Windows: []TimeWindow{{
+			StartTime: currentTime().Add(1 * time.Hour),
+			EndTime:   currentTime().Add(2 * time.Hour),
+		}},
+	}
+
+	suite.seedGsks(gsk1)
+	suite.seedAzureClientSecrets()
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa1,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa1key3.id,
+			JSON:      sa1key3.json(),
+			CreatedAt: now,
+		},
+		RotatedKeys: map[string]time.Time{
+			sa1key2.id: eightDaysAgo,
+		},
+		DisabledKeys: map[string]time.Time{
+			sa1key1.id: eightDaysAgo,
+		},
+	})
+
+	// Note: we do NOT expect any disable or delete operations, because we are outside the disable window
+
+	require.NoError(suite.T(), suite.yale.Run())
+
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{sa1key2.id}, sortedKeyIDs(entry.RotatedKeys))
+	assert.Equal(suite.T(), []string{sa1key1.id}, sortedKeyIDs(entry.DisabledKeys))
+}
+
+func (suite *YaleSuite) TestYaleDoesNotRotateDisableOrDeleteKeysThatAreNotOldEnough() {
+	suite.seedGsks(gsk1)
+	suite.seedAzureClientSecrets(acs1)
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa1,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa1key3.id,
+			JSON:      sa1key3.json(),
+			CreatedAt: now,
+		},
+		RotatedKeys: map[string]time.Time{
+			sa1key2.id: now,
+		},
+		DisabledKeys: map[string]time.Time{
+			sa1key1.id: now,
+		},
+	})
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: clientSecret1,
+		Type:       cache.AzureClientSecret,
+		CurrentKey: cache.CurrentKey{
+			ID:        clientSecret1Key3.id,
+			JSON:      clientSecret1Key3.json(),
+			CreatedAt: now,
+		},
+		RotatedKeys: map[string]time.Time{
+			clientSecret1Key2.id: now,
+		},
+		DisabledKeys: map[string]time.Time{
+			clientSecret1Key1.id: now,
+		},
+	})
 
 	require.NoError(suite.T(), suite.yale.Run())
 
@@ -937,194 +1725,1230 @@ func (suite *YaleSuite) TestYaleCorrectlyProcessesCacheEntryWithNoMatchingYaleCR
 	entryAcs, err := suite.cache.GetOrCreate(clientSecret1)
 	require.NoError(suite.T(), err)
 
-	// make sure no replacement key was issued
-	assert.Empty(suite.T(), entry.CurrentKey)
-	assert.Empty(suite.T(), entryAcs.CurrentKey)
+	// make sure the cache entry's rotated section still includes key2
+	t, exists := entry.RotatedKeys[sa1key2.id]
+	assert.True(suite.T(), exists)
+	suite.assertNow(t)
 
-	// make sure the old current key was rotated
-	assert.Len(suite.T(), entry.RotatedKeys, 1)
-	t, exists := entry.RotatedKeys[sa1key1.id]
+	t, exists = entryAcs.RotatedKeys[clientSecret1Key2.id]
 	assert.True(suite.T(), exists)
 	suite.assertNow(t)
 
-	assert.Len(suite.T(), entryAcs.RotatedKeys, 1)
-	t, exists = entryAcs.RotatedKeys[clientSecret1Key1.id]
+	// make sure the cache entry's disabled section still includes key1
+	t, exists = entry.DisabledKeys[sa1key1.id]
 	assert.True(suite.T(), exists)
 	suite.assertNow(t)
 
-	// make sure the old rotated key was disabled
-	assert.Len(suite.T(), entry.DisabledKeys, 1)
-	t, exists = entry.DisabledKeys[sa1key2.id]
+	t, exists = entryAcs.DisabledKeys[clientSecret1Key1.id]
 	assert.True(suite.T(), exists)
 	suite.assertNow(t)
+}
+
+func (suite *YaleSuite) TestYaleDeletesOldKeys() {
+	suite.seedGsks(gsk1)
+	suite.seedAzureClientSecrets(acs1)
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa1,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa1key2.id,
+			JSON:      sa1key2.json(),
+			CreatedAt: now,
+		},
+		DisabledKeys: map[string]time.Time{
+			sa1key1.id: eightDaysAgo,
+		},
+	})
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: clientSecret1,
+		Type:       cache.AzureClientSecret,
+		CurrentKey: cache.CurrentKey{
+			ID:        clientSecret1Key2.id,
+			JSON:      clientSecret1Key2.json(),
+			CreatedAt: now,
+		},
+		DisabledKeys: map[string]time.Time{
+			clientSecret1Key1.id: eightDaysAgo,
+		},
+	})
+
+	suite.expectDeleteKey(sa1key1)
+	suite.expectDeleteKey(clientSecret1Key1)
+
+	require.NoError(suite.T(), suite.yale.Run())
+
+	// validate cache entry
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+
+	entryAcs, err := suite.cache.GetOrCreate(clientSecret1)
+	require.NoError(suite.T(), err)
+
+	// make sure the cache entry's disabled section is empty
+	assert.Empty(suite.T(), entry.DisabledKeys)
+	assert.Empty(suite.T(), entryAcs.DisabledKeys)
+}
+
+func (suite *YaleSuite) TestYaleCorrectlyProcessesCacheEntryWithNoMatchingYaleCRDs() {
+	suite.seedGsks()
+	suite.seedAzureClientSecrets()
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa1,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa1key1.id,
+			JSON:      sa1key1.json(),
+			CreatedAt: eightDaysAgo,
+		},
+		RotatedKeys: map[string]time.Time{
+			sa1key2.id: eightDaysAgo,
+		},
+		DisabledKeys: map[string]time.Time{
+			sa1key3.id: eightDaysAgo,
+		},
+	})
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: clientSecret1,
+		Type:       cache.AzureClientSecret,
+		CurrentKey: cache.CurrentKey{
+			ID:        clientSecret1Key1.id,
+			JSON:      clientSecret1Key1.json(),
+			CreatedAt: eightDaysAgo,
+		},
+		RotatedKeys: map[string]time.Time{
+			clientSecret1Key2.id: eightDaysAgo,
+		},
+		DisabledKeys: map[string]time.Time{
+			clientSecret1Key3.id: eightDaysAgo,
+		},
+	})
+
+	suite.expectLastAuthTime(sa1key2, eightDaysAgo)
+	suite.expectDisableKey(sa1key2)
+	suite.expectDeleteKey(sa1key3)
+
+	suite.expectDisableKey(clientSecret1Key2)
+	suite.expectDeleteKey(clientSecret1Key3)
+
+	require.NoError(suite.T(), suite.yale.Run())
+
+	// validate cache entry
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+
+	entryAcs, err := suite.cache.GetOrCreate(clientSecret1)
+	require.NoError(suite.T(), err)
+
+	// make sure no replacement key was issued
+	assert.Empty(suite.T(), entry.CurrentKey)
+	assert.Empty(suite.T(), entryAcs.CurrentKey)
+
+	// make sure the old current key was rotated
+	assert.Len(suite.T(), entry.RotatedKeys, 1)
+	t, exists := entry.RotatedKeys[sa1key1.id]
+	assert.True(suite.T(), exists)
+	suite.assertNow(t)
+
+	assert.Len(suite.T(), entryAcs.RotatedKeys, 1)
+	t, exists = entryAcs.RotatedKeys[clientSecret1Key1.id]
+	assert.True(suite.T(), exists)
+	suite.assertNow(t)
+
+	// make sure the old rotated key was disabled
+	assert.Len(suite.T(), entry.DisabledKeys, 1)
+	t, exists = entry.DisabledKeys[sa1key2.id]
+	assert.True(suite.T(), exists)
+	suite.assertNow(t)
+
+	assert.Len(suite.T(), entryAcs.DisabledKeys, 1)
+	t, exists = entryAcs.DisabledKeys[clientSecret1Key2.id]
+	assert.True(suite.T(), exists)
+	suite.assertNow(t)
+}
+
+func (suite *YaleSuite) TestYaleCorrectlyRetiresCacheEntryWithNoMatchingGcpSaKeys() {
+	suite.seedGsks()
+	suite.seedAzureClientSecrets()
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier:  sa1,
+		Type:        cache.GcpSaKey,
+		CurrentKey:  cache.CurrentKey{},
+		RotatedKeys: map[string]time.Time{},
+		DisabledKeys: map[string]time.Time{
+			sa1key1.id: eightDaysAgo,
+		},
+	})
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier:  clientSecret1,
+		Type:        cache.AzureClientSecret,
+		CurrentKey:  cache.CurrentKey{},
+		RotatedKeys: map[string]time.Time{},
+		DisabledKeys: map[string]time.Time{
+			clientSecret1Key1.id: eightDaysAgo,
+		},
+	})
+
+	suite.expectDeleteKey(sa1key1)
+	suite.expectDeleteKey(clientSecret1Key1)
+
+	require.NoError(suite.T(), suite.yale.Run())
+
+	// ensure cache entry was removed from the cluster
+	entries, err := suite.cache.List()
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), entries)
+}
+
+func (suite *YaleSuite) TestYaleDeletesOrphanedSecretsIfEnabled() {
+	suite.seedGsks()
+	suite.seedAzureClientSecrets()
+	suite.seedOrphanedSecret()
+
+	suite.yale.options.DeleteOrphanedSecrets = true
+
+	require.NoError(suite.T(), suite.yale.Run())
+
+	_, err := suite.k8s.CoreV1().Secrets("ns-1").Get(context.Background(), "s1-secret", metav1.GetOptions{})
+	assert.True(suite.T(), errors.IsNotFound(err), "orphaned secret should have been deleted")
+
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), entry.SyncedSecrets)
+}
+
+func (suite *YaleSuite) TestYaleDoesNotDeleteSecretsOfResourcesWithInvalidSpecs() {
+	// gsk1 still exists, but is dropped from the run because its spec is invalid
+	invalid := gsk1
+	invalid.Spec.KeyRotation.SafeToDisableBufferDays = -1
+	suite.seedGsks(invalid)
+	suite.seedAzureClientSecrets()
+	suite.seedOrphanedSecret()
+
+	suite.yale.options.DeleteOrphanedSecrets = true
+
+	require.NoError(suite.T(), suite.yale.Run())
+
+	suite.assertSecretHasData("ns-1", "s1-secret", map[string]string{
+		"key.json": sa1key1.json(),
+	})
+
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), sa1key1.id, entry.CurrentKey.ID)
+	assert.Equal(suite.T(), map[string][]string{"ns-1/s1-gsk": {"ns-1/s1-secret"}}, entry.SyncedSecrets)
+}
+
+func (suite *YaleSuite) TestYaleDoesNotDeleteOrphanedSecretsByDefault() {
+	suite.seedGsks()
+	suite.seedAzureClientSecrets()
+	suite.seedOrphanedSecret()
+
+	require.NoError(suite.T(), suite.yale.Run())
+
+	suite.assertSecretHasData("ns-1", "s1-secret", map[string]string{
+		"key.json": sa1key1.json(),
+	})
+}
+
+func (suite *YaleSuite) TestYaleAggregatesAndReportsErrors() {
+	_keyops := make(map[string]keyops.KeyOps)
+	_keyops[gcpKeyops] = suite.keyops
+	_keyops[azureKeyops] = suite.keyops
+	// overwrite default yale instance with one that notifies two mock backends (eg. Slack and Teams), to make
+	// sure errors are reported to, and throttled for, every backend
+	_slack := slackmocks.NewSlackNotifier(suite.T())
+	_teams := slackmocks.NewSlackNotifier(suite.T())
+	suite.yale = newYaleFromComponents(
+		Options{
+			CacheNamespace:     cache.DefaultCacheNamespace,
+			IgnoreUsageMetrics: false,
+		},
+		suite.cache,
+		suite.resourcemapper,
+		suite.crd,
+		map[string]authmetrics.AuthMetrics{gcpKeyops: suite.authmetrics},
+		_keyops,
+		suite.keysync,
+		notify.NewComposite(_slack, _teams),
+	)
+	suite.seedGsks(gsk1, gsk2, gsk3)
+	suite.seedAzureClientSecrets(acs1, acs2, acs3)
+
+	suite.expectCreateKeyReturnsErr(sa1key1, fmt.Errorf("uh-oh"))
+	suite.expectCreateKey(sa2key1)
+	suite.expectCreateKeyReturnsErr(sa3key1, fmt.Errorf("oh noes"))
+
+	suite.expectCreateKeyReturnsErr(clientSecret1Key1, fmt.Errorf("uh-oh"))
+	suite.expectCreateKey(clientSecret2Key1)
+	suite.expectCreateKeyReturnsErr(clientSecret3Key1, fmt.Errorf("oh noes"))
+
+	lastNotification := now.Add(-20 * time.Minute)
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier:   sa3,
+		Type:         cache.GcpSaKey,
+		CurrentKey:   cache.CurrentKey{},
+		RotatedKeys:  map[string]time.Time{},
+		DisabledKeys: map[string]time.Time{},
+		LastError: cache.LastError{
+			Message:            "error issuing new secret for s3@p.com: oh noes",
+			Timestamp:          lastNotification,
+			LastNotificationAt: lastNotification,
+		},
+	})
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier:   clientSecret3,
+		Type:         cache.AzureClientSecret,
+		CurrentKey:   cache.CurrentKey{},
+		RotatedKeys:  map[string]time.Time{},
+		DisabledKeys: map[string]time.Time{},
+		LastError: cache.LastError{
+			Message:            "error issuing new secret for test-app-id-3: oh noes",
+			Timestamp:          lastNotification,
+			LastNotificationAt: lastNotification,
+		},
+	})
+
+	for _, notifier := range []*slackmocks.SlackNotifier{_slack, _teams} {
+		// expect that a key issue notification is sent for sa2key1
+		notifier.EXPECT().KeyIssued(mock.Anything, sa2key1.id).Return(nil)
+		// set expectation that yale notifies for the s1 error (but not s3)
+		notifier.EXPECT().Error(mock.Anything, mock.MatchedBy(func(s string) bool {
+			return strings.HasSuffix(s, "error issuing new secret for s1@p.com: uh-oh")
+		})).Return(nil)
+
+		notifier.EXPECT().KeyIssued(mock.Anything, clientSecret2Key1.id).Return(nil)
+		notifier.EXPECT().Error(mock.Anything, mock.MatchedBy(func(s string) bool {
+			return strings.HasSuffix(s, "error issuing new secret for test-app-id-1: uh-oh")
+		})).Return(nil)
+	}
+
+	err := suite.yale.Run()
+	require.Error(suite.T(), err)
+	assert.ErrorContains(suite.T(), err, "s1@p.com: uh-oh")
+	assert.ErrorContains(suite.T(), err, "s3@p.com: oh noes")
+	assert.ErrorContains(suite.T(), err, "test-app-id-1: uh-oh")
+	assert.ErrorContains(suite.T(), err, "test-app-id-3: oh noes")
+
+	// make sure the cache contains the new keys for sa2
+	entry, err := suite.cache.GetOrCreate(sa2)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), sa2key1.id, entry.CurrentKey.ID)
+	assert.Equal(suite.T(), sa2key1.json(), entry.CurrentKey.JSON)
+	suite.assertNow(entry.CurrentKey.CreatedAt)
+	assert.Empty(suite.T(), entry.LastError)
+
+	entryAcs, err := suite.cache.GetOrCreate(clientSecret2)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), clientSecret2Key1.id, entryAcs.CurrentKey.ID)
+	assert.Equal(suite.T(), clientSecret2Key1.json(), entryAcs.CurrentKey.JSON)
+	suite.assertNow(entryAcs.CurrentKey.CreatedAt)
+	assert.Empty(suite.T(), entryAcs.LastError)
+
+	// make sure the new key were replicated to the secret in the gsk spec
+	suite.assertSecretHasData("ns-2", "s2-secret", map[string]string{
+		"key.pem":  sa2key1.pem,
+		"key.json": sa2key1.json(),
+	})
+
+	suite.assertSecretHasData("ns-2", "clientsecret2-secret", map[string]string{
+		"clientsecret-key": clientSecret2Key1.json(),
+	})
+
+	// make sure the cache entries for s1 and s3 have error information
+	entry, err = suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "GcpSaKey s1@p.com: error issuing new secret: error issuing new secret for s1@p.com: uh-oh", entry.LastError.Message)
+	assert.True(suite.T(), entry.LastSuccessfulRun.IsZero())
+	suite.assertNow(entry.LastError.Timestamp)
+	suite.assertNow(entry.LastError.LastNotificationAt)
+
+	entryAcs, err = suite.cache.GetOrCreate(clientSecret1)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "AzureClientSecret test-app-id-1: error issuing new secret: error issuing new secret for test-app-id-1: uh-oh", entryAcs.LastError.Message)
+	suite.assertNow(entryAcs.LastError.Timestamp)
+	suite.assertNow(entryAcs.LastError.LastNotificationAt)
+
+	// s3 should NOT have sent an error, because it was already sent recently
+	entry, err = suite.cache.GetOrCreate(sa3)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "GcpSaKey s3@p.com: error issuing new secret: error issuing new secret for s3@p.com: oh noes", entry.LastError.Message)
+	suite.assertNow(entry.LastError.Timestamp)
+	assert.Equal(suite.T(), lastNotification, entry.LastError.LastNotificationAt)
+
+	entryAcs, err = suite.cache.GetOrCreate(clientSecret3)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "AzureClientSecret test-app-id-3: error issuing new secret: error issuing new secret for test-app-id-3: oh noes", entryAcs.LastError.Message)
+	suite.assertNow(entryAcs.LastError.Timestamp)
+	assert.Equal(suite.T(), lastNotification, entryAcs.LastError.LastNotificationAt)
+
+}
+
+func (suite *YaleSuite) TestYaleSendsSingleErrorDigestPerRun() {
+	_keyops := make(map[string]keyops.KeyOps)
+	_keyops[gcpKeyops] = suite.keyops
+	_keyops[azureKeyops] = suite.keyops
+	_slack := slackmocks.NewSlackNotifier(suite.T())
+	suite.yale = newYaleFromComponents(
+		Options{
+			CacheNamespace: cache.DefaultCacheNamespace,
+			ErrorDigest:    true,
+		},
+		suite.cache,
+		suite.resourcemapper,
+		suite.crd,
+		map[string]authmetrics.AuthMetrics{gcpKeyops: suite.authmetrics},
+		_keyops,
+		suite.keysync,
+		_slack,
+	)
+	suite.seedGsks(gsk1, gsk2, gsk3)
+	suite.seedAzureClientSecrets(acs1)
+
+	suite.expectCreateKeyReturnsErr(sa1key1, fmt.Errorf("uh-oh"))
+	suite.expectCreateKeyReturnsErr(sa2key1, fmt.Errorf("oh noes"))
+	suite.expectCreateKeyReturnsErr(sa3key1, fmt.Errorf("yikes"))
+	suite.expectCreateKeyReturnsErr(clientSecret1Key1, fmt.Errorf("uh-oh"))
+
+	// the error for clientSecret1 was reported recently, so it should be left out of the digest
+	lastNotification := now.Add(-20 * time.Minute)
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier:   clientSecret1,
+		Type:         cache.AzureClientSecret,
+		RotatedKeys:  map[string]time.Time{},
+		DisabledKeys: map[string]time.Time{},
+		LastError: cache.LastError{
+			Message:            "error issuing new secret for test-app-id-1: uh-oh",
+			Timestamp:          lastNotification,
+			LastNotificationAt: lastNotification,
+		},
+	})
+
+	_slack.EXPECT().ErrorDigest(mock.MatchedBy(func(entries []*cache.Entry) bool {
+		var identifiers []string
+		for _, entry := range entries {
+			identifiers = append(identifiers, entry.Identify())
+		}
+		return assert.ElementsMatch(suite.T(), []string{sa1.Email, sa2.Email, sa3.Email}, identifiers)
+	})).Return(nil).Once()
+
+	err := suite.yale.Run()
+	require.Error(suite.T(), err)
+	assert.ErrorContains(suite.T(), err, "s1@p.com: uh-oh")
+	assert.ErrorContains(suite.T(), err, "s2@p.com: oh noes")
+	assert.ErrorContains(suite.T(), err, "s3@p.com: yikes")
+
+	for _, identifier := range []cache.GcpSaKeyEntryIdentifier{sa1, sa2, sa3} {
+		entry, err := suite.cache.GetOrCreate(identifier)
+		require.NoError(suite.T(), err)
+		suite.assertNow(entry.LastError.LastNotificationAt)
+	}
+
+	entry, err := suite.cache.GetOrCreate(clientSecret1)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), lastNotification, entry.LastError.LastNotificationAt)
+}
+
+func (suite *YaleSuite) TestYaleStopsSyncingToUnhealthyDestinationAndAlertsOnce() {
+	_keyops := make(map[string]keyops.KeyOps)
+	_keyops[gcpKeyops] = suite.keyops
+	_keyops[azureKeyops] = suite.keyops
+	_slack := slackmocks.NewSlackNotifier(suite.T())
+	// don't retry failed Vault writes, and stop writing to Vault after two failures
+	_keysync := keysync.New(suite.k8s, suite.vaultServer.NewClient(), suite.gsmServer.NewClient(), nil, nil, suite.cache, func(options *keysync.Options) {
+		options.VaultWriteMaxAttempts = 1
+		options.MaxDestinationFailures = 2
+	})
+	suite.yale = newYaleFromComponents(
+		Options{
+			CacheNamespace: cache.DefaultCacheNamespace,
+		},
+		suite.cache,
+		suite.resourcemapper,
+		suite.crd,
+		map[string]authmetrics.AuthMetrics{gcpKeyops: suite.authmetrics},
+		_keyops,
+		_keysync,
+		_slack,
+	)
+
+	var gsks []apiv1b1.GcpSaKey
+	for _, gsk := range []apiv1b1.GcpSaKey{gsk1, gsk2, gsk3} {
+		gsk.Spec.VaultReplications = []apiv1b1.VaultReplication{{
+			Path:   "secret/" + gsk.Name(),
+			Format: apiv1b1.JSON,
+		}}
+		gsks = append(gsks, gsk)
+	}
+	suite.seedGsks(gsks...)
+	suite.seedAzureClientSecrets()
+
+	suite.expectCreateKey(sa1key1)
+	suite.expectCreateKey(sa2key1)
+	suite.expectCreateKey(sa3key1)
+
+	// Vault is down for the whole run
+	suite.vaultServer.FailRequests(100, http.StatusServiceUnavailable)
+	vaultErrorsBefore := testutil.ToFloat64(metrics.SyncErrors.WithLabelValues(metrics.VaultDestination))
+
+	_slack.EXPECT().KeyIssued(mock.Anything, mock.Anything).Return(nil).Times(3)
+	// the two failed syncs are reported individually, but the skipped one is not
+	_slack.EXPECT().Error(mock.Anything, mock.Anything).Return(nil).Times(2)
+	_slack.EXPECT().DestinationUnhealthy(metrics.VaultDestination, 2).Return(nil).Once()
+
+	require.Error(suite.T(), suite.yale.Run())
+
+	// only two Vault writes should have been attempted
+	assert.Equal(suite.T(), vaultErrorsBefore+2, testutil.ToFloat64(metrics.SyncErrors.WithLabelValues(metrics.VaultDestination)))
+
+	// the skipped sync is still recorded as an error, and will be retried on the next run; only the K8s
+	// destinations are recorded as synced
+	var skipped int
+	for _, identifier := range []cache.GcpSaKeyEntryIdentifier{sa1, sa2, sa3} {
+		entry, err := suite.cache.GetOrCreate(identifier)
+		require.NoError(suite.T(), err)
+		assert.NotEmpty(suite.T(), entry.LastError.Message)
+		require.Len(suite.T(), entry.SyncStatus, 1)
+		for _, status := range entry.SyncStatus {
+			assert.True(suite.T(), strings.HasSuffix(status, ";synced=k8s,target_cluster"), "sync status %q should not record a Vault sync", status)
+		}
+		if strings.Contains(entry.LastError.Message, keysync.ErrDestinationUnhealthy.Error()) {
+			skipped++
+		}
+	}
+	assert.Equal(suite.T(), 1, skipped)
+}
+
+func (suite *YaleSuite) TestYaleAlertsOncePerRepostIntervalWhenKeyExceedsMaxKeyAge() {
+	_keyops := make(map[string]keyops.KeyOps)
+	_keyops[gcpKeyops] = suite.keyops
+	_keyops[azureKeyops] = suite.keyops
+	_slack := slackmocks.NewSlackNotifier(suite.T())
+	suite.yale = newYaleFromComponents(
+		Options{
+			CacheNamespace: cache.DefaultCacheNamespace,
+		},
+		suite.cache,
+		suite.resourcemapper,
+		suite.crd,
+		map[string]authmetrics.AuthMetrics{gcpKeyops: suite.authmetrics},
+		_keyops,
+		suite.keysync,
+		_slack,
+	)
+
+	// rotation is paused, so the current key keeps aging past the max key age
+	gsk := gsk1
+	gsk.Spec.KeyRotation.Paused = true
+	gsk.Spec.KeyRotation.MaxKeyAge = 7
+	suite.seedGsks(gsk)
+	suite.seedAzureClientSecrets()
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa1,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa1key1.id,
+			JSON:      sa1key1.json(),
+			CreatedAt: eightDaysAgo,
+		},
+	})
+
+	_slack.EXPECT().Error(mock.Anything, mock.MatchedBy(func(message string) bool {
+		return strings.Contains(message, "has exceeded the max key age of 168h0m0s without being rotated")
+	})).Return(nil).Once()
+
+	// the alert is sent on the first run, but not repeated within the repost interval
+	for i := 0; i < 2; i++ {
+		err := suite.yale.Run()
+		require.Error(suite.T(), err)
+		assert.ErrorContains(suite.T(), err, "has exceeded the max key age")
+	}
+
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), sa1key1.id, entry.CurrentKey.ID)
+	suite.assertNow(entry.LastError.LastNotificationAt)
+	// a run that fires the alert isn't successful
+	assert.True(suite.T(), entry.LastSuccessfulRun.IsZero())
+
+	// once the repost interval has passed, the alert is sent again
+	entry.LastError.LastNotificationAt = now.Add(-5 * time.Hour)
+	require.NoError(suite.T(), suite.cache.Save(entry))
+
+	_slack.EXPECT().Error(mock.Anything, mock.Anything).Return(nil).Once()
+	require.Error(suite.T(), suite.yale.Run())
+}
+
+func (suite *YaleSuite) TestYaleAlertsOnceWhenRotatedKeysAccumulate() {
+	_keyops := make(map[string]keyops.KeyOps)
+	_keyops[gcpKeyops] = suite.keyops
+	_keyops[azureKeyops] = suite.keyops
+	_slack := slackmocks.NewSlackNotifier(suite.T())
+	suite.yale = newYaleFromComponents(
+		Options{
+			CacheNamespace: cache.DefaultCacheNamespace,
+			MaxRotatedKeys: 3,
+		},
+		suite.cache,
+		suite.resourcemapper,
+		suite.crd,
+		map[string]authmetrics.AuthMetrics{gcpKeyops: suite.authmetrics},
+		_keyops,
+		suite.keysync,
+		_slack,
+	)
+
+	suite.seedGsks(gsk1)
+	suite.seedAzureClientSecrets()
+
+	// a consumer is stuck on an old key, so every key rotated since is still in use as well
+	rotatedKeys := make(map[string]time.Time)
+	for i := 1; i <= 4; i++ {
+		stuck := key{id: fmt.Sprintf("s1-stuck-key%d", i), sa: sa1}
+		rotatedKeys[stuck.id] = eightDaysAgo
+		suite.expectLastAuthTime(stuck, fourHoursAgo)
+	}
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa1,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa1key1.id,
+			JSON:      sa1key1.json(),
+			CreatedAt: now,
+		},
+		RotatedKeys: rotatedKeys,
+	})
+
+	alertsBefore := testutil.ToFloat64(metrics.RotatedKeysAccumulating.WithLabelValues(cache.GcpSaKey.String()))
+
+	_slack.EXPECT().Error(mock.Anything, mock.MatchedBy(func(message string) bool {
+		return strings.Contains(message, "please find out what's still using this key")
+	})).Return(nil).Once()
+	_slack.EXPECT().RotatedKeysAccumulating(mock.Anything, 4).Return(nil).Once()
+
+	// the alert is sent on the first run, but not repeated on the next
+	for i := 0; i < 2; i++ {
+		require.Error(suite.T(), suite.yale.Run())
+	}
+
+	assert.Equal(suite.T(), alertsBefore+1, testutil.ToFloat64(metrics.RotatedKeysAccumulating.WithLabelValues(cache.GcpSaKey.String())))
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.Len(suite.T(), entry.RotatedKeys, 4)
+	suite.assertNow(entry.RotatedKeysAlertAt)
+
+	// once the backlog clears, the alert is reset so that it is sent again if it recurs
+	delete(entry.RotatedKeys, "s1-stuck-key1")
+	delete(entry.RotatedKeys, "s1-stuck-key2")
+	require.NoError(suite.T(), suite.cache.Save(entry))
+
+	require.Error(suite.T(), suite.yale.Run())
+
+	entry, err = suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), entry.RotatedKeysAlertAt.IsZero())
+}
+
+func (suite *YaleSuite) TestYaleDoesNotAlertIfKeyIsRotatedBeforeExceedingMaxKeyAge() {
+	gsk := gsk1
+	gsk.Spec.KeyRotation.MaxKeyAge = 7
+	suite.seedGsks(gsk)
+	suite.seedAzureClientSecrets()
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa1,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa1key1.id,
+			JSON:      sa1key1.json(),
+			CreatedAt: eightDaysAgo,
+		},
+	})
+
+	suite.expectCreateKey(sa1key2)
+
+	require.NoError(suite.T(), suite.yale.Run())
+
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), sa1key2.id, entry.CurrentKey.ID)
+	assert.Empty(suite.T(), entry.LastError.Message)
+}
+
+func (suite *YaleSuite) TestYaleWarnsOnceWhenKeyIsApproachingRotation() {
+	_keyops := make(map[string]keyops.KeyOps)
+	_keyops[gcpKeyops] = suite.keyops
+	_keyops[azureKeyops] = suite.keyops
+	_slack := slackmocks.NewSlackNotifier(suite.T())
+	suite.yale = newYaleFromComponents(
+		Options{
+			CacheNamespace:      cache.DefaultCacheNamespace,
+			RotationWarningLead: 24 * time.Hour,
+		},
+		suite.cache,
+		suite.resourcemapper,
+		suite.crd,
+		map[string]authmetrics.AuthMetrics{gcpKeyops: suite.authmetrics},
+		_keyops,
+		suite.keysync,
+		_slack,
+	)
+	suite.seedGsks(gsk1, gsk2)
+	suite.seedAzureClientSecrets()
+
+	// gsk1 rotates after 7 days, so sa1's key is within 24 hours of rotation; sa2's key is not
+	sa1CreatedAt := now.Add(-7 * 24 * time.Hour).Add(12 * time.Hour)
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa1,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa1key1.id,
+			JSON:      sa1key1.json(),
+			CreatedAt: sa1CreatedAt,
+		},
+	}, &cache.Entry{
+		Identifier: sa2,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa2key1.id,
+			JSON:      sa2key1.json(),
+			CreatedAt: now.Add(-5 * 24 * time.Hour),
+		},
+	})
+
+	_slack.EXPECT().KeyApproachingRotation(mock.Anything, sa1key1.id, sa1CreatedAt.Add(7*24*time.Hour)).Return(nil).Once()
+
+	require.NoError(suite.T(), suite.yale.Run())
+
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	suite.assertNow(entry.RotationWarningAt)
+
+	entry, err = suite.cache.GetOrCreate(sa2)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), entry.RotationWarningAt.IsZero())
+
+	// the second run should not warn about sa1's key again
+	require.NoError(suite.T(), suite.yale.Run())
+}
+
+func (suite *YaleSuite) TestYaleRefusesToRotateIfTooManyKeysAreTracked() {
+	suite.seedGsks(gsk1)
+	suite.seedAzureClientSecrets()
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa1,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa1key3.id,
+			JSON:      sa1key3.json(),
+			CreatedAt: eightDaysAgo,
+		},
+		RotatedKeys: map[string]time.Time{
+			sa1key2.id: fourHoursAgo,
+		},
+		DisabledKeys: map[string]time.Time{
+			sa1key1.id: fourHoursAgo,
+		},
+	})
+
+	suite.yale.options.MaxTrackedKeys = 2
+
+	err := suite.yale.Run()
+	require.Error(suite.T(), err)
+	assert.ErrorContains(suite.T(), err, "already tracking 2 rotated and disabled keys (limit is 2)")
+
+	// make sure the current key was not rotated, and the error was recorded
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), sa1key3.id, entry.CurrentKey.ID)
+	assert.Len(suite.T(), entry.RotatedKeys, 1)
+	assert.Contains(suite.T(), entry.LastError.Message, "refusing to rotate current key")
+	suite.assertNow(entry.LastError.Timestamp)
+}
+
+func (suite *YaleSuite) TestRotateAllRotatesKeysOlderThanCutoff() {
+	suite.seedGsks(gsk1, gsk2)
+	suite.seedAzureClientSecrets()
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa1,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa1key1.id,
+			JSON:      sa1key1.json(),
+			CreatedAt: fourDaysAgo,
+		},
+	})
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa2,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa2key1.id,
+			JSON:      sa2key1.json(),
+			CreatedAt: fourHoursAgo,
+		},
+	})
+
+	// sa1's key is not old enough to be rotated by the gsk's own thresholds, but is older than the cutoff
+	suite.expectCreateKey(sa1key2)
+
+	require.NoError(suite.T(), suite.yale.RotateAll(RotateAllOptions{OlderThan: 3 * 24 * time.Hour}))
+
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), sa1key2.id, entry.CurrentKey.ID)
+	suite.assertNow(entry.CurrentKey.CreatedAt)
+	rotatedAt, exists := entry.RotatedKeys[sa1key1.id]
+	assert.True(suite.T(), exists)
+	suite.assertNow(rotatedAt)
+
+	suite.assertSecretHasData("ns-1", "s1-secret", map[string]string{
+		"key.pem":  sa1key2.pem,
+		"key.json": sa1key2.json(),
+	})
+
+	// sa2's key is newer than the cutoff and should be untouched
+	entry, err = suite.cache.GetOrCreate(sa2)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), sa2key1.id, entry.CurrentKey.ID)
+	assert.Empty(suite.T(), entry.RotatedKeys)
+}
+
+func (suite *YaleSuite) TestRotateAllSkipsDecommissionedIdentifiers() {
+	decommissioned := gsk1
+	decommissioned.Spec.KeyRotation.Decommission = true
+	suite.seedGsks(decommissioned, gsk2)
+	suite.seedAzureClientSecrets()
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa1,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa1key1.id,
+			JSON:      sa1key1.json(),
+			CreatedAt: fourDaysAgo,
+		},
+	})
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa2,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa2key1.id,
+			JSON:      sa2key1.json(),
+			CreatedAt: fourDaysAgo,
+		},
+	})
+
+	// sa1 is skipped, and doesn't count towards the rotation cap
+	suite.expectCreateKey(sa2key2)
+
+	require.NoError(suite.T(), suite.yale.RotateAll(RotateAllOptions{OlderThan: 3 * 24 * time.Hour, MaxRotations: 1}))
+
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), sa1key1.id, entry.CurrentKey.ID)
+	assert.Empty(suite.T(), entry.RotatedKeys)
+
+	entry, err = suite.cache.GetOrCreate(sa2)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), sa2key2.id, entry.CurrentKey.ID)
+}
+
+func (suite *YaleSuite) TestRotateAllDoesNotRotateKeysInDryRunMode() {
+	suite.seedGsks(gsk1)
+	suite.seedAzureClientSecrets()
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa1,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa1key1.id,
+			JSON:      sa1key1.json(),
+			CreatedAt: eightDaysAgo,
+		},
+	})
+
+	require.NoError(suite.T(), suite.yale.RotateAll(RotateAllOptions{OlderThan: 3 * 24 * time.Hour, DryRun: true}))
+
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), sa1key1.id, entry.CurrentKey.ID)
+	assert.Empty(suite.T(), entry.RotatedKeys)
+}
+
+func (suite *YaleSuite) TestRotateAllRespectsMaxRotations() {
+	suite.seedGsks(gsk1, gsk2)
+	suite.seedAzureClientSecrets()
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa1,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa1key1.id,
+			JSON:      sa1key1.json(),
+			CreatedAt: eightDaysAgo,
+		},
+	})
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa2,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa2key1.id,
+			JSON:      sa2key1.json(),
+			CreatedAt: eightDaysAgo,
+		},
+	})
+
+	// identifiers are processed in sorted order, so only sa1 should be rotated
+	suite.expectCreateKey(sa1key2)
+
+	require.NoError(suite.T(), suite.yale.RotateAll(RotateAllOptions{OlderThan: 3 * 24 * time.Hour, MaxRotations: 1}))
+
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), sa1key2.id, entry.CurrentKey.ID)
+
+	entry, err = suite.cache.GetOrCreate(sa2)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), sa2key1.id, entry.CurrentKey.ID)
+}
+
+func (suite *YaleSuite) TestRotateNowRotatesKeyOutsideRotationWindow() {
+	// now is outside the rotation window, and the key is too new to be rotated by the gsk's thresholds
+	suite.yale.options.RotateWindow = RotateWindow{
+		Enabled: true,
+		Windows: []TimeWindow{{StartTime: currentTime().Add(1 * time.Hour), EndTime: currentTime().Add(2 * time.Hour)}},
+	}
+	// the new key should be synced right away anyway
+	suite.yale.options.SyncDelayAfterIssue = time.Hour
+
+	suite.seedGsks(gsk1, gsk2)
+	suite.seedAzureClientSecrets()
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa1,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa1key1.id,
+			JSON:      sa1key1.json(),
+			CreatedAt: fourHoursAgo,
+		},
+	})
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa2,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa2key1.id,
+			JSON:      sa2key1.json(),
+			CreatedAt: fourHoursAgo,
+		},
+	})
+
+	suite.expectCreateKey(sa1key2)
+
+	require.NoError(suite.T(), suite.yale.RotateNow(sa1.Email, RotateNowOptions{}))
+
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), sa1key2.id, entry.CurrentKey.ID)
+	suite.assertNow(entry.CurrentKey.CreatedAt)
+	rotatedAt, exists := entry.RotatedKeys[sa1key1.id]
+	assert.True(suite.T(), exists)
+	suite.assertNow(rotatedAt)
+
+	suite.assertSecretHasData("ns-1", "s1-secret", map[string]string{
+		"key.pem":  sa1key2.pem,
+		"key.json": sa1key2.json(),
+	})
+
+	// other identifiers should be untouched
+	entry, err = suite.cache.GetOrCreate(sa2)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), sa2key1.id, entry.CurrentKey.ID)
+	assert.Empty(suite.T(), entry.RotatedKeys)
+}
+
+func (suite *YaleSuite) TestRotateNowIssuesNewKeyWithGcpSaKeyAlgorithm() {
+	gsk := gsk1
+	gsk.Spec.KeyAlgorithm = "KEY_ALG_RSA_1024"
+	suite.seedGsks(gsk)
+	suite.seedAzureClientSecrets()
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa1,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa1key1.id,
+			JSON:      sa1key1.json(),
+			CreatedAt: fourHoursAgo,
+		},
+	})
+
+	suite.keyops.EXPECT().Create(sa1key2.sa.Scope(), sa1key2.sa.Identify(), mock.Anything).
+		Run(func(_ string, _ string, options ...keyops.CreateOption) {
+			var opts keyops.CreateOptions
+			for _, option := range options {
+				option(&opts)
+			}
+			assert.Equal(suite.T(), "KEY_ALG_RSA_1024", opts.KeyAlgorithm)
+		}).
+		Return(sa1key2.keyopsFormat(), []byte(sa1key2.json()), nil)
+
+	require.NoError(suite.T(), suite.yale.RotateNow(sa1.Email, RotateNowOptions{}))
+
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), sa1key2.id, entry.CurrentKey.ID)
+}
+
+func (suite *YaleSuite) TestRotateNowRefusesDecommissionedIdentifierUnlessAllowed() {
+	gsk := gsk1
+	gsk.Spec.KeyRotation.Decommission = true
+	suite.seedGsks(gsk)
+	suite.seedAzureClientSecrets()
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa1,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa1key1.id,
+			JSON:      sa1key1.json(),
+			CreatedAt: fourHoursAgo,
+		},
+	})
+
+	err := suite.yale.RotateNow(sa1.Email, RotateNowOptions{})
+	assert.ErrorContains(suite.T(), err, "GcpSaKey s1@p.com is being decommissioned; refusing to issue new key")
+
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), sa1key1.id, entry.CurrentKey.ID)
+	assert.Empty(suite.T(), entry.RotatedKeys)
+
+	suite.expectCreateKey(sa1key2)
+
+	require.NoError(suite.T(), suite.yale.RotateNow(sa1.Email, RotateNowOptions{AllowDecommissioned: true}))
+
+	entry, err = suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), sa1key2.id, entry.CurrentKey.ID)
+}
+
+func (suite *YaleSuite) TestRotateNowReturnsErrorForUnknownIdentifier() {
+	suite.seedGsks(gsk1)
+	suite.seedAzureClientSecrets()
 
-	assert.Len(suite.T(), entryAcs.DisabledKeys, 1)
-	t, exists = entryAcs.DisabledKeys[clientSecret1Key2.id]
-	assert.True(suite.T(), exists)
-	suite.assertNow(t)
+	err := suite.yale.RotateNow("unknown@p.iam.gserviceaccount.com", RotateNowOptions{})
+	require.Error(suite.T(), err)
+	assert.ErrorContains(suite.T(), err, "no cache entry or resources found for unknown@p.iam.gserviceaccount.com")
 }
 
-func (suite *YaleSuite) TestYaleCorrectlyRetiresCacheEntryWithNoMatchingGcpSaKeys() {
-	suite.seedGsks()
+func (suite *YaleSuite) TestCompactCacheRemovesSyncStatusKeysForDeletedResources() {
+	suite.seedGsks(gsk1)
 	suite.seedAzureClientSecrets()
 
 	suite.seedCacheEntries(&cache.Entry{
-		Identifier:  sa1,
-		Type:        cache.GcpSaKey,
-		CurrentKey:  cache.CurrentKey{},
-		RotatedKeys: map[string]time.Time{},
-		DisabledKeys: map[string]time.Time{
-			sa1key1.id: eightDaysAgo,
+		Identifier: sa1,
+		Type:       cache.GcpSaKey,
+		SyncStatus: map[string]string{
+			"ns-1/s1-gsk":        "current-status",
+			"bee-old/s1-gsk":     "stale-status",
+			"bee-deleted/s1-gsk": "stale-status",
 		},
 	})
-
+	// sa3 has no GcpSaKeys left in the cluster, so all of its keys are stale
 	suite.seedCacheEntries(&cache.Entry{
-		Identifier:  clientSecret1,
-		Type:        cache.AzureClientSecret,
-		CurrentKey:  cache.CurrentKey{},
-		RotatedKeys: map[string]time.Time{},
-		DisabledKeys: map[string]time.Time{
-			clientSecret1Key1.id: eightDaysAgo,
+		Identifier: sa3,
+		Type:       cache.GcpSaKey,
+		SyncStatus: map[string]string{
+			"ns-3/s3-gsk": "stale-status",
 		},
 	})
 
-	suite.expectDeleteKey(sa1key1)
-	suite.expectDeleteKey(clientSecret1Key1)
+	removed, err := suite.yale.CompactCache(CompactCacheOptions{})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), map[string]int{sa1.Email: 2, sa3.Email: 1}, removed)
 
-	require.NoError(suite.T(), suite.yale.Run())
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), map[string]string{"ns-1/s1-gsk": "current-status"}, entry.SyncStatus)
 
-	// ensure cache entry was removed from the cluster
-	entries, err := suite.cache.List()
+	entry, err = suite.cache.GetOrCreate(sa3)
 	require.NoError(suite.T(), err)
-	assert.Empty(suite.T(), entries)
+	assert.Empty(suite.T(), entry.SyncStatus)
+
+	// compacting again should be a no-op
+	removed, err = suite.yale.CompactCache(CompactCacheOptions{})
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), removed)
 }
 
-func (suite *YaleSuite) TestYaleAggregatesAndReportsErrors() {
-	_keyops := make(map[string]keyops.KeyOps)
-	_keyops[gcpKeyops] = suite.keyops
-	_keyops[azureKeyops] = suite.keyops
-	// overwrite default yale instance with one where slack client is a mock
-	_slack := slackmocks.NewSlackNotifier(suite.T())
-	suite.yale = newYaleFromComponents(
-		Options{
-			CacheNamespace:     cache.DefaultCacheNamespace,
-			IgnoreUsageMetrics: false,
+func (suite *YaleSuite) TestCompactCacheDoesNotSaveInDryRunMode() {
+	suite.seedGsks(gsk1)
+	suite.seedAzureClientSecrets()
+
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa1,
+		Type:       cache.GcpSaKey,
+		SyncStatus: map[string]string{
+			"ns-1/s1-gsk":    "current-status",
+			"bee-old/s1-gsk": "stale-status",
 		},
-		suite.cache,
-		suite.resourcemapper,
-		suite.authmetrics,
-		_keyops,
-		suite.keysync,
-		_slack,
-	)
-	suite.seedGsks(gsk1, gsk2, gsk3)
-	suite.seedAzureClientSecrets(acs1, acs2, acs3)
+	})
 
-	suite.expectCreateKeyReturnsErr(sa1key1, fmt.Errorf("uh-oh"))
-	suite.expectCreateKey(sa2key1)
-	suite.expectCreateKeyReturnsErr(sa3key1, fmt.Errorf("oh noes"))
+	removed, err := suite.yale.CompactCache(CompactCacheOptions{DryRun: true})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), map[string]int{sa1.Email: 1}, removed)
 
-	suite.expectCreateKeyReturnsErr(clientSecret1Key1, fmt.Errorf("uh-oh"))
-	suite.expectCreateKey(clientSecret2Key1)
-	suite.expectCreateKeyReturnsErr(clientSecret3Key1, fmt.Errorf("oh noes"))
+	entry, err := suite.cache.GetOrCreate(sa1)
+	require.NoError(suite.T(), err)
+	assert.Len(suite.T(), entry.SyncStatus, 2)
+}
 
-	lastNotification := now.Add(-20 * time.Minute)
+func (suite *YaleSuite) TestValidateReportsProblemsWithoutModifyingAnything() {
+	// gsk1's namespace exists, but gsk3's does not
+	_, err := suite.k8s.CoreV1().Namespaces().Create(context.Background(), &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: gsk1.Namespace()},
+	}, metav1.CreateOptions{})
+	require.NoError(suite.T(), err)
+
+	suite.seedGsks(gsk1, gsk2, gsk3)
+	suite.seedAzureClientSecrets()
+
+	// sa2's cache entry is for a different project than gsk2
 	suite.seedCacheEntries(&cache.Entry{
-		Identifier:   sa3,
-		Type:         cache.GcpSaKey,
-		CurrentKey:   cache.CurrentKey{},
-		RotatedKeys:  map[string]time.Time{},
-		DisabledKeys: map[string]time.Time{},
-		LastError: cache.LastError{
-			Message:            "error issuing new secret for s3@p.com: oh noes",
-			Timestamp:          lastNotification,
-			LastNotificationAt: lastNotification,
+		Identifier: cache.GcpSaKeyEntryIdentifier{
+			Email:   sa2.Email,
+			Project: "other-project",
 		},
+		Type: cache.GcpSaKey,
 	})
-
+	// there is no GcpSaKey for this cache entry
 	suite.seedCacheEntries(&cache.Entry{
-		Identifier:   clientSecret3,
-		Type:         cache.AzureClientSecret,
-		CurrentKey:   cache.CurrentKey{},
-		RotatedKeys:  map[string]time.Time{},
-		DisabledKeys: map[string]time.Time{},
-		LastError: cache.LastError{
-			Message:            "error issuing new secret for test-app-id-3: oh noes",
-			Timestamp:          lastNotification,
-			LastNotificationAt: lastNotification,
+		Identifier: cache.GcpSaKeyEntryIdentifier{
+			Email:   "deleted@p.com",
+			Project: "p.com",
 		},
+		Type: cache.GcpSaKey,
 	})
 
-	// expect that a key issue notification is sent for sa2key1
-	_slack.EXPECT().KeyIssued(mock.Anything, sa2key1.id).Return(nil)
-	// set expectation that yale notifies for the s1 error (but not s3)
-	_slack.EXPECT().Error(mock.Anything, mock.MatchedBy(func(s string) bool {
-		return strings.HasSuffix(s, "error issuing new secret for s1@p.com: uh-oh")
-	})).Return(nil)
+	problems, err := suite.yale.Validate()
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), problems, 3)
+	assert.ErrorContains(suite.T(), problems[0], "invalid cluster resources for service account s2@p.com: project mismatch")
+	assert.EqualError(suite.T(), problems[1], "cache entry for GcpSaKey deleted@p.com does not match any GcpSaKey or AzureClientSecret resource")
+	assert.EqualError(suite.T(), problems[2], "ns-3/s3-gsk: namespace ns-3 for secret s3-secret does not exist")
 
-	_slack.EXPECT().KeyIssued(mock.Anything, clientSecret2Key1.id).Return(nil)
-	_slack.EXPECT().Error(mock.Anything, mock.MatchedBy(func(s string) bool {
-		return strings.HasSuffix(s, "error issuing new secret for test-app-id-1: uh-oh")
-	})).Return(nil)
+	// no cache entries should have been created, and no secrets written
+	entries, err := suite.cache.List()
+	require.NoError(suite.T(), err)
+	assert.Len(suite.T(), entries, 2)
+	_, err = suite.k8s.CoreV1().Secrets(gsk1.Namespace()).Get(context.Background(), gsk1.Spec.Secret.Name, metav1.GetOptions{})
+	assert.True(suite.T(), errors.IsNotFound(err))
+}
 
-	err := suite.yale.Run()
-	require.Error(suite.T(), err)
-	assert.ErrorContains(suite.T(), err, "s1@p.com: uh-oh")
-	assert.ErrorContains(suite.T(), err, "s3@p.com: oh noes")
-	assert.ErrorContains(suite.T(), err, "test-app-id-1: uh-oh")
-	assert.ErrorContains(suite.T(), err, "test-app-id-3: oh noes")
+func (suite *YaleSuite) TestYaleDoesNotPruneTargetClusterSecretsOfResourcesWithInvalidSpecs() {
+	remote := testutils.NewFakeK8sClient(suite.T())
+	suite.useTargetCluster(remote)
 
-	// make sure the cache contains the new keys for sa2
-	entry, err := suite.cache.GetOrCreate(sa2)
-	require.NoError(suite.T(), err)
-	assert.Equal(suite.T(), sa2key1.id, entry.CurrentKey.ID)
-	assert.Equal(suite.T(), sa2key1.json(), entry.CurrentKey.JSON)
-	suite.assertNow(entry.CurrentKey.CreatedAt)
-	assert.Empty(suite.T(), entry.LastError)
+	invalid := gsk2
+	invalid.TypeMeta.Kind = "GcpSaKey"
+	invalid.Spec.Secret.TargetClusters = []string{"remote"}
+	invalid.Spec.KeyRotation.SafeToDisableBufferDays = -1
+	suite.seedTargetClusterSecret(remote, "ns-2", "s2-secret", "GcpSaKey/ns-2/s2-gsk")
 
-	entryAcs, err := suite.cache.GetOrCreate(clientSecret2)
-	require.NoError(suite.T(), err)
-	assert.Equal(suite.T(), clientSecret2Key1.id, entryAcs.CurrentKey.ID)
-	assert.Equal(suite.T(), clientSecret2Key1.json(), entryAcs.CurrentKey.JSON)
-	suite.assertNow(entryAcs.CurrentKey.CreatedAt)
-	assert.Empty(suite.T(), entryAcs.LastError)
+	suite.seedGsks(invalid)
+	suite.seedAzureClientSecrets()
 
-	// make sure the new key were replicated to the secret in the gsk spec
-	suite.assertSecretHasData("ns-2", "s2-secret", map[string]string{
-		"key.pem":  sa2key1.pem,
-		"key.json": sa2key1.json(),
-	})
+	require.NoError(suite.T(), suite.yale.Run())
 
-	suite.assertSecretHasData("ns-2", "clientsecret2-secret", map[string]string{
-		"clientsecret-key": clientSecret2Key1.json(),
+	_, err := remote.CoreV1().Secrets("ns-2").Get(context.Background(), "s2-secret", metav1.GetOptions{})
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *YaleSuite) TestYaleDoesNotPruneTargetClusterSecretsOfResourcesInFilteredNamespaces() {
+	remote := testutils.NewFakeK8sClient(suite.T())
+	suite.useTargetCluster(remote, func(options *resourcemap.Options) {
+		options.ExcludeNamespaces = []string{"ns-3"}
 	})
 
-	// make sure the cache entries for s1 and s3 have error information
-	entry, err = suite.cache.GetOrCreate(sa1)
-	require.NoError(suite.T(), err)
-	assert.Equal(suite.T(), "GcpSaKey s1@p.com: error issuing new secret: error issuing new secret for s1@p.com: uh-oh", entry.LastError.Message)
-	suite.assertNow(entry.LastError.Timestamp)
-	suite.assertNow(entry.LastError.LastNotificationAt)
+	filtered := gsk3
+	filtered.TypeMeta.Kind = "GcpSaKey"
+	filtered.Spec.Secret.TargetClusters = []string{"remote"}
+	suite.seedTargetClusterSecret(remote, "ns-3", "s3-secret", "GcpSaKey/ns-3/s3-gsk")
 
-	entryAcs, err = suite.cache.GetOrCreate(clientSecret1)
-	require.NoError(suite.T(), err)
-	assert.Equal(suite.T(), "AzureClientSecret test-app-id-1: error issuing new secret: error issuing new secret for test-app-id-1: uh-oh", entryAcs.LastError.Message)
-	suite.assertNow(entryAcs.LastError.Timestamp)
-	suite.assertNow(entryAcs.LastError.LastNotificationAt)
+	suite.seedGsks(filtered)
+	suite.seedAzureClientSecrets()
 
-	// s3 should NOT have sent an error, because it was already sent recently
-	entry, err = suite.cache.GetOrCreate(sa3)
-	require.NoError(suite.T(), err)
-	assert.Equal(suite.T(), "GcpSaKey s3@p.com: error issuing new secret: error issuing new secret for s3@p.com: oh noes", entry.LastError.Message)
-	suite.assertNow(entry.LastError.Timestamp)
-	assert.Equal(suite.T(), lastNotification, entry.LastError.LastNotificationAt)
+	require.NoError(suite.T(), suite.yale.Run())
 
-	entryAcs, err = suite.cache.GetOrCreate(clientSecret3)
-	require.NoError(suite.T(), err)
-	assert.Equal(suite.T(), "AzureClientSecret test-app-id-3: error issuing new secret: error issuing new secret for test-app-id-3: oh noes", entryAcs.LastError.Message)
-	suite.assertNow(entryAcs.LastError.Timestamp)
-	assert.Equal(suite.T(), lastNotification, entryAcs.LastError.LastNotificationAt)
+	_, err := remote.CoreV1().Secrets("ns-3").Get(context.Background(), "s3-secret", metav1.GetOptions{})
+	assert.NoError(suite.T(), err)
+}
+
+// useTargetCluster overwrites the default yale instance with one that can sync secrets to the given cluster, named
+// "remote", and whose resource mapper is configured with the given options
+func (suite *YaleSuite) useTargetCluster(remote kubernetes.Interface, mapperOptions ...resourcemap.Option) {
+	suite.yale = newYaleFromComponents(
+		suite.yale.options,
+		suite.cache,
+		resourcemap.New(suite.crd, suite.cache, mapperOptions...),
+		suite.crd,
+		suite.yale.authmetrics,
+		suite.yale.keyops,
+		keysync.New(suite.k8s, suite.vaultServer.NewClient(), suite.gsmServer.NewClient(), nil, nil, suite.cache, func(options *keysync.Options) {
+			options.TargetClusters = map[string]kubernetes.Interface{"remote": remote}
+		}),
+		suite.slack,
+	)
+}
+
+// useUniqueIDs replaces the GCP keyops with one that, like the real implementation, can look up the unique ids
+// of service accounts, using the given ids keyed by email
+func (suite *YaleSuite) useUniqueIDs(uniqueIDs map[string]string) {
+	_keyops := maps.Clone(suite.yale.keyops)
+	_keyops[gcpKeyops] = &keyOpsWithUniqueIDs{KeyOps: suite.keyops, uniqueIDs: uniqueIDs}
+	suite.yale = newYaleFromComponents(
+		suite.yale.options,
+		suite.cache,
+		suite.resourcemapper,
+		suite.crd,
+		suite.yale.authmetrics,
+		_keyops,
+		suite.keysync,
+		suite.slack,
+	)
+}
+
+// keyOpsWithUniqueIDs adds unique id lookups to the mock KeyOps
+type keyOpsWithUniqueIDs struct {
+	*keyopsmocks.KeyOps
+	uniqueIDs map[string]string
+}
 
+func (k *keyOpsWithUniqueIDs) UniqueID(_ string, serviceAccountEmail string) (string, error) {
+	return k.uniqueIDs[serviceAccountEmail], nil
+}
+
+// seedTargetClusterSecret creates a secret in the given cluster as if Yale had synced it there from owner
+func (suite *YaleSuite) seedTargetClusterSecret(cluster kubernetes.Interface, namespace string, name string, owner string) {
+	_, err := cluster.CoreV1().Secrets(namespace).Create(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Labels:      map[string]string{"yale.terra.bio/target-cluster-secret": "true"},
+			Annotations: map[string]string{"yale.terra.bio/owner": owner},
+		},
+	}, metav1.CreateOptions{})
+	require.NoError(suite.T(), err)
 }
 
 func (suite *YaleSuite) seedGsks(gsks ...apiv1b1.GcpSaKey) {
@@ -1150,6 +2974,34 @@ func (suite *YaleSuite) seedCacheEntries(entries ...*cache.Entry) {
 	}
 }
 
+// seedOrphanedSecret seeds a cache entry for sa1 that records a secret synced by gsk1, which no longer exists,
+// and creates the secret
+func (suite *YaleSuite) seedOrphanedSecret() {
+	suite.seedCacheEntries(&cache.Entry{
+		Identifier: sa1,
+		Type:       cache.GcpSaKey,
+		CurrentKey: cache.CurrentKey{
+			ID:        sa1key1.id,
+			JSON:      sa1key1.json(),
+			CreatedAt: fourHoursAgo,
+		},
+		SyncedSecrets: map[string][]string{
+			"ns-1/s1-gsk": {"ns-1/s1-secret"},
+		},
+	})
+	_, err := suite.k8s.CoreV1().Secrets("ns-1").Create(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "s1-secret",
+			Namespace:       "ns-1",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "GcpSaKey", Name: "s1-gsk"}},
+		},
+		Data: map[string][]byte{
+			"key.json": []byte(sa1key1.json()),
+		},
+	}, metav1.CreateOptions{})
+	require.NoError(suite.T(), err)
+}
+
 func (suite *YaleSuite) expectCreateKeyReturnsErr(k key, err error) {
 	suite.keyops.EXPECT().Create(k.sa.Scope(), k.sa.Identify()).Return(k.keyopsFormat(), []byte(k.json()), err)
 }
@@ -1188,6 +3040,55 @@ func (suite *YaleSuite) assertNow(t time.Time) {
 	assert.WithinDuration(suite.T(), now, t, 5*time.Second)
 }
 
+func TestRotateWindowContains(t *testing.T) {
+	at := func(hhmm string) time.Time {
+		parsed, err := time.Parse("15:04", hhmm)
+		require.NoError(t, err)
+		return time.Date(2023, 7, 31, parsed.Hour(), parsed.Minute(), 0, 0, time.UTC)
+	}
+
+	testCases := []struct {
+		name    string
+		windows [][2]string
+		inside  []string
+		outside []string
+	}{
+		{
+			name:    "window within a single day",
+			windows: [][2]string{{"05:00", "06:00"}},
+			inside:  []string{"05:00", "05:30", "06:00"},
+			outside: []string{"04:59", "06:01", "23:00"},
+		},
+		{
+			name:    "window that wraps around midnight",
+			windows: [][2]string{{"22:00", "06:00"}},
+			inside:  []string{"22:00", "22:01", "23:59", "00:00", "05:59", "06:00"},
+			outside: []string{"21:59", "06:01", "12:00"},
+		},
+		{
+			name:    "multiple windows",
+			windows: [][2]string{{"05:00", "06:00"}, {"23:00", "01:00"}},
+			inside:  []string{"05:00", "06:00", "23:30", "00:30"},
+			outside: []string{"04:59", "06:01", "12:00", "22:59", "01:01"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			window := RotateWindow{Enabled: true}
+			for _, w := range tc.windows {
+				window.Windows = append(window.Windows, TimeWindow{StartTime: at(w[0]), EndTime: at(w[1])})
+			}
+			for _, inside := range tc.inside {
+				assert.True(t, window.Contains(at(inside)), "%s should be inside window", inside)
+			}
+			for _, outside := range tc.outside {
+				assert.False(t, window.Contains(at(outside)), "%s should be outside window", outside)
+			}
+		})
+	}
+}
+
 func TestYaleTestSuite(t *testing.T) {
 	suite.Run(t, new(YaleSuite))
 }
@@ -1213,3 +3114,292 @@ func (k key) json() string {
 		return k.pem
 	}
 }
+
+// gatherRunCount scrapes the metrics registry and returns the number of Yale runs the run duration histogram has observed
+func (suite *YaleSuite) gatherRunCount() uint64 {
+	families, err := metrics.Registry.Gather()
+	require.NoError(suite.T(), err)
+	for _, family := range families {
+		if family.GetName() == "yale_run_duration_seconds" {
+			return family.GetMetric()[0].GetHistogram().GetSampleCount()
+		}
+	}
+	return 0
+}
+
+func TestDeleteOldKeysDeletesSeveralKeysWithOneCacheSave(t *testing.T) {
+	k8s := testutils.NewFakeK8sClient(t).(*k8sfake.Clientset)
+	_cache := cache.New(k8s, cacheNamespace)
+	_keyops := keyopsmocks.NewKeyOps(t)
+	yale := newYaleFromComponents(Options{}, _cache, nil, nil, nil, nil, nil, slack.New(""))
+
+	entry, err := _cache.GetOrCreate(sa1)
+	require.NoError(t, err)
+	entry.DisabledKeys = map[string]time.Time{
+		"s1-key1": eightDaysAgo,
+		"s1-key2": eightDaysAgo,
+		"s1-key3": eightDaysAgo,
+		"s1-key4": eightDaysAgo,
+		"s1-key5": eightDaysAgo,
+	}
+	require.NoError(t, _cache.Save(entry))
+	for keyId := range entry.DisabledKeys {
+		_keyops.EXPECT().DeleteIfDisabled(keyops.Key{Scope: sa1.Scope(), Identifier: sa1.Identify(), ID: keyId}).Return(nil)
+	}
+
+	k8s.ClearActions()
+	cutoffs := computeCutoffs(entry, []apiv1b1.GcpSaKey{gsk1}, false)
+	require.NoError(t, yale.deleteOldKeys(_keyops, entry, cutoffs))
+
+	var updates int
+	for _, action := range k8s.Actions() {
+		if action.GetVerb() == "update" && action.GetResource().Resource == "secrets" {
+			updates++
+		}
+	}
+	assert.Equal(t, 1, updates, "cache entry should be saved once, after all keys are deleted")
+
+	saved, err := _cache.GetOrCreate(sa1)
+	require.NoError(t, err)
+	assert.Empty(t, saved.DisabledKeys)
+}
+
+func TestDeleteOldKeysContinuesPastFailingKey(t *testing.T) {
+	k8s := testutils.NewFakeK8sClient(t)
+	_cache := cache.New(k8s, cacheNamespace)
+	_keyops := keyopsmocks.NewKeyOps(t)
+	yale := newYaleFromComponents(Options{}, _cache, nil, nil, nil, nil, nil, slack.New(""))
+
+	entry, err := _cache.GetOrCreate(sa1)
+	require.NoError(t, err)
+	entry.DisabledKeys = map[string]time.Time{
+		"s1-key1": eightDaysAgo,
+		"s1-key2": eightDaysAgo,
+		"s1-key3": eightDaysAgo,
+	}
+	require.NoError(t, _cache.Save(entry))
+	_keyops.EXPECT().DeleteIfDisabled(keyops.Key{Scope: sa1.Scope(), Identifier: sa1.Identify(), ID: "s1-key1"}).Return(nil)
+	_keyops.EXPECT().DeleteIfDisabled(keyops.Key{Scope: sa1.Scope(), Identifier: sa1.Identify(), ID: "s1-key2"}).Return(fmt.Errorf("permission denied"))
+	_keyops.EXPECT().DeleteIfDisabled(keyops.Key{Scope: sa1.Scope(), Identifier: sa1.Identify(), ID: "s1-key3"}).Return(nil)
+
+	cutoffs := computeCutoffs(entry, []apiv1b1.GcpSaKey{gsk1}, false)
+	err = yale.deleteOldKeys(_keyops, entry, cutoffs)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "error deleting key s1-key2")
+
+	// the keys that were deleted should be removed from the saved cache entry, despite the failure
+	saved, err := _cache.GetOrCreate(sa1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"s1-key2"}, sortedKeyIDs(saved.DisabledKeys))
+}
+
+func TestRotateYaleResourceIfNeededRespectsMinRotationInterval(t *testing.T) {
+	testCases := []struct {
+		name          string
+		keyAge        time.Duration
+		rotateAfter   time.Duration
+		expectRotated bool
+	}{
+		{
+			name:          "key issued more recently than the minimum interval is not rotated",
+			keyAge:        10 * time.Minute,
+			rotateAfter:   24 * time.Hour,
+			expectRotated: false,
+		},
+		{
+			name:          "key older than the minimum interval is rotated",
+			keyAge:        2 * time.Hour,
+			rotateAfter:   24 * time.Hour,
+			expectRotated: true,
+		},
+		{
+			name:          "minimum interval is capped at the rotation age",
+			keyAge:        45 * time.Minute,
+			rotateAfter:   30 * time.Minute,
+			expectRotated: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			k8s := testutils.NewFakeK8sClient(t)
+			_cache := cache.New(k8s, cacheNamespace)
+			_keyops := keyopsmocks.NewKeyOps(t)
+			yale := newYaleFromComponents(Options{}, _cache, nil, nil, nil, nil, nil, slack.New(""))
+
+			entry, err := _cache.GetOrCreate(sa1)
+			require.NoError(t, err)
+			createdAt := currentTime().Add(-tc.keyAge).Round(0)
+			entry.CurrentKey = cache.CurrentKey{ID: sa1key1.id, JSON: sa1key1.json(), CreatedAt: createdAt}
+			require.NoError(t, _cache.Save(entry))
+
+			if tc.expectRotated {
+				_keyops.EXPECT().Create(sa1.Scope(), sa1.Identify()).Return(sa1key2.keyopsFormat(), []byte(sa1key2.json()), nil)
+			}
+
+			// the new key is too new to sync, so no keysync is needed
+			cutoffs := dueForRotationCutoffs{rotateAfter: tc.rotateAfter}
+			require.NoError(t, rotateYaleResourceIfNeeded(_keyops, _cache, nil, yale.notifier, yale.options.AuditLog, entry, cutoffs, 0, time.Hour, 24*time.Hour, []apiv1b1.GcpSaKey{gsk1}))
+
+			if tc.expectRotated {
+				assert.Equal(t, sa1key2.id, entry.CurrentKey.ID)
+				assert.Contains(t, entry.RotatedKeys, sa1key1.id)
+			} else {
+				assert.Equal(t, sa1key1.id, entry.CurrentKey.ID)
+				assert.Equal(t, createdAt, entry.CurrentKey.CreatedAt)
+				assert.Empty(t, entry.RotatedKeys)
+			}
+		})
+	}
+}
+
+// dueForRotationCutoffs cutoffs that report every key as due for rotation, to exercise the checks that can
+// keep a due key from being rotated
+type dueForRotationCutoffs struct {
+	cutoff.Cutoffs
+	rotateAfter time.Duration
+}
+
+func (c dueForRotationCutoffs) ShouldRotate(time.Time) bool {
+	return true
+}
+
+func (c dueForRotationCutoffs) RotateAfter() time.Duration {
+	return c.rotateAfter
+}
+
+func (c dueForRotationCutoffs) Paused() bool {
+	return false
+}
+
+func (c dueForRotationCutoffs) Decommissioned() bool {
+	return false
+}
+
+func TestLastAuthTimeIsMemoizedWithinARun(t *testing.T) {
+	k8s := testutils.NewFakeK8sClient(t)
+	_cache := cache.New(k8s, cacheNamespace)
+	_authmetrics := authmetricsmocks.NewAuthMetrics(t)
+	yale := newYaleFromComponents(Options{}, _cache, nil, nil, map[string]authmetrics.AuthMetrics{gcpKeyops: _authmetrics}, nil, nil, slack.New(""))
+
+	entry, err := _cache.GetOrCreate(sa1)
+	require.NoError(t, err)
+
+	_authmetrics.EXPECT().LastAuthTime(sa1.Scope(), sa1.Identify(), "s1-key1").Return(&eightDaysAgo, nil).Once()
+
+	for i := 0; i < 3; i++ {
+		lastAuth, err := yale.lastAuthTime("s1-key1", entry)
+		require.NoError(t, err)
+		assert.Equal(t, &eightDaysAgo, lastAuth)
+	}
+
+	// the next run should query auth metrics again
+	yale.resetAuthMetrics()
+	_authmetrics.EXPECT().LastAuthTime(sa1.Scope(), sa1.Identify(), "s1-key1").Return(nil, nil).Once()
+
+	lastAuth, err := yale.lastAuthTime("s1-key1", entry)
+	require.NoError(t, err)
+	assert.Nil(t, lastAuth)
+}
+
+func TestComputeStatusKeepsLastSyncedAtIfNotResynced(t *testing.T) {
+	syncedAt := metav1.NewTime(eightDaysAgo).Rfc3339Copy()
+	entry := &cache.Entry{
+		CurrentKey: cache.CurrentKey{ID: "key-1", CreatedAt: eightDaysAgo},
+		SyncStatus: map[string]string{"ns-1/my-gsk": "checksum:key-1"},
+	}
+	previous := apiv1b1.Status{CurrentKeyID: "key-1", LastSyncedAt: &syncedAt, SyncedSecret: "my-secret"}
+
+	status := computeStatus(entry, "ns-1", "my-gsk", "my-secret", previous, maps.Clone(entry.SyncStatus), nil)
+	assert.Equal(t, "key-1", status.CurrentKeyID)
+	assert.Equal(t, "my-secret", status.SyncedSecret)
+	assert.Equal(t, &syncedAt, status.LastSyncedAt)
+
+	// a new key that has not been synced yet should not be reported as synced
+	entry.CurrentKey = cache.CurrentKey{ID: "key-2", CreatedAt: now}
+	status = computeStatus(entry, "ns-1", "my-gsk", "my-secret", previous, maps.Clone(entry.SyncStatus), nil)
+	assert.Equal(t, "key-2", status.CurrentKeyID)
+	assert.Empty(t, status.SyncedSecret)
+	assert.Equal(t, &syncedAt, status.LastSyncedAt)
+}
+
+func TestReportErrorRepostsKeyInUseErrorsOnTheirOwnInterval(t *testing.T) {
+	testCases := []struct {
+		name             string
+		options          Options
+		keyInUse         bool
+		lastNotification time.Time
+		expectNotified   bool
+	}{
+		{
+			name:             "other errors are not reposted within the default interval",
+			lastNotification: now.Add(-3 * time.Hour),
+			expectNotified:   false,
+		},
+		{
+			name:             "other errors are reposted after the default interval",
+			lastNotification: now.Add(-5 * time.Hour),
+			expectNotified:   true,
+		},
+		{
+			name:             "key in use errors use the default interval if not overridden",
+			keyInUse:         true,
+			lastNotification: now.Add(-3 * time.Hour),
+			expectNotified:   false,
+		},
+		{
+			name:             "key in use errors are reposted after their own interval",
+			options:          Options{KeyInUseErrorRepostInterval: time.Hour},
+			keyInUse:         true,
+			lastNotification: now.Add(-2 * time.Hour),
+			expectNotified:   true,
+		},
+		{
+			name:             "other errors are not affected by the key in use interval",
+			options:          Options{KeyInUseErrorRepostInterval: time.Hour},
+			lastNotification: now.Add(-2 * time.Hour),
+			expectNotified:   false,
+		},
+		{
+			name:             "other errors are reposted after the configured interval",
+			options:          Options{ErrorRepostInterval: 30 * time.Minute, KeyInUseErrorRepostInterval: 10 * time.Minute},
+			lastNotification: now.Add(-45 * time.Minute),
+			expectNotified:   true,
+		},
+		{
+			name:             "key in use errors are not reposted within their own interval",
+			options:          Options{ErrorRepostInterval: 10 * time.Minute, KeyInUseErrorRepostInterval: time.Hour},
+			keyInUse:         true,
+			lastNotification: now.Add(-30 * time.Minute),
+			expectNotified:   false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_cache := cache.New(testutils.NewFakeK8sClient(t), cacheNamespace)
+			_slack := slackmocks.NewSlackNotifier(t)
+			yale := newYaleFromComponents(tc.options, _cache, nil, nil, nil, nil, nil, _slack)
+
+			entry, err := _cache.GetOrCreate(sa1)
+			require.NoError(t, err)
+			entry.LastError.LastNotificationAt = tc.lastNotification
+
+			reportedErr := fmt.Errorf("error issuing new secret: uh-oh")
+			if tc.keyInUse {
+				// key in use errors are recognized even if wrapped
+				reportedErr = fmt.Errorf("GcpSaKey s1@p.com: %w", &keyInUseError{keyID: "s1-key1", entry: entry, rotatedAt: eightDaysAgo, lastAuthTime: fourHoursAgo})
+			}
+			if tc.expectNotified {
+				_slack.EXPECT().Error(entry, reportedErr.Error()).Return(nil)
+			}
+
+			require.NoError(t, yale.reportError(entry, reportedErr))
+			if tc.expectNotified {
+				assert.NotEqual(t, tc.lastNotification, entry.LastError.LastNotificationAt)
+			} else {
+				assert.Equal(t, tc.lastNotification, entry.LastError.LastNotificationAt)
+			}
+		})
+	}
+}